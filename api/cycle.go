@@ -0,0 +1,208 @@
+package focotimer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ------------------- Cycle engine -------------------
+
+// Interval is one named step of a Sequence, e.g. "work" for 52m. An
+// interval with Phases is itself a short sequence (a break made of 2m
+// stretch, 2m water, 1m breathing); its Duration is then ignored. Break
+// runs it as a break (SetBreak) rather than focus work, whatever its name.
+type Interval struct {
+	Name     string
+	Duration time.Duration
+	Phases   []Interval
+	Break    bool
+}
+
+// Total returns the length of the interval including all its phases.
+func (iv Interval) Total() time.Duration {
+	if len(iv.Phases) == 0 {
+		return iv.Duration
+	}
+	var total time.Duration
+	for _, ph := range iv.Phases {
+		total += ph.Duration
+	}
+	return total
+}
+
+// step returns the name and length of the given phase of the interval.
+func (iv Interval) step(phase int) (string, time.Duration) {
+	if len(iv.Phases) == 0 {
+		return "", iv.Duration
+	}
+	ph := iv.Phases[phase]
+	return ph.Name, ph.Duration
+}
+
+// Sequence is a programmable list of intervals such as work 52m / break
+// 17m, run Repeat times. A Repeat of zero loops until the cycle is stopped.
+type Sequence struct {
+	Name      string
+	Intervals []Interval
+	Repeat    int
+}
+
+// Validate reports whether the sequence can be run.
+func (s Sequence) Validate() error {
+	if len(s.Intervals) == 0 {
+		return fmt.Errorf("sequence %q has no intervals", s.Name)
+	}
+	if s.Repeat < 0 {
+		return fmt.Errorf("sequence %q: negative repeat %d", s.Name, s.Repeat)
+	}
+	for i, iv := range s.Intervals {
+		if len(iv.Phases) == 0 && iv.Duration <= 0 {
+			return fmt.Errorf("sequence %q: interval %d (%q) has no duration", s.Name, i, iv.Name)
+		}
+		for j, ph := range iv.Phases {
+			if len(ph.Phases) > 0 {
+				return fmt.Errorf("sequence %q: phase %d of %q has phases of its own", s.Name, j, iv.Name)
+			}
+			if ph.Duration <= 0 {
+				return fmt.Errorf("sequence %q: phase %d of %q (%q) has no duration", s.Name, j, iv.Name, ph.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Cycle drives a TimerManager through a Sequence. Whenever an interval
+// completes (or is skipped) the next one is loaded, labelled with its name
+// and started. Each phase of an interval runs as its own session, announced
+// with an EventPhase.
+type Cycle struct {
+	mu     sync.Mutex
+	tm     *TimerManager
+	seq    Sequence
+	index  int
+	phase  int
+	round  int
+	events <-chan Event // nil while the cycle is not active
+}
+
+func NewCycle(tm *TimerManager, seq Sequence) (*Cycle, error) {
+	if err := seq.Validate(); err != nil {
+		return nil, err
+	}
+	seq.Intervals = append([]Interval(nil), seq.Intervals...)
+	return &Cycle{tm: tm, seq: seq}, nil
+}
+
+// Start begins the first interval of the first round. Starting an active
+// cycle is a no-op.
+func (c *Cycle) Start() {
+	c.mu.Lock()
+	if c.events != nil {
+		c.mu.Unlock()
+		return
+	}
+	events := c.tm.SubscribeEvents()
+	c.events = events
+	c.index, c.phase, c.round = 0, 0, 0
+	iv := c.seq.Intervals[0]
+	c.mu.Unlock()
+
+	c.load(iv, 0)
+	go c.run(events)
+}
+
+// Stop detaches the cycle from the timer. The current interval keeps
+// running as a plain session, without its phase.
+func (c *Cycle) Stop() {
+	c.mu.Lock()
+	events := c.events
+	c.events = nil
+	c.mu.Unlock()
+	if events != nil {
+		c.tm.UnsubscribeEvents(events)
+		c.tm.setPhase("")
+	}
+}
+
+// Active reports whether the cycle is still driving the timer.
+func (c *Cycle) Active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.events != nil
+}
+
+// Current returns the interval being timed and the zero-based round. Use
+// Phase for the sub-phase of intervals that have them.
+func (c *Cycle) Current() (Interval, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq.Intervals[c.index], c.round
+}
+
+// Phase returns the sub-phase being timed, if the current interval has
+// phases.
+func (c *Cycle) Phase() (Interval, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	iv := c.seq.Intervals[c.index]
+	if len(iv.Phases) == 0 {
+		return Interval{}, false
+	}
+	return iv.Phases[c.phase], true
+}
+
+func (c *Cycle) Sequence() Sequence {
+	return c.seq
+}
+
+func (c *Cycle) run(events <-chan Event) {
+	for ev := range events {
+		if ev.Kind != EventCompleted {
+			continue
+		}
+		iv, phase, ok := c.advance(events)
+		if !ok {
+			c.Stop()
+			return
+		}
+		c.load(iv, phase)
+	}
+}
+
+// advance moves to the next phase or interval. It returns false once the
+// last round is over or when the cycle was stopped in the meantime.
+func (c *Cycle) advance(events <-chan Event) (Interval, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.events != events {
+		return Interval{}, 0, false
+	}
+	if c.phase+1 < len(c.seq.Intervals[c.index].Phases) {
+		c.phase++
+		return c.seq.Intervals[c.index], c.phase, true
+	}
+	c.phase = 0
+	if c.index+1 < len(c.seq.Intervals) {
+		c.index++
+		return c.seq.Intervals[c.index], 0, true
+	}
+	if c.seq.Repeat > 0 && c.round+1 >= c.seq.Repeat {
+		return Interval{}, 0, false
+	}
+	c.index = 0
+	c.round++
+	return c.seq.Intervals[c.index], 0, true
+}
+
+func (c *Cycle) load(iv Interval, phase int) {
+	name, d := iv.step(phase)
+	c.tm.Reset()
+	c.tm.SetDuration(d)
+	c.tm.setLabel(iv.Name, iv.Break)
+	c.tm.setPhase(name)
+	c.tm.Start()
+	if name != "" {
+		c.tm.emit(EventPhase)
+	}
+}
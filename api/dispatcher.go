@@ -0,0 +1,376 @@
+package focotimer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------- Dispatcher -------------------
+
+// CommandFunc handles a single command invocation with its arguments.
+type CommandFunc func(args []string) error
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// maxExpandDepth bounds how deeply custom commands may reference each other,
+// so a command that (indirectly) calls itself fails instead of looping.
+const maxExpandDepth = 8
+
+// maxQueueCount bounds the <n> of "queue add <n>x<duration>", so a typo
+// cannot fill the plan with sessions for the rest of the year.
+const maxQueueCount = 100
+
+// builtinCommand is one row of the command registry.
+type builtinCommand struct {
+	name    string
+	aliases []string
+	run     func(d *Dispatcher, args []string) error
+}
+
+// builtinCommands is the registry of commands every dispatcher knows. The
+// aliases keep older or alternative names working as the protocol grows.
+var builtinCommands = []builtinCommand{
+	{name: "start", aliases: []string{"play"}, run: (*Dispatcher).start},
+	{name: "stop", run: noArgs((*TimerManager).Stop)},
+	{name: "pause", run: noArgs((*TimerManager).Pause)},
+	{name: "resume", aliases: []string{"continue"}, run: noArgs((*TimerManager).Resume)},
+	{name: "toggle", aliases: []string{"play-pause", "playpause"}, run: noArgs((*TimerManager).Toggle)},
+	{name: "skip", aliases: []string{"next"}, run: noArgs((*TimerManager).Skip)},
+	{name: "reset", aliases: []string{"restart"}, run: noArgs((*TimerManager).Reset)},
+	{name: "inc", aliases: []string{"increase", "+"}, run: noArgs((*TimerManager).Inc)},
+	{name: "dec", aliases: []string{"decrease", "-"}, run: noArgs((*TimerManager).Dec)},
+	{name: "set", run: (*Dispatcher).set},
+	{name: "label", run: (*Dispatcher).label},
+	{name: "cycle", run: (*Dispatcher).cycle},
+	{name: "until", run: (*Dispatcher).until},
+	{name: "issue", run: (*Dispatcher).issue},
+	{name: "energy", run: (*Dispatcher).energy},
+	{name: "queue", aliases: []string{"plan"}, run: (*Dispatcher).queue},
+}
+
+func noArgs(f func(*TimerManager)) func(*Dispatcher, []string) error {
+	return func(d *Dispatcher, args []string) error {
+		f(d.tm)
+		return nil
+	}
+}
+
+// Dispatcher turns text commands ("start", "set 25m", "label writing") into
+// TimerManager calls. Frontends register their own commands with Handle,
+// users add alternative names with Alias and composite commands with Define.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	tm       *TimerManager
+	handlers map[string]CommandFunc
+	aliases  map[string]string
+	custom   map[string][]string
+
+	sequences map[string]Sequence
+	active    *Cycle
+}
+
+func NewDispatcher(tm *TimerManager) *Dispatcher {
+	d := &Dispatcher{
+		tm:       tm,
+		handlers: make(map[string]CommandFunc),
+		aliases:  make(map[string]string),
+		custom:   make(map[string][]string),
+
+		sequences: make(map[string]Sequence),
+	}
+	for _, cmd := range builtinCommands {
+		run := cmd.run
+		d.handlers[cmd.name] = func(args []string) error { return run(d, args) }
+		for _, alias := range cmd.aliases {
+			d.aliases[alias] = cmd.name
+		}
+	}
+	return d
+}
+
+// Handle registers or replaces the handler for a command name.
+func (d *Dispatcher) Handle(name string, fn CommandFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[name] = fn
+}
+
+// Alias makes name an alternative spelling of target, which must be a
+// handled command.
+func (d *Dispatcher) Alias(name, target string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	if _, ok := d.handlers[name]; ok {
+		return fmt.Errorf("alias %q shadows a command", name)
+	}
+	if _, ok := d.handlers[target]; !ok {
+		return fmt.Errorf("alias %q: %w: %q", name, ErrUnknownCommand, target)
+	}
+	d.aliases[name] = target
+	return nil
+}
+
+// Define adds a user command that expands to a sequence of other commands,
+// e.g. "coffee" = ["set 5m", "label coffee", "start"]. Built-in and
+// registered commands cannot be redefined.
+func (d *Dispatcher) Define(name string, steps []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid command name %q", name)
+	}
+	if _, ok := d.handlers[name]; ok {
+		return fmt.Errorf("command %q is built in and cannot be redefined", name)
+	}
+	if _, ok := d.aliases[name]; ok {
+		return fmt.Errorf("command %q is an alias and cannot be redefined", name)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("command %q has no steps", name)
+	}
+	d.custom[name] = append([]string(nil), steps...)
+	return nil
+}
+
+// DefineSequence makes an interval sequence available to "cycle <name>".
+func (d *Dispatcher) DefineSequence(name string, seq Sequence) error {
+	if name == "" || name == "stop" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid sequence name %q", name)
+	}
+	seq.Name = name
+	if err := seq.Validate(); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sequences[name] = seq
+	return nil
+}
+
+// Dispatch runs one command line.
+func (d *Dispatcher) Dispatch(line string) error {
+	return d.dispatch(line, 0)
+}
+
+func (d *Dispatcher) dispatch(line string, depth int) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	name, args := fields[0], fields[1:]
+
+	d.mu.RLock()
+	if target, ok := d.aliases[name]; ok {
+		name = target
+	}
+	fn, isHandler := d.handlers[name]
+	steps, isCustom := d.custom[name]
+	d.mu.RUnlock()
+
+	switch {
+	case isHandler:
+		return fn(args)
+	case isCustom:
+		if depth >= maxExpandDepth {
+			return fmt.Errorf("command %q: expansion too deep", name)
+		}
+		for _, step := range steps {
+			if err := d.dispatch(step, depth+1); err != nil {
+				return fmt.Errorf("command %q: %w", name, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, name)
+	}
+}
+
+// --- Built-in commands with arguments ---
+
+// start begins a session, optionally against an issue and with an energy
+// rating: "start --issue GH-123 --energy 4".
+func (d *Dispatcher) start(args []string) error {
+	usage := errors.New("usage: start [--issue <ref>] [--energy <1-5>]")
+	energy := 0
+	for ; len(args) > 0; args = args[2:] {
+		if len(args) < 2 {
+			return usage
+		}
+		switch args[0] {
+		case "--issue":
+			d.tm.SetIssue(args[1])
+		case "--energy":
+			level, err := parseEnergy(args[1])
+			if err != nil {
+				return err
+			}
+			energy = level
+		default:
+			return usage
+		}
+	}
+	d.tm.Start()
+	if energy > 0 {
+		return d.tm.RateEnergy(energy)
+	}
+	return nil
+}
+
+// energy rates the user's energy from 1 to 5, see TimerManager.RateEnergy.
+func (d *Dispatcher) energy(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: energy <1-5>")
+	}
+	level, err := parseEnergy(args[0])
+	if err != nil {
+		return err
+	}
+	return d.tm.RateEnergy(level)
+}
+
+func parseEnergy(s string) (int, error) {
+	level, err := strconv.Atoi(s)
+	if err != nil || level < 1 || level > 5 {
+		return 0, fmt.Errorf("energy: expected a rating from 1 to 5, got %q", s)
+	}
+	return level, nil
+}
+
+// issue links the following sessions to an issue; without an argument the
+// link is removed.
+func (d *Dispatcher) issue(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: issue [<ref>]")
+	}
+	d.tm.SetIssue(strings.Join(args, ""))
+	return nil
+}
+
+func (d *Dispatcher) set(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: set <duration>")
+	}
+	dur, err := ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	d.tm.SetDuration(dur)
+	return nil
+}
+
+// label names the next sessions; "label --break [name]" makes them breaks,
+// named "break" unless given a name.
+func (d *Dispatcher) label(args []string) error {
+	if len(args) > 0 && args[0] == "--break" {
+		name := strings.Join(args[1:], " ")
+		if name == "" {
+			name = "break"
+		}
+		d.tm.SetBreak(name)
+		return nil
+	}
+	d.tm.SetLabel(strings.Join(args, " "))
+	return nil
+}
+
+// until counts down to a time of day: "until 15:30" or "until 3:30pm". A
+// time that has already passed today means tomorrow.
+func (d *Dispatcher) until(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: until <hh:mm>")
+	}
+	at, err := NextClockTime(time.Now(), args[0])
+	if err != nil {
+		return fmt.Errorf("until: %w", err)
+	}
+	d.tm.StartUntil(at)
+	return nil
+}
+
+// cycle starts a defined sequence ("cycle 52-17") or stops the running one
+// ("cycle stop").
+func (d *Dispatcher) cycle(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: cycle <sequence>|stop")
+	}
+
+	d.mu.Lock()
+	prev := d.active
+	d.active = nil
+	seq, ok := d.sequences[args[0]]
+	d.mu.Unlock()
+
+	if prev != nil {
+		prev.Stop()
+	}
+	if args[0] == "stop" {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("cycle: unknown sequence %q", args[0])
+	}
+
+	c, err := NewCycle(d.tm, seq)
+	if err != nil {
+		return fmt.Errorf("cycle: %w", err)
+	}
+	d.mu.Lock()
+	d.active = c
+	d.mu.Unlock()
+	c.Start()
+	return nil
+}
+
+// queue manages the session plan:
+//
+//	queue add [<n>x]<duration> [label...]   e.g. "queue add 4x25m write report"
+//	queue start | stop | clear
+func (d *Dispatcher) queue(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: queue add|start|stop|clear")
+	}
+	q := d.tm.Queue()
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return errors.New("usage: queue add [<n>x]<duration> [label...]")
+		}
+		count, spec := 1, args[1]
+		if n, rest, ok := strings.Cut(spec, "x"); ok {
+			c, err := strconv.Atoi(n)
+			if err != nil || c < 1 {
+				return fmt.Errorf("queue: invalid count %q", n)
+			}
+			if c > maxQueueCount {
+				return fmt.Errorf("queue: count %d over %d", c, maxQueueCount)
+			}
+			count, spec = c, rest
+		}
+		dur, err := ParseDuration(spec)
+		if err != nil {
+			return fmt.Errorf("queue: %w", err)
+		}
+		task := Task{Label: strings.Join(args[2:], " "), Duration: dur}
+		for range count {
+			if err := q.Add(task); err != nil {
+				return fmt.Errorf("queue: %w", err)
+			}
+		}
+	case "start":
+		q.Start()
+	case "stop":
+		q.Stop()
+	case "clear":
+		q.Clear()
+	default:
+		return fmt.Errorf("queue: unknown action %q", args[0])
+	}
+	return nil
+}
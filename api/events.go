@@ -0,0 +1,210 @@
+package focotimer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ------------------- Events -------------------
+
+type EventKind int
+
+const (
+	EventStarted EventKind = iota
+	EventPaused
+	EventResumed
+	EventStopped
+	EventCompleted
+	EventReset
+	// EventWarning fires once per session, the configured lead time
+	// before it ends.
+	EventWarning
+	// EventAutoReset fires once when a completed session was left
+	// unacknowledged for longer than the auto-reset timeout.
+	EventAutoReset
+	// EventTick carries a periodic remaining-time sample. The manager does
+	// not emit it on SubscribeEvents; observers build it from Subscribe so
+	// external consumers get one stream.
+	EventTick
+	// EventPhase fires when a cycle moves to the next sub-phase of an
+	// interval, e.g. from "stretch" to "water" during a break.
+	EventPhase
+	// EventExtended fires when flow mode silently lengthens a session
+	// because the user is still active at its end.
+	EventExtended
+	// EventRated fires when the user rates their energy for a session; the
+	// end rating may arrive after EventCompleted.
+	EventRated
+	// EventReminder fires at each reminder point of a running session,
+	// e.g. halfway through or five minutes before the end.
+	EventReminder
+)
+
+var eventKindNames = map[EventKind]string{
+	EventStarted:   "started",
+	EventPaused:    "paused",
+	EventResumed:   "resumed",
+	EventStopped:   "stopped",
+	EventCompleted: "completed",
+	EventReset:     "reset",
+	EventWarning:   "warning",
+	EventAutoReset: "auto-reset",
+	EventTick:      "tick",
+	EventPhase:     "phase",
+	EventExtended:  "extended",
+	EventRated:     "rated",
+	EventReminder:  "reminder",
+}
+
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *EventKind) UnmarshalText(text []byte) error {
+	for kind, name := range eventKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event kind %q", text)
+}
+
+type Event struct {
+	Kind      EventKind
+	At        time.Time
+	Duration  time.Duration
+	Remaining time.Duration
+	Label     string
+	Phase     string
+	Issue     string
+	// Break marks a break rather than focus work; see SetBreak.
+	Break bool
+	// EnergyStart and EnergyEnd are the session's energy ratings from 1
+	// to 5, zero when unrated.
+	EnergyStart int
+	EnergyEnd   int
+	// Reminder is the reminder point reached, e.g. "50%" or "5m0s"; set
+	// on EventReminder.
+	Reminder string
+}
+
+// eventJSON is the wire form of Event; durations are in milliseconds so
+// non-Go consumers don't have to deal with nanoseconds.
+type eventJSON struct {
+	Kind        EventKind `json:"kind"`
+	At          time.Time `json:"at"`
+	DurationMs  int64     `json:"duration_ms"`
+	RemainingMs int64     `json:"remaining_ms"`
+	Label       string    `json:"label,omitempty"`
+	Phase       string    `json:"phase,omitempty"`
+	Issue       string    `json:"issue,omitempty"`
+	Break       bool      `json:"break,omitempty"`
+	EnergyStart int       `json:"energy_start,omitempty"`
+	EnergyEnd   int       `json:"energy_end,omitempty"`
+	Reminder    string    `json:"reminder,omitempty"`
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{
+		Kind:        e.Kind,
+		At:          e.At,
+		DurationMs:  e.Duration.Milliseconds(),
+		RemainingMs: e.Remaining.Milliseconds(),
+		Label:       e.Label,
+		Phase:       e.Phase,
+		Issue:       e.Issue,
+		Break:       e.Break,
+		EnergyStart: e.EnergyStart,
+		EnergyEnd:   e.EnergyEnd,
+		Reminder:    e.Reminder,
+	})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v eventJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = Event{
+		Kind:        v.Kind,
+		At:          v.At,
+		Duration:    time.Duration(v.DurationMs) * time.Millisecond,
+		Remaining:   time.Duration(v.RemainingMs) * time.Millisecond,
+		Label:       v.Label,
+		Phase:       v.Phase,
+		Issue:       v.Issue,
+		Break:       v.Break,
+		EnergyStart: v.EnergyStart,
+		EnergyEnd:   v.EnergyEnd,
+		Reminder:    v.Reminder,
+	}
+	return nil
+}
+
+// SubscribeEvents returns a channel receiving every lifecycle event of the
+// manager. Slow subscribers drop events rather than block the timer.
+func (t *TimerManager) SubscribeEvents() <-chan Event {
+	ch := make(chan Event, 16)
+	t.mu.Lock()
+	t.eventSubs = append(t.eventSubs, ch)
+	t.mu.Unlock()
+	return ch
+}
+
+// UnsubscribeEvents stops delivery to a channel returned by SubscribeEvents
+// and closes it.
+func (t *TimerManager) UnsubscribeEvents(ch <-chan Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, sub := range t.eventSubs {
+		if sub == ch {
+			t.eventSubs = append(t.eventSubs[:i], t.eventSubs[i+1:]...)
+			close(sub)
+			return
+		}
+	}
+}
+
+// TickEvent samples the current state as an EventTick.
+func (t *TimerManager) TickEvent() Event {
+	return t.newEvent(EventTick)
+}
+
+func (t *TimerManager) newEvent(kind EventKind) Event {
+	ev := Event{Kind: kind, At: time.Now(), Remaining: t.remaining()}
+	t.mu.Lock()
+	timer := t.Timer
+	ev.Label = t.label
+	ev.Phase = t.phase
+	ev.Issue = t.issue
+	ev.Break = t.brk
+	ev.EnergyStart, ev.EnergyEnd = t.energyStart, t.energyEnd
+	t.mu.Unlock()
+	ev.Duration = timer.Duration()
+	return ev
+}
+
+// emit fans an event out to event subscribers. Caller must not hold t.mu.
+func (t *TimerManager) emit(kind EventKind) {
+	t.fanOut(t.newEvent(kind))
+}
+
+func (t *TimerManager) fanOut(ev Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ch := range t.eventSubs {
+		select {
+		case ch <- ev:
+		default: // drop if slow
+		}
+	}
+}
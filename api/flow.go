@@ -0,0 +1,94 @@
+package focotimer
+
+import "time"
+
+// ------------------- Flow mode -------------------
+
+// FlowOptions configure flow mode: a session that ends while the user is
+// still active is silently extended by Step, up to Cap in total, and then
+// ends at the first pause in activity instead of interrupting.
+type FlowOptions struct {
+	// Active reports whether the user is currently active, e.g. typing.
+	// Nil disables flow mode.
+	Active func() bool
+	Step   time.Duration
+	Cap    time.Duration
+	// Poll is how often an extended session checks for a pause.
+	Poll time.Duration
+}
+
+// SetFlow enables flow mode; zero options disable it. Step defaults to 5
+// minutes and Poll to 5 seconds.
+func (t *TimerManager) SetFlow(opts FlowOptions) {
+	if opts.Step <= 0 {
+		opts.Step = 5 * time.Minute
+	}
+	if opts.Poll <= 0 {
+		opts.Poll = 5 * time.Second
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flow = opts
+}
+
+// FlowExtended returns how much flow mode has added to the current session.
+func (t *TimerManager) FlowExtended() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.flowExtended
+}
+
+// extendFlow is the timer's extender: it decides at the deadline whether
+// the session runs on.
+func (t *TimerManager) extendFlow(timer *TimerData) time.Duration {
+	t.mu.Lock()
+	flow := t.flow
+	if t.Timer != timer || flow.Active == nil || t.flowExtended >= flow.Cap {
+		t.mu.Unlock()
+		return 0
+	}
+	step := min(flow.Step, flow.Cap-t.flowExtended)
+	t.mu.Unlock()
+
+	if !flow.Active() {
+		return 0
+	}
+
+	t.mu.Lock()
+	first := t.flowExtended == 0
+	t.flowExtended += step
+	t.mu.Unlock()
+
+	if first {
+		go t.watchForPause(timer, flow)
+	}
+	t.wakeBroadcaster()
+	t.emit(EventExtended)
+	return step
+}
+
+// watchForPause ends an extended session as soon as the user stops.
+func (t *TimerManager) watchForPause(timer *TimerData, flow FlowOptions) {
+	ticker := time.NewTicker(flow.Poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stopCh:
+			return
+		case <-ticker.C:
+		}
+		t.mu.Lock()
+		current := t.Timer == timer
+		t.mu.Unlock()
+		if !current {
+			return
+		}
+		if timer.IsComplete() {
+			return
+		}
+		if timer.IsRunning() && !flow.Active() {
+			timer.FinishTimer()
+			return
+		}
+	}
+}
@@ -1,78 +1,475 @@
 package focotimer
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // ------------------- TimerData -------------------
 
+// TimerData is a single countdown. Its state is guarded by mu and only
+// reachable through the accessor methods, so frontends can read it while
+// the completion callback runs.
 type TimerData struct {
 	mu            sync.Mutex
-	Timer         *time.Timer
-	Duration      time.Duration
-	BreakDuration time.Duration
-	IsComplete    bool
-	StartedAt     time.Time
-	CompletedAt   time.Time
-	Handler       func()
+	timer         *time.Timer
+	duration      time.Duration
+	breakDuration time.Duration
+	isComplete    bool
+	startedAt     time.Time
+	completedAt   time.Time
+	// Handler is kept for compatibility and runs before any handler
+	// registered with AddCompletionHandler.
+	Handler func()
+
+	// onChange is called (without mu held) when a setter changes the
+	// timer, so the owner can notify its subscribers.
+	onChange func()
+
+	handlers      []completionHandler
+	nextHandlerID HandlerID
+	extender      func() time.Duration
+
+	// deadline carries a monotonic clock reading, so remaining time is
+	// measured against the same instant the AfterFunc fires on.
+	deadline    time.Time
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	// wallClock marks a countdown to a target time of day. Its deadline and
+	// start carry no monotonic reading, so time spent suspended counts, and
+	// completion is re-checked every wallClockCheck instead of trusting a
+	// single AfterFunc that would fire late after a resume.
+	wallClock bool
+}
+
+const wallClockCheck = time.Second
+
+// HandlerID identifies a registered completion handler.
+type HandlerID uint64
+
+type completionHandler struct {
+	id HandlerID
+	fn func()
 }
 
 func NewTimer(d time.Duration) *TimerData {
 	return &TimerData{
-		Duration:      d,
-		BreakDuration: 1 * time.Minute,
-		IsComplete:    false,
+		duration:      d,
+		breakDuration: 1 * time.Minute,
+		isComplete:    false,
 	}
 }
 
+// --- Accessors ---
+
+func (t *TimerData) Duration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.duration
+}
+
+// SetDuration sets the length of the session. Negative values are treated
+// as zero. A session already started keeps its elapsed time and moves its
+// deadline by the change, so lengthening a running session leaves it more
+// time; shortening it past the time run completes it.
+func (t *TimerData) SetDuration(d time.Duration) {
+	t.mu.Lock()
+	d = max(d, 0)
+	if !t.startedAt.IsZero() && !t.isComplete {
+		t.deadline = t.deadline.Add(d - t.duration)
+		if t.pausedAt.IsZero() {
+			if t.timer != nil {
+				t.timer.Stop()
+			}
+			t.schedule(time.Until(t.deadline))
+		}
+	}
+	t.duration = d
+	onChange := t.onChange
+	t.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+func (t *TimerData) BreakDuration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.breakDuration
+}
+
+func (t *TimerData) SetBreakDuration(d time.Duration) {
+	t.mu.Lock()
+	t.breakDuration = max(d, 0)
+	onChange := t.onChange
+	t.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+func (t *TimerData) IsComplete() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isComplete
+}
+
+// StartedAt returns when the session was started, or the zero time.
+func (t *TimerData) StartedAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.startedAt
+}
+
+// CompletedAt returns when the session completed, or the zero time.
+func (t *TimerData) CompletedAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.completedAt
+}
+
 func (t *TimerData) StartTimer() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.Timer != nil {
-		t.Timer.Stop()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	now := time.Now()
+	t.startedAt = now
+	t.completedAt = time.Time{}
+	t.isComplete = false
+	t.deadline = now.Add(t.duration)
+	t.pausedAt = time.Time{}
+	t.pausedTotal = 0
+	t.wallClock = false
+	t.schedule(t.duration)
+}
+
+// StartTimerUntil starts a countdown to the wall-clock time at, e.g. 15:30
+// today. Duration is set to the time left so progress can be shown. A
+// target in the past completes the timer as soon as possible.
+func (t *TimerData) StartTimerUntil(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
 	}
 
-	t.StartedAt = time.Now()
-	t.IsComplete = false
+	now := time.Now().Round(0) // strip the monotonic reading
+	at = at.Round(0)
+	t.duration = max(at.Sub(now), 0)
+	t.startedAt = now
+	t.completedAt = time.Time{}
+	t.isComplete = false
+	t.deadline = at
+	t.pausedAt = time.Time{}
+	t.pausedTotal = 0
+	t.wallClock = true
+	t.schedule(t.duration)
+}
 
-	t.Timer = time.AfterFunc(t.Duration, func() {
+// schedule arms the completion timer to fire after d. Caller holds t.mu.
+func (t *TimerData) schedule(d time.Duration) {
+	if t.wallClock {
+		d = min(d, wallClockCheck)
+	}
+	var fired *time.Timer
+	fired = time.AfterFunc(d, func() {
 		t.mu.Lock()
-		t.IsComplete = true
-		t.CompletedAt = time.Now()
-		handler := t.Handler
+		// A paused, stopped or restarted timer replaces t.timer; ignore
+		// stale callbacks that lost the race with Stop().
+		if t.timer != fired || t.isComplete {
+			t.mu.Unlock()
+			return
+		}
+		if t.wallClock {
+			if left := time.Until(t.deadline); left > 0 {
+				t.schedule(left)
+				t.mu.Unlock()
+				return
+			}
+		}
+		if extender := t.extender; extender != nil {
+			t.mu.Unlock()
+			more := extender()
+			t.mu.Lock()
+			if t.timer != fired || t.isComplete {
+				t.mu.Unlock()
+				return
+			}
+			if more > 0 {
+				t.deadline = t.deadline.Add(more)
+				t.duration += more
+				t.schedule(time.Until(t.deadline))
+				t.mu.Unlock()
+				return
+			}
+		}
+		t.completeLocked()
+	})
+	t.timer = fired
+}
+
+// SetExtender installs f to be asked, each time the deadline is reached,
+// for how much longer the session should run. A zero answer completes the
+// timer. f runs without t.mu held.
+func (t *TimerData) SetExtender(f func() time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.extender = f
+}
+
+// completeLocked marks the timer complete, releases t.mu and runs the
+// completion handlers.
+func (t *TimerData) completeLocked() {
+	t.isComplete = true
+	t.completedAt = time.Now()
+	handler := t.Handler
+	handlers := append([]completionHandler(nil), t.handlers...)
+	t.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+	for _, h := range handlers {
+		h.fn()
+	}
+}
+
+// FinishTimer completes a started timer immediately, as if its deadline
+// had been reached. It does nothing if the timer is idle or already done.
+func (t *TimerData) FinishTimer() {
+	t.mu.Lock()
+	if t.startedAt.IsZero() || t.isComplete {
 		t.mu.Unlock()
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	if !t.pausedAt.IsZero() {
+		t.pausedTotal += time.Since(t.pausedAt)
+		t.pausedAt = time.Time{}
+	}
+	t.completeLocked()
+}
+
+// AddCompletionHandler registers f to run when the timer completes. Handlers
+// run in registration order, outside the timer lock, and stay registered
+// across restarts until removed.
+func (t *TimerData) AddCompletionHandler(f func()) HandlerID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextHandlerID++
+	t.handlers = append(t.handlers, completionHandler{id: t.nextHandlerID, fn: f})
+	return t.nextHandlerID
+}
 
-		if handler != nil {
-			handler()
+// RemoveCompletionHandler unregisters the handler with the given id and
+// reports whether it was found.
+func (t *TimerData) RemoveCompletionHandler(id HandlerID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, h := range t.handlers {
+		if h.id == id {
+			t.handlers = append(t.handlers[:i:i], t.handlers[i+1:]...)
+			return true
 		}
-	})
+	}
+	return false
 }
 
+// StopTimer ends the session: the pending completion is cancelled and the
+// timer is idle again at its duration, so the next start begins afresh.
 func (t *TimerData) StopTimer() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.Timer != nil {
-		t.Timer.Stop()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.startedAt = time.Time{}
+	t.completedAt = time.Time{}
+	t.isComplete = false
+	t.deadline = time.Time{}
+	t.pausedAt = time.Time{}
+	t.pausedTotal = 0
+	t.wallClock = false
+}
+
+// PauseTimer cancels the pending completion and freezes the remaining
+// time; ResumeTimer continues it from the same remaining time.
+func (t *TimerData) PauseTimer() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
 	}
+	if !t.startedAt.IsZero() && !t.isComplete && t.pausedAt.IsZero() {
+		t.pausedAt = time.Now()
+	}
+}
+
+func (t *TimerData) ResumeTimer() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.startedAt.IsZero() || t.isComplete || t.pausedAt.IsZero() {
+		return
+	}
+
+	paused := time.Since(t.pausedAt)
+	t.pausedTotal += paused
+	t.deadline = t.deadline.Add(paused)
+	t.pausedAt = time.Time{}
+	t.schedule(time.Until(t.deadline))
+}
+
+// IsRunning reports whether the timer is counting down.
+func (t *TimerData) IsRunning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.startedAt.IsZero() && !t.isComplete && t.pausedAt.IsZero()
+}
+
+func (t *TimerData) IsPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.startedAt.IsZero() && !t.isComplete && !t.pausedAt.IsZero()
 }
 
+// Elapsed returns the running time of the session, excluding pauses. After
+// completion it reports the full time that was run.
 func (t *TimerData) Elapsed() time.Duration {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.StartedAt.IsZero() || t.IsComplete {
+	if t.startedAt.IsZero() {
 		return 0
 	}
-	return time.Since(t.StartedAt)
+	return t.refTime().Sub(t.startedAt) - t.pausedTotal
 }
 
+// Remaining returns the time left until the deadline, or 0 if the timer
+// has not been started or has already completed.
 func (t *TimerData) Remaining() time.Duration {
-	elapsed := t.Elapsed()
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.Duration < elapsed {
+	if t.startedAt.IsZero() || t.isComplete {
 		return 0
 	}
-	return t.Duration - elapsed
+	if r := t.deadline.Sub(t.refTime()); r > 0 {
+		return r
+	}
+	return 0
+}
+
+// span returns the length of the session as scheduled: the duration while
+// idle, and the time from the start to the (possibly extended) deadline,
+// less pauses, once started.
+func (t *TimerData) span() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.startedAt.IsZero() {
+		return t.duration
+	}
+	return t.deadline.Sub(t.startedAt) - t.pausedTotal
+}
+
+// refTime returns the reference instant for elapsed/remaining: the moment the
+// timer completed or was paused, or the current time. Caller holds t.mu.
+func (t *TimerData) refTime() time.Time {
+	switch {
+	case t.isComplete:
+		return t.completedAt
+	case !t.pausedAt.IsZero():
+		return t.pausedAt
+	default:
+		return time.Now()
+	}
+}
+
+// ------------------- Clock targets -------------------
+
+var clockLayouts = []string{"15:04", "15:04:05", "3:04pm", "3pm"}
+
+// NextClockTime returns the next occurrence of the time of day s (e.g.
+// "15:30", "3:30pm") after now, in now's location.
+func NextClockTime(now time.Time, s string) (time.Time, error) {
+	for _, layout := range clockLayouts {
+		c, err := time.Parse(layout, strings.ToLower(s))
+		if err != nil {
+			continue
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(),
+			c.Hour(), c.Minute(), c.Second(), 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time of day %q", s)
+}
+
+// ------------------- Durations -------------------
+
+// ParseDuration reads a session length as typed: "25m" or "1h30m" as for
+// time.ParseDuration, "25:00" or "1:30:00" as the countdown shows it, or
+// "25" minutes. It must be positive.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		d, err = parseClockDuration(s)
+	}
+	if err != nil {
+		if n, nerr := strconv.Atoi(s); nerr == nil {
+			d, err = time.Duration(n)*time.Minute, nil
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, want 25m, 25:00 or 25", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q is not positive", s)
+	}
+	return d, nil
+}
+
+// parseClockDuration reads "mm:ss" or "h:mm:ss".
+func parseClockDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("not mm:ss")
+	}
+	var d time.Duration
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		// only the first part may pass 59
+		if err != nil || n < 0 || (i > 0 && (n > 59 || len(p) != 2)) {
+			return 0, fmt.Errorf("not mm:ss")
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second, nil
+}
+
+// FormatClock formats d as the countdown shows it: MM:SS, or H:MM:SS from
+// an hour up, with a minus sign once a session runs over. ParseDuration
+// reads it back.
+func FormatClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h, m, sec := int(d/time.Hour), int(d/time.Minute)%60, int(d/time.Second)%60
+	if h > 0 {
+		return fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, sec)
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, m, sec)
 }
@@ -1,6 +1,11 @@
 package focotimer
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,17 +17,17 @@ func TestNewTimer(t *testing.T) {
 	duration := 5 * time.Second
 	timer := NewTimer(duration)
 
-	if timer.Duration != duration {
-		t.Errorf("Expected duration %v, got %v", duration, timer.Duration)
+	if timer.Duration() != duration {
+		t.Errorf("Expected duration %v, got %v", duration, timer.Duration())
 	}
-	if timer.BreakDuration != 1*time.Minute {
-		t.Errorf("Expected break duration %v, got %v", 1*time.Minute, timer.BreakDuration)
+	if timer.BreakDuration() != 1*time.Minute {
+		t.Errorf("Expected break duration %v, got %v", 1*time.Minute, timer.BreakDuration())
 	}
-	if timer.IsComplete != false {
-		t.Errorf("Expected IsComplete to be false, got %v", timer.IsComplete)
+	if timer.IsComplete() != false {
+		t.Errorf("Expected IsComplete to be false, got %v", timer.IsComplete())
 	}
-	if timer.Timer != nil {
-		t.Errorf("Expected Timer to be nil initially, got %v", timer.Timer)
+	if timer.timer != nil {
+		t.Errorf("Expected Timer to be nil initially, got %v", timer.timer)
 	}
 }
 
@@ -31,23 +36,23 @@ func TestTimerData_StartTimer(t *testing.T) {
 
 	// Test that timer starts
 	timer.StartTimer()
-	if timer.Timer == nil {
+	if timer.timer == nil {
 		t.Fatal("Expected Timer to be set after StartTimer")
 	}
-	if timer.StartedAt.IsZero() {
+	if timer.StartedAt().IsZero() {
 		t.Fatal("Expected StartedAt to be set after StartTimer")
 	}
-	if timer.IsComplete {
+	if timer.IsComplete() {
 		t.Fatal("Expected IsComplete to be false after StartTimer")
 	}
 
 	// Wait for timer to complete
 	time.Sleep(150 * time.Millisecond)
 
-	if !timer.IsComplete {
+	if !timer.IsComplete() {
 		t.Error("Expected IsComplete to be true after timer completion")
 	}
-	if timer.CompletedAt.IsZero() {
+	if timer.CompletedAt().IsZero() {
 		t.Error("Expected CompletedAt to be set after timer completion")
 	}
 }
@@ -76,6 +81,40 @@ func TestTimerData_StartTimer_WithHandler(t *testing.T) {
 	}
 }
 
+func TestTimerData_AddCompletionHandler(t *testing.T) {
+	timer := NewTimer(30 * time.Millisecond)
+
+	var mu sync.Mutex
+	var calls []int
+	record := func(n int) func() {
+		return func() {
+			mu.Lock()
+			calls = append(calls, n)
+			mu.Unlock()
+		}
+	}
+
+	timer.AddCompletionHandler(record(1))
+	removed := timer.AddCompletionHandler(record(2))
+	timer.AddCompletionHandler(record(3))
+
+	if !timer.RemoveCompletionHandler(removed) {
+		t.Fatal("Expected handler to be removed")
+	}
+	if timer.RemoveCompletionHandler(removed) {
+		t.Error("Expected second removal of the same handler to fail")
+	}
+
+	timer.StartTimer()
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 3 {
+		t.Errorf("Expected handlers [1 3] in order, got %v", calls)
+	}
+}
+
 func TestTimerData_StopTimer(t *testing.T) {
 	timer := NewTimer(1 * time.Second)
 	timer.StartTimer()
@@ -87,7 +126,7 @@ func TestTimerData_StopTimer(t *testing.T) {
 	// Wait a bit more to ensure it doesn't complete
 	time.Sleep(100 * time.Millisecond)
 
-	if timer.IsComplete {
+	if timer.IsComplete() {
 		t.Error("Expected timer to not complete after StopTimer")
 	}
 }
@@ -116,8 +155,8 @@ func TestTimerData_Elapsed(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 	elapsed = timer.Elapsed()
 
-	if elapsed != 0 {
-		t.Errorf("Expected elapsed to be 0 after completion, got %v", elapsed)
+	if elapsed < 10*time.Millisecond || elapsed > 30*time.Millisecond {
+		t.Errorf("Expected elapsed to stay at the run time after completion, got %v", elapsed)
 	}
 }
 
@@ -152,6 +191,44 @@ func TestTimerData_Remaining(t *testing.T) {
 	}
 }
 
+func TestTimerData_PauseResume(t *testing.T) {
+	timer := NewTimer(200 * time.Millisecond)
+	timer.StartTimer()
+	time.Sleep(50 * time.Millisecond)
+
+	timer.PauseTimer()
+	if !timer.IsPaused() {
+		t.Fatal("Expected timer to be paused")
+	}
+	frozen := timer.Remaining()
+	time.Sleep(250 * time.Millisecond)
+
+	complete := timer.IsComplete()
+	if complete {
+		t.Fatal("Expected paused timer to not complete")
+	}
+	if r := timer.Remaining(); r != frozen {
+		t.Errorf("Expected remaining to stay at %v while paused, got %v", frozen, r)
+	}
+
+	timer.ResumeTimer()
+	if timer.IsPaused() {
+		t.Error("Expected timer to not be paused after resume")
+	}
+	if e := timer.Elapsed(); e < 40*time.Millisecond || e > 100*time.Millisecond {
+		t.Errorf("Expected elapsed to exclude the pause, got %v", e)
+	}
+
+	time.Sleep(frozen + 50*time.Millisecond)
+	complete = timer.IsComplete()
+	if !complete {
+		t.Error("Expected timer to complete after resuming")
+	}
+	if r := timer.Remaining(); r != 0 {
+		t.Errorf("Expected remaining to be 0 after completion, got %v", r)
+	}
+}
+
 func TestTimerData_ConcurrentAccess(t *testing.T) {
 	timer := NewTimer(100 * time.Millisecond)
 
@@ -181,8 +258,8 @@ func TestNewTimerManager(t *testing.T) {
 	if tm.Timer == nil {
 		t.Fatal("Expected Timer to be initialized")
 	}
-	if tm.Timer.Duration != duration {
-		t.Errorf("Expected timer duration %v, got %v", duration, tm.Timer.Duration)
+	if tm.Timer.Duration() != duration {
+		t.Errorf("Expected timer duration %v, got %v", duration, tm.Timer.Duration())
 	}
 	if tm.updates == nil {
 		t.Fatal("Expected updates channel to be initialized")
@@ -227,6 +304,18 @@ func TestTimerManager_Subscribe(t *testing.T) {
 	if subCount != 2 {
 		t.Errorf("Expected 2 subscribers, got %d", subCount)
 	}
+
+	tm.Unsubscribe(ch)
+	tm.mu.Lock()
+	subCount = len(tm.subs)
+	tm.mu.Unlock()
+	if subCount != 1 {
+		t.Errorf("Expected 1 subscriber after Unsubscribe, got %d", subCount)
+	}
+	for range ch {
+		// drain the initial value; the loop ends once ch is closed
+	}
+	tm.Unsubscribe(ch) // unknown channels are ignored
 }
 
 func TestTimerManager_Broadcast(t *testing.T) {
@@ -259,10 +348,10 @@ func TestTimerManager_Start(t *testing.T) {
 
 	tm.Start()
 
-	if tm.Timer.Timer == nil {
+	if tm.Timer.timer == nil {
 		t.Error("Expected internal timer to be started")
 	}
-	if tm.Timer.StartedAt.IsZero() {
+	if tm.Timer.StartedAt().IsZero() {
 		t.Error("Expected StartedAt to be set")
 	}
 }
@@ -279,18 +368,47 @@ func TestTimerManager_Stop(t *testing.T) {
 
 	// Timer should be stopped, so it shouldn't complete
 	time.Sleep(100 * time.Millisecond)
-	if tm.Timer.IsComplete {
+	if tm.Timer.IsComplete() {
 		t.Error("Expected timer to not complete after Stop")
 	}
 }
 
+func TestTimerManager_StopEndsSession(t *testing.T) {
+	tm := NewTimerManager(1 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	events := tm.SubscribeEvents()
+
+	tm.Start()
+	time.Sleep(200 * time.Millisecond)
+	tm.Stop()
+	if s := tm.State(); s.Status != StatusIdle || s.Remaining != time.Second {
+		t.Errorf("Expected an idle timer at its full length after Stop, got %s with %v left", s.Status, s.Remaining)
+	}
+	for ev := range events {
+		if ev.Kind == EventStopped {
+			if ev.Remaining <= 0 || ev.Remaining >= 900*time.Millisecond {
+				t.Errorf("Expected the stop event to carry the time that was left, got %v", ev.Remaining)
+			}
+			break
+		}
+	}
+
+	// toggle starts afresh rather than resuming the stopped session
+	tm.Toggle()
+	if s := tm.State(); s.Status != StatusRunning || s.Remaining < 950*time.Millisecond {
+		t.Errorf("Expected a fresh running session after Toggle, got %s with %v left", s.Status, s.Remaining)
+	}
+}
+
 func TestTimerManager_Reset(t *testing.T) {
 	tm := NewTimerManager(100 * time.Millisecond)
 	defer func() {
 		close(tm.stopCh)
 	}()
 
-	originalDuration := tm.Timer.Duration
+	originalDuration := tm.Timer.Duration()
 	oldDoneCh := tm.doneCh
 
 	tm.Start()
@@ -298,13 +416,13 @@ func TestTimerManager_Reset(t *testing.T) {
 
 	tm.Reset()
 
-	if tm.Timer.Duration != originalDuration {
-		t.Errorf("Expected duration to be preserved after reset, got %v", tm.Timer.Duration)
+	if tm.Timer.Duration() != originalDuration {
+		t.Errorf("Expected duration to be preserved after reset, got %v", tm.Timer.Duration())
 	}
-	if tm.Timer.StartedAt != (time.Time{}) {
+	if tm.Timer.StartedAt() != (time.Time{}) {
 		t.Error("Expected StartedAt to be reset")
 	}
-	if tm.Timer.IsComplete {
+	if tm.Timer.IsComplete() {
 		t.Error("Expected IsComplete to be false after reset")
 	}
 	if tm.doneCh == oldDoneCh {
@@ -312,18 +430,40 @@ func TestTimerManager_Reset(t *testing.T) {
 	}
 }
 
+func TestTimerManager_StartOneOff(t *testing.T) {
+	tm := NewTimerManager(20 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetLabel("docs")
+	tm.Start()
+	<-tm.Done()
+
+	tm.StartOneOff(50 * time.Millisecond)
+	if s := tm.State(); s.Status != StatusRunning || s.Duration != 50*time.Millisecond || s.Label != "docs" {
+		t.Errorf("Expected a running 50ms session of docs, got %+v", s)
+	}
+	<-tm.Done()
+	tm.StartOneOff(30 * time.Millisecond)
+	tm.Reset()
+	if d := tm.Duration(); d != 20*time.Millisecond {
+		t.Errorf("Expected the session after the one-offs to last 20ms again, got %v", d)
+	}
+}
+
 func TestTimerManager_Inc(t *testing.T) {
 	tm := NewTimerManager(100 * time.Millisecond)
 	defer func() {
 		close(tm.stopCh)
 	}()
 
-	originalDuration := tm.Timer.Duration
+	originalDuration := tm.Timer.Duration()
 	tm.Inc()
 
 	expectedDuration := originalDuration + 5*time.Second
-	if tm.Timer.Duration != expectedDuration {
-		t.Errorf("Expected duration %v after Inc, got %v", expectedDuration, tm.Timer.Duration)
+	if tm.Timer.Duration() != expectedDuration {
+		t.Errorf("Expected duration %v after Inc, got %v", expectedDuration, tm.Timer.Duration())
 	}
 }
 
@@ -333,12 +473,12 @@ func TestTimerManager_Dec(t *testing.T) {
 		close(tm.stopCh)
 	}()
 
-	originalDuration := tm.Timer.Duration
+	originalDuration := tm.Timer.Duration()
 	tm.Dec()
 
 	expectedDuration := originalDuration - 5*time.Second
-	if tm.Timer.Duration != expectedDuration {
-		t.Errorf("Expected duration %v after Dec, got %v", expectedDuration, tm.Timer.Duration)
+	if tm.Timer.Duration() != expectedDuration {
+		t.Errorf("Expected duration %v after Dec, got %v", expectedDuration, tm.Timer.Duration())
 	}
 }
 
@@ -350,8 +490,8 @@ func TestTimerManager_Dec_MinimumZero(t *testing.T) {
 
 	tm.Dec() // Should not go below 0
 
-	if tm.Timer.Duration != 0 {
-		t.Errorf("Expected duration to be 0 when decreasing below 5 seconds, got %v", tm.Timer.Duration)
+	if tm.Timer.Duration() != 0 {
+		t.Errorf("Expected duration to be 0 when decreasing below 5 seconds, got %v", tm.Timer.Duration())
 	}
 }
 
@@ -469,9 +609,9 @@ func TestGlobalTimerManager(t *testing.T) {
 	}
 
 	expectedDuration := 10 * time.Second
-	if GTimerManager.Timer.Duration != expectedDuration {
+	if GTimerManager.Timer.Duration() != expectedDuration {
 		t.Errorf("Expected GTimerManager duration to be %v, got %v",
-			expectedDuration, GTimerManager.Timer.Duration)
+			expectedDuration, GTimerManager.Timer.Duration())
 	}
 }
 
@@ -522,27 +662,1436 @@ func TestTimerManager_IncDecWorkflow(t *testing.T) {
 		close(tm.stopCh)
 	}()
 
-	originalDuration := tm.Timer.Duration
+	originalDuration := tm.Timer.Duration()
 
 	// Increase duration
 	tm.Inc()
 	tm.Inc()
 	expectedDuration := originalDuration + 10*time.Second
-	if tm.Timer.Duration != expectedDuration {
-		t.Errorf("Expected duration %v after 2 Inc, got %v", expectedDuration, tm.Timer.Duration)
+	if tm.Timer.Duration() != expectedDuration {
+		t.Errorf("Expected duration %v after 2 Inc, got %v", expectedDuration, tm.Timer.Duration())
 	}
 
 	// Decrease duration
 	tm.Dec()
 	expectedDuration = originalDuration + 5*time.Second
-	if tm.Timer.Duration != expectedDuration {
-		t.Errorf("Expected duration %v after 1 Dec, got %v", expectedDuration, tm.Timer.Duration)
+	if tm.Timer.Duration() != expectedDuration {
+		t.Errorf("Expected duration %v after 1 Dec, got %v", expectedDuration, tm.Timer.Duration())
 	}
 
 	// Reset should restore original duration
 	tm.Reset()
-	if tm.Timer.Duration != originalDuration {
+	if tm.Timer.Duration() != originalDuration {
 		t.Errorf("Expected duration to be restored to %v after Reset, got %v",
-			originalDuration, tm.Timer.Duration)
+			originalDuration, tm.Timer.Duration())
+	}
+}
+
+// ================= Progress Tests =================
+
+func TestFraction(t *testing.T) {
+	tests := []struct {
+		part, total time.Duration
+		expected    float64
+	}{
+		{0, 10 * time.Second, 0},
+		{5 * time.Second, 10 * time.Second, 0.5},
+		{10 * time.Second, 10 * time.Second, 1},
+		{15 * time.Second, 10 * time.Second, 1},
+		{-1 * time.Second, 10 * time.Second, 0},
+		{5 * time.Second, 0, 0},
+	}
+
+	for _, test := range tests {
+		if got := Fraction(test.part, test.total); got != test.expected {
+			t.Errorf("Fraction(%v, %v) = %v, expected %v", test.part, test.total, got, test.expected)
+		}
+	}
+}
+
+func TestTimerManager_Progress(t *testing.T) {
+	tm := NewTimerManager(1 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	if p := tm.Progress(); p != 0 {
+		t.Errorf("Expected progress 0 before start, got %v", p)
+	}
+	if f := tm.ElapsedFraction(); f != 0 {
+		t.Errorf("Expected elapsed fraction 0 before start, got %v", f)
+	}
+
+	tm.Start()
+	time.Sleep(450 * time.Millisecond)
+
+	if p := tm.Progress(); p <= 0 || p >= 1 {
+		t.Errorf("Expected progress between 0 and 1 while running, got %v", p)
+	}
+	if f := tm.ElapsedFraction(); f < 0.3 || f > 0.7 {
+		t.Errorf("Expected elapsed fraction around 0.45, got %v", f)
+	}
+}
+
+func TestTimerManager_Progress_ZeroDuration(t *testing.T) {
+	tm := NewTimerManager(0)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	if p := tm.Progress(); p != 0 {
+		t.Errorf("Expected progress 0 for zero duration, got %v", p)
+	}
+	if f := tm.ElapsedFraction(); f != 0 {
+		t.Errorf("Expected elapsed fraction 0 for zero duration, got %v", f)
+	}
+}
+
+func TestTimerManager_Progress_DurationChangeMidFlight(t *testing.T) {
+	tm := NewTimerManager(1 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.Start()
+	time.Sleep(300 * time.Millisecond)
+	before := tm.Timer.Remaining()
+
+	tm.Inc()
+	if after := tm.Timer.Remaining(); math.Abs((after - before - 5*time.Second).Seconds()) > 0.05 {
+		t.Errorf("Expected Inc to leave 5s more than %v, got %v", before, after)
+	}
+	if span := tm.Span(); span != 6*time.Second {
+		t.Errorf("Expected the scheduled span to grow to 6s, got %v", span)
+	}
+	if f := tm.ElapsedFraction(); math.Abs(f-0.05) > 0.01 {
+		t.Errorf("Expected progress of about 0.3s of 6s, got %v", f)
+	}
+	tm.publish(true, 0)
+	if p := tm.Progress(); math.Abs(p-0.05) > 0.01 {
+		t.Errorf("Expected the broadcast progress to follow, got %v", p)
+	}
+
+	// the session runs past its original deadline
+	time.Sleep(900 * time.Millisecond)
+	if !tm.Timer.IsRunning() {
+		t.Fatal("Expected the session still running after its original deadline")
+	}
+
+	// and shortening it past the time run completes it
+	for range 2 {
+		tm.Dec()
+	}
+	select {
+	case <-tm.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected Dec past the elapsed time to complete the session")
+	}
+}
+
+func TestTimerManager_Progress_FlowExtension(t *testing.T) {
+	tm := NewTimerManager(100 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	tm.SetFlow(FlowOptions{Active: func() bool { return true }, Step: 300 * time.Millisecond, Cap: 300 * time.Millisecond, Poll: time.Hour})
+
+	tm.Start()
+	time.Sleep(200 * time.Millisecond)
+
+	if span := tm.Span(); span != 400*time.Millisecond {
+		t.Errorf("Expected the extended span to be 400ms, got %v", span)
+	}
+	if f := tm.ElapsedFraction(); f < 0.3 || f > 0.7 {
+		t.Errorf("Expected progress around 0.5 of the extended session, got %v", f)
+	}
+}
+
+func TestSmoothProgress(t *testing.T) {
+	var s SmoothProgress
+	t0 := time.Unix(0, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+
+	if got := s.At(at(0), 0.2, 10*time.Minute); got != 0.2 {
+		t.Errorf("Expected the target without a change, got %v", got)
+	}
+	if got := s.At(at(100), 0.9, 10*time.Minute); got != 0.9 {
+		t.Errorf("Expected progress to follow the target exactly, got %v", got)
+	}
+
+	// extended from 10m to 15m: the target drops to 0.6
+	if got := s.At(at(200), 0.6, 15*time.Minute); got != 0.9 {
+		t.Errorf("Expected the glide to start where the ring was, got %v", got)
+	}
+	mid := s.At(at(350), 0.6, 15*time.Minute)
+	if mid <= 0.6 || mid >= 0.9 {
+		t.Errorf("Expected a value between 0.6 and 0.9 mid-glide, got %v", mid)
+	}
+	if next := s.At(at(400), 0.6, 15*time.Minute); next >= mid || !s.Gliding() {
+		t.Errorf("Expected the glide to keep moving towards 0.6, got %v after %v", next, mid)
+	}
+	if got := s.At(at(500), 0.61, 15*time.Minute); got != 0.61 || s.Gliding() {
+		t.Errorf("Expected the glide to end on the target, got %v", got)
+	}
+
+	// a new session starts from zero without gliding
+	if got := s.At(at(600), 0, 25*time.Minute); got != 0 {
+		t.Errorf("Expected a new session to start at 0, got %v", got)
+	}
+}
+
+// ================= Event Tests =================
+
+func TestTimerManager_SubscribeEvents(t *testing.T) {
+	tm := NewTimerManager(50 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	events := tm.SubscribeEvents()
+	tm.Start()
+
+	for _, expected := range []EventKind{EventStarted, EventCompleted} {
+		select {
+		case ev := <-events:
+			if ev.Kind != expected {
+				t.Errorf("Expected %v event, got %v", expected, ev.Kind)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("Expected %v event within timeout", expected)
+		}
+	}
+}
+
+func TestTimerManager_AutoReset(t *testing.T) {
+	tm := NewTimerManager(20 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetAutoReset(50 * time.Millisecond)
+	tm.SetLabel("writing")
+	events := tm.SubscribeEvents()
+	tm.Start()
+
+	deadline := time.After(1 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind != EventAutoReset {
+				continue
+			}
+			tm.mu.Lock()
+			started := !tm.Timer.StartedAt().IsZero()
+			tm.mu.Unlock()
+			if started {
+				t.Error("Expected timer to be reset to stopped state")
+			}
+			if ev.Label != "writing" || ev.Remaining != 0 {
+				t.Errorf("Expected the event to describe the finished session, got %+v", ev)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Expected auto-reset event for unacknowledged session")
+		}
+	}
+}
+
+func TestTimerManager_AutoReset_Acknowledged(t *testing.T) {
+	tm := NewTimerManager(20 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetAutoReset(50 * time.Millisecond)
+	events := tm.SubscribeEvents()
+	tm.Start()
+	<-tm.Done()
+	tm.Acknowledge()
+
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventAutoReset {
+				t.Fatal("Expected no auto-reset after Acknowledge")
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestTimerManager_Warning(t *testing.T) {
+	tm := NewTimerManager(300 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetWarning(100 * time.Millisecond)
+	events := tm.SubscribeEvents()
+	start := time.Now()
+	tm.Start()
+
+	timeout := time.After(1 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventCompleted {
+				t.Fatal("Expected warning before completion")
+			}
+			if ev.Kind != EventWarning {
+				continue
+			}
+			if since := time.Since(start); since < 150*time.Millisecond || since > 280*time.Millisecond {
+				t.Errorf("Expected warning about 200ms after start, got %v", since)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Expected warning event within timeout")
+		}
+	}
+}
+
+func TestTimerManager_Warning_CancelledByPause(t *testing.T) {
+	tm := NewTimerManager(150 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetWarning(100 * time.Millisecond)
+	events := tm.SubscribeEvents()
+	tm.Start()
+	tm.Pause()
+
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventWarning {
+				t.Fatal("Expected no warning while paused")
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestParseReminder(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Reminder
+	}{
+		{"50%", Reminder{Fraction: 0.5}},
+		{"5m", Reminder{Left: 5 * time.Minute}},
+		{" 90s ", Reminder{Left: 90 * time.Second}},
+	} {
+		got, err := ParseReminder(tc.in)
+		if err != nil {
+			t.Errorf("ParseReminder(%q): unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Expected %+v for %q, got %+v", tc.want, tc.in, got)
+		}
+	}
+	for _, bad := range []string{"", "0%", "100%", "half", "-5m", "0s"} {
+		if _, err := ParseReminder(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+	if s := (Reminder{Fraction: 0.25}).String(); s != "25%" {
+		t.Errorf("Expected 25%%, got %q", s)
+	}
+	if s := (Reminder{Left: time.Minute}).String(); s != "1m0s" {
+		t.Errorf("Expected 1m0s, got %q", s)
+	}
+}
+
+func TestTimerManager_Reminders(t *testing.T) {
+	tm := NewTimerManager(400 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	// the 10m reminder is longer than the session and never fires
+	tm.SetReminders([]Reminder{{Fraction: 0.25}, {Left: 100 * time.Millisecond}, {Left: 10 * time.Minute}})
+	events := tm.SubscribeEvents()
+	start := time.Now()
+	tm.Start()
+
+	var got []string
+	timeout := time.After(1 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventCompleted {
+				if want := []string{"25%", "100ms"}; !slices.Equal(got, want) {
+					t.Errorf("Expected reminders %v, got %v", want, got)
+				}
+				return
+			}
+			if ev.Kind != EventReminder {
+				continue
+			}
+			got = append(got, ev.Reminder)
+			since := time.Since(start)
+			if ev.Reminder == "25%" && (since < 70*time.Millisecond || since > 180*time.Millisecond) {
+				t.Errorf("Expected the 25%% reminder about 100ms after start, got %v", since)
+			}
+			if ev.Reminder == "100ms" && (since < 250*time.Millisecond || since > 380*time.Millisecond) {
+				t.Errorf("Expected the 100ms reminder about 300ms after start, got %v", since)
+			}
+		case <-timeout:
+			t.Fatal("Expected completion within timeout")
+		}
+	}
+}
+
+func TestTimerManager_Reminders_SkippedWhenPassed(t *testing.T) {
+	tm := NewTimerManager(300 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetReminders([]Reminder{{Fraction: 0.25}})
+	events := tm.SubscribeEvents()
+	tm.Start()
+	time.Sleep(120 * time.Millisecond)
+	tm.Pause()
+	// the reminder went off before the pause and is not repeated
+	tm.Resume()
+
+	reminders := 0
+	timeout := time.After(1 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventReminder {
+				reminders++
+			}
+			if ev.Kind == EventCompleted {
+				if reminders != 1 {
+					t.Errorf("Expected 1 reminder, got %d", reminders)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("Expected completion within timeout")
+		}
+	}
+}
+
+// ================= Dispatcher Tests =================
+
+func TestDispatcher_BuiltIns(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	if err := d.Dispatch("set 25m"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.Timer.Duration() != 25*time.Minute {
+		t.Errorf("Expected duration 25m after set, got %v", tm.Timer.Duration())
+	}
+	if err := d.Dispatch("label write report"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.Label() != "write report" {
+		t.Errorf("Expected label %q, got %q", "write report", tm.Label())
+	}
+	for _, tt := range []struct {
+		line, label string
+		brk         bool
+	}{
+		{"label --break", "break", true},
+		{"label --break stretch legs", "stretch legs", true},
+		{"label fix line breaks", "fix line breaks", false},
+	} {
+		if err := d.Dispatch(tt.line); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.line, err)
+		}
+		if s := tm.State(); s.Label != tt.label || s.Break != tt.brk {
+			t.Errorf("%s: expected %q with break=%v, got %q with break=%v", tt.line, tt.label, tt.brk, s.Label, s.Break)
+		}
+	}
+	if err := d.Dispatch("set soon"); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+	if err := d.Dispatch("bogus"); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("Expected ErrUnknownCommand, got %v", err)
+	}
+	if err := d.Dispatch("   "); err != nil {
+		t.Errorf("Expected empty line to be ignored, got %v", err)
+	}
+}
+
+func TestDispatcher_StartIssue(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+	events := tm.SubscribeEvents()
+
+	if err := d.Dispatch("start --issue GH-123"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.Issue() != "GH-123" {
+		t.Errorf("Expected issue GH-123, got %q", tm.Issue())
+	}
+	ev := <-events
+	if ev.Kind != EventStarted || ev.Issue != "GH-123" {
+		t.Errorf("Expected started event linked to GH-123, got %+v", ev)
+	}
+
+	if err := d.Dispatch("start --label x"); err == nil {
+		t.Error("Expected error for unknown start option")
+	}
+	if err := d.Dispatch("issue"); err != nil || tm.Issue() != "" {
+		t.Errorf("Expected issue to be cleared, got %q, %v", tm.Issue(), err)
+	}
+}
+
+func TestTimerManager_RateEnergy(t *testing.T) {
+	tm := NewTimerManager(50 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	events := tm.SubscribeEvents()
+	energy := func() [2]int {
+		s := tm.State()
+		return [2]int{s.EnergyStart, s.EnergyEnd}
+	}
+
+	// a rating while idle is for the next session
+	if err := tm.RateEnergy(4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ev := <-events; ev.Kind != EventRated {
+		t.Errorf("Expected a rated event, got %v", ev.Kind)
+	}
+	if got := energy(); got != [2]int{4, 0} {
+		t.Errorf("Expected the pending start rating, got %v", got)
+	}
+	tm.Reset()
+	tm.Start()
+	if got := energy(); got != [2]int{4, 0} {
+		t.Errorf("Expected Start to take over the rating, got %v", got)
+	}
+	<-tm.Done()
+
+	// after completion it is the end rating
+	tm.RateEnergy(2)
+	if got := energy(); got != [2]int{4, 2} {
+		t.Errorf("Expected the end rating, got %v", got)
+	}
+	var rated Event
+	for ev := range events {
+		if ev.Kind == EventRated && ev.EnergyEnd == 2 {
+			rated = ev
+			break
+		}
+	}
+	if rated.EnergyStart != 4 {
+		t.Errorf("Expected the rated event to carry both ratings, got %+v", rated)
+	}
+
+	// restarting leaves the old ratings behind
+	tm.Start()
+	if got := energy(); got != [2]int{0, 0} {
+		t.Errorf("Expected a fresh session to be unrated, got %v", got)
+	}
+	tm.RateEnergy(3)
+	if got := energy(); got != [2]int{3, 0} {
+		t.Errorf("Expected a rating while running to rate the start, got %v", got)
+	}
+
+	for _, level := range []int{0, 6, -1} {
+		if err := tm.RateEnergy(level); err == nil {
+			t.Errorf("Expected an error for energy %d", level)
+		}
+	}
+}
+
+func TestDispatcher_Energy(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	if err := d.Dispatch("start --energy 5 --issue GH-7"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := tm.State(); s.Status != StatusRunning || s.EnergyStart != 5 || s.Issue != "GH-7" {
+		t.Errorf("Expected a running session rated 5 for GH-7, got %+v", s)
+	}
+	if err := d.Dispatch("energy 2"); err != nil || tm.State().EnergyStart != 2 {
+		t.Errorf("Expected energy to rerate the start, got %d, %v", tm.State().EnergyStart, err)
+	}
+
+	for _, line := range []string{"energy", "energy high", "energy 9", "start --energy", "start --energy 0"} {
+		if err := d.Dispatch(line); err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
+
+func TestDispatcher_CustomCommand(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	if err := d.Define("coffee", []string{"set 5m", "label coffee", "start"}); err != nil {
+		t.Fatalf("Unexpected error defining command: %v", err)
+	}
+	if err := d.Dispatch("coffee"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if tm.Timer.Duration() != 5*time.Minute {
+		t.Errorf("Expected duration 5m, got %v", tm.Timer.Duration())
+	}
+	if tm.Label() != "coffee" {
+		t.Errorf("Expected label coffee, got %q", tm.Label())
+	}
+	if tm.Timer.Remaining() == 0 {
+		t.Error("Expected timer to be started")
+	}
+}
+
+func TestDispatcher_DefineErrors(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	if err := d.Define("start", []string{"stop"}); err == nil {
+		t.Error("Expected error when redefining a built-in command")
+	}
+	if err := d.Define("two words", []string{"start"}); err == nil {
+		t.Error("Expected error for name with whitespace")
+	}
+	if err := d.Define("empty", nil); err == nil {
+		t.Error("Expected error for command without steps")
+	}
+
+	_ = d.Define("loop", []string{"loop"})
+	if err := d.Dispatch("loop"); err == nil {
+		t.Error("Expected error for recursive command")
+	}
+}
+
+func TestDispatcher_Aliases(t *testing.T) {
+	tests := []struct {
+		line    string
+		running bool
+		paused  bool
+	}{
+		{"play", true, false},
+		{"playpause", false, true},
+		{"continue", true, false},
+	}
+
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	for _, tt := range tests {
+		if err := d.Dispatch(tt.line); err != nil {
+			t.Fatalf("%s: Unexpected error: %v", tt.line, err)
+		}
+		if got := tm.Timer.IsRunning(); got != tt.running {
+			t.Errorf("%s: Expected running=%v, got %v", tt.line, tt.running, got)
+		}
+		if got := tm.Timer.IsPaused(); got != tt.paused {
+			t.Errorf("%s: Expected paused=%v, got %v", tt.line, tt.paused, got)
+		}
+	}
+}
+
+func TestDispatcher_AliasErrors(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	tests := []struct {
+		name, target string
+		wantErr      bool
+	}{
+		{"pp", "toggle", false},
+		{"start", "stop", true},
+		{"", "start", true},
+		{"two words", "start", true},
+		{"nope", "bogus", true},
+	}
+	for _, tt := range tests {
+		err := d.Alias(tt.name, tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Alias(%q, %q): expected error=%v, got %v", tt.name, tt.target, tt.wantErr, err)
+		}
+	}
+
+	if err := d.Dispatch("pp"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !tm.Timer.IsRunning() {
+		t.Error("Expected user alias to start the timer")
+	}
+	if err := d.Define("pp", []string{"stop"}); err == nil {
+		t.Error("Expected error when redefining an alias")
+	}
+}
+
+func TestDispatcher_Skip(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	_ = d.Dispatch("start")
+	if err := d.Dispatch("next"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case <-tm.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected skip to complete the session")
+	}
+	if !tm.Timer.IsComplete() {
+		t.Error("Expected timer to be complete after skip")
+	}
+}
+
+// ================= Wall-clock Target Tests =================
+
+func TestNextClockTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"15:30", time.Date(2024, 3, 1, 15, 30, 0, 0, time.UTC)},
+		{"3:30pm", time.Date(2024, 3, 1, 15, 30, 0, 0, time.UTC)},
+		{"3PM", time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)},
+		{"09:15", time.Date(2024, 3, 2, 9, 15, 0, 0, time.UTC)},
+		{"14:00", time.Date(2024, 3, 2, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := NextClockTime(now, tt.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.in, tt.want, got)
+		}
+	}
+	if _, err := NextClockTime(now, "teatime"); err == nil {
+		t.Error("Expected error for invalid time of day")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"25m", 25 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"25:00", 25 * time.Minute},
+		{"4:30", 4*time.Minute + 30*time.Second},
+		{"90:00", 90 * time.Minute},
+		{"1:05:00", 65 * time.Minute},
+		{" 25 ", 25 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q): expected %v, got %v, %v", tt.in, tt.want, got, err)
+		}
+	}
+	for _, in := range []string{"", "soon", "0", "-5m", "0:00", "25:60", "25:5", "1:2:3:4"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                              "00:00",
+		4*time.Minute + 59*time.Second: "04:59",
+		90 * time.Minute:               "1:30:00",
+		-5 * time.Second:               "-00:05",
+	}
+	for d, want := range tests {
+		if got := FormatClock(d); got != want {
+			t.Errorf("FormatClock(%v): expected %q, got %q", d, want, got)
+		}
+		if back, err := ParseDuration(want); d > 0 && (err != nil || back != d) {
+			t.Errorf("ParseDuration(%q): expected %v back, got %v, %v", want, d, back, err)
+		}
+	}
+}
+
+func TestTimerManager_StartUntil(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.StartUntil(time.Now().Add(150 * time.Millisecond))
+	d := tm.Timer.Duration()
+	if d <= 100*time.Millisecond || d > 150*time.Millisecond {
+		t.Errorf("Expected duration derived from target (~150ms), got %v", d)
+	}
+	if r := tm.Timer.Remaining(); r <= 0 || r > 150*time.Millisecond {
+		t.Errorf("Expected remaining within 150ms, got %v", r)
+	}
+
+	select {
+	case <-tm.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected wall-clock session to complete")
+	}
+
+	// a target that already passed completes right away
+	tm.Reset()
+	tm.StartUntil(time.Now().Add(-time.Minute))
+	select {
+	case <-tm.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected past target to complete immediately")
+	}
+}
+
+// ================= Flow Mode Tests =================
+
+func TestTimerManager_FlowExtendsWhileActive(t *testing.T) {
+	tm := NewTimerManager(50 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	var mu sync.Mutex
+	active := true
+	tm.SetFlow(FlowOptions{
+		Active: func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return active
+		},
+		Step: 50 * time.Millisecond,
+		Cap:  time.Second,
+		Poll: 10 * time.Millisecond,
+	})
+	events := tm.SubscribeEvents()
+	tm.Start()
+
+	select {
+	case <-tm.Done():
+		t.Fatal("Expected session to be extended while the user is active")
+	case <-time.After(150 * time.Millisecond):
+	}
+	if tm.FlowExtended() < 100*time.Millisecond {
+		t.Errorf("Expected at least two extensions, got %v", tm.FlowExtended())
+	}
+
+	mu.Lock()
+	active = false
+	mu.Unlock()
+	select {
+	case <-tm.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Expected session to end at the first pause")
+	}
+
+	var extended, completed int
+	for len(events) > 0 {
+		switch (<-events).Kind {
+		case EventExtended:
+			extended++
+		case EventCompleted:
+			completed++
+		}
+	}
+	if extended < 2 || completed != 1 {
+		t.Errorf("Expected extensions then one completion, got %d extended, %d completed", extended, completed)
+	}
+}
+
+func TestTimerManager_FlowCap(t *testing.T) {
+	tm := NewTimerManager(30 * time.Millisecond)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	tm.SetFlow(FlowOptions{
+		Active: func() bool { return true },
+		Step:   40 * time.Millisecond,
+		Cap:    60 * time.Millisecond,
+		Poll:   time.Hour,
+	})
+	start := time.Now()
+	tm.Start()
+
+	select {
+	case <-tm.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected session to end at the cap")
+	}
+	if took := time.Since(start); took < 85*time.Millisecond {
+		t.Errorf("Expected session to run ~90ms including extensions, took %v", took)
+	}
+	if tm.FlowExtended() != 60*time.Millisecond {
+		t.Errorf("Expected extension capped at 60ms, got %v", tm.FlowExtended())
+	}
+
+	tm.Reset()
+	if tm.Timer.Duration() != 30*time.Millisecond {
+		t.Errorf("Expected Reset to drop the extensions, got %v", tm.Timer.Duration())
+	}
+}
+
+// ================= State Tests =================
+
+func TestTimerManager_State(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	if s := tm.State(); s.Status != StatusIdle || s.Remaining != 10*time.Second || !s.StartedAt.IsZero() {
+		t.Errorf("Expected idle state with full duration, got %+v", s)
+	}
+
+	tm.SetLabel("write")
+	tm.SetIssue("GH-1")
+	tm.Start()
+	s := tm.State()
+	if s.Status != StatusRunning || s.Deadline.IsZero() || s.Label != "write" || s.Issue != "GH-1" {
+		t.Errorf("Expected running state, got %+v", s)
+	}
+
+	tm.Pause()
+	if s := tm.State(); s.Status != StatusPaused || !s.Deadline.IsZero() {
+		t.Errorf("Expected paused state without deadline, got %+v", s)
+	}
+
+	tm.Skip()
+	<-tm.Done()
+	if s := tm.State(); s.Status != StatusCompleted || s.Remaining != 0 {
+		t.Errorf("Expected completed state, got %+v", s)
+	}
+}
+
+func TestState_JSON(t *testing.T) {
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := State{
+		Status:        StatusRunning,
+		Duration:      25 * time.Minute,
+		Remaining:     90 * time.Second,
+		Elapsed:       23*time.Minute + 30*time.Second,
+		StartedAt:     started,
+		Deadline:      started.Add(25 * time.Minute),
+		Label:         "write report",
+		QueuePosition: 2,
+		QueueLength:   4,
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{`"status":"running"`, `"remaining_ms":90000`, `"queue":{"position":2,"length":4}`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in %s", want, data)
+		}
+	}
+
+	var back State
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if back != s {
+		t.Errorf("Expected round trip to preserve state, got %+v", back)
+	}
+
+	idle, _ := json.Marshal(State{Status: StatusIdle})
+	if strings.Contains(string(idle), "started_at") || strings.Contains(string(idle), "queue") {
+		t.Errorf("Expected unset fields to be omitted, got %s", idle)
+	}
+	if err := json.Unmarshal([]byte(`{"status":"sleeping"}`), &back); err == nil {
+		t.Error("Expected error for unknown status")
+	}
+}
+
+// ================= Cycle Tests =================
+
+func TestSequence_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		seq     Sequence
+		wantErr bool
+	}{
+		{"valid", Sequence{Intervals: []Interval{{Name: "work", Duration: time.Minute}}}, false},
+		{"empty", Sequence{}, true},
+		{"zero duration", Sequence{Intervals: []Interval{{Name: "work", Duration: 0}}}, true},
+		{"negative repeat", Sequence{Intervals: []Interval{{Name: "work", Duration: time.Minute}}, Repeat: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.seq.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestCycle_RunsSequence(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	seq := Sequence{
+		Intervals: []Interval{
+			{Name: "work", Duration: 40 * time.Millisecond},
+			{Name: "rest", Duration: 20 * time.Millisecond, Break: true},
+		},
+		Repeat: 2,
+	}
+	c, err := NewCycle(tm, seq)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	events := tm.SubscribeEvents()
+	c.Start()
+
+	if iv, round := c.Current(); iv.Name != "work" || round != 0 {
+		t.Errorf("Expected work in round 0, got %q in round %d", iv.Name, round)
+	}
+	if tm.Label() != "work" {
+		t.Errorf("Expected label work, got %q", tm.Label())
+	}
+
+	var started []string
+	timeout := time.After(2 * time.Second)
+	for c.Active() {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventStarted {
+				if ev.Break {
+					ev.Label += " (break)"
+				}
+				started = append(started, ev.Label)
+			}
+		case <-timeout:
+			t.Fatalf("Cycle did not finish, started %v", started)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	want := []string{"work", "rest (break)", "work", "rest (break)"}
+	if strings.Join(started, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected intervals %v, got %v", want, started)
+	}
+}
+
+func TestCycle_Stop(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	c, _ := NewCycle(tm, Sequence{Intervals: []Interval{
+		{Name: "work", Duration: 30 * time.Millisecond},
+		{Name: "break", Duration: time.Minute},
+	}})
+	c.Start()
+	c.Stop()
+	if c.Active() {
+		t.Error("Expected cycle to be inactive after Stop")
+	}
+
+	<-tm.Done()
+	time.Sleep(20 * time.Millisecond)
+	if tm.Label() != "work" {
+		t.Errorf("Expected stopped cycle not to advance, got label %q", tm.Label())
+	}
+}
+
+func TestCycle_Phases(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	brk := Interval{Name: "break", Phases: []Interval{
+		{Name: "stretch", Duration: 20 * time.Millisecond},
+		{Name: "water", Duration: 20 * time.Millisecond},
+		{Name: "breathing", Duration: 10 * time.Millisecond},
+	}}
+	if brk.Total() != 50*time.Millisecond {
+		t.Errorf("Expected break total 50ms, got %v", brk.Total())
+	}
+	bad := Sequence{Intervals: []Interval{{Name: "break", Phases: []Interval{{Name: "nap"}}}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("Expected error for phase without duration")
+	}
+
+	c, err := NewCycle(tm, Sequence{Intervals: []Interval{
+		{Name: "work", Duration: 20 * time.Millisecond},
+		brk,
+	}, Repeat: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	events := tm.SubscribeEvents()
+	c.Start()
+	if _, ok := c.Phase(); ok {
+		t.Error("Expected no phase during work")
+	}
+
+	var phases []string
+	timeout := time.After(2 * time.Second)
+	for c.Active() {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventPhase {
+				if ev.Label != "break" {
+					t.Errorf("Expected phase event labelled break, got %q", ev.Label)
+				}
+				phases = append(phases, ev.Phase)
+			}
+		case <-timeout:
+			t.Fatalf("Cycle did not finish, phases %v", phases)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	want := "stretch,water,breathing"
+	if got := strings.Join(phases, ","); got != want {
+		t.Errorf("Expected phases %s, got %s", want, got)
+	}
+	if got := tm.Activity(); got != "break" {
+		t.Errorf("Expected the phase gone with the cycle, got activity %q", got)
+	}
+}
+
+func TestCycle_PhaseCleared(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	stretch := Interval{Name: "break", Phases: []Interval{{Name: "stretch", Duration: 20 * time.Millisecond}}}
+	start := func(ivs ...Interval) *Cycle {
+		t.Helper()
+		c, err := NewCycle(tm, Sequence{Intervals: ivs, Repeat: 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		c.Start()
+		if got := tm.Phase(); got != "stretch" {
+			t.Fatalf("Expected the stretch phase, got %q", got)
+		}
+		return c
+	}
+
+	// a reset session leaves its phase behind
+	c := start(stretch)
+	tm.Reset()
+	tm.SetLabel("write")
+	tm.Start()
+	if got := tm.Activity(); got != "write" {
+		t.Errorf("Expected activity %q after a reset, got %q", "write", got)
+	}
+	c.Stop()
+	tm.Reset()
+
+	// a stopped cycle leaves a plain session
+	c = start(stretch)
+	c.Stop()
+	if got := tm.Phase(); got != "" {
+		t.Errorf("Expected no phase once the cycle stops, got %q", got)
+	}
+	tm.Reset()
+
+	// an interval without phases follows one with them
+	events := tm.SubscribeEvents()
+	defer tm.UnsubscribeEvents(events)
+	c = start(stretch, Interval{Name: "work", Duration: time.Minute})
+	defer c.Stop()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind != EventStarted || ev.Label != "work" {
+				continue
+			}
+			if ev.Phase != "" {
+				t.Errorf("Expected the work interval without a phase, got %q", ev.Phase)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Cycle did not reach the work interval")
+		}
+	}
+}
+
+func TestDispatcher_Cycle(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	seq := Sequence{Intervals: []Interval{{Name: "work", Duration: 52 * time.Minute}, {Name: "break", Duration: 17 * time.Minute}}, Repeat: 3}
+	if err := d.DefineSequence("52-17", seq); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := d.DefineSequence("stop", seq); err == nil {
+		t.Error("Expected error for sequence named stop")
+	}
+
+	if err := d.Dispatch("cycle 52-17"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.Label() != "work" || tm.Timer.Duration() != 52*time.Minute {
+		t.Errorf("Expected work 52m, got %q %v", tm.Label(), tm.Timer.Duration())
+	}
+	if err := d.Dispatch("skip"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for tm.Label() != "break" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if tm.Label() != "break" {
+		t.Errorf("Expected skip to advance to break, got %q", tm.Label())
+	}
+
+	if err := d.Dispatch("cycle stop"); err != nil {
+		t.Errorf("Unexpected error stopping cycle: %v", err)
+	}
+	if err := d.Dispatch("cycle nope"); err == nil {
+		t.Error("Expected error for unknown sequence")
+	}
+}
+
+// ================= Queue Tests =================
+
+func TestQueue_WorksThroughPlan(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	d := NewDispatcher(tm)
+
+	if err := d.Dispatch("queue add 2x40ms write report"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := d.Dispatch("queue add 30ms review"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	q := tm.Queue()
+	if cur, total := q.Position(); cur != 0 || total != 3 {
+		t.Errorf("Expected 0 of 3 before start, got %d of %d", cur, total)
+	}
+
+	events := tm.SubscribeEvents()
+	if err := d.Dispatch("queue start"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := tm.Activity(); got != "1 of 3: write report" {
+		t.Errorf("Expected activity %q, got %q", "1 of 3: write report", got)
+	}
+
+	var labels []string
+	timeout := time.After(2 * time.Second)
+	for q.Active() {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventStarted {
+				labels = append(labels, ev.Label)
+			}
+		case <-timeout:
+			t.Fatalf("Queue did not finish, started %v", labels)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	want := "write report,write report,review"
+	if got := strings.Join(labels, ","); got != want {
+		t.Errorf("Expected sessions %s, got %s", want, got)
+	}
+	if len(q.Tasks()) != 0 || q.Status() != "" {
+		t.Errorf("Expected finished queue to be empty, got %v %q", q.Tasks(), q.Status())
+	}
+}
+
+func TestQueue_StopKeepsPending(t *testing.T) {
+	tm := NewTimerManager(10 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+	q := tm.Queue()
+
+	if err := q.Add(Task{Label: "a", Duration: time.Minute}, Task{Label: "b", Duration: time.Minute}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := q.Add(Task{Label: "bad"}); err == nil {
+		t.Error("Expected error for task without duration")
+	}
+	q.Start()
+	tm.Skip()
+	deadline := time.Now().Add(time.Second)
+	for tm.Label() != "b" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cur, total := q.Position(); cur != 2 || total != 2 {
+		t.Errorf("Expected 2 of 2 after skip, got %d of %d", cur, total)
+	}
+
+	q.Stop()
+	if tasks := q.Tasks(); len(tasks) != 1 || tasks[0].Label != "b" {
+		t.Errorf("Expected unfinished task b to stay queued, got %v", tasks)
+	}
+	q.Clear()
+	if len(q.Tasks()) != 0 {
+		t.Error("Expected Clear to drop every task")
+	}
+
+	d := NewDispatcher(tm)
+	for _, line := range []string{"queue", "queue add", "queue add 0x5m", "queue add 101x5m", "queue add 99999999x25m", "queue add soon", "queue jump"} {
+		if err := d.Dispatch(line); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+	if tasks := q.Tasks(); len(tasks) != 0 {
+		t.Errorf("Expected nothing queued by the failing commands, got %d tasks", len(tasks))
+	}
+	if err := d.Dispatch("queue add 2x25:00 write"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tasks := q.Tasks(); len(tasks) != 2 || tasks[0].Duration != 25*time.Minute {
+		t.Errorf("Expected two 25 minute tasks, got %v", tasks)
+	}
+	q.Clear()
+}
+
+// ================= Subscription Option Tests =================
+
+func TestTimerManager_SubscribeEvery(t *testing.T) {
+	tm := NewTimerManager(5 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	fast := tm.SubscribeEvery(50*time.Millisecond, WithBuffer(100))
+	slow := tm.SubscribeEvery(300*time.Millisecond, WithBuffer(100))
+	tm.Start()
+	time.Sleep(650 * time.Millisecond)
+
+	if n := len(fast); n < 8 {
+		t.Errorf("Expected fast subscriber to receive at least 8 updates, got %d", n)
+	}
+	if n := len(slow); n < 2 || n > 5 {
+		t.Errorf("Expected slow subscriber to receive 2-5 updates, got %d", n)
+	}
+}
+
+func TestSubscription_DropPolicy(t *testing.T) {
+	newest := &subscription{ch: make(chan time.Duration, 1), policy: DropNewest}
+	newest.send(1)
+	newest.send(2)
+	if v := <-newest.ch; v != 1 {
+		t.Errorf("Expected DropNewest to keep the first value, got %v", v)
+	}
+
+	oldest := &subscription{ch: make(chan time.Duration, 1), policy: DropOldest}
+	oldest.send(1)
+	oldest.send(2)
+	if v := <-oldest.ch; v != 2 {
+		t.Errorf("Expected DropOldest to keep the latest value, got %v", v)
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	ev := Event{
+		Kind:      EventWarning,
+		At:        time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:  25 * time.Minute,
+		Remaining: 2 * time.Minute,
+		Label:     "writing",
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"kind":"warning"`) || !strings.Contains(string(data), `"remaining_ms":120000`) {
+		t.Errorf("Unexpected JSON encoding: %s", data)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded != ev {
+		t.Errorf("Expected %+v after round trip, got %+v", ev, decoded)
+	}
+}
+
+func TestTimerManager_BroadcasterParksWhenIdle(t *testing.T) {
+	tm := NewTimerManager(5 * time.Second)
+	defer func() {
+		close(tm.stopCh)
+	}()
+
+	ch := tm.SubscribeEvery(20*time.Millisecond, WithBuffer(100))
+	<-ch // initial value
+
+	time.Sleep(100 * time.Millisecond)
+	if n := len(ch); n != 0 {
+		t.Errorf("Expected no updates while idle, got %d", n)
+	}
+
+	tm.Start()
+	time.Sleep(100 * time.Millisecond)
+	tm.Pause()
+	time.Sleep(50 * time.Millisecond)
+	for len(ch) > 0 {
+		<-ch
+	}
+	final := tm.Snapshot()
+
+	time.Sleep(100 * time.Millisecond)
+	if n := len(ch); n != 0 {
+		t.Errorf("Expected no updates while paused, got %d", n)
+	}
+	if final <= 0 || final >= 5*time.Second {
+		t.Errorf("Expected final value from the paused timer, got %v", final)
+	}
+
+	tm.Inc()
+	select {
+	case v := <-ch:
+		if v != final+5*time.Second {
+			t.Errorf("Expected paused remaining %v after Inc wake-up, got %v", final+5*time.Second, v)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Error("Expected a value when the duration changes while parked")
 	}
 }
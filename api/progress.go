@@ -0,0 +1,90 @@
+package focotimer
+
+import "time"
+
+// ------------------- Progress helpers -------------------
+
+// Fraction returns part/total clamped to [0, 1]. A zero or negative total
+// yields 0 so callers never have to guard against division by zero.
+func Fraction(part, total time.Duration) float64 {
+	if total <= 0 || part <= 0 {
+		return 0
+	}
+	if part >= total {
+		return 1
+	}
+	return float64(part) / float64(total)
+}
+
+// Progress returns how much of the session has been completed (0..1),
+// based on the last broadcast snapshot so it matches what subscribers see.
+// It is measured against the deadline the session actually runs to, which
+// flow extensions and duration changes move; SmoothProgress eases the jump.
+func (t *TimerManager) Progress() float64 {
+	t.mu.Lock()
+	timer := t.Timer
+	remaining := t.lastValue
+	t.mu.Unlock()
+	total := timer.span()
+	return Fraction(total-remaining, total)
+}
+
+// ElapsedFraction returns the live elapsed time of the current timer as a
+// fraction of its scheduled length (0..1).
+func (t *TimerManager) ElapsedFraction() float64 {
+	t.mu.Lock()
+	timer := t.Timer
+	t.mu.Unlock()
+	return Fraction(timer.Elapsed(), timer.span())
+}
+
+// Span returns the length of the current session as scheduled, including
+// flow extensions; it is the total that Progress is measured against.
+func (t *TimerManager) Span() time.Duration {
+	return t.current().span()
+}
+
+// SmoothProgress eases the progress drawn for a session whose length
+// changes mid-flight, as when flow mode extends it: instead of jumping, the
+// shown value glides to the re-computed progress over Over. Otherwise it
+// follows the target exactly. The zero value is ready to use.
+type SmoothProgress struct {
+	// Over is the length of a glide; 300ms if zero.
+	Over time.Duration
+
+	total time.Duration // length of the session the last target belongs to
+	from  float64       // shown value when the current glide began
+	start time.Time     // zero unless gliding
+	shown float64
+}
+
+// At returns the progress to draw at now for the target progress of a
+// session of length total (see Span). New sessions (target 0) never glide.
+func (s *SmoothProgress) At(now time.Time, target float64, total time.Duration) float64 {
+	over := s.Over
+	if over <= 0 {
+		over = 300 * time.Millisecond
+	}
+	if s.total != 0 && total != s.total && target > 0 {
+		s.from, s.start = s.shown, now
+	}
+	s.total = total
+
+	if !s.start.IsZero() {
+		if k := now.Sub(s.start); k < over {
+			// ease out: fast at first, settling onto the target
+			x := 1 - float64(k)/float64(over)
+			s.shown = s.from + (target-s.from)*(1-x*x*x)
+			return s.shown
+		}
+		s.start = time.Time{}
+	}
+	s.shown = target
+	return s.shown
+}
+
+// Gliding reports whether the last value At returned was mid-glide, so
+// the caller knows to draw again soon.
+func (s *SmoothProgress) Gliding() bool {
+	return !s.start.IsZero()
+}
@@ -0,0 +1,175 @@
+package focotimer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ------------------- Session queue -------------------
+
+// Task is one planned session of a Queue.
+type Task struct {
+	Label    string
+	Duration time.Duration
+}
+
+// Queue is a plan of sessions (e.g. 4×25m "write report") that the manager
+// works through in order: when a session completes the next task is loaded
+// and started. Tasks can be added while the queue runs.
+type Queue struct {
+	mu     sync.Mutex
+	tm     *TimerManager
+	tasks  []Task
+	pos    int          // index of the current task while active
+	events <-chan Event // nil while the queue is not running
+}
+
+// Queue returns the session queue of the manager.
+func (t *TimerManager) Queue() *Queue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.queue == nil {
+		t.queue = &Queue{tm: t}
+	}
+	return t.queue
+}
+
+// Add appends tasks to the plan.
+func (q *Queue) Add(tasks ...Task) error {
+	for _, task := range tasks {
+		if task.Duration <= 0 {
+			return fmt.Errorf("task %q has no duration", task.Label)
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tasks = append(q.tasks, tasks...)
+	return nil
+}
+
+// Start works through the plan from its first task. Starting a running or
+// empty queue is a no-op.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	if q.events != nil || len(q.tasks) == 0 {
+		q.mu.Unlock()
+		return
+	}
+	events := q.tm.SubscribeEvents()
+	q.events = events
+	q.pos = 0
+	task := q.tasks[0]
+	q.mu.Unlock()
+
+	q.load(task)
+	go q.run(events)
+}
+
+// Stop detaches the queue from the timer, keeping the remaining tasks. The
+// current session keeps running as a plain session.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	events := q.events
+	q.events = nil
+	if events != nil {
+		// keep only what was not finished
+		q.tasks = q.tasks[q.pos:]
+		q.pos = 0
+	}
+	q.mu.Unlock()
+	if events != nil {
+		q.tm.UnsubscribeEvents(events)
+	}
+}
+
+// Clear stops the queue and drops every task.
+func (q *Queue) Clear() {
+	q.Stop()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tasks = nil
+}
+
+// Active reports whether the queue is driving the timer.
+func (q *Queue) Active() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.events != nil
+}
+
+// Tasks returns a copy of the planned tasks, including finished ones while
+// the queue runs.
+func (q *Queue) Tasks() []Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Task(nil), q.tasks...)
+}
+
+// Position returns the 1-based number of the current task and the number of
+// tasks, or 0 and the number of pending tasks when the queue is not running.
+func (q *Queue) Position() (current, total int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.events == nil {
+		return 0, len(q.tasks)
+	}
+	return q.pos + 1, len(q.tasks)
+}
+
+// Status describes the running queue for display, e.g.
+// "2 of 4: write report", and is empty when the queue is not running.
+func (q *Queue) Status() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.events == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%d of %d", q.pos+1, len(q.tasks))
+	if label := q.tasks[q.pos].Label; label != "" {
+		s += ": " + label
+	}
+	return s
+}
+
+func (q *Queue) run(events <-chan Event) {
+	for ev := range events {
+		if ev.Kind != EventCompleted {
+			continue
+		}
+		task, ok := q.advance(events)
+		if !ok {
+			return
+		}
+		q.load(task)
+	}
+}
+
+// advance moves to the next task. When the plan is done the queue empties
+// and detaches itself.
+func (q *Queue) advance(events <-chan Event) (Task, bool) {
+	q.mu.Lock()
+	if q.events != events {
+		q.mu.Unlock()
+		return Task{}, false
+	}
+	if q.pos+1 < len(q.tasks) {
+		q.pos++
+		task := q.tasks[q.pos]
+		q.mu.Unlock()
+		return task, true
+	}
+	q.tasks = nil
+	q.pos = 0
+	q.events = nil
+	q.mu.Unlock()
+	q.tm.UnsubscribeEvents(events)
+	return Task{}, false
+}
+
+func (q *Queue) load(task Task) {
+	q.tm.Reset()
+	q.tm.SetDuration(task.Duration)
+	q.tm.SetLabel(task.Label)
+	q.tm.Start()
+}
@@ -0,0 +1,105 @@
+package focotimer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------- Reminders -------------------
+
+// Reminder is a point of a session at which an EventReminder fires: a
+// share of the session elapsed (Fraction) or the time Left before its end.
+type Reminder struct {
+	Fraction float64
+	Left     time.Duration
+}
+
+// ParseReminder reads "50%", halfway through a session, or a duration such
+// as "5m", five minutes before its end.
+func ParseReminder(s string) (Reminder, error) {
+	s = strings.TrimSpace(s)
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		p, err := strconv.ParseFloat(pct, 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return Reminder{}, fmt.Errorf("reminder %q: want a percentage between 0 and 100", s)
+		}
+		return Reminder{Fraction: p / 100}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return Reminder{}, fmt.Errorf("reminder %q: want a percentage such as 50%% or a duration such as 5m", s)
+	}
+	return Reminder{Left: d}, nil
+}
+
+func (r Reminder) String() string {
+	if r.Fraction > 0 {
+		return strconv.FormatFloat(r.Fraction*100, 'f', -1, 64) + "%"
+	}
+	return r.Left.String()
+}
+
+// left is the time left in a session of duration d when r is reached.
+func (r Reminder) left(d time.Duration) time.Duration {
+	if r.Fraction > 0 {
+		return time.Duration(float64(d) * (1 - r.Fraction))
+	}
+	return r.Left
+}
+
+// SetReminders fires an EventReminder at each of points during every
+// session, for all frontends alike. Points a session is too short for, or
+// that already passed when it is resumed, are skipped. Nil disables the
+// reminders.
+func (t *TimerManager) SetReminders(points []Reminder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reminders = points
+}
+
+// armRemindersLocked (re)schedules the reminders still ahead from the
+// current remaining time. Caller holds t.mu.
+func (t *TimerManager) armRemindersLocked() {
+	t.cancelRemindersLocked()
+	timer := t.Timer
+	if len(t.reminders) == 0 || timer.IsPaused() {
+		return
+	}
+	d, remaining := timer.Duration(), timer.Remaining()
+	gen := t.remindGen
+	for _, r := range t.reminders {
+		left := r.left(d)
+		if left <= 0 || left >= remaining {
+			continue
+		}
+		t.remindTimers = append(t.remindTimers, time.AfterFunc(remaining-left, func() {
+			t.mu.Lock()
+			stale := t.remindGen != gen
+			t.mu.Unlock()
+			if stale {
+				return
+			}
+			ev := t.newEvent(EventReminder)
+			ev.Reminder = r.String()
+			t.fanOut(ev)
+		}))
+	}
+}
+
+func (t *TimerManager) cancelReminders() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancelRemindersLocked()
+}
+
+// cancelRemindersLocked stops the scheduled reminders; the generation
+// catches those already firing.
+func (t *TimerManager) cancelRemindersLocked() {
+	for _, timer := range t.remindTimers {
+		timer.Stop()
+	}
+	t.remindTimers = nil
+	t.remindGen++
+}
@@ -0,0 +1,165 @@
+package focotimer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ------------------- State -------------------
+
+// Status is the lifecycle state of the current session.
+type Status string
+
+const (
+	StatusIdle      Status = "idle"
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusCompleted Status = "completed"
+)
+
+// State is a point-in-time snapshot of a TimerManager. It is the canonical
+// wire format for status commands, remote interfaces and persistence.
+type State struct {
+	Status    Status
+	Duration  time.Duration
+	Remaining time.Duration
+	Elapsed   time.Duration
+	StartedAt time.Time // zero while idle
+	Deadline  time.Time // zero unless running
+	Label     string
+	Phase     string
+	Issue     string
+	// Break marks a break rather than focus work; see SetBreak.
+	Break bool
+	// EnergyStart and EnergyEnd are the energy ratings of the session from
+	// 1 to 5, zero when unrated. While idle, EnergyStart is the rating
+	// given for the next session.
+	EnergyStart int
+	EnergyEnd   int
+	// QueuePosition and QueueLength describe a running session plan
+	// ("2 of 4"); both are zero without one.
+	QueuePosition int
+	QueueLength   int
+}
+
+// stateJSON is the wire form of State; durations are in milliseconds like
+// in eventJSON.
+type stateJSON struct {
+	Status      Status     `json:"status"`
+	DurationMs  int64      `json:"duration_ms"`
+	RemainingMs int64      `json:"remaining_ms"`
+	ElapsedMs   int64      `json:"elapsed_ms"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	Label       string     `json:"label,omitempty"`
+	Phase       string     `json:"phase,omitempty"`
+	Issue       string     `json:"issue,omitempty"`
+	Break       bool       `json:"break,omitempty"`
+	EnergyStart int        `json:"energy_start,omitempty"`
+	EnergyEnd   int        `json:"energy_end,omitempty"`
+	Queue       *queueJSON `json:"queue,omitempty"`
+}
+
+type queueJSON struct {
+	Position int `json:"position"`
+	Length   int `json:"length"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (s State) MarshalJSON() ([]byte, error) {
+	v := stateJSON{
+		Status:      s.Status,
+		DurationMs:  s.Duration.Milliseconds(),
+		RemainingMs: s.Remaining.Milliseconds(),
+		ElapsedMs:   s.Elapsed.Milliseconds(),
+		StartedAt:   optionalTime(s.StartedAt),
+		Deadline:    optionalTime(s.Deadline),
+		Label:       s.Label,
+		Phase:       s.Phase,
+		Issue:       s.Issue,
+		Break:       s.Break,
+		EnergyStart: s.EnergyStart,
+		EnergyEnd:   s.EnergyEnd,
+	}
+	if s.QueueLength > 0 {
+		v.Queue = &queueJSON{Position: s.QueuePosition, Length: s.QueueLength}
+	}
+	return json.Marshal(v)
+}
+
+func (s *State) UnmarshalJSON(data []byte) error {
+	var v stateJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v.Status {
+	case StatusIdle, StatusRunning, StatusPaused, StatusCompleted:
+	default:
+		return fmt.Errorf("unknown timer status %q", v.Status)
+	}
+	*s = State{
+		Status:      v.Status,
+		Duration:    time.Duration(v.DurationMs) * time.Millisecond,
+		Remaining:   time.Duration(v.RemainingMs) * time.Millisecond,
+		Elapsed:     time.Duration(v.ElapsedMs) * time.Millisecond,
+		Label:       v.Label,
+		Phase:       v.Phase,
+		Issue:       v.Issue,
+		Break:       v.Break,
+		EnergyStart: v.EnergyStart,
+		EnergyEnd:   v.EnergyEnd,
+	}
+	if v.StartedAt != nil {
+		s.StartedAt = *v.StartedAt
+	}
+	if v.Deadline != nil {
+		s.Deadline = *v.Deadline
+	}
+	if v.Queue != nil {
+		s.QueuePosition, s.QueueLength = v.Queue.Position, v.Queue.Length
+	}
+	return nil
+}
+
+// State samples the manager.
+func (t *TimerManager) State() State {
+	t.mu.Lock()
+	timer := t.Timer
+	s := State{Label: t.label, Phase: t.phase, Issue: t.issue, Break: t.brk, EnergyStart: t.energyStart, EnergyEnd: t.energyEnd}
+	q, next := t.queue, t.nextEnergy
+	t.mu.Unlock()
+
+	s.Remaining = t.remaining()
+	s.Elapsed = timer.Elapsed()
+
+	timer.mu.Lock()
+	s.Duration = timer.duration
+	s.StartedAt = timer.startedAt.Round(0) // wall clock only, as on the wire
+	switch {
+	case timer.startedAt.IsZero():
+		s.Status = StatusIdle
+	case timer.isComplete:
+		s.Status = StatusCompleted
+	case !timer.pausedAt.IsZero():
+		s.Status = StatusPaused
+	default:
+		s.Status = StatusRunning
+		s.Deadline = timer.deadline.Round(0)
+	}
+	timer.mu.Unlock()
+	if s.Status == StatusIdle {
+		s.EnergyStart, s.EnergyEnd = next, 0
+	}
+
+	if q != nil && q.Active() {
+		s.QueuePosition, s.QueueLength = q.Position()
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package focotimer
+
+import "time"
+
+// ------------------- Subscriptions -------------------
+
+const defaultTickInterval = 200 * time.Millisecond
+
+// DropPolicy decides what happens when a subscriber's buffer is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the value being sent, keeping older queued values.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest queued value to make room, so a slow
+	// reader always sees the most recent remaining time.
+	DropOldest
+)
+
+type subscription struct {
+	ch     chan time.Duration
+	every  time.Duration
+	buffer int
+	policy DropPolicy
+	last   time.Time
+}
+
+// SubscribeOption configures a subscription created by Subscribe.
+type SubscribeOption func(*subscription)
+
+// Every sets how often the subscriber receives updates.
+func Every(d time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		if d > 0 {
+			s.every = d
+		}
+	}
+}
+
+// WithBuffer sets the channel buffer size.
+func WithBuffer(n int) SubscribeOption {
+	return func(s *subscription) {
+		if n >= 0 {
+			s.buffer = n
+		}
+	}
+}
+
+// WithDropPolicy selects which value is dropped when the buffer is full.
+func WithDropPolicy(p DropPolicy) SubscribeOption {
+	return func(s *subscription) {
+		s.policy = p
+	}
+}
+
+// Subscribe returns a channel receiving the remaining time, by default every
+// 200ms into a 10-element buffer that drops new values when full. Updates
+// only flow while the timer runs, plus one value on every state change.
+func (t *TimerManager) Subscribe(opts ...SubscribeOption) <-chan time.Duration {
+	sub := &subscription{
+		every:  defaultTickInterval,
+		buffer: 10,
+		policy: DropNewest,
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	sub.ch = make(chan time.Duration, sub.buffer)
+
+	t.mu.Lock()
+	t.subs = append(t.subs, sub)
+	// start with the current value; the broadcaster may be parked
+	sub.send(t.lastValue)
+	t.mu.Unlock()
+
+	// let the broadcaster pick up a faster tick rate if needed
+	select {
+	case t.retick <- struct{}{}:
+	default:
+	}
+	return sub.ch
+}
+
+// SubscribeEvery is shorthand for Subscribe(Every(d), opts...).
+func (t *TimerManager) SubscribeEvery(d time.Duration, opts ...SubscribeOption) <-chan time.Duration {
+	return t.Subscribe(append([]SubscribeOption{Every(d)}, opts...)...)
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe and closes
+// it.
+func (t *TimerManager) Unsubscribe(ch <-chan time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, sub := range t.subs {
+		if sub.ch == ch {
+			t.subs = append(t.subs[:i], t.subs[i+1:]...)
+			close(sub.ch)
+			return
+		}
+	}
+}
+
+// tickInterval is the broadcaster period: the fastest rate any subscriber
+// asked for. Caller holds t.mu.
+func (t *TimerManager) tickIntervalLocked() time.Duration {
+	interval := defaultTickInterval
+	for _, sub := range t.subs {
+		if sub.every < interval {
+			interval = sub.every
+		}
+	}
+	return interval
+}
+
+// due reports whether sub should receive a value at now, allowing half a
+// broadcaster tick of jitter.
+func (s *subscription) due(now time.Time, tick time.Duration) bool {
+	return s.last.IsZero() || now.Sub(s.last) >= s.every-tick/2
+}
+
+func (s *subscription) send(v time.Duration) {
+	select {
+	case s.ch <- v:
+		return
+	default:
+	}
+	if s.policy != DropOldest {
+		return // drop if slow
+	}
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- v:
+	default:
+	}
+}
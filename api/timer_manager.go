@@ -1,78 +1,260 @@
 package focotimer
 
 import (
+	"fmt"
+	"log"
 	"sync"
 	"time"
 )
 
 type TimerManager struct {
 	mu        sync.Mutex
-	subs      []chan time.Duration
+	subs      []*subscription
+	retick    chan struct{}
+	wake      chan struct{}
+	eventSubs []chan Event
 	Timer     *TimerData
 	lastValue time.Duration
 	updates   chan time.Duration
 	stopCh    chan struct{}
 	doneCh    chan struct{}
+
+	autoReset time.Duration
+	ackTimer  *time.Timer
+
+	warnLead  time.Duration
+	warnTimer *time.Timer
+
+	reminders    []Reminder
+	remindTimers []*time.Timer
+	remindGen    int
+
+	label string
+	phase string
+	issue string
+	// brk marks the sessions labelled by SetBreak as breaks
+	brk bool
+
+	// energy ratings of the current (or last) session, and the one given
+	// while idle, which Start takes over
+	energyStart, energyEnd int
+	nextEnergy             int
+
+	queue *Queue
+
+	flow         FlowOptions
+	flowExtended time.Duration
+
+	// extendedFrom is the length of the session a StartOneOff followed,
+	// which the next reset goes back to; zero otherwise
+	extendedFrom time.Duration
 }
 
 var GTimerManager = NewTimerManager(10 * time.Second)
 
 func NewTimerManager(duration time.Duration) *TimerManager {
 	tm := &TimerManager{
-		Timer:   NewTimer(duration),
-		updates: make(chan time.Duration),
-		stopCh:  make(chan struct{}),
-		doneCh:  make(chan struct{}),
+		lastValue: duration,
+		updates:   make(chan time.Duration),
+		retick:    make(chan struct{}, 1),
+		wake:      make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
 	}
+	tm.Timer = tm.newTimer(duration)
 	go tm.broadcast() // single broadcaster goroutine
 	return tm
 }
 
-// --- Subscriptions ---
+// --- Broadcasting ---
 
-func (t *TimerManager) Subscribe() <-chan time.Duration {
-	ch := make(chan time.Duration, 10)
+// broadcast ticks only while the timer is running. When it is idle,
+// paused or complete the ticker is parked and the goroutine sleeps until
+// wakeBroadcaster is called.
+func (t *TimerManager) broadcast() {
 	t.mu.Lock()
-	t.subs = append(t.subs, ch)
+	interval := t.tickIntervalLocked()
 	t.mu.Unlock()
-	return ch
-}
-
-func (t *TimerManager) broadcast() {
-	ticker := time.NewTicker(200 * time.Millisecond)
+	ticker := time.NewTicker(interval)
+	ticker.Stop()
 	defer ticker.Stop()
 
+	running := false
 	for {
 		select {
 		case <-t.stopCh:
 			return
-		case <-ticker.C:
-			remaining := t.Timer.Remaining()
+		case <-t.retick:
 			t.mu.Lock()
-			t.lastValue = remaining
-			for _, ch := range t.subs {
-				select {
-				case ch <- remaining:
-				default: // drop if slow
-				}
-			}
+			interval = t.tickIntervalLocked()
 			t.mu.Unlock()
+			if running {
+				ticker.Reset(interval)
+			}
+		case <-t.wake:
+			// state changed: give every subscriber the new value, then
+			// tick or park depending on whether the timer now runs
+			t.publish(true, 0)
+			running = t.running()
+			if running {
+				ticker.Reset(interval)
+			} else {
+				ticker.Stop()
+			}
+		case <-ticker.C:
+			t.publish(false, interval)
+			if running = t.running(); !running {
+				ticker.Stop()
+			}
+		}
+	}
+}
+
+// wakeBroadcaster tells the broadcaster the timer state changed.
+func (t *TimerManager) wakeBroadcaster() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *TimerManager) running() bool {
+	return t.current().IsRunning()
+}
+
+// publish samples the timer and fans the value out to subscribers that are
+// due, or to all of them when force is set.
+func (t *TimerManager) publish(force bool, tick time.Duration) {
+	remaining := t.remaining()
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastValue = remaining
+	for _, sub := range t.subs {
+		if !force && !sub.due(now, tick) {
+			continue
 		}
+		sub.last = now
+		sub.send(remaining)
+	}
+}
+
+// remaining reports the full duration while the timer is idle, so
+// subscribers show what a fresh session would count down from.
+func (t *TimerManager) remaining() time.Duration {
+	t.mu.Lock()
+	timer := t.Timer
+	t.mu.Unlock()
+
+	timer.mu.Lock()
+	idle := timer.startedAt.IsZero()
+	d := timer.duration
+	timer.mu.Unlock()
+	if idle {
+		return d
 	}
+	return timer.Remaining()
 }
 
 // --- Control methods ---
 
+// current returns the timer of the current session.
+func (t *TimerManager) current() *TimerData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Timer
+}
+
+// Stop ends the session without completing it. The timer is idle again at
+// the length it had before any flow extensions, so Toggle and Start begin
+// a fresh session. The event carries the time that was left.
 func (t *TimerManager) Stop() {
-	t.Timer.StopTimer()
+	ev := t.newEvent(EventStopped)
+	t.mu.Lock()
+	timer := t.Timer
+	t.cancelAckLocked()
+	t.cancelWarningLocked()
+	t.cancelRemindersLocked()
+	extended := t.flowExtended
+	t.flowExtended = 0
+	t.mu.Unlock()
+
+	timer.StopTimer()
+	if extended > 0 {
+		timer.SetDuration(timer.Duration() - extended)
+	}
+	t.wakeBroadcaster()
+	t.fanOut(ev)
+}
+
+func (t *TimerManager) Pause() {
+	t.current().PauseTimer()
+	t.cancelWarning()
+	t.cancelReminders()
+	t.wakeBroadcaster()
+	t.emit(EventPaused)
+}
+
+func (t *TimerManager) Resume() {
+	t.current().ResumeTimer()
+	t.mu.Lock()
+	t.armWarningLocked()
+	t.armRemindersLocked()
+	t.mu.Unlock()
+	t.wakeBroadcaster()
+	t.emit(EventResumed)
+}
+
+// Toggle pauses a running session, resumes a paused one and otherwise
+// starts a fresh session.
+func (t *TimerManager) Toggle() {
+	t.mu.Lock()
+	timer := t.Timer
+	t.mu.Unlock()
+
+	switch {
+	case timer.IsRunning():
+		t.Pause()
+	case timer.IsPaused():
+		t.Resume()
+	default:
+		t.Reset()
+		t.Start()
+	}
+}
+
+// Skip ends the current session now, firing completion as if its time was up.
+func (t *TimerManager) Skip() {
+	t.mu.Lock()
+	timer := t.Timer
+	t.cancelWarningLocked()
+	t.cancelRemindersLocked()
+	t.mu.Unlock()
+	timer.FinishTimer()
 }
 
 func (t *TimerManager) Reset() {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	t.resetLocked()
+	t.mu.Unlock()
+	t.wakeBroadcaster()
+	t.emit(EventReset)
+	// the phase ends with the session; the event above still names it
+	t.setPhase("")
+}
+
+func (t *TimerManager) resetLocked() {
+	t.cancelAckLocked()
+	t.cancelWarningLocked()
+	t.cancelRemindersLocked()
 
-	d := t.Timer.Duration
-	t.Timer = NewTimer(d)
+	// flow mode extensions only lengthen the session they were granted to
+	d := t.Timer.Duration() - t.flowExtended
+	t.flowExtended = 0
+	if t.extendedFrom > 0 {
+		d, t.extendedFrom = t.extendedFrom, 0
+	}
+	t.Timer = t.newTimer(d)
 	t.lastValue = d
 
 	// replace with a fresh done channel
@@ -81,38 +263,340 @@ func (t *TimerManager) Reset() {
 
 func (t *TimerManager) Start() {
 	t.mu.Lock()
+	defer t.emit(EventStarted)
+	defer t.publish(true, 0) // subscribers see the new session without waiting a tick
 	defer t.mu.Unlock()
 
+	t.cancelAckLocked()
+	t.flowExtended = 0
+	t.energyStart, t.energyEnd, t.nextEnergy = t.nextEnergy, 0, 0
 	if t.Timer != nil {
-		// hook completion into TimerData
-		t.Timer.Handler = func() {
-			t.mu.Lock()
-			defer t.mu.Unlock()
-			select {
-			case <-t.doneCh:
-				// already closed
-			default:
-				close(t.doneCh) // fire done
-			}
-		}
 		t.Timer.StartTimer()
+		t.armWarningLocked()
+		t.armRemindersLocked()
+	}
+	t.wakeBroadcaster()
+}
+
+// StartOneOff starts a session of d that leaves the length of the ones
+// after it alone, such as a few more minutes once a session completed, or
+// a short break: the next reset goes back to the length of the last
+// session.
+func (t *TimerManager) StartOneOff(d time.Duration) {
+	t.mu.Lock()
+	full := t.Timer.Duration() - t.flowExtended
+	if t.extendedFrom > 0 {
+		// one-offs in a row still go back to the session before them
+		full = t.extendedFrom
 	}
+	t.resetLocked()
+	t.extendedFrom = full
+	t.Timer.SetDuration(d)
+	t.mu.Unlock()
+	t.Start()
 }
 
+// StartUntil starts a session that ends at the wall-clock time at instead
+// of after a fixed duration.
+func (t *TimerManager) StartUntil(at time.Time) {
+	t.mu.Lock()
+	defer t.emit(EventStarted)
+	defer t.publish(true, 0)
+	defer t.mu.Unlock()
+
+	t.cancelAckLocked()
+	t.flowExtended = 0
+	t.energyStart, t.energyEnd, t.nextEnergy = t.nextEnergy, 0, 0
+	t.Timer.StartTimerUntil(at)
+	t.armWarningLocked()
+	t.armRemindersLocked()
+	t.wakeBroadcaster()
+}
+
+// newTimer creates a timer whose completion is hooked into the manager.
+func (t *TimerManager) newTimer(d time.Duration) *TimerData {
+	timer := NewTimer(d)
+	timer.onChange = t.wakeBroadcaster
+	timer.AddCompletionHandler(func() { t.complete(timer) })
+	timer.SetExtender(func() time.Duration { return t.extendFlow(timer) })
+	return timer
+}
+
+// complete runs when timer fires: it closes the done channel, notifies
+// event subscribers and arms the auto-reset for forgotten sessions.
+func (t *TimerManager) complete(timer *TimerData) {
+	t.mu.Lock()
+	t.lastValue = 0
+	select {
+	case <-t.doneCh:
+		// already closed
+	default:
+		close(t.doneCh) // fire done
+	}
+	if t.autoReset > 0 {
+		t.ackTimer = time.AfterFunc(t.autoReset, func() { t.forget(timer) })
+	}
+	t.mu.Unlock()
+
+	t.wakeBroadcaster()
+	t.emit(EventCompleted)
+}
+
+// --- Pre-end warning ---
+
+// SetWarning schedules an EventWarning lead before each session ends, so
+// the user can wrap up a thought. Zero disables the warning. Sessions
+// shorter than lead are not warned about.
+func (t *TimerManager) SetWarning(lead time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.warnLead = lead
+}
+
+// armWarningLocked (re)schedules the warning from the current remaining
+// time. Caller holds t.mu.
+func (t *TimerManager) armWarningLocked() {
+	t.cancelWarningLocked()
+	timer := t.Timer
+	if t.warnLead <= 0 || timer.IsPaused() {
+		return
+	}
+	remaining := timer.Remaining()
+	if remaining <= t.warnLead {
+		return
+	}
+
+	var fired *time.Timer
+	fired = time.AfterFunc(remaining-t.warnLead, func() {
+		t.mu.Lock()
+		if t.warnTimer != fired {
+			t.mu.Unlock()
+			return
+		}
+		t.warnTimer = nil
+		t.mu.Unlock()
+		t.emit(EventWarning)
+	})
+	t.warnTimer = fired
+}
+
+func (t *TimerManager) cancelWarning() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancelWarningLocked()
+}
+
+func (t *TimerManager) cancelWarningLocked() {
+	if t.warnTimer != nil {
+		t.warnTimer.Stop()
+		t.warnTimer = nil
+	}
+}
+
+// --- Auto-reset of forgotten sessions ---
+
+// SetAutoReset makes a completed session that nobody acknowledges within d
+// reset itself to the stopped state. Zero disables the auto-reset.
+func (t *TimerManager) SetAutoReset(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.autoReset = d
+}
+
+// Acknowledge marks the completed session as seen by the user, cancelling
+// any pending auto-reset.
+func (t *TimerManager) Acknowledge() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancelAckLocked()
+}
+
+func (t *TimerManager) cancelAckLocked() {
+	if t.ackTimer != nil {
+		t.ackTimer.Stop()
+		t.ackTimer = nil
+	}
+}
+
+// forget resets a completed session nobody acknowledged. Its
+// EventAutoReset describes the session forgotten, not the reset one, so
+// the notice sent for it can name what was left.
+func (t *TimerManager) forget(timer *TimerData) {
+	ev := t.newEvent(EventAutoReset)
+	t.mu.Lock()
+	if t.Timer != timer || t.ackTimer == nil {
+		// acknowledged, reset or restarted in the meantime
+		t.mu.Unlock()
+		return
+	}
+	t.ackTimer = nil
+
+	completedAt := timer.CompletedAt()
+	log.Printf("focotimer: session completed at %s was not acknowledged, resetting",
+		completedAt.Format(time.Kitchen))
+
+	t.resetLocked()
+	t.phase = ""
+	t.mu.Unlock()
+
+	t.wakeBroadcaster()
+	t.fanOut(ev)
+}
+
+// Inc, Dec and SetDuration change the length of the session, moving the
+// deadline of one already running; the timer notifies subscribers through
+// its onChange hook.
+
 func (t *TimerManager) Inc() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.Timer.Duration += 5 * time.Second
+	t.setDurationLocked(t.Timer.Duration() + 5*time.Second)
 }
 
 func (t *TimerManager) Dec() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.Timer.Duration > 5*time.Second {
-		t.Timer.Duration -= 5 * time.Second
-	} else {
-		t.Timer.Duration = 0
+	t.setDurationLocked(t.Timer.Duration() - 5*time.Second)
+}
+
+// SetDuration sets the length of the session. Negative values are
+// treated as zero.
+func (t *TimerManager) SetDuration(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setDurationLocked(d)
+}
+
+// setDurationLocked changes the length of the session and moves the
+// warning and the reminders of a running one with its deadline. Caller
+// holds t.mu.
+func (t *TimerManager) setDurationLocked(d time.Duration) {
+	t.Timer.SetDuration(d)
+	if t.Timer.IsRunning() {
+		t.armWarningLocked()
+		t.armRemindersLocked()
+	}
+}
+
+// Duration returns the length of the current session.
+func (t *TimerManager) Duration() time.Duration {
+	return t.current().Duration()
+}
+
+// IsComplete reports whether the current session has completed.
+func (t *TimerManager) IsComplete() bool {
+	return t.current().IsComplete()
+}
+
+// SetLabel names what the current session is spent on, as focus work.
+// Subscribers get a fresh value so observers pick up the new label without
+// waiting a tick.
+func (t *TimerManager) SetLabel(label string) {
+	t.setLabel(label, false)
+}
+
+// SetBreak names the current session like SetLabel and marks it as a
+// break. Break enforcement, hooks and statistics go by this mark, never by
+// what the label says.
+func (t *TimerManager) SetBreak(label string) {
+	t.setLabel(label, true)
+}
+
+func (t *TimerManager) setLabel(label string, brk bool) {
+	t.mu.Lock()
+	t.label, t.brk = label, brk
+	t.mu.Unlock()
+	t.wakeBroadcaster()
+}
+
+// IsBreak reports whether the session was marked as a break by SetBreak.
+func (t *TimerManager) IsBreak() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.brk
+}
+
+func (t *TimerManager) Label() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.label
+}
+
+// SetIssue links the sessions to an issue reference such as "GH-123" or
+// "PROJ-42". An empty ref removes the link.
+func (t *TimerManager) SetIssue(ref string) {
+	t.mu.Lock()
+	t.issue = ref
+	t.mu.Unlock()
+	t.wakeBroadcaster()
+}
+
+func (t *TimerManager) Issue() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.issue
+}
+
+// RateEnergy records how energetic the user feels, from 1 (drained) to 5
+// (sharp). A rating given while idle is for the next session, one given
+// after the session completed is its end rating, and otherwise it is the
+// start rating of the current session.
+func (t *TimerManager) RateEnergy(level int) error {
+	if level < 1 || level > 5 {
+		return fmt.Errorf("energy must be between 1 and 5, got %d", level)
 	}
+	timer := t.current()
+	idle, complete := timer.StartedAt().IsZero(), timer.IsComplete()
+
+	t.mu.Lock()
+	switch {
+	case idle:
+		t.nextEnergy = level
+	case complete:
+		t.energyEnd = level
+	default:
+		t.energyStart = level
+	}
+	t.mu.Unlock()
+	t.emit(EventRated)
+	return nil
+}
+
+// Phase names the sub-activity of the current session, if a cycle runs one.
+func (t *TimerManager) Phase() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phase
+}
+
+// Activity describes what the session is spent on for display: the queue
+// position while a plan runs ("2 of 4: write report"), otherwise the label
+// followed by the sub-phase when there is one ("break: stretch").
+func (t *TimerManager) Activity() string {
+	t.mu.Lock()
+	q := t.queue
+	t.mu.Unlock()
+	if q != nil {
+		if status := q.Status(); status != "" {
+			return status
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase == "" {
+		return t.label
+	}
+	if t.label == "" {
+		return t.phase
+	}
+	return t.label + ": " + t.phase
+}
+
+func (t *TimerManager) setPhase(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phase = phase
 }
 
 func (t *TimerManager) Snapshot() time.Duration {
@@ -122,5 +606,7 @@ func (t *TimerManager) Snapshot() time.Duration {
 }
 
 func (t *TimerManager) Done() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.doneCh
 }
@@ -0,0 +1,193 @@
+// Package appearance tells whether the desktop prefers dark or light
+// colors, so the window can follow it.
+package appearance
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+// Scheme is the color scheme the desktop prefers.
+type Scheme int
+
+const (
+	NoPreference Scheme = iota
+	Dark
+	Light
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case Dark:
+		return "dark"
+	case Light:
+		return "light"
+	}
+	return "none"
+}
+
+// Detector reports the color scheme the desktop prefers.
+type Detector interface {
+	Scheme() (Scheme, error)
+}
+
+// DetectorFunc adapts a function to a Detector.
+type DetectorFunc func() (Scheme, error)
+
+func (f DetectorFunc) Scheme() (Scheme, error) { return f() }
+
+// ------------------- Settings portal -------------------
+
+// portalTimeout bounds connecting to the bus and reading the setting.
+const portalTimeout = 2 * time.Second
+
+// Portal reads the color-scheme of org.freedesktop.appearance from the
+// freedesktop settings portal, which GNOME, KDE and most other desktops
+// provide.
+type Portal struct {
+	// Address is a D-Bus server address such as
+	// "unix:path=/run/user/1000/bus"; empty is the session bus.
+	Address string
+}
+
+func (p Portal) Scheme() (Scheme, error) {
+	conn, err := dbus.Dial(p.Address)
+	if err != nil {
+		return NoPreference, fmt.Errorf("portal: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(portalTimeout))
+
+	var e dbus.Encoder
+	e.String("org.freedesktop.appearance")
+	e.String("color-scheme")
+	reply, err := conn.Call(dbus.Message{
+		Path:        "/org/freedesktop/portal/desktop",
+		Interface:   "org.freedesktop.portal.Settings",
+		Member:      "Read",
+		Destination: "org.freedesktop.portal.Desktop",
+		Signature:   "ss",
+		Body:        e.Bytes(),
+	})
+	if err != nil {
+		return NoPreference, fmt.Errorf("portal: %w", err)
+	}
+	return portalScheme(reply)
+}
+
+// portalScheme decodes the reply to Read: the setting as a variant, which
+// older portals wrap in a second variant. 1 prefers dark, 2 light.
+func portalScheme(reply dbus.Message) (Scheme, error) {
+	d := reply.Decoder()
+	sig := d.Variant()
+	if sig == "v" {
+		sig = d.Variant()
+	}
+	if sig != "u" {
+		return NoPreference, fmt.Errorf("portal: color-scheme has type %q, want u", sig)
+	}
+	v := d.Uint32()
+	if err := d.Err(); err != nil {
+		return NoPreference, fmt.Errorf("portal: %w", err)
+	}
+	switch v {
+	case 1:
+		return Dark, nil
+	case 2:
+		return Light, nil
+	}
+	return NoPreference, nil
+}
+
+// ------------------- GSettings -------------------
+
+// GSettings reads GNOME's color-scheme with the gsettings tool and, when
+// that states no preference, whether the GTK theme is a dark one.
+type GSettings struct{}
+
+func (GSettings) Scheme() (Scheme, error) {
+	get := func(key string) (string, error) {
+		out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", key).Output()
+		if err != nil {
+			return "", fmt.Errorf("gsettings: %w", err)
+		}
+		return strings.Trim(strings.TrimSpace(string(out)), "'"), nil
+	}
+	// color-scheme is missing before GNOME 42
+	scheme, schemeErr := get("color-scheme")
+	if s := gsettingsScheme(scheme, ""); s != NoPreference {
+		return s, nil
+	}
+	theme, err := get("gtk-theme")
+	if err != nil {
+		return NoPreference, errors.Join(schemeErr, err)
+	}
+	return gsettingsScheme(scheme, theme), nil
+}
+
+// gsettingsScheme reads "prefer-dark" and "prefer-light", then a theme
+// name such as "Adwaita-dark".
+func gsettingsScheme(colorScheme, gtkTheme string) Scheme {
+	switch colorScheme {
+	case "prefer-dark":
+		return Dark
+	case "prefer-light":
+		return Light
+	}
+	if strings.HasSuffix(strings.ToLower(gtkTheme), "-dark") {
+		return Dark
+	}
+	return NoPreference
+}
+
+// ------------------- Following -------------------
+
+// First asks each detector in turn and returns the first preference. It
+// only fails when every detector failed.
+type First []Detector
+
+func (f First) Scheme() (Scheme, error) {
+	var errs []error
+	for _, d := range f {
+		s, err := d.Scheme()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if s != NoPreference {
+			return s, nil
+		}
+	}
+	if len(errs) == len(f) {
+		return NoPreference, errors.Join(errs...)
+	}
+	return NoPreference, nil
+}
+
+// Follow asks d every interval until stop is closed, and calls fn with the
+// scheme at once and whenever it changes. A detector error counts as no
+// preference.
+func Follow(d Detector, interval time.Duration, stop <-chan struct{}, fn func(Scheme)) {
+	last, _ := d.Scheme()
+	fn(last)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		s, _ := d.Scheme()
+		if s != last {
+			last = s
+			fn(s)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package appearance
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+func TestPortalScheme(t *testing.T) {
+	reply := func(nested bool, sig string, v uint32) dbus.Message {
+		var e dbus.Encoder
+		value := func() { e.Variant(sig, func() { e.Uint32(v) }) }
+		if nested {
+			e.Variant("v", value)
+		} else {
+			value()
+		}
+		return dbus.Message{Type: dbus.MethodReturn, Signature: "v", Body: e.Bytes()}
+	}
+	tests := []struct {
+		name  string
+		reply dbus.Message
+		want  Scheme
+		err   bool
+	}{
+		{"dark", reply(false, "u", 1), Dark, false},
+		{"light in an old portal", reply(true, "u", 2), Light, false},
+		{"no preference", reply(false, "u", 0), NoPreference, false},
+		{"wrong type", reply(false, "i", 1), NoPreference, true},
+	}
+	for _, tt := range tests {
+		got, err := portalScheme(tt.reply)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("%s: expected %v (error %v), got %v, %v", tt.name, tt.want, tt.err, got, err)
+		}
+	}
+}
+
+func TestGSettingsScheme(t *testing.T) {
+	tests := []struct {
+		scheme, theme string
+		want          Scheme
+	}{
+		{"prefer-dark", "Adwaita", Dark},
+		{"prefer-light", "Adwaita-dark", Light},
+		{"default", "Adwaita-dark", Dark},
+		{"", "Arc-Dark", Dark},
+		{"default", "Adwaita", NoPreference},
+	}
+	for _, tt := range tests {
+		if got := gsettingsScheme(tt.scheme, tt.theme); got != tt.want {
+			t.Errorf("gsettingsScheme(%q, %q): expected %v, got %v", tt.scheme, tt.theme, tt.want, got)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	fails := DetectorFunc(func() (Scheme, error) { return NoPreference, errors.New("no portal") })
+	none := DetectorFunc(func() (Scheme, error) { return NoPreference, nil })
+	light := DetectorFunc(func() (Scheme, error) { return Light, nil })
+
+	if s, err := (First{fails, none, light}).Scheme(); s != Light || err != nil {
+		t.Errorf("Expected light from the last detector, got %v, %v", s, err)
+	}
+	if s, err := (First{fails, none}).Scheme(); s != NoPreference || err != nil {
+		t.Errorf("Expected no preference without an error, got %v, %v", s, err)
+	}
+	if _, err := (First{fails}).Scheme(); err == nil {
+		t.Error("Expected an error when every detector failed")
+	}
+}
+
+func TestFollow(t *testing.T) {
+	var scheme atomic.Int32
+	scheme.Store(int32(Dark))
+	d := DetectorFunc(func() (Scheme, error) { return Scheme(scheme.Load()), nil })
+	got := make(chan Scheme, 4)
+	stop := make(chan struct{})
+	defer close(stop)
+	go Follow(d, 5*time.Millisecond, stop, func(s Scheme) { got <- s })
+
+	next := func() Scheme {
+		select {
+		case s := <-got:
+			return s
+		case <-time.After(time.Second):
+			t.Fatal("Expected a scheme")
+			return NoPreference
+		}
+	}
+	if s := next(); s != Dark {
+		t.Fatalf("Expected dark first, got %v", s)
+	}
+	scheme.Store(int32(Light))
+	if s := next(); s != Light {
+		t.Fatalf("Expected the change to light, got %v", s)
+	}
+	select {
+	case s := <-got:
+		t.Errorf("Expected no call while the scheme stays, got %v", s)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
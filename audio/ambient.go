@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Ambient sound -------------------
+
+// Ambient plays a player while a session runs. Over the final FadeOver of
+// the session the volume is lowered to silence, a gentle cue that the end
+// is near before any alarm.
+type Ambient struct {
+	player   Player
+	volume   float64
+	fadeOver time.Duration
+
+	mu      sync.Mutex
+	current float64
+}
+
+func NewAmbient(p Player, volume float64, fadeOver time.Duration) *Ambient {
+	return &Ambient{player: p, volume: volume, fadeOver: fadeOver, current: -1}
+}
+
+func (a *Ambient) Name() string { return "ambient" }
+
+// OnEvent starts the sound with the session and stops it when the session
+// stops, pauses or ends.
+func (a *Ambient) OnEvent(ev focotimer.Event) error {
+	switch ev.Kind {
+	case focotimer.EventStarted, focotimer.EventResumed:
+		if err := a.setVolume(FadeLevel(a.volume, ev.Remaining, a.fadeOver)); err != nil {
+			return err
+		}
+		return a.player.Play()
+	case focotimer.EventPaused, focotimer.EventStopped, focotimer.EventCompleted,
+		focotimer.EventReset, focotimer.EventAutoReset:
+		return a.player.Stop()
+	}
+	return nil
+}
+
+// FollowTicks fades the sound from the manager's remaining-time ticks until
+// the channel is closed.
+func (a *Ambient) FollowTicks(ticks <-chan time.Duration) {
+	for remaining := range ticks {
+		if a.player.Playing() {
+			a.setVolume(FadeLevel(a.volume, remaining, a.fadeOver))
+		}
+	}
+}
+
+func (a *Ambient) setVolume(v float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if v == a.current {
+		return nil
+	}
+	a.current = v
+	return a.player.SetVolume(v)
+}
+
+// FadeLevel returns the volume for the remaining time: base until the
+// last `over` of the session, then falling linearly to zero. Zero over
+// disables the fade.
+func FadeLevel(base float64, remaining, over time.Duration) float64 {
+	if over <= 0 || remaining >= over {
+		return base
+	}
+	if remaining <= 0 {
+		return 0
+	}
+	// quantise to 1% steps so ticks don't flood the player with updates
+	level := base * float64(remaining) / float64(over)
+	return float64(int(level*100)) / 100
+}
@@ -0,0 +1,98 @@
+// Package audio plays ambient sound while a session runs and fades it out
+// as the session nears its end, ticks through its final seconds, and plays
+// a sound when a session completes.
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Player is a looping ambient sound source.
+type Player interface {
+	Play() error
+	Stop() error
+	// SetVolume sets the volume from 0 (silent) to 1 (full).
+	SetVolume(v float64) error
+	Playing() bool
+}
+
+// MPV plays a file in a loop with mpv and controls its volume through mpv's
+// JSON IPC socket.
+type MPV struct {
+	File string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	sock   string
+	volume float64
+}
+
+func NewMPV(file string) *MPV {
+	return &MPV{File: file, volume: 1}
+}
+
+func (m *MPV) Play() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cmd != nil {
+		return nil
+	}
+
+	m.sock = filepath.Join(os.TempDir(), fmt.Sprintf("focotimer-mpv-%d.sock", os.Getpid()))
+	cmd := exec.Command("mpv", "--no-video", "--really-quiet", "--loop=inf",
+		"--input-ipc-server="+m.sock,
+		"--volume="+strconv.Itoa(int(m.volume*100)),
+		m.File)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+	m.cmd = cmd
+	go cmd.Wait()
+	return nil
+}
+
+func (m *MPV) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cmd == nil {
+		return nil
+	}
+	err := m.cmd.Process.Kill()
+	m.cmd = nil
+	os.Remove(m.sock)
+	return err
+}
+
+func (m *MPV) Playing() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cmd != nil
+}
+
+func (m *MPV) SetVolume(v float64) error {
+	v = min(max(v, 0), 1)
+	m.mu.Lock()
+	m.volume = v
+	playing, sock := m.cmd != nil, m.sock
+	m.mu.Unlock()
+	if !playing {
+		return nil // applied on the next Play
+	}
+
+	conn, err := net.DialTimeout("unix", sock, time.Second)
+	if err != nil {
+		return fmt.Errorf("audio: mpv ipc: %w", err)
+	}
+	defer conn.Close()
+	msg, _ := json.Marshal(map[string]any{"command": []any{"set_property", "volume", v * 100}})
+	_, err = conn.Write(append(msg, '\n'))
+	return err
+}
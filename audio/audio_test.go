@@ -0,0 +1,229 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+type fakePlayer struct {
+	mu      sync.Mutex
+	playing bool
+	volumes []float64
+}
+
+func (p *fakePlayer) Play() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.playing = true
+	return nil
+}
+
+func (p *fakePlayer) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.playing = false
+	return nil
+}
+
+func (p *fakePlayer) SetVolume(v float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.volumes = append(p.volumes, v)
+	return nil
+}
+
+func (p *fakePlayer) Playing() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.playing
+}
+
+func TestFadeLevel(t *testing.T) {
+	tests := []struct {
+		remaining, over time.Duration
+		want            float64
+	}{
+		{10 * time.Minute, 30 * time.Second, 0.8},
+		{30 * time.Second, 30 * time.Second, 0.8},
+		{15 * time.Second, 30 * time.Second, 0.4},
+		{0, 30 * time.Second, 0},
+		{5 * time.Second, 0, 0.8},
+	}
+	for _, tt := range tests {
+		if got := FadeLevel(0.8, tt.remaining, tt.over); got != tt.want {
+			t.Errorf("FadeLevel(0.8, %v, %v): expected %v, got %v", tt.remaining, tt.over, tt.want, got)
+		}
+	}
+}
+
+func TestAmbient_FollowsSession(t *testing.T) {
+	p := &fakePlayer{}
+	a := NewAmbient(p, 1, 30*time.Second)
+
+	a.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, Remaining: 25 * time.Minute})
+	if !p.Playing() {
+		t.Fatal("Expected sound to play when the session starts")
+	}
+
+	ticks := make(chan time.Duration, 4)
+	ticks <- 20 * time.Minute
+	ticks <- 15 * time.Second
+	ticks <- 15 * time.Second // unchanged level is not resent
+	ticks <- 0
+	close(ticks)
+	a.FollowTicks(ticks)
+
+	want := []float64{1, 0.5, 0}
+	if len(p.volumes) != len(want) {
+		t.Fatalf("Expected volumes %v, got %v", want, p.volumes)
+	}
+	for i := range want {
+		if p.volumes[i] != want[i] {
+			t.Errorf("Expected volumes %v, got %v", want, p.volumes)
+			break
+		}
+	}
+
+	a.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted})
+	if p.Playing() {
+		t.Error("Expected sound to stop when the session completes")
+	}
+}
+
+func TestSounds_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return path
+	}
+	alarm, chime, bell := file("alarm.ogg"), file("chime.ogg"), file("bell.ogg")
+	sounds := Sounds{
+		Default:  alarm,
+		Profiles: map[string]string{"break": chime, "missing": filepath.Join(dir, "gone.ogg")},
+		Phases:   map[string]string{"stretch": bell},
+	}
+
+	tests := []struct {
+		label, phase string
+		want         string
+	}{
+		{"", "", alarm},
+		{"work", "", alarm},
+		{"break", "", chime},
+		{"break", "stretch", bell},
+		{"break", "water", chime},
+		{"", "stretch", bell},
+		{"missing", "", alarm},
+	}
+	for _, tt := range tests {
+		if got := sounds.Resolve(tt.label, tt.phase); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, expected %q", tt.label, tt.phase, got, tt.want)
+		}
+	}
+
+	if got := (Sounds{Default: filepath.Join(dir, "gone.ogg")}).Resolve("work", ""); got != "" {
+		t.Errorf("Expected nothing to play without an existing file, got %q", got)
+	}
+}
+
+func TestChime_PlaysOnCompletion(t *testing.T) {
+	dir := t.TempDir()
+	alarm, chime := filepath.Join(dir, "alarm.ogg"), filepath.Join(dir, "chime.ogg")
+	for _, path := range []string{alarm, chime} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	c := NewChime(Sounds{Default: alarm, Profiles: map[string]string{"break": chime}})
+	var played []string
+	c.play = func(file string) error {
+		played = append(played, file)
+		return nil
+	}
+
+	events := []focotimer.Event{
+		{Kind: focotimer.EventStarted, Label: "work"},
+		{Kind: focotimer.EventCompleted, Label: "work"},
+		{Kind: focotimer.EventStarted, Label: "break"},
+		{Kind: focotimer.EventStopped, Label: "break"},
+		{Kind: focotimer.EventCompleted, Label: "break"},
+	}
+	for _, ev := range events {
+		if err := c.OnEvent(ev); err != nil {
+			t.Fatalf("OnEvent failed: %v", err)
+		}
+	}
+	if len(played) != 2 || played[0] != alarm || played[1] != chime {
+		t.Errorf("Expected the alarm then the chime, got %v", played)
+	}
+
+	quiet := true
+	c.MuteWhile(func() bool { return quiet })
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, Label: "work"})
+	if len(played) != 2 {
+		t.Errorf("Expected no sound while muted, got %v", played)
+	}
+	quiet = false
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, Label: "work"})
+	if len(played) != 3 {
+		t.Errorf("Expected the alarm once unmuted, got %v", played)
+	}
+}
+
+func TestCountdown(t *testing.T) {
+	var played []float64
+	c := NewCountdown("tick.ogg", 0.3, 3*time.Second)
+	c.play = func(file string, volume float64) error {
+		if file != "tick.ogg" {
+			t.Errorf("Expected the tick file, got %q", file)
+		}
+		played = append(played, volume)
+		return nil
+	}
+	follow := func(remaining ...time.Duration) int {
+		before := len(played)
+		ticks := make(chan time.Duration, len(remaining))
+		for _, r := range remaining {
+			ticks <- r
+		}
+		close(ticks)
+		c.FollowTicks(ticks)
+		return len(played) - before
+	}
+	ms := time.Millisecond
+
+	if n := follow(2500 * ms); n != 0 {
+		t.Errorf("Expected no ticks before the session starts, got %d", n)
+	}
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventStarted})
+	if n := follow(5*time.Second, 3250*ms, 3*time.Second, 2750*ms, 2500*ms, 2*time.Second, 1250*ms, 500*ms, 0); n != 3 {
+		t.Errorf("Expected one tick in each of the last 3 seconds, got %d", n)
+	}
+	if played[0] != 0.3 {
+		t.Errorf("Expected the tick volume 0.3, got %v", played[0])
+	}
+
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventReset})
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventStarted})
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventPaused})
+	if n := follow(2 * time.Second); n != 0 {
+		t.Errorf("Expected a paused session not to tick, got %d", n)
+	}
+	c.OnEvent(focotimer.Event{Kind: focotimer.EventResumed})
+	c.MuteWhile(func() bool { return true })
+	if n := follow(1500 * ms); n != 0 {
+		t.Errorf("Expected no ticks while muted, got %d", n)
+	}
+	c.MuteWhile(nil)
+	if n := follow(1250*ms, 900*ms); n != 1 {
+		t.Errorf("Expected the second after a muted one to tick, got %d", n)
+	}
+}
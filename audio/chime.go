@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Completion sounds -------------------
+
+// Sounds picks the sound played when a session completes. A phase sound
+// wins over a profile sound, which wins over Default, so a break can end
+// with a chime while focus sessions end with an alarm.
+type Sounds struct {
+	Default string
+	// Profiles maps a session label, such as a sequence interval name
+	// ("work", "break") or the label of a custom command, to a file.
+	Profiles map[string]string
+	// Phases maps the sub-phase of an interval ("stretch") to a file.
+	Phases map[string]string
+}
+
+// Resolve returns the file for a session, skipping files that do not
+// exist. It is empty when there is nothing to play.
+func (s Sounds) Resolve(label, phase string) string {
+	candidates := []string{s.Phases[phase], s.Profiles[label], s.Default}
+	if phase == "" {
+		candidates[0] = ""
+	}
+	if label == "" {
+		candidates[1] = ""
+	}
+	for _, file := range candidates {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			log.Printf("audio.Sounds: %v, falling back", err)
+			continue
+		}
+		return file
+	}
+	return ""
+}
+
+// Chime plays the resolved sound once whenever a session completes.
+type Chime struct {
+	sounds Sounds
+	play   func(file string) error
+	quiet  func() bool
+}
+
+func NewChime(sounds Sounds) *Chime {
+	return &Chime{sounds: sounds, play: PlayOnce}
+}
+
+// MuteWhile silences the chime whenever quiet returns true, e.g. during a
+// call.
+func (c *Chime) MuteWhile(quiet func() bool) {
+	c.quiet = quiet
+}
+
+func (c *Chime) Name() string { return "chime" }
+
+func (c *Chime) OnEvent(ev focotimer.Event) error {
+	if ev.Kind != focotimer.EventCompleted {
+		return nil
+	}
+	if c.quiet != nil && c.quiet() {
+		return nil
+	}
+	file := c.sounds.Resolve(ev.Label, ev.Phase)
+	if file == "" {
+		return nil
+	}
+	return c.play(file)
+}
+
+// PlayOnce plays file with mpv in the background.
+func PlayOnce(file string) error { return PlayAt(file, 1) }
+
+// PlayAt plays file once at volume, from 0 to 1, in the background.
+func PlayAt(file string, volume float64) error {
+	volume = min(max(volume, 0), 1)
+	cmd := exec.Command("mpv", "--no-video", "--really-quiet",
+		"--volume="+strconv.Itoa(int(volume*100)), file)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("audio: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Final countdown -------------------
+
+// Countdown plays a short tick once a second over the final seconds of a
+// running session, so the end can be heard coming without looking.
+type Countdown struct {
+	file   string
+	volume float64
+	last   time.Duration
+	play   func(file string, volume float64) error
+	quiet  func() bool
+
+	mu      sync.Mutex
+	running bool
+	ticked  int // the second last ticked, so each ticks once
+}
+
+// NewCountdown ticks file at volume (0 to 1) over the last `last` of each
+// session.
+func NewCountdown(file string, volume float64, last time.Duration) *Countdown {
+	return &Countdown{file: file, volume: volume, last: last, play: PlayAt}
+}
+
+// MuteWhile silences the ticks whenever quiet returns true, e.g. during a
+// call.
+func (c *Countdown) MuteWhile(quiet func() bool) {
+	c.quiet = quiet
+}
+
+func (c *Countdown) Name() string { return "countdown" }
+
+// OnEvent tracks whether the session runs; a paused or finished one does
+// not tick.
+func (c *Countdown) OnEvent(ev focotimer.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev.Kind {
+	case focotimer.EventStarted, focotimer.EventResumed:
+		c.running = true
+	case focotimer.EventPaused, focotimer.EventStopped, focotimer.EventCompleted,
+		focotimer.EventReset, focotimer.EventAutoReset:
+		c.running = false
+		c.ticked = 0
+	}
+	return nil
+}
+
+// FollowTicks ticks from the manager's remaining-time ticks until the
+// channel is closed. They should come more often than once a second.
+func (c *Countdown) FollowTicks(ticks <-chan time.Duration) {
+	for remaining := range ticks {
+		if c.due(remaining) && (c.quiet == nil || !c.quiet()) {
+			c.play(c.file, c.volume)
+		}
+	}
+}
+
+// due reports whether remaining is in a final second that has not ticked
+// yet.
+func (c *Countdown) due(remaining time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running || remaining <= 0 || remaining > c.last {
+		return false
+	}
+	second := int((remaining + time.Second - 1) / time.Second)
+	if second == c.ticked {
+		return false
+	}
+	c.ticked = second
+	return true
+}
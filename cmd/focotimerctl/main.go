@@ -0,0 +1,244 @@
+// Command focotimerctl drives a running focotimer over its control socket,
+// for scripts and keybindings:
+//
+//	focotimerctl start
+//	focotimerctl set 25m
+//	focotimerctl status --json
+//	focotimerctl watch
+//
+// Commands other than status and watch are passed to the timer as they
+// are, so aliases and custom commands from the config work too.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/ipc"
+)
+
+const usage = `usage: focotimerctl [-socket path] <command> [args]
+
+commands:
+  start [--issue <ref>] [--energy <1-5>]
+  stop, pause, resume, toggle, reset, skip, inc, dec
+  set <duration>        e.g. set 25m
+  label <text>
+  energy <1-5>          rate your energy before or after a session
+  gui                   open or close the window of a focotimer -daemon
+  open <page>           show a page in the window, e.g. stats or
+                        focotimer://timeline/2025-09-02
+  refresh               send the bar output again, e.g. from a polybar
+                        custom/ipc hook
+  path                  print the command FIFO of the bar module, for
+                        scripts writing to it
+  status [--json]       print the current state
+  status --prompt       print a short colored summary for a shell prompt
+                        or starship; it exits 0 during focus, 3 paused,
+                        4 on a break, 5 completed and 6 idle
+  watch [--json]        print events until interrupted
+  <any other command>   passed to the timer, e.g. "queue add 4x25m report"
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("focotimerctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	socket := fs.String("socket", ipc.DefaultSocket(), "control socket of the running focotimer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	client := ipc.NewClient(*socket)
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	var err error
+	switch cmd {
+	case "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	case "status":
+		var code int
+		if code, err = status(ctx, client, rest, stdout); err == nil {
+			return code
+		}
+	case "watch":
+		err = watch(ctx, client, rest, stdout)
+	case "path":
+		var path string
+		if path, err = polybar.Discover(); err == nil {
+			fmt.Fprintln(stdout, path)
+		}
+	default:
+		_, err = client.Command(ctx, strings.Join(fs.Args(), " "))
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "focotimerctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// jsonFlag parses the --json flag of status and watch.
+func jsonFlag(name string, args []string) (bool, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := fs.Parse(args); err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	if fs.NArg() > 0 {
+		return false, fmt.Errorf("%s: unexpected argument %q", name, fs.Arg(0))
+	}
+	return *asJSON, nil
+}
+
+func status(ctx context.Context, client *ipc.Client, args []string, w io.Writer) (int, error) {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print JSON")
+	prompt := fs.Bool("prompt", false, "print a prompt segment")
+	if err := fs.Parse(args); err != nil {
+		return 0, fmt.Errorf("status: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return 0, fmt.Errorf("status: unexpected argument %q", fs.Arg(0))
+	}
+	s, err := client.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case *asJSON:
+		return 0, json.NewEncoder(w).Encode(s)
+	case *prompt:
+		line, code := formatPrompt(s, os.Getenv("NO_COLOR") == "")
+		if line != "" {
+			_, err = fmt.Fprintln(w, line)
+		}
+		return code, err
+	}
+	_, err = fmt.Fprintln(w, formatState(s))
+	return 0, err
+}
+
+func watch(ctx context.Context, client *ipc.Client, args []string, w io.Writer) error {
+	asJSON, err := jsonFlag("watch", args)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	return client.Watch(ctx, func(ev focotimer.Event) error {
+		if asJSON {
+			return enc.Encode(ev)
+		}
+		_, err := fmt.Fprintln(w, formatEvent(ev))
+		return err
+	})
+}
+
+// formatState renders a state as "running 24m12s of 25m0s (writing)".
+func formatState(s focotimer.State) string {
+	line := fmt.Sprintf("%s %s of %s", s.Status, s.Remaining.Truncate(time.Second), s.Duration.Truncate(time.Second))
+	if activity := activity(s.Label, s.Phase); activity != "" {
+		line += " (" + activity + ")"
+	}
+	if s.QueueLength > 0 {
+		line += fmt.Sprintf(" [%d/%d]", s.QueuePosition, s.QueueLength)
+	}
+	return line
+}
+
+// Exit codes of "status --prompt", so prompts can tell phases apart
+// without parsing.
+const (
+	promptFocus     = 0
+	promptPaused    = 3
+	promptBreak     = 4
+	promptCompleted = 5
+	promptIdle      = 6
+)
+
+// formatPrompt renders a state as "▶ 24m writing", in ANSI colors when
+// color is set, and returns its exit code. An idle timer prints nothing.
+func formatPrompt(s focotimer.State, color bool) (string, int) {
+	brk := s.Break
+	var symbol, sgr string
+	code := promptFocus
+	switch {
+	case s.Status == focotimer.StatusPaused:
+		symbol, sgr, code = "⏸", "90", promptPaused
+	case s.Status == focotimer.StatusCompleted:
+		return paint("✔ done", "1;31", color), promptCompleted
+	case s.Status != focotimer.StatusRunning:
+		return "", promptIdle
+	case brk:
+		symbol, sgr, code = "☕", "32", promptBreak
+	default:
+		symbol, sgr = "▶", "33"
+	}
+	if s.Status == focotimer.StatusRunning && s.Remaining <= time.Minute {
+		sgr = "31"
+	}
+	// round up so the last seconds still read 1m
+	minutes := (max(s.Remaining, 0) + time.Minute - 1) / time.Minute
+	line := fmt.Sprintf("%s %dm", symbol, minutes)
+	a := activity(s.Label, s.Phase)
+	if brk {
+		// the cup says it is a break
+		a = s.Phase
+	}
+	if a != "" {
+		line += " " + a
+	}
+	return paint(line, sgr, color), code
+}
+
+func paint(text, sgr string, color bool) string {
+	if !color {
+		return text
+	}
+	return "\x1b[" + sgr + "m" + text + "\x1b[0m"
+}
+
+// formatEvent renders an event as "15:04:05 started 25m0s (writing)".
+func formatEvent(ev focotimer.Event) string {
+	line := fmt.Sprintf("%s %s %s", ev.At.Format(time.TimeOnly), ev.Kind, ev.Remaining.Truncate(time.Second))
+	if activity := activity(ev.Label, ev.Phase); activity != "" {
+		line += " (" + activity + ")"
+	}
+	return line
+}
+
+func activity(label, phase string) string {
+	switch {
+	case label == "":
+		return phase
+	case phase == "":
+		return label
+	}
+	return label + ": " + phase
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/ipc"
+)
+
+func serve(t *testing.T) (*focotimer.TimerManager, string) {
+	t.Helper()
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	obs := ipc.NewObserver(tm, 50*time.Millisecond)
+	t.Cleanup(func() { obs.Close() })
+	api := ipc.NewAPI(tm, focotimer.NewDispatcher(tm))
+	api.Stream(obs)
+	socket := filepath.Join(t.TempDir(), "run", "ctl.sock")
+	if err := api.ListenUnix(socket); err != nil {
+		t.Fatalf("ListenUnix failed: %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+	return tm, socket
+}
+
+func ctl(t *testing.T, socket string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	code := run(ctx, append([]string{"-socket", socket}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Commands(t *testing.T) {
+	tm, socket := serve(t)
+
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"status"}, 0, "idle 25m0s of 25m0s\n", ""},
+		{[]string{"set", "10m"}, 0, "", ""},
+		{[]string{"label", "writing"}, 0, "", ""},
+		{[]string{"start"}, 0, "", ""},
+		{[]string{"pause"}, 0, "", ""},
+		{[]string{"status"}, 0, "paused ", ""},
+		{[]string{"launch"}, 1, "", "unknown command"},
+		{[]string{"set", "soon"}, 1, "", "focotimerctl: "},
+		{[]string{"status", "--yaml"}, 1, "", "status: "},
+		{[]string{}, 2, "", "usage:"},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := ctl(t, socket, tt.args...)
+		if code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d (%q)", tt.args, tt.code, code, stderr)
+		}
+		if !strings.HasPrefix(stdout, tt.stdout) {
+			t.Errorf("%v: expected stdout to start with %q, got %q", tt.args, tt.stdout, stdout)
+		}
+		if !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("%v: expected stderr to contain %q, got %q", tt.args, tt.stderr, stderr)
+		}
+	}
+
+	s := tm.State()
+	if s.Status != focotimer.StatusPaused || s.Duration != 10*time.Minute || s.Label != "writing" {
+		t.Errorf("Expected a paused 10m session labelled writing, got %+v", s)
+	}
+
+	_, stdout, _ := ctl(t, socket, "status", "--json")
+	var got focotimer.State
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("Failed to decode status --json %q: %v", stdout, err)
+	}
+	if got.Status != focotimer.StatusPaused || got.Label != "writing" {
+		t.Errorf("Unexpected JSON status %+v", got)
+	}
+}
+
+func TestRun_Prompt(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	tm, socket := serve(t)
+
+	if code, stdout, _ := ctl(t, socket, "status", "--prompt"); code != promptIdle || stdout != "" {
+		t.Errorf("Expected an empty idle prompt, got %d %q", code, stdout)
+	}
+	tm.SetLabel("writing")
+	tm.Start()
+	if code, stdout, _ := ctl(t, socket, "status", "--prompt"); code != promptFocus || stdout != "▶ 25m writing\n" {
+		t.Errorf("Expected a focus prompt, got %d %q", code, stdout)
+	}
+	tm.Pause()
+	if code, stdout, _ := ctl(t, socket, "status", "--prompt"); code != promptPaused || stdout != "⏸ 25m writing\n" {
+		t.Errorf("Expected a paused prompt, got %d %q", code, stdout)
+	}
+}
+
+func TestRun_Path(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	socket := filepath.Join(t.TempDir(), "ctl.sock")
+	if code, _, stderr := ctl(t, socket, "path"); code != 1 || !strings.Contains(stderr, "advertised") {
+		t.Errorf("Expected an error without a running focotimer, got %d %q", code, stderr)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(polybar.DiscoveryFile()), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(polybar.DiscoveryFile(), []byte("/run/user/1000/focotimer/cmd.fifo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, _ := ctl(t, socket, "path"); code != 0 || stdout != "/run/user/1000/focotimer/cmd.fifo\n" {
+		t.Errorf("Expected the advertised path, got %d %q", code, stdout)
+	}
+}
+
+func TestRun_NotRunning(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ctl.sock")
+	code, _, stderr := ctl(t, socket, "start")
+	if code != 1 || !strings.Contains(stderr, "not running") {
+		t.Errorf("Expected a not-running error, got %d %q", code, stderr)
+	}
+}
+
+func TestRun_Watch(t *testing.T) {
+	tm, socket := serve(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, w := io.Pipe()
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"-socket", socket, "watch", "--json"}, w, io.Discard)
+		w.Close()
+	}()
+
+	sc := bufio.NewScanner(r)
+	started := false
+	for sc.Scan() {
+		var ev focotimer.Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", sc.Text(), err)
+		}
+		// ticks show the stream is live
+		if ev.Kind == focotimer.EventTick && !started {
+			started = true
+			tm.Start()
+		}
+		if ev.Kind == focotimer.EventStarted {
+			cancel()
+			break
+		}
+	}
+	go io.Copy(io.Discard, r)
+	if code := <-done; code != 0 {
+		t.Errorf("Expected watch to exit cleanly when interrupted, got %d", code)
+	}
+}
+
+func TestFormatPrompt(t *testing.T) {
+	tests := []struct {
+		state focotimer.State
+		color bool
+		want  string
+		code  int
+	}{
+		{focotimer.State{Status: focotimer.StatusIdle, Remaining: 25 * time.Minute}, true, "", promptIdle},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 90 * time.Second, Label: "writing"}, false, "▶ 2m writing", promptFocus},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 5 * time.Minute, Label: "writing"}, true, "\x1b[33m▶ 5m writing\x1b[0m", promptFocus},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 30 * time.Second}, true, "\x1b[31m▶ 1m\x1b[0m", promptFocus},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 5 * time.Minute, Label: "fix-line-breaks"}, false, "▶ 5m fix-line-breaks", promptFocus},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 4 * time.Minute, Label: "break", Break: true, Phase: "stretch"}, true, "\x1b[32m☕ 4m stretch\x1b[0m", promptBreak},
+		{focotimer.State{Status: focotimer.StatusPaused, Remaining: 4 * time.Minute, Label: "break", Break: true}, false, "⏸ 4m", promptPaused},
+		{focotimer.State{Status: focotimer.StatusCompleted}, true, "\x1b[1;31m✔ done\x1b[0m", promptCompleted},
+	}
+	for _, tt := range tests {
+		got, code := formatPrompt(tt.state, tt.color)
+		if got != tt.want || code != tt.code {
+			t.Errorf("formatPrompt(%s %q) = %q, %d, expected %q, %d", tt.state.Status, tt.state.Label, got, code, tt.want, tt.code)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	s := focotimer.State{
+		Status:        focotimer.StatusRunning,
+		Duration:      25 * time.Minute,
+		Remaining:     24*time.Minute + 12500*time.Millisecond,
+		Label:         "break",
+		Phase:         "stretch",
+		QueuePosition: 2,
+		QueueLength:   4,
+	}
+	if got, want := formatState(s), "running 24m12s of 25m0s (break: stretch) [2/4]"; got != want {
+		t.Errorf("formatState = %q, expected %q", got, want)
+	}
+
+	ev := focotimer.Event{
+		Kind:      focotimer.EventStarted,
+		At:        time.Date(2025, 9, 1, 15, 4, 5, 0, time.Local),
+		Remaining: 25 * time.Minute,
+		Label:     "writing",
+	}
+	if got, want := formatEvent(ev), "15:04:05 started 25m0s (writing)"; got != want {
+		t.Errorf("formatEvent = %q, expected %q", got, want)
+	}
+}
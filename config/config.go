@@ -0,0 +1,579 @@
+// Package config loads the user configuration shared by all focotimer
+// frontends.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+type Config struct {
+	// Commands maps a user-defined command name to the sequence of
+	// commands it expands to, e.g. "coffee": ["set 5m", "label coffee", "start"].
+	Commands map[string][]string `json:"commands,omitempty"`
+	// Aliases maps an extra command name to an existing command, e.g.
+	// "pp": "toggle", so existing keybindings keep working.
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Sequences maps a name to a programmable list of intervals that the
+	// "cycle <name>" command runs, e.g. work 52m / break 17m repeated 3 times.
+	Sequences map[string]Sequence `json:"sequences,omitempty"`
+	// Git labels sessions after the repository being worked on.
+	Git GitConfig `json:"git,omitempty"`
+	// Issues reports sessions started with an issue reference back to the
+	// tracker.
+	Issues IssuesConfig `json:"issues,omitempty"`
+	// Ambient plays a looping sound file during sessions.
+	Ambient AmbientConfig `json:"ambient,omitempty"`
+	// Sounds are played when a session completes.
+	Sounds SoundsConfig `json:"sounds,omitempty"`
+	// Notifications shows a desktop notification when a session or a
+	// break completes.
+	Notifications NotificationsConfig `json:"notifications,omitempty"`
+	// Reminders mark points of a running session, e.g. halfway through or
+	// five minutes before the end, with a quiet notification or a sound.
+	Reminders RemindersConfig `json:"reminders,omitempty"`
+	// Calls pauses focus sessions or silences sounds during audio and
+	// video calls.
+	Calls CallsConfig `json:"calls,omitempty"`
+	// Breaks escalates breaks the user keeps working through.
+	Breaks BreaksConfig `json:"breaks,omitempty"`
+	// Goal is the daily target of completed focus sessions shown by
+	// "focotimer stats".
+	Goal GoalConfig `json:"goal,omitempty"`
+	// Triggers are sessions that external systems, such as calendar
+	// automation or CI, start through the HTTP API's /trigger endpoint.
+	Triggers TriggersConfig `json:"triggers,omitempty"`
+	// Hooks run shell commands on session events.
+	Hooks HooksConfig `json:"hooks,omitempty"`
+	// Webhooks post session events to URLs, for IFTTT, Zapier, n8n and
+	// other automation.
+	Webhooks WebhooksConfig `json:"webhooks,omitempty"`
+	// MQTT publishes the timer to an MQTT broker and takes commands from
+	// it, for Home Assistant and other home automation.
+	MQTT MQTTConfig `json:"mqtt,omitempty"`
+	// Digest delivers the focus report of each finished week or month.
+	Digest DigestConfig `json:"digest,omitempty"`
+	// Bindings maps key chords ("Ctrl+Shift+P") to commands, in the window
+	// and system-wide. They are applied again whenever the file changes.
+	Bindings BindingsConfig `json:"bindings,omitempty"`
+	Polybar  PolybarConfig  `json:"polybar,omitempty"`
+	// RootName keeps the timer in the X root window name for dwm.
+	RootName RootNameConfig `json:"root_name,omitempty"`
+	// Tray shows the timer in the system tray, with a menu to control it.
+	Tray TrayConfig `json:"tray,omitempty"`
+	// Window places the window, which otherwise opens where it was last
+	// closed.
+	Window WindowConfig `json:"window,omitempty"`
+	// Theme colors the window.
+	Theme ThemeConfig `json:"theme,omitempty"`
+	// Countdown sets how the remaining time is shown in the window, and
+	// how the ring around it sets the duration.
+	Countdown CountdownConfig `json:"countdown,omitempty"`
+}
+
+type PolybarConfig struct {
+	// Clicks maps a mouse button on the timer ("left", "middle", "right",
+	// "scroll_up", "scroll_down") to a command, replacing the defaults.
+	Clicks map[string]string `json:"clicks,omitempty"`
+	// SegmentClicks does the same for each part of the output: "timer"
+	// (like Clicks), and the "dec" and "inc" buttons, which default to a
+	// left click running their own command.
+	SegmentClicks map[string]map[string]string `json:"segment_clicks,omitempty"`
+	// Format is the output markup: "polybar" (default), "plain", "tmux",
+	// "i3blocks" or "i3status-rs".
+	Format string `json:"format,omitempty"`
+	// Colors colors the timer by state ("running", "break", "paused",
+	// "completed", "final" for the last minute) with "#RRGGBB" values.
+	// Present but empty, it uses the GUI's colors.
+	Colors map[string]string `json:"colors,omitempty"`
+	// ColorThreshold is how much time left shows the "final" color.
+	ColorThreshold Duration `json:"color_threshold,omitempty"`
+	// Flash makes a completed timer blink until acknowledged; it turns
+	// the colors on.
+	Flash bool `json:"flash,omitempty"`
+	// Variant is the fullest output: "long" (default), "medium" without
+	// the total, or "short" with just an icon and the minutes left.
+	Variant string `json:"variant,omitempty"`
+	// MaxWidth, in characters, makes the timer drop parts until it fits
+	// the bar; zero leaves it unbounded.
+	MaxWidth int `json:"max_width,omitempty"`
+	// Icon leads the short output.
+	Icon string `json:"icon,omitempty"`
+	// IPCModule pushes the output to this custom/ipc module with
+	// polybar-msg instead of printing it.
+	IPCModule string `json:"ipc_module,omitempty"`
+	// Interval is how often the output is rendered; defaults to 1s. A
+	// line is only printed when it changed.
+	Interval Duration `json:"interval,omitempty"`
+	// Icons puts an icon by phase (work, break, paused) before the timer
+	// and on the buttons: "nerd" for Nerd Font glyphs or "ascii".
+	Icons string `json:"icons,omitempty"`
+	// Template lays the output out as a Go template instead, e.g.
+	// "{{button \"[-]\" \"dec\"}}{{.Remaining}} / {{.Duration}} {{.Phase}}";
+	// see polybar.TemplateData for the fields.
+	Template string `json:"template,omitempty"`
+}
+
+type RootNameConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Prefix and Suffix surround the timer, e.g. "[" and "] ".
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+	// Command gets the text as its last argument; defaults to
+	// ["xsetroot", "-name"].
+	Command []string `json:"command,omitempty"`
+}
+
+type TrayConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+type WindowConfig struct {
+	// Geometry is like the -geometry flag, e.g. "300x300+20+20"; the flag
+	// wins over it.
+	Geometry string `json:"geometry,omitempty"`
+	// Corner pins the window to "top-left", "top-right", "bottom-left"
+	// or "bottom-right", Margin pixels from the edges of the screen.
+	Corner string `json:"corner,omitempty"`
+	Margin int    `json:"margin,omitempty"`
+	// Monitor is the monitor the corner and the geometry count from, and
+	// the one the break overlay covers: "primary", its number from 0 or
+	// its output name, e.g. "DP-1". Without a corner or a geometry the
+	// window opens in its middle.
+	Monitor string `json:"monitor,omitempty"`
+	// Close is what the close key, Escape unless rebound, does: "close"
+	// (default) closes the window while focotimer runs on, "tray" closes
+	// it into the tray, showing the tray icon if it is not shown, and
+	// "confirm" asks for it twice in a row while a session is running or
+	// paused.
+	Close string `json:"close,omitempty"`
+	// OnTop keeps the window above other windows and Sticky shows it on
+	// every workspace, for a floating focus widget.
+	OnTop  bool `json:"on_top,omitempty"`
+	Sticky bool `json:"sticky,omitempty"`
+}
+
+type ThemeConfig struct {
+	// Preset is the built-in theme to start from: "dark", "light" or
+	// "system" (default), which follows the desktop's preference.
+	Preset string `json:"preset,omitempty"`
+	// Colors override colors of the preset by name: "background",
+	// "surface", "text", "muted", "accent", "button", "button_text",
+	// "alert", "ring_start" and "ring_end", as "#RRGGBB" or "#RRGGBBAA".
+	Colors map[string]string `json:"colors,omitempty"`
+	// Phases override colors further while a focus session ("focus") or
+	// a break ("break") is on screen, by the same names. "warning" is
+	// what the last minute of a focus session shifts toward.
+	Phases map[string]map[string]string `json:"phases,omitempty"`
+	// Opacity is how opaque the window's background is, from 0 to 1, fully
+	// opaque when unset; the text, ring and buttons stay opaque.
+	Opacity float32 `json:"opacity,omitempty"`
+	// Image is a PNG or JPEG file covering the background, drawn over the
+	// background color at the same opacity.
+	Image string `json:"image,omitempty"`
+	// Blur asks the compositor to blur what is behind the window as it
+	// opens, where it supports it: KWin, and picom with blur, on X11.
+	Blur bool `json:"blur,omitempty"`
+}
+
+type CountdownConfig struct {
+	// Font is a font file (.ttf, .otf or .ttc) or the family of an
+	// installed font, e.g. "JetBrains Mono"; empty keeps the default.
+	Font string `json:"font,omitempty"`
+	// Size is the text size in sp, 48 by default, and MiniSize the size
+	// in a window too narrow for it, 32 by default.
+	Size     float32 `json:"size,omitempty"`
+	MiniSize float32 `json:"mini_size,omitempty"`
+	// Ring is "gradient", the default, or "arc" for a smooth arc.
+	Ring string `json:"ring,omitempty"`
+	// RingDiameter and RingThickness size the ring in dp, 200 and 10
+	// by default.
+	RingDiameter  float32 `json:"ring_diameter,omitempty"`
+	RingThickness float32 `json:"ring_thickness,omitempty"`
+	// Direction the ring fills in from the top: "clockwise", the
+	// default, or "counterclockwise".
+	Direction string `json:"direction,omitempty"`
+	// RoundCaps rounds the ends of the progress.
+	RoundCaps bool `json:"round_caps,omitempty"`
+	// ScrollStep is the time each notch scrolled over the ring adds or
+	// takes off, 1m by default.
+	ScrollStep Duration `json:"scroll_step,omitempty"`
+	// DragTurn is the duration set by dragging once around the idle
+	// ring, 60m by default.
+	DragTurn Duration `json:"drag_turn,omitempty"`
+}
+
+type BindingsConfig struct {
+	// GUI replaces the default window bindings when set. Escape runs
+	// "close" unless it is bound to another command or "close" to another
+	// key, e.g. "Ctrl+W": "close"; window.close says what closing does.
+	GUI map[string]string `json:"gui,omitempty"`
+	// Global hotkeys work while the window is unfocused or closed, e.g.
+	// "Ctrl+Alt+P": "toggle" or "Ctrl+Alt+G": "gui" to show or hide it.
+	Global map[string]string `json:"global,omitempty"`
+}
+
+type HooksConfig struct {
+	// Each runs through sh, e.g. "playerctl pause" or
+	// "~/.config/focotimer/hooks/break.sh". Breaks are sessions labelled
+	// as such.
+	OnWorkStart  string `json:"on_work_start,omitempty"`
+	OnWorkEnd    string `json:"on_work_end,omitempty"`
+	OnBreakStart string `json:"on_break_start,omitempty"`
+	OnBreakEnd   string `json:"on_break_end,omitempty"`
+	OnPause      string `json:"on_pause,omitempty"`
+	OnResume     string `json:"on_resume,omitempty"`
+	// Timeout kills a command running longer; defaults to 30s.
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+type WebhooksConfig struct {
+	Hooks []WebhookConfig `json:"hooks,omitempty"`
+	// Timeout bounds each request; defaults to 10s.
+	Timeout Duration `json:"timeout,omitempty"`
+	// Retries after a failed request, with a doubling delay; defaults
+	// to 3.
+	Retries int `json:"retries,omitempty"`
+}
+
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Events are some of "started", "completed" and "aborted"; empty is
+	// all of them.
+	Events  []string          `json:"events,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type MQTTConfig struct {
+	// Broker is "host:port" or "tls://host:port"; empty disables MQTT.
+	Broker   string `json:"broker,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Topics replace the defaults, "focotimer/state" and so on.
+	Topics MQTTTopicsConfig `json:"topics,omitempty"`
+	// Discovery is the Home Assistant discovery prefix, usually
+	// "homeassistant"; set, the timer shows up in Home Assistant on its own.
+	Discovery string `json:"discovery,omitempty"`
+}
+
+type MQTTTopicsConfig struct {
+	State        string `json:"state,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Remaining    string `json:"remaining,omitempty"`
+	Event        string `json:"event,omitempty"`
+	Command      string `json:"command,omitempty"`
+	Availability string `json:"availability,omitempty"`
+}
+
+type DigestConfig struct {
+	// Period is "weekly" or "monthly"; empty disables the digest.
+	Period string `json:"period,omitempty"`
+	// Path is the file the CSV report is written to; "{from}" and "{to}"
+	// are replaced by the period's dates.
+	Path string `json:"path,omitempty"`
+	// Command gets the report on stdin, e.g. "msmtp me@example.com".
+	Command string `json:"command,omitempty"`
+}
+
+type AmbientConfig struct {
+	File string `json:"file,omitempty"`
+	// Volume from 0 to 1; defaults to 1.
+	Volume float64 `json:"volume,omitempty"`
+	// FadeOver is how long before the end the sound fades out; defaults
+	// to 30s, "0s" disables the fade.
+	FadeOver *Duration `json:"fade_over,omitempty"`
+}
+
+type SoundsConfig struct {
+	// Default is played when no more specific sound applies.
+	Default string `json:"default,omitempty"`
+	// Profiles maps a session label, e.g. a sequence interval name such as
+	// "break", to its completion sound.
+	Profiles map[string]string `json:"profiles,omitempty"`
+	// Phases maps an interval phase name to its completion sound.
+	Phases map[string]string `json:"phases,omitempty"`
+	// Tick plays a short sound every second over the final seconds of a
+	// session.
+	Tick TickConfig `json:"tick,omitempty"`
+}
+
+type TickConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	File    string `json:"file,omitempty"`
+	// Volume from 0 to 1; defaults to 0.3.
+	Volume float64 `json:"volume,omitempty"`
+	// Last is how long before the end the ticking starts; defaults to
+	// 10s.
+	Last Duration `json:"last,omitempty"`
+}
+
+type NotificationsConfig struct {
+	Enabled bool               `json:"enabled,omitempty"`
+	Work    NotificationConfig `json:"work,omitempty"`
+	Break   NotificationConfig `json:"break,omitempty"`
+}
+
+type NotificationConfig struct {
+	// Title and Body may use "{label}" and "{duration}"; an empty title
+	// keeps the default text.
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// Urgency is "low", "normal" (default) or "critical".
+	Urgency string `json:"urgency,omitempty"`
+	// Actions replace the default buttons, e.g. {"label": "+10 min",
+	// "commands": ["set 10m", "start"]}; present but empty, there are
+	// none. Commands may use the "{label}" and "{duration}" of the last
+	// work session.
+	Actions []NotificationAction `json:"actions,omitempty"`
+}
+
+type NotificationAction struct {
+	Label    string   `json:"label"`
+	Commands []string `json:"commands"`
+}
+
+type RemindersConfig struct {
+	// At lists the reminder points: a share of the session elapsed
+	// ("50%") or the time left ("5m").
+	At []string `json:"at,omitempty"`
+	// Notify shows a low urgency notification at each point.
+	Notify bool `json:"notify,omitempty"`
+	// Sound is played at each point.
+	Sound string `json:"sound,omitempty"`
+	// Volume from 0 to 1; defaults to 0.5.
+	Volume float64 `json:"volume,omitempty"`
+}
+
+type CallsConfig struct {
+	// Action is "pause" to pause focus sessions for the length of a call,
+	// or "mute" to only silence completion sounds; empty disables call
+	// detection.
+	Action string `json:"action,omitempty"`
+	// Apps are the applications whose microphone use counts as a call;
+	// defaults to common meeting apps and browsers.
+	Apps []string `json:"apps,omitempty"`
+	// Processes count as a call whenever one of them runs, e.g. "zoom".
+	Processes []string `json:"processes,omitempty"`
+	// Interval between checks; defaults to 5s.
+	Interval Duration `json:"interval,omitempty"`
+}
+
+type IssuesConfig struct {
+	GitHub GitHubConfig `json:"github,omitempty"`
+	Jira   JiraConfig   `json:"jira,omitempty"`
+}
+
+type GitHubConfig struct {
+	Token string `json:"token,omitempty"`
+	// Repo ("owner/name") resolves short references such as "GH-123".
+	Repo    string `json:"repo,omitempty"`
+	APIURL  string `json:"api_url,omitempty"`
+	Comment bool   `json:"comment,omitempty"`
+}
+
+type JiraConfig struct {
+	URL     string `json:"url,omitempty"`
+	User    string `json:"user,omitempty"`
+	Token   string `json:"token,omitempty"`
+	Worklog bool   `json:"worklog,omitempty"`
+}
+
+type GoalConfig struct {
+	Daily int `json:"daily,omitempty"`
+	// RequireBreaks counts only sessions whose following break was taken.
+	RequireBreaks bool `json:"require_breaks,omitempty"`
+}
+
+type BreaksConfig struct {
+	// Notify, Overlay and Lock are how long input may continue into a
+	// break before a desktop notification, a reminder over the screen and
+	// a screen lock follow; each is off when unset.
+	Notify  Duration `json:"notify,omitempty"`
+	Overlay Duration `json:"overlay,omitempty"`
+	Lock    Duration `json:"lock,omitempty"`
+	// LockCommand replaces "loginctl lock-session".
+	LockCommand []string `json:"lock_command,omitempty"`
+	// Threshold is how recent input must be to count as activity;
+	// defaults to 15s.
+	Threshold Duration `json:"threshold,omitempty"`
+	// Interval between checks; defaults to 5s.
+	Interval Duration `json:"interval,omitempty"`
+}
+
+type TriggersConfig struct {
+	// Token authenticates webhook calls and every request that changes
+	// the timer through the HTTP API; without one the webhook is off and
+	// the HTTP API only serves reads.
+	Token string `json:"token,omitempty"`
+	// Profiles maps a name to the session it starts, e.g.
+	// "deep": {"duration": "50m", "label": "deep work"}.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+type Profile struct {
+	// Duration defaults to the current session length.
+	Duration Duration `json:"duration,omitempty"`
+	Label    string   `json:"label,omitempty"`
+	Issue    string   `json:"issue,omitempty"`
+}
+
+type GitConfig struct {
+	AutoLabel bool `json:"auto_label,omitempty"`
+	// Workspace is the directory whose repository names the session.
+	Workspace string `json:"workspace,omitempty"`
+	// FollowFocus uses the working directory of the focused terminal
+	// instead, falling back to Workspace.
+	FollowFocus bool `json:"follow_focus,omitempty"`
+}
+
+type Sequence struct {
+	Intervals []Interval `json:"intervals"`
+	// Repeat is how many times the intervals run; 0 loops until stopped.
+	Repeat int `json:"repeat,omitempty"`
+}
+
+// Interval is a named step of a sequence. An interval with phases, e.g. a
+// break made of stretch, water and breathing, takes its length from them.
+// Break makes it a break, which break enforcement, hooks and statistics
+// tell from work; the name does not.
+type Interval struct {
+	Name     string     `json:"name"`
+	Duration Duration   `json:"duration,omitempty"`
+	Phases   []Interval `json:"phases,omitempty"`
+	Break    bool       `json:"break,omitempty"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("52m").
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"25m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used when no config file exists.
+func Default() *Config {
+	return &Config{
+		Commands:  map[string][]string{},
+		Aliases:   map[string]string{},
+		Sequences: map[string]Sequence{},
+	}
+}
+
+// Dir returns the focotimer config directory, honouring XDG_CONFIG_HOME.
+func Dir() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "focotimer")
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "focotimer")
+}
+
+// DefaultPath returns the path of the default config file. FOCOTIMER_CONFIG
+// overrides it.
+func DefaultPath() string {
+	if p := os.Getenv("FOCOTIMER_CONFIG"); p != "" {
+		return p
+	}
+	return filepath.Join(Dir(), "config.json")
+}
+
+// Load reads the config at path. A missing file is not an error and yields
+// the defaults. A file that no longer parses falls back to the backup Save
+// kept, returned with an error wrapping safefile.ErrRecovered.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	data, err := file(path).Read()
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if errors.Is(err, safefile.ErrRecovered) {
+		// The backup parsed when it was read; report the damage but use it.
+		_ = json.Unmarshal(data, cfg)
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	if err != nil && !errors.Is(err, safefile.ErrDamaged) {
+		return cfg, fmt.Errorf("read config %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), fmt.Errorf("parse config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to path, creating its directory. The file is replaced
+// atomically so a watcher never reads it half-written, and the previous
+// version is kept as a backup Load falls back to.
+func Save(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := file(path).Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	return nil
+}
+
+// file is the config at path. It is not checksummed since people edit it
+// by hand; a version that does not parse counts as damaged.
+func file(path string) safefile.File {
+	return safefile.File{
+		Path: path,
+		Valid: func(data []byte) error {
+			return json.Unmarshal(data, Default())
+		},
+	}
+}
+
+// Watch reloads the config at path whenever it changes, checking every
+// interval until stop is closed, and calls fn with the result of Load.
+func Watch(path string, interval time.Duration, stop <-chan struct{}, fn func(*Config, error)) {
+	stamp := func() (time.Time, int64) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return fi.ModTime(), fi.Size()
+	}
+	lastMod, lastSize := stamp()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		mod, size := stamp()
+		if mod.Equal(lastMod) && size == lastSize {
+			continue
+		}
+		lastMod, lastSize = mod, size
+		fn(Load(path))
+	}
+}
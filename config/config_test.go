@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing config, got %v", err)
+	}
+	if cfg == nil || cfg.Commands == nil {
+		t.Fatal("Expected default config for missing file")
+	}
+}
+
+func TestLoad_Commands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"commands": {"coffee": ["set 5m", "label coffee", "start"]}, "aliases": {"pp": "toggle"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	steps := cfg.Commands["coffee"]
+	if len(steps) != 3 || steps[0] != "set 5m" || steps[2] != "start" {
+		t.Errorf("Expected coffee command steps, got %v", steps)
+	}
+	if cfg.Aliases["pp"] != "toggle" {
+		t.Errorf("Expected alias pp -> toggle, got %v", cfg.Aliases)
+	}
+}
+
+func TestLoad_Sequences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"sequences": {"52-17": {"intervals": [
+		{"name": "work", "duration": "52m"},
+		{"name": "break", "phases": [
+			{"name": "stretch", "duration": "2m"},
+			{"name": "water", "duration": "2m"}
+		]}
+	], "repeat": 3}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	seq, ok := cfg.Sequences["52-17"]
+	if !ok {
+		t.Fatal("Expected sequence 52-17 to be loaded")
+	}
+	if seq.Repeat != 3 || len(seq.Intervals) != 2 {
+		t.Fatalf("Expected 2 intervals repeated 3 times, got %+v", seq)
+	}
+	if seq.Intervals[0].Name != "work" || time.Duration(seq.Intervals[0].Duration) != 52*time.Minute {
+		t.Errorf("Expected work 52m, got %+v", seq.Intervals[0])
+	}
+	if phases := seq.Intervals[1].Phases; len(phases) != 2 || phases[1].Name != "water" {
+		t.Errorf("Expected break phases stretch and water, got %+v", phases)
+	}
+
+	bad := `{"sequences": {"x": {"intervals": [{"name": "work", "duration": 90}]}}}`
+	if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for numeric duration")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err == nil {
+		t.Error("Expected error for invalid config")
+	}
+	if cfg == nil {
+		t.Error("Expected defaults to be returned alongside the error")
+	}
+}
+
+func TestLoad_RecoversBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := Default()
+	cfg.Aliases = map[string]string{"pp": "toggle"}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := Save(path, Default()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// A write cut short by a crash.
+	if err := os.WriteFile(path, []byte(`{"aliases": {"p`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	got, err := Load(path)
+	if !errors.Is(err, safefile.ErrRecovered) {
+		t.Errorf("Expected the recovery to be reported, got %v", err)
+	}
+	if got.Aliases["pp"] != "toggle" {
+		t.Errorf("Expected the version before the damaged one, got aliases %v", got.Aliases)
+	}
+}
+
+func TestDefaultPath_Env(t *testing.T) {
+	t.Setenv("FOCOTIMER_CONFIG", "/custom/config.json")
+	if p := DefaultPath(); p != "/custom/config.json" {
+		t.Errorf("Expected FOCOTIMER_CONFIG to override path, got %q", p)
+	}
+
+	t.Setenv("FOCOTIMER_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if p := DefaultPath(); p != "/xdg/focotimer/config.json" {
+		t.Errorf("Expected XDG config path, got %q", p)
+	}
+}
+
+func TestSaveLoad_Bindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+	cfg := Default()
+	cfg.Bindings.GUI = map[string]string{"Space": "toggle"}
+	cfg.Bindings.Global = map[string]string{"Super+Shift+P": "toggle"}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got.Bindings.GUI["Space"] != "toggle" || got.Bindings.Global["Super+Shift+P"] != "toggle" {
+		t.Errorf("Expected bindings to round-trip, got %+v", got.Bindings)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	reloaded := make(chan *Config, 1)
+	go Watch(path, 10*time.Millisecond, stop, func(cfg *Config, err error) {
+		if err != nil {
+			t.Errorf("Unexpected reload error: %v", err)
+		}
+		select {
+		case reloaded <- cfg:
+		default:
+		}
+	})
+
+	// keep changing the file until the watcher, which starts concurrently,
+	// has seen a version differ from the one it started with
+	deadline := time.After(2 * time.Second)
+	for i := 0; ; i++ {
+		data := fmt.Sprintf(`{"bindings": {"gui": {"P": "pause"}}, "commands": {"c%d": ["start"]}}`, i)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		select {
+		case cfg := <-reloaded:
+			if cfg.Bindings.GUI["P"] != "pause" {
+				t.Errorf("Expected reloaded bindings, got %+v", cfg.Bindings)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Expected the change to be picked up")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ------------------- Marshalling -------------------
+
+// pad is the padding that aligns n to a multiple of to.
+func pad(n, to int) int { return (to - n%to) % to }
+
+// Encoder marshals values in little-endian byte order. Offsets count from
+// the start of the buffer, which must start at a message or body boundary.
+type Encoder struct {
+	buf []byte
+}
+
+// Bytes returns the values written so far.
+func (e *Encoder) Bytes() []byte { return e.buf }
+
+func (e *Encoder) Align(n int) {
+	for range pad(len(e.buf), n) {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *Encoder) Byte(b byte) { e.buf = append(e.buf, b) }
+
+func (e *Encoder) Bool(b bool) {
+	if b {
+		e.Uint32(1)
+	} else {
+		e.Uint32(0)
+	}
+}
+
+func (e *Encoder) Int32(v int32) { e.Uint32(uint32(v)) }
+
+func (e *Encoder) Uint32(v uint32) {
+	e.Align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+// String writes a string or an object path.
+func (e *Encoder) String(s string) {
+	e.Uint32(uint32(len(s)))
+	e.buf = append(append(e.buf, s...), 0)
+}
+
+func (e *Encoder) Signature(s string) {
+	e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+}
+
+// Array writes the length, then the elements written by elems. The length
+// leaves out the padding to the first element, aligned to elemAlign: 8
+// for structs and dict entries, 4 for strings, and the size of other
+// fixed types.
+func (e *Encoder) Array(elemAlign int, elems func()) {
+	e.Uint32(0)
+	at := len(e.buf)
+	e.Align(elemAlign)
+	start := len(e.buf)
+	elems()
+	binary.LittleEndian.PutUint32(e.buf[at-4:], uint32(len(e.buf)-start))
+}
+
+// Struct aligns a struct or dict entry, whose fields fields writes.
+func (e *Encoder) Struct(fields func()) {
+	e.Align(8)
+	fields()
+}
+
+// Variant writes the signature of a single complete type, then the value
+// that value writes in it.
+func (e *Encoder) Variant(signature string, value func()) {
+	e.Signature(signature)
+	value()
+}
+
+// Decoder unmarshals values; the first error sticks and zero values follow
+// it.
+type Decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+	err   error
+}
+
+// Err is the first error met.
+func (d *Decoder) Err() error { return d.err }
+
+func (d *Decoder) Align(n int) { d.pos += pad(d.pos, n) }
+
+func (d *Decoder) need(n int) bool {
+	if d.err == nil && d.pos+n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+	}
+	return d.err == nil
+}
+
+func (d *Decoder) Byte() byte {
+	if !d.need(1) {
+		return 0
+	}
+	d.pos++
+	return d.buf[d.pos-1]
+}
+
+func (d *Decoder) Bool() bool { return d.Uint32() != 0 }
+
+func (d *Decoder) Int32() int32 { return int32(d.Uint32()) }
+
+func (d *Decoder) Uint32() uint32 {
+	d.Align(4)
+	if !d.need(4) {
+		return 0
+	}
+	d.pos += 4
+	return d.order.Uint32(d.buf[d.pos-4:])
+}
+
+func (d *Decoder) uint64() uint64 {
+	d.Align(8)
+	if !d.need(8) {
+		return 0
+	}
+	d.pos += 8
+	return d.order.Uint64(d.buf[d.pos-8:])
+}
+
+// String reads a string or an object path.
+func (d *Decoder) String() string {
+	n := int(d.Uint32())
+	if !d.need(n + 1) {
+		return ""
+	}
+	d.pos += n + 1
+	return string(d.buf[d.pos-n-1 : d.pos-1])
+}
+
+func (d *Decoder) Signature() string {
+	n := int(d.Byte())
+	if !d.need(n + 1) {
+		return ""
+	}
+	d.pos += n + 1
+	return string(d.buf[d.pos-n-1 : d.pos-1])
+}
+
+// Array calls elem once per element of an array whose elements are
+// aligned to elemAlign.
+func (d *Decoder) Array(elemAlign int, elem func()) {
+	n := int(d.Uint32())
+	d.Align(elemAlign)
+	if !d.need(n) {
+		return
+	}
+	for end := d.pos + n; d.err == nil && d.pos < end; {
+		elem()
+	}
+}
+
+// Variant reads the signature of a variant, whose value follows.
+func (d *Decoder) Variant() string { return d.Signature() }
+
+// Skip reads past the values of signature, such as the value of a
+// variant that is of no interest.
+func (d *Decoder) Skip(signature string) {
+	for rest := signature; rest != "" && d.err == nil; {
+		rest = d.skipOne(rest)
+	}
+}
+
+// skipOne reads past the first complete type of sig and returns the rest.
+func (d *Decoder) skipOne(sig string) string {
+	switch sig[0] {
+	case 'y':
+		d.Byte()
+	case 'b', 'i', 'u', 'h':
+		d.Uint32()
+	case 'n', 'q':
+		d.Align(2)
+		if d.need(2) {
+			d.pos += 2
+		}
+	case 'x', 't', 'd':
+		d.uint64()
+	case 's', 'o':
+		_ = d.String()
+	case 'g':
+		d.Signature()
+	case 'v':
+		d.Skip(d.Variant())
+	case 'a':
+		elem := completeType(sig[1:])
+		if elem == "" {
+			d.err = fmt.Errorf("bad signature %q", sig)
+			return ""
+		}
+		d.Array(alignment(elem[0]), func() { d.skipOne(elem) })
+		return sig[1+len(elem):]
+	case '(', '{':
+		t := completeType(sig)
+		if t == "" {
+			d.err = fmt.Errorf("bad signature %q", sig)
+			return ""
+		}
+		d.Align(8)
+		d.Skip(t[1 : len(t)-1])
+		return sig[len(t):]
+	default:
+		d.err = fmt.Errorf("unsupported type %q", sig[0])
+		return ""
+	}
+	return sig[1:]
+}
+
+// completeType is the first complete type of sig, or empty when sig does
+// not start with one.
+func completeType(sig string) string {
+	if sig == "" {
+		return ""
+	}
+	switch sig[0] {
+	case 'a':
+		if elem := completeType(sig[1:]); elem != "" {
+			return sig[:1+len(elem)]
+		}
+		return ""
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+		for i := 1; i < len(sig); {
+			if sig[i] == closing {
+				return sig[:i+1]
+			}
+			t := completeType(sig[i:])
+			if t == "" {
+				return ""
+			}
+			i += len(t)
+		}
+		return ""
+	}
+	return sig[:1]
+}
+
+// alignment is the alignment of values of the type starting with c.
+func alignment(c byte) int {
+	switch c {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 4
+}
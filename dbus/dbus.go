@@ -0,0 +1,388 @@
+// Package dbus speaks just enough of the D-Bus wire protocol for the
+// desktop integration of focotimer: method calls and signals on the
+// session bus, and answering the calls made to objects it exports, over
+// unix sockets.
+package dbus
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------- Connections -------------------
+
+// Timeout bounds connecting to the bus and authenticating.
+const Timeout = 5 * time.Second
+
+// ErrClosed is returned by calls on a connection that was closed or lost.
+var ErrClosed = errors.New("dbus: connection closed")
+
+// Conn is an authenticated connection to a bus. Calls may be made from
+// several goroutines; incoming method calls and signals go to the handler.
+type Conn struct {
+	conn net.Conn
+	name string
+
+	wmu    sync.Mutex
+	w      *bufio.Writer
+	serial uint32
+
+	mu      sync.Mutex
+	pending map[uint32]chan Message
+	handler func(Message)
+	done    chan struct{}
+	err     error
+}
+
+// SessionBus is the session bus address, from DBUS_SESSION_BUS_ADDRESS or
+// the bus socket in the runtime directory.
+func SessionBus() (string, error) {
+	if addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); addr != "" {
+		return addr, nil
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return "unix:path=" + filepath.Join(dir, "bus"), nil
+	}
+	return "", errors.New("no session bus: DBUS_SESSION_BUS_ADDRESS is unset")
+}
+
+// Dial connects to the bus at addr, such as "unix:path=/run/user/1000/bus"
+// (empty is the session bus), authenticates and says Hello.
+func Dial(addr string) (*Conn, error) {
+	conn, err := dialBus(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(Timeout))
+	r := bufio.NewReader(conn)
+	c := &Conn{
+		conn:    conn,
+		w:       bufio.NewWriter(conn),
+		pending: make(map[uint32]chan Message),
+		done:    make(chan struct{}),
+	}
+	if err := c.auth(r); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	if err := c.hello(r); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Hello: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	go c.read(r)
+	return c, nil
+}
+
+// dialBus connects to the first unix address of addr that answers.
+func dialBus(addr string) (net.Conn, error) {
+	if addr == "" {
+		var err error
+		if addr, err = SessionBus(); err != nil {
+			return nil, err
+		}
+	}
+	var errs []error
+	for _, a := range strings.Split(addr, ";") {
+		transport, params, _ := strings.Cut(a, ":")
+		if transport != "unix" {
+			continue
+		}
+		var path string
+		for _, kv := range strings.Split(params, ",") {
+			key, value, _ := strings.Cut(kv, "=")
+			switch key {
+			case "path":
+				path = unescapeAddress(value)
+			case "abstract":
+				path = "@" + unescapeAddress(value)
+			}
+		}
+		if path == "" {
+			continue
+		}
+		conn, err := net.DialTimeout("unix", path, Timeout)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no usable unix address in %q", addr)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// unescapeAddress decodes the %XX escapes of D-Bus address values.
+func unescapeAddress(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// auth runs the EXTERNAL authentication, which proves the user by the
+// credentials of the unix socket.
+func (c *Conn) auth(r *bufio.Reader) error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := c.w.WriteString("\x00AUTH EXTERNAL " + uid + "\r\n"); err != nil {
+		return err
+	}
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return errors.New(strings.TrimSpace(line))
+	}
+	if _, err := c.w.WriteString("BEGIN\r\n"); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// hello registers the connection with the bus, which answers with its
+// unique name.
+func (c *Conn) hello(r *bufio.Reader) error {
+	serial, err := c.send(Message{
+		Type:        MethodCall,
+		Path:        "/org/freedesktop/DBus",
+		Interface:   "org.freedesktop.DBus",
+		Member:      "Hello",
+		Destination: "org.freedesktop.DBus",
+	}, nil)
+	if err != nil {
+		return err
+	}
+	for {
+		m, err := ReadMessage(r)
+		if err != nil {
+			return err
+		}
+		if m.ReplySerial != serial {
+			continue
+		}
+		if err := m.Err(); err != nil {
+			return err
+		}
+		d := m.Decoder()
+		c.name = d.String()
+		return d.Err()
+	}
+}
+
+// Name is the unique name the bus gave the connection, e.g. ":1.42".
+func (c *Conn) Name() string { return c.name }
+
+// read delivers replies to their calls and everything else to the handler
+// until the connection fails.
+func (c *Conn) read(r *bufio.Reader) {
+	for {
+		m, err := ReadMessage(r)
+		if err != nil {
+			c.fail(err)
+			c.conn.Close()
+			return
+		}
+		switch m.Type {
+		case MethodReturn, Error:
+			c.mu.Lock()
+			ch := c.pending[m.ReplySerial]
+			delete(c.pending, m.ReplySerial)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		default:
+			c.mu.Lock()
+			h := c.handler
+			c.mu.Unlock()
+			if h != nil {
+				h(m)
+			}
+		}
+	}
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	c.err = err
+	close(c.done)
+}
+
+// Handle passes the method calls made to the connection and the signals
+// it matched to h, one at a time on the goroutine reading the connection:
+// h may Reply and Emit but must not Call, or the reply never arrives.
+func (c *Conn) Handle(h func(m Message)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = h
+}
+
+// send writes m with the next serial and returns the serial. The reply to
+// m goes to reply, when set.
+func (c *Conn) send(m Message, reply chan Message) (uint32, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.serial++
+	m.Serial = c.serial
+	if reply != nil {
+		c.mu.Lock()
+		c.pending[m.Serial] = reply
+		c.mu.Unlock()
+	}
+	if _, err := c.w.Write(m.Encode()); err != nil {
+		return m.Serial, err
+	}
+	return m.Serial, c.w.Flush()
+}
+
+// Call sends the method call m and returns its reply. An error reply
+// becomes an error.
+func (c *Conn) Call(m Message) (Message, error) {
+	m.Type = MethodCall
+	ch := make(chan Message, 1)
+	serial, err := c.send(m, ch)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, serial)
+		c.mu.Unlock()
+		return Message{}, err
+	}
+	select {
+	case reply := <-ch:
+		return reply, reply.Err()
+	case <-c.done:
+		return Message{}, c.closedErr()
+	}
+}
+
+func (c *Conn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil || errors.Is(c.err, net.ErrClosed) {
+		return ErrClosed
+	}
+	return fmt.Errorf("%w: %v", ErrClosed, c.err)
+}
+
+// Emit sends a signal from the object at path.
+func (c *Conn) Emit(path, iface, member, signature string, body []byte) error {
+	_, err := c.send(Message{
+		Type:      Signal,
+		Path:      path,
+		Interface: iface,
+		Member:    member,
+		Signature: signature,
+		Body:      body,
+	}, nil)
+	return err
+}
+
+// Reply answers the method call.
+func (c *Conn) Reply(call Message, signature string, body []byte) error {
+	_, err := c.send(Message{
+		Type:        MethodReturn,
+		Destination: call.Sender,
+		ReplySerial: call.Serial,
+		Signature:   signature,
+		Body:        body,
+	}, nil)
+	return err
+}
+
+// ReplyError fails the method call with the error name, such as
+// "org.freedesktop.DBus.Error.UnknownMethod", and text.
+func (c *Conn) ReplyError(call Message, name, text string) error {
+	var e Encoder
+	e.String(text)
+	_, err := c.send(Message{
+		Type:        Error,
+		Destination: call.Sender,
+		ReplySerial: call.Serial,
+		ErrorName:   name,
+		Signature:   "s",
+		Body:        e.Bytes(),
+	}, nil)
+	return err
+}
+
+// busCall is a method call to the bus itself.
+func busCall(member, signature string, body []byte) Message {
+	return Message{
+		Path:        "/org/freedesktop/DBus",
+		Interface:   "org.freedesktop.DBus",
+		Member:      member,
+		Destination: "org.freedesktop.DBus",
+		Signature:   signature,
+		Body:        body,
+	}
+}
+
+// AddMatch asks the bus for the signals matching rule, e.g.
+// "type='signal',interface='org.freedesktop.Notifications'".
+func (c *Conn) AddMatch(rule string) error {
+	var e Encoder
+	e.String(rule)
+	_, err := c.Call(busCall("AddMatch", "s", e.Bytes()))
+	return err
+}
+
+// RequestName takes the well-known name, failing if another connection
+// owns it.
+func (c *Conn) RequestName(name string) error {
+	var e Encoder
+	e.String(name)
+	e.Uint32(4) // DBUS_NAME_FLAG_DO_NOT_QUEUE
+	reply, err := c.Call(busCall("RequestName", "su", e.Bytes()))
+	if err != nil {
+		return err
+	}
+	d := reply.Decoder()
+	switch code := d.Uint32(); {
+	case d.Err() != nil:
+		return d.Err()
+	case code != 1 && code != 4: // primary owner, or already the owner
+		return fmt.Errorf("name %q is taken", name)
+	}
+	return nil
+}
+
+// SetDeadline bounds the reads and writes of the connection; a read that
+// times out ends it.
+func (c *Conn) SetDeadline(t time.Time) error { return c.conn.SetDeadline(t) }
+
+// Done is closed once the connection is closed or lost.
+func (c *Conn) Done() <-chan struct{} { return c.done }
+
+func (c *Conn) Close() error {
+	err := c.conn.Close()
+	c.fail(net.ErrClosed)
+	return err
+}
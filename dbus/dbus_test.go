@@ -0,0 +1,222 @@
+package dbus
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/dbus/dbustest"
+)
+
+func TestUnescapeAddress(t *testing.T) {
+	if got := unescapeAddress("/tmp/dbus%2dtest%"); got != "/tmp/dbus-test%" {
+		t.Errorf("Expected %q, got %q", "/tmp/dbus-test%", got)
+	}
+}
+
+func TestMessage_RoundTrip(t *testing.T) {
+	var e Encoder
+	e.String("focotimer")
+	e.Array(8, func() {
+		e.Struct(func() {
+			e.String("urgency")
+			e.Variant("y", func() { e.Byte(2) })
+		})
+		e.Struct(func() {
+			e.String("pos")
+			e.Variant("(ii)", func() { e.Struct(func() { e.Int32(-3); e.Int32(4) }) })
+		})
+	})
+	e.Bool(true)
+	in := Message{Type: Signal, Serial: 9, Path: "/a", Interface: "b.c", Member: "D", Sender: ":1.1",
+		Signature: "sa{sv}b", Body: e.Bytes()}
+	m, err := ReadMessage(strings.NewReader(string(in.Encode())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != Signal || m.Serial != 9 || m.Path != "/a" || m.Interface != "b.c" || m.Member != "D" ||
+		m.Sender != ":1.1" || m.Signature != "sa{sv}b" {
+		t.Errorf("Expected the header of %+v, got %+v", in, m)
+	}
+	d := m.Decoder()
+	if s := d.String(); s != "focotimer" {
+		t.Errorf("Expected %q, got %q", "focotimer", s)
+	}
+	var keys []string
+	d.Array(8, func() {
+		d.Align(8)
+		keys = append(keys, d.String())
+		d.Skip(d.Variant())
+	})
+	if strings.Join(keys, ",") != "urgency,pos" {
+		t.Errorf("Expected keys urgency,pos, got %v", keys)
+	}
+	if !d.Bool() {
+		t.Error("Expected true after the skipped variants")
+	}
+	if err := d.Err(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	d.Uint32()
+	if d.Err() == nil {
+		t.Error("Expected an error reading past the body")
+	}
+}
+
+// fakeBus accepts one connection and hands its messages to the test.
+type fakeBus struct {
+	*dbustest.Bus
+	conn chan net.Conn
+	in   chan Message
+}
+
+func newFakeBus(t *testing.T) *fakeBus {
+	b := &fakeBus{Bus: dbustest.New(t), conn: make(chan net.Conn, 1), in: make(chan Message, 16)}
+	go func() {
+		conn, r := b.Accept()
+		if conn == nil {
+			return
+		}
+		b.conn <- conn
+		for {
+			m, err := ReadMessage(r)
+			if err != nil {
+				close(b.in)
+				return
+			}
+			b.in <- m
+		}
+	}()
+	return b
+}
+
+// next is the next message the client sent.
+func (b *fakeBus) next(t *testing.T) Message {
+	t.Helper()
+	select {
+	case m := <-b.in:
+		return m
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+		return Message{}
+	}
+}
+
+// dial connects a client and answers its Hello.
+func (b *fakeBus) dial(t *testing.T) (*Conn, net.Conn) {
+	t.Helper()
+	type result struct {
+		c   *Conn
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c, err := Dial(b.Address())
+		done <- result{c, err}
+	}()
+	var conn net.Conn
+	select {
+	case conn = <-b.conn:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the client")
+	}
+	hello := b.next(t)
+	if hello.Member != "Hello" || hello.Destination != "org.freedesktop.DBus" {
+		t.Fatalf("Expected Hello, got %+v", hello)
+	}
+	var e Encoder
+	e.String(":1.42")
+	conn.Write(Message{Type: MethodReturn, Serial: 1, ReplySerial: hello.Serial, Signature: "s", Body: e.Bytes()}.Encode())
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("Dial failed: %v", r.err)
+	}
+	t.Cleanup(func() { r.c.Close() })
+	return r.c, conn
+}
+
+func TestConn_Call(t *testing.T) {
+	bus := newFakeBus(t)
+	c, conn := bus.dial(t)
+	if c.Name() != ":1.42" {
+		t.Errorf("Expected the name from Hello, got %q", c.Name())
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.RequestName("org.example.Timer") }()
+	call := bus.next(t)
+	if call.Member != "RequestName" || call.Signature != "su" {
+		t.Fatalf("Expected RequestName, got %+v", call)
+	}
+	if d := call.Decoder(); d.String() != "org.example.Timer" || d.Uint32() != 4 {
+		t.Errorf("Unexpected RequestName arguments")
+	}
+	var e Encoder
+	e.Uint32(3) // exists
+	conn.Write(Message{Type: MethodReturn, Serial: 2, ReplySerial: call.Serial, Signature: "u", Body: e.Bytes()}.Encode())
+	if err := <-errc; err == nil || !strings.Contains(err.Error(), "taken") {
+		t.Errorf("Expected the name to be taken, got %v", err)
+	}
+
+	go func() { errc <- c.AddMatch("type='signal'") }()
+	call = bus.next(t)
+	e = Encoder{}
+	e.String("bad rule")
+	conn.Write(Message{Type: Error, Serial: 3, ReplySerial: call.Serial, ErrorName: "org.freedesktop.DBus.Error.MatchRuleInvalid",
+		Signature: "s", Body: e.Bytes()}.Encode())
+	if err := <-errc; err == nil || err.Error() != "org.freedesktop.DBus.Error.MatchRuleInvalid: bad rule" {
+		t.Errorf("Expected the error reply, got %v", err)
+	}
+
+	go func() { errc <- c.AddMatch("type='signal'") }()
+	bus.next(t)
+	conn.Close()
+	if err := <-errc; !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed once the bus is gone, got %v", err)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(2 * time.Second):
+		t.Error("Expected Done to close")
+	}
+}
+
+func TestConn_Handle(t *testing.T) {
+	bus := newFakeBus(t)
+	c, conn := bus.dial(t)
+	c.Handle(func(m Message) {
+		switch m.Member {
+		case "Ping":
+			var e Encoder
+			e.String("pong")
+			c.Reply(m, "s", e.Bytes())
+		default:
+			c.ReplyError(m, "org.freedesktop.DBus.Error.UnknownMethod", "no "+m.Member)
+		}
+	})
+
+	conn.Write(Message{Type: MethodCall, Serial: 10, Path: "/", Member: "Ping", Sender: ":1.7"}.Encode())
+	reply := bus.next(t)
+	if reply.Type != MethodReturn || reply.ReplySerial != 10 || reply.Destination != ":1.7" {
+		t.Errorf("Expected a reply to the ping, got %+v", reply)
+	}
+	if d := reply.Decoder(); d.String() != "pong" {
+		t.Error("Expected pong")
+	}
+
+	conn.Write(Message{Type: MethodCall, Serial: 11, Path: "/", Member: "Frob", Sender: ":1.7"}.Encode())
+	reply = bus.next(t)
+	if err := reply.Err(); err == nil || err.Error() != "org.freedesktop.DBus.Error.UnknownMethod: no Frob" {
+		t.Errorf("Expected an error reply, got %v", err)
+	}
+
+	if err := c.Emit("/StatusNotifierItem", "org.kde.StatusNotifierItem", "NewTitle", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	sig := bus.next(t)
+	if sig.Type != Signal || sig.Path != "/StatusNotifierItem" || sig.Member != "NewTitle" {
+		t.Errorf("Expected the signal, got %+v", sig)
+	}
+}
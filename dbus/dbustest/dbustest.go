@@ -0,0 +1,57 @@
+// Package dbustest runs a session bus for the tests of the D-Bus clients
+// of focotimer: it listens on a unix socket and authenticates the client
+// as the bus daemon would, leaving the messages to the test.
+package dbustest
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Bus is a session bus in the temporary directory of a test.
+type Bus struct {
+	t  testing.TB
+	ln net.Listener
+}
+
+// New listens for a client until the test ends.
+func New(t testing.TB) *Bus {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "bus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return &Bus{t: t, ln: ln}
+}
+
+// Address is the D-Bus address of the bus, for the client to dial.
+func (b *Bus) Address() string { return "unix:path=" + b.ln.Addr().String() }
+
+// Accept waits for a client and authenticates it. It returns the
+// connection and a reader of the messages the client sends next, closed
+// when the test ends, or nil when the bus closes first or the client does
+// not authenticate with EXTERNAL, which fails the test. It may be called
+// from another goroutine than the test's.
+func (b *Bus) Accept() (net.Conn, *bufio.Reader) {
+	conn, err := b.ln.Accept()
+	if err != nil {
+		return nil, nil
+	}
+	b.t.Cleanup(func() { conn.Close() })
+	r := bufio.NewReader(conn)
+	if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "\x00AUTH EXTERNAL ") {
+		b.t.Errorf("Expected EXTERNAL authentication, got %q", line)
+		conn.Close()
+		return nil, nil
+	}
+	conn.Write([]byte("OK 0123456789abcdef\r\n"))
+	if line, _ := r.ReadString('\n'); line != "BEGIN\r\n" {
+		b.t.Errorf("Expected BEGIN, got %q", line)
+		conn.Close()
+		return nil, nil
+	}
+	return conn, r
+}
@@ -0,0 +1,191 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ------------------- Messages -------------------
+
+// Type is the kind of a message.
+type Type byte
+
+const (
+	MethodCall   Type = 1
+	MethodReturn Type = 2
+	Error        Type = 3
+	Signal       Type = 4
+)
+
+// Header fields of the wire protocol.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// Message is a method call, a reply or a signal, with the header fields
+// focotimer needs. Body is marshalled by an Encoder in the types of
+// Signature.
+type Message struct {
+	Type        Type
+	Serial      uint32
+	Path        string
+	Interface   string
+	Member      string
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []byte
+
+	// set on replies
+	ReplySerial uint32
+	ErrorName   string
+
+	// Order is the byte order of Body; ReadMessage sets it and Encode
+	// writes little-endian bodies only.
+	Order binary.ByteOrder
+}
+
+// Decoder reads the body.
+func (m Message) Decoder() *Decoder {
+	order := m.Order
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	return &Decoder{buf: m.Body, order: order}
+}
+
+// Err is the error of an error reply, with its text when it has one; nil
+// for other messages.
+func (m Message) Err() error {
+	if m.Type != Error {
+		return nil
+	}
+	if strings.HasPrefix(m.Signature, "s") {
+		d := m.Decoder()
+		if text := d.String(); d.Err() == nil {
+			return errors.New(m.ErrorName + ": " + text)
+		}
+	}
+	return errors.New(m.ErrorName)
+}
+
+// Encode lays m out in little-endian byte order.
+func (m Message) Encode() []byte {
+	var e Encoder
+	e.Byte('l')
+	e.Byte(byte(m.Type))
+	e.Byte(0) // flags
+	e.Byte(1) // protocol version
+	e.Uint32(uint32(len(m.Body)))
+	e.Uint32(m.Serial)
+	e.Array(8, func() {
+		field := func(code byte, sig, value string) {
+			if value == "" {
+				return
+			}
+			e.Align(8)
+			e.Byte(code)
+			e.Signature(sig)
+			if sig == "g" {
+				e.Signature(value)
+			} else {
+				e.String(value)
+			}
+		}
+		field(fieldPath, "o", m.Path)
+		field(fieldInterface, "s", m.Interface)
+		field(fieldMember, "s", m.Member)
+		field(fieldErrorName, "s", m.ErrorName)
+		field(fieldDestination, "s", m.Destination)
+		field(fieldSender, "s", m.Sender)
+		field(fieldSignature, "g", m.Signature)
+		if m.ReplySerial != 0 {
+			e.Align(8)
+			e.Byte(fieldReplySerial)
+			e.Signature("u")
+			e.Uint32(m.ReplySerial)
+		}
+	})
+	e.Align(8)
+	return append(e.buf, m.Body...)
+}
+
+// ReadMessage reads one message, keeping the header fields of Message.
+func ReadMessage(r io.Reader) (Message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return Message{}, err
+	}
+	var m Message
+	switch fixed[0] {
+	case 'l':
+		m.Order = binary.LittleEndian
+	case 'B':
+		m.Order = binary.BigEndian
+	default:
+		return Message{}, fmt.Errorf("bad byte order %q", fixed[0])
+	}
+	m.Type = Type(fixed[1])
+	bodyLen := m.Order.Uint32(fixed[4:])
+	m.Serial = m.Order.Uint32(fixed[8:])
+	fieldsLen := m.Order.Uint32(fixed[12:])
+	if fieldsLen > 1<<20 || bodyLen > 1<<26 {
+		return Message{}, errors.New("message too large")
+	}
+	headerLen := 16 + int(fieldsLen)
+	rest := make([]byte, int(fieldsLen)+pad(headerLen, 8)+int(bodyLen))
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return Message{}, err
+	}
+
+	d := Decoder{buf: append(fixed, rest...), pos: 16, order: m.Order}
+	for d.pos < headerLen {
+		d.Align(8)
+		code := d.Byte()
+		sig := d.Signature()
+		switch sig {
+		case "u":
+			v := d.Uint32()
+			if code == fieldReplySerial {
+				m.ReplySerial = v
+			}
+		case "s", "o":
+			v := d.String()
+			switch code {
+			case fieldPath:
+				m.Path = v
+			case fieldInterface:
+				m.Interface = v
+			case fieldMember:
+				m.Member = v
+			case fieldErrorName:
+				m.ErrorName = v
+			case fieldDestination:
+				m.Destination = v
+			case fieldSender:
+				m.Sender = v
+			}
+		case "g":
+			if v := d.Signature(); code == fieldSignature {
+				m.Signature = v
+			}
+		default:
+			d.Skip(sig)
+		}
+		if d.err != nil {
+			return Message{}, d.err
+		}
+	}
+	m.Body = d.buf[headerLen+pad(headerLen, 8):]
+	return m, nil
+}
@@ -0,0 +1,358 @@
+// Package e2e runs the frontends of one daemon side by side and checks
+// that they agree.
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/ipc"
+	"github.com/d093w1z/focotimer/ipc/pb"
+)
+
+// tick is the update rate of the observer and the status file; every
+// surface must catch up with a change within one tick, give or take
+// scheduling.
+const tick = 100 * time.Millisecond
+
+// view is what a surface reports about the timer. Status is empty where a
+// surface cannot tell.
+type view struct {
+	Status    focotimer.Status
+	Label     string
+	Duration  time.Duration
+	Remaining time.Duration
+}
+
+func (v view) String() string {
+	return fmt.Sprintf("%s %q %v/%v", v.Status, v.Label, v.Remaining, v.Duration)
+}
+
+func stateView(s focotimer.State) view {
+	return view{Status: s.Status, Label: s.Label, Duration: s.Duration, Remaining: s.Remaining}
+}
+
+// tickClock paces the bar by hand so each sample is one output line.
+type tickClock struct {
+	ticks chan time.Time
+}
+
+func (c *tickClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (c *tickClock) NewTicker(time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {}
+}
+
+// daemon wires every frontend to one TimerManager, as main does.
+type daemon struct {
+	tm *focotimer.TimerManager
+
+	barCmds *io.PipeWriter
+	barTick chan time.Time
+	barOut  *bufio.Reader
+
+	client     *ipc.Client
+	grpc       pb.TimerClient
+	statusPath string
+
+	eventsMu  sync.Mutex
+	lastEvent focotimer.Event
+}
+
+func startDaemon(t *testing.T) *daemon {
+	t.Helper()
+	dir := t.TempDir()
+	tm := focotimer.NewTimerManager(time.Minute)
+	d := focotimer.NewDispatcher(tm)
+	dm := &daemon{tm: tm, statusPath: filepath.Join(dir, "status.json")}
+
+	// polybar module reading commands from a pipe, in plain format so the
+	// state shows as a color
+	cmdR, cmdW := io.Pipe()
+	outR, outW := io.Pipe()
+	clock := &tickClock{ticks: make(chan time.Time)}
+	bar := polybar.New(polybar.WithCommands(cmdR), polybar.WithClock(clock), polybar.WithOutput(outW),
+		polybar.WithFormat(polybar.FormatPlain), polybar.WithColors(polybar.DefaultColors))
+	bar.SetTimerManager(tm)
+	go bar.Run()
+	t.Cleanup(func() {
+		bar.Stop()
+		outR.Close()
+	})
+	dm.barCmds, dm.barTick, dm.barOut = cmdW, clock.ticks, bufio.NewReader(outR)
+
+	// events socket
+	obs := ipc.NewObserver(tm, tick)
+	t.Cleanup(func() { obs.Close() })
+	eventsPath := filepath.Join(dir, "events.sock")
+	if err := obs.Listen(eventsPath); err != nil {
+		t.Fatalf("Observer.Listen failed: %v", err)
+	}
+	conn, err := net.Dial("unix", eventsPath)
+	if err != nil {
+		t.Fatalf("Dial events socket failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go dm.readEvents(conn)
+
+	// HTTP API on the control socket, through the focotimerctl client
+	api := ipc.NewAPI(tm, d)
+	api.Stream(obs)
+	ctlPath := filepath.Join(dir, "ctl.sock")
+	if err := api.ListenUnix(ctlPath); err != nil {
+		t.Fatalf("API.ListenUnix failed: %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+	dm.client = ipc.NewClient(ctlPath)
+
+	// gRPC
+	g := ipc.NewGRPC(tm, d)
+	g.RequireToken("s3cret")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	g.Serve(ln)
+	t.Cleanup(func() { g.Close() })
+	withToken := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	gconn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(withToken))
+	if err != nil {
+		t.Fatalf("grpc.NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { gconn.Close() })
+	dm.grpc = pb.NewTimerClient(gconn)
+
+	// status file
+	stop := make(chan struct{})
+	go ipc.NewStatusFile(tm, dm.statusPath, tick).Run(stop)
+	t.Cleanup(func() { close(stop) })
+
+	return dm
+}
+
+func (dm *daemon) readEvents(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var ev focotimer.Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			continue
+		}
+		dm.eventsMu.Lock()
+		dm.lastEvent = ev
+		dm.eventsMu.Unlock()
+	}
+}
+
+// --- Surfaces ---
+
+// barLine matches the plain bar output: an optional color, the activity,
+// then "duration : remaining".
+var barLine = regexp.MustCompile(`^(?:\x1b\[38;2;(\d+);(\d+);(\d+)m)?(?:(.*) )?(\S+) : (\S+?)(?:\x1b\[0m)?$`)
+
+func (dm *daemon) barView() (view, error) {
+	dm.barTick <- time.Now()
+	line, err := dm.barOut.ReadString('\n')
+	if err != nil {
+		return view{}, err
+	}
+	m := barLine.FindStringSubmatch(strings.TrimRight(line, "\n"))
+	if m == nil {
+		return view{}, fmt.Errorf("unexpected bar output %q", line)
+	}
+	var v view
+	if m[1] != "" {
+		color := fmt.Sprintf("#%02X%02X%02X", atoi(m[1]), atoi(m[2]), atoi(m[3]))
+		switch color {
+		case polybar.DefaultColors.Running, polybar.DefaultColors.Final:
+			v.Status = focotimer.StatusRunning
+		case polybar.DefaultColors.Paused:
+			v.Status = focotimer.StatusPaused
+		}
+	}
+	v.Label = m[4]
+	if v.Duration, err = time.ParseDuration(m[5]); err != nil {
+		return v, err
+	}
+	v.Remaining, err = time.ParseDuration(m[6])
+	return v, err
+}
+
+func atoi(s string) int {
+	var n int
+	fmt.Sscan(s, &n)
+	return n
+}
+
+func (dm *daemon) httpView(ctx context.Context) (view, error) {
+	s, err := dm.client.Status(ctx)
+	return stateView(s), err
+}
+
+func (dm *daemon) grpcView(ctx context.Context) (view, error) {
+	s, err := dm.grpc.GetState(ctx, &emptypb.Empty{})
+	if err != nil {
+		return view{}, err
+	}
+	return view{
+		Status:    focotimer.Status(strings.ToLower(strings.TrimPrefix(s.GetStatus().String(), "STATUS_"))),
+		Label:     s.GetLabel(),
+		Duration:  s.GetDuration().AsDuration(),
+		Remaining: s.GetRemaining().AsDuration(),
+	}, nil
+}
+
+func (dm *daemon) fileView() (view, error) {
+	data, err := os.ReadFile(dm.statusPath)
+	if err != nil {
+		return view{}, err
+	}
+	var s focotimer.State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return view{}, err
+	}
+	return stateView(s), nil
+}
+
+func (dm *daemon) eventsView() (view, error) {
+	dm.eventsMu.Lock()
+	defer dm.eventsMu.Unlock()
+	ev := dm.lastEvent
+	if ev.At.IsZero() {
+		return view{}, fmt.Errorf("no event yet")
+	}
+	return view{Label: ev.Label, Duration: ev.Duration, Remaining: ev.Remaining}, nil
+}
+
+// --- Comparison ---
+
+// diverges reports how v differs from the manager's own state. The bar
+// truncates to whole seconds and every surface may lag one tick.
+func diverges(v, want view) string {
+	var diffs []string
+	if v.Status != "" && v.Status != want.Status {
+		diffs = append(diffs, fmt.Sprintf("status %s", v.Status))
+	}
+	if v.Label != want.Label {
+		diffs = append(diffs, fmt.Sprintf("label %q", v.Label))
+	}
+	if v.Duration.Truncate(time.Second) != want.Duration.Truncate(time.Second) {
+		diffs = append(diffs, fmt.Sprintf("duration %v", v.Duration))
+	}
+	if d := v.Remaining - want.Remaining; d > time.Second+tick || d < -(time.Second+tick) {
+		diffs = append(diffs, fmt.Sprintf("remaining %v", v.Remaining))
+	}
+	return strings.Join(diffs, ", ")
+}
+
+// sample reads every surface once and returns the ones that disagree with
+// the manager.
+func (dm *daemon) sample(ctx context.Context) map[string]string {
+	want := stateView(dm.tm.State())
+	surfaces := map[string]func() (view, error){
+		"polybar":     dm.barView,
+		"http":        func() (view, error) { return dm.httpView(ctx) },
+		"grpc":        func() (view, error) { return dm.grpcView(ctx) },
+		"status file": dm.fileView,
+		"events":      dm.eventsView,
+	}
+	bad := make(map[string]string)
+	for name, read := range surfaces {
+		v, err := read()
+		if err != nil {
+			bad[name] = err.Error()
+			continue
+		}
+		if diff := diverges(v, want); diff != "" {
+			bad[name] = fmt.Sprintf("%s (want %s)", diff, want)
+		}
+	}
+	return bad
+}
+
+// converge waits one tick, then requires every surface to agree, allowing
+// a few more ticks for a loaded machine.
+func (dm *daemon) converge(t *testing.T, ctx context.Context, step string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * tick)
+	for {
+		time.Sleep(tick)
+		bad := dm.sample(ctx)
+		if len(bad) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			for name, diff := range bad {
+				t.Errorf("after %s: %s diverged: %s", step, name, diff)
+			}
+			return
+		}
+	}
+}
+
+func TestFrontendsAgree(t *testing.T) {
+	dm := startDaemon(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bar := func(cmd string) func() error {
+		return func() error {
+			_, err := io.WriteString(dm.barCmds, cmd+"\n")
+			return err
+		}
+	}
+	http := func(cmd string) func() error {
+		return func() error {
+			_, err := dm.client.Command(ctx, cmd)
+			return err
+		}
+	}
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"bar: set 10m", bar("set 10m")},
+		{"http: label writing", http("label writing")},
+		{"grpc: start", func() error {
+			_, err := dm.grpc.Start(ctx, &emptypb.Empty{})
+			return err
+		}},
+		{"bar: inc", bar("inc")},
+		{"http: pause", http("pause")},
+		{"grpc: label reading", func() error {
+			_, err := dm.grpc.SetLabel(ctx, &pb.SetLabelRequest{Label: "reading"})
+			return err
+		}},
+		{"bar: resume", bar("resume")},
+		{"http: reset", http("reset")},
+		{"bar: dec", bar("dec")},
+	}
+	dm.converge(t, ctx, "startup")
+	for _, st := range steps {
+		if err := st.run(); err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		dm.converge(t, ctx, st.name)
+	}
+}
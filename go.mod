@@ -5,12 +5,16 @@ go 1.24.4
 require (
 	github.com/d093w1z/gio v0.0.0-20250825171224-7252df1038c7
 	golang.org/x/exp/shiny v0.0.0-20250819193227-8b4c13bb791b
+	golang.org/x/image v0.30.0
+	golang.org/x/sys v0.35.0
+	google.golang.org/grpc v1.75.0
+	google.golang.org/protobuf v1.36.10
 )
 
 require (
 	gioui.org/shader v1.0.8 // indirect
 	github.com/go-text/typesetting v0.3.0 // indirect
-	golang.org/x/image v0.30.0 // indirect
-	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
 )
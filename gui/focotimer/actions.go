@@ -0,0 +1,181 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/op"
+)
+
+// ---------------- PAGE ACTIONS ----------------
+// The buttons and the test script both go through these, so scripted runs
+// exercise the same page state machine as a user.
+
+func (r *Router) goBack() { r.Show(TimerStopped) }
+
+// togglePlayPause pauses a running session, resumes a paused one and
+// otherwise starts a fresh session.
+func (r *Router) togglePlayPause() {
+	switch focotimer.GTimerManager.State().Status {
+	case focotimer.StatusRunning:
+		pause()
+	case focotimer.StatusPaused:
+		r.resume()
+	default:
+		r.startSession()
+	}
+}
+
+// pause holds a running session where it is, on its page.
+func pause() {
+	if focotimer.GTimerManager.State().Status == focotimer.StatusRunning {
+		focotimer.GTimerManager.Pause()
+	}
+}
+
+func (r *Router) resume() {
+	r.Show(TimerRunning)
+	focotimer.GTimerManager.Resume()
+}
+
+// stopSession ends the session and resets it to its full length.
+func (r *Router) stopSession() {
+	r.Show(TimerStopped)
+	focotimer.GTimerManager.Stop()
+	focotimer.GTimerManager.Reset()
+}
+
+// quit ends focotimer, which runs on after its window closes, e.g. from
+// the tray menu.
+func quit() {
+	if *isPolybarEnabled {
+		polybar.Shutdown()
+	}
+	os.Exit(0)
+}
+
+// startSession starts a fresh session and shows it finished once it is
+// over, unless a cycle went on to a break.
+func (r *Router) startSession() {
+	r.Show(TimerRunning)
+	focotimer.GTimerManager.Reset()
+	focotimer.GTimerManager.Start()
+	r.finishWhenDone()
+}
+
+// finishWhenDone shows the session started last finished once it is over.
+func (r *Router) finishWhenDone() {
+	done := focotimer.GTimerManager.Done()
+	go func() {
+		<-done
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.current != Break {
+			r.current = TimerFinished
+		}
+	}()
+}
+
+// extendBy and breakLength are what the finished page offers: a few more
+// minutes of the session, or a short break, unless -break sets its length.
+const (
+	extendBy    = 5 * time.Minute
+	breakLength = 5 * time.Minute
+)
+
+// extendSession carries on with the finished session for extendBy.
+func (r *Router) extendSession() {
+	r.Show(TimerRunning)
+	focotimer.GTimerManager.StartOneOff(extendBy)
+	r.finishWhenDone()
+}
+
+// startBreak takes a short break after the finished session; the break
+// page then offers the next one.
+func (r *Router) startBreak() {
+	d := breakLength
+	if *breakFlag > 0 {
+		d = *breakFlag
+	}
+	r.Show(Break)
+	focotimer.GTimerManager.SetBreak("break")
+	focotimer.GTimerManager.StartOneOff(d)
+}
+
+// skipBreak ends the break now; a cycle goes on to its next interval.
+func skipBreak() { focotimer.GTimerManager.Skip() }
+
+// startNextSession starts a session as long as, and labelled like, the
+// last focus session, once a break that nothing followed is over.
+func (r *Router) startNextSession() {
+	work := r.brk.lastWorkSession()
+	if work.Duration > 0 {
+		focotimer.GTimerManager.SetDuration(work.Duration)
+	}
+	focotimer.GTimerManager.SetLabel(work.Label)
+	r.startSession()
+}
+
+func (r *Router) openSettings() {
+	r.settings.load()
+	r.Show(Settings)
+	focotimer.GTimerManager.Stop()
+}
+
+// openTimeline shows today's sessions, reading the history afresh.
+func (r *Router) openTimeline() {
+	r.timeline.load(history.Day(time.Now()))
+	r.Show(Timeline)
+}
+
+// openInsights shows how energy ratings relate to time of day and session
+// length, reading the history afresh.
+func (r *Router) openInsights() {
+	r.insights.load()
+	r.Show(Insights)
+}
+
+// rateEnergy rates the coming session while stopped and the finished one
+// once it is over.
+func rateEnergy(level int) {
+	if err := focotimer.GTimerManager.RateEnergy(level); err != nil {
+		log.Printf("energy: %v", err)
+	}
+}
+
+// taskEditable reports whether the task can be renamed: not while a
+// session runs or is paused.
+func taskEditable() bool {
+	switch focotimer.GTimerManager.State().Status {
+	case focotimer.StatusRunning, focotimer.StatusPaused:
+		return false
+	}
+	return true
+}
+
+func setTask(name string) {
+	if taskEditable() {
+		focotimer.GTimerManager.SetLabel(strings.TrimSpace(name))
+	}
+}
+
+func increase() { focotimer.GTimerManager.Inc() }
+func decrease() { focotimer.GTimerManager.Dec() }
+
+// ringProgress is the progress to draw on a ring, gliding to the new
+// progress when a session's length changes, e.g. when flow mode extends
+// it. Each view draws its own ring, so each window eases its own; while
+// it glides the window is drawn again.
+func ringProgress(gtx C, ring *focotimer.SmoothProgress) float64 {
+	tm := focotimer.GTimerManager
+	p := ring.At(time.Now(), tm.Progress(), tm.Span())
+	if ring.Gliding() {
+		gtx.Execute(op.InvalidateCmd{})
+	}
+	return p
+}
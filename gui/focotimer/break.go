@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- BREAK PAGE ----------------
+
+// breakPage counts a break down in the break colors. While it runs the
+// break can be skipped; once it is over and no cycle started the next
+// interval, the next session is a click away.
+type breakPage struct {
+	r *Router
+
+	back, skip, next *widgets.ButtonState
+	view             *widgets.TimerView
+	ring             focotimer.SmoothProgress
+
+	// lastWork is the last focus session that completed, for the next
+	// one to follow it
+	mu       sync.Mutex
+	lastWork focotimer.Event
+}
+
+func newBreakPage(r *Router) *breakPage {
+	return &breakPage{
+		r:    r,
+		back: widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		skip: widgets.NewButton("Skip break", icons.AVSkipNext, 10),
+		next: widgets.NewButton("Start next session", icons.AVPlayArrow, 10),
+		view: newTimerView(),
+	}
+}
+
+func (p *breakPage) Layout(th *material.Theme, gtx C) D {
+	next := p.skip.Widget(th, skipBreak)
+	if focotimer.GTimerManager.IsComplete() {
+		next = p.next.Widget(th, p.r.startNextSession)
+	}
+	return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+		p.view.Layout(th, focotimer.GTimerManager.Activity(), getLastRemaining(), ringProgress(gtx, &p.ring), widgets.Colors().Ring, p.r.timer.completedDots()),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, widgets.Wrap(10, p.back.Widget(th, p.r.goBack), next))
+		}),
+	)
+}
+
+// Typing covers the countdown being edited.
+func (p *breakPage) Typing(gtx C) bool { return p.view.Typing(gtx) }
+
+// lastWorkSession returns the last focus session that completed.
+func (p *breakPage) lastWorkSession() focotimer.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastWork
+}
+
+func (p *breakPage) keepWork(ev focotimer.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastWork = ev
+}
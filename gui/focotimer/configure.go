@@ -0,0 +1,528 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/audio"
+	"github.com/d093w1z/focotimer/config"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/focotimer/idle"
+	"github.com/d093w1z/focotimer/integrations"
+	"github.com/d093w1z/focotimer/ipc"
+	"github.com/d093w1z/focotimer/keymap"
+	"github.com/d093w1z/focotimer/notify"
+	"github.com/d093w1z/focotimer/systemd"
+)
+
+// ---------------- CONFIG ----------------
+// The config and the flags are turned into the timer, its frontends and
+// integrations here; main applies them at startup and on reload.
+
+// placementFromConfig is how the window is placed over its remembered
+// geometry: the corner it is pinned to, then the configured geometry and
+// then the one given on the command line, counted from the configured
+// monitor, if any.
+func placementFromConfig(cfg config.WindowConfig, flagGeometry string) []geometry {
+	var placement []geometry
+	if cfg.Corner != "" {
+		if g, err := cornerGeometry(cfg.Corner, cfg.Margin); err != nil {
+			log.Printf("config: window: %v", err)
+		} else {
+			placement = append(placement, g)
+		}
+	}
+	for _, s := range []string{cfg.Geometry, flagGeometry} {
+		if s == "" {
+			continue
+		}
+		if g, err := parseGeometry(s); err != nil {
+			log.Printf("window: %v", err)
+		} else {
+			placement = append(placement, g)
+		}
+	}
+	if cfg.Monitor == "" {
+		return placement
+	}
+	placed := false
+	for i := range placement {
+		if placement[i].Placed {
+			placement[i].Monitor, placed = cfg.Monitor, true
+		}
+	}
+	if !placed {
+		placement = append(placement, geometry{Placed: true, Center: true, Monitor: cfg.Monitor})
+	}
+	return placement
+}
+
+// sequencesFromConfig converts the configured interval sequences for the
+// cycle engine.
+func sequencesFromConfig(cfg *config.Config) map[string]focotimer.Sequence {
+	seqs := make(map[string]focotimer.Sequence, len(cfg.Sequences))
+	for name, cs := range cfg.Sequences {
+		seqs[name] = focotimer.Sequence{
+			Name:      name,
+			Intervals: intervalsFromConfig(cs.Intervals),
+			Repeat:    cs.Repeat,
+		}
+	}
+	return seqs
+}
+
+// flagSequence is the pomodoro sequence given on the command line: work
+// then a short break, with every fourth break long when long is set. A
+// missing short break takes breakLength. ok is false when neither break
+// is given.
+func flagSequence(work, short, long time.Duration) (seq config.Sequence, ok bool) {
+	if short <= 0 && long <= 0 {
+		return config.Sequence{}, false
+	}
+	if short <= 0 {
+		short = breakLength
+	}
+	focus := config.Interval{Name: "work", Duration: config.Duration(work)}
+	brk := config.Interval{Name: "break", Duration: config.Duration(short), Break: true}
+	if long <= 0 {
+		return config.Sequence{Intervals: []config.Interval{focus, brk}}, true
+	}
+	for range widgets.DotsPerCycle - 1 {
+		seq.Intervals = append(seq.Intervals, focus, brk)
+	}
+	seq.Intervals = append(seq.Intervals, focus, config.Interval{Name: "long break", Duration: config.Duration(long), Break: true})
+	return seq, true
+}
+
+func intervalsFromConfig(ivs []config.Interval) []focotimer.Interval {
+	var out []focotimer.Interval
+	for _, iv := range ivs {
+		out = append(out, focotimer.Interval{
+			Name:     iv.Name,
+			Duration: time.Duration(iv.Duration),
+			Phases:   intervalsFromConfig(iv.Phases),
+			Break:    iv.Break,
+		})
+	}
+	return out
+}
+
+func enabledIntegrations(cfg *config.Config) []integrations.Integration {
+	list := []integrations.Integration{
+		integrations.NewRecorder(history.DefaultPath()),
+	}
+	if cfg.Git.AutoLabel {
+		list = append(list, integrations.NewGitLabel(focotimer.GTimerManager, cfg.Git.Workspace, cfg.Git.FollowFocus))
+	}
+	if gh, jira := cfg.Issues.GitHub, cfg.Issues.Jira; gh.Comment || jira.Worklog {
+		list = append(list, &integrations.IssueLinker{
+			GitHub: integrations.GitHubOptions{Token: gh.Token, Repo: gh.Repo, APIURL: gh.APIURL, Comment: gh.Comment},
+			Jira:   integrations.JiraOptions{URL: jira.URL, User: jira.User, Token: jira.Token, Worklog: jira.Worklog},
+		})
+	}
+	if h := cfg.Hooks; h.OnWorkStart != "" || h.OnWorkEnd != "" || h.OnBreakStart != "" || h.OnBreakEnd != "" || h.OnPause != "" || h.OnResume != "" {
+		list = append(list, &integrations.ExecHooks{
+			WorkStart:  h.OnWorkStart,
+			WorkEnd:    h.OnWorkEnd,
+			BreakStart: h.OnBreakStart,
+			BreakEnd:   h.OnBreakEnd,
+			Pause:      h.OnPause,
+			Resume:     h.OnResume,
+			Timeout:    time.Duration(h.Timeout),
+		})
+	}
+	if hooks := webhooksFromConfig(cfg.Webhooks); hooks != nil {
+		list = append(list, hooks)
+	}
+	if cfg.Notifications.Enabled {
+		list = append(list, &integrations.Notifications{
+			Work:         notificationFromConfig("work", cfg.Notifications.Work),
+			Break:        notificationFromConfig("break", cfg.Notifications.Break),
+			Dispatch:     dispatcherFromConfig(cfg).Dispatch,
+			WorkActions:  notificationActionsFromConfig(cfg.Notifications.Work.Actions),
+			BreakActions: notificationActionsFromConfig(cfg.Notifications.Break.Actions),
+		})
+	}
+	return list
+}
+
+func notificationFromConfig(name string, cfg config.NotificationConfig) notify.Notification {
+	urgency, err := notify.ParseUrgency(cfg.Urgency)
+	if err != nil {
+		log.Printf("config: notifications: %s: %v", name, err)
+	}
+	return notify.Notification{Summary: cfg.Title, Body: cfg.Body, Urgency: urgency}
+}
+
+// notificationActionsFromConfig keeps nil, the default buttons, apart from
+// an empty list, none.
+func notificationActionsFromConfig(actions []config.NotificationAction) []integrations.NotificationAction {
+	if actions == nil {
+		return nil
+	}
+	out := make([]integrations.NotificationAction, len(actions))
+	for i, a := range actions {
+		out[i] = integrations.NotificationAction{Label: a.Label, Commands: a.Commands}
+	}
+	return out
+}
+
+func webhooksFromConfig(cfg config.WebhooksConfig) *integrations.Webhooks {
+	if len(cfg.Hooks) == 0 {
+		return nil
+	}
+	w := &integrations.Webhooks{Retries: cfg.Retries}
+	if cfg.Timeout > 0 {
+		w.Client = &http.Client{Timeout: time.Duration(cfg.Timeout)}
+	}
+	for _, h := range cfg.Hooks {
+		for _, ev := range h.Events {
+			if ev != integrations.HookStarted && ev != integrations.HookCompleted && ev != integrations.HookAborted {
+				log.Printf("config: webhook %s: unknown event %q", h.URL, ev)
+			}
+		}
+		w.Hooks = append(w.Hooks, integrations.Webhook{URL: h.URL, Events: h.Events, Headers: h.Headers})
+	}
+	return w
+}
+
+func ambientFromConfig(cfg config.AmbientConfig) *audio.Ambient {
+	if cfg.File == "" {
+		return nil
+	}
+	volume := cfg.Volume
+	if volume <= 0 {
+		volume = 1
+	}
+	fadeOver := 30 * time.Second
+	if cfg.FadeOver != nil {
+		fadeOver = time.Duration(*cfg.FadeOver)
+	}
+	return audio.NewAmbient(audio.NewMPV(cfg.File), volume, fadeOver)
+}
+
+func chimeFromConfig(cfg config.SoundsConfig) *audio.Chime {
+	if cfg.Default == "" && len(cfg.Profiles) == 0 && len(cfg.Phases) == 0 {
+		return nil
+	}
+	return audio.NewChime(audio.Sounds{Default: cfg.Default, Profiles: cfg.Profiles, Phases: cfg.Phases})
+}
+
+func countdownFromConfig(cfg config.TickConfig) *audio.Countdown {
+	if !cfg.Enabled || cfg.File == "" {
+		return nil
+	}
+	volume := cfg.Volume
+	if volume <= 0 {
+		volume = 0.3
+	}
+	last := time.Duration(cfg.Last)
+	if last <= 0 {
+		last = 10 * time.Second
+	}
+	return audio.NewCountdown(cfg.File, volume, last)
+}
+
+func remindersFromConfig(cfg config.RemindersConfig) []focotimer.Reminder {
+	var points []focotimer.Reminder
+	for _, at := range cfg.At {
+		r, err := focotimer.ParseReminder(at)
+		if err != nil {
+			log.Printf("config: reminders: %v", err)
+			continue
+		}
+		points = append(points, r)
+	}
+	return points
+}
+
+// reminderAlertsFromConfig marks the reminder points of cfg, and the
+// warning when warn is set, e.g. by -warn-before.
+func reminderAlertsFromConfig(cfg config.RemindersConfig, warn time.Duration) *integrations.Reminders {
+	points := len(cfg.At) > 0 && (cfg.Notify || cfg.Sound != "")
+	if !points && warn <= 0 {
+		return nil
+	}
+	r := &integrations.Reminders{}
+	if warn > 0 {
+		r.Warnings = notify.Default()
+	}
+	if !points {
+		return r
+	}
+	if cfg.Notify {
+		r.Notifier = notify.Default()
+	}
+	if file := cfg.Sound; file != "" {
+		volume := cfg.Volume
+		if volume <= 0 {
+			volume = 0.5
+		}
+		r.Play = func() error { return audio.PlayAt(file, volume) }
+	}
+	return r
+}
+
+func callWatchFromConfig(cfg config.CallsConfig) *integrations.CallWatch {
+	if cfg.Action == "" {
+		return nil
+	}
+	action, err := integrations.ParseCallAction(cfg.Action)
+	if err != nil {
+		log.Printf("config: calls: %v", err)
+		return nil
+	}
+	detector := integrations.AnyCall{
+		integrations.NewMicInUse(cfg.Apps),
+		integrations.Processes{Names: cfg.Processes},
+	}
+	return integrations.NewCallWatch(focotimer.GTimerManager, detector, action)
+}
+
+// breakWatchFromConfig escalates ignored breaks as configured, or returns
+// nil when no escalation is set. Its notification opens the timer in the
+// window of manager when clicked; without a manager, when headless, it
+// only notifies and never covers the screen.
+func breakWatchFromConfig(cfg config.BreaksConfig, manager *AppManager) *integrations.BreakWatch {
+	policy := integrations.BreakPolicy{
+		Notify:  time.Duration(cfg.Notify),
+		Overlay: time.Duration(cfg.Overlay),
+		Lock:    time.Duration(cfg.Lock),
+	}
+	if policy == (integrations.BreakPolicy{}) {
+		return nil
+	}
+	threshold := time.Duration(cfg.Threshold)
+	if threshold <= 0 {
+		threshold = 15 * time.Second
+	}
+	actions := integrations.BreakActions{
+		Notify: integrations.DesktopNotify(nil, nil),
+		Lock:   integrations.LockCommand(cfg.LockCommand),
+	}
+	if manager != nil {
+		actions.Notify = integrations.DesktopNotify(nil, func() {
+			if err := manager.Open(pageLink{page: TimerStopped}.String()); err != nil {
+				log.Printf("open: %v", err)
+			}
+		})
+		actions.Overlay = (&breakOverlay{monitor: manager.overlayMonitor, dots: manager.router.timer.completedDots}).Show
+	}
+	active := idle.Active(idle.XPrintIdle{}, threshold)
+	return integrations.NewBreakWatch(focotimer.GTimerManager, active, policy, actions)
+}
+
+// guiCommand shows the window, or closes it when it is open.
+func guiCommand(manager *AppManager) focotimer.CommandFunc {
+	return func(args []string) error {
+		manager.ToggleState()
+		return nil
+	}
+}
+
+// openCommand is the "open <page>" command, showing the page in the
+// window of manager.
+func openCommand(manager *AppManager) focotimer.CommandFunc {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: open <page>")
+		}
+		return manager.Open(args[0])
+	}
+}
+
+// errHeadless answers the window commands when running headless.
+var errHeadless = errors.New("running headless, without a window")
+
+// handleWindowCommands adds "gui", "open" and "close" to d, showing or
+// closing the window of manager, or failing without one.
+func handleWindowCommands(d *focotimer.Dispatcher, manager *AppManager) {
+	if manager == nil {
+		fail := func(args []string) error { return errHeadless }
+		d.Handle("gui", fail)
+		d.Handle("open", fail)
+		d.Handle(keymap.CloseCommand, fail)
+		return
+	}
+	d.Handle("gui", guiCommand(manager))
+	d.Handle("open", openCommand(manager))
+	d.Handle(keymap.CloseCommand, func(args []string) error { manager.requestClose(); return nil })
+}
+
+// dispatcherFromConfig builds a command dispatcher that knows the user's
+// aliases, sequences and custom commands.
+func dispatcherFromConfig(cfg *config.Config) *focotimer.Dispatcher {
+	d := focotimer.NewDispatcher(focotimer.GTimerManager)
+	for name, target := range cfg.Aliases {
+		if err := d.Alias(name, target); err != nil {
+			log.Printf("config: skipping alias %q: %v", name, err)
+		}
+	}
+	for name, seq := range sequencesFromConfig(cfg) {
+		if err := d.DefineSequence(name, seq); err != nil {
+			log.Printf("config: skipping sequence %q: %v", name, err)
+		}
+	}
+	for name, steps := range cfg.Commands {
+		if err := d.Define(name, steps); err != nil {
+			log.Printf("config: skipping command %q: %v", name, err)
+		}
+	}
+	return d
+}
+
+// activatedSockets returns the control and events sockets passed by
+// systemd socket activation, if any. The socket named "events" streams
+// events; any other serves the control API.
+func activatedSockets() (ctl, events net.Listener) {
+	lns, err := systemd.Listeners()
+	if err != nil {
+		log.Printf("systemd: %v", err)
+		return nil, nil
+	}
+	for _, l := range lns {
+		switch {
+		case l.Name == "events" && events == nil:
+			events = l
+		case l.Name != "events" && ctl == nil:
+			ctl = l
+		default:
+			log.Printf("systemd: ignoring extra socket %q", l.Name)
+			l.Close()
+		}
+	}
+	return ctl, events
+}
+
+func profilesFromConfig(cfg config.TriggersConfig) map[string]ipc.Profile {
+	profiles := make(map[string]ipc.Profile, len(cfg.Profiles))
+	for name, p := range cfg.Profiles {
+		profiles[name] = ipc.Profile{Duration: time.Duration(p.Duration), Label: p.Label, Issue: p.Issue}
+	}
+	return profiles
+}
+
+func mqttFromConfig(cfg *config.Config) *ipc.MQTT {
+	if cfg.MQTT.Broker == "" {
+		return nil
+	}
+	t := cfg.MQTT.Topics
+	return ipc.NewMQTT(focotimer.GTimerManager, dispatcherFromConfig(cfg), ipc.MQTTOptions{
+		Broker:   cfg.MQTT.Broker,
+		ClientID: cfg.MQTT.ClientID,
+		Username: cfg.MQTT.Username,
+		Password: cfg.MQTT.Password,
+		Topics: ipc.MQTTTopics{
+			State:        t.State,
+			Status:       t.Status,
+			Remaining:    t.Remaining,
+			Event:        t.Event,
+			Command:      t.Command,
+			Availability: t.Availability,
+		},
+		Discovery: cfg.MQTT.Discovery,
+	})
+}
+
+func clicksFromConfig(cfg config.PolybarConfig) map[polybar.MouseButton]string {
+	if clicks, ok := cfg.SegmentClicks[string(polybar.SegmentTimer)]; ok {
+		return parseClicks(clicks)
+	}
+	return parseClicks(cfg.Clicks)
+}
+
+// segmentClicksFromConfig returns the configured clicks of the buttons.
+func segmentClicksFromConfig(cfg config.PolybarConfig) map[polybar.Segment]map[polybar.MouseButton]string {
+	segments := make(map[polybar.Segment]map[polybar.MouseButton]string)
+	for name, clicks := range cfg.SegmentClicks {
+		seg, err := polybar.ParseSegment(name)
+		if err != nil {
+			log.Printf("config: polybar: %v", err)
+			continue
+		}
+		if seg != polybar.SegmentTimer {
+			segments[seg] = parseClicks(clicks)
+		}
+	}
+	return segments
+}
+
+func parseClicks(names map[string]string) map[polybar.MouseButton]string {
+	if names == nil {
+		return nil
+	}
+	clicks := make(map[polybar.MouseButton]string, len(names))
+	for name, cmd := range names {
+		b, err := polybar.ParseMouseButton(name)
+		if err != nil {
+			log.Printf("config: polybar: %v", err)
+			continue
+		}
+		clicks[b] = cmd
+	}
+	return clicks
+}
+
+func fitFromConfig(cfg config.PolybarConfig) polybar.Fit {
+	fit := polybar.Fit{MaxWidth: cfg.MaxWidth, Icon: cfg.Icon}
+	if cfg.Variant != "" {
+		v, err := polybar.ParseVariant(cfg.Variant)
+		if err != nil {
+			log.Printf("config: polybar: %v", err)
+		}
+		fit.Variant = v
+	}
+	return fit
+}
+
+// colorsFromConfig overlays the configured state colors on the defaults,
+// or returns nil when coloring is off.
+func colorsFromConfig(cfg config.PolybarConfig) *polybar.Colors {
+	if cfg.Colors == nil && !cfg.Flash {
+		return nil
+	}
+	colors := polybar.DefaultColors
+	colors.Threshold = time.Duration(cfg.ColorThreshold)
+	colors.Flash = cfg.Flash
+	for state, c := range cfg.Colors {
+		switch state {
+		case "running":
+			colors.Running = c
+		case "break":
+			colors.Break = c
+		case "paused":
+			colors.Paused = c
+		case "completed":
+			colors.Completed = c
+		case "final":
+			colors.Final = c
+		default:
+			log.Printf("config: polybar: unknown color state %q", state)
+		}
+	}
+	if err := colors.Validate(); err != nil {
+		log.Printf("config: polybar: %v, using the default colors", err)
+		return &polybar.DefaultColors
+	}
+	return &colors
+}
+
+func digestFromConfig(cfg config.DigestConfig) *history.Digest {
+	if cfg.Period == "" || (cfg.Path == "" && cfg.Command == "") {
+		return nil
+	}
+	return &history.Digest{
+		Period:      history.Period(cfg.Period),
+		HistoryPath: history.DefaultPath(),
+		Path:        cfg.Path,
+		Command:     cfg.Command,
+		StatePath:   history.DefaultDigestState(),
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/config"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/gio/font"
+	"github.com/d093w1z/gio/font/opentype"
+	"github.com/d093w1z/gio/text"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// ---------------- COUNTDOWN ----------------
+// The remaining time can be written in a font of its own: an installed
+// family, which the shaper finds among the system fonts, or a font file,
+// which every window loads into a shaper of its own. The progress around
+// it is the gradient ring or a smooth arc.
+//
+// Scrolling over the ring adds or takes off a step, and dragging round the
+// ring of an idle timer sets the duration, a full turn being DragTurn.
+
+const (
+	defaultScrollStep = time.Minute
+	defaultDragTurn   = time.Hour
+)
+
+var (
+	countdownMu sync.Mutex
+	// fontFaces are the faces of the font file, none for a family.
+	fontFaces []font.FontFace
+	// fontVersion counts the fonts applied, so windows know to reload.
+	fontVersion int
+
+	scrollStep = defaultScrollStep
+	dragTurn   = defaultDragTurn
+)
+
+// countdownStyleFromConfig resolves cfg to how Timer shows the time and
+// the font faces to load for it. Sizes left out keep their default.
+func countdownStyleFromConfig(cfg config.CountdownConfig) (widgets.CountdownStyle, []font.FontFace, error) {
+	f := widgets.DefaultCountdown
+	switch cfg.Ring {
+	case "", "gradient":
+	case "arc":
+		f.Arc = true
+	default:
+		return f, nil, fmt.Errorf("unknown ring %q, expected gradient or arc", cfg.Ring)
+	}
+	switch cfg.Direction {
+	case "", "clockwise":
+	case "counterclockwise":
+		f.Ring.CounterClockwise = true
+	default:
+		return f, nil, fmt.Errorf("unknown direction %q, expected clockwise or counterclockwise", cfg.Direction)
+	}
+	if cfg.RingDiameter > 0 {
+		f.Ring.Diameter = unit.Dp(cfg.RingDiameter)
+	}
+	if cfg.RingThickness > 0 {
+		f.Ring.Thickness = unit.Dp(cfg.RingThickness)
+	}
+	if f.Ring.Thickness*2 > f.Ring.Diameter {
+		return f, nil, fmt.Errorf("ring thickness %v is over half its diameter %v", f.Ring.Thickness, f.Ring.Diameter)
+	}
+	f.Ring.RoundCaps = cfg.RoundCaps
+	if cfg.Size > 0 {
+		f.Size = unit.Sp(cfg.Size)
+	}
+	if cfg.MiniSize > 0 {
+		f.MiniSize = unit.Sp(cfg.MiniSize)
+	}
+	if !isFontFile(cfg.Font) {
+		f.Typeface = font.Typeface(cfg.Font)
+		return f, nil, nil
+	}
+	data, err := os.ReadFile(cfg.Font)
+	if err != nil {
+		return f, nil, err
+	}
+	faces, err := opentype.ParseCollection(data)
+	if err != nil {
+		return f, nil, fmt.Errorf("%s: %w", cfg.Font, err)
+	}
+	f.Typeface = faces[0].Font.Typeface
+	return f, faces, nil
+}
+
+// isFontFile tells a path, "fonts/Mono.ttf", from a family name.
+func isFontFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttf", ".otf", ".ttc", ".otc":
+		return true
+	}
+	return strings.ContainsRune(name, filepath.Separator)
+}
+
+// applyCountdown switches the countdown to cfg; on an error it keeps the
+// default font.
+func applyCountdown(cfg config.CountdownConfig) error {
+	f, faces, err := countdownStyleFromConfig(cfg)
+	if err != nil {
+		f.Typeface = ""
+	}
+	widgets.UseCountdown(f)
+	countdownMu.Lock()
+	fontFaces = faces
+	fontVersion++
+	scrollStep, dragTurn = defaultScrollStep, defaultDragTurn
+	if cfg.ScrollStep > 0 {
+		scrollStep = time.Duration(cfg.ScrollStep)
+	}
+	if cfg.DragTurn > 0 {
+		dragTurn = time.Duration(cfg.DragTurn)
+	}
+	countdownMu.Unlock()
+	redraw()
+	return err
+}
+
+// loadFont gives th a shaper with the countdown font when it changed
+// since version, and returns the version th now has.
+func loadFont(th *material.Theme, version int) int {
+	countdownMu.Lock()
+	defer countdownMu.Unlock()
+	if version == fontVersion {
+		return version
+	}
+	// the faces loaded become the default; keep the rest of the window
+	// in the system's
+	th.Face = ""
+	if len(fontFaces) > 0 {
+		th.Face = "sans-serif"
+	}
+	th.Shaper = text.NewShaper(text.WithCollection(fontFaces))
+	return fontVersion
+}
+
+// scrollDuration adds notches scroll steps to the duration, never going
+// below one step.
+func scrollDuration(notches int) {
+	countdownMu.Lock()
+	step := scrollStep
+	countdownMu.Unlock()
+	tm := focotimer.GTimerManager
+	tm.SetDuration(max(step, tm.Duration()+time.Duration(notches)*step))
+}
+
+// dragDuration sets the duration of an idle timer to turn of a full turn,
+// to the nearest scroll step and at least one.
+func dragDuration(turn float64) {
+	tm := focotimer.GTimerManager
+	if tm.State().Status != focotimer.StatusIdle {
+		return
+	}
+	countdownMu.Lock()
+	step, full := scrollStep, dragTurn
+	countdownMu.Unlock()
+	d := time.Duration(turn * float64(full)).Round(step)
+	tm.SetDuration(max(step, d))
+}
+
+// editDuration sets the duration of an idle timer to text typed into the
+// countdown, as mm:ss or as "25m".
+func editDuration(text string) error {
+	tm := focotimer.GTimerManager
+	if tm.State().Status != focotimer.StatusIdle {
+		return errors.New("the timer is running")
+	}
+	d, err := focotimer.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	tm.SetDuration(d)
+	return nil
+}
+
+// newTimerView is a TimerView whose ring and countdown set the duration.
+func newTimerView() *widgets.TimerView {
+	v := widgets.NewTimerView()
+	v.Scrolled, v.Dragged = scrollDuration, dragDuration
+	v.Edit = editDuration
+	v.Editable = func() bool {
+		return focotimer.GTimerManager.State().Status == focotimer.StatusIdle
+	}
+	return v
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+// ---------------- WINDOW GEOMETRY ----------------
+// The window opens where it was last closed, unless it is pinned to a
+// corner of the screen or given a geometry, on the whole screen or on one
+// monitor. Gio sizes the window but cannot place it, and tells nothing of
+// the monitors on X11 and Wayland, so xdotool moves it and reads back
+// where it is, and keeps it above other windows or on every workspace when
+// asked to, while xrandr lists the monitors.
+
+const windowTitle = "Pomodoro Timer"
+
+// geometry is an X11-style window geometry, "300x300+20+20". The size is
+// in dp and the offset in pixels; a negative offset, "-20-20", counts
+// from the right and bottom edges of the screen.
+type geometry struct {
+	W, H          int
+	X, Y          int
+	Right, Bottom bool
+	Placed        bool // the offset is set
+	// Monitor is the monitor the offset counts from, the whole screen when
+	// empty, and Center puts the window in its middle instead; neither is
+	// remembered
+	Monitor string
+	Center  bool
+}
+
+var geometryRe = regexp.MustCompile(`^(?:(\d+)x(\d+))?(?:([+-]\d+)([+-]\d+))?$`)
+
+func parseGeometry(s string) (geometry, error) {
+	m := geometryRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || (m[1] == "" && m[3] == "") {
+		return geometry{}, fmt.Errorf("invalid geometry %q, expected WxH+X+Y, WxH or +X+Y", s)
+	}
+	var g geometry
+	if m[1] != "" {
+		g.W, _ = strconv.Atoi(m[1])
+		g.H, _ = strconv.Atoi(m[2])
+		if g.W == 0 || g.H == 0 {
+			return geometry{}, fmt.Errorf("invalid geometry %q: empty size", s)
+		}
+	}
+	if m[3] != "" {
+		g.Placed = true
+		g.Right, g.Bottom = m[3][0] == '-', m[4][0] == '-'
+		g.X, _ = strconv.Atoi(m[3][1:])
+		g.Y, _ = strconv.Atoi(m[4][1:])
+	}
+	return g, nil
+}
+
+func (g geometry) String() string {
+	var b strings.Builder
+	if g.W > 0 && g.H > 0 {
+		fmt.Fprintf(&b, "%dx%d", g.W, g.H)
+	}
+	if g.Placed {
+		sign := func(neg bool) string {
+			if neg {
+				return "-"
+			}
+			return "+"
+		}
+		fmt.Fprintf(&b, "%s%d%s%d", sign(g.Right), g.X, sign(g.Bottom), g.Y)
+	}
+	return b.String()
+}
+
+// over is g with the size and the offset of o, where o has them.
+func (g geometry) over(o geometry) geometry {
+	if o.W > 0 && o.H > 0 {
+		g.W, g.H = o.W, o.H
+	}
+	if o.Placed {
+		g.X, g.Y, g.Right, g.Bottom, g.Placed = o.X, o.Y, o.Right, o.Bottom, true
+		g.Monitor, g.Center = o.Monitor, o.Center
+	}
+	return g
+}
+
+// cornerGeometry pins the window to a corner of the screen, margin pixels
+// from its edges.
+func cornerGeometry(corner string, margin int) (geometry, error) {
+	g := geometry{X: margin, Y: margin, Placed: true}
+	switch corner {
+	case "top-left":
+	case "top-right":
+		g.Right = true
+	case "bottom-left":
+		g.Bottom = true
+	case "bottom-right":
+		g.Right, g.Bottom = true, true
+	default:
+		return geometry{}, fmt.Errorf("unknown corner %q, expected top-left, top-right, bottom-left or bottom-right", corner)
+	}
+	return g, nil
+}
+
+// windowCorners are the choices offered in Settings; "none" leaves the
+// window where it was last closed.
+var windowCorners = []string{"none", "top-left", "top-right", "bottom-left", "bottom-right"}
+
+// ---------------- MONITORS ----------------
+
+// monitor is an area of the screen shown by one output, in pixels.
+type monitor struct {
+	Name       string
+	X, Y, W, H int
+	Primary    bool
+}
+
+// monitorRe matches a line of "xrandr --listactivemonitors", e.g.
+// " 0: +*DP-1 2560/597x1440/336+0+0  DP-1".
+var monitorRe = regexp.MustCompile(`^\s*\d+:\s+\+?(\*?)(\S+)\s+(\d+)/\d+x(\d+)/\d+\+(\d+)\+(\d+)`)
+
+// parseMonitors reads the output of "xrandr --listactivemonitors".
+func parseMonitors(out string) []monitor {
+	var ms []monitor
+	for _, line := range strings.Split(out, "\n") {
+		m := monitorRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		mon := monitor{Name: m[2], Primary: m[1] == "*"}
+		mon.W, _ = strconv.Atoi(m[3])
+		mon.H, _ = strconv.Atoi(m[4])
+		mon.X, _ = strconv.Atoi(m[5])
+		mon.Y, _ = strconv.Atoi(m[6])
+		ms = append(ms, mon)
+	}
+	return ms
+}
+
+// listMonitors asks xrandr for the active monitors.
+func listMonitors() ([]monitor, error) {
+	out, err := exec.Command("xrandr", "--listactivemonitors").Output()
+	if err != nil {
+		return nil, fmt.Errorf("xrandr: %w", err)
+	}
+	return parseMonitors(string(out)), nil
+}
+
+// pickMonitor finds the monitor called name among ms: "primary", its
+// position from 0 as xrandr lists it, or its output name, e.g. "DP-1".
+func pickMonitor(ms []monitor, name string) (monitor, error) {
+	for i, m := range ms {
+		if m.Name == name || strconv.Itoa(i) == name || (name == "primary" && m.Primary) {
+			return m, nil
+		}
+	}
+	if name == "primary" && len(ms) > 0 {
+		return ms[0], nil
+	}
+	return monitor{}, fmt.Errorf("no monitor %q", name)
+}
+
+// findMonitor is the area of the monitor called name.
+func findMonitor(name string) (monitor, error) {
+	ms, err := listMonitors()
+	if err != nil {
+		return monitor{}, err
+	}
+	return pickMonitor(ms, name)
+}
+
+// ---------------- REMEMBERED GEOMETRY ----------------
+
+func defaultGeometryPath() string {
+	return filepath.Join(history.Dir(), "window")
+}
+
+// loadGeometry is the geometry saved at path; none if it was never saved.
+func loadGeometry(path string) geometry {
+	data, err := safefile.File{Path: path}.Read()
+	if errors.Is(err, os.ErrNotExist) {
+		return geometry{}
+	}
+	if err != nil && !errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("window: %v", err)
+		return geometry{}
+	}
+	g, err := parseGeometry(string(data))
+	if err != nil {
+		log.Printf("window: %s: %v", path, err)
+	}
+	return g
+}
+
+func saveGeometry(path string, g geometry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return safefile.File{Path: path}.Write([]byte(g.String() + "\n"))
+}
+
+// ---------------- PLACEMENT ----------------
+
+// xdotool runs xdotool and returns its output.
+func xdotool(args ...string) (string, error) {
+	out, err := exec.Command("xdotool", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("xdotool %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// findWindow is the X window id of the focotimer window.
+func findWindow() (string, error) {
+	return findTitled(windowTitle)
+}
+
+// findTitled is the X window id of the window titled title.
+func findTitled(title string) (string, error) {
+	out, err := xdotool("search", "--name", "^"+regexp.QuoteMeta(title)+"$")
+	if err != nil {
+		return "", err
+	}
+	id, _, _ := strings.Cut(out, "\n")
+	return id, nil
+}
+
+// windowShell reads the values of "xdotool getwindowgeometry --shell" or
+// "getdisplaygeometry --shell", e.g. X, Y, WIDTH and HEIGHT.
+func windowShell(args ...string) (map[string]int, error) {
+	out, err := xdotool(args...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if n, err := strconv.Atoi(v); ok && err == nil {
+			values[k] = n
+		}
+	}
+	return values, nil
+}
+
+// placeWindow moves the window to the offset of g.
+func placeWindow(g geometry) error {
+	id, err := findWindow()
+	if err != nil {
+		return err
+	}
+	if g.Monitor == "" && !g.Right && !g.Bottom && !g.Center {
+		_, err = xdotool("windowmove", id, strconv.Itoa(g.X), strconv.Itoa(g.Y))
+		return err
+	}
+	var screen monitor
+	if g.Monitor != "" {
+		screen, err = findMonitor(g.Monitor)
+	} else {
+		var values map[string]int
+		values, err = windowShell("getdisplaygeometry", "--shell")
+		screen.W, screen.H = values["WIDTH"], values["HEIGHT"]
+	}
+	if err != nil {
+		return err
+	}
+	win, err := windowShell("getwindowgeometry", "--shell", id)
+	if err != nil {
+		return err
+	}
+	x, y := g.offset(screen, win["WIDTH"], win["HEIGHT"])
+	_, err = xdotool("windowmove", id, strconv.Itoa(x), strconv.Itoa(y))
+	return err
+}
+
+// offset is where a window w by h pixels goes on screen to be at the
+// offset of g.
+func (g geometry) offset(screen monitor, w, h int) (x, y int) {
+	if g.Center {
+		return screen.X + (screen.W-w)/2, screen.Y + (screen.H-h)/2
+	}
+	x, y = screen.X+g.X, screen.Y+g.Y
+	if g.Right {
+		x = screen.X + screen.W - w - g.X
+	}
+	if g.Bottom {
+		y = screen.Y + screen.H - h - g.Y
+	}
+	return x, y
+}
+
+// windowStates are the EWMH states of the window to set: ABOVE keeps it
+// over other windows and STICKY shows it on every workspace.
+func windowStates(onTop, sticky bool) []string {
+	var states []string
+	if onTop {
+		states = append(states, "ABOVE")
+	}
+	if sticky {
+		states = append(states, "STICKY")
+	}
+	return states
+}
+
+// setWindowStates adds the EWMH states to the window.
+func setWindowStates(states []string) error {
+	id, err := findWindow()
+	if err != nil {
+		return err
+	}
+	for _, s := range states {
+		if _, err := xdotool("windowstate", "--add", s, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blurBehind asks the compositor to blur behind the window through the
+// _KDE_NET_WM_BLUR_BEHIND_REGION property, which KWin and picom read; a
+// region of 0 blurs behind all of it.
+func blurBehind() error {
+	id, err := findWindow()
+	if err != nil {
+		return err
+	}
+	const prop = "_KDE_NET_WM_BLUR_BEHIND_REGION"
+	if out, err := exec.Command("xprop", "-id", id, "-f", prop, "32c", "-set", prop, "0").CombinedOutput(); err != nil {
+		return fmt.Errorf("xprop: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// windowPosition is where the window is on the screen.
+func windowPosition() (x, y int, err error) {
+	id, err := findWindow()
+	if err != nil {
+		return 0, 0, err
+	}
+	win, err := windowShell("getwindowgeometry", "--shell", id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return win["X"], win["Y"], nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- INSIGHTS PAGE ----------------
+type insightsPage struct {
+	r *Router
+
+	mu       sync.Mutex
+	insights history.Insights
+
+	back *widgets.ButtonState
+}
+
+func newInsightsPage(r *Router) *insightsPage {
+	return &insightsPage{r: r, back: widgets.NewButton("Back", icons.NavigationArrowBack, 10)}
+}
+
+// load works the insights out of the history, read afresh.
+func (p *insightsPage) load() {
+	records, err := history.Load(history.DefaultPath())
+	if err != nil {
+		log.Printf("insights: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.insights = history.EnergyInsights(records)
+}
+
+func (p *insightsPage) current() history.Insights {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.insights
+}
+
+func (p *insightsPage) Layout(th *material.Theme, gtx C) D {
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			widgets.EnergyInsights(th, p.current()),
+			p.back.Layout(th, p.r.goBack),
+		)
+	})
+}
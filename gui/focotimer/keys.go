@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/config"
+	"github.com/d093w1z/focotimer/keymap"
+	"github.com/d093w1z/focotimer/safefile"
+	"github.com/d093w1z/gio/io/key"
+	"github.com/d093w1z/gio/widget"
+)
+
+// ---------------- KEY BINDINGS ----------------
+// Keys pressed in the window are looked up in the current keymap and run as
+// commands; global hotkeys run theirs while the window is unfocused or
+// closed. The keymap is replaced whenever the config file changes, and
+// the Settings page captures new keys for an action and saves them.
+
+// bindableActions are the commands offered for rebinding in Settings.
+var bindableActions = []string{"toggle", "inc", "dec", "skip", "skip-break", "reset", "settings", "timeline", "insights", "tasks", "stats", "back", keymap.CloseCommand}
+
+// globalActions are the commands offered as global hotkeys in Settings:
+// starting or pausing the session, and showing or hiding the window.
+var globalActions = []string{"toggle", "gui"}
+
+func clickables(actions []string) map[string]*widget.Clickable {
+	m := make(map[string]*widget.Clickable, len(actions))
+	for _, action := range actions {
+		m[action] = new(widget.Clickable)
+	}
+	return m
+}
+
+// capture is an action waiting for a key press, for the window or as a
+// global hotkey.
+type capture struct {
+	action string
+	global bool
+}
+
+var (
+	keysMu      sync.Mutex
+	keys        *keymap.Keymap
+	guiSpecs    map[string]string // the window bindings as configured
+	globalSpecs map[string]string // the global hotkeys as configured
+	capturing   capture
+	keysErr     string // last binding problem, shown in Settings
+	keyRunner   *focotimer.Dispatcher
+	hotkeys     keymap.Grabber
+)
+
+// applyBindings validates the bindings and switches to them. On error the
+// previous bindings stay active.
+func applyBindings(cfg config.BindingsConfig) error {
+	gui := cfg.GUI
+	if gui == nil {
+		gui = keymap.DefaultGUI
+	}
+	km, err := keymap.New(gui, cfg.Global)
+	keysMu.Lock()
+	if err != nil {
+		keysErr = err.Error()
+		keysMu.Unlock()
+		return err
+	}
+	keys, guiSpecs, globalSpecs, keysErr = km, gui, cfg.Global, ""
+	grabber := hotkeys
+	keysMu.Unlock()
+
+	if grabber != nil {
+		if err := grabber.Grab(km.Global, runKeyCommand); err != nil {
+			setKeysErr(err)
+			return fmt.Errorf("global hotkeys: %w", err)
+		}
+	}
+	return nil
+}
+
+func setKeysErr(err error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keysErr = err.Error()
+}
+
+// setKeyRunner makes d run bound commands, adding the window-only
+// commands of r to it, when given. Toggle and reset go through the page
+// actions, so the page follows the timer as it does for the buttons.
+func setKeyRunner(d *focotimer.Dispatcher, r *Router) {
+	if r != nil {
+		d.Handle("toggle", func(args []string) error { r.togglePlayPause(); return nil })
+		d.Handle("reset", func(args []string) error { r.stopSession(); return nil })
+		d.Handle("skip-break", func(args []string) error {
+			if r.Current() == Break {
+				skipBreak()
+			}
+			return nil
+		})
+		d.Handle("settings", func(args []string) error { r.openSettings(); return nil })
+		d.Handle("timeline", func(args []string) error { r.openTimeline(); return nil })
+		d.Handle("insights", func(args []string) error { r.openInsights(); return nil })
+		d.Handle("tasks", func(args []string) error { r.openTasks(); return nil })
+		d.Handle("stats", func(args []string) error { r.openStats(); return nil })
+		d.Handle("back", func(args []string) error { r.goBack(); return nil })
+	}
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keyRunner = d
+}
+
+func runKeyCommand(line string) {
+	keysMu.Lock()
+	if keyRunner == nil {
+		keysMu.Unlock()
+		setKeyRunner(focotimer.NewDispatcher(focotimer.GTimerManager), nil)
+		keysMu.Lock()
+	}
+	d := keyRunner
+	keysMu.Unlock()
+	if err := d.Dispatch(line); err != nil {
+		log.Printf("bindings: %q: %v", line, err)
+	}
+}
+
+// handleKey runs the binding of c, or completes a key capture with it. It
+// returns false for the close command, Escape unless rebound, which asks
+// the window to close.
+func handleKey(c keymap.Chord) bool {
+	keysMu.Lock()
+	cp := capturing
+	capturing = capture{}
+	km := keys
+	keysMu.Unlock()
+
+	if cp.action != "" {
+		// Escape cancels the capture
+		if c != keymap.Escape {
+			bindCaptured(cp, c)
+		}
+		return true
+	}
+	if km == nil {
+		return c != keymap.Escape
+	}
+	if cmd, ok := km.Lookup(c); ok {
+		if cmd == keymap.CloseCommand {
+			return false
+		}
+		runKeyCommand(cmd)
+	}
+	return true
+}
+
+func startCapture(action string, global bool) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	capturing = capture{action: action, global: global}
+}
+
+// bindCaptured makes c the only key of the captured action and saves the
+// result to the config file, if the new bindings are valid.
+func bindCaptured(cp capture, c keymap.Chord) {
+	keysMu.Lock()
+	specs := guiSpecs
+	if cp.global {
+		specs = globalSpecs
+	}
+	specs = keymap.Rebind(specs, cp.action, c)
+	keysMu.Unlock()
+
+	path := config.DefaultPath()
+	cfg, err := config.Load(path)
+	if errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("keys: %v", err)
+	} else if err != nil {
+		setKeysErr(err)
+		return
+	}
+	if cp.global {
+		cfg.Bindings.Global = specs
+	} else {
+		cfg.Bindings.GUI = specs
+	}
+	if err := applyBindings(cfg.Bindings); err != nil {
+		log.Printf("bindings: %v", err)
+		return
+	}
+	if err := config.Save(path, cfg); err != nil {
+		setKeysErr(err)
+	}
+}
+
+// actionKeys describes the window keys bound to action, e.g. "Space, P".
+func actionKeys(action string) string {
+	keysMu.Lock()
+	km := keys
+	keysMu.Unlock()
+	if km == nil {
+		return ""
+	}
+	return chordNames(km.Chords(action))
+}
+
+// hotkeyKeys describes the global hotkeys bound to action.
+func hotkeyKeys(action string) string {
+	keysMu.Lock()
+	km := keys
+	keysMu.Unlock()
+	if km == nil {
+		return ""
+	}
+	return chordNames(km.GlobalChords(action))
+}
+
+func chordNames(chords []keymap.Chord) string {
+	names := make([]string, len(chords))
+	for i, c := range chords {
+		names[i] = c.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+func bindingState() (cp capture, problem string) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	return capturing, keysErr
+}
+
+// --- Gio key events ---
+
+// keyModifiers are the modifiers a window binding may use.
+const keyModifiers = key.ModCtrl | key.ModShift | key.ModAlt | key.ModSuper | key.ModCommand
+
+var gioKeyNames = map[key.Name]string{
+	key.NameEscape:         "Escape",
+	key.NameReturn:         "Return",
+	key.NameEnter:          "Return",
+	key.NameLeftArrow:      "Left",
+	key.NameRightArrow:     "Right",
+	key.NameUpArrow:        "Up",
+	key.NameDownArrow:      "Down",
+	key.NameHome:           "Home",
+	key.NameEnd:            "End",
+	key.NamePageUp:         "PageUp",
+	key.NamePageDown:       "PageDown",
+	key.NameDeleteBackward: "Backspace",
+	key.NameDeleteForward:  "Delete",
+}
+
+// chordFromGio converts a key press. Presses of a lone modifier are not
+// chords.
+func chordFromGio(ev key.Event) (keymap.Chord, bool) {
+	switch ev.Name {
+	case key.NameCtrl, key.NameShift, key.NameAlt, key.NameSuper, key.NameCommand:
+		return keymap.Chord{}, false
+	}
+	name, named := gioKeyNames[ev.Name]
+	if !named {
+		name = string(ev.Name)
+	}
+	k, err := keymap.CanonicalKey(name)
+	if err != nil {
+		return keymap.Chord{}, false
+	}
+
+	c := keymap.Chord{Key: k}
+	if ev.Modifiers.Contain(key.ModCtrl) {
+		c.Mods |= keymap.Ctrl
+	}
+	if ev.Modifiers.Contain(key.ModAlt) {
+		c.Mods |= keymap.Alt
+	}
+	if ev.Modifiers.Contain(key.ModSuper) || ev.Modifiers.Contain(key.ModCommand) {
+		c.Mods |= keymap.Super
+	}
+	// Gio already applies Shift to punctuation ("+" is Shift+= on a US
+	// layout), so Shift only counts for letters and named keys
+	if ev.Modifiers.Contain(key.ModShift) {
+		r, size := utf8.DecodeRuneInString(name)
+		if named || size != len(name) || unicode.IsLetter(r) {
+			c.Mods |= keymap.Shift
+		}
+	}
+	return c, true
+}
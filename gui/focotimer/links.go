@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ---------------- DEEP LINKS ----------------
+// Every page has an address such as focotimer://timeline/2025-09-02, so the
+// CLI ("focotimer open timeline"), the "open" command and notifications can
+// bring the window up on the page they are about. The scheme is optional.
+
+const linkScheme = "focotimer://"
+
+// pageLink is a parsed page address. section narrows the page down: a day
+// of the timeline, or a part of the settings.
+type pageLink struct {
+	page    Page
+	section string
+}
+
+// linkPages maps the first part of an address to its page.
+var linkPages = map[string]Page{
+	"timer":    TimerStopped,
+	"settings": Settings,
+	"timeline": Timeline,
+	"insights": Insights,
+	"stats":    Stats,
+	"tasks":    Tasks,
+}
+
+// settingsSections are the parts of the settings page an address can
+// name.
+var settingsSections = map[string]bool{"keys": true}
+
+func parseLink(s string) (pageLink, error) {
+	path := strings.Trim(strings.TrimPrefix(strings.TrimSpace(s), linkScheme), "/")
+	name, section, _ := strings.Cut(path, "/")
+	page, ok := linkPages[name]
+	if !ok {
+		return pageLink{}, fmt.Errorf("unknown page %q", name)
+	}
+	if section == "" {
+		return pageLink{page: page}, nil
+	}
+	switch page {
+	case Timeline:
+		if _, err := time.ParseInLocation(time.DateOnly, section, time.Local); err != nil {
+			return pageLink{}, fmt.Errorf("timeline day %q: expected YYYY-MM-DD", section)
+		}
+	case Settings:
+		if !settingsSections[section] {
+			return pageLink{}, fmt.Errorf("unknown settings section %q", section)
+		}
+	default:
+		return pageLink{}, fmt.Errorf("page %q has no sections", name)
+	}
+	return pageLink{page: page, section: section}, nil
+}
+
+func (l pageLink) String() string {
+	name := l.page.String()
+	switch l.page {
+	case TimerStopped, TimerRunning, TimerFinished:
+		name = "timer"
+	}
+	if l.section != "" {
+		name += "/" + l.section
+	}
+	return linkScheme + name
+}
+
+// openLink shows the page l addresses, through the same actions as the
+// buttons.
+func (r *Router) openLink(l pageLink) {
+	switch l.page {
+	case Settings:
+		r.openSettings()
+	case Timeline:
+		day := time.Now()
+		if l.section != "" {
+			day, _ = time.ParseInLocation(time.DateOnly, l.section, time.Local)
+		}
+		r.timeline.load(day)
+		r.Show(Timeline)
+	case Insights:
+		r.openInsights()
+	case Tasks:
+		r.openTasks()
+	case Stats:
+		r.openStats()
+	default:
+		if focotimer.GTimerManager.State().Status == focotimer.StatusRunning {
+			r.Show(TimerRunning)
+		} else {
+			r.goBack()
+		}
+	}
+}
+
+// Open brings the window up on the page at link; it is the "open"
+// command.
+func (m *AppManager) Open(link string) error {
+	l, err := parseLink(link)
+	if err != nil {
+		return err
+	}
+	m.router.openLink(l)
+	go m.Start()
+	return nil
+}
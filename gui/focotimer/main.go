@@ -1,16 +1,30 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"image"
-	"image/color"
 	"log"
+	"os"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
 	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/appearance"
+	"github.com/d093w1z/focotimer/config"
 	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/gui/focotimer/tray"
 	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/idle"
+	"github.com/d093w1z/focotimer/integrations"
+	"github.com/d093w1z/focotimer/ipc"
+	"github.com/d093w1z/focotimer/keymap"
+	"github.com/d093w1z/focotimer/safefile"
+	"github.com/d093w1z/focotimer/systemd"
 	"github.com/d093w1z/gio/app"
 	"github.com/d093w1z/gio/io/event"
 	"github.com/d093w1z/gio/io/key"
@@ -18,20 +32,39 @@ import (
 	"github.com/d093w1z/gio/layout"
 	"github.com/d093w1z/gio/op"
 	"github.com/d093w1z/gio/op/clip"
-	"github.com/d093w1z/gio/op/paint"
+	"github.com/d093w1z/gio/text"
 	"github.com/d093w1z/gio/unit"
-	"github.com/d093w1z/gio/widget"
 	"github.com/d093w1z/gio/widget/material"
-	"golang.org/x/exp/shiny/materialdesign/icons"
 )
 
 type C = layout.Context
 type D = layout.Dimensions
 
 var isPolybarEnabled = flag.Bool("polybar", false, "Enable polybar output")
-
-var lastRemaining time.Duration
-var lastRemainingMu sync.RWMutex
+var autoResetAfter = flag.Duration("auto-reset", 15*time.Minute, "Reset a finished session left unacknowledged for this long (0 disables)")
+var warnBefore = flag.Duration("warn-before", 2*time.Minute, "Notify this long before a session ends (0 disables)")
+var flowCap = flag.Duration("flow-cap", 0, "Extend sessions in 5m steps up to this long while you are still typing (0 disables)")
+var eventsSocket = flag.String("events-socket", "", "Stream timer events as JSON lines on this unix socket")
+var ctlSocket = flag.String("socket", ipc.DefaultSocket(), "Serve the control API for focotimerctl on this unix socket (empty disables)")
+var rootName = flag.Bool("root-name", false, "Keep the timer in the X root window name, for dwm; see also root_name in the config")
+var statusFile = flag.String("status-file", "", "Keep the timer state as JSON in this file, for bars that poll a file")
+var statusText = flag.String("status-text", "", "Keep the timer as a line of text in this file, for conky and other tools that watch a file")
+var httpAddr = flag.String("http", "", "Serve the JSON control API on this address, e.g. :7272 on loopback or 0.0.0.0:7272 on every interface; requests that change the timer need the triggers token")
+var barFormat = flag.String("bar-format", "", "Bar output markup: polybar, plain (ANSI colors), tmux, i3blocks or i3status-rs; overrides the config")
+var cmdSource = flag.String("cmd-source", "fifo", "Where the bar module reads commands: fifo, socket (a unix socket in place of the FIFO) or stdin (for bars that print click actions, such as lemonbar)")
+var barTemplate = flag.String("bar-template", "", "Bar output as a Go template, e.g. '{{.Remaining}} / {{.Duration}} {{.Phase}}'; overrides the config")
+var grpcAddr = flag.String("grpc", "", "Serve the gRPC timer service on this address, e.g. :7273")
+var trayIcon = flag.Bool("tray", false, "Show the timer in the system tray, with a menu to start, pause and stop it; see also tray in the config")
+var windowGeometry = flag.String("geometry", "", "Open the window at this geometry, WxH+X+Y with the size in dp, e.g. 300x300+20+20 or 300x300-20-20 from the bottom right; overrides the remembered one")
+var onTop = flag.Bool("on-top", false, "Keep the window above other windows; see also window.on_top in the config")
+var sticky = flag.Bool("sticky", false, "Show the window on every workspace; see also window.sticky in the config")
+var daemon = flag.Bool("daemon", false, "Start without the window, e.g. as a systemd user service; the gui command opens it")
+var background = flag.Bool("background", false, "Start as -daemon does, announced by a notification that opens the window when there is no tray to find it in")
+var workFlag = flag.Duration("work", 0, "Length of a focus session, e.g. 25m (0 keeps the default)")
+var breakFlag = flag.Duration("break", 0, "Length of a short break, e.g. 5m; with -long-break, cycles focus sessions and breaks as the pomodoro sequence")
+var longBreakFlag = flag.Duration("long-break", 0, "Length of the long break after every fourth focus session, e.g. 15m")
+var autostart = flag.Bool("autostart", false, "Start timing at launch: the pomodoro sequence when -break or -long-break is given, or else a focus session")
+var headless = flag.Bool("headless", false, "Run only the timer, the control API, notifications and hooks, with no window, bar or tray, e.g. on a server; drive it with focotimerctl")
 
 type Page int64
 
@@ -41,23 +74,78 @@ const (
 	TimerFinished
 	Splash
 	Settings
+	Timeline
+	Insights
+	Break
+	Tasks
+	Stats
 )
 
-var (
-	btnStartStop      = new(widget.Clickable)
-	btnPause          = new(widget.Clickable)
-	btnIncrease       = new(widget.Clickable)
-	btnDecrease       = new(widget.Clickable)
-	btnSettings       = new(widget.Clickable)
-	btnBack           = new(widget.Clickable)
-	page         Page = TimerStopped
-)
+var pageNames = map[Page]string{
+	TimerStopped:  "stopped",
+	TimerRunning:  "running",
+	TimerFinished: "finished",
+	Splash:        "splash",
+	Settings:      "settings",
+	Timeline:      "timeline",
+	Insights:      "insights",
+	Break:         "break",
+	Tasks:         "tasks",
+	Stats:         "stats",
+}
+
+// pageTitles are what screen readers announce for each page.
+var pageTitles = map[Page]string{
+	TimerStopped:  "Timer",
+	TimerRunning:  "Timer",
+	TimerFinished: "Timer",
+	Settings:      "Settings",
+	Timeline:      "Timeline",
+	Insights:      "Insights",
+	Break:         "Break",
+	Tasks:         "Tasks",
+	Stats:         "Statistics",
+}
+
+func (p Page) String() string {
+	if name, ok := pageNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Page(%d)", int64(p))
+}
 
 type AppManager struct {
 	window *app.Window
 	mu     sync.Mutex
+	// router is the page the window shows, kept while it is closed
+	router *Router
+
+	// placement overrides the remembered geometry in order: the corner
+	// the window is pinned to, then -geometry
+	placement []geometry
+	// geometryPath keeps the geometry of the window when it closes;
+	// empty forgets it
+	geometryPath string
+	geometry     geometry
+	// states are the EWMH states set on the window when it opens
+	states []string
+	// monitor is the monitor the break overlay covers
+	monitor string
+	// closeAction is one of closeActions; confirmAt is when closing was
+	// last asked for without being confirmed
+	closeAction string
+	confirmAt   time.Time
+	trayOnce    sync.Once
+}
+
+func NewAppManager() *AppManager {
+	return &AppManager{router: NewRouter()}
 }
 
+// minWindowSize is the smallest side the window can be resized to; the
+// mini layout no longer fits below it.
+const minWindowSize = unit.Dp(150)
+
 // Start creates the window and launches the event loop
 func (m *AppManager) Start() {
 	m.mu.Lock()
@@ -66,8 +154,16 @@ func (m *AppManager) Start() {
 		return
 	}
 
+	g := geometry{W: 300, H: 300}
+	if m.geometryPath != "" {
+		g = g.over(loadGeometry(m.geometryPath))
+	}
+	for _, o := range m.placement {
+		g = g.over(o)
+	}
+	m.geometry = g
 	m.window = new(app.Window)
-	m.window.Option(app.Decorated(false), app.Transparent(true), app.Size(300, 300), app.Title("Pomodoro Timer"))
+	m.window.Option(app.Decorated(false), app.Transparent(true), app.Size(unit.Dp(g.W), unit.Dp(g.H)), app.MinSize(minWindowSize, minWindowSize), app.Title(windowTitle))
 	m.mu.Unlock()
 
 	go func() {
@@ -79,6 +175,7 @@ func (m *AppManager) Start() {
 
 // Stop closes the window safely
 func (m *AppManager) Stop() {
+	m.rememberPosition()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if m.window != nil {
@@ -101,6 +198,164 @@ func (m *AppManager) ToggleState() {
 	}
 }
 
+// rememberPosition notes where the window is, to open it there next time.
+// Without xdotool, e.g. on Wayland, the position is not known.
+func (m *AppManager) rememberPosition() {
+	x, y, err := windowPosition()
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.geometry = m.geometry.over(geometry{X: x, Y: y, Placed: true})
+}
+
+// placed notes the size of the window and, on its first frame, moves it
+// to its offset and sets its states.
+func (m *AppManager) placed(size image.Point, metric unit.Metric, first bool) {
+	m.mu.Lock()
+	m.geometry.W, m.geometry.H = int(metric.PxToDp(size.X)), int(metric.PxToDp(size.Y))
+	g := m.geometry
+	m.mu.Unlock()
+	if first && g.Placed {
+		go func() {
+			if err := placeWindow(g); err != nil {
+				log.Printf("window: %v", err)
+			}
+		}()
+	}
+	if first && blurRequested() {
+		go func() {
+			if err := blurBehind(); err != nil {
+				log.Printf("window: %v", err)
+			}
+		}()
+	}
+	if first && len(m.states) > 0 {
+		go func() {
+			if err := setWindowStates(m.states); err != nil {
+				log.Printf("window: %v", err)
+			}
+		}()
+	}
+}
+
+// setPlacement places the window as cfg and -geometry say, moving it
+// there when it is open and the placement changed.
+func (m *AppManager) setPlacement(cfg config.WindowConfig) {
+	placement := placementFromConfig(cfg, *windowGeometry)
+	m.mu.Lock()
+	changed := !slices.Equal(placement, m.placement)
+	m.placement, m.monitor = placement, cfg.Monitor
+	g := m.geometry
+	for _, o := range placement {
+		g = g.over(o)
+	}
+	open := m.window != nil
+	if open {
+		m.geometry = g
+	}
+	m.mu.Unlock()
+	if open && changed && g.Placed {
+		go func() {
+			if err := placeWindow(g); err != nil {
+				log.Printf("window: %v", err)
+			}
+		}()
+	}
+}
+
+// closeActions are what closing the window can do; the first is the
+// default.
+var closeActions = []string{"close", "tray", "confirm"}
+
+// confirmFor is how long a close asked for mid-session waits for the
+// second request that confirms it.
+const confirmFor = 3 * time.Second
+
+// setCloseAction sets what closing the window does.
+func (m *AppManager) setCloseAction(action string) error {
+	if action == "" {
+		action = closeActions[0]
+	}
+	if !slices.Contains(closeActions, action) {
+		return fmt.Errorf("unknown close action %q, expected close, tray or confirm", action)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeAction = action
+	return nil
+}
+
+// requestClose closes the window as configured: at once, into the tray,
+// or, while a session is running or paused, on the second request within
+// confirmFor.
+func (m *AppManager) requestClose() {
+	status := focotimer.GTimerManager.State().Status
+	m.mu.Lock()
+	action := m.closeAction
+	inSession := status == focotimer.StatusRunning || status == focotimer.StatusPaused
+	if action == "confirm" && inSession && time.Since(m.confirmAt) > confirmFor {
+		m.confirmAt = time.Now()
+		m.mu.Unlock()
+		redraw()
+		return
+	}
+	m.confirmAt = time.Time{}
+	m.mu.Unlock()
+	if action == "tray" {
+		m.startTray()
+	}
+	m.Stop()
+}
+
+// confirmingUntil returns when a close waiting to be confirmed lapses.
+func (m *AppManager) confirmingUntil() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until := m.confirmAt.Add(confirmFor)
+	return until, !m.confirmAt.IsZero() && time.Now().Before(until)
+}
+
+// startTray shows the timer in the system tray, once: as configured, or
+// as the window closes into it.
+func (m *AppManager) startTray() {
+	m.trayOnce.Do(func() {
+		icon := tray.New(focotimer.GTimerManager, tray.Actions{
+			Start: m.router.togglePlayPause,
+			Pause: pause,
+			Stop:  m.router.stopSession,
+			Show:  m.Start,
+			Quit:  quit,
+		})
+		go func() {
+			if err := icon.Run(nil); err != nil {
+				log.Printf("%v", err)
+			}
+		}()
+	})
+}
+
+// overlayMonitor is the monitor the break overlay covers.
+func (m *AppManager) overlayMonitor() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.monitor
+}
+
+// saveGeometry keeps the geometry of the closed window.
+func (m *AppManager) saveGeometry() {
+	m.mu.Lock()
+	path, g := m.geometryPath, m.geometry
+	m.mu.Unlock()
+	if path == "" {
+		return
+	}
+	if err := saveGeometry(path, g); err != nil {
+		log.Printf("window: %v", err)
+	}
+}
+
 func getLastRemaining() time.Duration {
 	return focotimer.GTimerManager.Snapshot()
 }
@@ -109,6 +364,9 @@ func getLastRemaining() time.Duration {
 func (m *AppManager) loop(window *app.Window) error {
 	var ops op.Ops
 	th := material.NewTheme()
+	fonts := 0
+	first := true
+	defer followTimer(window)()
 
 	for {
 		e := window.Event()
@@ -119,110 +377,408 @@ func (m *AppManager) loop(window *app.Window) error {
 				m.window = nil
 			}
 			m.mu.Unlock()
+			m.saveGeometry()
 			return e.Err
 
+		case app.ConfigEvent:
+			if !e.Config.Focused {
+				go m.rememberPosition()
+			}
+
 		case app.FrameEvent:
+			m.placed(e.Size, e.Metric, first)
+			first = false
 			gtx := app.NewContext(&ops, e)
 
 			// Key input handling
 			event.Op(gtx.Ops, window)
 			key.InputHintOp{Tag: window, Hint: key.HintAny}.Add(gtx.Ops)
 			for {
-				ev, ok := gtx.Source.Event(key.Filter{Focus: nil})
+				ev, ok := gtx.Source.Event(key.Filter{Focus: nil, Optional: keyModifiers})
 				if !ok {
 					break
 				}
-				if keyEv, ok := ev.(key.Event); ok && keyEv.Name == key.NameEscape && keyEv.State == key.Press {
-					m.Stop()
+				keyEv, ok := ev.(key.Event)
+				// typing a task name or a duration is not a command
+				if !ok || keyEv.State != key.Press || m.router.Typing(gtx) {
+					continue
+				}
+				if c, ok := chordFromGio(keyEv); ok && !handleKey(c) {
+					m.requestClose()
 				}
 			}
 
+			theme := phaseTheme(m.router.Current(), focotimer.GTimerManager.State())
+			widgets.UseTheme(theme)
+			theme.Material(th)
+			fonts = loadFont(th, fonts)
+
 			// Draw rounded background
 			rect := clip.UniformRRect(
 				image.Rect(0, 0, gtx.Constraints.Max.X, gtx.Constraints.Max.Y),
 				8,
 			)
 			rect.Push(gtx.Ops)
-			paint.FillShape(gtx.Ops, color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF}, rect.Op(gtx.Ops))
+			drawBackground(gtx, theme.Background)
 
-			timerPage(th, gtx, getLastRemaining())
-
-			gtx.Execute(op.InvalidateCmd{}) // refresh
+			m.router.Layout(th, gtx)
+			if until, ok := m.confirmingUntil(); ok {
+				layoutConfirmClose(th, gtx)
+				gtx.Execute(op.InvalidateCmd{At: until})
+			}
 			e.Frame(gtx.Ops)
 		}
 	}
 }
 
-// ---------------- TIMER PAGE ----------------
-func timerPage(th *material.Theme, gtx C, remaining time.Duration) D {
-	var mainIcon []byte
-	if page == TimerRunning {
-		mainIcon = icons.AVLoop
-	} else {
-		mainIcon = icons.AVPlayArrow
-	}
-
-	return layout.Center.Layout(gtx, func(gtx C) D {
-		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
-			layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
-			widgets.Timer(th, remaining, focotimer.GTimerManager.Timer.Duration),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
-			layout.Rigid(func(gtx C) D {
-				inset := layout.UniformInset(unit.Dp(8))
-				return inset.Layout(gtx, func(gtx C) D {
-					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
-						widgets.Button(th, 10, "BACK", icons.NavigationArrowBack, btnBack, func() { page = TimerStopped }),
-						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-						widgets.Button(th, 5, "DECREASE", icons.ContentRemove, btnDecrease, func() {
-							focotimer.GTimerManager.Dec()
-						}),
-						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-						widgets.Button(th, 10, "PLAY/PAUSE", mainIcon, btnStartStop, func() {
-							if page == TimerRunning {
-								page = TimerStopped
-								focotimer.GTimerManager.Stop()
-								focotimer.GTimerManager.Reset()
-
-							} else {
-								page = TimerRunning
-
-								focotimer.GTimerManager.Reset()
-								focotimer.GTimerManager.Start()
-								go func() {
-									<-focotimer.GTimerManager.Done()
-									page = TimerFinished
-								}()
-							}
-						}),
-						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-						widgets.Button(th, 5, "INCREASE", icons.ContentAdd, btnIncrease, func() {
-							focotimer.GTimerManager.Inc()
-						}),
-						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-						widgets.Button(th, 10, "SETTINGS", icons.ActionSettings, btnSettings, func() {
-							page = Settings
-							focotimer.GTimerManager.Stop()
-						}),
-					)
-				})
-			}),
-		)
+// layoutConfirmClose asks at the bottom of the window to close it again,
+// as a session is running.
+func layoutConfirmClose(th *material.Theme, gtx C) D {
+	prompt := "A session is running. Close again to close the window."
+	if keys := actionKeys(keymap.CloseCommand); keys != "" {
+		prompt = fmt.Sprintf("A session is running. Press %s again to close the window.", keys)
+	}
+	return layout.S.Layout(gtx, func(gtx C) D {
+		return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx C) D {
+			l := material.Caption(th, prompt)
+			l.Color = widgets.Colors().Alert
+			l.Alignment = text.Middle
+			return l.Layout(gtx)
+		})
 	})
 }
 
 // ---------------- MAIN ----------------
 func main() {
-	manager := &AppManager{}
+	if len(os.Args) > 1 && os.Args[1] == "send" {
+		os.Exit(send(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit(stats(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "open" {
+		os.Exit(open(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "block" {
+		os.Exit(block(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "listen" {
+		os.Exit(listen(os.Args[2:]))
+	}
+	manager := NewAppManager()
 
 	flag.Parse()
-	if *isPolybarEnabled {
-		polybar.Init()
+	// window is the manager of the window, none when headless
+	window := manager
+	if *headless {
+		if *isPolybarEnabled || *trayIcon || *rootName || *background {
+			log.Printf("headless: ignoring -polybar, -tray, -root-name and -background")
+		}
+		window = nil
+	}
+	if *background {
+		// -background is -daemon, plus the notification below
+		*daemon = true
+	}
+	cfg, err := config.Load(config.DefaultPath())
+	if errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("%v", err)
+	} else if err != nil {
+		log.Printf("config: %v, using defaults", err)
+	}
+	manager.geometryPath = defaultGeometryPath()
+	manager.setPlacement(cfg.Window)
+	if err := manager.setCloseAction(cfg.Window.Close); err != nil {
+		log.Printf("config: window: %v", err)
+	}
+	manager.states = windowStates(*onTop || cfg.Window.OnTop, *sticky || cfg.Window.Sticky)
+
+	if *workFlag > 0 {
+		focotimer.GTimerManager.SetDuration(*workFlag)
+	}
+	seq, cycling := flagSequence(focotimer.GTimerManager.Duration(), *breakFlag, *longBreakFlag)
+	if cycling {
+		if cfg.Sequences == nil {
+			cfg.Sequences = make(map[string]config.Sequence)
+		}
+		cfg.Sequences["pomodoro"] = seq
+	}
+
+	focotimer.GTimerManager.SetAutoReset(*autoResetAfter)
+	focotimer.GTimerManager.SetWarning(*warnBefore)
+	focotimer.GTimerManager.SetReminders(remindersFromConfig(cfg.Reminders))
+	if *flowCap > 0 {
+		focotimer.GTimerManager.SetFlow(focotimer.FlowOptions{
+			Active: idle.Active(idle.XPrintIdle{}, 30*time.Second),
+			Cap:    *flowCap,
+		})
+	}
+	go manager.router.follow(focotimer.GTimerManager.SubscribeEvents())
+
+	active := enabledIntegrations(cfg)
+	if ambient := ambientFromConfig(cfg.Ambient); ambient != nil {
+		active = append(active, ambient)
+		go ambient.FollowTicks(focotimer.GTimerManager.SubscribeEvery(500*time.Millisecond,
+			focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest)))
+	}
+	calls := callWatchFromConfig(cfg.Calls)
+	if calls != nil {
+		go calls.Run(time.Duration(cfg.Calls.Interval), nil)
+	}
+	if breaks := breakWatchFromConfig(cfg.Breaks, window); breaks != nil {
+		go breaks.Run(time.Duration(cfg.Breaks.Interval), nil)
+	}
+	if chime := chimeFromConfig(cfg.Sounds); chime != nil {
+		if calls != nil {
+			chime.MuteWhile(calls.InCall)
+		}
+		active = append(active, chime)
+	}
+	if *autoResetAfter > 0 {
+		active = append(active, &integrations.AutoResetNotice{})
+	}
+	if reminders := reminderAlertsFromConfig(cfg.Reminders, *warnBefore); reminders != nil {
+		if calls != nil {
+			reminders.Quiet = calls.InCall
+		}
+		active = append(active, reminders)
+	}
+	if countdown := countdownFromConfig(cfg.Sounds.Tick); countdown != nil {
+		if calls != nil {
+			countdown.MuteWhile(calls.InCall)
+		}
+		active = append(active, countdown)
+		go countdown.FollowTicks(focotimer.GTimerManager.SubscribeEvery(250*time.Millisecond,
+			focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest)))
+	}
+	go integrations.Run(focotimer.GTimerManager, nil, active...)
+	if digest := digestFromConfig(cfg.Digest); digest != nil {
+		go digest.Run(nil)
+	}
+
+	keyDispatcher := dispatcherFromConfig(cfg)
+	// cycles runs the pomodoro sequence at launch; it is the control API's
+	// when served, so that focotimerctl can stop it
+	cycles := keyDispatcher
+	handleWindowCommands(keyDispatcher, window)
+	if window != nil {
+		hotkeys = &keymap.XBindKeys{}
+		defer hotkeys.Close()
+		setKeyRunner(keyDispatcher, window.router)
+	} else {
+		setKeyRunner(keyDispatcher, nil)
+	}
+	if err := applyBindings(cfg.Bindings); err != nil {
+		log.Printf("config: bindings: %v", err)
+	}
+	if err := applyTheme(cfg.Theme); err != nil {
+		log.Printf("config: theme: %v", err)
+	}
+	if err := applyCountdown(cfg.Countdown); err != nil {
+		log.Printf("config: countdown: %v", err)
+	}
+	restoreActiveTask()
+	if window != nil {
+		go appearance.Follow(appearance.First{appearance.Portal{}, appearance.GSettings{}}, 5*time.Second, nil, setSystemScheme)
+	}
+	go config.Watch(config.DefaultPath(), 2*time.Second, nil, func(cfg *config.Config, err error) {
+		if err != nil {
+			log.Printf("config: reload: %v", err)
+			return
+		}
+		if err := applyBindings(cfg.Bindings); err != nil {
+			log.Printf("config: bindings: %v, keeping the previous ones", err)
+		}
+		if err := applyTheme(cfg.Theme); err != nil {
+			log.Printf("config: theme: %v", err)
+		}
+		if err := applyCountdown(cfg.Countdown); err != nil {
+			log.Printf("config: countdown: %v", err)
+		}
+		manager.setPlacement(cfg.Window)
+		if err := manager.setCloseAction(cfg.Window.Close); err != nil {
+			log.Printf("config: window: %v", err)
+		}
+	})
+
+	ctlLn, eventsLn := activatedSockets()
+
+	var observer *ipc.Observer
+	if *eventsSocket != "" || eventsLn != nil || *httpAddr != "" || *ctlSocket != "" || ctlLn != nil {
+		observer = ipc.NewObserver(focotimer.GTimerManager, time.Second)
+		defer observer.Close()
+	}
+	if eventsLn != nil {
+		observer.Serve(eventsLn)
+	} else if *eventsSocket != "" {
+		if err := observer.Listen(*eventsSocket); err != nil {
+			log.Printf("events socket: %v", err)
+		}
+	}
+
+	if window != nil && (*rootName || cfg.RootName.Enabled) {
+		go polybar.NewRootName(focotimer.GTimerManager, polybar.RootNameOptions{
+			Prefix:  cfg.RootName.Prefix,
+			Suffix:  cfg.RootName.Suffix,
+			Command: cfg.RootName.Command,
+			Fit:     fitFromConfig(cfg.Polybar),
+		}).Run(nil)
+	}
+
+	inTray := window != nil && (*trayIcon || cfg.Tray.Enabled)
+	if inTray {
+		manager.startTray()
+	}
+
+	if *statusFile != "" {
+		go ipc.NewStatusFile(focotimer.GTimerManager, *statusFile, time.Second).Run(nil)
+	}
+	if *statusText != "" {
+		go polybar.NewLineFile(focotimer.GTimerManager, *statusText, fitFromConfig(cfg.Polybar), time.Second).Run(nil)
+	}
+
+	if *httpAddr != "" || *ctlSocket != "" || ctlLn != nil {
+		d := dispatcherFromConfig(cfg)
+		handleWindowCommands(d, window)
+		cycles = d
+		d.Handle("refresh", func(args []string) error { polybar.Refresh(); return nil })
+		api := ipc.NewAPI(focotimer.GTimerManager, d)
+		api.Stream(observer)
+		api.RequireToken(cfg.Triggers.Token)
+		if cfg.Triggers.Token != "" {
+			api.Triggers(cfg.Triggers.Token, profilesFromConfig(cfg.Triggers))
+		} else if *httpAddr != "" {
+			log.Printf("http: no triggers token in the config, only reads are served")
+		}
+		defer api.Close()
+		if *httpAddr != "" {
+			if err := api.Listen(*httpAddr); err != nil {
+				log.Printf("http: %v", err)
+			}
+		}
+		if ctlLn != nil {
+			api.Serve(ctlLn)
+		} else if *ctlSocket != "" {
+			if err := api.ListenUnix(*ctlSocket); err != nil {
+				log.Printf("control socket: %v", err)
+			}
+		}
+	}
+
+	if mqtt := mqttFromConfig(cfg); mqtt != nil {
+		go mqtt.Run(nil)
+	}
+
+	if *grpcAddr != "" {
+		g := ipc.NewGRPC(focotimer.GTimerManager, dispatcherFromConfig(cfg))
+		g.RequireToken(cfg.Triggers.Token)
+		if cfg.Triggers.Token == "" {
+			log.Printf("grpc: no triggers token in the config, only reads are served")
+		}
+		if err := g.Listen(*grpcAddr); err != nil {
+			log.Printf("grpc: %v", err)
+		} else {
+			defer g.Close()
+		}
+	}
+
+	if window != nil && *isPolybarEnabled {
+		switch *cmdSource {
+		case "stdin":
+			polybar.SetCommands(os.Stdin)
+		case "socket":
+			polybar.SetTransport(polybar.NewSocketTransport())
+			polybar.Init()
+		default:
+			if *cmdSource != "fifo" {
+				log.Printf("polybar: unknown command source %q, using the FIFO", *cmdSource)
+			}
+			polybar.Init()
+		}
+		if *cmdSource != "stdin" {
+			if err := polybar.Advertise(); err != nil {
+				log.Printf("polybar: %v", err)
+			}
+		}
 		polybar.SetTimerManager(focotimer.GTimerManager)
+		polybar.DefineCommands(cfg.Commands)
+		polybar.DefineAliases(cfg.Aliases)
+		polybar.DefineSequences(sequencesFromConfig(cfg))
+		if clicks := clicksFromConfig(cfg.Polybar); clicks != nil {
+			polybar.SetClicks(clicks)
+		}
+		for seg, clicks := range segmentClicksFromConfig(cfg.Polybar) {
+			polybar.SetSegmentClicks(seg, clicks)
+		}
+		format := cfg.Polybar.Format
+		if *barFormat != "" {
+			format = *barFormat
+		}
+		if format != "" {
+			if f, err := polybar.ParseFormat(format); err != nil {
+				log.Printf("polybar: %v", err)
+			} else {
+				polybar.SetFormat(f)
+			}
+		}
+		polybar.SetColors(colorsFromConfig(cfg.Polybar))
+		polybar.SetFit(fitFromConfig(cfg.Polybar))
+		polybar.SetInterval(time.Duration(cfg.Polybar.Interval))
+		if cfg.Polybar.IPCModule != "" {
+			polybar.SetOutput(&polybar.PolybarMsg{Module: cfg.Polybar.IPCModule})
+		}
+		if cfg.Polybar.Icons != "" {
+			if icons, err := polybar.ParseIcons(cfg.Polybar.Icons); err != nil {
+				log.Printf("config: polybar: %v", err)
+			} else {
+				polybar.SetIcons(&icons)
+			}
+		}
+		tmpl := cfg.Polybar.Template
+		if *barTemplate != "" {
+			tmpl = *barTemplate
+		}
+		if err := polybar.SetTemplate(tmpl); err != nil {
+			log.Printf("polybar: %v", err)
+		}
 		polybar.AddHandler(manager.ToggleState)
+		polybar.Handle("open", openCommand(manager))
 		go polybar.Main()
-	} else {
+	} else if window != nil && !*daemon {
 		manager.Start()
 	}
+	if window != nil && *background && !inTray {
+		announce := integrations.DesktopNotify(nil, manager.Start)
+		if err := announce("focotimer is running", "Click to open the timer; the gui command opens it later"); err != nil {
+			log.Printf("background: %v", err)
+		}
+	}
+
+	if *autostart {
+		if cycling {
+			manager.router.Show(TimerRunning)
+			if err := cycles.Dispatch("cycle pomodoro"); err != nil {
+				log.Printf("autostart: %v", err)
+			}
+		} else {
+			manager.router.startSession()
+		}
+	}
 
+	if path := os.Getenv(scriptEnv); path != "" {
+		go runScriptFile(manager.router, path)
+	}
+	if err := systemd.Notify(systemd.Ready); err != nil {
+		log.Printf("systemd: %v", err)
+	}
+
+	if window == nil {
+		// app.Main would never return; stop on a signal instead, so the
+		// sockets are closed and removed
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		return
+	}
 	app.Main()
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/gio/app"
+	"github.com/d093w1z/gio/io/system"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op"
+	"github.com/d093w1z/gio/op/paint"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// ---------------- BREAK OVERLAY ----------------
+
+// overlayTitle names the overlay window, for xdotool to find it.
+const overlayTitle = "Time for a break"
+
+// breakOverlay dims the screen behind the break countdown while a break
+// is being ignored. It goes again once the user steps away or the break
+// ends.
+type breakOverlay struct {
+	// monitor names the monitor the overlay covers as it opens; the
+	// window manager picks one when it is nil or names none
+	monitor func() string
+	// dots are the pomodoros done in the cycle, as the window shows them
+	dots func() int
+
+	mu     sync.Mutex
+	window *app.Window
+}
+
+// Show opens or closes the overlay window; it is the Overlay action of
+// the break watch.
+func (o *breakOverlay) Show(show bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch {
+	case show && o.window == nil:
+		var monitor string
+		if o.monitor != nil {
+			monitor = o.monitor()
+		}
+		w := new(app.Window)
+		w.Option(app.Decorated(false), app.Transparent(true), app.Title(overlayTitle))
+		if monitor == "" {
+			w.Option(app.Fullscreen.Option())
+		}
+		o.window = w
+		go o.loop(w, monitor)
+	case !show && o.window != nil:
+		o.window.Perform(system.ActionClose)
+		o.window = nil
+	}
+}
+
+func (o *breakOverlay) loop(window *app.Window, monitor string) {
+	var ops op.Ops
+	th := material.NewTheme()
+	fonts := 0
+	view := widgets.NewTimerView()
+	var ring focotimer.SmoothProgress
+	defer followTimer(window)()
+	first := true
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			o.mu.Lock()
+			if o.window == window {
+				o.window = nil
+			}
+			o.mu.Unlock()
+			return
+
+		case app.FrameEvent:
+			if first && monitor != "" {
+				go moveToMonitor(window, monitor)
+			}
+			first = false
+			gtx := app.NewContext(&ops, e)
+			theme := phaseTheme(Break, focotimer.GTimerManager.State())
+			theme.Material(th)
+			fonts = loadFont(th, fonts)
+			dim := theme.Background
+			dim.A = 0xC0
+			paint.Fill(gtx.Ops, dim)
+			layout.Center.Layout(gtx, func(gtx C) D {
+				return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+					view.Layout(th, focotimer.GTimerManager.Activity(), getLastRemaining(), ringProgress(gtx, &ring), theme.Ring, o.completedDots()),
+					layout.Rigid(func(gtx C) D {
+						l := material.H6(th, "Time for a break. Step away from the screen.")
+						l.Color = theme.Text
+						return layout.Inset{Top: unit.Dp(24)}.Layout(gtx, l.Layout)
+					}),
+				)
+			})
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+func (o *breakOverlay) completedDots() int {
+	if o.dots == nil {
+		return 0
+	}
+	return o.dots()
+}
+
+// moveToMonitor moves the overlay window onto its monitor before it goes
+// fullscreen, which fills the monitor the window is on.
+func moveToMonitor(window *app.Window, monitor string) {
+	defer window.Option(app.Fullscreen.Option())
+	mon, err := findMonitor(monitor)
+	if err != nil {
+		log.Printf("break overlay: %v", err)
+		return
+	}
+	id, err := findTitled(overlayTitle)
+	if err == nil {
+		_, err = xdotool("windowmove", id, strconv.Itoa(mon.X), strconv.Itoa(mon.Y))
+	}
+	if err != nil {
+		log.Printf("break overlay: %v", err)
+	}
+}
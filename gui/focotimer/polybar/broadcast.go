@@ -0,0 +1,197 @@
+package polybar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/d093w1z/focotimer/ipc"
+)
+
+// ------------------- Bar subscriptions -------------------
+
+// barWriteTimeout drops a bar that stops reading instead of stalling the
+// output for the others.
+const barWriteTimeout = time.Second
+
+// broadcast is the output of every bar that subscribed, e.g. one per
+// monitor, each on its own pipe.
+type broadcast struct {
+	mu   sync.Mutex
+	bars map[string]io.WriteCloser
+	last string
+}
+
+// add feeds w, the pipe at path, from now on, starting with the current
+// line.
+func (b *broadcast) add(path string, w io.WriteCloser) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if old, ok := b.bars[path]; ok {
+		old.Close()
+	}
+	if b.bars == nil {
+		b.bars = make(map[string]io.WriteCloser)
+	}
+	b.bars[path] = w
+	if b.last != "" {
+		b.writeLocked(path, w, b.last)
+	}
+}
+
+func (b *broadcast) remove(path string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w, ok := b.bars[path]
+	if ok {
+		w.Close()
+		delete(b.bars, path)
+	}
+	return ok
+}
+
+// write sends one output line to every bar, dropping those that hung up.
+// Unless forced, a line that did not change is not sent again.
+func (b *broadcast) write(line string, force bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if line == b.last && !force {
+		return
+	}
+	b.last = line
+	for path, w := range b.bars {
+		b.writeLocked(path, w, line)
+	}
+}
+
+func (b *broadcast) writeLocked(path string, w io.WriteCloser, line string) {
+	if d, ok := w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		d.SetWriteDeadline(time.Now().Add(barWriteTimeout))
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		log.Printf("polybar.broadcast: dropping %q: %v", path, err)
+		w.Close()
+		delete(b.bars, path)
+	}
+}
+
+// closeAll hangs up on every bar, which ends their Listen.
+func (b *broadcast) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for path, w := range b.bars {
+		w.Close()
+		delete(b.bars, path)
+	}
+}
+
+// AddBar sends the output to the pipe at path too, until the reader hangs
+// up or RemoveBar; it is the "subscribe <path>" command. It waits a while
+// for the reader to open the pipe.
+func (s *Server) AddBar(path string) error {
+	w, err := s.dialRetry(path)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return errors.New("stopping")
+	}
+	s.bars.add(path, w)
+	log.Printf("polybar.AddBar: sending the output to %q", path)
+	return nil
+}
+
+// RemoveBar stops sending the output to path; it is the
+// "unsubscribe <path>" command.
+func (s *Server) RemoveBar(path string) {
+	if s.bars.remove(path) {
+		log.Printf("polybar.RemoveBar: stopped sending the output to %q", path)
+	}
+}
+
+// Listen copies the output of the focotimer reading the command pipe at
+// pipe to w, one line per change, through a pipe of its own, so several
+// bars can show the module at once. It returns when focotimer hangs up or
+// stop is closed. A polybar module runs it as
+//
+//	[module/focotimer]
+//	type = custom/script
+//	exec = focotimer listen
+//	tail = true
+func Listen(pipe string, w io.Writer, stop <-chan struct{}) error {
+	t := DefaultTransport
+	if err := os.MkdirAll(ipc.RuntimeDir(), 0o700); err != nil {
+		return err
+	}
+	path, err := t.Create(filepath.Join(ipc.RuntimeDir(), "bar"))
+	if err != nil {
+		return err
+	}
+	defer t.Remove(path)
+
+	type accepted struct {
+		r   io.ReadCloser
+		err error
+	}
+	ch := make(chan accepted, 1)
+	go func() {
+		r, err := t.Accept(path)
+		ch <- accepted{r, err}
+	}()
+	// unblocks the Accept above if focotimer never connects
+	wake := func() {
+		if c, err := t.Dial(path); err == nil {
+			c.Close()
+		}
+	}
+
+	if err := sendRetry(pipe, "subscribe "+path); err != nil {
+		wake()
+		return err
+	}
+	defer sendRetry(pipe, "unsubscribe "+path)
+
+	var r io.ReadCloser
+	select {
+	case a := <-ch:
+		if a.err != nil {
+			return a.err
+		}
+		r = a.r
+	case <-time.After(replyTimeout):
+		wake()
+		return fmt.Errorf("focotimer did not connect to %q", path)
+	case <-stop:
+		wake()
+		return nil
+	}
+	defer r.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			r.Close()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(w, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+	return scanner.Err()
+}
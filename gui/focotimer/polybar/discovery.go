@@ -0,0 +1,70 @@
+package polybar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/d093w1z/focotimer/ipc"
+)
+
+// ------------------- Discovery -------------------
+
+// DiscoveryFile holds the path of the command pipe of the running
+// focotimer, for bar configs and scripts that cannot predict it, e.g. when
+// the default path was taken by another instance.
+func DiscoveryFile() string {
+	return filepath.Join(ipc.RuntimeDir(), "cmd.path")
+}
+
+// Discover returns the command pipe path the running focotimer advertised.
+func Discover() (string, error) {
+	data, err := os.ReadFile(DiscoveryFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no focotimer has advertised its command pipe in %q", DiscoveryFile())
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Advertise writes the command pipe path to the DiscoveryFile; Stop
+// removes it again unless another focotimer has replaced it.
+func (s *Server) Advertise() error {
+	path := s.FifoPath()
+	if path == "" {
+		return errors.New("no command pipe to advertise")
+	}
+	file := DiscoveryFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, []byte(path+"\n"), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	s.mu.Lock()
+	s.discovery = file
+	s.mu.Unlock()
+	return nil
+}
+
+// unadvertise removes the discovery file if it still names path.
+func (s *Server) unadvertise(path string) {
+	s.mu.RLock()
+	file := s.discovery
+	s.mu.RUnlock()
+	if file == "" {
+		return
+	}
+	if data, err := os.ReadFile(file); err == nil && strings.TrimSpace(string(data)) == path {
+		os.Remove(file)
+	}
+}
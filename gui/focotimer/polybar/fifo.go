@@ -0,0 +1,123 @@
+//go:build !windows
+
+package polybar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/d093w1z/focotimer/ipc"
+)
+
+// defaultPipePath is the command FIFO when $FOCOTIMER_PIPE is unset, so
+// bar configs can name it.
+func defaultPipePath() string { return filepath.Join(ipc.RuntimeDir(), "cmd.fifo") }
+
+// fifoTransport carries commands over FIFOs in the file system. A relative
+// base is taken from the temporary directory.
+type fifoTransport struct{}
+
+func defaultTransport() Transport { return fifoTransport{} }
+
+func (fifoTransport) Create(base string) (string, error) {
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(os.TempDir(), base)
+	}
+	return mkfifoUnique(base, 0666)
+}
+
+func (fifoTransport) Ensure(path string) error { return ensureFifo(path) }
+
+// Accept opens the FIFO for reading, which blocks until a writer opens it.
+func (fifoTransport) Accept(path string) (io.ReadCloser, error) {
+	return os.OpenFile(path, os.O_RDONLY, os.ModeNamedPipe)
+}
+
+func (fifoTransport) Dial(path string) (io.WriteCloser, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%q is not a FIFO", path)
+	}
+	// a non-blocking open fails with ENXIO instead of waiting for a reader
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ENXIO) {
+		return nil, fmt.Errorf("open %q: %w", path, ErrNotListening)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (fifoTransport) Remove(path string) error { return os.Remove(path) }
+
+func mkfifoUnique(base string, mode os.FileMode) (string, error) {
+	// Add PID to make it unique per process
+	pid := os.Getpid()
+
+	for i := 0; i < 1000; i++ {
+		var path string
+		if i == 0 {
+			path = fmt.Sprintf("%s.%d", base, pid)
+		} else {
+			path = fmt.Sprintf("%s.%d.%d", base, pid, i)
+		}
+
+		err := syscall.Mkfifo(path, uint32(mode.Perm()))
+		if err == nil {
+			return path, nil
+		}
+		if errors.Is(err, os.ErrExist) || err == syscall.EEXIST {
+			fi, statErr := os.Lstat(path)
+			if statErr != nil {
+				continue
+			}
+			if (fi.Mode() & os.ModeNamedPipe) != 0 {
+				// Check if the FIFO is actually usable (not in use by another process)
+				if canUseFifo(path) {
+					return path, nil
+				}
+			}
+			continue
+		}
+		return "", fmt.Errorf("mkfifo %q: %w", path, err)
+	}
+	return "", fmt.Errorf("unable to allocate unique FIFO for base %q after many attempts", base)
+}
+
+// canUseFifo checks if we can actually use this FIFO (not locked by another process)
+func canUseFifo(path string) bool {
+	// Try to open for writing with O_NONBLOCK to test availability
+	file, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return true
+}
+
+// ensureFifo creates the FIFO at path unless a FIFO is already there.
+func ensureFifo(path string) error {
+	err := syscall.Mkfifo(path, 0666)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("mkfifo %q: %w", path, err)
+	}
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeNamedPipe == 0 {
+		return fmt.Errorf("%q exists and is not a FIFO", path)
+	}
+	return nil
+}
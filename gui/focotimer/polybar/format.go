@@ -0,0 +1,358 @@
+package polybar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Output formats -------------------
+
+// Format selects the markup of the module output.
+type Format string
+
+const (
+	// FormatPolybar emits polybar action and color tags.
+	FormatPolybar Format = "polybar"
+	// FormatPlain emits bare text, colored with ANSI escapes, for scripts
+	// and terminals.
+	FormatPlain Format = "plain"
+	// FormatTmux emits tmux status-line styles (#[fg=...]).
+	FormatTmux Format = "tmux"
+	// FormatI3Blocks emits bare text, one line per update, for a
+	// persistent i3blocks block. "focotimer block" serves interval blocks.
+	FormatI3Blocks Format = "i3blocks"
+	// FormatI3Status emits the JSON of an i3status-rust custom block, one
+	// object per line for a persistent block.
+	FormatI3Status Format = "i3status-rs"
+)
+
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatPolybar, FormatPlain, FormatTmux, FormatI3Blocks, FormatI3Status:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q", name)
+}
+
+// ------------------- Width -------------------
+
+// Variant is how much the module shows at most.
+type Variant string
+
+const (
+	// VariantLong shows the label, the total and the remaining time
+	// between the [-] and [+] buttons.
+	VariantLong Variant = "long"
+	// VariantMedium leaves the total out.
+	VariantMedium Variant = "medium"
+	// VariantShort shows an icon and the minutes left.
+	VariantShort Variant = "short"
+)
+
+func ParseVariant(name string) (Variant, error) {
+	switch v := Variant(name); v {
+	case VariantLong, VariantMedium, VariantShort:
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown output variant %q", name)
+}
+
+// DefaultIcon leads the short output.
+const DefaultIcon = "⏱"
+
+// Fit bounds how much room the module takes on the bar.
+type Fit struct {
+	// Variant is the fullest output; empty is VariantLong.
+	Variant Variant
+	// MaxWidth, in characters, makes the output drop parts until it
+	// fits: the total first, then the buttons, and finally everything but
+	// the icon and the minutes left. Zero leaves it unbounded.
+	MaxWidth int
+	// Icon replaces DefaultIcon.
+	Icon string
+}
+
+// The outputs from the fullest to the barest; MaxWidth moves down them.
+const (
+	stepLong   = iota // label, total and remaining, with buttons
+	stepMedium        // label and remaining, with buttons
+	stepBare          // label and remaining
+	stepShort         // icon and minutes left
+)
+
+func (f Fit) first() int {
+	switch f.Variant {
+	case VariantMedium:
+		return stepMedium
+	case VariantShort:
+		return stepShort
+	}
+	return stepLong
+}
+
+// text is the visible text of step, and whether the buttons go around it.
+func (f Fit) text(step int, label string, total, remaining time.Duration) (string, bool) {
+	var text string
+	switch step {
+	case stepLong:
+		text = fmt.Sprintf("%s : %s", truncToSecond(total), truncToSecond(remaining))
+	case stepMedium, stepBare:
+		text = truncToSecond(remaining).String()
+	default:
+		icon := f.Icon
+		if icon == "" {
+			icon = DefaultIcon
+		}
+		return fmt.Sprintf("%s %dm", icon, minutes(remaining)), false
+	}
+	if label != "" {
+		text = label + " " + text
+	}
+	return text, step <= stepMedium
+}
+
+// fitted is the fullest output of f that fits its MaxWidth once padded
+// for markup, and whether the buttons go around it.
+func (f Fit) fitted(label string, total, remaining time.Duration, markup bool) (string, bool) {
+	for step := f.first(); ; step++ {
+		text, buttons := f.text(step, label, total, remaining)
+		buttons = buttons && markup
+		if step == stepShort || f.MaxWidth <= 0 || visibleWidth(text, markup, buttons) <= f.MaxWidth {
+			return text, buttons
+		}
+	}
+}
+
+// visibleWidth is how many characters text takes on the bar once padded
+// as a clickable area and flanked by the buttons.
+func visibleWidth(text string, markup, buttons bool) int {
+	n := utf8.RuneCountInString(text)
+	if markup {
+		n += 2
+	}
+	if buttons {
+		n += len(" [-] ") + len(" [+] ")
+	}
+	return n
+}
+
+// ------------------- Icons -------------------
+
+// Icons lead the timer by phase and replace the text of the buttons.
+type Icons struct {
+	Work   string
+	Break  string
+	Paused string
+	Dec    string
+	Inc    string
+}
+
+// NerdIcons are Nerd Font glyphs: a timer for work (Nerd Fonts have no
+// tomato), a coffee cup for breaks and the pause sign.
+var NerdIcons = Icons{Work: "\U000f051b", Break: "\U000f0176", Paused: "\U000f03e4", Dec: "\U000f0374", Inc: "\U000f0415"}
+
+// ASCIIIcons stand in for NerdIcons on fonts without them.
+var ASCIIIcons = Icons{Work: "*", Break: "~", Paused: "||", Dec: "[-]", Inc: "[+]"}
+
+// ParseIcons reads the config name of an icon set, "nerd" or "ascii".
+func ParseIcons(name string) (Icons, error) {
+	switch name {
+	case "nerd":
+		return NerdIcons, nil
+	case "ascii":
+		return ASCIIIcons, nil
+	}
+	return Icons{}, fmt.Errorf("unknown icons %q, want nerd or ascii", name)
+}
+
+// For returns the icon of the phase s is in.
+func (i Icons) For(s focotimer.State) string {
+	switch {
+	case s.Status == focotimer.StatusPaused:
+		return i.Paused
+	case s.Break:
+		return i.Break
+	}
+	return i.Work
+}
+
+// ------------------- i3 blocks -------------------
+
+// Activity is the label shown for s: its label and phase. A running
+// TimerManager knows better while a session plan runs; see
+// focotimer.TimerManager.Activity.
+func Activity(s focotimer.State) string {
+	switch {
+	case s.Phase == "":
+		return s.Label
+	case s.Label == "":
+		return s.Phase
+	}
+	return s.Label + ": " + s.Phase
+}
+
+// urgent reports a running session in its final stretch.
+func urgent(s focotimer.State) bool {
+	return s.Status == focotimer.StatusRunning && s.Remaining <= finalStretch
+}
+
+// I3Block is an i3blocks interval block for s: the full text, the short
+// text and the color, one per line. urgent asks for exit code 33, which
+// i3blocks shows as urgent.
+func I3Block(s focotimer.State, activity string, colors *Colors, fit Fit) (block string, urgentBlock bool) {
+	full, _ := fit.fitted(activity, s.Duration, s.Remaining, false)
+	short, _ := fit.text(stepShort, "", s.Duration, s.Remaining)
+	color := ""
+	if colors != nil {
+		color = colors.For(s)
+	}
+	return full + "\n" + short + "\n" + color + "\n", urgent(s)
+}
+
+// I3StatusBlock is the JSON of an i3status-rust custom block for s.
+func I3StatusBlock(s focotimer.State, activity string, fit Fit) string {
+	full, _ := fit.fitted(activity, s.Duration, s.Remaining, false)
+	short, _ := fit.text(stepShort, "", s.Duration, s.Remaining)
+	return i3status(s, full, short)
+}
+
+func i3status(s focotimer.State, full, short string) string {
+	state := "Idle"
+	switch {
+	case urgent(s):
+		state = "Critical"
+	case s.Status == focotimer.StatusRunning:
+		state = "Info"
+	case s.Status == focotimer.StatusPaused:
+		state = "Warning"
+	case s.Status == focotimer.StatusCompleted:
+		state = "Good"
+	}
+	data, _ := json.Marshal(struct {
+		Text      string `json:"text"`
+		ShortText string `json:"short_text"`
+		State     string `json:"state"`
+	}{full, short, state})
+	return string(data)
+}
+
+// finalStretch is how close to the end a running session shows the Final
+// color unless Colors says otherwise.
+const finalStretch = time.Minute
+
+// Colors maps timer states to "#RRGGBB" colors. An empty color leaves the
+// state unstyled.
+type Colors struct {
+	Running string
+	Paused  string
+	// Final replaces Running and Break during the last Threshold of a
+	// session.
+	Final string
+	// Break replaces Running while a break, told apart by its label, runs.
+	Break     string
+	Completed string
+	// Threshold defaults to a minute.
+	Threshold time.Duration
+	// Flash makes a completed session blink until it is acknowledged.
+	Flash bool
+}
+
+// DefaultColors follow the GUI's ring: amber while running, red in the
+// final minute and the label grey while paused; breaks are green.
+var DefaultColors = Colors{Running: "#FFA12C", Paused: "#BBBBBB", Final: "#F11D28", Break: "#3FB950"}
+
+// ParseColor checks a "#RRGGBB" color and returns its components.
+func ParseColor(c string) (r, g, b uint8, err error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(c, "#"), 16, 32)
+	if err != nil || len(c) != 7 || c[0] != '#' {
+		return 0, 0, 0, fmt.Errorf("invalid color %q, want #RRGGBB", c)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
+
+// Validate reports the first malformed color.
+func (c Colors) Validate() error {
+	for _, color := range []string{c.Running, c.Paused, c.Final, c.Break, c.Completed} {
+		if color == "" {
+			continue
+		}
+		if _, _, _, err := ParseColor(color); err != nil {
+			return err
+		}
+	}
+	if c.Threshold < 0 {
+		return fmt.Errorf("negative color threshold %v", c.Threshold)
+	}
+	return nil
+}
+
+// For returns the color of a timer state; idle sessions are unstyled.
+func (c Colors) For(s focotimer.State) string {
+	threshold := c.Threshold
+	if threshold == 0 {
+		threshold = finalStretch
+	}
+	switch s.Status {
+	case focotimer.StatusRunning:
+		if s.Remaining <= threshold && c.Final != "" {
+			return c.Final
+		}
+		if c.Break != "" && s.Break {
+			return c.Break
+		}
+		return c.Running
+	case focotimer.StatusPaused:
+		return c.Paused
+	case focotimer.StatusCompleted:
+		return c.Completed
+	}
+	return ""
+}
+
+// Flashes reports whether s blinks.
+func (c Colors) Flashes(s focotimer.State) bool {
+	return c.Flash && s.Status == focotimer.StatusCompleted
+}
+
+// colorize wraps text in the color markup of f. Malformed colors are left
+// out rather than corrupting the bar.
+func colorize(f Format, color, text string) string {
+	if color == "" {
+		return text
+	}
+	r, g, b, err := ParseColor(color)
+	if err != nil {
+		return text
+	}
+	switch f {
+	case FormatPlain:
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, text)
+	case FormatTmux:
+		return fmt.Sprintf("#[fg=%s]%s#[default]", color, text)
+	default:
+		return fmt.Sprintf("%%{F%s}%s%%{F-}", color, text)
+	}
+}
+
+// blink makes text blink in the markup of f. Polybar has no blinking
+// text, so there on alternates with reverse video; formats without styles
+// are left alone.
+func blink(f Format, on bool, text string) string {
+	switch f {
+	case FormatPlain:
+		return "\x1b[5m" + text + "\x1b[25m"
+	case FormatTmux:
+		return "#[blink]" + text + "#[noblink]"
+	case FormatPolybar:
+		if on {
+			return "%{R}" + text + "%{R}"
+		}
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package polybar
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ------------------- polybar-msg -------------------
+
+// PolybarMsg is an output for a custom/ipc module: each line written to it
+// is pushed with "polybar-msg action <module> send <line>", so polybar
+// needs no script tailing the output. A module such as
+//
+//	[module/focotimer]
+//	type = custom/ipc
+//	hook-0 = focotimerctl refresh
+//	initial = 1
+//
+// asks for the current line when the bar starts.
+type PolybarMsg struct {
+	Module string
+	// Command defaults to "polybar-msg".
+	Command string
+}
+
+func (p *PolybarMsg) Write(b []byte) (int, error) {
+	command := p.Command
+	if command == "" {
+		command = "polybar-msg"
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		out, err := exec.Command(command, "action", p.Module, "send", line).CombinedOutput()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w: %s", command, err, bytes.TrimSpace(out))
+		}
+	}
+	return len(b), nil
+}
@@ -0,0 +1,184 @@
+package polybar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultPipePath is the command pipe when $FOCOTIMER_PIPE is unset.
+func defaultPipePath() string { return pipePrefix + "focotimer.cmd" }
+
+// pipePrefix is the namespace of local named pipes.
+const pipePrefix = `\\.\pipe\`
+
+// pipeTransport carries commands over local named pipes. A base outside
+// the pipe namespace keeps only its file name, so $FOCOTIMER_PIPE values
+// meant for unix still work.
+//
+// Unlike a FIFO a named pipe exists only while the server holds an
+// instance of it, and each client connects to an instance of its own. The
+// transport therefore keeps one idle instance per path, replaced on every
+// Accept, for clients to connect to between reads.
+type pipeTransport struct {
+	mu      sync.Mutex
+	pending map[string]windows.Handle
+}
+
+func defaultTransport() Transport {
+	return &pipeTransport{pending: make(map[string]windows.Handle)}
+}
+
+// pipeName maps base into the pipe namespace.
+func pipeName(base string) string {
+	if strings.HasPrefix(strings.ToLower(base), pipePrefix) {
+		return base
+	}
+	return pipePrefix + filepath.Base(base)
+}
+
+// listen creates an inbound instance of the pipe at path. With first set
+// it fails with ERROR_ACCESS_DENIED if the pipe already exists.
+func listen(path string, first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	flags := uint32(windows.PIPE_ACCESS_INBOUND)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	mode := uint32(windows.PIPE_TYPE_BYTE | windows.PIPE_READMODE_BYTE | windows.PIPE_WAIT | windows.PIPE_REJECT_REMOTE_CLIENTS)
+	return windows.CreateNamedPipe(name, flags, mode, windows.PIPE_UNLIMITED_INSTANCES, 4096, 4096, 0, nil)
+}
+
+func (t *pipeTransport) Create(base string) (string, error) {
+	base = pipeName(base)
+	pid := os.Getpid()
+	for i := 0; i < 1000; i++ {
+		path := fmt.Sprintf("%s.%d", base, pid)
+		if i > 0 {
+			path = fmt.Sprintf("%s.%d.%d", base, pid, i)
+		}
+		h, err := listen(path, true)
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("create pipe %q: %w", path, err)
+		}
+		t.mu.Lock()
+		t.pending[path] = h
+		t.mu.Unlock()
+		return path, nil
+	}
+	return "", fmt.Errorf("unable to allocate unique pipe for base %q after many attempts", base)
+}
+
+func (t *pipeTransport) Ensure(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.pending[path]; ok {
+		return nil
+	}
+	h, err := listen(path, false)
+	if err != nil {
+		return fmt.Errorf("create pipe %q: %w", path, err)
+	}
+	t.pending[path] = h
+	return nil
+}
+
+// Accept waits on the idle instance of path for a client and leaves a new
+// idle instance behind.
+func (t *pipeTransport) Accept(path string) (io.ReadCloser, error) {
+	t.mu.Lock()
+	h, ok := t.pending[path]
+	delete(t.pending, path)
+	t.mu.Unlock()
+	if !ok {
+		var err error
+		if h, err = listen(path, false); err != nil {
+			return nil, fmt.Errorf("create pipe %q: %w", path, err)
+		}
+	}
+
+	// a client may have connected before the wait
+	if err := windows.ConnectNamedPipe(h, nil); err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		windows.CloseHandle(h)
+		return nil, fmt.Errorf("connect pipe %q: %w", path, err)
+	}
+
+	if next, err := listen(path, false); err == nil {
+		t.mu.Lock()
+		if _, ok := t.pending[path]; ok {
+			windows.CloseHandle(next)
+		} else {
+			t.pending[path] = next
+		}
+		t.mu.Unlock()
+	}
+	return pipeConn(h), nil
+}
+
+// Dial connects to the pipe at path. It retries for a moment while every
+// instance is busy, between one Accept returning and the next.
+func (t *pipeTransport) Dial(path string) (io.WriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for tries := 0; ; tries++ {
+		h, err := windows.CreateFile(name, windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+		switch {
+		case err == nil:
+			return pipeConn(h), nil
+		case errors.Is(err, windows.ERROR_FILE_NOT_FOUND):
+			return nil, fmt.Errorf("open %q: %w", path, ErrNotListening)
+		case errors.Is(err, windows.ERROR_PIPE_BUSY) && tries < 50:
+			time.Sleep(10 * time.Millisecond)
+		default:
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+	}
+}
+
+// Remove closes the idle instance of path; the pipe goes away with the
+// last connection.
+func (t *pipeTransport) Remove(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h, ok := t.pending[path]; ok {
+		delete(t.pending, path)
+		return windows.CloseHandle(h)
+	}
+	return nil
+}
+
+// pipeConn is one end of a named pipe connection.
+type pipeConn windows.Handle
+
+// Read ends with io.EOF when the other end hangs up.
+func (c pipeConn) Read(b []byte) (int, error) {
+	var n uint32
+	err := windows.ReadFile(windows.Handle(c), b, &n, nil)
+	if errors.Is(err, windows.ERROR_BROKEN_PIPE) {
+		return int(n), io.EOF
+	}
+	return int(n), err
+}
+
+func (c pipeConn) Write(b []byte) (int, error) {
+	var n uint32
+	err := windows.WriteFile(windows.Handle(c), b, &n, nil)
+	return int(n), err
+}
+
+func (c pipeConn) Close() error { return windows.CloseHandle(windows.Handle(c)) }
@@ -2,144 +2,380 @@ package polybar
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"syscall"
+	"text/template"
 	"time"
 
 	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/ipc"
 )
 
-var (
-	fifoPipePath string
-
+// Server is one polybar module runtime: the command FIFO, the command loop
+// and the status output. The zero value is not usable; create it with New.
+// A Server can be started and stopped once.
+type Server struct {
 	mu                sync.RWMutex
+	fifoPipePath      string
 	guiToggleCallback func()
+	handlers          map[string]focotimer.CommandFunc
+	timerManager      *focotimer.TimerManager
+	dispatcher        *focotimer.Dispatcher
+	customCommands    map[string][]string
+	aliases           map[string]string
+	sequences         map[string]focotimer.Sequence
+
+	clock     Clock
+	commands  io.Reader // replaces the FIFO when set
+	bareClick bool      // click actions are the commands themselves
+	discovery string    // the file written by Advertise
+	transport Transport
+	out       io.Writer
+	clicks    map[Segment]map[MouseButton]string
+	sender    string // binary whose send subcommand click actions run
+	format    Format
+	colors    *Colors // nil leaves the output unstyled
+	fit       Fit
+	template  *template.Template // nil is the default output
+	flashOn   bool               // a flashing timer is highlighted this tick
+	icons     *Icons             // nil is text only
+	interval  time.Duration
+	refresh   chan struct{} // prints the output now, changed or not
+	bars      broadcast     // bars that subscribed to the output
 
-	timerMu   sync.Mutex
 	startOnce sync.Once
 	stopOnce  sync.Once
 	wg        sync.WaitGroup
-	stopping  = make(chan struct{})
+	stopping  chan struct{}
+}
 
-	timerManager *focotimer.TimerManager
-)
+// Option configures a Server at construction.
+type Option func(*Server)
+
+// WithPath uses path as the command FIFO instead of a unique one derived
+// from $FOCOTIMER_PIPE. It is created on Start if missing.
+func WithPath(path string) Option {
+	return func(s *Server) { s.fifoPipePath = path }
+}
+
+// WithClock replaces the wall clock that paces the output and the command
+// loop's retries.
+func WithClock(c Clock) Option {
+	return func(s *Server) { s.clock = c }
+}
+
+// WithCommands reads commands from r instead of a FIFO. The command loop
+// ends at EOF; Stop closes r if it is an io.Closer.
+func WithCommands(r io.Reader) Option {
+	return func(s *Server) { s.commands = r }
+}
+
+// WithTransport carries commands and replies over t instead of the
+// platform's DefaultTransport.
+func WithTransport(t Transport) Option {
+	return func(s *Server) { s.transport = t }
+}
+
+// WithOutput writes the module output to w instead of stdout.
+func WithOutput(w io.Writer) Option {
+	return func(s *Server) { s.out = w }
+}
+
+// WithClicks replaces the commands run by clicks on the timer; buttons
+// missing from clicks do nothing.
+func WithClicks(clicks map[MouseButton]string) Option {
+	return func(s *Server) { s.clicks[SegmentTimer] = clicks }
+}
+
+// WithSender makes click actions run "<exe> send" instead of the running
+// binary's send subcommand.
+func WithSender(exe string) Option {
+	return func(s *Server) { s.sender = exe }
+}
+
+// WithFormat selects the output markup; the default is FormatPolybar.
+func WithFormat(f Format) Option {
+	return func(s *Server) { s.format = f }
+}
+
+// WithColors colors the timer by state.
+func WithColors(c Colors) Option {
+	return func(s *Server) { s.colors = &c }
+}
+
+// WithInterval sets how often the output is rendered; the default is a
+// second.
+func WithInterval(d time.Duration) Option {
+	return func(s *Server) { s.interval = d }
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		clock:     realClock{},
+		transport: DefaultTransport,
+		out:       os.Stdout,
+		clicks:    maps.Clone(DefaultSegmentClicks),
+		sender:    executable(),
+		format:    FormatPolybar,
+		interval:  time.Second,
+		refresh:   make(chan struct{}, 1),
+		stopping:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// --- Clock ---
+
+// Clock is the time source of the server loops.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns the tick channel and a function that stops it.
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// defaultServer backs the package-level functions.
+var defaultServer = New()
 
 // --- TimerManager injection ---
 
 // SetTimerManager lets the application provide a shared TimerManager instance.
 // Safe to call before or after Init().
-func SetTimerManager(tm *focotimer.TimerManager) {
-	timerMu.Lock()
-	defer timerMu.Unlock()
-	timerManager = tm
+func (s *Server) SetTimerManager(tm *focotimer.TimerManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timerManager = tm
+	s.rebuildDispatcherLocked()
+}
+
+// DefineCommands installs user-defined commands (name -> command sequence)
+// that FIFO clients can invoke by name.
+func (s *Server) DefineCommands(cmds map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customCommands = cmds
+	s.rebuildDispatcherLocked()
+}
+
+// DefineAliases installs alternative names (alias -> command) for FIFO
+// commands.
+func (s *Server) DefineAliases(aliases map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases = aliases
+	s.rebuildDispatcherLocked()
+}
+
+// DefineSequences installs interval sequences that FIFO clients start with
+// "cycle <name>".
+func (s *Server) DefineSequences(seqs map[string]focotimer.Sequence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequences = seqs
+	s.rebuildDispatcherLocked()
+}
+
+func (s *Server) rebuildDispatcherLocked() {
+	if s.timerManager == nil {
+		s.dispatcher = nil
+		return
+	}
+	d := focotimer.NewDispatcher(s.timerManager)
+	d.Handle("refresh", func(args []string) error {
+		s.Refresh()
+		return nil
+	})
+	d.Handle("subscribe", func(args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: subscribe <path>")
+		}
+		// the bar opens its pipe after sending the command, so do not hold
+		// up the command loop while waiting for it
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.AddBar(args[0]); err != nil {
+				log.Printf("polybar.AddBar: %v", err)
+			}
+		}()
+		return nil
+	})
+	d.Handle("unsubscribe", func(args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: unsubscribe <path>")
+		}
+		s.RemoveBar(args[0])
+		return nil
+	})
+	d.Handle("gui", func(args []string) error {
+		s.mu.RLock()
+		cb := s.guiToggleCallback
+		s.mu.RUnlock()
+		if cb != nil {
+			cb()
+		}
+		return nil
+	})
+	for name, fn := range s.handlers {
+		d.Handle(name, fn)
+	}
+	for name, target := range s.aliases {
+		if err := d.Alias(name, target); err != nil {
+			log.Printf("polybar: skipping alias %q: %v", name, err)
+		}
+	}
+	for name, seq := range s.sequences {
+		if err := d.DefineSequence(name, seq); err != nil {
+			log.Printf("polybar: skipping sequence %q: %v", name, err)
+		}
+	}
+	for name, steps := range s.customCommands {
+		if err := d.Define(name, steps); err != nil {
+			log.Printf("polybar: skipping command %q: %v", name, err)
+		}
+	}
+	s.dispatcher = d
 }
 
 // getTimerManager safely returns the current TimerManager or nil.
-func getTimerManager() *focotimer.TimerManager {
-	timerMu.Lock()
-	defer timerMu.Unlock()
-	return timerManager
+func (s *Server) getTimerManager() *focotimer.TimerManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.timerManager
+}
+
+func (s *Server) getDispatcher() *focotimer.Dispatcher {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dispatcher
 }
 
 // --- Polybar setup ---
 
-func Init() {
-	base := os.Getenv("FOCOTIMER_PIPE")
-	if base == "" {
-		base = "/tmp/focotimer.pipe"
+// Init creates the command pipe: a unique one named after
+// $FOCOTIMER_PIPE when set, else the well-known one in the runtime
+// directory ($XDG_RUNTIME_DIR/focotimer/cmd.fifo), or a unique one next to
+// it while another focotimer reads that. Advertise tells clients which.
+func (s *Server) Init() {
+	var path string
+	var err error
+	if base := os.Getenv("FOCOTIMER_PIPE"); base != "" {
+		path, err = s.InitWithBase(base)
+	} else {
+		path, err = s.initDefault()
 	}
-	path, err := InitWithBase(base)
 	if err != nil {
 		log.Fatalf("polybar.Init: %v", err)
 	}
 	log.Printf("FIFO created at %q", path)
 }
 
-func InitWithBase(base string) (string, error) {
-	abs := base
-	if !filepath.IsAbs(abs) {
-		abs = filepath.Join(os.TempDir(), base)
+func (s *Server) initDefault() (string, error) {
+	path := defaultPipePath()
+	if _, ok := s.transport.(*socketTransport); ok {
+		path = filepath.Join(ipc.RuntimeDir(), "cmd.sock")
 	}
-
-	path, err := mkfifoUnique(abs, 0666)
-	if err != nil {
+	if w, err := s.transport.Dial(path); err == nil {
+		w.Close()
+		return s.InitWithBase(path)
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return "", err
+		}
+	}
+	if err := s.transport.Ensure(path); err != nil {
 		return "", err
 	}
-	fifoPipePath = path
+	s.mu.Lock()
+	s.fifoPipePath = path
+	s.mu.Unlock()
 	return path, nil
 }
 
-func mkfifoUnique(base string, mode os.FileMode) (string, error) {
-	// Add PID to make it unique per process
-	pid := os.Getpid()
-
-	for i := 0; i < 1000; i++ {
-		var path string
-		if i == 0 {
-			path = fmt.Sprintf("%s.%d", base, pid)
-		} else {
-			path = fmt.Sprintf("%s.%d.%d", base, pid, i)
-		}
-
-		err := syscall.Mkfifo(path, uint32(mode.Perm()))
-		if err == nil {
-			return path, nil
-		}
-		if errors.Is(err, os.ErrExist) || err == syscall.EEXIST {
-			fi, statErr := os.Lstat(path)
-			if statErr != nil {
-				continue
-			}
-			if (fi.Mode() & os.ModeNamedPipe) != 0 {
-				// Check if the FIFO is actually usable (not in use by another process)
-				if canUseFifo(path) {
-					return path, nil
-				}
-			}
-			continue
-		}
-		return "", fmt.Errorf("mkfifo %q: %w", path, err)
+func (s *Server) InitWithBase(base string) (string, error) {
+	path, err := s.transport.Create(base)
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("unable to allocate unique FIFO for base %q after many attempts", base)
+	s.mu.Lock()
+	s.fifoPipePath = path
+	s.mu.Unlock()
+	return path, nil
 }
 
-// canUseFifo checks if we can actually use this FIFO (not locked by another process)
-func canUseFifo(path string) bool {
-	// Try to open for writing with O_NONBLOCK to test availability
-	file, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
-	if err != nil {
-		return false
-	}
-	file.Close()
-	return true
+func (s *Server) FifoPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fifoPipePath
 }
 
 // --- Handlers ---
 
-func AddHandler(f func()) {
-	mu.Lock()
-	guiToggleCallback = f
-	mu.Unlock()
+func (s *Server) AddHandler(f func()) {
+	s.mu.Lock()
+	s.guiToggleCallback = f
+	s.mu.Unlock()
 }
 
-func Main() {
-	if fifoPipePath == "" {
-		Init()
+// Handle adds a command of the frontend, such as the GUI's "open".
+func (s *Server) Handle(name string, fn focotimer.CommandFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]focotimer.CommandFunc)
+	}
+	s.handlers[name] = fn
+	s.rebuildDispatcherLocked()
+}
+
+// Start creates the FIFO if needed and launches the command loop. It
+// returns immediately; calling it again has no effect.
+func (s *Server) Start() {
+	if s.commands == nil {
+		if path := s.FifoPath(); path == "" {
+			s.Init()
+		} else if err := s.transport.Ensure(path); err != nil {
+			log.Fatalf("polybar.Start: %v", err)
+		}
 	}
 
-	startOnce.Do(func() {
-		wg.Add(1)
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
 		go func() {
-			defer wg.Done()
-			handle_cmds()
+			defer s.wg.Done()
+			s.handleCmds()
 		}()
 	})
+}
+
+// Run starts the server and renders the module output every interval
+// until SIGINT/SIGTERM or Stop. A line is only printed when it changed, so
+// the bar is not redrawn for nothing, or on Refresh.
+func (s *Server) Run() {
+	s.Start()
 
 	// Set up signal handling BEFORE starting the main loop
 	sigc := make(chan os.Signal, 2) // Increased buffer size
@@ -151,120 +387,465 @@ func Main() {
 		close(sigc)
 	}()
 
-	t := time.NewTicker(1 * time.Second)
-	defer t.Stop()
+	s.mu.RLock()
+	interval := s.interval
+	s.mu.RUnlock()
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticks, stopTicker := s.clock.NewTicker(interval)
+	defer stopTicker()
 
 	// Defensive: check timer manager before use
-	if tm := getTimerManager(); tm != nil {
-		Subscribe()
-		// TimerStart()
-		// tm.Timer.AddHandler(func() { log.Println("Timer finished!") })
+	if tm := s.getTimerManager(); tm != nil {
+		s.Subscribe()
 	} else {
 		log.Println("polybar.Main: no TimerManager set, timer disabled")
 	}
 
 	log.Println("polybar.Main: starting main loop")
 
+	var last, lastErr string
+	emit := func(out string) {
+		s.mu.RLock()
+		w := s.out
+		s.mu.RUnlock()
+		if _, err := fmt.Fprintln(w, out); err != nil {
+			// a bar that is not up yet fails every tick
+			if err.Error() != lastErr {
+				log.Printf("polybar.Main: %v", err)
+			}
+			lastErr = err.Error()
+			return
+		}
+		last, lastErr = out, ""
+	}
 	for {
 		select {
-		case <-t.C:
-			fmt.Println(output())
+		case <-ticks:
+			out := s.output()
+			s.bars.write(out, false)
+			if out != last {
+				emit(out)
+			}
+		case <-s.refresh:
+			out := s.output()
+			s.bars.write(out, true)
+			emit(out)
 		case sig := <-sigc:
 			log.Printf("polybar.Main: received signal %v, shutting down", sig)
-			Shutdown()
+			s.Stop()
 			return
-		case <-stopping:
+		case <-s.stopping:
 			log.Println("polybar.Main: stopping channel triggered")
 			return
 		}
 	}
 }
 
-func Shutdown() {
+// Refresh prints the output right away, even if it did not change, e.g.
+// after the bar restarted; it is the "refresh" command.
+func (s *Server) Refresh() {
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// SetCommands reads commands from r instead of the FIFO, e.g. stdin under
+// a bar such as lemonbar that prints the action of a click: click actions
+// become the bare commands. Call it before Start.
+func (s *Server) SetCommands(r io.Reader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = r
+	s.bareClick = true
+}
+
+// SetTransport carries commands over t, e.g. NewSocketTransport. Call it
+// before Init.
+func (s *Server) SetTransport(t Transport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transport = t
+}
+
+// SetOutput writes the module output to w, e.g. a PolybarMsg.
+func (s *Server) SetOutput(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.out = w
+}
+
+// SetInterval sets how often Run renders the output; it applies from the
+// next Run.
+func (s *Server) SetInterval(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = d
+}
+
+// Stop ends the command loop and removes the FIFO. It is safe to call
+// more than once and from several goroutines.
+func (s *Server) Stop() {
 	log.Println("polybar.Shutdown: initiating shutdown")
-	stopOnce.Do(func() {
-		close(stopping)
-		if fifoPipePath != "" {
-			log.Printf("polybar.Shutdown: removing FIFO %q", fifoPipePath)
-			if err := os.Remove(fifoPipePath); err != nil && !errors.Is(err, os.ErrNotExist) {
-				log.Printf("warning: removing FIFO %q: %v", fifoPipePath, err)
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+		s.bars.closeAll()
+		if s.commands != nil {
+			if c, ok := s.commands.(io.Closer); ok {
+				c.Close()
+			}
+			return
+		}
+		path := s.FifoPath()
+		if path != "" {
+			s.unadvertise(path)
+			s.wakeCommandLoop(path)
+			log.Printf("polybar.Shutdown: removing FIFO %q", path)
+			if err := s.transport.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("warning: removing FIFO %q: %v", path, err)
 			}
 		}
 	})
 	log.Println("polybar.Shutdown: waiting for goroutines")
-	wg.Wait()
+	s.wg.Wait()
 	log.Println("polybar.Shutdown: complete")
 }
 
-func FifoPath() string { return fifoPipePath }
+// wakeCommandLoop unblocks a command loop waiting for a client by briefly
+// connecting to the FIFO, until the loop has exited.
+func (s *Server) wakeCommandLoop(path string) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	for {
+		if w, err := s.transport.Dial(path); err == nil {
+			w.Close()
+		}
+		select {
+		case <-done:
+			return
+		case <-s.clock.After(10 * time.Millisecond):
+		}
+	}
+}
 
 // --- Internal command loop ---
 
-func handle_cmds() {
+func (s *Server) handleCmds() {
 	log.Println("polybar.handle_cmds: starting command handler")
 	defer log.Println("polybar.handle_cmds: command handler stopped")
 
+	if s.commands != nil {
+		s.readCmds(s.commands)
+		return
+	}
+
 	for {
 		select {
-		case <-stopping:
+		case <-s.stopping:
 			log.Println("polybar.handle_cmds: stopping signal received")
 			return
 		default:
 		}
 
-		log.Printf("polybar.handle_cmds: opening FIFO %q", fifoPipePath)
-		file, err := os.OpenFile(fifoPipePath, os.O_RDONLY, os.ModeNamedPipe)
+		path := s.FifoPath()
+		log.Printf("polybar.handle_cmds: opening FIFO %q", path)
+		file, err := s.transport.Accept(path)
 		if err != nil {
 			log.Printf("polybar.handle_cmds: open FIFO error: %v", err)
 			// Check if we're shutting down
 			select {
-			case <-stopping:
+			case <-s.stopping:
 				return
-			case <-time.After(time.Second):
+			case <-s.clock.After(time.Second):
 				continue
 			}
 		}
 
 		log.Println("polybar.handle_cmds: FIFO opened, reading commands")
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			cmd := scanner.Text()
-			log.Printf("polybar.handle_cmds: received command: %q", cmd)
-			switch cmd {
-			case "start":
-				TimerStart()
-			case "gui":
-				mu.RLock()
-				cb := guiToggleCallback
-				mu.RUnlock()
-				if cb != nil {
-					cb()
-				}
-			case "inc":
-				TimerInc()
-			case "dec":
-				TimerDec()
-			case "stop":
-				TimerStop()
-			default:
-				log.Printf("polybar.handle_cmds: unknown command: %q", cmd)
-			}
-		}
-
-		if err := scanner.Err(); err != nil {
-			log.Printf("polybar.handle_cmds: scanner error: %v", err)
-		}
+		s.readCmds(file)
 
 		log.Println("polybar.handle_cmds: closing FIFO")
 		_ = file.Close()
 
 		// Small delay before reopening to prevent tight loops
 		select {
-		case <-stopping:
+		case <-s.stopping:
 			return
-		case <-time.After(100 * time.Millisecond):
+		case <-s.clock.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// readCmds dispatches one command per line until r is exhausted.
+func (s *Server) readCmds(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		cmd := scanner.Text()
+		if strings.TrimSpace(cmd) == "" {
+			continue
+		}
+		log.Printf("polybar.handle_cmds: received command: %q", cmd)
+		s.dispatch(cmd)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		log.Printf("polybar.handle_cmds: scanner error: %v", err)
+	}
+}
+
+// dispatch runs one command line. A line ending in "> path" also writes
+// the outcome to the reply FIFO at path; "status > path" only replies.
+func (s *Server) dispatch(line string) {
+	cmd, replyPath := splitReply(line)
+	var err error
+	d := s.getDispatcher()
+	switch {
+	case d == nil:
+		log.Printf("polybar.handle_cmds: no TimerManager set, ignoring command: %q", cmd)
+		err = errors.New("no timer")
+	case strings.TrimSpace(cmd) == "status":
+	default:
+		if err = d.Dispatch(cmd); err != nil {
+			log.Printf("polybar.handle_cmds: %v", err)
+		}
+	}
+	if replyPath == "" {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.reply(replyPath, err); err != nil {
+			log.Printf("polybar.handle_cmds: reply: %v", err)
+		}
+	}()
+}
+
+// --- Replies ---
+
+// replyTimeout bounds how long a reply waits for a reader on its FIFO.
+const replyTimeout = 5 * time.Second
+
+// splitReply separates a trailing "> path" reply target from a command.
+// Only an absolute path to an existing FIFO, or socket with the socket
+// transport, is a target, so "label a>b" keeps its '>'; another path is
+// logged and left in the command.
+func splitReply(line string) (cmd, path string) {
+	i := strings.LastIndex(line, ">")
+	if i < 0 {
+		return line, ""
+	}
+	path = strings.TrimSpace(line[i+1:])
+	if path == "" {
+		return line, ""
+	}
+	if err := replyTarget(path); err != nil {
+		log.Printf("polybar.handle_cmds: not replying to %q: %v", path, err)
+		return line, ""
+	}
+	return strings.TrimSpace(line[:i]), path
+}
+
+// replyTarget checks that path can take a reply.
+func replyTarget(path string) error {
+	if !filepath.IsAbs(path) {
+		return errors.New("not an absolute path")
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&(os.ModeNamedPipe|os.ModeSocket) == 0 {
+		return errors.New("not a FIFO")
+	}
+	return nil
+}
+
+// reply writes the timer state, or the command's error, as one JSON line
+// to the FIFO at path, like the HTTP API answers.
+func (s *Server) reply(path string, cmdErr error) error {
+	var v any = map[string]string{"error": fmt.Sprint(cmdErr)}
+	if tm := s.getTimerManager(); tm != nil && cmdErr == nil {
+		v = tm.State()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w, err := s.dialRetry(path)
+	if err != nil || w == nil {
+		return err
+	}
+	defer w.Close()
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// dialRetry connects to the pipe at path, waiting up to replyTimeout for
+// its reader. It returns nil and no error if the Server stops meanwhile.
+func (s *Server) dialRetry(path string) (io.WriteCloser, error) {
+	// the reader may open the FIFO just after sending the command; Dial
+	// fails until then instead of stalling
+	const retry = 50 * time.Millisecond
+	for waited := time.Duration(0); ; waited += retry {
+		w, err := s.transport.Dial(path)
+		if err == nil {
+			return w, nil
+		}
+		if !errors.Is(err, ErrNotListening) || waited >= replyTimeout {
+			return nil, err
+		}
+		select {
+		case <-s.stopping:
+			return nil, nil
+		case <-s.clock.After(retry):
+		}
+	}
+}
+
+// --- Click actions ---
+
+// MouseButton is a polybar action button: 1 to 3 are the left, middle and
+// right buttons, 4 and 5 scrolling up and down.
+type MouseButton int
+
+const (
+	LeftClick MouseButton = iota + 1
+	MiddleClick
+	RightClick
+	ScrollUp
+	ScrollDown
+)
+
+var mouseButtonNames = map[string]MouseButton{
+	"left":        LeftClick,
+	"middle":      MiddleClick,
+	"right":       RightClick,
+	"scroll_up":   ScrollUp,
+	"scroll_down": ScrollDown,
+}
+
+// ParseMouseButton reads the config name of a button, e.g. "scroll_up".
+func ParseMouseButton(name string) (MouseButton, error) {
+	if b, ok := mouseButtonNames[name]; ok {
+		return b, nil
+	}
+	return 0, fmt.Errorf("unknown mouse button %q", name)
+}
+
+// DefaultClicks are the commands behind each button on the timer.
+var DefaultClicks = map[MouseButton]string{
+	LeftClick:   "gui",
+	MiddleClick: "toggle",
+	RightClick:  "reset",
+	ScrollUp:    "inc",
+	ScrollDown:  "dec",
+}
+
+// Segment is a clickable part of the polybar output.
+type Segment string
+
+const (
+	SegmentTimer Segment = "timer"
+	// SegmentDec and SegmentInc are the [-] and [+] buttons.
+	SegmentDec Segment = "dec"
+	SegmentInc Segment = "inc"
+)
+
+// ParseSegment reads the config name of a segment, e.g. "inc".
+func ParseSegment(name string) (Segment, error) {
+	switch seg := Segment(name); seg {
+	case SegmentTimer, SegmentDec, SegmentInc:
+		return seg, nil
+	}
+	return "", fmt.Errorf("unknown segment %q", name)
+}
+
+// DefaultSegmentClicks are the commands behind each button on each
+// segment.
+var DefaultSegmentClicks = map[Segment]map[MouseButton]string{
+	SegmentTimer: DefaultClicks,
+	SegmentDec:   {LeftClick: "dec"},
+	SegmentInc:   {LeftClick: "inc"},
+}
+
+// SetClicks replaces the commands run by clicks on the timer.
+func (s *Server) SetClicks(clicks map[MouseButton]string) {
+	s.SetSegmentClicks(SegmentTimer, clicks)
+}
+
+// SetSegmentClicks replaces the commands run by clicks on seg; buttons
+// missing from clicks do nothing there.
+func (s *Server) SetSegmentClicks(seg Segment, clicks map[MouseButton]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clicks[seg] = clicks
+}
+
+// SetFormat selects the output markup.
+func (s *Server) SetFormat(f Format) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = f
+}
+
+// SetColors colors the timer by state; nil turns coloring off.
+func (s *Server) SetColors(c *Colors) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.colors = c
+}
+
+// SetIcons puts an icon by phase before the timer and on the buttons;
+// nil goes back to text.
+func (s *Server) SetIcons(i *Icons) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.icons = i
+}
+
+// SetFit bounds the width of the output.
+func (s *Server) SetFit(f Fit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fit = f
+}
+
+// polybarClickable wraps label in one action tag per button of seg, each
+// sending its command to the FIFO.
+func (s *Server) polybarClickable(seg Segment, label string) string {
+	s.mu.RLock()
+	clicks := s.clicks[seg]
+	s.mu.RUnlock()
+
+	var open, closing strings.Builder
+	for b := LeftClick; b <= ScrollDown; b++ {
+		cmd, ok := clicks[b]
+		if !ok || cmd == "" {
+			continue
 		}
+		fmt.Fprintf(&open, "%%{A%d:%s:}", b, s.clickAction(cmd))
+		closing.WriteString("%{A}")
+	}
+	return open.String() + " " + label + " " + closing.String()
+}
+
+// polybarButton is label as the button seg. A button only clicked on
+// with the left button keeps the short action tag.
+func (s *Server) polybarButton(seg Segment, label string) string {
+	s.mu.RLock()
+	clicks := s.clicks[seg]
+	s.mu.RUnlock()
+	if cmd, ok := clicks[LeftClick]; ok && len(clicks) == 1 {
+		return polybarActionButton(label, s.clickAction(cmd))
 	}
+	return s.polybarClickable(seg, label)
 }
 
 func polybarActionButton(button string, action string) string {
@@ -275,59 +856,135 @@ func polybarActionButton(button string, action string) string {
 	return fmt.Sprintf("%%{A:%s:} %s %%{A}", action, lbl)
 }
 
-func pipeCommand(cmd string) string {
-	return fmt.Sprintf("echo '%s' > %s", cmd, fifoPipePath)
+// clickAction is the shell command polybar runs for a click: the send
+// subcommand with the FIFO and cmd as quoted arguments, so commands with
+// arguments need no shell escaping of their own. See EncodeArg for how
+// polybar's tag syntax is kept out of the arguments.
+func (s *Server) clickAction(cmd string) string {
+	s.mu.RLock()
+	exe, bare := s.sender, s.bareClick
+	s.mu.RUnlock()
+	if bare {
+		return strings.ReplaceAll(cmd, ":", `\:`)
+	}
+	// polybar unescapes "\:" in actions; the path of the binary cannot be
+	// percent-encoded as the shell runs it
+	exe = strings.ReplaceAll(shellQuote(exe), ":", `\:`)
+	return fmt.Sprintf("%s send -pipe %s %s", exe, shellQuote(EncodeArg(s.FifoPath())), shellQuote(EncodeArg(cmd)))
 }
 
 // --- Output helpers ---
 
-func output() string {
-	dur, rem := timerSnapshot()
-	timestring := fmt.Sprintf("%s : %s", truncToSecond(dur), truncToSecond(rem))
+func (s *Server) output() string {
+	dur, rem := s.timerSnapshot()
+	label, color := "", ""
+	state := focotimer.State{Status: focotimer.StatusIdle, Duration: dur, Remaining: rem}
+	s.mu.RLock()
+	format, colors, fit, tmpl, icons := s.format, s.colors, s.fit, s.template, s.icons
+	s.mu.RUnlock()
+	flashing := false
+	dec, inc := "[-]", "[+]"
+	if tm := s.getTimerManager(); tm != nil {
+		// the interval (and sub-phase) while a sequence runs, or a user label
+		label = tm.Activity()
+		state = tm.State()
+		state.Duration, state.Remaining = dur, rem
+		if colors != nil {
+			color = colors.For(state)
+			flashing = colors.Flashes(state)
+		}
+	}
+	activity := label
+	if icons != nil {
+		icon := icons.For(state)
+		label = strings.TrimSpace(icon + " " + label)
+		if fit.Icon == "" {
+			fit.Icon = icon
+		}
+		dec, inc = icons.Dec, icons.Inc
+	}
+	style := func(text string) string {
+		text = colorize(format, color, text)
+		if !flashing {
+			return text
+		}
+		s.mu.Lock()
+		s.flashOn = !s.flashOn
+		on := s.flashOn
+		s.mu.Unlock()
+		return blink(format, on, text)
+	}
+
+	text := func(markup bool) (string, bool) {
+		if tmpl != nil {
+			out, err := executeTemplate(tmpl, state, activity, fit)
+			if err == nil {
+				return out, false
+			}
+			log.Printf("polybar.output: %v", err)
+		}
+		return fit.fitted(label, dur, rem, markup)
+	}
 
-	return polybarActionButton("[-]", pipeCommand("dec")) +
-		polybarActionButton(timestring, pipeCommand("gui")) +
-		polybarActionButton("[+]", pipeCommand("inc"))
+	switch format {
+	case FormatI3Status:
+		full, _ := text(false)
+		short, _ := fit.text(stepShort, "", dur, rem)
+		return i3status(state, full, short)
+	case FormatI3Blocks:
+		full, _ := text(false)
+		return full
+	case FormatPlain, FormatTmux:
+		full, _ := text(false)
+		return style(full)
+	}
+	full, buttons := text(true)
+	out := style(s.polybarClickable(SegmentTimer, full))
+	if buttons {
+		out = s.polybarButton(SegmentDec, dec) + out + s.polybarButton(SegmentInc, inc)
+	}
+	return out
 }
 
 // --- Timer wrappers (null-safe) ---
 
-func TimerStart() {
-	if tm := getTimerManager(); tm != nil {
+func (s *Server) TimerStart() {
+	if tm := s.getTimerManager(); tm != nil {
 		tm.Start()
 	}
 }
-func TimerStop() {
-	if tm := getTimerManager(); tm != nil {
+func (s *Server) TimerStop() {
+	if tm := s.getTimerManager(); tm != nil {
 		tm.Stop()
 	}
 }
-func TimerInc() {
-	if tm := getTimerManager(); tm != nil {
+func (s *Server) TimerInc() {
+	if tm := s.getTimerManager(); tm != nil {
 		tm.Inc()
 	}
 }
-func TimerDec() {
-	if tm := getTimerManager(); tm != nil {
+func (s *Server) TimerDec() {
+	if tm := s.getTimerManager(); tm != nil {
 		tm.Dec()
 	}
 }
-func Subscribe() <-chan time.Duration {
-	if tm := getTimerManager(); tm != nil {
-		return tm.Subscribe()
+func (s *Server) Subscribe() <-chan time.Duration {
+	if tm := s.getTimerManager(); tm != nil {
+		// the bar only renders whole seconds
+		return tm.SubscribeEvery(time.Second, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
 	}
 	return nil
 }
-func Snapshot() time.Duration {
-	if tm := getTimerManager(); tm != nil {
+func (s *Server) Snapshot() time.Duration {
+	if tm := s.getTimerManager(); tm != nil {
 		return tm.Snapshot()
 	}
 	return 0
 }
 
-func timerSnapshot() (time.Duration, time.Duration) {
-	if tm := getTimerManager(); tm != nil {
-		d := tm.Timer.Duration
+func (s *Server) timerSnapshot() (time.Duration, time.Duration) {
+	if tm := s.getTimerManager(); tm != nil {
+		d := tm.Duration()
 		r := tm.Snapshot()
 		return d, r
 	}
@@ -340,3 +997,39 @@ func truncToSecond(d time.Duration) time.Duration {
 	}
 	return d.Truncate(time.Second)
 }
+
+// --- Package-level wrappers around the default Server ---
+
+func SetTimerManager(tm *focotimer.TimerManager)         { defaultServer.SetTimerManager(tm) }
+func DefineCommands(cmds map[string][]string)            { defaultServer.DefineCommands(cmds) }
+func DefineAliases(aliases map[string]string)            { defaultServer.DefineAliases(aliases) }
+func DefineSequences(seqs map[string]focotimer.Sequence) { defaultServer.DefineSequences(seqs) }
+func SetClicks(clicks map[MouseButton]string)            { defaultServer.SetClicks(clicks) }
+func SetFormat(f Format)                                 { defaultServer.SetFormat(f) }
+func SetColors(c *Colors)                                { defaultServer.SetColors(c) }
+func SetFit(f Fit)                                       { defaultServer.SetFit(f) }
+func SetIcons(i *Icons)                                  { defaultServer.SetIcons(i) }
+func SetInterval(d time.Duration)                        { defaultServer.SetInterval(d) }
+func Refresh()                                           { defaultServer.Refresh() }
+func SetOutput(w io.Writer)                              { defaultServer.SetOutput(w) }
+func SetCommands(r io.Reader)                            { defaultServer.SetCommands(r) }
+func SetTransport(t Transport)                           { defaultServer.SetTransport(t) }
+func Advertise() error                                   { return defaultServer.Advertise() }
+func SetTemplate(text string) error                      { return defaultServer.SetTemplate(text) }
+func Init()                                              { defaultServer.Init() }
+func InitWithBase(base string) (string, error)           { return defaultServer.InitWithBase(base) }
+func AddHandler(f func())                                { defaultServer.AddHandler(f) }
+func Handle(name string, fn focotimer.CommandFunc)       { defaultServer.Handle(name, fn) }
+func Main()                                              { defaultServer.Run() }
+func Shutdown()                                          { defaultServer.Stop() }
+func FifoPath() string                                   { return defaultServer.FifoPath() }
+func TimerStart()                                        { defaultServer.TimerStart() }
+func TimerStop()                                         { defaultServer.TimerStop() }
+func TimerInc()                                          { defaultServer.TimerInc() }
+func TimerDec()                                          { defaultServer.TimerDec() }
+func Subscribe() <-chan time.Duration                    { return defaultServer.Subscribe() }
+func Snapshot() time.Duration                            { return defaultServer.Snapshot() }
+
+func SetSegmentClicks(seg Segment, clicks map[MouseButton]string) {
+	defaultServer.SetSegmentClicks(seg, clicks)
+}
@@ -1,12 +1,19 @@
+//go:build !windows
+
 package polybar
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -30,6 +37,52 @@ func waitForFile(path string, timeout time.Duration) bool {
 	return false
 }
 
+// fakeClock ticks only when the test says so; its timers never fire, and
+// every wait is reported on waits (if set).
+type fakeClock struct {
+	ticks chan time.Time
+	waits chan time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{ticks: make(chan time.Time)}
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	if c.waits != nil {
+		c.waits <- d
+	}
+	return make(chan time.Time)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {}
+}
+
+// send writes a command to the in-memory command source and returns once
+// the command loop has dispatched it: the pipe only accepts the following
+// blank line when the loop reads again.
+func send(t *testing.T, w io.Writer, cmd string) {
+	t.Helper()
+	if _, err := io.WriteString(w, cmd+"\n"); err != nil {
+		t.Fatalf("Failed to send %q: %v", cmd, err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		t.Fatalf("Failed to sync after %q: %v", cmd, err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
 func writeToFifo(t *testing.T, path, data string) {
 	file, err := os.OpenFile(path, os.O_WRONLY, 0)
 	if err != nil {
@@ -45,8 +98,7 @@ func writeToFifo(t *testing.T, path, data string) {
 // ================= Setup/Teardown Tests =================
 
 func TestInit(t *testing.T) {
-	// Reset global state
-	fifoPipePath = ""
+	s := New()
 
 	tmpDir := setupTempDir(t)
 	basePipe := filepath.Join(tmpDir, "test.pipe")
@@ -56,7 +108,8 @@ func TestInit(t *testing.T) {
 	os.Setenv("FOCOTIMER_PIPE", basePipe)
 	defer os.Setenv("FOCOTIMER_PIPE", oldEnv)
 
-	Init()
+	s.Init()
+	fifoPipePath := s.FifoPath()
 
 	if fifoPipePath == "" {
 		t.Fatal("Expected fifoPipePath to be set after Init")
@@ -84,6 +137,56 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestInit_Default(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	t.Setenv("FOCOTIMER_PIPE", "")
+	want := filepath.Join(runtimeDir, "focotimer", "cmd.fifo")
+
+	s := New(WithClock(realClock{}))
+	s.Init()
+	if path := s.FifoPath(); path != want {
+		t.Fatalf("Expected the well-known FIFO %q, got %q", want, path)
+	}
+	if _, err := Discover(); err == nil {
+		t.Error("Expected no discovery file before Advertise")
+	}
+	if err := s.Advertise(); err != nil {
+		t.Fatalf("Advertise failed: %v", err)
+	}
+	if path, err := Discover(); err != nil || path != want {
+		t.Errorf("Expected to discover %q, got %q, %v", want, path, err)
+	}
+	s.Start()
+	waitFor(t, "the command loop to open the FIFO", func() bool {
+		w, err := DefaultTransport.Dial(want)
+		if err != nil {
+			return false
+		}
+		w.Close()
+		return true
+	})
+
+	// a second instance keeps out of the way of the first
+	other := New()
+	other.Init()
+	if path := other.FifoPath(); path == want || !strings.HasPrefix(path, want+".") {
+		t.Errorf("Expected a unique FIFO next to %q, got %q", want, path)
+	}
+	if err := other.Advertise(); err != nil {
+		t.Fatalf("Advertise failed: %v", err)
+	}
+
+	s.Stop()
+	if path, err := Discover(); err != nil || path != other.FifoPath() {
+		t.Errorf("Expected the discovery file of the other instance to stay, got %q, %v", path, err)
+	}
+	other.Stop()
+	if _, err := os.Stat(DiscoveryFile()); !os.IsNotExist(err) {
+		t.Errorf("Expected the discovery file removed on Stop, got %v", err)
+	}
+}
+
 func TestInitWithBase(t *testing.T) {
 	tmpDir := setupTempDir(t)
 	basePipe := filepath.Join(tmpDir, "custom.pipe")
@@ -174,12 +277,13 @@ func TestAddHandler(t *testing.T) {
 		mu.Unlock()
 	}
 
-	AddHandler(handler)
+	s := New()
+	s.AddHandler(handler)
 
 	// Verify handler was stored
-	// mu.RLock()
-	storedHandler := guiToggleCallback
-	// mu2.RUnlock()
+	s.mu.RLock()
+	storedHandler := s.guiToggleCallback
+	s.mu.RUnlock()
 
 	if storedHandler == nil {
 		t.Fatal("Expected handler to be stored")
@@ -204,7 +308,7 @@ func TestSetTimerManager(t *testing.T) {
 
 	SetTimerManager(tm)
 
-	retrieved := getTimerManager()
+	retrieved := defaultServer.getTimerManager()
 	if retrieved != tm {
 		t.Error("Expected retrieved TimerManager to match the one set")
 	}
@@ -214,7 +318,7 @@ func TestGetTimerManager_Nil(t *testing.T) {
 	// Reset global state
 	SetTimerManager(nil)
 
-	retrieved := getTimerManager()
+	retrieved := defaultServer.getTimerManager()
 	if retrieved != nil {
 		t.Error("Expected getTimerManager to return nil when none set")
 	}
@@ -226,17 +330,17 @@ func TestTimerWrappers_WithManager(t *testing.T) {
 
 	// Test all wrapper functions
 	TimerStart()
-	if tm.Timer.Timer == nil {
+	if tm.Timer.StartedAt().IsZero() {
 		t.Error("Expected timer to be started after TimerStart")
 	}
 
 	TimerInc()
-	if tm.Timer.Duration != 100*time.Millisecond+5*time.Second {
+	if tm.Timer.Duration() != 100*time.Millisecond+5*time.Second {
 		t.Error("Expected timer duration to be increased after TimerInc")
 	}
 
 	TimerDec()
-	if tm.Timer.Duration != 100*time.Millisecond {
+	if tm.Timer.Duration() != 100*time.Millisecond {
 		t.Error("Expected timer duration to be decreased after TimerDec")
 	}
 
@@ -275,6 +379,24 @@ func TestTimerWrappers_WithoutManager(t *testing.T) {
 	}
 }
 
+func TestDefineCommands(t *testing.T) {
+	tm := focotimer.NewTimerManager(10 * time.Second)
+	SetTimerManager(tm)
+	DefineCommands(map[string][]string{
+		"coffee": {"set 5m", "label coffee"},
+	})
+	defer DefineCommands(nil)
+
+	defaultServer.dispatch("coffee")
+
+	if tm.Timer.Duration() != 5*time.Minute {
+		t.Errorf("Expected duration 5m after custom command, got %v", tm.Timer.Duration())
+	}
+	if tm.Label() != "coffee" {
+		t.Errorf("Expected label coffee after custom command, got %q", tm.Label())
+	}
+}
+
 // ================= Output Tests =================
 
 func TestPolybarActionButton(t *testing.T) {
@@ -301,25 +423,74 @@ func TestPolybarActionButton_WithNewline(t *testing.T) {
 	}
 }
 
-func TestPipeCommand(t *testing.T) {
-	fifoPipePath = "/tmp/test.pipe"
-	cmd := "start"
+func TestClickAction(t *testing.T) {
+	tests := []struct {
+		sender, path, cmd string
+		expected          string
+	}{
+		{"focotimer", "/tmp/test.pipe", "start", `'focotimer' send -pipe '/tmp/test.pipe' 'start'`},
+		{"focotimer", "/tmp/test.pipe", "label it's done", `'focotimer' send -pipe '/tmp/test.pipe' 'label it'\''s done'`},
+		{"focotimer", "/tmp/test.pipe", "label {x}: 50%", `'focotimer' send -pipe '/tmp/test.pipe' 'label {x%7D%3A 50%25'`},
+		{"/opt/foco:1/focotimer", "/run/a:b.pipe", "until 15:30", `'/opt/foco\:1/focotimer' send -pipe '/run/a%3Ab.pipe' 'until 15%3A30'`},
+	}
+	for _, tt := range tests {
+		s := New(WithSender(tt.sender), WithPath(tt.path))
+		if result := s.clickAction(tt.cmd); result != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, result)
+		}
+	}
+}
 
-	result := pipeCommand(cmd)
-	expected := "echo 'start' > /tmp/test.pipe"
+func TestEncodeArg(t *testing.T) {
+	for _, arg := range []string{"until 15:30", "label {x}", "100%", "%3A", "%%7D:"} {
+		if got := DecodeArg(EncodeArg(arg)); got != arg {
+			t.Errorf("Expected %q to survive encoding, got %q", arg, got)
+		}
+		if enc := EncodeArg(arg); strings.ContainsAny(enc, ":}") {
+			t.Errorf("Expected no polybar syntax in %q", enc)
+		}
+	}
+	if got := DecodeArg("label 50%"); got != "label 50%" {
+		t.Errorf("Expected a lone %% to be kept, got %q", got)
+	}
+}
 
-	if result != expected {
-		t.Errorf("Expected %q, got %q", expected, result)
+func TestSend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cmd.pipe")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatalf("Mkfifo failed: %v", err)
+	}
+
+	if err := Send(path, "toggle"); err == nil || !strings.Contains(err.Error(), "not reading") {
+		t.Errorf("Expected an error without a reader, got %v", err)
+	}
+	if err := Send(t.TempDir(), "toggle"); err == nil {
+		t.Error("Expected an error for a path that is not a FIFO")
+	}
+
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Failed to open FIFO: %v", err)
+	}
+	defer r.Close()
+	if err := Send(path, "until 15:30 "); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, _ := r.Read(buf)
+	if got := string(buf[:n]); got != "until 15:30\n" {
+		t.Errorf("Expected one command line, got %q", got)
 	}
 }
 
 func TestOutput(t *testing.T) {
 	// Set up a timer manager with known values
 	tm := focotimer.NewTimerManager(300 * time.Second)
-	SetTimerManager(tm)
-	fifoPipePath = "/tmp/test.pipe"
+	s := New()
+	s.SetTimerManager(tm)
+	s.fifoPipePath = "/tmp/test.pipe"
 
-	result := output()
+	result := s.output()
 
 	// Should contain the expected button structure
 	if !strings.Contains(result, "[-]") {
@@ -336,6 +507,459 @@ func TestOutput(t *testing.T) {
 	}
 }
 
+func TestOutput_Clicks(t *testing.T) {
+	s := New(WithPath("/tmp/test.pipe"), WithSender("focotimer"))
+	s.SetTimerManager(focotimer.NewTimerManager(300 * time.Second))
+
+	result := s.output()
+	send := "'focotimer' send -pipe '/tmp/test.pipe' "
+	want := "%{A1:" + send + "'gui':}%{A2:" + send + "'toggle':}" +
+		"%{A3:" + send + "'reset':}%{A4:" + send + "'inc':}" +
+		"%{A5:" + send + "'dec':} 5m0s : 5m0s %{A}%{A}%{A}%{A}%{A}"
+	if !strings.Contains(result, want) {
+		t.Errorf("Expected the timer to carry one action per button, got %q", result)
+	}
+
+	s.SetClicks(map[MouseButton]string{RightClick: "until 15:30"})
+	result = s.output()
+	want = "%{A3:" + send + "'until 15%3A30':} 5m0s : 5m0s %{A}"
+	if !strings.Contains(result, want) {
+		t.Errorf("Expected only the right click, with its colon encoded, got %q", result)
+	}
+}
+
+func TestOutput_SegmentClicks(t *testing.T) {
+	s := New(WithPath("/tmp/test.pipe"), WithSender("focotimer"))
+	s.SetTimerManager(focotimer.NewTimerManager(300 * time.Second))
+	send := "'focotimer' send -pipe '/tmp/test.pipe' "
+
+	result := s.output()
+	if !strings.HasPrefix(result, "%{A:"+send+"'dec':} [-] %{A}") || !strings.HasSuffix(result, "%{A:"+send+"'inc':} [+] %{A}") {
+		t.Errorf("Expected left-click buttons by default, got %q", result)
+	}
+
+	s.SetSegmentClicks(SegmentInc, map[MouseButton]string{LeftClick: "inc", RightClick: "set 50m"})
+	s.SetSegmentClicks(SegmentDec, map[MouseButton]string{ScrollDown: "dec"})
+	result = s.output()
+	if want := "%{A5:" + send + "'dec':} [-] %{A}"; !strings.HasPrefix(result, want) {
+		t.Errorf("Expected %q to start the output, got %q", want, result)
+	}
+	if want := "%{A1:" + send + "'inc':}%{A3:" + send + "'set 50m':} [+] %{A}%{A}"; !strings.HasSuffix(result, want) {
+		t.Errorf("Expected %q to end the output, got %q", want, result)
+	}
+}
+
+func TestOutput_Fit(t *testing.T) {
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	tm.SetLabel("writing")
+	s := New(WithPath("/tmp/test.pipe"))
+	s.SetTimerManager(tm)
+
+	tests := []struct {
+		fit     Fit
+		want    string
+		buttons bool
+	}{
+		{Fit{}, " writing 25m0s : 25m0s ", true},
+		{Fit{MaxWidth: 33}, " writing 25m0s : 25m0s ", true},
+		{Fit{MaxWidth: 32}, " writing 25m0s ", true},
+		{Fit{Variant: VariantMedium}, " writing 25m0s ", true},
+		{Fit{MaxWidth: 24}, " writing 25m0s ", false},
+		{Fit{MaxWidth: 10}, " ⏱ 25m ", false},
+		{Fit{MaxWidth: 3, Icon: "T"}, " T 25m ", false},
+		{Fit{Variant: VariantShort}, " ⏱ 25m ", false},
+	}
+	for _, tt := range tests {
+		s.SetFit(tt.fit)
+		result := s.output()
+		if !strings.Contains(result, ":}"+tt.want+"%{A}") {
+			t.Errorf("%+v: expected %q, got %q", tt.fit, tt.want, result)
+		}
+		if strings.Contains(result, "[+]") != tt.buttons {
+			t.Errorf("%+v: expected buttons %v, got %q", tt.fit, tt.buttons, result)
+		}
+	}
+
+	s.SetFormat(FormatPlain)
+	s.SetFit(Fit{MaxWidth: 14})
+	if result := s.output(); result != "writing 25m0s" {
+		t.Errorf("Expected plain output to drop only the total, got %q", result)
+	}
+}
+
+func TestParseSegment(t *testing.T) {
+	if seg, err := ParseSegment("dec"); err != nil || seg != SegmentDec {
+		t.Errorf("Expected SegmentDec, got %v, %v", seg, err)
+	}
+	if _, err := ParseSegment("label"); err == nil {
+		t.Error("Expected error for unknown segment")
+	}
+}
+
+func TestParseMouseButton(t *testing.T) {
+	if b, err := ParseMouseButton("scroll_down"); err != nil || b != ScrollDown {
+		t.Errorf("Expected ScrollDown, got %v, %v", b, err)
+	}
+	if _, err := ParseMouseButton("thumb"); err == nil {
+		t.Error("Expected error for unknown button")
+	}
+}
+
+func TestOutput_Formats(t *testing.T) {
+	tm := focotimer.NewTimerManager(300 * time.Second)
+	colors := Colors{Running: "#00FF00", Paused: "#0000FF", Final: "#FF0000"}
+
+	s := New(WithPath("/tmp/test.pipe"), WithFormat(FormatPlain))
+	s.SetTimerManager(tm)
+	if result := s.output(); result != "5m0s : 5m0s" {
+		t.Errorf("Expected bare text without colors, got %q", result)
+	}
+
+	s.SetColors(&colors)
+	if result := s.output(); result != "5m0s : 5m0s" {
+		t.Errorf("Expected an idle timer to stay unstyled, got %q", result)
+	}
+
+	tm.Start()
+	if result := s.output(); !strings.HasPrefix(result, "\x1b[38;2;0;255;0m") || !strings.HasSuffix(result, "\x1b[0m") {
+		t.Errorf("Expected the running color as an ANSI escape, got %q", result)
+	}
+
+	s.SetFormat(FormatTmux)
+	tm.Pause()
+	if result := s.output(); !strings.HasPrefix(result, "#[fg=#0000FF]") || !strings.HasSuffix(result, "#[default]") {
+		t.Errorf("Expected the paused color as a tmux style, got %q", result)
+	}
+
+	s.SetFormat(FormatPolybar)
+	if result := s.output(); !strings.Contains(result, "%{F#0000FF}%{A1:") || !strings.Contains(result, "%{A}%{F-}") {
+		t.Errorf("Expected polybar color tags around the clickable timer, got %q", result)
+	}
+}
+
+func TestOutput_I3(t *testing.T) {
+	tm := focotimer.NewTimerManager(300 * time.Second)
+	tm.SetLabel("writing")
+	s := New(WithPath("/tmp/test.pipe"), WithFormat(FormatI3Blocks))
+	s.SetTimerManager(tm)
+	s.SetColors(&DefaultColors)
+	if result := s.output(); result != "writing 5m0s : 5m0s" {
+		t.Errorf("Expected bare text for i3blocks, got %q", result)
+	}
+
+	s.SetFormat(FormatI3Status)
+	want := `{"text":"writing 5m0s : 5m0s","short_text":"⏱ 5m","state":"Idle"}`
+	if result := s.output(); result != want {
+		t.Errorf("Expected %s, got %s", want, result)
+	}
+}
+
+func TestI3Block(t *testing.T) {
+	tests := []struct {
+		state  focotimer.State
+		block  string
+		urgent bool
+		json   string
+	}{
+		{
+			focotimer.State{Status: focotimer.StatusIdle, Duration: 25 * time.Minute, Remaining: 25 * time.Minute},
+			"25m0s : 25m0s\n⏱ 25m\n\n", false,
+			`{"text":"25m0s : 25m0s","short_text":"⏱ 25m","state":"Idle"}`,
+		},
+		{
+			focotimer.State{Status: focotimer.StatusRunning, Duration: 25 * time.Minute, Remaining: 10 * time.Minute, Label: "writing"},
+			"writing 25m0s : 10m0s\n⏱ 10m\n" + DefaultColors.Running + "\n", false,
+			`{"text":"writing 25m0s : 10m0s","short_text":"⏱ 10m","state":"Info"}`,
+		},
+		{
+			focotimer.State{Status: focotimer.StatusRunning, Duration: 25 * time.Minute, Remaining: time.Minute, Label: "writing"},
+			"writing 25m0s : 1m0s\n⏱ 1m\n" + DefaultColors.Final + "\n", true,
+			`{"text":"writing 25m0s : 1m0s","short_text":"⏱ 1m","state":"Critical"}`,
+		},
+		{
+			focotimer.State{Status: focotimer.StatusPaused, Duration: 25 * time.Minute, Remaining: time.Minute},
+			"25m0s : 1m0s\n⏱ 1m\n" + DefaultColors.Paused + "\n", false,
+			`{"text":"25m0s : 1m0s","short_text":"⏱ 1m","state":"Warning"}`,
+		},
+		{
+			focotimer.State{Status: focotimer.StatusCompleted, Duration: 25 * time.Minute},
+			"25m0s : 0s\n⏱ 0m\n\n", false,
+			`{"text":"25m0s : 0s","short_text":"⏱ 0m","state":"Good"}`,
+		},
+	}
+	for _, tt := range tests {
+		block, urgent := I3Block(tt.state, Activity(tt.state), &DefaultColors, Fit{})
+		if block != tt.block || urgent != tt.urgent {
+			t.Errorf("%s: expected %q, %v, got %q, %v", tt.state.Status, tt.block, tt.urgent, block, urgent)
+		}
+		if got := I3StatusBlock(tt.state, Activity(tt.state), Fit{}); got != tt.json {
+			t.Errorf("%s: expected %s, got %s", tt.state.Status, tt.json, got)
+		}
+	}
+}
+
+func TestOutput_Template(t *testing.T) {
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	tm.SetLabel("writing")
+	s := New(WithPath("/tmp/test.pipe"), WithFormat(FormatPlain), WithSender("focotimer"))
+	s.SetTimerManager(tm)
+
+	if err := s.SetTemplate("{{.Remaining}} / {{.Duration}} {{.Icon}} {{.Label}}"); err != nil {
+		t.Fatalf("SetTemplate failed: %v", err)
+	}
+	if result := s.output(); result != "25m0s / 25m0s ⏱ writing" {
+		t.Errorf("Expected the template output, got %q", result)
+	}
+
+	if err := s.SetTemplate(`{{button "<" "dec"}}{{clock .Remaining}} ({{minutes .Elapsed}}m){{button ">" "inc"}}`); err != nil {
+		t.Fatalf("SetTemplate failed: %v", err)
+	}
+	if result := s.output(); result != "25:00 (0m)" {
+		t.Errorf("Expected buttons left out of plain output, got %q", result)
+	}
+	s.SetFormat(FormatPolybar)
+	result := s.output()
+	want := polybarActionButton("<", s.clickAction("dec")) + "25:00 (0m)" + polybarActionButton(">", s.clickAction("inc"))
+	if !strings.Contains(result, want) {
+		t.Errorf("Expected the buttons where the template puts them, got %q", result)
+	}
+	if strings.Contains(result, "[+]") {
+		t.Errorf("Expected no default buttons, got %q", result)
+	}
+
+	if err := s.SetTemplate("{{.Remaining"); err == nil {
+		t.Error("Expected an error for a malformed template")
+	}
+	if err := s.SetTemplate(""); err != nil {
+		t.Fatalf("SetTemplate failed: %v", err)
+	}
+	if result := s.output(); !strings.Contains(result, "[+]") {
+		t.Errorf("Expected the default output back, got %q", result)
+	}
+}
+
+func TestRootName(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "names")
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	tm.SetLabel("writing")
+	r := NewRootName(tm, RootNameOptions{
+		Prefix:  "[",
+		Suffix:  "] | 12:00",
+		Command: []string{"sh", "-c", `echo "$1" >> "$0"`, out},
+		Fit:     Fit{Variant: VariantMedium},
+	})
+
+	if got := r.Text(); got != "[writing 25m0s] | 12:00" {
+		t.Errorf("Expected the timer between prefix and suffix, got %q", got)
+	}
+	for range 2 {
+		if err := r.Write(); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	tm.SetLabel("reading")
+	if err := r.Write(); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != "[writing 25m0s] | 12:00" || lines[1] != "[reading 25m0s] | 12:00" {
+		t.Errorf("Expected the command to run once per change, got %q", lines)
+	}
+
+	r = NewRootName(tm, RootNameOptions{Command: []string{"false"}})
+	if err := r.Write(); err == nil {
+		t.Error("Expected an error from a failing command")
+	}
+}
+
+func TestLineFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conky", "focotimer.txt")
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	tm.SetLabel("writing")
+	f := NewLineFile(tm, path, Fit{Variant: VariantMedium}, time.Second)
+
+	if err := f.Write(); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "writing 25m0s\n" {
+		t.Errorf("Expected the status line, got %q", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected no temporary file left, got %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(stop)
+	}()
+	tm.SetLabel("reading")
+	tm.Start()
+	waitFor(t, "the started session in the file", func() bool {
+		data, _ := os.ReadFile(path)
+		return strings.HasPrefix(string(data), "reading ")
+	})
+	close(stop)
+	<-done
+}
+
+func TestColors_For(t *testing.T) {
+	tests := []struct {
+		state focotimer.State
+		want  string
+	}{
+		{focotimer.State{Status: focotimer.StatusIdle, Remaining: 30 * time.Second}, ""},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 5 * time.Minute}, DefaultColors.Running},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: time.Minute}, DefaultColors.Final},
+		{focotimer.State{Status: focotimer.StatusPaused, Remaining: 30 * time.Second}, DefaultColors.Paused},
+		{focotimer.State{Status: focotimer.StatusCompleted}, ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultColors.For(tt.state); got != tt.want {
+			t.Errorf("For(%s, %v) = %q, expected %q", tt.state.Status, tt.state.Remaining, got, tt.want)
+		}
+	}
+
+	breaks := []struct {
+		state focotimer.State
+		want  string
+	}{
+		{focotimer.State{Status: focotimer.StatusRunning, Label: "break", Break: true, Remaining: 5 * time.Minute}, DefaultColors.Break},
+		{focotimer.State{Status: focotimer.StatusRunning, Label: "break", Break: true, Remaining: time.Minute}, DefaultColors.Final},
+		{focotimer.State{Status: focotimer.StatusPaused, Label: "break", Break: true, Remaining: 5 * time.Minute}, DefaultColors.Paused},
+		{focotimer.State{Status: focotimer.StatusRunning, Label: "fix-line-breaks", Remaining: 5 * time.Minute}, DefaultColors.Running},
+	}
+	for _, tt := range breaks {
+		if got := DefaultColors.For(tt.state); got != tt.want {
+			t.Errorf("For(%s break, %v) = %q, expected %q", tt.state.Status, tt.state.Remaining, got, tt.want)
+		}
+	}
+
+	custom := Colors{Running: "#00FF00", Final: "#FF0000", Completed: "#0000FF", Threshold: 5 * time.Minute}
+	if got := custom.For(focotimer.State{Status: focotimer.StatusRunning, Remaining: 4 * time.Minute}); got != "#FF0000" {
+		t.Errorf("Expected the final color under the threshold, got %q", got)
+	}
+	if got := custom.For(focotimer.State{Status: focotimer.StatusRunning, Remaining: 6 * time.Minute}); got != "#00FF00" {
+		t.Errorf("Expected the running color above the threshold, got %q", got)
+	}
+	if got := custom.For(focotimer.State{Status: focotimer.StatusCompleted}); got != "#0000FF" {
+		t.Errorf("Expected the completed color, got %q", got)
+	}
+
+	noFinal := Colors{Running: "#00FF00"}
+	if got := noFinal.For(focotimer.State{Status: focotimer.StatusRunning, Remaining: time.Second}); got != "#00FF00" {
+		t.Errorf("Expected the running color without a final color, got %q", got)
+	}
+}
+
+func TestOutput_Flash(t *testing.T) {
+	tm := focotimer.NewTimerManager(10 * time.Millisecond)
+	tm.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for tm.State().Status != focotimer.StatusCompleted && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	colors := Colors{Completed: "#00FF00", Flash: true}
+	s := New(WithPath("/tmp/test.pipe"), WithColors(colors))
+	s.SetTimerManager(tm)
+
+	first, second := s.output(), s.output()
+	if strings.Contains(first, "%{R}") == strings.Contains(second, "%{R}") {
+		t.Errorf("Expected polybar output to alternate reverse video, got %q then %q", first, second)
+	}
+	if !strings.Contains(first, "%{F#00FF00}") {
+		t.Errorf("Expected the completed color, got %q", first)
+	}
+
+	s.SetFormat(FormatPlain)
+	if result := s.output(); !strings.HasPrefix(result, "\x1b[5m\x1b[38;2;0;255;0m") || !strings.HasSuffix(result, "\x1b[0m\x1b[25m") {
+		t.Errorf("Expected a blinking ANSI escape, got %q", result)
+	}
+	s.SetFormat(FormatTmux)
+	if result := s.output(); !strings.HasPrefix(result, "#[blink]#[fg=#00FF00]") || !strings.HasSuffix(result, "#[noblink]") {
+		t.Errorf("Expected a blinking tmux style, got %q", result)
+	}
+
+	s.SetColors(&Colors{Completed: "#00FF00"})
+	if result := s.output(); strings.Contains(result, "blink") {
+		t.Errorf("Expected no blinking without Flash, got %q", result)
+	}
+}
+
+func TestOutput_Icons(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Minute)
+	tm.SetLabel("writing")
+	s := New(WithPath("/tmp/test.pipe"), WithFormat(FormatPlain))
+	s.SetTimerManager(tm)
+	s.SetIcons(&ASCIIIcons)
+
+	if result := s.output(); result != "* writing 5m0s : 5m0s" {
+		t.Errorf("Expected the work icon, got %q", result)
+	}
+	tm.SetLabel("fix-line-breaks")
+	if result := s.output(); result != "* fix-line-breaks 5m0s : 5m0s" {
+		t.Errorf("Expected the work icon for a label naming breaks, got %q", result)
+	}
+	tm.SetBreak("break")
+	if result := s.output(); result != "~ break 5m0s : 5m0s" {
+		t.Errorf("Expected the break icon, got %q", result)
+	}
+	tm.Start()
+	tm.Pause()
+	s.SetFit(Fit{Variant: VariantShort})
+	if result := s.output(); result != "|| 5m" {
+		t.Errorf("Expected the paused icon in the short output, got %q", result)
+	}
+
+	s.SetIcons(&NerdIcons)
+	s.SetFit(Fit{})
+	s.SetFormat(FormatPolybar)
+	result := s.output()
+	if !strings.Contains(result, " "+NerdIcons.Dec+" %{A}") || !strings.Contains(result, " "+NerdIcons.Inc+" %{A}") {
+		t.Errorf("Expected icons on the buttons, got %q", result)
+	}
+	if strings.Contains(result, "[+]") {
+		t.Errorf("Expected no text buttons, got %q", result)
+	}
+
+	if _, err := ParseIcons("emoji"); err == nil {
+		t.Error("Expected error for unknown icons")
+	}
+}
+
+func TestParseFormatAndColor(t *testing.T) {
+	if f, err := ParseFormat("tmux"); err != nil || f != FormatTmux {
+		t.Errorf("Expected FormatTmux, got %v, %v", f, err)
+	}
+	if _, err := ParseFormat("lemonbar"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if r, g, b, err := ParseColor("#FFA12C"); err != nil || r != 0xFF || g != 0xA1 || b != 0x2C {
+		t.Errorf("Expected FF A1 2C, got %x %x %x, %v", r, g, b, err)
+	}
+	for _, c := range []string{"FFA12C", "#FFF", "#GGGGGG", "#FFA12C00"} {
+		if _, _, _, err := ParseColor(c); err == nil {
+			t.Errorf("Expected error for color %q", c)
+		}
+	}
+	if err := (Colors{Running: "orange"}).Validate(); err == nil {
+		t.Error("Expected Validate to reject a named color")
+	}
+	if result := colorize(FormatPlain, "orange", "5m0s"); result != "5m0s" {
+		t.Errorf("Expected a malformed color to be left out, got %q", result)
+	}
+}
+
 func TestTruncToSecond(t *testing.T) {
 	tests := []struct {
 		input    time.Duration
@@ -359,18 +983,18 @@ func TestTruncToSecond(t *testing.T) {
 
 func TestTimerSnapshot(t *testing.T) {
 	// Test with nil manager
-	SetTimerManager(nil)
-	dur, rem := timerSnapshot()
+	s := New()
+	dur, rem := s.timerSnapshot()
 	if dur != 0 || rem != 0 {
 		t.Errorf("Expected (0, 0) with nil manager, got (%v, %v)", dur, rem)
 	}
 
 	// Test with actual manager
 	tm := focotimer.NewTimerManager(200 * time.Second)
-	SetTimerManager(tm)
+	s.SetTimerManager(tm)
 	tm.Start()
 
-	dur, rem = timerSnapshot()
+	dur, rem = s.timerSnapshot()
 	if dur != 200*time.Second {
 		t.Errorf("Expected duration 200s, got %v", dur)
 	}
@@ -383,149 +1007,274 @@ func TestTimerSnapshot(t *testing.T) {
 
 func TestFifoPath(t *testing.T) {
 	expectedPath := "/tmp/test.pipe"
-	fifoPipePath = expectedPath
+	s := New()
+	s.fifoPipePath = expectedPath
 
-	result := FifoPath()
+	result := s.FifoPath()
 	if result != expectedPath {
 		t.Errorf("Expected %q, got %q", expectedPath, result)
 	}
 }
 
 func TestHandleCmds_Commands(t *testing.T) {
-	tmpDir := setupTempDir(t)
-	basePipe := filepath.Join(tmpDir, "test.pipe")
-
-	path, err := InitWithBase(basePipe)
-	if err != nil {
-		t.Fatalf("Failed to initialize FIFO: %v", err)
-	}
-	defer os.Remove(path)
+	r, w := io.Pipe()
+	s := New(WithCommands(r), WithClock(newFakeClock()))
+	defer s.Stop()
 
-	// Set up timer manager
 	tm := focotimer.NewTimerManager(100 * time.Millisecond)
-	SetTimerManager(tm)
+	s.SetTimerManager(tm)
 
-	// Set up handler
 	var guiCalled bool
-	var guiMu sync.Mutex
-	AddHandler(func() {
-		guiMu.Lock()
-		guiCalled = true
-		guiMu.Unlock()
+	s.AddHandler(func() { guiCalled = true })
+	var opened string
+	s.Handle("open", func(args []string) error {
+		opened = strings.Join(args, " ")
+		return nil
 	})
 
-	// Start command handler in background
-	go func() {
-		wg.Add(1)
-		defer wg.Done()
-		handle_cmds()
-	}()
-
-	// Give handler time to start
-	time.Sleep(50 * time.Millisecond)
+	s.Start()
 
 	tests := []struct {
 		command        string
 		expectedEffect func() bool
 		description    string
 	}{
-		{
-			command: "start",
-			expectedEffect: func() bool {
-				return tm.Timer.Timer != nil && !tm.Timer.StartedAt.IsZero()
-			},
-			description: "timer should be started",
-		},
-		{
-			command: "gui",
-			expectedEffect: func() bool {
-				guiMu.Lock()
-				called := guiCalled
-				guiMu.Unlock()
-				return called
-			},
-			description: "GUI callback should be called",
-		},
-		{
-			command: "inc",
-			expectedEffect: func() bool {
-				return tm.Timer.Duration > 100*time.Millisecond
-			},
-			description: "timer duration should be increased",
-		},
-		{
-			command: "stop",
-			expectedEffect: func() bool {
-				// Reset timer first to have a clean state
-				tm.Reset()
-				tm.Start()
-				time.Sleep(10 * time.Millisecond)
-
-				// Send stop command
-				go writeToFifo(t, path, "stop")
-				time.Sleep(50 * time.Millisecond)
-
-				// Timer should not be complete after stop
-				return !tm.Timer.IsComplete
-			},
-			description: "timer should be stopped",
-		},
+		{"start", func() bool { return !tm.Timer.StartedAt().IsZero() }, "timer should be started"},
+		{"gui", func() bool { return guiCalled }, "GUI callback should be called"},
+		{"open focotimer://stats", func() bool { return opened == "focotimer://stats" }, "frontend command should get its arguments"},
+		{"inc", func() bool { return tm.Timer.Duration() > 100*time.Millisecond }, "timer duration should be increased"},
+		{"stop", func() bool { return tm.Timer.StartedAt().IsZero() && !tm.Timer.IsComplete() }, "timer should be stopped"},
+		{"unknown_command", func() bool { return true }, "unknown commands should be ignored"},
 	}
 
 	for _, test := range tests {
-		t.Run(test.command, func(t *testing.T) {
-			if test.command == "stop" {
-				// Special handling for stop command test
-				if !test.expectedEffect() {
-					t.Errorf("Command %s failed: %s", test.command, test.description)
-				}
-				return
-			}
+		send(t, w, test.command)
+		if !test.expectedEffect() {
+			t.Errorf("Command %s failed: %s", test.command, test.description)
+		}
+	}
+}
 
-			// Send command
-			go writeToFifo(t, path, test.command)
+func TestHandleCmds_EOF(t *testing.T) {
+	tm := focotimer.NewTimerManager(time.Minute)
+	s := New(WithCommands(strings.NewReader("set 5m\n\nlabel reading\n")))
+	s.SetTimerManager(tm)
 
-			// Wait for command to be processed
-			time.Sleep(100 * time.Millisecond)
+	s.Start()
+	s.Stop() // returns once every command was read
 
-			if !test.expectedEffect() {
-				t.Errorf("Command %s failed: %s", test.command, test.description)
-			}
-		})
+	if tm.Duration() != 5*time.Minute || tm.Label() != "reading" {
+		t.Errorf("Expected 5m labelled reading, got %v %q", tm.Duration(), tm.Label())
 	}
+}
 
-	// Signal shutdown
-	close(stopping)
-	wg.Wait()
+func TestHandleCmds_NoTimerManager(t *testing.T) {
+	r, w := io.Pipe()
+	s := New(WithCommands(r))
+	s.Start()
+	send(t, w, "start") // must not panic
+	s.Stop()
 }
 
-func TestHandleCmds_UnknownCommand(t *testing.T) {
-	tmpDir := setupTempDir(t)
-	basePipe := filepath.Join(tmpDir, "test.pipe")
+func TestHandleCmds_Fifo(t *testing.T) {
+	path := filepath.Join(setupTempDir(t), "cmd.fifo")
+	s := New(WithPath(path))
 
-	path, err := InitWithBase(basePipe)
+	tm := focotimer.NewTimerManager(time.Minute)
+	s.SetTimerManager(tm)
+	s.Start()
+
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("Expected Start to create the FIFO at %s: %v", path, err)
+	}
+
+	writeToFifo(t, path, "start\n")
+	waitFor(t, "the start command", func() bool { return tm.Timer.IsRunning() })
+
+	s.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the FIFO to be removed on Stop")
+	}
+}
+
+// memTransport queues client connections in memory and records replies.
+type memTransport struct {
+	path    string
+	conns   chan io.ReadCloser
+	mu      sync.Mutex
+	replies map[string]*strings.Builder
+	removed []string
+}
+
+func (m *memTransport) Create(base string) (string, error) { return base, nil }
+func (m *memTransport) Ensure(path string) error           { return nil }
+
+func (m *memTransport) Accept(path string) (io.ReadCloser, error) { return <-m.conns, nil }
+
+func (m *memTransport) Dial(path string) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if path == m.path { // waking the command loop
+		select {
+		case m.conns <- io.NopCloser(strings.NewReader("")):
+		default:
+		}
+		return nopWriteCloser{io.Discard}, nil
+	}
+	b := m.replies[path]
+	if b == nil {
+		return nil, os.ErrNotExist
+	}
+	return nopWriteCloser{b}, nil
+}
+
+func (m *memTransport) Remove(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removed = append(m.removed, path)
+	return nil
+}
+
+func (m *memTransport) reply(path string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.replies[path].String()
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestHandleCmds_Transport(t *testing.T) {
+	// reply targets are checked to be FIFOs whatever the transport
+	answer := filepath.Join(setupTempDir(t), "answer")
+	if err := ensureFifo(answer); err != nil {
+		t.Fatalf("Failed to create FIFO: %v", err)
+	}
+	mt := &memTransport{
+		path:    "cmd",
+		conns:   make(chan io.ReadCloser, 1),
+		replies: map[string]*strings.Builder{answer: {}},
+	}
+	s := New(WithPath("cmd"), WithTransport(mt), WithClock(newFakeClock()))
+	tm := focotimer.NewTimerManager(time.Minute)
+	s.SetTimerManager(tm)
+	s.Start()
+
+	mt.conns <- io.NopCloser(strings.NewReader("start > " + answer + "\n"))
+	waitFor(t, "the start command", func() bool { return tm.Timer.IsRunning() })
+	waitFor(t, "the reply", func() bool { return strings.Contains(mt.reply(answer), `"running"`) })
+
+	if err := s.reply("missing", nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a reply to a missing endpoint to fail, got %v", err)
+	}
+
+	s.Stop()
+	if len(mt.removed) != 1 || mt.removed[0] != "cmd" {
+		t.Errorf("Expected Stop to remove the endpoint, got %v", mt.removed)
+	}
+}
+
+func TestHandleCmds_Reply(t *testing.T) {
+	replyPath := filepath.Join(setupTempDir(t), "reply.fifo")
+	if err := syscall.Mkfifo(replyPath, 0600); err != nil {
+		t.Fatalf("Failed to create reply FIFO: %v", err)
+	}
+	// open the reading end first, like a script that waits for the answer
+	reply, err := os.OpenFile(replyPath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
 	if err != nil {
-		t.Fatalf("Failed to initialize FIFO: %v", err)
+		t.Fatalf("Failed to open reply FIFO: %v", err)
 	}
-	defer os.Remove(path)
+	defer reply.Close()
+	// a writer of our own keeps reads blocking between replies
+	hold, err := os.OpenFile(replyPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to hold reply FIFO open: %v", err)
+	}
+	defer hold.Close()
+	replies := bufio.NewReader(reply)
 
-	// Start command handler in background
-	go func() {
-		wg.Add(1)
-		defer wg.Done()
-		handle_cmds()
-	}()
+	r, w := io.Pipe()
+	s := New(WithCommands(r), WithClock(newFakeClock()))
+	defer s.Stop()
+	tm := focotimer.NewTimerManager(time.Minute)
+	s.SetTimerManager(tm)
+	s.Start()
+
+	tests := []struct {
+		command string
+		check   func(v map[string]any) bool
+	}{
+		{"label writing > " + replyPath, func(v map[string]any) bool { return v["status"] == "idle" && v["label"] == "writing" }},
+		{"start >" + replyPath, func(v map[string]any) bool { return v["status"] == "running" }},
+		{"status > " + replyPath, func(v map[string]any) bool { return v["status"] == "running" && v["remaining_ms"] != nil }},
+		{"launch > " + replyPath, func(v map[string]any) bool { return v["error"] != nil }},
+	}
+	for _, tt := range tests {
+		send(t, w, tt.command)
+		line, err := replies.ReadString('\n')
+		if err != nil {
+			t.Fatalf("%s: failed to read reply: %v", tt.command, err)
+		}
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("%s: failed to decode reply %q: %v", tt.command, line, err)
+		}
+		if !tt.check(v) {
+			t.Errorf("%s: unexpected reply %v", tt.command, v)
+		}
+	}
 
-	// Give handler time to start
-	time.Sleep(50 * time.Millisecond)
+	regular := filepath.Join(setupTempDir(t), "reply.txt")
+	if err := os.WriteFile(regular, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := s.reply(regular, nil); err == nil {
+		t.Error("Expected replies to a regular file to be refused")
+	}
+}
 
-	// Send unknown command - should not panic
-	go writeToFifo(t, path, "unknown_command")
-	time.Sleep(100 * time.Millisecond)
+func TestSplitReply(t *testing.T) {
+	dir := setupTempDir(t)
+	fifo := filepath.Join(dir, "r.fifo")
+	if err := ensureFifo(fifo); err != nil {
+		t.Fatalf("Failed to create FIFO: %v", err)
+	}
+	regular := filepath.Join(dir, "notes")
+	if err := os.WriteFile(regular, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
 
-	// Signal shutdown
-	close(stopping)
-	wg.Wait()
+	tests := []struct {
+		line, cmd, path string
+	}{
+		{"status", "status", ""},
+		{"status > " + fifo, "status", fifo},
+		{"set 25m>" + fifo, "set 25m", fifo},
+		{"label a > b > " + fifo, "label a > b", fifo},
+		{"label >", "label >", ""},
+		{"label a>b", "label a>b", ""},
+		{"label input > output", "label input > output", ""},
+		{"label x > " + filepath.Join(dir, "missing"), "label x > " + filepath.Join(dir, "missing"), ""},
+		{"label x > " + regular, "label x > " + regular, ""},
+	}
+	for _, tt := range tests {
+		cmd, path := splitReply(tt.line)
+		if cmd != tt.cmd || path != tt.path {
+			t.Errorf("splitReply(%q) = %q, %q, expected %q, %q", tt.line, cmd, path, tt.cmd, tt.path)
+		}
+	}
+}
+
+func TestEnsureFifo_NotAFifo(t *testing.T) {
+	path := filepath.Join(setupTempDir(t), "regular")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := ensureFifo(path); err == nil {
+		t.Error("Expected error for a regular file in place of the FIFO")
+	}
 }
 
 // ================= Shutdown Tests =================
@@ -534,7 +1283,8 @@ func TestShutdown(t *testing.T) {
 	tmpDir := setupTempDir(t)
 	basePipe := filepath.Join(tmpDir, "shutdown_test.pipe")
 
-	path, err := InitWithBase(basePipe)
+	s := New()
+	path, err := s.InitWithBase(basePipe)
 	if err != nil {
 		t.Fatalf("Failed to initialize FIFO: %v", err)
 	}
@@ -544,11 +1294,7 @@ func TestShutdown(t *testing.T) {
 		t.Fatal("FIFO file should exist before shutdown")
 	}
 
-	// Reset the sync.Once variables to test shutdown
-	stopOnce = sync.Once{}
-	stopping = make(chan struct{})
-
-	Shutdown()
+	s.Stop()
 
 	// Verify file is removed after shutdown
 	time.Sleep(100 * time.Millisecond)
@@ -561,127 +1307,368 @@ func TestShutdown_MultipleCall(t *testing.T) {
 	tmpDir := setupTempDir(t)
 	basePipe := filepath.Join(tmpDir, "multi_shutdown.pipe")
 
-	_, err := InitWithBase(basePipe)
+	s := New()
+	_, err := s.InitWithBase(basePipe)
 	if err != nil {
 		t.Fatalf("Failed to initialize FIFO: %v", err)
 	}
 
-	// Reset sync variables
-	stopOnce = sync.Once{}
-	stopping = make(chan struct{})
-
-	// Multiple calls to Shutdown should not panic
-	go Shutdown()
-	go Shutdown()
-	go Shutdown()
+	// Multiple calls to Stop should not panic
+	go s.Stop()
+	go s.Stop()
+	go s.Stop()
 
 	time.Sleep(100 * time.Millisecond)
 }
 
 // ================= Integration Tests =================
 
-func TestMain_Integration(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
+func TestRun_Integration(t *testing.T) {
+	cmdR, cmdW := io.Pipe()
+	outR, outW := io.Pipe()
+	defer outR.Close()
+	clock := newFakeClock()
+	s := New(WithCommands(cmdR), WithClock(clock), WithOutput(outW))
+
+	tm := focotimer.NewTimerManager(5 * time.Minute)
+	s.SetTimerManager(tm)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run()
+	}()
+	out := bufio.NewReader(outR)
+
+	clock.ticks <- time.Now()
+	line, err := out.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !strings.Contains(line, "5m0s : 5m0s") {
+		t.Errorf("Expected idle output, got %q", line)
 	}
 
-	tmpDir := setupTempDir(t)
-	basePipe := filepath.Join(tmpDir, "integration.pipe")
+	send(t, cmdW, "label writing")
+	send(t, cmdW, "start")
+	if !tm.Timer.IsRunning() {
+		t.Error("Expected timer to be started after 'start' command")
+	}
 
-	// Set environment variable
-	oldEnv := os.Getenv("FOCOTIMER_PIPE")
-	os.Setenv("FOCOTIMER_PIPE", basePipe)
-	defer os.Setenv("FOCOTIMER_PIPE", oldEnv)
+	clock.ticks <- time.Now()
+	line, err = out.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !strings.HasPrefix(line, "%{A:") || !strings.Contains(line, "writing ") {
+		t.Errorf("Expected labelled output, got %q", line)
+	}
 
-	// Reset global state
-	fifoPipePath = ""
-	startOnce = sync.Once{}
-	stopOnce = sync.Once{}
-	stopping = make(chan struct{})
-	wg = sync.WaitGroup{}
-
-	// Set up timer manager
-	tm := focotimer.NewTimerManager(200 * time.Millisecond)
-	SetTimerManager(tm)
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("Run should return after Stop")
+	}
+}
 
-	// Start Main in background
+func TestRun_OnlyChanges(t *testing.T) {
+	cmdR, cmdW := io.Pipe()
+	outR, outW := io.Pipe()
+	defer outR.Close()
+	clock := newFakeClock()
+	s := New(WithCommands(cmdR), WithClock(clock), WithOutput(outW), WithFormat(FormatPlain))
+	s.SetTimerManager(focotimer.NewTimerManager(5 * time.Minute))
+
+	done := make(chan struct{})
 	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				t.Errorf("Main() panicked: %v", r)
-			}
-		}()
-		Main()
+		defer close(done)
+		s.Run()
 	}()
+	lines := make(chan string, 10)
+	go func() {
+		out := bufio.NewScanner(outR)
+		for out.Scan() {
+			lines <- out.Text()
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for output")
+			return ""
+		}
+	}
 
-	// Wait for initialization
-	time.Sleep(100 * time.Millisecond)
+	clock.ticks <- time.Now()
+	if line := next(); line != "5m0s : 5m0s" {
+		t.Errorf("Expected idle output, got %q", line)
+	}
+	clock.ticks <- time.Now()
+	send(t, cmdW, "label writing")
+	clock.ticks <- time.Now()
+	if line := next(); line != "writing 5m0s : 5m0s" {
+		t.Errorf("Expected the unchanged tick to print nothing, got %q", line)
+	}
+	send(t, cmdW, "refresh")
+	if line := next(); line != "writing 5m0s : 5m0s" {
+		t.Errorf("Expected refresh to print the output again, got %q", line)
+	}
 
-	// Verify FIFO was created
-	if fifoPipePath == "" {
-		t.Fatal("Expected fifoPipePath to be set after Main start")
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("Run should return after Stop")
 	}
+}
 
-	if !waitForFile(fifoPipePath, 2*time.Second) {
-		t.Fatal("FIFO file should exist after Main start")
+// lineWriter passes every line written to it on to a channel.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w <- line
 	}
+	return len(p), nil
+}
 
-	// Test sending commands
-	go writeToFifo(t, fifoPipePath, "start")
-	time.Sleep(50 * time.Millisecond)
+func TestListen_SeveralBars(t *testing.T) {
+	pipe := filepath.Join(t.TempDir(), "cmd.fifo")
+	s := New(WithPath(pipe), WithOutput(io.Discard), WithFormat(FormatPlain), WithInterval(10*time.Millisecond))
+	s.SetTimerManager(focotimer.NewTimerManager(5 * time.Minute))
+	s.Start()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run()
+	}()
+	defer func() {
+		s.Stop()
+		<-done
+	}()
 
-	if tm.Timer.Timer == nil {
-		t.Error("Expected timer to be started after 'start' command")
+	type bar struct {
+		lines lineWriter
+		stop  chan struct{}
+		err   chan error
+	}
+	listen := func() bar {
+		// bars in one process would share their unique pipe name
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+		b := bar{make(lineWriter, 10), make(chan struct{}), make(chan error, 1)}
+		go func() { b.err <- Listen(pipe, b.lines, b.stop) }()
+		return b
+	}
+	next := func(b bar, want string) {
+		t.Helper()
+		for {
+			select {
+			case line := <-b.lines:
+				if line == want {
+					return
+				}
+			case err := <-b.err:
+				t.Fatalf("Listen ended waiting for %q: %v", want, err)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Timed out waiting for %q", want)
+			}
+		}
+	}
+	ended := func(b bar) {
+		t.Helper()
+		select {
+		case err := <-b.err:
+			if err != nil {
+				t.Errorf("Expected Listen to end cleanly, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for Listen to end")
+		}
 	}
 
-	// Test shutdown
-	go writeToFifo(t, fifoPipePath, "stop")
-	time.Sleep(100 * time.Millisecond)
+	left := listen()
+	next(left, "5m0s : 5m0s")
+	right := listen()
+	next(right, "5m0s : 5m0s")
+	if err := sendRetry(pipe, "label writing"); err != nil {
+		t.Fatal(err)
+	}
+	next(left, "writing 5m0s : 5m0s")
+	next(right, "writing 5m0s : 5m0s")
 
-	// Trigger shutdown
-	Shutdown()
-	time.Sleep(100 * time.Millisecond)
+	close(left.stop)
+	ended(left)
+	if err := sendRetry(pipe, "label reading"); err != nil {
+		t.Fatal(err)
+	}
+	next(right, "reading 5m0s : 5m0s")
+
+	s.Stop()
+	ended(right)
 }
 
-func TestConcurrentOperations(t *testing.T) {
-	tmpDir := setupTempDir(t)
-	basePipe := filepath.Join(tmpDir, "concurrent.pipe")
+// flakyWriter fails its first writes, then keeps the lines.
+type flakyWriter struct {
+	mu    sync.Mutex
+	fails int
+	lines []string
+}
 
-	path, err := InitWithBase(basePipe)
+func (w *flakyWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fails > 0 {
+		w.fails--
+		return 0, errors.New("bar not running")
+	}
+	w.lines = append(w.lines, string(b))
+	return len(b), nil
+}
+
+func TestRun_RetriesFailedOutput(t *testing.T) {
+	w := &flakyWriter{fails: 1}
+	clock := newFakeClock()
+	s := New(WithCommands(strings.NewReader("")), WithClock(clock), WithOutput(w), WithFormat(FormatPlain))
+	s.SetTimerManager(focotimer.NewTimerManager(5 * time.Minute))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run()
+	}()
+
+	clock.ticks <- time.Now()
+	clock.ticks <- time.Now()
+	clock.ticks <- time.Now()
+	s.Stop()
+	<-done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.lines) != 1 || w.lines[0] != "5m0s : 5m0s\n" {
+		t.Errorf("Expected the output again after a failed write, and only once, got %q", w.lines)
+	}
+}
+
+func TestPolybarMsg(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "sent")
+	script := filepath.Join(dir, "polybar-msg")
+	body := "#!/bin/sh\n[ -e " + filepath.Join(dir, "down") + " ] && { echo 'no bar running' >&2; exit 1; }\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	msg := &PolybarMsg{Module: "focotimer", Command: script}
+
+	if _, err := fmt.Fprintln(msg, "25m0s : 25m0s"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(log)
 	if err != nil {
-		t.Fatalf("Failed to initialize FIFO: %v", err)
+		t.Fatal(err)
+	}
+	if got := string(data); got != "action focotimer send 25m0s : 25m0s\n" {
+		t.Errorf("Expected one action per line, got %q", got)
 	}
-	defer os.Remove(path)
 
-	tm := focotimer.NewTimerManager(1 * time.Second)
-	SetTimerManager(tm)
+	os.WriteFile(filepath.Join(dir, "down"), nil, 0o644)
+	if _, err := fmt.Fprintln(msg, "24m59s : 25m0s"); err == nil || !strings.Contains(err.Error(), "no bar running") {
+		t.Errorf("Expected the error of polybar-msg, got %v", err)
+	}
+}
 
-	// Reset sync variables
-	startOnce = sync.Once{}
-	stopOnce = sync.Once{}
-	stopping = make(chan struct{})
-	wg = sync.WaitGroup{}
+func TestSocketTransport(t *testing.T) {
+	dir := t.TempDir()
+	s := New(WithTransport(NewSocketTransport()))
+	tm := focotimer.NewTimerManager(5 * time.Minute)
+	s.SetTimerManager(tm)
+	path, err := s.InitWithBase(filepath.Join(dir, "cmd"))
+	if err != nil {
+		t.Fatalf("InitWithBase failed: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		t.Fatalf("Expected a socket at %q, got %v", path, err)
+	}
+	s.Start()
 
-	// Start command handler
-	go func() {
-		wg.Add(1)
-		defer wg.Done()
-		handle_cmds()
-	}()
+	if err := Send(path, "label writing"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := Send(path, "start"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	waitFor(t, "the commands over the socket", func() bool {
+		st := tm.State()
+		return st.Label == "writing" && st.Status == focotimer.StatusRunning
+	})
+	if err := NewSocketTransport().Ensure(path); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("Expected a socket in use to be refused, got %v", err)
+	}
+
+	s.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket removed on Stop, got %v", err)
+	}
+	if err := Send(filepath.Join(dir, "missing.sock"), "start"); err == nil {
+		t.Error("Expected Send to a missing socket to fail")
+	}
 
-	time.Sleep(50 * time.Millisecond)
+	// a socket left behind by a focotimer that is gone is replaced
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if err := Send(stale, "start"); err == nil || !strings.Contains(err.Error(), "not reading") {
+		t.Errorf("Expected a not-listening error for a stale socket, got %v", err)
+	}
+	tr := NewSocketTransport()
+	if err := tr.Ensure(stale); err != nil {
+		t.Errorf("Expected a stale socket to be replaced, got %v", err)
+	}
+	tr.Remove(stale)
+}
+
+func TestSetCommands_BareClicks(t *testing.T) {
+	s := New(WithPath("/tmp/test.pipe"), WithSender("focotimer"))
+	s.SetTimerManager(focotimer.NewTimerManager(300 * time.Second))
+	s.SetCommands(strings.NewReader(""))
+	s.SetClicks(map[MouseButton]string{LeftClick: "toggle", RightClick: "until 15:30"})
+
+	result := s.output()
+	if want := `%{A1:toggle:}%{A3:until 15\:30:} 5m0s : 5m0s %{A}%{A}`; !strings.Contains(result, want) {
+		t.Errorf("Expected bare commands as click actions, got %q", result)
+	}
+	if strings.Contains(result, "send -pipe") {
+		t.Errorf("Expected no send subcommand, got %q", result)
+	}
+}
+
+func TestConcurrentOperations(t *testing.T) {
+	r, w := io.Pipe()
+	s := New(WithCommands(r))
+
+	tm := focotimer.NewTimerManager(1 * time.Second)
+	s.SetTimerManager(tm)
+
+	s.Start()
 
 	var testWg sync.WaitGroup
 
 	// Concurrent operations
 	operations := []func(){
-		func() { TimerStart() },
-		func() { TimerStop() },
-		func() { TimerInc() },
-		func() { TimerDec() },
-		func() { Snapshot() },
-		func() { Subscribe() },
-		func() { output() },
+		func() { s.TimerStart() },
+		func() { s.TimerStop() },
+		func() { s.TimerInc() },
+		func() { s.TimerDec() },
+		func() { s.Snapshot() },
+		func() { s.Subscribe() },
+		func() { s.output() },
 	}
 
 	// Run operations concurrently
@@ -691,57 +1678,48 @@ func TestConcurrentOperations(t *testing.T) {
 			defer testWg.Done()
 			for i := 0; i < 10; i++ {
 				operation()
-				time.Sleep(time.Millisecond)
 			}
 		}(op)
 	}
 
-	// Also send FIFO commands concurrently
+	// Also send commands concurrently; pipe writes are serialized
 	commands := []string{"start", "stop", "inc", "dec", "gui"}
 	for _, cmd := range commands {
 		testWg.Add(1)
 		go func(command string) {
 			defer testWg.Done()
 			for i := 0; i < 5; i++ {
-				writeToFifo(t, path, command)
-				time.Sleep(10 * time.Millisecond)
+				io.WriteString(w, command+"\n")
 			}
 		}(cmd)
 	}
 
 	testWg.Wait()
-
-	// Cleanup
-	close(stopping)
-	wg.Wait()
+	w.Close()
+	s.Stop()
 }
 
 // ================= Error Handling Tests =================
 
 func TestHandleCmds_FifoError(t *testing.T) {
-	// Set an invalid FIFO path
-	fifoPipePath = "/nonexistent/directory/pipe"
-
-	// Reset stopping channel
-	stopping = make(chan struct{})
+	clock := newFakeClock()
+	clock.waits = make(chan time.Duration)
+	s := New(WithPath("/nonexistent/directory/pipe"), WithClock(clock))
 
-	// Start handler - should handle error gracefully
-	done := make(chan bool)
+	done := make(chan struct{})
 	go func() {
-		handle_cmds()
-		done <- true
+		s.handleCmds()
+		close(done)
 	}()
 
-	// Give it time to fail and retry
-	time.Sleep(200 * time.Millisecond)
-
-	// Signal stop
-	close(stopping)
+	// the loop backs off before retrying the open
+	if d := <-clock.waits; d != time.Second {
+		t.Errorf("Expected a 1s retry delay, got %v", d)
+	}
+	close(s.stopping)
 
-	// Should exit gracefully
 	select {
 	case <-done:
-		// Expected
 	case <-time.After(2 * time.Second):
 		t.Error("handle_cmds should exit when stopping channel is closed")
 	}
@@ -759,12 +1737,13 @@ func TestMkfifoUnique_PermissionError(t *testing.T) {
 
 func BenchmarkOutput(b *testing.B) {
 	tm := focotimer.NewTimerManager(300 * time.Second)
-	SetTimerManager(tm)
-	fifoPipePath = "/tmp/bench.pipe"
+	s := New()
+	s.SetTimerManager(tm)
+	s.fifoPipePath = "/tmp/bench.pipe"
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		output()
+		s.output()
 	}
 }
 
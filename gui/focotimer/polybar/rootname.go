@@ -0,0 +1,135 @@
+package polybar
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Status lines -------------------
+
+// line renders the timer as one line of plain text and hands it to set
+// whenever it changes.
+type line struct {
+	tm       *focotimer.TimerManager
+	name     string // for logs
+	prefix   string
+	suffix   string
+	fit      Fit
+	tickRate time.Duration
+	set      func(text string) error
+	last     string
+}
+
+// Text is the status text for the current state of the timer.
+func (l *line) Text() string {
+	text, _ := l.fit.fitted(l.tm.Activity(), l.tm.Duration(), l.tm.Snapshot(), false)
+	return l.prefix + text + l.suffix
+}
+
+// Write passes the text on, unless it is the same as last time.
+func (l *line) Write() error {
+	text := l.Text()
+	if text == l.last {
+		return nil
+	}
+	if err := l.set(text); err != nil {
+		return err
+	}
+	l.last = text
+	return nil
+}
+
+// Run keeps the text current until stop is closed.
+func (l *line) Run(stop <-chan struct{}) {
+	tickRate := l.tickRate
+	if tickRate <= 0 {
+		tickRate = time.Second
+	}
+	events := l.tm.SubscribeEvents()
+	defer l.tm.UnsubscribeEvents(events)
+	ticks := l.tm.SubscribeEvery(tickRate, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	defer l.tm.Unsubscribe(ticks)
+
+	for {
+		if err := l.Write(); err != nil {
+			log.Printf("polybar.%s: %v", l.name, err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-events:
+		case <-ticks:
+		}
+	}
+}
+
+// DefaultRootNameCommand sets the X root window name, which dwm shows as
+// its status text.
+var DefaultRootNameCommand = []string{"xsetroot", "-name"}
+
+type RootNameOptions struct {
+	// Prefix and Suffix surround the timer, e.g. to keep a clock beside it.
+	Prefix string
+	Suffix string
+	// Command gets the status text as its last argument; defaults to
+	// DefaultRootNameCommand.
+	Command  []string
+	Fit      Fit
+	TickRate time.Duration
+}
+
+// RootName keeps the timer in the X root window name, for dwm and other
+// bars that read it. The command only runs when the text changes.
+type RootName struct {
+	line
+}
+
+func NewRootName(tm *focotimer.TimerManager, opts RootNameOptions) *RootName {
+	command := opts.Command
+	if len(command) == 0 {
+		command = DefaultRootNameCommand
+	}
+	r := &RootName{line{tm: tm, name: "RootName", prefix: opts.Prefix, suffix: opts.Suffix, fit: opts.Fit, tickRate: opts.TickRate}}
+	r.set = func(text string) error {
+		args := append(command[1:len(command):len(command)], text)
+		if out, err := exec.Command(command[0], args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w: %s", command[0], err, bytes.TrimSpace(out))
+		}
+		return nil
+	}
+	return r
+}
+
+// LineFile keeps the timer as a line of text in a file, for conky,
+// AwesomeWM widgets and other tools that watch a file; ipc.StatusFile
+// keeps the whole state as JSON instead. The file is replaced through a
+// temporary file so readers never see a partial line.
+type LineFile struct {
+	line
+}
+
+func NewLineFile(tm *focotimer.TimerManager, path string, fit Fit, tickRate time.Duration) *LineFile {
+	f := &LineFile{line{tm: tm, name: "LineFile", fit: fit, tickRate: tickRate}}
+	f.set = func(text string) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(text+"\n"), 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+	return f
+}
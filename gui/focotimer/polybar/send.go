@@ -0,0 +1,78 @@
+package polybar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ------------------- Sending commands -------------------
+
+// Send writes one command line to the command pipe or socket at path, as
+// click actions do through "focotimer send". Unlike a shell redirect it fails at
+// once when no focotimer reads the pipe instead of blocking.
+func Send(path, line string) error {
+	err := sendLine(path, line)
+	if errors.Is(err, ErrNotListening) {
+		return fmt.Errorf("focotimer is not reading %q", path)
+	}
+	return err
+}
+
+// sendRetry is Send for clients that outlive a click: it waits up to
+// replyTimeout while the command loop reopens the pipe between two
+// clients.
+func sendRetry(path, line string) error {
+	const retry = 50 * time.Millisecond
+	for waited := time.Duration(0); ; waited += retry {
+		err := sendLine(path, line)
+		if !errors.Is(err, ErrNotListening) {
+			return err
+		}
+		if waited >= replyTimeout {
+			return fmt.Errorf("focotimer is not reading %q", path)
+		}
+		time.Sleep(retry)
+	}
+}
+
+func sendLine(path, line string) error {
+	w, err := dial(path)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	_, err = w.Write([]byte(strings.TrimSpace(line) + "\n"))
+	return err
+}
+
+// Polybar ends an action at an unescaped ':' and a tag at '}', and older
+// versions have no escape for the latter. Click action arguments therefore
+// carry both percent-encoded; send decodes them with DecodeArg.
+var (
+	argEncoder = strings.NewReplacer("%", "%25", ":", "%3A", "}", "%7D")
+	argDecoder = strings.NewReplacer("%25", "%", "%3A", ":", "%7D", "}")
+)
+
+// EncodeArg hides the characters of polybar's tag syntax in s.
+func EncodeArg(s string) string { return argEncoder.Replace(s) }
+
+// DecodeArg reverses EncodeArg. Other text, including a lone '%', is kept
+// as is, so hand-typed commands need no encoding.
+func DecodeArg(s string) string { return argDecoder.Replace(s) }
+
+// shellQuote quotes s as one sh word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// executable is the running binary, whose send subcommand click actions
+// call; it falls back to looking focotimer up on $PATH.
+func executable() string {
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "focotimer"
+}
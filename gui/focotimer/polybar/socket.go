@@ -0,0 +1,118 @@
+package polybar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// socketTransport carries commands over unix sockets. Every connection is
+// one client; replies still go to whatever the reply path is, so scripts
+// can keep using a FIFO for them.
+type socketTransport struct {
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+}
+
+// NewSocketTransport returns a Transport over unix sockets, for setups
+// where FIFOs are a poor fit: many clients at once, or sandboxes that only
+// pass sockets through.
+func NewSocketTransport() Transport {
+	return &socketTransport{listeners: make(map[string]net.Listener)}
+}
+
+func (t *socketTransport) Create(base string) (string, error) {
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(os.TempDir(), base)
+	}
+	pid := os.Getpid()
+	for i := 0; i < 1000; i++ {
+		path := fmt.Sprintf("%s.%d.sock", base, pid)
+		if i > 0 {
+			path = fmt.Sprintf("%s.%d.%d.sock", base, pid, i)
+		}
+		if _, err := os.Lstat(path); err == nil {
+			continue
+		}
+		if err := t.Ensure(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("no free socket name for %q", base)
+}
+
+// Ensure listens on path. A socket left behind by a focotimer that is
+// gone is replaced; one that still answers is an error.
+func (t *socketTransport) Ensure(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.listeners[path]; ok {
+		return nil
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%q exists and is not a socket", path)
+		}
+		if c, err := net.Dial("unix", path); err == nil {
+			c.Close()
+			return fmt.Errorf("%q is in use", path)
+		}
+		os.Remove(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	t.listeners[path] = l
+	return nil
+}
+
+func (t *socketTransport) Accept(path string) (io.ReadCloser, error) {
+	t.mu.Lock()
+	l, ok := t.listeners[path]
+	t.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("not listening on %q", path)
+	}
+	return l.Accept()
+}
+
+func (t *socketTransport) Dial(path string) (io.WriteCloser, error) { return dial(path) }
+
+func (t *socketTransport) Remove(path string) error {
+	t.mu.Lock()
+	l, ok := t.listeners[path]
+	delete(t.listeners, path)
+	t.mu.Unlock()
+	if ok {
+		// closing the listener also unlinks the socket
+		l.Close()
+	}
+	err := os.Remove(path)
+	if ok && errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// dial connects to the socket or the pipe at path, whichever it is.
+func dial(path string) (io.WriteCloser, error) {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return DefaultTransport.Dial(path)
+	}
+	c, err := net.Dial("unix", path)
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, fmt.Errorf("connect %q: %w", path, ErrNotListening)
+	}
+	return c, err
+}
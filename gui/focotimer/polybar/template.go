@@ -0,0 +1,87 @@
+package polybar
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Templates -------------------
+
+// TemplateData is what an output template sees, e.g.
+// "{{.Remaining}} / {{.Duration}} {{.Icon}} {{.Phase}}". Durations are
+// truncated to the second.
+type TemplateData struct {
+	Remaining time.Duration
+	Duration  time.Duration
+	Elapsed   time.Duration
+	// Activity is the label and phase as the default output shows them.
+	Activity string
+	Label    string
+	Phase    string
+	Status   focotimer.Status
+	Icon     string
+}
+
+// SetTemplate replaces the text of the timer with a text/template, which
+// places the buttons itself: {{button "[+]" "inc"}} is a button running
+// "inc" on click, left out of formats without clicks. Besides the
+// built-in functions there are clock ({{clock .Remaining}} is "04:59")
+// and minutes (rounded up). MaxWidth and Variant do not apply to a
+// template. An empty text restores the default output.
+func (s *Server) SetTemplate(text string) error {
+	var tmpl *template.Template
+	if text != "" {
+		var err error
+		tmpl, err = template.New("output").Funcs(template.FuncMap{
+			"button":  s.templateButton,
+			"clock":   focotimer.FormatClock,
+			"minutes": minutes,
+		}).Parse(text)
+		if err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.template = tmpl
+	return nil
+}
+
+func (s *Server) templateButton(label, cmd string) string {
+	s.mu.RLock()
+	format := s.format
+	s.mu.RUnlock()
+	if format != FormatPolybar {
+		return ""
+	}
+	return polybarActionButton(label, s.clickAction(cmd))
+}
+
+func executeTemplate(tmpl *template.Template, st focotimer.State, activity string, fit Fit) (string, error) {
+	icon := fit.Icon
+	if icon == "" {
+		icon = DefaultIcon
+	}
+	var b strings.Builder
+	err := tmpl.Execute(&b, TemplateData{
+		Remaining: truncToSecond(st.Remaining),
+		Duration:  truncToSecond(st.Duration),
+		Elapsed:   truncToSecond(st.Duration - st.Remaining),
+		Activity:  activity,
+		Label:     st.Label,
+		Phase:     st.Phase,
+		Status:    st.Status,
+		Icon:      icon,
+	})
+	return b.String(), err
+}
+
+// minutes is d in whole minutes, rounded up so the last seconds still
+// read 1.
+func minutes(d time.Duration) int {
+	return int((max(d, 0) + time.Minute - 1) / time.Minute)
+}
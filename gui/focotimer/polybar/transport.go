@@ -0,0 +1,34 @@
+package polybar
+
+import (
+	"errors"
+	"io"
+)
+
+// ------------------- Command transport -------------------
+
+// Transport is the local channel command lines and replies travel over: a
+// FIFO on unix, a named pipe on Windows. Paths name endpoints in the
+// transport's own namespace.
+type Transport interface {
+	// Create makes an endpoint named after base that no other process
+	// uses and returns its path.
+	Create(base string) (string, error)
+	// Ensure creates the endpoint at path unless one is already there.
+	Ensure(path string) error
+	// Accept waits for the next client of path and returns what it writes.
+	// The reader ends when the client hangs up.
+	Accept(path string) (io.ReadCloser, error)
+	// Dial connects to the endpoint at path without waiting for a reader;
+	// it fails with ErrNotListening when nothing serves path.
+	Dial(path string) (io.WriteCloser, error)
+	// Remove deletes the endpoint at path.
+	Remove(path string) error
+}
+
+// ErrNotListening reports a command pipe nobody reads.
+var ErrNotListening = errors.New("nothing is reading the pipe")
+
+// DefaultTransport is the platform's transport: FIFOs, or named pipes on
+// Windows.
+var DefaultTransport Transport = defaultTransport()
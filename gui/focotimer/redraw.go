@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/gio/app"
+)
+
+// ---------------- REDRAWS ----------------
+// Windows are only drawn again when what they show changes: the countdown
+// ticks over to another second, the timer changes state, the page changes
+// or the config restyles them. An idle window draws nothing.
+
+var (
+	windowsMu sync.Mutex
+	windows   = make(map[*app.Window]bool)
+)
+
+// redraw draws every open window again.
+func redraw() {
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+	for w := range windows {
+		w.Invalidate()
+	}
+}
+
+// followTimer draws window again whenever the second of the remaining
+// time or the status of the timer changes, and for redraw, until stop is
+// called.
+func followTimer(window *app.Window) (stop func()) {
+	windowsMu.Lock()
+	windows[window] = true
+	windowsMu.Unlock()
+
+	tm := focotimer.GTimerManager
+	ch := tm.Subscribe(focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	go func() {
+		shown := time.Duration(-1)
+		var status focotimer.Status
+		for remaining := range ch {
+			// the countdown shows whole seconds
+			s := tm.State().Status
+			if sec := remaining.Truncate(time.Second); sec != shown || s != status {
+				shown, status = sec, s
+				window.Invalidate()
+			}
+		}
+	}()
+	return func() {
+		tm.Unsubscribe(ch)
+		windowsMu.Lock()
+		delete(windows, window)
+		windowsMu.Unlock()
+	}
+}
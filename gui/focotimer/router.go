@@ -0,0 +1,138 @@
+package main
+
+import (
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// ---------------- ROUTER ----------------
+// Each window has a Router: the page it shows and a view of every page,
+// which keeps its buttons, fields and data from frame to frame. The page
+// actions are methods of the Router, so a window, a script or a test
+// drives its own pages.
+
+// PageView draws a page.
+type PageView interface {
+	Layout(th *material.Theme, gtx C) D
+}
+
+// typer is a PageView with fields; keys typed into them are not
+// shortcuts.
+type typer interface {
+	Typing(gtx C) bool
+}
+
+type Router struct {
+	mu      sync.RWMutex
+	current Page
+
+	timer    *timerPage
+	settings *settingsPage
+	timeline *timelinePage
+	insights *insightsPage
+	tasks    *tasksPage
+	stats    *statsPage
+	brk      *breakPage
+}
+
+// NewRouter starts on the stopped timer.
+func NewRouter() *Router {
+	r := &Router{current: TimerStopped}
+	r.timer = newTimerPage(r)
+	r.settings = newSettingsPage(r)
+	r.timeline = newTimelinePage(r)
+	r.insights = newInsightsPage(r)
+	r.tasks = newTasksPage(r)
+	r.stats = newStatsPage(r)
+	r.brk = newBreakPage(r)
+	return r
+}
+
+// Current returns the page shown.
+func (r *Router) Current() Page {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Show switches to p and redraws.
+func (r *Router) Show(p Page) {
+	r.mu.Lock()
+	r.current = p
+	r.mu.Unlock()
+	redraw()
+}
+
+// view returns the view of p; the timer shows every page of its own.
+func (r *Router) view(p Page) PageView {
+	switch p {
+	case Settings:
+		return r.settings
+	case Timeline:
+		return r.timeline
+	case Insights:
+		return r.insights
+	case Tasks:
+		return r.tasks
+	case Stats:
+		return r.stats
+	case Break:
+		return r.brk
+	}
+	return r.timer
+}
+
+// Layout draws the current page, named for screen readers.
+func (r *Router) Layout(th *material.Theme, gtx C) D {
+	p := r.Current()
+	return widgets.Labelled(gtx, pageTitles[p], func(gtx C) D {
+		return r.view(p).Layout(th, gtx)
+	})
+}
+
+// Typing tells whether a field of the current page has the keys.
+func (r *Router) Typing(gtx C) bool {
+	t, ok := r.view(r.Current()).(typer)
+	return ok && t.Typing(gtx)
+}
+
+// onTimerPage reports whether the timer is on screen, rather than a page
+// the user went to on purpose, such as the settings.
+func (r *Router) onTimerPage() bool {
+	switch r.Current() {
+	case TimerStopped, TimerRunning, TimerFinished, Break:
+		return true
+	}
+	return false
+}
+
+// follow keeps the pages in sync with changes made outside the GUI: the
+// break page follows breaks in and out, as the cycle engine starts them
+// after focus sessions, completed sessions fill the dots and the last
+// focus session is kept for the next one to follow.
+func (r *Router) follow(events <-chan focotimer.Event) {
+	for ev := range events {
+		switch ev.Kind {
+		case focotimer.EventCompleted:
+			r.timer.countDot(ev)
+			if !ev.Break {
+				r.brk.keepWork(ev)
+			}
+		case focotimer.EventAutoReset:
+			if p := r.Current(); p == TimerFinished || p == Break {
+				r.Show(TimerStopped)
+			}
+		case focotimer.EventStarted:
+			switch {
+			case !r.onTimerPage():
+			case ev.Break:
+				r.Show(Break)
+			case r.Current() == Break:
+				r.Show(TimerRunning)
+			}
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/keymap"
+)
+
+// ---------------- TEST MODE ----------------
+// When FOCOTIMER_SCRIPT names a file, its steps are played against the page
+// state machine after startup and the process exits with the result, so the
+// GUI can be covered end to end without a human:
+//
+//	# start a short session and wait for it to finish
+//	set 200ms
+//	click play
+//	assert page running
+//	wait 300ms
+//	assert page finished
+//
+// Keys go through the bindings like real presses: "press Space" runs the
+// key's command, and "bind inc" followed by "press Ctrl+I" rebinds inc as
+// the Settings page does; "bind global toggle" captures a global hotkey.
+//
+// "open focotimer://timeline/2025-09-02" goes to a page by its address.
+//
+// "type writing docs" names the task as if typed into the timer page, and
+// "task add docs", "task pick docs" and "task remove docs" edit the list on
+// the tasks page.
+//
+// Blank lines and lines starting with '#' are ignored.
+
+const scriptEnv = "FOCOTIMER_SCRIPT"
+
+// scriptClicks maps the names used in scripts to the button actions of
+// the pages of r.
+func scriptClicks(r *Router) map[string]func() {
+	return map[string]func(){
+		"play":         r.togglePlayPause,
+		"pause":        pause,
+		"stop":         r.stopSession,
+		"back":         r.goBack,
+		"inc":          increase,
+		"dec":          decrease,
+		"settings":     r.openSettings,
+		"timeline":     r.openTimeline,
+		"prev-day":     r.timeline.prevDay,
+		"next-day":     r.timeline.nextDay,
+		"insights":     r.openInsights,
+		"tasks":        r.openTasks,
+		"stats":        r.openStats,
+		"daily":        r.stats.showDaily,
+		"weekly":       r.stats.showWeekly,
+		"skip-break":   skipBreak,
+		"next-session": r.startNextSession,
+		"extend":       r.extendSession,
+		"start-break":  r.startBreak,
+		"new-session":  r.startSession,
+		"energy-1":     func() { rateEnergy(1) },
+		"energy-2":     func() { rateEnergy(2) },
+		"energy-3":     func() { rateEnergy(3) },
+		"energy-4":     func() { rateEnergy(4) },
+		"energy-5":     func() { rateEnergy(5) },
+		"theme-system": func() { setThemePreset("system") },
+		"theme-dark":   func() { setThemePreset("dark") },
+		"theme-light":  func() { setThemePreset("light") },
+	}
+}
+
+type scriptStep struct {
+	line int
+	op   string
+	args []string
+}
+
+func parseScript(r io.Reader) ([]scriptStep, error) {
+	var steps []scriptStep
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		steps = append(steps, scriptStep{line: n, op: fields[0], args: fields[1:]})
+	}
+	return steps, sc.Err()
+}
+
+// runScript plays steps against the pages of r.
+func runScript(r *Router, steps []scriptStep) error {
+	for _, st := range steps {
+		if err := runStep(r, st); err != nil {
+			return fmt.Errorf("line %d: %s: %w", st.line, st.op, err)
+		}
+	}
+	return nil
+}
+
+func runStep(r *Router, st scriptStep) error {
+	switch st.op {
+	case "click":
+		if len(st.args) != 1 {
+			return fmt.Errorf("usage: click <button>")
+		}
+		click, ok := scriptClicks(r)[st.args[0]]
+		if !ok {
+			return fmt.Errorf("unknown button %q", st.args[0])
+		}
+		click()
+	case "wait":
+		if len(st.args) != 1 {
+			return fmt.Errorf("usage: wait <duration>")
+		}
+		d, err := time.ParseDuration(st.args[0])
+		if err != nil {
+			return err
+		}
+		time.Sleep(d)
+	case "set":
+		if len(st.args) != 1 {
+			return fmt.Errorf("usage: set <duration>")
+		}
+		d, err := time.ParseDuration(st.args[0])
+		if err != nil {
+			return err
+		}
+		focotimer.GTimerManager.SetDuration(d)
+	case "press":
+		if len(st.args) != 1 {
+			return fmt.Errorf("usage: press <chord>")
+		}
+		c, err := keymap.Parse(st.args[0])
+		if err != nil {
+			return err
+		}
+		handleKey(c)
+	case "bind":
+		switch {
+		case len(st.args) == 1:
+			startCapture(st.args[0], false)
+		case len(st.args) == 2 && st.args[0] == "global":
+			startCapture(st.args[1], true)
+		default:
+			return fmt.Errorf("usage: bind [global] <action>")
+		}
+	case "type":
+		setTask(strings.Join(st.args, " "))
+	case "task":
+		if len(st.args) < 2 {
+			return fmt.Errorf("usage: task add|pick|remove <name>")
+		}
+		name := strings.Join(st.args[1:], " ")
+		switch st.args[0] {
+		case "add":
+			r.tasks.addTask(name)
+		case "pick":
+			r.tasks.pickTask(name)
+		case "remove":
+			r.tasks.removeTask(name)
+		default:
+			return fmt.Errorf("usage: task add|pick|remove <name>")
+		}
+	case "open":
+		if len(st.args) != 1 {
+			return fmt.Errorf("usage: open <page>")
+		}
+		l, err := parseLink(st.args[0])
+		if err != nil {
+			return err
+		}
+		r.openLink(l)
+	case "assert":
+		return assertStep(r, st.args)
+	default:
+		return fmt.Errorf("unknown step")
+	}
+	return nil
+}
+
+func assertStep(r *Router, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: assert page|status|label|activity|dots|tasks|stats|theme|binding|hotkey|timeline|energy|peak <value>")
+	}
+	want := strings.Join(args[1:], " ")
+	var got string
+	switch args[0] {
+	case "binding":
+		// assert binding <action> <keys>, e.g. "assert binding inc Plus"
+		want = strings.Join(args[2:], " ")
+		got = actionKeys(args[1])
+	case "hotkey":
+		// assert hotkey <action> <keys>, e.g. "assert hotkey toggle Ctrl+Alt+P"
+		want = strings.Join(args[2:], " ")
+		got = hotkeyKeys(args[1])
+	case "page":
+		got = r.Current().String()
+	case "status":
+		got = string(focotimer.GTimerManager.State().Status)
+	case "label":
+		got = focotimer.GTimerManager.Label()
+	case "activity":
+		got = focotimer.GTimerManager.Activity()
+	case "dots":
+		// assert dots <pomodoros since the long break>
+		got = strconv.Itoa(r.timer.completedDots())
+	case "tasks":
+		// assert tasks <list>, e.g. "assert tasks *docs (2), review (0)"
+		got = r.tasks.summary()
+	case "stats":
+		// assert stats <period> <pomodoros>, e.g. "assert stats daily 0 0 0 0 0 2 1"
+		got = r.stats.summary()
+	case "theme":
+		// assert theme <chosen> <used>, e.g. "assert theme system light"
+		chosen, used := themeState()
+		got = chosen + " " + used
+	case "timeline":
+		// assert timeline <date> <kinds>, e.g. "assert timeline 2025-09-01 focus break"
+		day, blocks := r.timeline.blocks()
+		kinds := make([]string, len(blocks))
+		for i, b := range blocks {
+			kinds[i] = b.Kind.String()
+		}
+		got = strings.TrimSpace(day.Format(time.DateOnly) + " " + strings.Join(kinds, " "))
+	case "energy":
+		// assert energy <start> <end>, zero when unrated
+		s := focotimer.GTimerManager.State()
+		got = fmt.Sprintf("%d %d", s.EnergyStart, s.EnergyEnd)
+	case "peak":
+		// assert peak <time of day>, "none" without start ratings
+		got = "none"
+		if peak, ok := r.insights.current().Peak(); ok {
+			got = peak.Name
+		}
+	default:
+		return fmt.Errorf("unknown assertion %q", args[0])
+	}
+	if got != want {
+		return fmt.Errorf("expected %s %q, got %q", args[0], want, got)
+	}
+	return nil
+}
+
+// runScriptFile plays the script at path against the pages of r and
+// exits: 0 if every step passed, 1 otherwise.
+func runScriptFile(r *Router, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("script: %v", err)
+		os.Exit(1)
+	}
+	steps, err := parseScript(f)
+	f.Close()
+	if err == nil {
+		err = runScript(r, steps)
+	}
+	if err != nil {
+		log.Printf("script %s: %v", path, err)
+		os.Exit(1)
+	}
+	log.Printf("script %s: ok", path)
+	os.Exit(0)
+}
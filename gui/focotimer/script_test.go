@@ -0,0 +1,1085 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/appearance"
+	"github.com/d093w1z/focotimer/config"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/focotimer/keymap"
+	"github.com/d093w1z/gio/io/key"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+func TestParseScript(t *testing.T) {
+	src := `
+# comment
+click play
+
+wait 10ms
+assert page running
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %d", len(steps))
+	}
+	if steps[0].op != "click" || steps[0].args[0] != "play" || steps[0].line != 3 {
+		t.Errorf("Unexpected first step %+v", steps[0])
+	}
+}
+
+func TestScript_PageStateMachine(t *testing.T) {
+	defer focotimer.GTimerManager.SetDuration(10 * time.Second)
+	r := NewRouter()
+
+	src := `
+set 100ms
+assert page stopped
+click play
+assert page running
+wait 300ms
+assert page finished
+click back
+assert page stopped
+click play
+click play
+assert page running
+assert status paused
+click play
+assert status running
+click pause
+assert status paused
+click stop
+assert page stopped
+assert status idle
+click settings
+assert page settings
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouter_PagesPerWindow(t *testing.T) {
+	t.Setenv("FOCOTIMER_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+	a, b := NewRouter(), NewRouter()
+	a.openStats()
+	a.stats.showWeekly()
+	if a.Current() != Stats || b.Current() != TimerStopped {
+		t.Errorf("Expected only the first window to go to the stats, got %v and %v", a.Current(), b.Current())
+	}
+	b.openStats()
+	if got := b.stats.summary(); !strings.HasPrefix(got, "daily") {
+		t.Errorf("Expected the second window to keep its own period, got %q", got)
+	}
+	if a.view(TimerFinished) != a.timer || a.view(Break) != a.brk {
+		t.Error("Expected the finished timer on the timer page and breaks on their own")
+	}
+}
+
+func TestScript_Errors(t *testing.T) {
+	r := NewRouter()
+	tests := []string{
+		"assert page running",
+		"click nowhere",
+		"wait soon",
+		"frobnicate",
+		"assert color red",
+	}
+	for _, src := range tests {
+		steps, _ := parseScript(strings.NewReader(src))
+		if err := runScript(r, steps); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
+
+func TestScript_KeyBindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("FOCOTIMER_CONFIG", path)
+	defer focotimer.GTimerManager.SetDuration(10 * time.Second)
+	defer applyBindings(config.BindingsConfig{})
+	r := NewRouter()
+	setKeyRunner(focotimer.NewDispatcher(focotimer.GTimerManager), r)
+	cfg := config.Default()
+	cfg.Bindings.Global = map[string]string{"Ctrl+P": "toggle"}
+	if err := config.Save(path, cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if err := applyBindings(cfg.Bindings); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	focotimer.GTimerManager.SetDuration(10 * time.Second)
+
+	src := `
+assert binding inc Plus, Right, Up
+press S
+assert page settings
+press Backspace
+assert page stopped
+bind inc
+press ctrl+i
+assert binding inc Ctrl+I
+press Ctrl+I
+bind dec
+press Escape
+assert binding dec Down, Left, Minus
+bind global gui
+press Ctrl+Alt+G
+assert hotkey gui Ctrl+Alt+G
+assert hotkey toggle Ctrl+P
+bind global toggle
+press Escape
+assert hotkey toggle Ctrl+P
+# bound globally, so the capture is refused
+bind dec
+press Ctrl+P
+assert binding dec Down, Left, Minus
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := focotimer.GTimerManager.Duration(); d != 15*time.Second {
+		t.Errorf("Expected Ctrl+I to increase the duration to 15s, got %v", d)
+	}
+	if _, problem := bindingState(); !strings.Contains(problem, "bound globally") {
+		t.Errorf("Expected the conflict to be reported, got %q", problem)
+	}
+	cfg, err = config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if cfg.Bindings.GUI["Ctrl+I"] != "inc" || cfg.Bindings.Global["Ctrl+P"] != "toggle" || cfg.Bindings.Global["Ctrl+Alt+G"] != "gui" {
+		t.Errorf("Expected the captured binding to be saved, got %+v", cfg.Bindings)
+	}
+	if handleKey(keymap.Escape) {
+		t.Error("Expected Escape outside a capture to close the window")
+	}
+}
+
+func TestScript_DefaultKeys(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	r := NewRouter()
+	defer r.stopSession()
+	if err := applyBindings(config.BindingsConfig{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	setKeyRunner(focotimer.NewDispatcher(tm), r)
+	tm.SetDuration(10 * time.Second)
+
+	src := `
+press Up
+press Right
+press Left
+press Space
+assert page running
+assert status running
+press Space
+assert status paused
+press Space
+assert status running
+# outside a break there is nothing to skip
+press B
+assert status running
+press R
+assert page stopped
+assert status idle
+press Down
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+	if d := tm.Duration(); d != 10*time.Second {
+		t.Errorf("Expected the arrows to net +5s then -5s, got %v", d)
+	}
+}
+
+func TestChordFromGio(t *testing.T) {
+	tests := []struct {
+		ev   key.Event
+		want string
+		ok   bool
+	}{
+		{key.Event{Name: key.NameSpace}, "Space", true},
+		{key.Event{Name: "P", Modifiers: key.ModCtrl | key.ModShift}, "Ctrl+Shift+P", true},
+		{key.Event{Name: "+", Modifiers: key.ModShift}, "Plus", true},
+		{key.Event{Name: key.NameEscape}, "Escape", true},
+		{key.Event{Name: key.NameLeftArrow, Modifiers: key.ModShift}, "Shift+Left", true},
+		{key.Event{Name: key.NameCtrl, Modifiers: key.ModCtrl}, "", false},
+	}
+	for _, tt := range tests {
+		c, ok := chordFromGio(tt.ev)
+		if ok != tt.ok || (ok && c.String() != tt.want) {
+			t.Errorf("chordFromGio(%v) = %s, %v; expected %s, %v", tt.ev.Name, c, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestScript_Timeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv("FOCOTIMER_HISTORY", path)
+	today := history.Day(time.Now())
+	yesterday := today.AddDate(0, 0, -1)
+	records := []history.Record{
+		{Start: yesterday.Add(9 * time.Hour), End: yesterday.Add(9*time.Hour + 25*time.Minute), Completed: true},
+		{Start: yesterday.Add(10 * time.Hour), End: yesterday.Add(10*time.Hour + 5*time.Minute), Label: "break", Completed: true},
+		{Start: today.Add(time.Hour), End: today.Add(time.Hour + 10*time.Minute)},
+	}
+	for _, r := range records {
+		if err := history.Append(path, r); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	r := NewRouter()
+
+	src := fmt.Sprintf(`
+click timeline
+assert page timeline
+assert timeline %s abandoned
+click next-day
+assert timeline %s abandoned
+click prev-day
+assert timeline %s focus break
+click prev-day
+assert timeline %s
+click back
+assert page stopped
+`, today.Format(time.DateOnly), today.Format(time.DateOnly), yesterday.Format(time.DateOnly), yesterday.AddDate(0, 0, -1).Format(time.DateOnly))
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScript_Energy(t *testing.T) {
+	defer focotimer.GTimerManager.SetDuration(10 * time.Second)
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv("FOCOTIMER_HISTORY", path)
+	evening := history.Day(time.Now()).Add(19 * time.Hour)
+	if err := history.Append(path, history.Record{Start: evening, Duration: 25 * time.Minute, EnergyStart: 4}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	r := NewRouter()
+
+	src := `
+set 100ms
+click energy-4
+assert energy 4 0
+click play
+assert energy 4 0
+wait 300ms
+assert page finished
+click energy-2
+assert energy 4 2
+click back
+click insights
+assert page insights
+assert peak evening
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScript_Task(t *testing.T) {
+	defer focotimer.GTimerManager.SetDuration(10 * time.Second)
+	defer focotimer.GTimerManager.SetLabel("")
+	r := NewRouter()
+
+	src := `
+set 200ms
+type  writing docs 
+assert label writing docs
+click play
+type something else
+assert label writing docs
+click pause
+type something else
+assert label writing docs
+click play
+wait 400ms
+assert page finished
+type review
+assert label review
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScript_Tasks(t *testing.T) {
+	defer focotimer.GTimerManager.SetLabel("")
+	dir := t.TempDir()
+	t.Setenv("FOCOTIMER_TASKS", filepath.Join(dir, "tasks.json"))
+	path := filepath.Join(dir, "history.jsonl")
+	t.Setenv("FOCOTIMER_HISTORY", path)
+	for _, label := range []string{"docs", "docs", "review"} {
+		if err := history.Append(path, history.Record{Start: time.Now(), Duration: 25 * time.Minute, Label: label, Completed: true}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	r := NewRouter()
+
+	src := `
+click tasks
+assert page tasks
+task add docs
+task add tests
+task add docs
+assert tasks docs (2), tests (0)
+task pick docs
+assert tasks *docs (2), tests (0)
+assert label docs
+task remove docs
+assert tasks tests (0)
+click back
+open tasks
+assert tasks tests (0)
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScript_Stats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv("FOCOTIMER_HISTORY", path)
+	now := time.Now()
+	for _, r := range []history.Record{
+		{Start: now.AddDate(0, 0, -1), Duration: 25 * time.Minute, Label: "docs", Completed: true},
+		{Start: now.AddDate(0, 0, -1), Duration: 25 * time.Minute, Label: "docs"},
+		{Start: now, Duration: 25 * time.Minute, Label: "docs", Completed: true},
+		{Start: now, Duration: 5 * time.Minute, Label: "break", Completed: true},
+	} {
+		r.End = r.Start.Add(r.Duration)
+		if err := history.Append(path, r); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	week := "0 0 0 0 0 0 0 2"
+	if now.Weekday() == time.Monday {
+		// yesterday was in the week before
+		week = "0 0 0 0 0 0 1 1"
+	}
+	r := NewRouter()
+
+	src := `
+click stats
+assert page stats
+assert stats daily 0 0 0 0 0 1 1
+click weekly
+assert stats weekly ` + week + `
+click daily
+assert stats daily 0 0 0 0 0 1 1
+click back
+assert page stopped
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"stats", "focotimer://stats", false},
+		{"insights", "focotimer://insights", false},
+		{"focotimer://settings/keys", "focotimer://settings/keys", false},
+		{"focotimer://timeline/2025-09-02/", "focotimer://timeline/2025-09-02", false},
+		{"timer", "focotimer://timer", false},
+		{"tasks/", "focotimer://tasks", false},
+		{"focotimer://settings/colors", "", true},
+		{"timeline/yesterday", "", true},
+		{"insights/energy", "", true},
+		{"focotimer://nowhere", "", true},
+	}
+	for _, tt := range tests {
+		l, err := parseLink(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLink(%q): expected error %v, got %v", tt.in, tt.wantErr, err)
+			continue
+		}
+		if err == nil && l.String() != tt.want {
+			t.Errorf("parseLink(%q): expected %s, got %s", tt.in, tt.want, l)
+		}
+	}
+}
+
+func TestScript_Open(t *testing.T) {
+	t.Setenv("FOCOTIMER_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+	r := NewRouter()
+
+	src := `
+open focotimer://timeline/2025-09-02
+assert page timeline
+assert timeline 2025-09-02
+open stats
+assert page stats
+open insights
+assert page insights
+open timer
+assert page stopped
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScript_BreakPage(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	defer tm.SetLabel("")
+	r := NewRouter()
+	events := tm.SubscribeEvents()
+	defer tm.UnsubscribeEvents(events)
+	go r.follow(events)
+
+	d := focotimer.NewDispatcher(tm)
+	work := focotimer.Interval{Name: "work", Duration: 150 * time.Millisecond}
+	seqs := map[string]focotimer.Sequence{
+		"twice": {Intervals: []focotimer.Interval{work, {Name: "break", Duration: 10 * time.Second, Break: true}}, Repeat: 2},
+		"once":  {Intervals: []focotimer.Interval{work, {Name: "break", Duration: 150 * time.Millisecond, Break: true}}, Repeat: 1},
+	}
+	for name, seq := range seqs {
+		if err := d.DefineSequence(name, seq); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	run := func(src string) {
+		t.Helper()
+		steps, err := parseScript(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := runScript(r, steps); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the work session completes and the cycle goes on to the break
+	r.Show(TimerRunning)
+	if err := d.Dispatch("cycle twice"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	run(`
+wait 300ms
+assert page break
+assert label break
+click skip-break
+wait 50ms
+assert page running
+assert label work
+`)
+	d.Dispatch("cycle stop")
+
+	// the last break ends the cycle and waits for the next session
+	if err := d.Dispatch("cycle once"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	run(`
+wait 450ms
+assert page break
+click next-session
+assert page running
+assert label work
+`)
+	if got := tm.Duration(); got != 150*time.Millisecond {
+		t.Errorf("Expected the next session to last 150ms like the last one, got %v", got)
+	}
+	tm.Stop()
+}
+
+func TestScript_FinishedPage(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	defer tm.SetLabel("")
+	r := NewRouter()
+	r.stopSession()
+	defer r.stopSession()
+
+	src := `
+set 100ms
+type docs
+click play
+wait 300ms
+assert page finished
+click extend
+assert page running
+assert status running
+assert label docs
+click stop
+click play
+wait 300ms
+assert page finished
+click start-break
+assert page break
+assert label break
+click stop
+type docs
+click play
+wait 300ms
+click new-session
+assert page running
+assert label docs
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(r, steps); err != nil {
+		t.Fatal(err)
+	}
+	if d := tm.Duration(); d != 100*time.Millisecond {
+		t.Errorf("Expected the new session as long as the first, got %v", d)
+	}
+}
+
+func TestScript_SessionDots(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	defer tm.SetLabel("")
+	t.Setenv("FOCOTIMER_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+	r := NewRouter()
+	events := tm.SubscribeEvents()
+	defer tm.UnsubscribeEvents(events)
+	go r.follow(events)
+
+	run := func(src string) {
+		t.Helper()
+		steps, err := parseScript(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := runScript(r, steps); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(`
+set 100ms
+assert dots 0
+click play
+wait 300ms
+assert dots 1
+`)
+	// breaks fill no dot, whatever their label
+	tm.SetBreak("stretch")
+	run(`
+click play
+wait 300ms
+assert dots 1
+type fix-line-breaks
+click play
+wait 300ms
+assert dots 2
+`)
+	tm.SetBreak("long break")
+	run(`
+click play
+wait 300ms
+assert dots 0
+`)
+}
+
+func TestThemesFromConfig(t *testing.T) {
+	themes, err := themesFromConfig(config.ThemeConfig{
+		Preset: "light",
+		Colors: map[string]string{"accent": "#112233"},
+		Phases: map[string]map[string]string{"break": {"background": "#00000080"}},
+	}, appearance.Dark)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	focus, brk := themes["focus"], themes["break"]
+	if focus.Text != widgets.LightTheme.Text || focus.Ring != widgets.FocusRing {
+		t.Errorf("Expected the light preset with the focus ring, got %+v", focus)
+	}
+	if want := (color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xFF}); focus.Accent != want || brk.Accent != want {
+		t.Errorf("Expected the accent overridden in both phases, got %v and %v", focus.Accent, brk.Accent)
+	}
+	if brk.Ring != widgets.BreakRing || brk.Background != (color.NRGBA{A: 0x80}) || focus.Background != widgets.LightTheme.Background {
+		t.Errorf("Expected only the break background overridden, got %+v", brk)
+	}
+
+	themes, err = themesFromConfig(config.ThemeConfig{
+		Preset: "neon",
+		Colors: map[string]string{"accent": "orange", "glow": "#FFFFFF"},
+		Phases: map[string]map[string]string{"lunch": {}},
+	}, appearance.NoPreference)
+	if err == nil || strings.Count(err.Error(), "\n") != 3 {
+		t.Errorf("Expected four problems, got %v", err)
+	}
+	if themes["focus"] != widgets.DarkTheme {
+		t.Errorf("Expected the dark preset to stand in, got %+v", themes["focus"])
+	}
+
+	for scheme, want := range map[appearance.Scheme]widgets.Theme{
+		appearance.NoPreference: widgets.DarkTheme,
+		appearance.Dark:         widgets.DarkTheme,
+		appearance.Light:        widgets.LightTheme,
+	} {
+		themes, err := themesFromConfig(config.ThemeConfig{Preset: "system"}, scheme)
+		if err != nil || themes["focus"] != want {
+			t.Errorf("Expected the system preset to follow %v, got %+v, %v", scheme, themes["focus"], err)
+		}
+	}
+}
+
+func TestPhaseTheme(t *testing.T) {
+	defer applyTheme(config.ThemeConfig{})
+	if err := applyTheme(config.ThemeConfig{Preset: "dark"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	running := func(label string, remaining time.Duration) focotimer.State {
+		return focotimer.State{Status: focotimer.StatusRunning, Label: label, Remaining: remaining}
+	}
+	focus := phaseTheme(TimerRunning, running("docs", 10*time.Minute))
+	if focus != themes["focus"] {
+		t.Errorf("Expected the focus theme mid-session, got %+v", focus)
+	}
+	brk := phaseTheme(TimerRunning, focotimer.State{Status: focotimer.StatusRunning, Label: "break", Break: true, Remaining: 10 * time.Second})
+	if brk.Ring != widgets.BreakRing || brk.Background == widgets.DarkTheme.Background {
+		t.Errorf("Expected a break in the break ring on a tinted background, got %+v", brk)
+	}
+	if phaseTheme(Break, focotimer.State{}) != brk {
+		t.Error("Expected the break page in the break theme")
+	}
+	half := phaseTheme(TimerRunning, running("docs", 30*time.Second))
+	if half == focus || half == themes["warning"] {
+		t.Errorf("Expected half a minute left to sit between focus and warning, got %+v", half)
+	}
+	if end := phaseTheme(TimerRunning, running("docs", 0)); end.Ring != widgets.WarningRing {
+		t.Errorf("Expected the warning ring as the session ends, got %+v", end.Ring)
+	}
+	paused := focotimer.State{Status: focotimer.StatusPaused, Remaining: 10 * time.Second}
+	if phaseTheme(TimerRunning, paused) != focus {
+		t.Error("Expected a paused session to keep the focus theme")
+	}
+}
+
+func TestBackdropFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bg.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	b, err := backdropFromConfig(config.ThemeConfig{Opacity: 0.8, Image: path, Blur: true})
+	if err != nil || b.opacity != 0.8 || b.image == nil || b.image.Size() != image.Pt(4, 3) || !b.blur {
+		t.Errorf("Expected a see-through, blurred backdrop with the image, got %+v (%v)", b, err)
+	}
+	if b, err := backdropFromConfig(config.ThemeConfig{}); err != nil || b.opacity != 1 || b.image != nil {
+		t.Errorf("Expected an opaque backdrop by default, got %+v (%v)", b, err)
+	}
+	b, err = backdropFromConfig(config.ThemeConfig{Opacity: 2, Image: filepath.Join(t.TempDir(), "missing.png")})
+	if err == nil || !strings.Contains(err.Error(), "opacity") || !strings.Contains(err.Error(), "missing.png") {
+		t.Errorf("Expected the opacity and the image reported, got %v", err)
+	}
+	if b.opacity != 1 || b.image != nil {
+		t.Errorf("Expected the bad settings left out, got %+v", b)
+	}
+}
+
+func TestScript_ThemePreset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("FOCOTIMER_CONFIG", path)
+	defer setSystemScheme(appearance.NoPreference)
+	defer applyTheme(config.ThemeConfig{})
+	applyTheme(config.ThemeConfig{})
+	setSystemScheme(appearance.Light)
+
+	src := `
+assert theme system light
+click theme-dark
+assert theme dark dark
+click theme-system
+assert theme system light
+click theme-light
+`
+	steps, err := parseScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runScript(NewRouter(), steps); err != nil {
+		t.Fatal(err)
+	}
+
+	setSystemScheme(appearance.Dark)
+	if _, used := themeState(); used != "light" {
+		t.Errorf("Expected the picked preset to ignore the desktop, got %s", used)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if cfg.Theme.Preset != "light" {
+		t.Errorf("Expected the picked preset to be saved, got %q", cfg.Theme.Preset)
+	}
+}
+
+func TestCountdownStyleFromConfig(t *testing.T) {
+	f, faces, err := countdownStyleFromConfig(config.CountdownConfig{Font: "JetBrains Mono", MiniSize: 24})
+	if err != nil || faces != nil {
+		t.Fatalf("Expected a family without faces to load, got %v, %v", faces, err)
+	}
+	if f.Typeface != "JetBrains Mono" || f.Size != widgets.DefaultCountdown.Size || f.MiniSize != 24 {
+		t.Errorf("Unexpected font %+v", f)
+	}
+
+	path := filepath.Join(t.TempDir(), "mono.ttf")
+	if err := os.WriteFile(path, gomono.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, faces, err = countdownStyleFromConfig(config.CountdownConfig{Font: path, Size: 64})
+	if err != nil || len(faces) != 1 {
+		t.Fatalf("Expected the font file to load, got %d faces, %v", len(faces), err)
+	}
+	if f.Typeface != faces[0].Font.Typeface || f.Size != 64 {
+		t.Errorf("Expected the family of the file, got %+v", f)
+	}
+
+	if f, _, err := countdownStyleFromConfig(config.CountdownConfig{Ring: "arc"}); err != nil || !f.Arc {
+		t.Errorf("Expected the arc ring, got %+v, %v", f, err)
+	}
+	if _, _, err := countdownStyleFromConfig(config.CountdownConfig{Ring: "donut"}); err == nil {
+		t.Error("Expected an error for an unknown ring")
+	}
+	f, _, err = countdownStyleFromConfig(config.CountdownConfig{RingDiameter: 120, Direction: "counterclockwise", RoundCaps: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := widgets.DefaultRingStyle
+	want.Diameter, want.CounterClockwise, want.RoundCaps = 120, true, true
+	if f.Ring != want {
+		t.Errorf("Expected ring %+v, got %+v", want, f.Ring)
+	}
+	if _, _, err := countdownStyleFromConfig(config.CountdownConfig{RingDiameter: 40, RingThickness: 30}); err == nil {
+		t.Error("Expected an error for a ring thicker than its radius")
+	}
+	if _, _, err := countdownStyleFromConfig(config.CountdownConfig{Direction: "sideways"}); err == nil {
+		t.Error("Expected an error for an unknown direction")
+	}
+	if _, _, err := countdownStyleFromConfig(config.CountdownConfig{Font: filepath.Join(t.TempDir(), "missing.otf")}); err == nil {
+		t.Error("Expected an error for a missing font file")
+	}
+	if err := os.WriteFile(path, []byte("not a font"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := countdownStyleFromConfig(config.CountdownConfig{Font: path}); err == nil {
+		t.Error("Expected an error for a file that is not a font")
+	}
+}
+
+func TestRingGestures(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	defer applyCountdown(config.CountdownConfig{})
+	if err := applyCountdown(config.CountdownConfig{ScrollStep: config.Duration(5 * time.Minute)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tm.Reset()
+	tm.SetDuration(25 * time.Minute)
+
+	scrollDuration(2)
+	if d := tm.Duration(); d != 35*time.Minute {
+		t.Errorf("Expected two notches up to add 10m, got %v", d)
+	}
+	scrollDuration(-10)
+	if d := tm.Duration(); d != 5*time.Minute {
+		t.Errorf("Expected scrolling down to stop at one step, got %v", d)
+	}
+	dragDuration(0.26)
+	if d := tm.Duration(); d != 15*time.Minute {
+		t.Errorf("Expected a quarter turn to set 15m, got %v", d)
+	}
+
+	tm.Start()
+	defer tm.Reset()
+	dragDuration(0.5)
+	if d := tm.Duration(); d != 15*time.Minute {
+		t.Errorf("Expected dragging to leave a running session alone, got %v", d)
+	}
+}
+
+func TestEditDuration(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.SetDuration(10 * time.Second)
+	tm.Reset()
+
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"25:00", 25 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"12", 12 * time.Minute},
+	}
+	for _, tt := range tests {
+		if err := editDuration(tt.text); err != nil {
+			t.Errorf("Unexpected error for %q: %v", tt.text, err)
+		} else if d := tm.Duration(); d != tt.want {
+			t.Errorf("Expected %q to set %v, got %v", tt.text, tt.want, d)
+		}
+	}
+	for _, text := range []string{"", "soon", "0:00", "-5m"} {
+		if err := editDuration(text); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+	if d := tm.Duration(); d != 12*time.Minute {
+		t.Errorf("Expected a rejected edit to keep the duration, got %v", d)
+	}
+
+	tm.Start()
+	defer tm.Reset()
+	if err := editDuration("5m"); err == nil {
+		t.Error("Expected editing a running session to fail")
+	}
+}
+
+func TestParseGeometry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want geometry
+		ok   bool
+	}{
+		{"300x300+20+20", geometry{W: 300, H: 300, X: 20, Y: 20, Placed: true}, true},
+		{"400x250", geometry{W: 400, H: 250}, true},
+		{"-10+0", geometry{X: 10, Right: true, Placed: true}, true},
+		{"300x300-20-20", geometry{W: 300, H: 300, X: 20, Y: 20, Right: true, Bottom: true, Placed: true}, true},
+		{"", geometry{}, false},
+		{"0x300", geometry{}, false},
+		{"300x300+20", geometry{}, false},
+		{"big", geometry{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseGeometry(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseGeometry(%q): Expected %+v (ok %v), got %+v, %v", tt.in, tt.want, tt.ok, got, err)
+		}
+		if tt.ok && got.String() != tt.in {
+			t.Errorf("Expected %q to print as itself, got %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestPlacementFromConfig(t *testing.T) {
+	saved := geometry{W: 320, H: 280, X: 100, Y: 50, Placed: true}
+	path := filepath.Join(t.TempDir(), "window")
+	if err := saveGeometry(path, saved); err != nil {
+		t.Fatalf("Failed to save the geometry: %v", err)
+	}
+	if got := loadGeometry(path); got != saved {
+		t.Errorf("Expected the saved geometry %v, got %v", saved, got)
+	}
+	if got := loadGeometry(filepath.Join(t.TempDir(), "missing")); got != (geometry{}) {
+		t.Errorf("Expected no geometry before the first save, got %v", got)
+	}
+
+	apply := func(placement []geometry) string {
+		g := saved
+		for _, o := range placement {
+			g = g.over(o)
+		}
+		return g.String()
+	}
+	tests := []struct {
+		cfg      config.WindowConfig
+		flagGeom string
+		want     string
+	}{
+		{config.WindowConfig{}, "", "320x280+100+50"},
+		{config.WindowConfig{Corner: "bottom-right", Margin: 16}, "", "320x280-16-16"},
+		{config.WindowConfig{Corner: "top-right", Geometry: "200x200"}, "", "200x200-0+0"},
+		{config.WindowConfig{Corner: "top-right", Geometry: "+5+5"}, "400x400", "400x400+5+5"},
+		{config.WindowConfig{Corner: "middle"}, "bad", "320x280+100+50"},
+	}
+	for _, tt := range tests {
+		if got := apply(placementFromConfig(tt.cfg, tt.flagGeom)); got != tt.want {
+			t.Errorf("%+v, %q: Expected %s, got %s", tt.cfg, tt.flagGeom, tt.want, got)
+		}
+	}
+}
+
+func TestMonitors(t *testing.T) {
+	ms := parseMonitors(`Monitors: 2
+ 0: +*DP-1 2560/597x1440/336+0+0  DP-1
+ 1: +HDMI-1 1920/527x1080/296+2560+0  HDMI-1
+`)
+	if len(ms) != 2 || ms[1] != (monitor{Name: "HDMI-1", X: 2560, W: 1920, H: 1080}) || !ms[0].Primary {
+		t.Fatalf("Expected DP-1 (primary) and HDMI-1, got %+v", ms)
+	}
+	for _, tt := range []struct{ name, want string }{
+		{"primary", "DP-1"}, {"1", "HDMI-1"}, {"HDMI-1", "HDMI-1"}, {"VGA-1", ""},
+	} {
+		m, err := pickMonitor(ms, tt.name)
+		if got := m.Name; got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("%s: Expected %q, got %q (%v)", tt.name, tt.want, got, err)
+		}
+	}
+
+	hdmi := ms[1]
+	placement := placementFromConfig(config.WindowConfig{Corner: "bottom-right", Margin: 10, Monitor: "HDMI-1"}, "")
+	g := geometry{}.over(placement[0])
+	if x, y := g.offset(hdmi, 300, 300); x != 2560+1920-300-10 || y != 1080-300-10 {
+		t.Errorf("Expected the bottom right of HDMI-1, got %d,%d", x, y)
+	}
+	placement = placementFromConfig(config.WindowConfig{Monitor: "HDMI-1"}, "")
+	g = geometry{X: 5, Y: 5, Placed: true}.over(placement[0])
+	if x, y := g.offset(hdmi, 300, 300); g.Monitor != "HDMI-1" || x != 2560+810 || y != 390 {
+		t.Errorf("Expected the middle of HDMI-1, got %d,%d on %q", x, y, g.Monitor)
+	}
+}
+
+func TestRequestClose_Confirm(t *testing.T) {
+	tm := focotimer.GTimerManager
+	defer tm.Reset()
+	m := NewAppManager()
+	if err := m.setCloseAction("minimize"); err == nil {
+		t.Error("Expected an unknown close action to be refused")
+	}
+	if err := m.setCloseAction("confirm"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tm.Reset()
+	m.requestClose()
+	if _, ok := m.confirmingUntil(); ok {
+		t.Error("Expected no confirmation without a session")
+	}
+	tm.Start()
+	m.requestClose()
+	if _, ok := m.confirmingUntil(); !ok {
+		t.Fatal("Expected a running session to ask for confirmation")
+	}
+	m.requestClose()
+	if _, ok := m.confirmingUntil(); ok {
+		t.Error("Expected the second request to close")
+	}
+}
+
+func TestWindowStates(t *testing.T) {
+	if got := strings.Join(windowStates(true, true), ","); got != "ABOVE,STICKY" {
+		t.Errorf("Expected ABOVE,STICKY, got %q", got)
+	}
+	if got := windowStates(false, false); len(got) != 0 {
+		t.Errorf("Expected no states, got %v", got)
+	}
+}
+
+func TestHandleWindowCommands_Headless(t *testing.T) {
+	d := focotimer.NewDispatcher(focotimer.NewTimerManager(time.Minute))
+	handleWindowCommands(d, nil)
+	for _, line := range []string{"gui", "open settings", "close"} {
+		if err := d.Dispatch(line); !errors.Is(err, errHeadless) {
+			t.Errorf("%s: Expected errHeadless, got %v", line, err)
+		}
+	}
+}
+
+func TestFlagSequence(t *testing.T) {
+	names := func(seq config.Sequence) string {
+		var parts []string
+		for _, iv := range seq.Intervals {
+			parts = append(parts, fmt.Sprintf("%s %s", iv.Name, time.Duration(iv.Duration)))
+		}
+		return strings.Join(parts, ", ")
+	}
+	tests := []struct {
+		work, short, long time.Duration
+		want              string
+	}{
+		{25 * time.Minute, 0, 0, ""},
+		{25 * time.Minute, 5 * time.Minute, 0, "work 25m0s, break 5m0s"},
+		{50 * time.Minute, 10 * time.Minute, 30 * time.Minute,
+			"work 50m0s, break 10m0s, work 50m0s, break 10m0s, work 50m0s, break 10m0s, work 50m0s, long break 30m0s"},
+		{25 * time.Minute, 0, 15 * time.Minute,
+			"work 25m0s, break 5m0s, work 25m0s, break 5m0s, work 25m0s, break 5m0s, work 25m0s, long break 15m0s"},
+	}
+	for _, tt := range tests {
+		seq, ok := flagSequence(tt.work, tt.short, tt.long)
+		if ok != (tt.want != "") {
+			t.Errorf("%v %v %v: Expected ok %v, got %v", tt.work, tt.short, tt.long, tt.want != "", ok)
+		}
+		if got := names(seq); got != tt.want {
+			t.Errorf("%v %v %v: Expected %q, got %q", tt.work, tt.short, tt.long, tt.want, got)
+		}
+	}
+}
+
+func TestSpokenDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                  "0 seconds",
+		-time.Second:                       "0 seconds",
+		time.Second + 900*time.Millisecond: "1 second",
+		12*time.Minute + 30*time.Second:    "12 minutes 30 seconds",
+		time.Hour + 5*time.Minute:          "1 hour 5 minutes",
+	} {
+		if got := widgets.SpokenDuration(d); got != want {
+			t.Errorf("SpokenDuration(%v) = %q, expected %q", d, got, want)
+		}
+	}
+	for p := range pageNames {
+		if p != Splash && pageTitles[p] == "" {
+			t.Errorf("Expected a title for screen readers on page %v", p)
+		}
+	}
+}
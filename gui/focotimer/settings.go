@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/d093w1z/focotimer/config"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/safefile"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- SETTINGS PAGE ----------------
+type settingsPage struct {
+	r *Router
+
+	back        *widgets.ButtonState
+	bind        map[string]*widget.Clickable
+	hotkey      map[string]*widget.Clickable
+	themePreset map[string]*widget.Clickable
+	corners     map[string]*widget.Clickable
+
+	// the window's place as configured, and the monitors to choose from,
+	// "auto" first, read as the page opens
+	mu       sync.Mutex
+	place    config.WindowConfig
+	monitors []string
+	monitor  map[string]*widget.Clickable
+}
+
+func newSettingsPage(r *Router) *settingsPage {
+	return &settingsPage{
+		r:           r,
+		back:        widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		bind:        clickables(bindableActions),
+		hotkey:      clickables(globalActions),
+		themePreset: clickables(themePresets),
+		corners:     clickables(windowCorners),
+		monitor:     clickables([]string{"auto"}),
+	}
+}
+
+// load reads the window's place from the config and lists the monitors.
+func (p *settingsPage) load() {
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil && !errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("settings: %v", err)
+	}
+	monitors := []string{"auto"}
+	if ms, err := listMonitors(); err == nil {
+		for _, m := range ms {
+			monitors = append(monitors, m.Name)
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	btns := make(map[string]*widget.Clickable, len(monitors))
+	for _, name := range monitors {
+		if btns[name] = p.monitor[name]; btns[name] == nil {
+			btns[name] = new(widget.Clickable)
+		}
+	}
+	p.place, p.monitors, p.monitor = cfg.Window, monitors, btns
+}
+
+// placeState returns the corner and the monitor chosen, "none" and
+// "auto" when there are none, and the monitors to choose from with their
+// buttons.
+func (p *settingsPage) placeState() (corner, monitor string, monitors []string, btns map[string]*widget.Clickable) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	corner, monitor = p.place.Corner, p.place.Monitor
+	if corner == "" {
+		corner = "none"
+	}
+	if monitor == "" {
+		monitor = "auto"
+	}
+	return corner, monitor, p.monitors, p.monitor
+}
+
+// pickCorner pins the window to the corner picked in Settings.
+func (p *settingsPage) pickCorner(corner string) {
+	if corner == "none" {
+		corner = ""
+	}
+	p.savePlace(func(w *config.WindowConfig) { w.Corner = corner })
+}
+
+// pickMonitor moves the window and the break overlay to the monitor
+// picked in Settings.
+func (p *settingsPage) pickMonitor(monitor string) {
+	if monitor == "auto" {
+		monitor = ""
+	}
+	p.savePlace(func(w *config.WindowConfig) { w.Monitor = monitor })
+}
+
+// savePlace changes the window's place in the config; the config watch
+// moves the window.
+func (p *settingsPage) savePlace(change func(*config.WindowConfig)) {
+	path := config.DefaultPath()
+	cfg, err := config.Load(path)
+	if errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("settings: %v", err)
+	} else if err != nil {
+		log.Printf("settings: %v", err)
+		return
+	}
+	change(&cfg.Window)
+	p.mu.Lock()
+	p.place = cfg.Window
+	p.mu.Unlock()
+	if err := config.Save(path, cfg); err != nil {
+		log.Printf("settings: %v", err)
+	}
+}
+
+func (p *settingsPage) Layout(th *material.Theme, gtx C) D {
+	capture, problem := bindingState()
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			l := material.Body1(th, text)
+			l.Color = widgets.Colors().Accent
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, l.Layout)
+		})
+	}
+	rows := []layout.FlexChild{heading("KEY BINDINGS")}
+	for _, action := range bindableActions {
+		capturing := capture.action == action && !capture.global
+		rows = append(rows, widgets.BindingRow(th, action, actionKeys(action), capturing, p.bind[action], func() {
+			startCapture(action, false)
+		}))
+	}
+	rows = append(rows, heading("GLOBAL HOTKEYS"))
+	for _, action := range globalActions {
+		capturing := capture.action == action && capture.global
+		rows = append(rows, widgets.BindingRow(th, action, hotkeyKeys(action), capturing, p.hotkey[action], func() {
+			startCapture(action, true)
+		}))
+	}
+	chosen, _ := themeState()
+	corner, monitor, monitors, monitorBtns := p.placeState()
+	rows = append(rows,
+		heading("THEME"),
+		widgets.Choices(th, themePresets, chosen, p.themePreset, setThemePreset),
+		heading("WINDOW"),
+		widgets.Choices(th, windowCorners, corner, p.corners, p.pickCorner),
+	)
+	if len(monitors) > 1 {
+		rows = append(rows, widgets.Choices(th, monitors, monitor, monitorBtns, p.pickMonitor))
+	}
+	rows = append(rows,
+		layout.Rigid(func(gtx C) D {
+			if problem == "" {
+				return D{}
+			}
+			l := material.Caption(th, problem)
+			l.Color = widgets.Colors().Alert
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, l.Layout)
+		}),
+		p.back.Layout(th, p.r.goBack),
+	)
+	return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx, rows...)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- STATS PAGE ----------------
+// The stats page charts the focused time and the completed pomodoros of
+// the last days, or of the last weeks.
+
+const (
+	statsDays  = 7
+	statsWeeks = 8
+)
+
+type statsPage struct {
+	r *Router
+
+	mu      sync.Mutex
+	records []history.Record
+	byWeek  bool
+
+	daily, back, weekly *widgets.ButtonState
+}
+
+func newStatsPage(r *Router) *statsPage {
+	return &statsPage{
+		r:      r,
+		daily:  widgets.NewButton("Daily", icons.ActionViewDay, 10),
+		back:   widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		weekly: widgets.NewButton("Weekly", icons.ActionViewWeek, 10),
+	}
+}
+
+// openStats shows the stats by day, reading the history afresh.
+func (r *Router) openStats() {
+	records, err := history.Load(history.DefaultPath())
+	if err != nil {
+		log.Printf("stats: %v", err)
+	}
+	r.stats.mu.Lock()
+	r.stats.records, r.stats.byWeek = records, false
+	r.stats.mu.Unlock()
+	r.Show(Stats)
+}
+
+func (p *statsPage) showDaily()  { p.setWeekly(false) }
+func (p *statsPage) showWeekly() { p.setWeekly(true) }
+
+func (p *statsPage) setWeekly(weekly bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byWeek = weekly
+}
+
+// tallies returns the period shown, "daily" or "weekly", and its tallies
+// up to now.
+func (p *statsPage) tallies(now time.Time) (string, []history.Tally) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byWeek {
+		return "weekly", history.WeeklyTallies(p.records, now, statsWeeks)
+	}
+	return "daily", history.DailyTallies(p.records, now, statsDays)
+}
+
+// summary reads the period shown and its pomodoros, oldest first, e.g.
+// "daily 0 0 0 0 0 2 1".
+func (p *statsPage) summary() string {
+	period, tallies := p.tallies(time.Now())
+	parts := []string{period}
+	for _, t := range tallies {
+		parts = append(parts, strconv.Itoa(t.Pomodoros))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *statsPage) Layout(th *material.Theme, gtx C) D {
+	period, tallies := p.tallies(time.Now())
+	focus := make([]widgets.Bar, len(tallies))
+	pomodoros := make([]widgets.Bar, len(tallies))
+	for i, t := range tallies {
+		label := t.From.Format("Mon")
+		if period == "weekly" {
+			label = t.From.Format("2 Jan")
+		}
+		focus[i] = widgets.Bar{Value: t.Focus.Minutes(), Label: label, Text: focusText(t.Focus)}
+		pomodoros[i] = widgets.Bar{Value: float64(t.Pomodoros), Label: label, Text: strconv.Itoa(t.Pomodoros)}
+	}
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			widgets.BarChart(th, "FOCUSED TIME", focus, widgets.KindColors[history.Focus]),
+			widgets.BarChart(th, "POMODOROS", pomodoros, widgets.KindColors[history.Break]),
+			layout.Rigid(func(gtx C) D {
+				inset := layout.UniformInset(unit.Dp(8))
+				return inset.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						p.daily.Layout(th, p.showDaily),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+						p.back.Layout(th, p.r.goBack),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+						p.weekly.Layout(th, p.showWeekly),
+					)
+				})
+			}),
+		)
+	})
+}
+
+// focusText reads "1h35m", "25m" or "" for no focus at all.
+func focusText(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d == 0:
+		return ""
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", d/time.Hour, (d%time.Hour)/time.Minute)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/d093w1z/focotimer/config"
+	"github.com/d093w1z/focotimer/gui/focotimer/polybar"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/focotimer/ipc"
+	"github.com/d093w1z/focotimer/safefile"
+	"github.com/d093w1z/focotimer/tui"
+)
+
+// ---------------- SUBCOMMANDS ----------------
+// "focotimer <subcommand>" talks to a running focotimer or reads the
+// history instead of starting one.
+
+// send implements "focotimer send [-pipe <fifo>] <command...>", which bar
+// click actions run to deliver a command to the polybar FIFO.
+func send(args []string) int {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	pipe := fs.String("pipe", "", "Command FIFO of the running focotimer; defaults to the one it advertised")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: focotimer send [-pipe <fifo>] <command> [args]")
+		return 2
+	}
+	if *pipe == "" {
+		path, err := polybar.Discover()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "focotimer send: %v\n", err)
+			return 1
+		}
+		*pipe = path
+	}
+	words := make([]string, fs.NArg())
+	for i, w := range fs.Args() {
+		words[i] = polybar.DecodeArg(w)
+	}
+	if err := polybar.Send(polybar.DecodeArg(*pipe), strings.Join(words, " ")); err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer send: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// listen implements "focotimer listen [-pipe <fifo>]", which prints the
+// bar output of the running focotimer through a pipe of its own, so every
+// bar, e.g. one per monitor, can run it.
+func listen(args []string) int {
+	fs := flag.NewFlagSet("listen", flag.ContinueOnError)
+	pipe := fs.String("pipe", "", "Command FIFO of the running focotimer; defaults to the one it advertised")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: focotimer listen [-pipe <fifo>]")
+		return 2
+	}
+	if *pipe == "" {
+		path, err := polybar.Discover()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "focotimer listen: %v\n", err)
+			return 1
+		}
+		*pipe = path
+	}
+
+	stop := make(chan struct{})
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	go func() {
+		<-sigc
+		close(stop)
+	}()
+	if err := polybar.Listen(*pipe, os.Stdout, stop); err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer listen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// open implements "focotimer open <page>", which asks the running
+// focotimer to show the page, e.g. "stats" or "focotimer://settings/keys".
+func open(args []string) int {
+	fs := flag.NewFlagSet("open", flag.ContinueOnError)
+	socket := fs.String("socket", ipc.DefaultSocket(), "Control socket of the running focotimer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: focotimer open [-socket path] <page>")
+		return 2
+	}
+	if _, err := parseLink(fs.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer open: %v\n", err)
+		return 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := ipc.NewClient(*socket).Command(ctx, "open "+fs.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer open: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// block implements "focotimer block [-format i3blocks|i3status-rs]", an
+// i3blocks or i3status-rust block for bars without polybar. A click
+// (BLOCK_BUTTON, set by i3blocks) runs its configured command first; an
+// i3blocks block exits 33, urgent, in the final stretch of a session.
+func block(args []string) int {
+	fs := flag.NewFlagSet("block", flag.ContinueOnError)
+	format := fs.String("format", string(polybar.FormatI3Blocks), "Block format: i3blocks or i3status-rs")
+	socket := fs.String("socket", ipc.DefaultSocket(), "Control socket of the running focotimer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	f := polybar.Format(*format)
+	if f != polybar.FormatI3Blocks && f != polybar.FormatI3Status {
+		fmt.Fprintf(os.Stderr, "focotimer block: unknown format %q\n", *format)
+		return 2
+	}
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil && !errors.Is(err, safefile.ErrRecovered) {
+		fmt.Fprintf(os.Stderr, "focotimer block: %v\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := ipc.NewClient(*socket)
+	if n, err := strconv.Atoi(os.Getenv("BLOCK_BUTTON")); err == nil {
+		clicks := clicksFromConfig(cfg.Polybar)
+		if clicks == nil {
+			clicks = polybar.DefaultClicks
+		}
+		if cmd := clicks[polybar.MouseButton(n)]; cmd != "" {
+			if _, err := client.Command(ctx, cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "focotimer block: %v\n", err)
+			}
+		}
+	}
+	s, err := client.Status(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer block: %v\n", err)
+		return 1
+	}
+
+	fit := fitFromConfig(cfg.Polybar)
+	if f == polybar.FormatI3Status {
+		fmt.Println(polybar.I3StatusBlock(s, polybar.Activity(s), fit))
+		return 0
+	}
+	out, urgent := polybar.I3Block(s, polybar.Activity(s), colorsFromConfig(cfg.Polybar), fit)
+	fmt.Print(out)
+	if urgent {
+		return 33
+	}
+	return 0
+}
+
+// stats implements "focotimer stats [--tui] [-period weekly|monthly]",
+// which prints the focus history or, with --tui, browses it in an
+// interactive terminal dashboard.
+func stats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	interactive := fs.Bool("tui", false, "Browse the statistics in an interactive dashboard")
+	period := fs.String("period", string(history.Weekly), "Period to show: weekly or monthly")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer stats: %v\n", err)
+	}
+	v := tui.View{
+		Period: history.Period(*period),
+		Goal:   history.Goal{Daily: cfg.Goal.Daily, RequireBreaks: cfg.Goal.RequireBreaks},
+	}
+	if _, _, err := v.Bounds(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer stats: %v\n", err)
+		return 2
+	}
+	records, err := history.Load(history.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer stats: %v\n", err)
+		if !errors.Is(err, safefile.ErrDamaged) {
+			return 1
+		}
+	}
+	if *interactive {
+		err = tui.Run(os.Stdin, os.Stdout, records, v)
+	} else {
+		err = tui.Render(os.Stdout, records, v, time.Now(), 80)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "focotimer stats: %v\n", err)
+		return 1
+	}
+	return 0
+}
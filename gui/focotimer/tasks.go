@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- TASKS PAGE ----------------
+// The tasks page keeps a list of what the user works on, with the number
+// of pomodoros each got so far. Picking a task names the next sessions
+// after it, so their records count towards it.
+
+type tasksPage struct {
+	r *Router
+
+	mu     sync.Mutex
+	tasks  history.Tasks
+	counts map[string]int
+
+	add, back *widgets.ButtonState
+	editor    widget.Editor
+	// buttons are the pick and remove buttons of each task.
+	buttons map[string]*[2]widget.Clickable
+}
+
+func newTasksPage(r *Router) *tasksPage {
+	return &tasksPage{
+		r:       r,
+		add:     widgets.NewButton("Add task", icons.ContentAdd, 5),
+		back:    widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		buttons: make(map[string]*[2]widget.Clickable),
+	}
+}
+
+// openTasks shows the task list, reading it and the history afresh.
+func (r *Router) openTasks() {
+	list, err := history.LoadTasks(history.DefaultTasksPath())
+	if err != nil {
+		log.Printf("tasks: %v", err)
+	}
+	records, err := history.Load(history.DefaultPath())
+	if err != nil {
+		log.Printf("tasks: %v", err)
+	}
+	r.tasks.mu.Lock()
+	r.tasks.tasks, r.tasks.counts = list, history.Pomodoros(records)
+	r.tasks.mu.Unlock()
+	r.Show(Tasks)
+}
+
+// change applies change to the task list and saves it.
+func (p *tasksPage) change(change func(*history.Tasks) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !change(&p.tasks) {
+		return
+	}
+	if err := history.SaveTasks(history.DefaultTasksPath(), p.tasks); err != nil {
+		log.Printf("tasks: %v", err)
+	}
+}
+
+func (p *tasksPage) addTask(name string) {
+	p.change(func(t *history.Tasks) bool { return t.Add(name) })
+}
+
+func (p *tasksPage) removeTask(name string) {
+	p.change(func(t *history.Tasks) bool { t.Remove(name); return true })
+}
+
+// pickTask makes name the active task and the name of the next sessions;
+// like the task entry, not while a session runs or is paused.
+func (p *tasksPage) pickTask(name string) {
+	if !taskEditable() {
+		return
+	}
+	p.change(func(t *history.Tasks) bool { return t.Pick(name) })
+	setTask(name)
+}
+
+// restoreActiveTask names the first session after the task picked last
+// time, unless it already has a name.
+func restoreActiveTask() {
+	list, err := history.LoadTasks(history.DefaultTasksPath())
+	if err != nil {
+		log.Printf("tasks: %v", err)
+	}
+	if list.Active != "" && focotimer.GTimerManager.Label() == "" {
+		setTask(list.Active)
+	}
+}
+
+// summary lists the tasks with their pomodoros, the active one starred,
+// e.g. "*docs (2), review (0)".
+func (p *tasksPage) summary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	parts := make([]string, len(p.tasks.Names))
+	for i, name := range p.tasks.Names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, p.counts[name])
+		if name == p.tasks.Active {
+			parts[i] = "*" + parts[i]
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *tasksPage) Layout(th *material.Theme, gtx C) D {
+	for {
+		ev, ok := p.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			p.addNewTask()
+		}
+	}
+	p.mu.Lock()
+	list, counts := p.tasks, p.counts
+	p.mu.Unlock()
+
+	rows := []layout.FlexChild{layout.Rigid(func(gtx C) D {
+		l := material.Body1(th, "TASKS")
+		l.Color = widgets.Colors().Accent
+		return layout.UniformInset(unit.Dp(8)).Layout(gtx, l.Layout)
+	})}
+	for _, name := range list.Names {
+		btns, ok := p.buttons[name]
+		if !ok {
+			btns = new([2]widget.Clickable)
+			p.buttons[name] = btns
+		}
+		rows = append(rows, widgets.TaskRow(th, name, counts[name], name == list.Active, &btns[0], &btns[1],
+			func() { p.pickTask(name) }, func() { p.removeTask(name) }))
+	}
+	rows = append(rows,
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx C) D {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						p.editor.SingleLine, p.editor.Submit = true, true
+						gtx.Constraints.Min.X = gtx.Dp(unit.Dp(200))
+						gtx.Constraints.Max.X = gtx.Constraints.Min.X
+						return material.Editor(th, &p.editor, "New task").Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					p.add.Layout(th, p.addNewTask),
+				)
+			})
+		}),
+		p.back.Layout(th, p.r.goBack),
+	)
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx, rows...)
+	})
+}
+
+// Typing covers the new task being typed.
+func (p *tasksPage) Typing(gtx C) bool { return gtx.Source.Focused(&p.editor) }
+
+// addNewTask adds the task typed into the page and clears the field.
+func (p *tasksPage) addNewTask() {
+	p.addTask(p.editor.Text())
+	p.editor.SetText("")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/appearance"
+	"github.com/d093w1z/focotimer/config"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/safefile"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op/paint"
+	"github.com/d093w1z/gio/widget"
+)
+
+// ---------------- THEME ----------------
+// The window is drawn in a theme built from the config: a preset, colors
+// overriding it, and colors overriding those while a focus session or a
+// break is on screen. Breaks lean to a calm teal, and over the last minute
+// of a focus session the window shifts toward the warning colors. The
+// "system" preset, the default, follows the desktop's dark or light
+// preference; Settings can pin one instead. Under every phase the window
+// is created transparent, so the background can be see-through, show an
+// image or blur what is behind it.
+
+// themePhases are the phases the config can color apart.
+var themePhases = []string{"focus", "break", "warning"}
+
+// warningLead is how long before a focus session ends the window starts
+// shifting toward the warning theme.
+const warningLead = time.Minute
+
+// phaseTint is how far the background of a break or a warning leans to
+// its tint.
+const phaseTint = 0.15
+
+// themePresets are the choices offered in Settings.
+var themePresets = []string{"system", "dark", "light"}
+
+var (
+	themesMu      sync.RWMutex
+	themeCfg      config.ThemeConfig
+	systemScheme  appearance.Scheme
+	themes        = mustThemes(config.ThemeConfig{}, appearance.NoPreference)
+	themeBackdrop = backdrop{opacity: 1}
+)
+
+// backdrop is what the window draws its pages on besides the background
+// color.
+type backdrop struct {
+	opacity float32
+	image   *paint.ImageOp
+	blur    bool
+}
+
+// backdropFromConfig reads the opacity and the image of cfg. A problem is
+// reported and leaves that part out.
+func backdropFromConfig(cfg config.ThemeConfig) (backdrop, error) {
+	var errs []error
+	b := backdrop{opacity: 1, blur: cfg.Blur}
+	switch {
+	case cfg.Opacity < 0 || cfg.Opacity > 1:
+		errs = append(errs, fmt.Errorf("opacity %v out of range, expected 0 to 1", cfg.Opacity))
+	case cfg.Opacity > 0:
+		b.opacity = cfg.Opacity
+	}
+	if cfg.Image != "" {
+		img, err := loadImage(cfg.Image)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			op := paint.NewImageOp(img)
+			b.image = &op
+		}
+	}
+	return b, errors.Join(errs...)
+}
+
+// loadImage decodes a PNG or JPEG file.
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("image: %w", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("image %q: %w", path, err)
+	}
+	return img, nil
+}
+
+// presetName resolves the preset of cfg; "system" and none pick light or
+// dark as the desktop prefers, dark when it has no preference.
+func presetName(cfg config.ThemeConfig, scheme appearance.Scheme) string {
+	switch cfg.Preset {
+	case "", "system":
+		if scheme == appearance.Light {
+			return "light"
+		}
+		return "dark"
+	}
+	return cfg.Preset
+}
+
+// themesFromConfig builds the theme of each phase. Breaks and warnings
+// start from the preset with their ring and background tint. Every problem
+// is reported; the colors that are valid still apply.
+func themesFromConfig(cfg config.ThemeConfig, scheme appearance.Scheme) (map[string]widgets.Theme, error) {
+	var errs []error
+	name := presetName(cfg, scheme)
+	preset, ok := widgets.Themes[name]
+	if !ok {
+		errs = append(errs, fmt.Errorf("unknown preset %q, expected system, dark or light", name))
+		preset = widgets.DarkTheme
+	}
+	for phase := range cfg.Phases {
+		if !slices.Contains(themePhases, phase) {
+			errs = append(errs, fmt.Errorf("unknown phase %q, expected focus, break or warning", phase))
+		}
+	}
+
+	out := make(map[string]widgets.Theme, len(themePhases))
+	for _, phase := range themePhases {
+		t := preset
+		switch phase {
+		case "break":
+			t.Ring = widgets.BreakRing
+			t = t.Tint(widgets.BreakTint, phaseTint)
+		case "warning":
+			t.Ring = widgets.WarningRing
+			t = t.Tint(widgets.WarningTint, phaseTint)
+		}
+		for _, name := range sortedNames(cfg.Colors) {
+			// report a bad color once, not once per phase
+			if err := t.Set(name, cfg.Colors[name]); err != nil && phase == "focus" {
+				errs = append(errs, err)
+			}
+		}
+		for _, name := range sortedNames(cfg.Phases[phase]) {
+			if err := t.Set(name, cfg.Phases[phase][name]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", phase, err))
+			}
+		}
+		out[phase] = t
+	}
+	return out, errors.Join(errs...)
+}
+
+func mustThemes(cfg config.ThemeConfig, scheme appearance.Scheme) map[string]widgets.Theme {
+	t, err := themesFromConfig(cfg, scheme)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyTheme switches the window to the themes and the backdrop in cfg.
+func applyTheme(cfg config.ThemeConfig) error {
+	b, berr := backdropFromConfig(cfg)
+	themesMu.Lock()
+	t, err := themesFromConfig(cfg, systemScheme)
+	themeCfg, themes, themeBackdrop = cfg, t, b
+	themesMu.Unlock()
+	redraw()
+	return errors.Join(err, berr)
+}
+
+// drawBackground paints the background in the current clip: bg, then the
+// image covering it, at the configured opacity.
+func drawBackground(gtx C, bg color.NRGBA) {
+	themesMu.RLock()
+	b := themeBackdrop
+	themesMu.RUnlock()
+	defer paint.PushOpacity(gtx.Ops, b.opacity).Pop()
+	paint.Fill(gtx.Ops, bg)
+	if b.image != nil {
+		gtx.Constraints.Min = gtx.Constraints.Max
+		widget.Image{Src: *b.image, Fit: widget.Cover, Position: layout.Center}.Layout(gtx)
+	}
+}
+
+// blurRequested tells whether the config asks to blur behind the window.
+func blurRequested() bool {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	return themeBackdrop.blur
+}
+
+// setSystemScheme follows a change of the desktop's preference.
+func setSystemScheme(s appearance.Scheme) {
+	themesMu.Lock()
+	systemScheme = s
+	// the config was reported when it was applied
+	themes, _ = themesFromConfig(themeCfg, s)
+	themesMu.Unlock()
+	redraw()
+}
+
+// themeState returns the preset chosen in the config, "system" when none
+// is, and the one in use.
+func themeState() (chosen, used string) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	chosen = themeCfg.Preset
+	if chosen == "" {
+		chosen = "system"
+	}
+	return chosen, presetName(themeCfg, systemScheme)
+}
+
+// setThemePreset switches to the preset picked in Settings and saves it.
+func setThemePreset(name string) {
+	path := config.DefaultPath()
+	cfg, err := config.Load(path)
+	if errors.Is(err, safefile.ErrRecovered) {
+		log.Printf("theme: %v", err)
+	} else if err != nil {
+		log.Printf("theme: %v", err)
+		return
+	}
+	cfg.Theme.Preset = name
+	if err := applyTheme(cfg.Theme); err != nil {
+		log.Printf("config: theme: %v", err)
+	}
+	if err := config.Save(path, cfg); err != nil {
+		log.Printf("theme: %v", err)
+	}
+}
+
+// phaseTheme returns the theme of the phase on screen: the break theme on
+// the break page or while s is a break, and over the last warningLead of a running focus session the focus
+// theme shifting toward the warning one.
+func phaseTheme(p Page, s focotimer.State) widgets.Theme {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	if p == Break || s.Break {
+		return themes["break"]
+	}
+	if s.Status != focotimer.StatusRunning || s.Remaining > warningLead {
+		return themes["focus"]
+	}
+	f := 1 - float32(s.Remaining)/float32(warningLead)
+	return themes["focus"].Blend(themes["warning"], f)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- TIMELINE PAGE ----------------
+type timelinePage struct {
+	r *Router
+
+	mu      sync.Mutex
+	day     time.Time
+	records []history.Record
+
+	prev, back, next *widgets.ButtonState
+}
+
+func newTimelinePage(r *Router) *timelinePage {
+	return &timelinePage{
+		r:    r,
+		prev: widgets.NewButton("Previous day", icons.NavigationChevronLeft, 10),
+		back: widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		next: widgets.NewButton("Next day", icons.NavigationChevronRight, 10),
+	}
+}
+
+// load reads the history afresh and shows day, but not past today.
+func (p *timelinePage) load(day time.Time) {
+	records, err := history.Load(history.DefaultPath())
+	if err != nil {
+		log.Printf("timeline: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records, p.day = records, notAfterToday(history.Day(day))
+}
+
+// shift moves the timeline by days, but not past today.
+func (p *timelinePage) shift(days int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.day = notAfterToday(p.day.AddDate(0, 0, days))
+}
+
+func notAfterToday(day time.Time) time.Time {
+	if today := history.Day(time.Now()); day.After(today) {
+		return today
+	}
+	return day
+}
+
+func (p *timelinePage) prevDay() { p.shift(-1) }
+func (p *timelinePage) nextDay() { p.shift(1) }
+
+// blocks returns the day shown and its sessions.
+func (p *timelinePage) blocks() (time.Time, []history.Block) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.day, history.Timeline(p.records, p.day)
+}
+
+func (p *timelinePage) Layout(th *material.Theme, gtx C) D {
+	day, blocks := p.blocks()
+	return layout.Center.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			widgets.Timeline(th, day, blocks),
+			layout.Rigid(func(gtx C) D {
+				inset := layout.UniformInset(unit.Dp(8))
+				return inset.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						p.prev.Layout(th, p.prevDay),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+						p.back.Layout(th, p.r.goBack),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+						p.next.Layout(th, p.nextDay),
+					)
+				})
+			}),
+		)
+	})
+}
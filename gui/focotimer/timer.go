@@ -0,0 +1,155 @@
+package main
+
+import (
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	widgets "github.com/d093w1z/focotimer/gui/focotimer/widgets"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// ---------------- TIMER PAGE ----------------
+type timerPage struct {
+	r *Router
+
+	startStop, pause, stop, increase, decrease *widgets.ButtonState
+	back, settings, timeline, insights, tasks  *widgets.ButtonState
+	stats                                      *widgets.ButtonState
+	extend, takeBreak, newSession              *widgets.ButtonState
+	energy                                     [5]widget.Clickable
+	view                                       *widgets.TimerView
+	ring                                       focotimer.SmoothProgress
+	// task names what the session is for; the name becomes the label
+	// of the session and of its record in the history.
+	task widget.Editor
+
+	// dots counts the pomodoros completed since the last long break, as
+	// shown under the countdown
+	dotsMu sync.Mutex
+	dots   int
+}
+
+func newTimerPage(r *Router) *timerPage {
+	return &timerPage{
+		r:         r,
+		startStop: widgets.NewButton("Start timer", icons.AVPlayArrow, 10),
+		pause:     widgets.NewButton("Pause timer", icons.AVPause, 10),
+		stop:      widgets.NewButton("Stop timer", icons.AVStop, 10),
+		increase:  widgets.NewButton("Increase duration", icons.ContentAdd, 5),
+		decrease:  widgets.NewButton("Decrease duration", icons.ContentRemove, 5),
+		back:      widgets.NewButton("Back", icons.NavigationArrowBack, 10),
+		settings:  widgets.NewButton("Settings", icons.ActionSettings, 10),
+		timeline:  widgets.NewButton("Timeline", icons.ActionTimeline, 10),
+		insights:  widgets.NewButton("Insights", icons.ActionTrendingUp, 10),
+		tasks:     widgets.NewButton("Tasks", icons.ActionList, 10),
+		stats:     widgets.NewButton("Statistics", icons.ActionAssessment, 10),
+		// the finished session's next steps
+		extend:     widgets.NewButton("Extend by 5 minutes", icons.AVSnooze, 10),
+		takeBreak:  widgets.NewButton("Start break", icons.MapsLocalCafe, 10),
+		newSession: widgets.NewButton("New session", icons.AVReplay, 10),
+		view:       newTimerView(),
+	}
+}
+
+func (p *timerPage) Layout(th *material.Theme, gtx C) D {
+	r := p.r
+	// the main button pauses a running session and plays otherwise
+	p.startStop.Label = "Start timer"
+	status := focotimer.GTimerManager.State().Status
+	if status == focotimer.StatusPaused {
+		p.startStop.Label = "Resume timer"
+	}
+	playPause := p.startStop.Widget(th, r.togglePlayPause)
+	if status == focotimer.StatusRunning {
+		playPause = p.pause.Widget(th, pause)
+	}
+	timer := p.view.Layout(th, focotimer.GTimerManager.Activity(), getLastRemaining(), ringProgress(gtx, &p.ring), widgets.Colors().Ring, p.completedDots())
+	controls := []layout.Widget{
+		p.decrease.Widget(th, decrease),
+		playPause,
+		p.stop.Widget(th, r.stopSession),
+		p.increase.Widget(th, increase),
+	}
+	// a finished session leads on rather than back to the start
+	if r.Current() == TimerFinished {
+		controls = []layout.Widget{
+			p.extend.Widget(th, r.extendSession),
+			p.takeBreak.Widget(th, r.startBreak),
+			p.newSession.Widget(th, r.startSession),
+		}
+	}
+	buttons := func(row ...layout.Widget) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, widgets.Wrap(10, row...))
+		})
+	}
+
+	// a narrow window keeps to the timer and its controls
+	if widgets.Mini(gtx) {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			timer,
+			buttons(controls...),
+			p.energyPrompt(th),
+		)
+	}
+	all := append([]layout.Widget{p.back.Widget(th, r.goBack)}, controls...)
+	all = append(all,
+		p.settings.Widget(th, r.openSettings),
+		p.timeline.Widget(th, r.openTimeline),
+		p.insights.Widget(th, r.openInsights),
+		p.tasks.Widget(th, r.openTasks),
+		p.stats.Widget(th, r.openStats),
+	)
+	return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+		timer,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+		widgets.TaskEntry(th, &p.task, focotimer.GTimerManager.Label(), taskEditable(), setTask),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+		buttons(all...),
+		p.energyPrompt(th),
+	)
+}
+
+// completedDots is the number of dots filled under the countdown.
+func (p *timerPage) completedDots() int {
+	p.dotsMu.Lock()
+	defer p.dotsMu.Unlock()
+	return p.dots
+}
+
+// countDot fills the next dot for a completed focus session, starting over
+// once all are filled, and clears them after a long break.
+func (p *timerPage) countDot(ev focotimer.Event) {
+	p.dotsMu.Lock()
+	defer p.dotsMu.Unlock()
+	switch {
+	case ev.Break && history.IsLongBreak(ev.Label):
+		p.dots = 0
+	case !ev.Break:
+		p.dots = p.dots%widgets.DotsPerCycle + 1
+	}
+}
+
+// Typing covers the task and the countdown being edited.
+func (p *timerPage) Typing(gtx C) bool {
+	return gtx.Source.Focused(&p.task) || p.view.Typing(gtx)
+}
+
+// energyPrompt asks how the user feels before a session and after it; it
+// is hidden while one runs.
+func (p *timerPage) energyPrompt(th *material.Theme) layout.FlexChild {
+	s := focotimer.GTimerManager.State()
+	switch {
+	case s.Status == focotimer.StatusCompleted:
+		return widgets.EnergyPrompt(th, "ENERGY NOW", s.EnergyEnd, &p.energy, rateEnergy)
+	case p.r.Current() == TimerRunning:
+		return layout.Rigid(func(gtx C) D { return D{} })
+	}
+	return widgets.EnergyPrompt(th, "ENERGY", s.EnergyStart, &p.energy, rateEnergy)
+}
@@ -0,0 +1,196 @@
+package tray
+
+import (
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+// ------------------- StatusNotifierItem -------------------
+
+// prop is a property value: its signature and how to write it.
+type prop struct {
+	sig   string
+	write func(e *dbus.Encoder)
+}
+
+func stringProp(s string) prop {
+	return prop{"s", func(e *dbus.Encoder) { e.String(s) }}
+}
+
+func pathProp(p string) prop {
+	return prop{"o", func(e *dbus.Encoder) { e.String(p) }}
+}
+
+func boolProp(b bool) prop {
+	return prop{"b", func(e *dbus.Encoder) { e.Bool(b) }}
+}
+
+func int32Prop(v int32) prop {
+	return prop{"i", func(e *dbus.Encoder) { e.Int32(v) }}
+}
+
+func uint32Prop(v uint32) prop {
+	return prop{"u", func(e *dbus.Encoder) { e.Uint32(v) }}
+}
+
+// emptyProp is an empty array of type sig, e.g. no icon pixmaps.
+func emptyProp(sig string, elemAlign int) prop {
+	return prop{sig, func(e *dbus.Encoder) { e.Array(elemAlign, func() {}) }}
+}
+
+// writeProps writes props as an a{sv}, only those in names when it is
+// not empty.
+func writeProps(e *dbus.Encoder, props map[string]prop, names []string) {
+	wanted := func(name string) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	e.Array(8, func() {
+		for name, p := range props {
+			if !wanted(name) {
+				continue
+			}
+			e.Struct(func() {
+				e.String(name)
+				e.Variant(p.sig, func() { p.write(e) })
+			})
+		}
+	})
+}
+
+func itemProps(v view) map[string]prop {
+	return map[string]prop{
+		"Category":          stringProp("ApplicationStatus"),
+		"Id":                stringProp("focotimer"),
+		"Title":             stringProp(v.title),
+		"Status":            stringProp("Active"),
+		"WindowId":          int32Prop(0),
+		"IconName":          stringProp(v.icon),
+		"IconPixmap":        emptyProp("a(iiay)", 8),
+		"OverlayIconName":   stringProp(""),
+		"AttentionIconName": stringProp(""),
+		"ItemIsMenu":        boolProp(false),
+		"Menu":              pathProp(menuPath),
+		"ToolTip": {"(sa(iiay)ss)", func(e *dbus.Encoder) {
+			e.Struct(func() {
+				e.String(v.icon)
+				e.Array(8, func() {})
+				e.String(v.title)
+				e.String(v.body)
+			})
+		}},
+	}
+}
+
+// itemCall answers the clicks on the icon: a left click shows the window
+// and a middle click starts or pauses the session. The menu is exported,
+// so ContextMenu and Scroll have nothing to do.
+func (t *Tray) itemCall(m dbus.Message) error {
+	switch m.Member {
+	case "Activate":
+		run(t.actions.Show)
+	case "SecondaryActivate":
+		v, _ := t.current()
+		run(t.playPause(v).action)
+	case "ContextMenu", "Scroll":
+	default:
+		return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.UnknownMethod", "no method "+m.Member)
+	}
+	return t.conn.Reply(m, "", nil)
+}
+
+// properties answers org.freedesktop.DBus.Properties for both objects.
+func (t *Tray) properties(m dbus.Message) error {
+	d := m.Decoder()
+	iface := d.String()
+	var props map[string]prop
+	switch v, _ := t.current(); {
+	case m.Path == itemPath && iface == itemIface:
+		props = itemProps(v)
+	case m.Path == menuPath && iface == menuIface:
+		props = menuProps()
+	}
+	switch m.Member {
+	case "Get":
+		name := d.String()
+		p, ok := props[name]
+		if d.Err() != nil || !ok {
+			return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.UnknownProperty", "no property "+iface+"."+name)
+		}
+		var e dbus.Encoder
+		e.Variant(p.sig, func() { p.write(&e) })
+		return t.conn.Reply(m, "v", e.Bytes())
+	case "GetAll":
+		if d.Err() != nil {
+			return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.InvalidArgs", d.Err().Error())
+		}
+		var e dbus.Encoder
+		writeProps(&e, props, nil)
+		return t.conn.Reply(m, "a{sv}", e.Bytes())
+	case "Set":
+		return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.PropertyReadOnly", "the properties are read-only")
+	}
+	return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.UnknownMethod", "no method "+m.Member)
+}
+
+// introspect describes the objects for hosts that look before they call.
+func (t *Tray) introspect(m dbus.Message) error {
+	var xml string
+	switch m.Path {
+	case itemPath:
+		xml = itemXML
+	case menuPath:
+		xml = menuXML
+	default:
+		xml = `<node><node name="StatusNotifierItem"/><node name="MenuBar"/></node>`
+	}
+	var e dbus.Encoder
+	e.String(xml)
+	return t.conn.Reply(m, "s", e.Bytes())
+}
+
+const propertiesXML = `<interface name="org.freedesktop.DBus.Properties">
+<method name="Get"><arg type="s" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="out"/></method>
+<method name="GetAll"><arg type="s" direction="in"/><arg type="a{sv}" direction="out"/></method>
+</interface>`
+
+const itemXML = `<node><interface name="org.kde.StatusNotifierItem">
+<property name="Category" type="s" access="read"/>
+<property name="Id" type="s" access="read"/>
+<property name="Title" type="s" access="read"/>
+<property name="Status" type="s" access="read"/>
+<property name="WindowId" type="i" access="read"/>
+<property name="IconName" type="s" access="read"/>
+<property name="IconPixmap" type="a(iiay)" access="read"/>
+<property name="OverlayIconName" type="s" access="read"/>
+<property name="AttentionIconName" type="s" access="read"/>
+<property name="ToolTip" type="(sa(iiay)ss)" access="read"/>
+<property name="ItemIsMenu" type="b" access="read"/>
+<property name="Menu" type="o" access="read"/>
+<method name="ContextMenu"><arg type="i" direction="in"/><arg type="i" direction="in"/></method>
+<method name="Activate"><arg type="i" direction="in"/><arg type="i" direction="in"/></method>
+<method name="SecondaryActivate"><arg type="i" direction="in"/><arg type="i" direction="in"/></method>
+<method name="Scroll"><arg type="i" direction="in"/><arg type="s" direction="in"/></method>
+<signal name="NewTitle"/><signal name="NewIcon"/><signal name="NewToolTip"/>
+</interface>` + propertiesXML + `</node>`
+
+const menuXML = `<node><interface name="com.canonical.dbusmenu">
+<property name="Version" type="u" access="read"/>
+<property name="TextDirection" type="s" access="read"/>
+<property name="Status" type="s" access="read"/>
+<property name="IconThemePath" type="as" access="read"/>
+<method name="GetLayout"><arg type="i" direction="in"/><arg type="i" direction="in"/><arg type="as" direction="in"/><arg type="u" direction="out"/><arg type="(ia{sv}av)" direction="out"/></method>
+<method name="GetGroupProperties"><arg type="ai" direction="in"/><arg type="as" direction="in"/><arg type="a(ia{sv})" direction="out"/></method>
+<method name="GetProperty"><arg type="i" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="out"/></method>
+<method name="Event"><arg type="i" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="in"/><arg type="u" direction="in"/></method>
+<method name="EventGroup"><arg type="a(isvu)" direction="in"/><arg type="ai" direction="out"/></method>
+<method name="AboutToShow"><arg type="i" direction="in"/><arg type="b" direction="out"/></method>
+<method name="AboutToShowGroup"><arg type="ai" direction="in"/><arg type="ai" direction="out"/><arg type="ai" direction="out"/></method>
+<signal name="LayoutUpdated"><arg type="u"/><arg type="i"/></signal>
+</interface>` + propertiesXML + `</node>`
@@ -0,0 +1,232 @@
+package tray
+
+import (
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+// ------------------- Menu -------------------
+
+// Menu entry ids; 0 is the root.
+const (
+	idPlayPause int32 = iota + 1
+	idStop
+	idSeparator
+	idShow
+	idQuit
+)
+
+type entry struct {
+	id        int32
+	label     string
+	enabled   bool
+	separator bool
+	action    func()
+}
+
+func (e entry) props() map[string]prop {
+	if e.separator {
+		return map[string]prop{"type": stringProp("separator")}
+	}
+	return map[string]prop{
+		"label":   stringProp(e.label),
+		"enabled": boolProp(e.enabled),
+	}
+}
+
+// playPause is Pause while a session runs, and Start or Resume otherwise.
+func (t *Tray) playPause(v view) entry {
+	switch v.status {
+	case focotimer.StatusRunning:
+		return entry{id: idPlayPause, label: "Pause", enabled: true, action: t.actions.Pause}
+	case focotimer.StatusPaused:
+		return entry{id: idPlayPause, label: "Resume", enabled: true, action: t.actions.Start}
+	}
+	return entry{id: idPlayPause, label: "Start", enabled: true, action: t.actions.Start}
+}
+
+// entries is the menu for the timer in view v, without the entries whose
+// action is unset.
+func (t *Tray) entries(v view) []entry {
+	stoppable := v.status == focotimer.StatusRunning || v.status == focotimer.StatusPaused
+	all := []entry{
+		t.playPause(v),
+		{id: idStop, label: "Stop", enabled: stoppable, action: t.actions.Stop},
+		{id: idSeparator, separator: true},
+		{id: idShow, label: "Show window", enabled: true, action: t.actions.Show},
+		{id: idQuit, label: "Quit", enabled: true, action: t.actions.Quit},
+	}
+	var entries []entry
+	for _, e := range all {
+		if e.action != nil || (e.separator && (t.actions.Show != nil || t.actions.Quit != nil)) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func menuProps() map[string]prop {
+	return map[string]prop{
+		"Version":       uint32Prop(3),
+		"TextDirection": stringProp("ltr"),
+		"Status":        stringProp("normal"),
+		"IconThemePath": emptyProp("as", 4),
+	}
+}
+
+// writeLayout writes the (ia{sv}av) layout of an entry, with children
+// for the root.
+func writeLayout(e *dbus.Encoder, id int32, props map[string]prop, names []string, children []entry) {
+	e.Struct(func() {
+		e.Int32(id)
+		writeProps(e, props, names)
+		e.Array(1, func() {
+			for _, c := range children {
+				e.Variant("(ia{sv}av)", func() { writeLayout(e, c.id, c.props(), names, nil) })
+			}
+		})
+	})
+}
+
+var rootProps = map[string]prop{"children-display": stringProp("submenu")}
+
+// menuCall answers com.canonical.dbusmenu.
+func (t *Tray) menuCall(m dbus.Message) error {
+	v, revision := t.current()
+	entries := t.entries(v)
+	find := func(id int32) (entry, bool) {
+		for _, e := range entries {
+			if e.id == id {
+				return e, true
+			}
+		}
+		return entry{}, false
+	}
+	d := m.Decoder()
+	invalid := func(err error) error {
+		return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.InvalidArgs", err.Error())
+	}
+	noEntry := func() error {
+		return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.InvalidArgs", "no such menu entry")
+	}
+	var e dbus.Encoder
+
+	switch m.Member {
+	case "GetLayout":
+		parent := d.Int32()
+		d.Int32() // recursion depth; the menu is flat
+		names := readStrings(d)
+		if d.Err() != nil {
+			return invalid(d.Err())
+		}
+		e.Uint32(revision)
+		if parent == 0 {
+			writeLayout(&e, 0, rootProps, names, entries)
+		} else if entry, ok := find(parent); ok {
+			writeLayout(&e, entry.id, entry.props(), names, nil)
+		} else {
+			return noEntry()
+		}
+		return t.conn.Reply(m, "u(ia{sv}av)", e.Bytes())
+
+	case "GetGroupProperties":
+		var ids []int32
+		d.Array(4, func() { ids = append(ids, d.Int32()) })
+		names := readStrings(d)
+		if d.Err() != nil {
+			return invalid(d.Err())
+		}
+		e.Array(8, func() {
+			for _, entry := range entries {
+				if len(ids) > 0 && !containsID(ids, entry.id) {
+					continue
+				}
+				e.Struct(func() {
+					e.Int32(entry.id)
+					writeProps(&e, entry.props(), names)
+				})
+			}
+		})
+		return t.conn.Reply(m, "a(ia{sv})", e.Bytes())
+
+	case "GetProperty":
+		id, name := d.Int32(), d.String()
+		if d.Err() != nil {
+			return invalid(d.Err())
+		}
+		entry, ok := find(id)
+		p, found := entry.props()[name]
+		if !ok || !found {
+			return noEntry()
+		}
+		e.Variant(p.sig, func() { p.write(&e) })
+		return t.conn.Reply(m, "v", e.Bytes())
+
+	case "Event":
+		id, event := d.Int32(), d.String()
+		if d.Err() != nil {
+			return invalid(d.Err())
+		}
+		entry, ok := find(id)
+		if !ok && id != 0 {
+			return noEntry()
+		}
+		if event == "clicked" && entry.enabled {
+			run(entry.action)
+		}
+		return t.conn.Reply(m, "", nil)
+
+	case "EventGroup":
+		var unknown []int32
+		d.Array(8, func() {
+			d.Align(8)
+			id, event := d.Int32(), d.String()
+			d.Skip(d.Variant())
+			d.Uint32() // timestamp
+			if d.Err() != nil {
+				return
+			}
+			entry, ok := find(id)
+			switch {
+			case !ok:
+				unknown = append(unknown, id)
+			case event == "clicked" && entry.enabled:
+				run(entry.action)
+			}
+		})
+		if d.Err() != nil {
+			return invalid(d.Err())
+		}
+		e.Array(4, func() {
+			for _, id := range unknown {
+				e.Int32(id)
+			}
+		})
+		return t.conn.Reply(m, "ai", e.Bytes())
+
+	case "AboutToShow":
+		e.Bool(false)
+		return t.conn.Reply(m, "b", e.Bytes())
+
+	case "AboutToShowGroup":
+		e.Array(4, func() {})
+		e.Array(4, func() {})
+		return t.conn.Reply(m, "aiai", e.Bytes())
+	}
+	return t.conn.ReplyError(m, "org.freedesktop.DBus.Error.UnknownMethod", "no method "+m.Member)
+}
+
+func readStrings(d *dbus.Decoder) []string {
+	var s []string
+	d.Array(4, func() { s = append(s, d.String()) })
+	return s
+}
+
+func containsID(ids []int32, id int32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+// Package tray puts the timer in the system tray as a StatusNotifierItem,
+// the tray protocol of KDE, GNOME (with the AppIndicator extension), waybar
+// and most other panels, with the time left in its title and tooltip and a
+// menu to control the session.
+package tray
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+// ------------------- Tray -------------------
+
+const (
+	itemPath    = "/StatusNotifierItem"
+	menuPath    = "/MenuBar"
+	itemIface   = "org.kde.StatusNotifierItem"
+	menuIface   = "com.canonical.dbusmenu"
+	watcherName = "org.kde.StatusNotifierWatcher"
+)
+
+// Actions are what the menu entries do. The Pause entry shows while a
+// session runs, Start otherwise; nil entries are left out.
+type Actions struct {
+	Start func()
+	Pause func()
+	Stop  func()
+	// Show opens the window, also on a click on the icon.
+	Show func()
+	Quit func()
+}
+
+// Tray is the tray icon of a timer.
+type Tray struct {
+	// Address is the bus, such as "unix:path=/run/user/1000/bus"; empty
+	// is the session bus.
+	Address string
+
+	tm       *focotimer.TimerManager
+	actions  Actions
+	tickRate time.Duration
+
+	mu       sync.Mutex
+	conn     *dbus.Conn
+	name     string
+	view     view
+	revision uint32
+}
+
+func New(tm *focotimer.TimerManager, actions Actions) *Tray {
+	return &Tray{tm: tm, actions: actions, tickRate: time.Second}
+}
+
+// view is what the tray shows of a state.
+type view struct {
+	title  string
+	body   string
+	icon   string
+	status focotimer.Status
+}
+
+func viewOf(s focotimer.State) view {
+	v := view{title: "focotimer", icon: "alarm", status: s.Status}
+	name := s.Label
+	if name == "" {
+		name = "Session"
+	}
+	switch s.Status {
+	case focotimer.StatusRunning:
+		v.title = focotimer.FormatClock(s.Remaining) + " " + name
+		v.body = "Running, " + focotimer.FormatClock(s.Duration) + " in all"
+		v.icon = "media-playback-start"
+	case focotimer.StatusPaused:
+		v.title = focotimer.FormatClock(s.Remaining) + " " + name
+		v.body = "Paused"
+		v.icon = "media-playback-pause"
+	case focotimer.StatusCompleted:
+		v.title = name + " complete"
+		v.body = focotimer.FormatClock(s.Duration) + " done"
+	default:
+		v.body = "Ready for " + focotimer.FormatClock(s.Duration)
+	}
+	return v
+}
+
+// Run shows the icon until stop is closed or the bus connection is lost.
+// The icon comes back whenever a tray host (re)starts.
+func (t *Tray) Run(stop <-chan struct{}) error {
+	conn, err := dbus.Dial(t.Address)
+	if err != nil {
+		return fmt.Errorf("tray: %w", err)
+	}
+	defer conn.Close()
+	name := fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid())
+	t.mu.Lock()
+	t.conn, t.name = conn, name
+	t.view = viewOf(t.tm.State())
+	t.mu.Unlock()
+
+	conn.Handle(t.handle)
+	if err := conn.RequestName(name); err != nil {
+		return fmt.Errorf("tray: RequestName: %w", err)
+	}
+	if err := conn.AddMatch("type='signal',sender='org.freedesktop.DBus',member='NameOwnerChanged',arg0='" + watcherName + "'"); err != nil {
+		return fmt.Errorf("tray: AddMatch: %w", err)
+	}
+	if err := t.register(); err != nil {
+		// no tray host yet; NameOwnerChanged tells when one starts
+		log.Printf("tray.Tray: %v", err)
+	}
+
+	events := t.tm.SubscribeEvents()
+	defer t.tm.UnsubscribeEvents(events)
+	ticks := t.tm.SubscribeEvery(t.tickRate, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	defer t.tm.Unsubscribe(ticks)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-conn.Done():
+			return fmt.Errorf("tray: %w", dbus.ErrClosed)
+		case <-events:
+		case <-ticks:
+		}
+		if err := t.update(); err != nil {
+			return fmt.Errorf("tray: %w", err)
+		}
+	}
+}
+
+// register announces the item to the StatusNotifierWatcher.
+func (t *Tray) register() error {
+	var e dbus.Encoder
+	e.String(t.name)
+	_, err := t.conn.Call(dbus.Message{
+		Path:        "/StatusNotifierWatcher",
+		Interface:   watcherName,
+		Member:      "RegisterStatusNotifierItem",
+		Destination: watcherName,
+		Signature:   "s",
+		Body:        e.Bytes(),
+	})
+	return err
+}
+
+// update signals what changed since the tray last showed the timer.
+func (t *Tray) update() error {
+	v := viewOf(t.tm.State())
+	t.mu.Lock()
+	old := t.view
+	t.view = v
+	if v.status != old.status {
+		t.revision++
+	}
+	revision := t.revision
+	t.mu.Unlock()
+
+	if v.title != old.title {
+		if err := t.conn.Emit(itemPath, itemIface, "NewTitle", "", nil); err != nil {
+			return err
+		}
+	}
+	if v.title != old.title || v.body != old.body {
+		if err := t.conn.Emit(itemPath, itemIface, "NewToolTip", "", nil); err != nil {
+			return err
+		}
+	}
+	if v.icon != old.icon {
+		if err := t.conn.Emit(itemPath, itemIface, "NewIcon", "", nil); err != nil {
+			return err
+		}
+	}
+	if v.status != old.status {
+		var e dbus.Encoder
+		e.Uint32(revision)
+		e.Int32(0)
+		return t.conn.Emit(menuPath, menuIface, "LayoutUpdated", "ui", e.Bytes())
+	}
+	return nil
+}
+
+// current is the view and menu revision the tray last signalled.
+func (t *Tray) current() (view, uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.view, t.revision
+}
+
+// handle answers the tray host and the bus, on the goroutine reading the
+// connection.
+func (t *Tray) handle(m dbus.Message) {
+	if m.Type == dbus.Signal {
+		if m.Member == "NameOwnerChanged" {
+			d := m.Decoder()
+			name, _, owner := d.String(), d.String(), d.String()
+			if d.Err() == nil && name == watcherName && owner != "" {
+				go func() {
+					if err := t.register(); err != nil {
+						log.Printf("tray.Tray: %v", err)
+					}
+				}()
+			}
+		}
+		return
+	}
+	if m.Type != dbus.MethodCall {
+		return
+	}
+	var err error
+	switch {
+	case m.Interface == "org.freedesktop.DBus.Introspectable" && m.Member == "Introspect":
+		err = t.introspect(m)
+	case m.Interface == "org.freedesktop.DBus.Properties":
+		err = t.properties(m)
+	case m.Path == itemPath && m.Interface == itemIface:
+		err = t.itemCall(m)
+	case m.Path == menuPath && m.Interface == menuIface:
+		err = t.menuCall(m)
+	default:
+		err = t.conn.ReplyError(m, "org.freedesktop.DBus.Error.UnknownMethod", "no method "+m.Interface+"."+m.Member)
+	}
+	if err != nil {
+		log.Printf("tray.Tray: %s: %v", m.Member, err)
+	}
+}
+
+// run calls an action off the goroutine reading the connection, since the
+// actions may take their time.
+func run(action func()) {
+	if action != nil {
+		go action()
+	}
+}
@@ -0,0 +1,273 @@
+package tray
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/dbus"
+	"github.com/d093w1z/focotimer/dbus/dbustest"
+)
+
+func TestViewOf(t *testing.T) {
+	tests := []struct {
+		state focotimer.State
+		title string
+	}{
+		{focotimer.State{Status: focotimer.StatusIdle, Duration: 25 * time.Minute}, "focotimer"},
+		{focotimer.State{Status: focotimer.StatusRunning, Remaining: 24*time.Minute + 13*time.Second, Label: "writing"}, "24:13 writing"},
+		{focotimer.State{Status: focotimer.StatusPaused, Remaining: 90 * time.Minute}, "1:30:00 Session"},
+		{focotimer.State{Status: focotimer.StatusCompleted, Label: "writing"}, "writing complete"},
+	}
+	for _, tt := range tests {
+		if got := viewOf(tt.state).title; got != tt.title {
+			t.Errorf("%s: Expected title %q, got %q", tt.state.Status, tt.title, got)
+		}
+	}
+}
+
+// fakeBus is the session bus together with a StatusNotifierWatcher and a
+// tray host: it answers the calls of the tray and lets the test call it.
+type fakeBus struct {
+	*dbustest.Bus
+	t          *testing.T
+	conn       net.Conn
+	registered chan string
+	replies    chan dbus.Message
+	signals    chan dbus.Message
+	serial     uint32
+}
+
+func newFakeBus(t *testing.T) *fakeBus {
+	b := &fakeBus{Bus: dbustest.New(t), t: t, registered: make(chan string, 2),
+		replies: make(chan dbus.Message, 16), signals: make(chan dbus.Message, 64), serial: 1000}
+	return b
+}
+
+// serve accepts the tray and answers it until it hangs up.
+func (b *fakeBus) serve(accepted chan<- struct{}) {
+	conn, r := b.Accept()
+	if conn == nil {
+		return
+	}
+	b.conn = conn
+	close(accepted)
+	for {
+		m, err := dbus.ReadMessage(r)
+		if err != nil {
+			return
+		}
+		switch m.Type {
+		case dbus.MethodReturn, dbus.Error:
+			b.replies <- m
+			continue
+		case dbus.Signal:
+			b.signals <- m
+			continue
+		}
+		reply := dbus.Message{Type: dbus.MethodReturn, Serial: 100 + m.Serial, ReplySerial: m.Serial}
+		var e dbus.Encoder
+		switch m.Member {
+		case "Hello":
+			e.String(":1.42")
+			reply.Signature = "s"
+		case "RequestName":
+			e.Uint32(1)
+			reply.Signature = "u"
+		case "AddMatch":
+		case "RegisterStatusNotifierItem":
+			d := m.Decoder()
+			b.registered <- d.String()
+		default:
+			b.t.Errorf("Unexpected call %q", m.Member)
+		}
+		reply.Body = e.Bytes()
+		conn.Write(reply.Encode())
+	}
+}
+
+// call calls a method of the tray, as the tray host would, and returns
+// the reply.
+func (b *fakeBus) call(path, iface, member, sig string, args func(e *dbus.Encoder)) dbus.Message {
+	b.t.Helper()
+	var e dbus.Encoder
+	if args != nil {
+		args(&e)
+	}
+	b.serial++
+	b.conn.Write(dbus.Message{Type: dbus.MethodCall, Serial: b.serial, Path: path, Interface: iface, Member: member,
+		Sender: ":1.7", Destination: ":1.42", Signature: sig, Body: e.Bytes()}.Encode())
+	select {
+	case m := <-b.replies:
+		if m.ReplySerial != b.serial {
+			b.t.Fatalf("Expected the reply to %d, got %+v", b.serial, m)
+		}
+		return m
+	case <-time.After(2 * time.Second):
+		b.t.Fatalf("Timed out waiting for the reply to %s", member)
+		return dbus.Message{}
+	}
+}
+
+// signal waits for the tray to emit member.
+func (b *fakeBus) signal(member string) dbus.Message {
+	b.t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case m := <-b.signals:
+			if m.Member == member {
+				return m
+			}
+		case <-timeout:
+			b.t.Fatalf("Timed out waiting for %s", member)
+			return dbus.Message{}
+		}
+	}
+}
+
+func (b *fakeBus) property(path, iface, name string) *dbus.Decoder {
+	b.t.Helper()
+	reply := b.call(path, "org.freedesktop.DBus.Properties", "Get", "ss", func(e *dbus.Encoder) {
+		e.String(iface)
+		e.String(name)
+	})
+	if err := reply.Err(); err != nil {
+		b.t.Fatalf("Get %s: %v", name, err)
+	}
+	d := reply.Decoder()
+	d.Variant()
+	return d
+}
+
+// menu is the labels of the menu entries, "-" for separators and "(x)"
+// around disabled ones, and the id of each label.
+func (b *fakeBus) menu() (string, map[string]int32) {
+	b.t.Helper()
+	reply := b.call(menuPath, menuIface, "GetLayout", "iias", func(e *dbus.Encoder) {
+		e.Int32(0)
+		e.Int32(-1)
+		e.Array(4, func() {})
+	})
+	d := reply.Decoder()
+	d.Uint32() // revision
+	d.Align(8)
+	d.Int32()
+	d.Skip("a{sv}")
+	var labels []string
+	ids := make(map[string]int32)
+	d.Array(1, func() {
+		d.Variant()
+		d.Align(8)
+		id := d.Int32()
+		label, enabled, separator := "", true, false
+		d.Array(8, func() {
+			d.Align(8)
+			key := d.String()
+			sig := d.Variant()
+			switch {
+			case key == "label" && sig == "s":
+				label = d.String()
+			case key == "enabled" && sig == "b":
+				enabled = d.Bool()
+			case key == "type" && sig == "s":
+				separator = d.String() == "separator"
+			default:
+				d.Skip(sig)
+			}
+		})
+		d.Skip("av")
+		switch {
+		case separator:
+			label = "-"
+		case !enabled:
+			label = "(" + label + ")"
+		}
+		labels = append(labels, label)
+		ids[strings.Trim(label, "()")] = id
+	})
+	if err := d.Err(); err != nil {
+		b.t.Fatalf("Bad layout: %v", err)
+	}
+	return strings.Join(labels, " "), ids
+}
+
+func TestTray(t *testing.T) {
+	bus := newFakeBus(t)
+	accepted := make(chan struct{})
+	go bus.serve(accepted)
+
+	tm := focotimer.NewTimerManager(25 * time.Minute)
+	clicked := make(chan string, 4)
+	action := func(name string) func() { return func() { clicked <- name } }
+	tray := New(tm, Actions{Start: action("start"), Pause: action("pause"), Stop: action("stop"), Show: action("show")})
+	tray.Address = bus.Address()
+	tray.tickRate = 10 * time.Millisecond
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- tray.Run(stop) }()
+	<-accepted
+
+	select {
+	case name := <-bus.registered:
+		if want := fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid()); name != want {
+			t.Errorf("Expected to register %q, got %q", want, name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the item to register")
+	}
+
+	if title := bus.property(itemPath, itemIface, "Title").String(); title != "focotimer" {
+		t.Errorf("Expected the idle title, got %q", title)
+	}
+	if menu, _ := bus.menu(); menu != "Start (Stop) - Show window" {
+		t.Errorf("Expected the idle menu, got %q", menu)
+	}
+
+	_, ids := bus.menu()
+	reply := bus.call(menuPath, menuIface, "Event", "isvu", func(e *dbus.Encoder) {
+		e.Int32(ids["Start"])
+		e.String("clicked")
+		e.Variant("i", func() { e.Int32(0) })
+		e.Uint32(0)
+	})
+	if err := reply.Err(); err != nil {
+		t.Fatalf("Event failed: %v", err)
+	}
+	if name := <-clicked; name != "start" {
+		t.Errorf("Expected Start to run, got %q", name)
+	}
+
+	tm.Start()
+	bus.signal("NewTitle")
+	bus.signal("LayoutUpdated")
+	if title := bus.property(itemPath, itemIface, "Title").String(); !strings.HasSuffix(title, " Session") || !strings.HasPrefix(title, "2") {
+		t.Errorf("Expected the time left in the title, got %q", title)
+	}
+	if menu, _ := bus.menu(); menu != "Pause Stop - Show window" {
+		t.Errorf("Expected the running menu, got %q", menu)
+	}
+
+	bus.call(itemPath, itemIface, "Activate", "ii", func(e *dbus.Encoder) { e.Int32(0); e.Int32(0) })
+	if name := <-clicked; name != "show" {
+		t.Errorf("Expected a click on the icon to show the window, got %q", name)
+	}
+	if err := bus.call(itemPath, itemIface, "Frob", "", nil).Err(); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+
+	close(stop)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Run to end cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for Run to return")
+	}
+	tm.Stop()
+}
@@ -0,0 +1,47 @@
+package widgets
+
+import (
+	"image"
+	"math"
+
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op/clip"
+	"github.com/d093w1z/gio/op/paint"
+)
+
+// ProgressArc draws the track of s and, over it, progress (0 to 1) of the
+// ring as one smooth arc rather than the segments of DrawGradientRing. The
+// arc goes from s.Colors.Start where it starts to s.Colors.End across the
+// circle.
+func ProgressArc(gtx layout.Context, progress float32, s RingStyle) layout.Dimensions {
+	g := s.geometry(gtx)
+	s.drawTrack(gtx, g)
+
+	progress = max(0, min(progress, 1))
+	if progress == 0 {
+		return layout.Dimensions{Size: image.Pt(g.size, g.size)}
+	}
+	end := s.Start + g.dir*2*math.Pi*progress
+	gradient := paint.LinearGradientOp{
+		Stop1:  g.at(s.Start, g.r),
+		Stop2:  g.at(s.Start+g.dir*math.Pi, g.r),
+		Color1: s.Colors.Start,
+		Color2: s.Colors.End,
+	}
+	fill := func(area clip.Op) {
+		defer area.Push(gtx.Ops).Pop()
+		gradient.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+	}
+
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(g.at(s.Start, g.r))
+	p.ArcTo(g.center, g.center, end-s.Start)
+	fill(clip.Stroke{Path: p.End(), Width: g.width}.Op())
+	if s.RoundCaps {
+		fill(g.cap(gtx, s.Start))
+		fill(g.cap(gtx, end))
+	}
+	return layout.Dimensions{Size: image.Pt(g.size, g.size)}
+}
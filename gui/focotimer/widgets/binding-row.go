@@ -0,0 +1,47 @@
+package widgets
+
+import (
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// BindingRow shows an action, the keys bound to it and a button that starts
+// capturing a new key. While capturing, the button asks for a key press.
+func BindingRow(th *material.Theme, action, keys string, capturing bool, btnWidget *widget.Clickable, onClick func()) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		if btnWidget.Clicked(gtx) {
+			onClick()
+		}
+		return layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2), Left: unit.Dp(12), Right: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					l := material.Body2(th, action)
+					l.Color = Colors().Text
+					return l.Layout(gtx)
+				}),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					if keys == "" {
+						keys = "—"
+					}
+					l := material.Body2(th, keys)
+					l.Color = Colors().Muted
+					return l.Layout(gtx)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					label := "CHANGE"
+					if capturing {
+						label = "PRESS A KEY"
+					}
+					btn := material.Button(th, btnWidget, label)
+					btn.Background = Colors().Button
+					btn.Color = Colors().ButtonText
+					btn.TextSize = unit.Sp(10)
+					btn.Inset = layout.UniformInset(unit.Dp(4))
+					return Labelled(gtx, "Change the keys of "+action, btn.Layout)
+				}),
+			)
+		})
+	})
+}
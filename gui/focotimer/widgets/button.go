@@ -1,24 +1,106 @@
 package widgets
 
 import (
-	"image/color"
+	"image"
 
 	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op"
 	"github.com/d093w1z/gio/unit"
 	"github.com/d093w1z/gio/widget"
 	"github.com/d093w1z/gio/widget/material"
 )
 
-func Button(th *material.Theme, inset unit.Dp, label string, icon []byte, btnWidget *widget.Clickable, onClick func()) layout.FlexChild {
-	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+// ButtonState is a button kept from frame to frame: its clicks, and its
+// icon, parsed once. Label is what screen readers announce, e.g. "Start
+// timer".
+type ButtonState struct {
+	widget.Clickable
+	Label string
+	Inset unit.Dp
+	icon  *widget.Icon
+}
+
+// NewButton makes a button from one of the material design icons, which
+// always parse.
+func NewButton(label string, icon []byte, inset unit.Dp) *ButtonState {
+	ic, err := widget.NewIcon(icon)
+	if err != nil {
+		panic(err)
+	}
+	return &ButtonState{Label: label, Inset: inset, icon: ic}
+}
 
-		startIcon, _ := widget.NewIcon(icon)
-		btn := material.IconButton(th, btnWidget, startIcon, label)
-		btn.Background = color.NRGBA{R: 0x3D, G: 0x3D, B: 0x3D, A: 0xFF}
-		btn.Inset = layout.UniformInset(inset)
-		if btnWidget.Clicked(gtx) {
+// Layout draws the button in a Flex, calling onClick when it was clicked.
+func (b *ButtonState) Layout(th *material.Theme, onClick func()) layout.FlexChild {
+	return layout.Rigid(b.Widget(th, onClick))
+}
+
+// Widget is Layout outside a Flex, e.g. in Wrap.
+func (b *ButtonState) Widget(th *material.Theme, onClick func()) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		btn := material.IconButton(th, &b.Clickable, b.icon, b.Label)
+		btn.Background = Colors().Button
+		btn.Color = Colors().ButtonText
+		btn.Inset = layout.UniformInset(b.Inset)
+		if b.Clicked(gtx) {
 			onClick()
 		}
 		return btn.Layout(gtx)
-	})
+	}
+}
+
+// Wrap lays children out left to right, gap apart, starting a new row
+// when the next would not fit the width; each row is centred.
+func Wrap(gap unit.Dp, children ...layout.Widget) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if len(children) == 0 {
+			return layout.Dimensions{}
+		}
+		type placed struct {
+			call op.CallOp
+			size image.Point
+		}
+		width, g := gtx.Constraints.Max.X, gtx.Dp(gap)
+		cgtx := gtx
+		cgtx.Constraints.Min = image.Point{}
+
+		var rows [][]placed
+		var row []placed
+		rowWidth := 0
+		for _, w := range children {
+			m := op.Record(gtx.Ops)
+			dims := w(cgtx)
+			call := m.Stop()
+			if len(row) > 0 && rowWidth+g+dims.Size.X > width {
+				rows, row, rowWidth = append(rows, row), nil, 0
+			}
+			if len(row) > 0 {
+				rowWidth += g
+			}
+			row = append(row, placed{call, dims.Size})
+			rowWidth += dims.Size.X
+		}
+		rows = append(rows, row)
+
+		y := 0
+		for i, row := range rows {
+			w, h := g*(len(row)-1), 0
+			for _, p := range row {
+				w += p.size.X
+				h = max(h, p.size.Y)
+			}
+			x := max(0, (width-w)/2)
+			for _, p := range row {
+				st := op.Offset(image.Pt(x, y+(h-p.size.Y)/2)).Push(gtx.Ops)
+				p.call.Add(gtx.Ops)
+				st.Pop()
+				x += p.size.X + g
+			}
+			y += h
+			if i < len(rows)-1 {
+				y += g
+			}
+		}
+		return layout.Dimensions{Size: image.Pt(width, y)}
+	}
 }
@@ -0,0 +1,77 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op"
+	"github.com/d093w1z/gio/op/clip"
+	"github.com/d093w1z/gio/op/paint"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// Chart helpers shared by the history pages.
+
+// KindColors color sessions by kind: the ring's amber for focus, green for
+// breaks and a dimmed red for abandoned sessions.
+var KindColors = map[history.Kind]color.NRGBA{
+	history.Focus:     {R: 0xFF, G: 0xA1, B: 0x2C, A: 0xFF},
+	history.Break:     {R: 0x3C, G: 0xB3, B: 0x71, A: 0xFF},
+	history.Abandoned: {R: 0xF1, G: 0x1D, B: 0x28, A: 0x80},
+}
+
+// FillSpan paints the horizontal span [from, to) of a bar, given as
+// fractions of the available width.
+func FillSpan(gtx layout.Context, from, to float64, height int, c color.NRGBA) {
+	width := gtx.Constraints.Max.X
+	x0, x1 := int(from*float64(width)), int(to*float64(width))
+	if x1 <= x0 {
+		x1 = x0 + 1 // keep short sessions visible
+	}
+	paint.FillShape(gtx.Ops, c, clip.Rect(image.Rect(x0, 0, x1, height)).Op())
+}
+
+// HourAxis labels a bar spanning a day every `every` hours.
+func HourAxis(th *material.Theme, every int) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		width := gtx.Constraints.Max.X
+		gtx.Constraints.Min = image.Point{}
+		var height int
+		for h := 0; h < 24; h += every {
+			l := material.Caption(th, fmt.Sprintf("%02d", h))
+			l.Color = Colors().Muted
+			stack := op.Offset(image.Pt(width*h/24, 0)).Push(gtx.Ops)
+			dims := l.Layout(gtx)
+			stack.Pop()
+			height = max(height, dims.Size.Y)
+		}
+		return layout.Dimensions{Size: image.Pt(width, height)}
+	}
+}
+
+// Legend shows the color of each session kind.
+func Legend(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		var children []layout.FlexChild
+		for _, kind := range []history.Kind{history.Focus, history.Break, history.Abandoned} {
+			c := KindColors[kind]
+			children = append(children,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					size := gtx.Dp(unit.Dp(10))
+					paint.FillShape(gtx.Ops, c, clip.Rect(image.Rect(0, 0, size, size)).Op())
+					return layout.Dimensions{Size: image.Pt(size, size)}
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Caption(th, kind.String())
+					l.Color = Colors().Muted
+					return layout.Inset{Left: unit.Dp(4), Right: unit.Dp(12)}.Layout(gtx, l.Layout)
+				}),
+			)
+		}
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+	}
+}
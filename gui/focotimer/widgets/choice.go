@@ -0,0 +1,35 @@
+package widgets
+
+import (
+	"strings"
+
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// Choices lays out one button per option, in capitals, with current
+// highlighted and, for screen readers, selected; they wrap onto more
+// rows when the window is narrow.
+func Choices(th *material.Theme, options []string, current string, btns map[string]*widget.Clickable, onPick func(option string)) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		children := make([]layout.Widget, 0, len(options))
+		for _, option := range options {
+			children = append(children, func(gtx layout.Context) layout.Dimensions {
+				if btns[option].Clicked(gtx) {
+					onPick(option)
+				}
+				btn := material.Button(th, btns[option], strings.ToUpper(option))
+				btn.Background = Colors().Button
+				btn.Color = Colors().ButtonText
+				if option == current {
+					btn.Background = Colors().Accent
+				}
+				btn.Inset = layout.UniformInset(unit.Dp(6))
+				return selectable(gtx, option, option == current, btn.Layout)
+			})
+		}
+		return layout.UniformInset(unit.Dp(4)).Layout(gtx, Wrap(unit.Dp(4), children...))
+	})
+}
@@ -5,11 +5,17 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"sync"
 	"time"
 
+	focotimer "github.com/d093w1z/focotimer/api"
 	"github.com/d093w1z/gio/f32"
+	"github.com/d093w1z/gio/font"
+	"github.com/d093w1z/gio/io/event"
+	"github.com/d093w1z/gio/io/key"
+	"github.com/d093w1z/gio/io/pointer"
+	"github.com/d093w1z/gio/io/semantic"
 	"github.com/d093w1z/gio/layout"
-	"github.com/d093w1z/gio/op"
 	"github.com/d093w1z/gio/op/clip"
 	"github.com/d093w1z/gio/op/paint"
 	"github.com/d093w1z/gio/text"
@@ -19,44 +25,6 @@ import (
 	"golang.org/x/exp/shiny/materialdesign/icons"
 )
 
-func formatDuration(d time.Duration) string {
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
-	return fmt.Sprintf("%02d:%02d", m, s)
-}
-
-func ProgressArc(gtx layout.Context, remaining, total time.Duration) layout.Dimensions {
-	size := gtx.Dp(unit.Dp(200))
-	center := f32.Point{X: float32(size) / 2, Y: float32(size) / 2}
-	radius := float32(size/2 - gtx.Dp(unit.Dp(10)))
-
-	progress := float32(remaining.Seconds() / total.Seconds())
-	angle := 2 * math.Pi * progress
-	// startAngle := -math.Pi / 2 // starting from top
-
-	f1 := f32.Point{X: center.X - radius, Y: center.Y}
-	f2 := f32.Point{X: center.X + radius, Y: center.Y}
-
-	var ops op.Ops = *gtx.Ops
-	var path clip.Path
-	path.Begin(&ops)
-	path.MoveTo(center)
-	path.Arc(f1, f2, angle) // builds arc segment
-	spec := path.End()
-
-	// Gradient paint (linear for example)
-	paint.LinearGradientOp{
-		Stop1:  f32.Pt(center.X-radius, center.Y),
-		Stop2:  f32.Pt(center.X+radius, center.Y),
-		Color1: color.NRGBA{R: 0x00, G: 0xFF, B: 0x00, A: 0xFF},
-		Color2: color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF},
-	}.Add(gtx.Ops)
-
-	paint.FillShape(gtx.Ops, color.NRGBA{A: 0xFF}, clip.Outline{Path: spec}.Op())
-
-	return layout.Dimensions{Size: image.Pt(size, size)}
-}
-
 // Linear interpolation of colors
 func lerpColor(c1, c2 color.NRGBA, t float32) color.NRGBA {
 	return color.NRGBA{
@@ -66,204 +34,450 @@ func lerpColor(c1, c2 color.NRGBA, t float32) color.NRGBA {
 		A: uint8(float32(c1.A) + t*(float32(c2.A)-float32(c1.A))),
 	}
 }
-func DrawGradientRing(gtx layout.Context, progress float32, startColor, endColor color.NRGBA) layout.Dimensions {
-	size := gtx.Dp(unit.Dp(200))
-	center := float32(size) / 2
-	outerRadius := center
-	innerRadius := outerRadius - 10 // thickness
-
-	// Use fewer segments for smoother arcs
-	segments := 60
-	maxSeg := int(float32(segments) * progress)
-
-	if maxSeg == 0 {
-		return layout.Dimensions{Size: image.Pt(size, size)}
-	}
-
-	angleStep := 2 * math.Pi / float64(segments)
-	segmentAngle := float32(angleStep)
-
-	for i := 0; i < maxSeg; i++ {
-		startAngle := float32(i)*segmentAngle - math.Pi/2 // Start from top
-		endAngle := startAngle + segmentAngle
 
-		// Calculate gradient color for this segment
-		// Key change: interpolate only within the drawn arc (0 to maxSeg)
-		t := float32(i) / float32(maxSeg-1)
-		if maxSeg == 1 {
-			t = 0
+// DrawGradientRing draws the track of s and, over it, progress (0 to 1)
+// of the ring in segments, each a shade further from s.Colors.Start to
+// s.Colors.End.
+func DrawGradientRing(gtx layout.Context, progress float32, s RingStyle) layout.Dimensions {
+	g := s.geometry(gtx)
+	s.drawTrack(gtx, g)
+
+	// one segment per 10px of the circumference, for a smooth gradient at
+	// any size
+	segments := max(24, int(math.Pi*float64(g.size)/10))
+	drawn := int(float32(segments) * max(0, min(progress, 1)))
+	if drawn == 0 {
+		return layout.Dimensions{Size: image.Pt(g.size, g.size)}
+	}
+	step := g.dir * 2 * math.Pi / float32(segments)
+	outer, inner := g.r+g.width/2, g.r-g.width/2
+	colorAt := func(i int) color.NRGBA {
+		if drawn == 1 {
+			return s.Colors.Start
 		}
-		c := lerpColor(startColor, endColor, t)
-
-		// Create path for the ring segment
+		return lerpColor(s.Colors.Start, s.Colors.End, float32(i)/float32(drawn-1))
+	}
+	for i := range drawn {
+		from := s.Start + float32(i)*step
 		var p clip.Path
 		p.Begin(gtx.Ops)
+		p.MoveTo(g.at(from, outer))
+		p.ArcTo(g.center, g.center, step)
+		p.LineTo(g.at(from+step, inner))
+		p.ArcTo(g.center, g.center, -step)
+		p.Close()
+		paint.FillShape(gtx.Ops, colorAt(i), clip.Outline{Path: p.End()}.Op())
+	}
+	if s.RoundCaps {
+		paint.FillShape(gtx.Ops, colorAt(0), g.cap(gtx, s.Start))
+		paint.FillShape(gtx.Ops, colorAt(drawn-1), g.cap(gtx, s.Start+float32(drawn)*step))
+	}
+	return layout.Dimensions{Size: image.Pt(g.size, g.size)}
+}
 
-		// Calculate points
-		startCos, startSin := math.Cos(float64(startAngle)), math.Sin(float64(startAngle))
-		endCos, endSin := math.Cos(float64(endAngle)), math.Sin(float64(endAngle))
+// Ring is the gradient the progress ring is drawn in, from the start of the
+// session to where it is now.
+type Ring struct {
+	Start, End color.NRGBA
+}
+
+// RingStyle is the shape of a progress ring and the colors it is drawn
+// in.
+type RingStyle struct {
+	Diameter  unit.Dp
+	Thickness unit.Dp
+	// Start is where progress starts, in radians clockwise from three
+	// o'clock: -math.Pi/2 starts at the top.
+	Start            float32
+	CounterClockwise bool
+	// RoundCaps rounds the ends of the progress.
+	RoundCaps bool
+	Track     color.NRGBA
+	Colors    Ring
+}
+
+// DefaultRingStyle is a 200dp ring filling clockwise from the top.
+var DefaultRingStyle = RingStyle{Diameter: 200, Thickness: 10, Start: -math.Pi / 2}
+
+// ringGeometry is a RingStyle in pixels.
+type ringGeometry struct {
+	size   int
+	center f32.Point
+	// r is the radius of the middle of the stroke.
+	r, width float32
+	// dir is 1 clockwise and -1 counterclockwise.
+	dir float32
+}
 
-		// Outer arc start point
-		outerStartX := center + outerRadius*float32(startCos)
-		outerStartY := center + outerRadius*float32(startSin)
+func (s RingStyle) geometry(gtx layout.Context) ringGeometry {
+	size := gtx.Dp(s.Diameter)
+	width := float32(gtx.Dp(s.Thickness))
+	g := ringGeometry{
+		size:   size,
+		center: f32.Pt(float32(size)/2, float32(size)/2),
+		r:      float32(size)/2 - width/2,
+		width:  width,
+		dir:    1,
+	}
+	if s.CounterClockwise {
+		g.dir = -1
+	}
+	return g
+}
 
-		// Outer arc end point
-		outerEndX := center + outerRadius*float32(endCos)
-		outerEndY := center + outerRadius*float32(endSin)
+// at is the point at angle, r from the centre.
+func (g ringGeometry) at(angle, r float32) f32.Point {
+	sin, cos := math.Sincos(float64(angle))
+	return g.center.Add(f32.Pt(r*float32(cos), r*float32(sin)))
+}
 
-		// Inner arc start point
-		innerStartX := center + innerRadius*float32(startCos)
-		innerStartY := center + innerRadius*float32(startSin)
+// cap is the round end of the stroke at angle.
+func (g ringGeometry) cap(gtx layout.Context, angle float32) clip.Op {
+	c := g.at(angle, g.r)
+	half := g.width / 2
+	rect := image.Rect(int(c.X-half), int(c.Y-half), int(c.X+half), int(c.Y+half))
+	return clip.Ellipse(rect).Op(gtx.Ops)
+}
 
-		// Inner arc end point
-		innerEndX := center + innerRadius*float32(endCos)
-		innerEndY := center + innerRadius*float32(endSin)
+func (s RingStyle) drawTrack(gtx layout.Context, g ringGeometry) {
+	track := clip.Ellipse(image.Rect(0, 0, g.size, g.size).Inset(int(g.width) / 2))
+	paint.FillShape(gtx.Ops, s.Track, clip.Stroke{Path: track.Path(gtx.Ops), Width: g.width}.Op())
+}
 
-		// Build the path
-		p.MoveTo(f32.Pt(outerStartX, outerStartY))
+var (
+	// FocusRing glows from red to orange during focus sessions.
+	FocusRing = Ring{
+		Start: color.NRGBA{R: 0xF1, G: 0x1D, B: 0x28, A: 0x00},
+		End:   color.NRGBA{R: 0xFF, G: 0xA1, B: 0x2C, A: 0xFF}, // FFA12C
+	}
+	// BreakRing is teal to green, so a break is told apart at a glance.
+	BreakRing = Ring{
+		Start: color.NRGBA{R: 0x00, G: 0xB3, B: 0xB3, A: 0x00},
+		End:   color.NRGBA{R: 0x2C, G: 0xE0, B: 0x8A, A: 0xFF},
+	}
+	// WarningRing runs from orange into red as a focus session ends.
+	WarningRing = Ring{
+		Start: color.NRGBA{R: 0xFF, G: 0xA1, B: 0x2C, A: 0x00},
+		End:   color.NRGBA{R: 0xF1, G: 0x1D, B: 0x28, A: 0xFF},
+	}
+)
 
-		// Outer arc - use QuadTo for smoother curves
-		if segmentAngle <= math.Pi {
-			// For segments <= 180°, use a single arc
-			midAngle := startAngle + segmentAngle/2
-			midCos, midSin := math.Cos(float64(midAngle)), math.Sin(float64(midAngle))
+// TimerView is the progress ring with the remaining time, kept from frame
+// to frame by the window drawing it. It takes the space left in a Flex and
+// scales to fit it.
+type TimerView struct {
+	// Scrolled, when set, gets each notch scrolled over the ring: 1 up,
+	// -1 down.
+	Scrolled func(notches int)
+	// Dragged, when set, gets the turn, from 0 to 1, from where the ring
+	// starts to the pointer pressed or dragged on it.
+	Dragged func(turn float64)
+	// Edit, when set, gets the text typed into the countdown, which
+	// turns into a field when clicked while Editable says so. An error
+	// is shown under the field, which stays open.
+	Edit     func(text string) error
+	Editable func() bool
+
+	icon    *widget.Icon
+	click   widget.Clickable
+	editor  widget.Editor
+	editing bool
+	focused bool
+	problem string
+}
 
-			// Control point for quadratic bezier to approximate arc
-			controlRadius := outerRadius / float32(math.Cos(float64(segmentAngle/4)))
-			controlX := center + controlRadius*float32(midCos)
-			controlY := center + controlRadius*float32(midSin)
+func NewTimerView() *TimerView {
+	icon, err := widget.NewIcon(icons.ActionVisibility)
+	if err != nil {
+		panic(err)
+	}
+	return &TimerView{icon: icon}
+}
 
-			p.QuadTo(f32.Pt(controlX, controlY), f32.Pt(outerEndX, outerEndY))
-		} else {
-			// For larger segments, draw line (fallback)
-			p.LineTo(f32.Pt(outerEndX, outerEndY))
+// Layout draws the progress ring with the remaining time, when set the
+// session activity (e.g. the current interval and break sub-phase), and
+// the pomodoros done in the current cycle as dots.
+func (v *TimerView) Layout(th *material.Theme, label string, remaining time.Duration, progress float64, ring Ring, dots int) layout.FlexChild {
+	return layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+		c := Countdown()
+		style, size := c.Ring, c.Size
+		style.Track, style.Colors = Colors().Surface, ring
+		if Mini(gtx) {
+			// the ring shrinks with the text
+			shrink := float32(c.MiniSize / c.Size)
+			style.Diameter *= unit.Dp(shrink)
+			style.Thickness *= unit.Dp(shrink)
+			size = c.MiniSize
 		}
+		// then both fit the space given, from half to twice their size
+		fit := float32(min(gtx.Constraints.Max.X, gtx.Constraints.Max.Y)) / float32(gtx.Dp(style.Diameter))
+		scale := max(0.5, min(fit, 2))
+		style.Diameter *= unit.Dp(scale)
+		style.Thickness *= unit.Dp(scale)
+		size *= unit.Sp(scale)
+
+		return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Stack{Alignment: layout.Center}.Layout(gtx,
+				layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+					v.gestures(gtx, style, remaining)
+					if c.Arc {
+						return ProgressArc(gtx, float32(progress), style)
+					}
+					return DrawGradientRing(gtx, float32(progress), style)
+				}),
+				layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							return v.icon.Layout(gtx, Colors().Text)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							return v.countdown(gtx, th, c, size, remaining)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							if label == "" {
+								return layout.Dimensions{}
+							}
+							l := material.Body2(th, label)
+							l.Alignment = text.Middle
+							l.Color = Colors().Muted
+							return l.Layout(gtx)
+						}),
+						layout.Rigid(SessionDots(dots, DotsPerCycle)),
+					)
+				}),
+			)
+		})
+	})
+}
 
-		// Connect to inner arc
-		p.LineTo(f32.Pt(innerEndX, innerEndY))
-
-		// Inner arc (reverse direction)
-		if segmentAngle <= math.Pi {
-			midAngle := endAngle - segmentAngle/2
-			midCos, midSin := math.Cos(float64(midAngle)), math.Sin(float64(midAngle))
+// countdown shows the remaining time, or the field editing it.
+func (v *TimerView) countdown(gtx layout.Context, th *material.Theme, c CountdownStyle, size unit.Sp, remaining time.Duration) layout.Dimensions {
+	editable := v.Edit != nil && v.Editable != nil && v.Editable()
+	if !editable {
+		v.editing = false
+	}
+	if editable && v.click.Clicked(gtx) && !v.editing {
+		v.editing, v.focused, v.problem = true, false, ""
+		v.editor.SingleLine, v.editor.Submit = true, true
+		v.editor.Alignment = text.Middle
+		v.editor.SetText(focotimer.FormatClock(remaining))
+		v.editor.SetCaret(v.editor.Len(), 0)
+		gtx.Execute(key.FocusCmd{Tag: &v.editor})
+	}
+	if v.editing {
+		v.edit(gtx)
+	}
+	if !v.editing {
+		m := material.H3(th, focotimer.FormatClock(remaining))
+		m.TextSize = size
+		if c.Typeface != "" {
+			m.Font.Typeface = c.Typeface
+		}
+		m.Alignment = text.Middle
+		m.Color = Colors().Text
+		if !editable {
+			return m.Layout(gtx)
+		}
+		return v.click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			semantic.Button.Add(gtx.Ops)
+			semantic.DescriptionOp("Edit the duration").Add(gtx.Ops)
+			return m.Layout(gtx)
+		})
+	}
 
-			controlRadius := innerRadius / float32(math.Cos(float64(segmentAngle/4)))
-			controlX := center + controlRadius*float32(midCos)
-			controlY := center + controlRadius*float32(midSin)
+	return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			// as wide as the time it replaces
+			gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Sp(size)*4)
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			e := material.Editor(th, &v.editor, "25m")
+			e.TextSize = size
+			if c.Typeface != "" {
+				e.Font.Typeface = c.Typeface
+			}
+			e.Color = Colors().Text
+			e.HintColor = Colors().Muted
+			return e.Layout(gtx)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if v.problem == "" {
+				return layout.Dimensions{}
+			}
+			l := material.Caption(th, v.problem)
+			l.Alignment = text.Middle
+			l.Color = Colors().Alert
+			return l.Layout(gtx)
+		}),
+	)
+}
 
-			p.QuadTo(f32.Pt(controlX, controlY), f32.Pt(innerStartX, innerStartY))
-		} else {
-			p.LineTo(f32.Pt(innerStartX, innerStartY))
+// edit handles the field: Enter passes the text on to Edit, Escape or
+// leaving the field puts the countdown back.
+func (v *TimerView) edit(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(key.Filter{Focus: &v.editor, Name: key.NameEscape})
+		if !ok {
+			break
+		}
+		if e, ok := ev.(key.Event); ok && e.State == key.Press {
+			v.stopEditing(gtx)
+			return
+		}
+	}
+	for {
+		ev, ok := v.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			if err := v.Edit(v.editor.Text()); err != nil {
+				v.problem = err.Error()
+				continue
+			}
+			v.stopEditing(gtx)
+			return
 		}
+	}
+	// once focused, clicking elsewhere drops the edit
+	focused := gtx.Source.Focused(&v.editor)
+	if v.focused && !focused {
+		v.editing, v.problem = false, ""
+	}
+	v.focused = focused
+}
 
-		// Close the path
-		p.Close()
+func (v *TimerView) stopEditing(gtx layout.Context) {
+	v.editing, v.problem = false, ""
+	gtx.Execute(key.FocusCmd{})
+}
+
+// Typing tells whether the countdown is being edited, so that the keys
+// typed are not taken as shortcuts.
+func (v *TimerView) Typing(gtx layout.Context) bool {
+	return v.editing && gtx.Source.Focused(&v.editor)
+}
+
+// gestures passes the scrolls and drags over the ring of style on to
+// Scrolled and Dragged. Screen readers hear the ring as the time
+// remaining.
+func (v *TimerView) gestures(gtx layout.Context, style RingStyle, remaining time.Duration) {
+	g := style.geometry(gtx)
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target:  v,
+			Kinds:   pointer.Scroll | pointer.Press | pointer.Drag,
+			ScrollY: pointer.ScrollRange{Min: math.MinInt32, Max: math.MaxInt32},
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch {
+		case e.Kind == pointer.Scroll && v.Scrolled != nil && e.Scroll.Y != 0:
+			// scrolling down shortens
+			notches := 1
+			if e.Scroll.Y > 0 {
+				notches = -1
+			}
+			v.Scrolled(notches)
+		case e.Kind != pointer.Scroll && v.Dragged != nil:
+			v.Dragged(g.turn(e.Position, style.Start))
+		}
+	}
+	area := clip.Ellipse(image.Rect(0, 0, g.size, g.size)).Push(gtx.Ops)
+	event.Op(gtx.Ops, v)
+	semantic.LabelOp(SpokenDuration(remaining) + " remaining").Add(gtx.Ops)
+	if v.Scrolled != nil || v.Dragged != nil {
+		semantic.DescriptionOp("Scroll or drag round the ring to set the duration").Add(gtx.Ops)
+	}
+	area.Pop()
+}
 
-		// Fill the segment
-		paint.FillShape(gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
+// turn is how far round the ring p is from the angle start, from 0 to 1
+// in the direction the ring fills.
+func (g ringGeometry) turn(p f32.Point, start float32) float64 {
+	d := p.Sub(g.center)
+	angle := (math.Atan2(float64(d.Y), float64(d.X)) - float64(start)) * float64(g.dir)
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
 	}
+	return angle / (2 * math.Pi)
+}
+
+// CountdownStyle is how Timer shows the remaining time.
+type CountdownStyle struct {
+	// Typeface is empty for the theme's.
+	Typeface font.Typeface
+	Size     unit.Sp
+	// MiniSize is for windows narrower than MiniWidth.
+	MiniSize unit.Sp
+	// Ring is the shape of the ring around it; Timer colors it. In a
+	// mini window it shrinks as the text does.
+	Ring RingStyle
+	// Arc draws the progress as a ProgressArc instead of the gradient
+	// ring.
+	Arc bool
+}
+
+// DefaultCountdown writes the time as large as an H3 heading in the
+// gradient ring.
+var DefaultCountdown = CountdownStyle{Size: 48, MiniSize: 32, Ring: DefaultRingStyle}
 
-	return layout.Dimensions{Size: image.Pt(size, size)}
+// MiniWidth is the width below which the countdown shrinks to MiniSize.
+const MiniWidth = unit.Dp(260)
+
+// Mini tells whether gtx is a window narrower than MiniWidth, which
+// shows the mini layout.
+func Mini(gtx layout.Context) bool {
+	return gtx.Constraints.Max.X < gtx.Dp(MiniWidth)
+}
+
+var (
+	countdownMu sync.RWMutex
+	countdown   = DefaultCountdown
+)
+
+// UseCountdown makes Timer show the time in s from the next frame on.
+func UseCountdown(s CountdownStyle) {
+	countdownMu.Lock()
+	defer countdownMu.Unlock()
+	countdown = s
 }
 
-func Timer(th *material.Theme, remaining, total time.Duration) layout.FlexChild {
-	progress := 1.0 - float32(remaining.Seconds()/total.Seconds())
-	_ = progress
-	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-		return layout.Stack{Alignment: layout.Center}.Layout(gtx,
-			layout.Stacked(func(gtx layout.Context) layout.Dimensions {
-				size := gtx.Dp(unit.Dp(200))
-				rect := image.Rect(0, 0, size, size)
-
-				// Outer ring ellipse
-				outer := clip.Ellipse{Min: rect.Min, Max: rect.Max}.Op(gtx.Ops)
-				paint.FillShape(gtx.Ops, color.NRGBA{R: 0x3D, G: 0x3D, B: 0x3D, A: 0xFF}, outer)
-
-				DrawGradientRing(
-					gtx,
-					1-float32(remaining.Seconds())/float32(total.Seconds()),
-					color.NRGBA{R: 0xF1, G: 0x1D, B: 0x28, A: 0x00}, // start
-					color.NRGBA{R: 0xFF, G: 0xA1, B: 0x2C, A: 0xFF}, // end FFA12C
-				)
-				// Inner circle (cutout effect)
-				inset := gtx.Dp(unit.Dp(10))
-				innerRect := rect.Inset(inset)
-				inner := clip.Ellipse{Min: innerRect.Min, Max: innerRect.Max}.Op(gtx.Ops)
-				paint.FillShape(gtx.Ops, color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF}, inner)
-				return layout.Dimensions{Size: rect.Size()}
-
-			}),
-			// layout.Stacked(func(gtx layout.Context) layout.Dimensions {
-			// 	return DrawGradientRing(
-			// 		gtx,
-			// 		float32(remaining.Seconds())/float32(total.Seconds()),
-			// 		color.NRGBA{R: 0x00, G: 0xFF, B: 0x80, A: 0xFF}, // start
-			// 		color.NRGBA{R: 0x00, G: 0x80, B: 0xFF, A: 0xFF}, // end
-			// 	)
-			// }),
-			layout.Stacked(func(gtx layout.Context) layout.Dimensions {
-				return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
-
-					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						icon, _ := widget.NewIcon(icons.ActionVisibility)
-
-						iconColor := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
-						return icon.Layout(gtx, iconColor)
-
-					}), layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						m := material.H3(th, formatDuration(remaining))
-						m.Alignment = text.Middle
-						m.Color = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
-						return m.Layout(gtx)
-
-					}),
-					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
-							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								rect := image.Rect(0, 0, 5, 12)
-								cRect := clip.UniformRRect(
-									rect, 2, // corner radius in pixels
-								)
-								defer cRect.Push(gtx.Ops).Pop()
-								paint.FillShape(gtx.Ops, color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF}, cRect.Op(gtx.Ops))
-								return layout.Dimensions{Size: cRect.Rect.Size()}
-							}), layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-
-								rect := clip.UniformRRect(
-									image.Rect(0, 0, 5, 12),
-									2, // corner radius in pixels
-								)
-								defer rect.Push(gtx.Ops).Pop()
-								// paint.FillShape(gtx.Ops, color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF}, rect.Op(gtx.Ops))
-								paint.FillShape(gtx.Ops, color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF}, rect.Op(gtx.Ops))
-								return layout.Dimensions{Size: rect.Rect.Size()}
-							}), layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-
-								rect := clip.UniformRRect(
-									image.Rect(0, 0, 5, 12),
-									2, // corner radius in pixels
-								)
-								defer rect.Push(gtx.Ops).Pop()
-								// paint.FillShape(gtx.Ops, color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF}, rect.Op(gtx.Ops))
-								paint.FillShape(gtx.Ops, color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF}, rect.Op(gtx.Ops))
-								return layout.Dimensions{Size: rect.Rect.Size()}
-							}), layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-
-								rect := clip.UniformRRect(
-									image.Rect(0, 0, 5, 12),
-									2, // corner radius in pixels
-								)
-								defer rect.Push(gtx.Ops).Pop()
-								// paint.FillShape(gtx.Ops, color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF}, rect.Op(gtx.Ops))
-								paint.FillShape(gtx.Ops, color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF}, rect.Op(gtx.Ops))
-								return layout.Dimensions{Size: rect.Rect.Size()}
-							}),
-						)
-					}),
-				)
+// Countdown returns how Timer shows the time.
+func Countdown() CountdownStyle {
+	countdownMu.RLock()
+	defer countdownMu.RUnlock()
+	return countdown
+}
+
+// DotsPerCycle is the number of pomodoros before the long break.
+const DotsPerCycle = 4
+
+// SessionDots draws total dots, the first done of them filled.
+func SessionDots(done, total int) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		children := make([]layout.FlexChild, 0, 2*total)
+		for i := range total {
+			c := Colors().Surface
+			if i < done {
+				c = Colors().Alert
+			}
+			if i > 0 {
+				children = append(children, layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout))
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				rect := clip.UniformRRect(image.Rect(0, 0, gtx.Dp(unit.Dp(5)), gtx.Dp(unit.Dp(12))), gtx.Dp(unit.Dp(2)))
+				paint.FillShape(gtx.Ops, c, rect.Op(gtx.Ops))
+				return layout.Dimensions{Size: rect.Rect.Size()}
 			}))
-	})
+		}
+		return Labelled(gtx, fmt.Sprintf("%d of %d pomodoros done", done, total), func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+		})
+	}
 }
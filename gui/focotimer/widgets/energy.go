@@ -0,0 +1,130 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// EnergyPrompt asks for an energy rating from 1 to 5 with one button per
+// level; current (0 when unrated) is highlighted.
+func EnergyPrompt(th *material.Theme, title string, current int, btns *[5]widget.Clickable, onRate func(level int)) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				l := material.Caption(th, title)
+				l.Color = Colors().Muted
+				return layout.Inset{Right: unit.Dp(8)}.Layout(gtx, l.Layout)
+			}),
+		}
+		for i := range btns {
+			level := i + 1
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if btns[i].Clicked(gtx) {
+					onRate(level)
+				}
+				btn := material.Button(th, &btns[i], strconv.Itoa(level))
+				btn.Background = Colors().Button
+				btn.Color = Colors().ButtonText
+				if level == current {
+					btn.Background = Colors().Accent
+				}
+				btn.Inset = layout.UniformInset(unit.Dp(6))
+				return layout.Inset{Right: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return selectable(gtx, fmt.Sprintf("Energy %d of %d", level, len(btns)), level == current, btn.Layout)
+				})
+			}))
+		}
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+	})
+}
+
+// EnergyInsights shows the average start and end energy by time of day and
+// by session length, headed by the best time for hard work.
+func EnergyInsights(th *material.Theme, in history.Insights) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			title := "Rate your energy to see insights"
+			if peak, ok := in.Peak(); ok {
+				title = fmt.Sprintf("Most energy in the %s (%.1f)", peak.Name, peak.Start)
+			}
+			rows := []layout.FlexChild{
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Body1(th, title)
+					l.Color = Colors().Text
+					return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, l.Layout)
+				}),
+			}
+			rows = append(rows, energySection(th, "BY TIME OF DAY", in.ByTimeOfDay)...)
+			rows = append(rows, energySection(th, "BY SESSION LENGTH", in.ByLength)...)
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+		})
+	})
+}
+
+func energySection(th *material.Theme, title string, buckets []history.EnergyBucket) []layout.FlexChild {
+	rows := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(th, title)
+			l.Color = Colors().Accent
+			return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(2)}.Layout(gtx, l.Layout)
+		}),
+	}
+	for _, b := range buckets {
+		rows = append(rows, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min.X = gtx.Dp(unit.Dp(90))
+					l := material.Caption(th, b.Name)
+					l.Color = Colors().Muted
+					return l.Layout(gtx)
+				}),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					// start energy above end energy, out of 5
+					height := gtx.Dp(unit.Dp(6))
+					c := Colors()
+					FillSpan(gtx, 0, 1, 2*height, c.Surface)
+					if b.Start > 0 {
+						FillSpan(gtx, 0, b.Start/5, height, c.Accent)
+					}
+					if b.End > 0 {
+						end := c.Accent
+						end.A /= 2
+						stack := op.Offset(image.Pt(0, height)).Push(gtx.Ops)
+						FillSpan(gtx, 0, b.End/5, height, end)
+						stack.Pop()
+					}
+					return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, 2*height)}
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Caption(th, energySummary(b))
+					l.Color = Colors().Muted
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, l.Layout)
+				}),
+			)
+		}))
+	}
+	return rows
+}
+
+// energySummary reads "4.2 → 3.1 (12)": average start and end energy and
+// the number of rated sessions.
+func energySummary(b history.EnergyBucket) string {
+	if b.Rated == 0 {
+		return "–"
+	}
+	rating := func(v float64) string {
+		if v == 0 {
+			return "?"
+		}
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("%s → %s (%d)", rating(b.Start), rating(b.End), b.Rated)
+}
@@ -0,0 +1,68 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d093w1z/gio/io/semantic"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op"
+	"github.com/d093w1z/gio/op/clip"
+)
+
+// ---------------- ACCESSIBILITY ----------------
+// Screen readers read the semantic tree Gio builds from clip areas: icon
+// buttons carry their labels and text its words. These name what the text
+// does not say: the time left in words, the choice selected, and the
+// buttons that act on something named beside them.
+
+// SpokenDuration says d the way a screen reader should, to the second as
+// the countdown shows it: "1 hour 5 minutes", "12 minutes 30 seconds".
+func SpokenDuration(d time.Duration) string {
+	d = max(0, d.Truncate(time.Second))
+	var parts []string
+	for _, u := range []struct {
+		n    int
+		name string
+	}{
+		{int(d.Hours()), "hour"},
+		{int(d.Minutes()) % 60, "minute"},
+		{int(d.Seconds()) % 60, "second"},
+	} {
+		switch {
+		case u.n == 1:
+			parts = append(parts, "1 "+u.name)
+		case u.n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", u.n, u.name))
+		}
+	}
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Labelled lays w out in a node named label for screen readers, holding
+// the nodes of w.
+func Labelled(gtx layout.Context, label string, w layout.Widget) layout.Dimensions {
+	return semanticNode(gtx, w, semantic.LabelOp(label))
+}
+
+// selectable lays w out as one of a group of choices named label,
+// selected or not.
+func selectable(gtx layout.Context, label string, selected bool, w layout.Widget) layout.Dimensions {
+	return semanticNode(gtx, w, semantic.RadioButton, semantic.SelectedOp(selected), semantic.LabelOp(label))
+}
+
+func semanticNode(gtx layout.Context, w layout.Widget, ops ...interface{ Add(*op.Ops) }) layout.Dimensions {
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	for _, o := range ops {
+		o.Add(gtx.Ops)
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
@@ -0,0 +1,101 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/d093w1z/gio/f32"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/op/clip"
+	"github.com/d093w1z/gio/op/paint"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// Bar is one column of a BarChart: its value, the label under it and the
+// text over it.
+type Bar struct {
+	Value float64
+	Label string
+	Text  string
+}
+
+// BarChart shows bars side by side under a title, scaled to the highest;
+// empty bars keep their slot on the track.
+func BarChart(th *material.Theme, title string, bars []Bar, c color.NRGBA) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(6), Left: unit.Dp(12), Right: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			var most float64
+			for _, b := range bars {
+				most = max(most, b.Value)
+			}
+			column := func(b Bar) layout.FlexChild {
+				return layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							l := material.Caption(th, b.Text)
+							l.Color = Colors().Muted
+							return l.Layout(gtx)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(unit.Dp(60)))
+							var share float64
+							if most > 0 {
+								share = b.Value / most
+							}
+							fillBar(gtx, size, share, gtx.Dp(unit.Dp(3)), c)
+							return layout.Dimensions{Size: size}
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							l := material.Caption(th, b.Label)
+							l.Color = Colors().Muted
+							return l.Layout(gtx)
+						}),
+					)
+				})
+			}
+			columns := make([]layout.FlexChild, len(bars))
+			for i, b := range bars {
+				columns[i] = column(b)
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Caption(th, title)
+					l.Color = Colors().Accent
+					return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, l.Layout)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.End}.Layout(gtx, columns...)
+				}),
+			)
+		})
+	})
+}
+
+// fillBar draws a bar filled from the bottom to share of its height on a
+// track, inset by gap on each side so neighbouring bars stay apart. The
+// fill is a path rounded at the top.
+func fillBar(gtx layout.Context, size image.Point, share float64, gap int, c color.NRGBA) {
+	x0, x1 := float32(gap), float32(size.X-gap)
+	if x1 <= x0 {
+		return
+	}
+	bottom := float32(size.Y)
+	paint.FillShape(gtx.Ops, Colors().Surface, clip.Rect(image.Rect(int(x0), 0, int(x1), size.Y)).Op())
+	if share <= 0 {
+		return
+	}
+	top := bottom - float32(share)*bottom
+	r := min((x1-x0)/2, bottom-top)
+
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(f32.Pt(x0, bottom))
+	p.LineTo(f32.Pt(x0, top+r))
+	p.QuadTo(f32.Pt(x0, top), f32.Pt(x0+r, top))
+	p.LineTo(f32.Pt(x1-r, top))
+	p.QuadTo(f32.Pt(x1, top), f32.Pt(x1, top+r))
+	p.LineTo(f32.Pt(x1, bottom))
+	p.Close()
+	paint.FillShape(gtx.Ops, c, clip.Outline{Path: p.End()}.Op())
+}
@@ -0,0 +1,105 @@
+package widgets
+
+import (
+	"fmt"
+
+	"github.com/d093w1z/gio/io/key"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// TaskEntry names what the session is for. It can be edited while
+// editable, and onChange gets every edit; otherwise it shows task read-only.
+// Enter leaves the field.
+func TaskEntry(th *material.Theme, editor *widget.Editor, task string, editable bool, onChange func(task string)) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		editor.SingleLine, editor.Submit = true, true
+		editor.ReadOnly = !editable
+		for {
+			ev, ok := editor.Update(gtx)
+			if !ok {
+				break
+			}
+			switch ev.(type) {
+			case widget.ChangeEvent:
+				if editable {
+					onChange(editor.Text())
+				}
+			case widget.SubmitEvent:
+				gtx.Execute(key.FocusCmd{})
+			}
+		}
+		// follow changes made elsewhere, e.g. by a cycle or the API
+		if (!editable || !gtx.Source.Focused(editor)) && editor.Text() != task {
+			editor.SetText(task)
+		}
+
+		gtx.Constraints.Max.X = gtx.Dp(unit.Dp(220))
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+		e := material.Editor(th, editor, "What are you working on?")
+		e.TextSize = unit.Sp(14)
+		e.Color = Colors().Text
+		e.HintColor = Colors().Muted
+		e.HintColor.A = 0x99
+		if !editable {
+			e.Color = Colors().Muted
+		}
+		return layout.UniformInset(unit.Dp(4)).Layout(gtx, e.Layout)
+	})
+}
+
+// TaskRow shows a task, its pomodoro count and buttons to pick it for the
+// next sessions and to take it off the list. The active task has no pick
+// button.
+func TaskRow(th *material.Theme, name string, pomodoros int, active bool, btnPick, btnRemove *widget.Clickable, onPick, onRemove func()) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		if btnPick.Clicked(gtx) {
+			onPick()
+		}
+		if btnRemove.Clicked(gtx) {
+			onRemove()
+		}
+		small := func(btnWidget *widget.Clickable, label, spoken string) layout.FlexChild {
+			return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				btn := material.Button(th, btnWidget, label)
+				btn.Background = Colors().Button
+				btn.Color = Colors().ButtonText
+				btn.TextSize = unit.Sp(10)
+				btn.Inset = layout.UniformInset(unit.Dp(4))
+				return layout.Inset{Left: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return Labelled(gtx, spoken, btn.Layout)
+				})
+			})
+		}
+		pick := small(btnPick, "PICK", "Work on "+name)
+		if active {
+			pick = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				l := material.Caption(th, "ACTIVE")
+				l.Color = Colors().Accent
+				return layout.Inset{Left: unit.Dp(4), Right: unit.Dp(4)}.Layout(gtx, l.Layout)
+			})
+		}
+		return layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2), Left: unit.Dp(12), Right: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					l := material.Body2(th, name)
+					l.Color = Colors().Text
+					return l.Layout(gtx)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					count := fmt.Sprintf("%d pomodoros", pomodoros)
+					if pomodoros == 1 {
+						count = "1 pomodoro"
+					}
+					l := material.Body2(th, count)
+					l.Color = Colors().Muted
+					return l.Layout(gtx)
+				}),
+				pick,
+				small(btnRemove, "REMOVE", "Remove "+name),
+			)
+		})
+	})
+}
@@ -0,0 +1,162 @@
+package widgets
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// Theme is the set of colors the window is drawn in.
+type Theme struct {
+	Background color.NRGBA
+	// Surface is behind the progress: the ring track, bar tracks and
+	// empty dots.
+	Surface color.NRGBA
+	Text    color.NRGBA
+	// Muted is for captions and text that cannot be changed.
+	Muted color.NRGBA
+	// Accent is for headings and the highlighted choice.
+	Accent     color.NRGBA
+	Button     color.NRGBA
+	ButtonText color.NRGBA
+	// Alert is for problems and the filled session dots.
+	Alert color.NRGBA
+	Ring  Ring
+}
+
+var (
+	// DarkTheme is the default: light text on black, the ring in amber.
+	DarkTheme = Theme{
+		Background: color.NRGBA{R: 0x01, G: 0x01, B: 0x01, A: 0xFF},
+		Surface:    color.NRGBA{R: 0x3D, G: 0x3D, B: 0x3D, A: 0xFF},
+		Text:       color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF},
+		Muted:      color.NRGBA{R: 0xBB, G: 0xBB, B: 0xBB, A: 0xFF},
+		Accent:     color.NRGBA{R: 0xFF, G: 0xA1, B: 0x2C, A: 0xFF},
+		Button:     color.NRGBA{R: 0x3D, G: 0x3D, B: 0x3D, A: 0xFF},
+		ButtonText: color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF},
+		Alert:      color.NRGBA{R: 0xF1, G: 0x1D, B: 0x28, A: 0xFF},
+		Ring:       FocusRing,
+	}
+	// LightTheme is dark text on an off-white background.
+	LightTheme = Theme{
+		Background: color.NRGBA{R: 0xF5, G: 0xF5, B: 0xF5, A: 0xFF},
+		Surface:    color.NRGBA{R: 0xDD, G: 0xDD, B: 0xDD, A: 0xFF},
+		Text:       color.NRGBA{R: 0x1A, G: 0x1A, B: 0x1A, A: 0xFF},
+		Muted:      color.NRGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xFF},
+		Accent:     color.NRGBA{R: 0xD9, G: 0x73, B: 0x06, A: 0xFF},
+		Button:     color.NRGBA{R: 0xDD, G: 0xDD, B: 0xDD, A: 0xFF},
+		ButtonText: color.NRGBA{R: 0x1A, G: 0x1A, B: 0x1A, A: 0xFF},
+		Alert:      color.NRGBA{R: 0xD0, G: 0x10, B: 0x1C, A: 0xFF},
+		Ring:       FocusRing,
+	}
+)
+
+var (
+	// BreakTint is the calm teal the background leans to during breaks.
+	BreakTint = color.NRGBA{R: 0x00, G: 0x80, B: 0x80, A: 0xFF}
+	// WarningTint is the red the background leans to as a focus session
+	// ends.
+	WarningTint = color.NRGBA{R: 0xF1, G: 0x1D, B: 0x28, A: 0xFF}
+)
+
+// Themes are the built-in presets by name.
+var Themes = map[string]Theme{
+	"dark":  DarkTheme,
+	"light": LightTheme,
+}
+
+// themeColors name the colors of a Theme for the config.
+var themeColors = map[string]func(*Theme) *color.NRGBA{
+	"background":  func(t *Theme) *color.NRGBA { return &t.Background },
+	"surface":     func(t *Theme) *color.NRGBA { return &t.Surface },
+	"text":        func(t *Theme) *color.NRGBA { return &t.Text },
+	"muted":       func(t *Theme) *color.NRGBA { return &t.Muted },
+	"accent":      func(t *Theme) *color.NRGBA { return &t.Accent },
+	"button":      func(t *Theme) *color.NRGBA { return &t.Button },
+	"button_text": func(t *Theme) *color.NRGBA { return &t.ButtonText },
+	"alert":       func(t *Theme) *color.NRGBA { return &t.Alert },
+	"ring_start":  func(t *Theme) *color.NRGBA { return &t.Ring.Start },
+	"ring_end":    func(t *Theme) *color.NRGBA { return &t.Ring.End },
+}
+
+// Set changes the color called name, e.g. "background" or "ring_end", to
+// a "#RRGGBB" or "#RRGGBBAA" value.
+func (t *Theme) Set(name, value string) error {
+	field, ok := themeColors[name]
+	if !ok {
+		names := make([]string, 0, len(themeColors))
+		for n := range themeColors {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown color %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	c, err := ParseColor(value)
+	if err != nil {
+		return err
+	}
+	*field(t) = c
+	return nil
+}
+
+// ParseColor reads a "#RRGGBB" or "#RRGGBBAA" color.
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(s) != len(hex)+1 || (len(hex) != 6 && len(hex) != 8) {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, want #RRGGBB or #RRGGBBAA", s)
+	}
+	if len(hex) == 6 {
+		v = v<<8 | 0xFF
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Tint returns t with its background f (0 to 1) of the way to c.
+func (t Theme) Tint(c color.NRGBA, f float32) Theme {
+	bg := lerpColor(t.Background, c, f)
+	bg.A = t.Background.A
+	t.Background = bg
+	return t
+}
+
+// Blend returns the theme f (0 to 1) of the way from t to u, every color
+// shifting together.
+func (t Theme) Blend(u Theme, f float32) Theme {
+	f = max(0, min(f, 1))
+	out := t
+	for _, field := range themeColors {
+		*field(&out) = lerpColor(*field(&t), *field(&u), f)
+	}
+	return out
+}
+
+// Material carries the colors over to the stock material widgets, such as
+// editors.
+func (t Theme) Material(th *material.Theme) {
+	th.Palette = material.Palette{Bg: t.Background, Fg: t.Text, ContrastBg: t.Button, ContrastFg: t.ButtonText}
+}
+
+var (
+	themeMu sync.RWMutex
+	current = DarkTheme
+)
+
+// UseTheme makes the widgets draw in t from the next frame on.
+func UseTheme(t Theme) {
+	themeMu.Lock()
+	defer themeMu.Unlock()
+	current = t
+}
+
+// Colors returns the theme the widgets draw in.
+func Colors() Theme {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	return current
+}
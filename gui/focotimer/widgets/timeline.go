@@ -0,0 +1,44 @@
+package widgets
+
+import (
+	"image"
+	"time"
+
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/gio/layout"
+	"github.com/d093w1z/gio/unit"
+	"github.com/d093w1z/gio/widget/material"
+)
+
+// Timeline shows the sessions of one day as colored blocks on a 24 hour
+// bar, with the date above and an hour axis below.
+func Timeline(th *material.Theme, day time.Time, blocks []history.Block) layout.FlexChild {
+	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					title := day.Format("Monday, 2 January 2006")
+					if len(blocks) == 0 {
+						title += " (no sessions)"
+					}
+					l := material.Body1(th, title)
+					l.Color = Colors().Text
+					return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, l.Layout)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					height := gtx.Dp(unit.Dp(24))
+					FillSpan(gtx, 0, 1, height, Colors().Surface)
+					for _, b := range blocks {
+						from, to := b.Span(day)
+						FillSpan(gtx, from, to, height, KindColors[b.Kind])
+					}
+					return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, height)}
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+				layout.Rigid(HourAxis(th, 3)),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(Legend(th)),
+			)
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+// ------------------- Digest -------------------
+
+// Digest delivers the report of each finished period once: written to a
+// file, piped to a command (e.g. "msmtp me@example.com"), or both. The
+// last delivered period is remembered in StatePath so restarts neither
+// skip nor repeat a digest.
+type Digest struct {
+	Period      Period
+	HistoryPath string
+	// Path is the file to write; "{from}" and "{to}" are replaced by the
+	// period's dates.
+	Path string
+	// Command is run with sh -c and gets the report on stdin.
+	Command   string
+	StatePath string
+
+	now func() time.Time
+}
+
+// DefaultDigestState returns where the last delivered digest is recorded.
+func DefaultDigestState() string {
+	return filepath.Join(Dir(), "digest.last")
+}
+
+func (d *Digest) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// Due returns the previous period if its digest was not delivered yet.
+func (d *Digest) Due() (from, to time.Time, due bool, err error) {
+	from, to, err = d.Period.Previous(d.clock())
+	if err != nil {
+		return from, to, false, err
+	}
+	data, err := d.state().Read()
+	if err == nil || errors.Is(err, safefile.ErrRecovered) {
+		last, perr := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		if perr == nil && !last.Before(from) {
+			return from, to, false, nil
+		}
+	}
+	return from, to, true, nil
+}
+
+// Deliver renders the report for [from, to) and sends it to the configured
+// destinations, then records the period as delivered.
+func (d *Digest) Deliver(from, to time.Time) error {
+	records, err := Load(d.HistoryPath)
+	if errors.Is(err, safefile.ErrDamaged) {
+		log.Printf("history.Digest: %v", err)
+	} else if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records, from, to); err != nil {
+		return err
+	}
+
+	if d.Path != "" {
+		path := strings.NewReplacer(
+			"{from}", from.Format(time.DateOnly),
+			"{to}", to.AddDate(0, 0, -1).Format(time.DateOnly),
+		).Replace(d.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("digest: %w", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("digest: %w", err)
+		}
+	}
+	if d.Command != "" {
+		cmd := exec.Command("sh", "-c", d.Command)
+		cmd.Stdin = bytes.NewReader(buf.Bytes())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("digest: %s: %w: %s", d.Command, err, bytes.TrimSpace(out))
+		}
+	}
+
+	if err := d.state().Write([]byte(from.Format(time.RFC3339) + "\n")); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	return nil
+}
+
+// state is the file remembering the last delivered period. A damaged one
+// falls back to the period before, at worst sending a digest twice.
+func (d *Digest) state() safefile.File {
+	return safefile.File{Path: d.StatePath, Checksum: true}
+}
+
+// Run checks hourly for a due digest and delivers it, until stop is
+// closed. Checking by the clock instead of sleeping until the period ends
+// keeps it correct across suspend.
+func (d *Digest) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if from, to, due, err := d.Due(); err != nil {
+			log.Printf("history.Digest: %v", err)
+			return
+		} else if due {
+			if err := d.Deliver(from, to); err != nil {
+				log.Printf("history.Digest: %v", err)
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
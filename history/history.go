@@ -0,0 +1,197 @@
+// Package history stores finished focus sessions as JSON lines, one record
+// per session, so statistics and integrations can look back at them.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+type Record struct {
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	Label    string        `json:"label,omitempty"`
+	// Issue is the issue reference the session was worked against,
+	// e.g. "GH-123" or "PROJ-42".
+	Issue string `json:"issue,omitempty"`
+	// Completed is false for sessions reset before their time was up.
+	Completed bool `json:"completed"`
+	// EnergyStart and EnergyEnd rate the user's energy from 1 to 5 when
+	// the session started and ended; zero when unrated.
+	EnergyStart int `json:"energy_start,omitempty"`
+	EnergyEnd   int `json:"energy_end,omitempty"`
+	// BreakTaken marks a completed focus session whose following break
+	// was completed too, rather than skipped or cut short.
+	BreakTaken bool `json:"break_taken,omitempty"`
+	// Break marks a break rather than focus work. It is nil in records
+	// written before sessions were marked; see IsBreak.
+	Break *bool `json:"break,omitempty"`
+}
+
+// IsBreak reports whether the record is of a break: as marked, or for
+// older records without the mark, by its label.
+func (r Record) IsBreak() bool {
+	if r.Break != nil {
+		return *r.Break
+	}
+	return IsBreak(r.Label)
+}
+
+// Dir returns the focotimer data directory, honouring XDG_DATA_HOME.
+func Dir() string {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "focotimer")
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(base, "focotimer")
+}
+
+// DefaultPath returns the path of the history file. FOCOTIMER_HISTORY
+// overrides it.
+func DefaultPath() string {
+	if p := os.Getenv("FOCOTIMER_HISTORY"); p != "" {
+		return p
+	}
+	return filepath.Join(Dir(), "history.jsonl")
+}
+
+// file is the history at path, for the changes that rewrite it: written
+// atomically with a backup. Appends only add a line to its end. People
+// may edit it by hand, so it is not checksummed.
+func file(path string) safefile.File {
+	return safefile.File{Path: path}
+}
+
+// Append adds a record to the end of the history at path, creating the
+// file and its directory as needed. A last line a crash left half-written
+// is cut off first, so it cannot hide the records after it.
+func Append(path string, r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	defer f.Close()
+	end, err := lineEnd(f)
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	if _, err := f.Write(append([]byte(end), append(line, '\n')...)); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	return nil
+}
+
+// lineEnd readies f for a line to be appended, returning what must come
+// before it: nothing when f ends in a newline, a newline when its last
+// line is a record saved without one, e.g. by an editor. A last line that
+// does not parse is the end of an interrupted append, and is truncated.
+func lineEnd(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return "", err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return "", err
+	}
+	if last[0] == '\n' {
+		return "", nil
+	}
+	data := make([]byte, info.Size())
+	if _, err := f.ReadAt(data, 0); err != nil {
+		return "", err
+	}
+	start := bytes.LastIndexByte(data, '\n') + 1
+	var r Record
+	if json.Unmarshal(data[start:], &r) == nil {
+		return "\n", nil
+	}
+	log.Printf("history: dropping the half-written last line of %q", f.Name())
+	return "", f.Truncate(int64(start))
+}
+
+// Load reads all records at path. A missing file is an empty history. The
+// records before a line that does not parse are returned along with an
+// error wrapping safefile.ErrDamaged.
+func Load(path string) ([]Record, error) {
+	data, err := file(path).Read()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	records, err := parse(data)
+	if err != nil {
+		return records, fmt.Errorf("history: %w %q: %w", safefile.ErrDamaged, path, err)
+	}
+	return records, nil
+}
+
+// parse reads JSON lines up to the first that does not parse.
+func parse(data []byte) ([]Record, error) {
+	var records []Record
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return records, fmt.Errorf("line %d: %w", n, err)
+		}
+		records = append(records, r)
+	}
+	return records, sc.Err()
+}
+
+// Amend changes the last record at path that started at start, e.g. to add
+// a rating given after the session was recorded. Other lines are kept as
+// they are. It is an error if no record matches.
+func Amend(path string, start time.Time, change func(*Record)) error {
+	f := file(path)
+	data, err := f.Read()
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var r Record
+		if len(lines[i]) == 0 || json.Unmarshal(lines[i], &r) != nil || !r.Start.Equal(start) {
+			continue
+		}
+		change(&r)
+		if lines[i], err = json.Marshal(r); err != nil {
+			return fmt.Errorf("history: %w", err)
+		}
+		if err := f.Write(bytes.Join(lines, []byte("\n"))); err != nil {
+			return fmt.Errorf("history: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("history: no session started at %s", start.Format(time.RFC3339))
+}
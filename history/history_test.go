@@ -0,0 +1,498 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Start: start, End: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Label: "writing", Issue: "GH-123", Completed: true},
+		{Start: start.Add(30 * time.Minute), End: start.Add(40 * time.Minute), Duration: 25 * time.Minute},
+	}
+	for _, r := range records {
+		if err := Append(path, r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(got))
+	}
+	if got[0].Issue != "GH-123" || !got[0].Completed || !got[0].End.Equal(records[0].End) {
+		t.Errorf("Unexpected first record %+v", got[0])
+	}
+	if got[1].Completed {
+		t.Error("Expected second record to be incomplete")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || got != nil {
+		t.Errorf("Expected empty history, got %v, %v", got, err)
+	}
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"start":"2024-03-01T09:00:00Z","completed":true}` + "\n{broken\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write history: %v", err)
+	}
+	got, err := Load(path)
+	if !errors.Is(err, safefile.ErrDamaged) {
+		t.Errorf("Expected the corrupt line reported as damage, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("Expected the records before the corrupt line, got %d", len(got))
+	}
+}
+
+func TestAppend_HandEdited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := range 2 {
+		if err := Append(path, Record{Start: start.Add(time.Duration(i) * time.Hour), Label: "writing", Completed: true}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	// Relabel a session and save without a final newline, as an editor
+	// might.
+	data, _ := os.ReadFile(path)
+	data = bytes.TrimSuffix(bytes.Replace(data, []byte("writing"), []byte("review"), 1), []byte("\n"))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Append(path, Record{Start: start.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("Expected 3 records, got %+v, %v", got, err)
+	}
+	if got[0].Label != "review" || got[1].Label != "writing" {
+		t.Errorf("Expected the edit kept, got %q and %q", got[0].Label, got[1].Label)
+	}
+}
+
+func TestAppend_TornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := Append(path, Record{Start: start, Completed: true}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	// A crash in the middle of the next append.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"start":"2024-03-01T10:0`)
+	f.Close()
+
+	if err := Append(path, Record{Start: start.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil || len(got) != 2 || got[1].Start.Hour() != 11 {
+		t.Errorf("Expected the half-written line dropped, got %+v, %v", got, err)
+	}
+}
+
+func TestDefaultPath_Env(t *testing.T) {
+	t.Setenv("FOCOTIMER_HISTORY", "/custom/history.jsonl")
+	if p := DefaultPath(); p != "/custom/history.jsonl" {
+		t.Errorf("Expected FOCOTIMER_HISTORY to be used, got %s", p)
+	}
+	t.Setenv("FOCOTIMER_HISTORY", "")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if p := DefaultPath(); p != "/data/focotimer/history.jsonl" {
+		t.Errorf("Expected XDG_DATA_HOME to be used, got %s", p)
+	}
+}
+
+func TestPeriod_Bounds(t *testing.T) {
+	wed := time.Date(2024, 3, 6, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period     Period
+		start, end time.Time
+	}{
+		{Weekly, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{Monthly, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		start, end, err := tt.period.Bounds(wed)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.period, err)
+		}
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: expected [%v, %v), got [%v, %v)", tt.period, tt.start, tt.end, start, end)
+		}
+	}
+
+	start, _, _ := Weekly.Previous(wed)
+	if want := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("Expected previous week to start %v, got %v", want, start)
+	}
+	if _, _, err := Period("daily").Bounds(wed); err == nil {
+		t.Error("Expected error for unknown period")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Start: day, End: day.Add(25 * time.Minute), Label: "writing", Completed: true},
+		{Start: day.Add(time.Hour), End: day.Add(time.Hour + 10*time.Minute), Label: "writing"},
+		{Start: day.AddDate(0, 0, 1), End: day.AddDate(0, 0, 1).Add(50 * time.Minute), Label: "review", Completed: true},
+		{Start: day.AddDate(0, 0, 7), End: day.AddDate(0, 0, 7).Add(time.Hour), Label: "next week"},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records, day.Add(-9*time.Hour), day.AddDate(0, 0, 7).Add(-9*time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "date,label,sessions,completed,focus_minutes\n" +
+		"2024-03-04,writing,2,1,35.0\n" +
+		"2024-03-05,review,1,1,50.0\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestGoal_Progress(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	records := []Record{
+		{Start: at(9), Completed: true, BreakTaken: true},
+		{Start: at(10), Label: "break", Completed: true},
+		{Start: at(11), Completed: true},
+		{Start: at(12), Completed: false},
+		{Start: at(-2), Completed: true, BreakTaken: true}, // the day before
+	}
+	if n := (Goal{Daily: 4}).Progress(records, at(18)); n != 2 {
+		t.Errorf("Expected 2 completed sessions, got %d", n)
+	}
+	if n := (Goal{Daily: 4, RequireBreaks: true}).Progress(records, at(18)); n != 1 {
+		t.Errorf("Expected 1 session followed by a break, got %d", n)
+	}
+}
+
+func TestStreak(t *testing.T) {
+	today := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	session := func(daysAgo int, label string, completed bool) Record {
+		start := today.AddDate(0, 0, -daysAgo).Add(-5 * time.Hour)
+		return Record{Start: start, End: start.Add(25 * time.Minute), Label: label, Completed: completed}
+	}
+	tests := []struct {
+		name             string
+		records          []Record
+		current, longest int
+	}{
+		{"none", nil, 0, 0},
+		{"through today", []Record{session(0, "", true), session(1, "", true), session(2, "", true)}, 3, 3},
+		{"today still open", []Record{session(1, "", true), session(2, "", true)}, 2, 2},
+		{"broken", []Record{session(0, "", true), session(2, "", true), session(3, "", true), session(4, "", true)}, 1, 3},
+		{"breaks and resets do not count", []Record{session(0, "short break", true), session(1, "", false), session(2, "", true)}, 0, 1},
+	}
+	for _, tt := range tests {
+		current, longest := Streak(tt.records, today)
+		if current != tt.current || longest != tt.longest {
+			t.Errorf("%s: expected %d/%d, got %d/%d", tt.name, tt.current, tt.longest, current, longest)
+		}
+	}
+}
+
+func TestDigest_DeliversOnce(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, "history.jsonl")
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	if err := Append(historyPath, Record{Start: start, End: start.Add(25 * time.Minute), Label: "writing", Completed: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now := time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC)
+	d := &Digest{
+		Period:      Weekly,
+		HistoryPath: historyPath,
+		Path:        filepath.Join(dir, "out", "focus-{from}-{to}.csv"),
+		Command:     "cat > " + filepath.Join(dir, "mail.txt"),
+		StatePath:   filepath.Join(dir, "digest.last"),
+		now:         func() time.Time { return now },
+	}
+
+	from, to, due, err := d.Due()
+	if err != nil || !due {
+		t.Fatalf("Expected last week to be due, got %v, %v", due, err)
+	}
+	if err := d.Deliver(from, to); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "out", "focus-2024-03-04-2024-03-10.csv"))
+	if err != nil {
+		t.Fatalf("Expected report file: %v", err)
+	}
+	if !strings.Contains(string(written), "2024-03-04,writing,1,1,25.0") {
+		t.Errorf("Unexpected report %q", written)
+	}
+	piped, err := os.ReadFile(filepath.Join(dir, "mail.txt"))
+	if err != nil || !bytes.Equal(piped, written) {
+		t.Errorf("Expected the command to receive the report, got %q, %v", piped, err)
+	}
+
+	if _, _, due, _ := d.Due(); due {
+		t.Error("Expected the digest not to be due again in the same week")
+	}
+	now = now.AddDate(0, 0, 7)
+	if _, _, due, _ := d.Due(); !due {
+		t.Error("Expected the digest to be due the following week")
+	}
+}
+
+func TestDigest_CommandError(t *testing.T) {
+	dir := t.TempDir()
+	d := &Digest{
+		Period:      Monthly,
+		HistoryPath: filepath.Join(dir, "history.jsonl"),
+		Command:     "exit 3",
+		StatePath:   filepath.Join(dir, "digest.last"),
+	}
+	from, to, _, _ := d.Due()
+	if err := d.Deliver(from, to); err == nil {
+		t.Error("Expected error from failing command")
+	}
+	if _, _, due, _ := d.Due(); !due {
+		t.Error("Expected a failed digest to stay due")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	day := time.Date(2025, 9, 2, 0, 0, 0, 0, loc)
+	at := func(d, h, m int) time.Time { return time.Date(2025, 9, d, h, m, 0, 0, loc) }
+	records := []Record{
+		{Start: at(2, 14, 0), End: at(2, 14, 25), Label: "writing", Completed: true},
+		{Start: at(1, 23, 50), End: at(2, 0, 15), Label: "late", Completed: true},
+		{Start: at(2, 14, 25), End: at(2, 14, 30), Label: "Long Break", Completed: true},
+		{Start: at(2, 15, 0), End: at(2, 15, 5), Label: "writing", Completed: false},
+		{Start: at(2, 23, 55), End: at(3, 0, 20), Completed: true},
+		{Start: at(3, 9, 0), End: at(3, 9, 25), Completed: true},
+	}
+
+	blocks := Timeline(records, day)
+	want := []struct {
+		start, end time.Time
+		kind       Kind
+	}{
+		{day, at(2, 0, 15), Focus},
+		{at(2, 14, 0), at(2, 14, 25), Focus},
+		{at(2, 14, 25), at(2, 14, 30), Break},
+		{at(2, 15, 0), at(2, 15, 5), Abandoned},
+		{at(2, 23, 55), at(3, 0, 0), Focus},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("Expected %d blocks, got %d: %+v", len(want), len(blocks), blocks)
+	}
+	for i, w := range want {
+		b := blocks[i]
+		if !b.Start.Equal(w.start) || !b.End.Equal(w.end) || b.Kind != w.kind {
+			t.Errorf("Block %d: expected %v-%v %s, got %v-%v %s", i, w.start, w.end, w.kind, b.Start, b.End, b.Kind)
+		}
+	}
+
+	from, to := blocks[1].Span(day)
+	if math.Abs(from-14.0/24) > 1e-9 || math.Abs(to-from-25.0/(24*60)) > 1e-9 {
+		t.Errorf("Expected the 14:00 session to span 14/24 for 25 minutes, got %v to %v", from, to)
+	}
+	if from, to := blocks[4].Span(day); to != 1 || from >= to {
+		t.Errorf("Expected the last block to end at the end of the day, got %v to %v", from, to)
+	}
+}
+
+func TestAmend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2025, 9, 2, 9, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		r := Record{Start: start.Add(time.Duration(i) * time.Hour), Duration: 25 * time.Minute, Completed: true}
+		if err := Append(path, r); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if err := Amend(path, start.Add(time.Hour), func(r *Record) { r.EnergyEnd = 2 }); err != nil {
+		t.Fatalf("Amend failed: %v", err)
+	}
+	records, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(records) != 3 || records[1].EnergyEnd != 2 || records[0].EnergyEnd != 0 || records[2].EnergyEnd != 0 {
+		t.Errorf("Expected only the second record amended, got %+v", records)
+	}
+
+	if err := Amend(path, start.Add(time.Minute), func(r *Record) {}); err == nil {
+		t.Error("Expected an error when no record matches")
+	}
+	if err := Amend(filepath.Join(t.TempDir(), "missing.jsonl"), start, func(r *Record) {}); err == nil {
+		t.Error("Expected an error for a missing history")
+	}
+}
+
+func TestEnergyInsights(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2025, 9, 2, h, 0, 0, 0, time.Local) }
+	records := []Record{
+		{Start: at(9), Duration: 25 * time.Minute, EnergyStart: 5, EnergyEnd: 4},
+		{Start: at(10), Duration: 50 * time.Minute, EnergyStart: 4, EnergyEnd: 2},
+		{Start: at(14), Duration: 50 * time.Minute, EnergyStart: 2},
+		{Start: at(15), Duration: 15 * time.Minute, EnergyEnd: 3},
+		{Start: at(15), Duration: 5 * time.Minute, Label: "break", EnergyStart: 1},
+		{Start: at(20), Duration: 25 * time.Minute},
+	}
+	in := EnergyInsights(records)
+
+	type avg struct {
+		rated             int
+		start, end, tired float64
+	}
+	check := func(b EnergyBucket, want avg) {
+		t.Helper()
+		got := avg{b.Rated, b.Start, b.End, b.Fatigue}
+		if got != want {
+			t.Errorf("%s: expected %+v, got %+v", b.Name, want, got)
+		}
+	}
+	if len(in.ByTimeOfDay) != 4 || len(in.ByLength) != 3 {
+		t.Fatalf("Expected every bucket present, got %+v", in)
+	}
+	check(in.ByTimeOfDay[0], avg{})
+	check(in.ByTimeOfDay[1], avg{2, 4.5, 3, 1.5})
+	check(in.ByTimeOfDay[2], avg{2, 2, 3, 0})
+	check(in.ByTimeOfDay[3], avg{})
+	check(in.ByLength[0], avg{1, 0, 3, 0})
+	check(in.ByLength[1], avg{1, 5, 4, 1})
+	check(in.ByLength[2], avg{2, 3, 2, 2})
+
+	if peak, ok := in.Peak(); !ok || peak.Name != "morning" {
+		t.Errorf("Expected the morning to be the peak, got %q, %v", peak.Name, ok)
+	}
+	if _, ok := EnergyInsights(nil).Peak(); ok {
+		t.Error("Expected no peak without ratings")
+	}
+}
+
+func TestTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	tasks, err := LoadTasks(path)
+	if err != nil || len(tasks.Names) != 0 {
+		t.Fatalf("Expected an empty list for a missing file, got %+v, %v", tasks, err)
+	}
+
+	if !tasks.Add(" docs ") || !tasks.Add("review") || tasks.Add("docs") || tasks.Add("  ") {
+		t.Errorf("Expected only new, non-empty names to be added, got %q", tasks.Names)
+	}
+	if tasks.Pick("nothing") || !tasks.Pick("docs") {
+		t.Errorf("Expected only listed tasks to be picked, got %q", tasks.Active)
+	}
+	if err := SaveTasks(path, tasks); err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+	got, err := LoadTasks(path)
+	if err != nil {
+		t.Fatalf("LoadTasks failed: %v", err)
+	}
+	if strings.Join(got.Names, ",") != "docs,review" || got.Active != "docs" {
+		t.Errorf("Expected the saved list, got %+v", got)
+	}
+
+	got.Remove("docs")
+	if strings.Join(got.Names, ",") != "review" || got.Active != "" {
+		t.Errorf("Expected docs removed and unpicked, got %+v", got)
+	}
+}
+
+func TestPomodoros(t *testing.T) {
+	records := []Record{
+		{Label: "docs", Completed: true},
+		{Label: "docs", Completed: true},
+		{Label: "docs"},
+		{Label: "break", Completed: true},
+		{Label: "review", Completed: true},
+	}
+	counts := Pomodoros(records)
+	if counts["docs"] != 2 || counts["review"] != 1 || counts["break"] != 0 {
+		t.Errorf("Expected 2 docs and 1 review, got %v", counts)
+	}
+}
+
+func TestRecord_IsBreak(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		rec  Record
+		want bool
+	}{
+		{Record{Label: "fix-line-breaks", Break: &no}, false},
+		{Record{Label: "stretch", Break: &yes}, true},
+		{Record{Label: "long break"}, true}, // written before the mark
+		{Record{Label: "docs"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rec.IsBreak(); got != tt.want {
+			t.Errorf("IsBreak(%q) = %v, expected %v", tt.rec.Label, got, tt.want)
+		}
+	}
+
+	start := time.Date(2025, 9, 2, 9, 0, 0, 0, time.UTC)
+	summaries := Summarize([]Record{
+		{Start: start, End: start.Add(25 * time.Minute), Label: "walk", Break: &no},
+		{Start: start.Add(time.Hour), End: start.Add(65 * time.Minute), Label: "walk", Break: &yes},
+	}, Day(start), Day(start).AddDate(0, 0, 1))
+	if len(summaries) != 2 || summaries[0].Break || !summaries[1].Break {
+		t.Errorf("Expected the focus and break walks summarized apart, got %+v", summaries)
+	}
+}
+
+func TestTallies(t *testing.T) {
+	now := time.Date(2025, 9, 3, 15, 0, 0, 0, time.UTC) // a Wednesday
+	session := func(start time.Time, label string, completed bool) Record {
+		return Record{Start: start, End: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Label: label, Completed: completed}
+	}
+	records := []Record{
+		session(now.Add(-time.Hour), "docs", true),
+		session(now.Add(-time.Hour), "break", true),
+		session(now.AddDate(0, 0, -1), "docs", false),
+		session(now.AddDate(0, 0, -1), "docs", true),
+		session(now.AddDate(0, 0, -3), "review", true), // the Sunday before
+		session(now.AddDate(0, 0, -30), "old", true),
+	}
+
+	daily := DailyTallies(records, now, 7)
+	if len(daily) != 7 || !daily[0].From.Equal(time.Date(2025, 8, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 7 days from 28 August, got %+v", daily)
+	}
+	if d := daily[6]; d.Focus != 25*time.Minute || d.Pomodoros != 1 {
+		t.Errorf("Expected today's break left out, got %+v", d)
+	}
+	if d := daily[5]; d.Focus != 50*time.Minute || d.Pomodoros != 1 {
+		t.Errorf("Expected yesterday's abandoned session as focus only, got %+v", d)
+	}
+
+	weekly := WeeklyTallies(records, now, 2)
+	if !weekly[1].From.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected this week to start on Monday, got %v", weekly[1].From)
+	}
+	if weekly[0].Pomodoros != 1 || weekly[1].Pomodoros != 2 {
+		t.Errorf("Expected 1 and 2 pomodoros, got %+v", weekly)
+	}
+}
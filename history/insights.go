@@ -0,0 +1,125 @@
+package history
+
+import "time"
+
+// EnergyBucket averages the energy ratings of the sessions in one group,
+// e.g. those started in the morning or those 25 to 50 minutes long.
+type EnergyBucket struct {
+	Name string
+	// Rated counts the sessions with at least one rating.
+	Rated int
+	// Start and End average the start and end ratings; zero when none
+	// were given.
+	Start, End float64
+	// Fatigue averages how much energy sessions rated at both ends cost,
+	// start minus end; negative when sessions left the user fresher.
+	Fatigue float64
+
+	starts, ends, pairs int
+}
+
+// Insights correlates energy ratings with when and how long the user works.
+type Insights struct {
+	ByTimeOfDay []EnergyBucket
+	ByLength    []EnergyBucket
+}
+
+// timesOfDay split the day by the hour a session starts.
+var timesOfDay = []struct {
+	name     string
+	from, to int
+}{
+	{"night", 0, 6},
+	{"morning", 6, 12},
+	{"afternoon", 12, 17},
+	{"evening", 17, 24},
+}
+
+// sessionLengths split sessions by their planned duration.
+var sessionLengths = []struct {
+	name  string
+	below time.Duration
+}{
+	{"under 25m", 25 * time.Minute},
+	{"25m to 50m", 50 * time.Minute},
+	{"50m and more", 1<<63 - 1},
+}
+
+// EnergyInsights groups the rated focus sessions of records by time of day
+// and by length. Breaks are left out. Every group is present, empty ones
+// with zero Rated.
+func EnergyInsights(records []Record) Insights {
+	var in Insights
+	for _, t := range timesOfDay {
+		in.ByTimeOfDay = append(in.ByTimeOfDay, EnergyBucket{Name: t.name})
+	}
+	for _, l := range sessionLengths {
+		in.ByLength = append(in.ByLength, EnergyBucket{Name: l.name})
+	}
+
+	for _, r := range records {
+		if (r.EnergyStart == 0 && r.EnergyEnd == 0) || r.IsBreak() {
+			continue
+		}
+		hour := r.Start.Hour()
+		for i, t := range timesOfDay {
+			if hour >= t.from && hour < t.to {
+				in.ByTimeOfDay[i].add(r)
+			}
+		}
+		for i, l := range sessionLengths {
+			if r.Duration < l.below {
+				in.ByLength[i].add(r)
+				break
+			}
+		}
+	}
+	for i := range in.ByTimeOfDay {
+		in.ByTimeOfDay[i].finish()
+	}
+	for i := range in.ByLength {
+		in.ByLength[i].finish()
+	}
+	return in
+}
+
+// add sums a rated record; finish turns the sums into averages.
+func (b *EnergyBucket) add(r Record) {
+	b.Rated++
+	if r.EnergyStart > 0 {
+		b.Start += float64(r.EnergyStart)
+		b.starts++
+	}
+	if r.EnergyEnd > 0 {
+		b.End += float64(r.EnergyEnd)
+		b.ends++
+	}
+	if r.EnergyStart > 0 && r.EnergyEnd > 0 {
+		b.Fatigue += float64(r.EnergyStart - r.EnergyEnd)
+		b.pairs++
+	}
+}
+
+func (b *EnergyBucket) finish() {
+	if b.starts > 0 {
+		b.Start /= float64(b.starts)
+	}
+	if b.ends > 0 {
+		b.End /= float64(b.ends)
+	}
+	if b.pairs > 0 {
+		b.Fatigue /= float64(b.pairs)
+	}
+}
+
+// Peak returns the time of day the user starts sessions with the most
+// energy, the best slot for hard work. It is false without start ratings.
+func (in Insights) Peak() (EnergyBucket, bool) {
+	var best EnergyBucket
+	for _, b := range in.ByTimeOfDay {
+		if b.starts > 0 && b.Start > best.Start {
+			best = b
+		}
+	}
+	return best, best.starts > 0
+}
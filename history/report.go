@@ -0,0 +1,179 @@
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ------------------- Reports -------------------
+
+// Period is the span a report covers.
+type Period string
+
+const (
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+)
+
+// Bounds returns the period containing t as [start, end). Weeks start on
+// Monday.
+func (p Period) Bounds(t time.Time) (start, end time.Time, err error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch p {
+	case Weekly:
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		start = day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7), nil
+	case Monthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown report period %q", p)
+}
+
+// Previous returns the period before the one containing t.
+func (p Period) Previous(t time.Time) (start, end time.Time, err error) {
+	start, _, err = p.Bounds(t)
+	if err != nil {
+		return start, start, err
+	}
+	return p.Bounds(start.Add(-time.Nanosecond))
+}
+
+// Summary aggregates the sessions of one day and label. Breaks are
+// summarized apart from focus sessions of the same label.
+type Summary struct {
+	Day       time.Time
+	Label     string
+	Break     bool
+	Sessions  int
+	Completed int
+	Focus     time.Duration
+}
+
+// Summarize groups the records started within [from, to) by day and label.
+// Focus counts the time between start and end of each session.
+func Summarize(records []Record, from, to time.Time) []Summary {
+	type key struct {
+		day   time.Time
+		label string
+		brk   bool
+	}
+	byKey := make(map[key]*Summary)
+	for _, r := range records {
+		if r.Start.Before(from) || !r.Start.Before(to) {
+			continue
+		}
+		k := key{Day(r.Start.In(from.Location())), r.Label, r.IsBreak()}
+		s, ok := byKey[k]
+		if !ok {
+			s = &Summary{Day: k.day, Label: k.label, Break: k.brk}
+			byKey[k] = s
+		}
+		s.Sessions++
+		if r.Completed {
+			s.Completed++
+		}
+		s.Focus += r.End.Sub(r.Start)
+	}
+
+	out := make([]Summary, 0, len(byKey))
+	for _, s := range byKey {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Day.Equal(out[j].Day) {
+			return out[i].Day.Before(out[j].Day)
+		}
+		if out[i].Label != out[j].Label {
+			return out[i].Label < out[j].Label
+		}
+		return !out[i].Break && out[j].Break
+	})
+	return out
+}
+
+// WriteCSV renders the report for [from, to) as CSV, one row per day and
+// label.
+func WriteCSV(w io.Writer, records []Record, from, to time.Time) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "label", "sessions", "completed", "focus_minutes"})
+	for _, s := range Summarize(records, from, to) {
+		cw.Write([]string{
+			s.Day.Format(time.DateOnly),
+			s.Label,
+			strconv.Itoa(s.Sessions),
+			strconv.Itoa(s.Completed),
+			strconv.FormatFloat(s.Focus.Minutes(), 'f', 1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Goal is a daily target of completed focus sessions.
+type Goal struct {
+	Daily int
+	// RequireBreaks counts only sessions whose following break was taken,
+	// so working through breaks does not pay.
+	RequireBreaks bool
+}
+
+// Progress counts the sessions of the day of t that count towards g.
+func (g Goal) Progress(records []Record, t time.Time) int {
+	from := Day(t)
+	to := from.AddDate(0, 0, 1)
+	n := 0
+	for _, r := range records {
+		start := r.Start.In(t.Location())
+		if !r.Completed || r.IsBreak() || start.Before(from) || !start.Before(to) {
+			continue
+		}
+		if g.RequireBreaks && !r.BreakTaken {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// Streak counts the days in a row up to today with at least one completed
+// focus session, and the longest such run in records. Today does not break
+// the current streak before it is over.
+func Streak(records []Record, today time.Time) (current, longest int) {
+	days := make(map[time.Time]bool)
+	for _, r := range records {
+		if r.Completed && !r.IsBreak() {
+			days[Day(r.Start.In(today.Location()))] = true
+		}
+	}
+
+	sorted := make([]time.Time, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	run := 0
+	for i, d := range sorted {
+		if i > 0 && sorted[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	d := Day(today)
+	if !days[d] {
+		d = d.AddDate(0, 0, -1)
+	}
+	for days[d] {
+		current++
+		d = d.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
@@ -0,0 +1,63 @@
+package history
+
+import "time"
+
+// ------------------- Statistics -------------------
+
+// Tally sums the focus sessions started within one day or week.
+type Tally struct {
+	From time.Time
+	// Focus counts the time between start and end of each session,
+	// finished or not.
+	Focus time.Duration
+	// Pomodoros counts the completed sessions.
+	Pomodoros int
+}
+
+// DailyTallies sums the focus sessions of the n days up to and including
+// the day of now, oldest first. Breaks are left out.
+func DailyTallies(records []Record, now time.Time, n int) []Tally {
+	today := Day(now)
+	return tallies(records, today.AddDate(0, 0, -(n-1)), n, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+}
+
+// WeeklyTallies sums the focus sessions of the n weeks up to and
+// including the week of now, oldest first. Weeks start on Monday.
+func WeeklyTallies(records []Record, now time.Time, n int) []Tally {
+	week, _, _ := Weekly.Bounds(now)
+	return tallies(records, week.AddDate(0, 0, -7*(n-1)), n, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 7)
+	})
+}
+
+func tallies(records []Record, from time.Time, n int, next func(time.Time) time.Time) []Tally {
+	if n <= 0 {
+		return nil
+	}
+	out := make([]Tally, n)
+	bounds := make([]time.Time, n+1)
+	bounds[0] = from
+	for i := range out {
+		out[i].From = bounds[i]
+		bounds[i+1] = next(bounds[i])
+	}
+	for _, r := range records {
+		if r.IsBreak() {
+			continue
+		}
+		start := r.Start.In(from.Location())
+		for i := range out {
+			if start.Before(bounds[i]) || !start.Before(bounds[i+1]) {
+				continue
+			}
+			out[i].Focus += r.End.Sub(r.Start)
+			if r.Completed {
+				out[i].Pomodoros++
+			}
+			break
+		}
+	}
+	return out
+}
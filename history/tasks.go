@@ -0,0 +1,114 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/d093w1z/focotimer/safefile"
+)
+
+// ------------------- Tasks -------------------
+
+// Tasks is the list of things the user works on and the one picked for
+// the next sessions. Sessions count towards the task their label names.
+type Tasks struct {
+	Names  []string `json:"names"`
+	Active string   `json:"active,omitempty"`
+}
+
+// DefaultTasksPath returns the path of the task list. FOCOTIMER_TASKS
+// overrides it.
+func DefaultTasksPath() string {
+	if p := os.Getenv("FOCOTIMER_TASKS"); p != "" {
+		return p
+	}
+	return filepath.Join(Dir(), "tasks.json")
+}
+
+func tasksFile(path string) safefile.File {
+	return safefile.File{
+		Path:     path,
+		Checksum: true,
+		Valid: func(data []byte) error {
+			return json.Unmarshal(data, new(Tasks))
+		},
+	}
+}
+
+// LoadTasks reads the task list at path. A missing file is an empty list,
+// and a damaged one is restored from its backup where possible.
+func LoadTasks(path string) (Tasks, error) {
+	var t Tasks
+	data, err := tasksFile(path).Read()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return t, nil
+	case errors.Is(err, safefile.ErrRecovered):
+		log.Printf("tasks: %v", err)
+	case err != nil:
+		return t, fmt.Errorf("tasks: %w", err)
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Tasks{}, fmt.Errorf("tasks %q: %w", path, err)
+	}
+	return t, nil
+}
+
+// SaveTasks writes the task list to path, creating its directory as
+// needed.
+func SaveTasks(path string, t Tasks) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("tasks: %w", err)
+	}
+	if err := tasksFile(path).Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("tasks: %w", err)
+	}
+	return nil
+}
+
+// Add appends a task, trimmed of spaces. It reports false for an empty
+// name or one already on the list.
+func (t *Tasks) Add(name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" || slices.Contains(t.Names, name) {
+		return false
+	}
+	t.Names = append(t.Names, name)
+	return true
+}
+
+// Remove takes a task off the list, and unpicks it if it was active.
+func (t *Tasks) Remove(name string) {
+	t.Names = slices.DeleteFunc(t.Names, func(n string) bool { return n == name })
+	if t.Active == name {
+		t.Active = ""
+	}
+}
+
+// Pick makes a task on the list the active one. It reports false for a
+// task not on the list.
+func (t *Tasks) Pick(name string) bool {
+	if !slices.Contains(t.Names, name) {
+		return false
+	}
+	t.Active = name
+	return true
+}
+
+// Pomodoros counts the completed focus sessions of each label.
+func Pomodoros(records []Record) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range records {
+		if r.Kind() == Focus {
+			counts[r.Label]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind classifies a session for display.
+type Kind int
+
+const (
+	Focus Kind = iota
+	Break
+	// Abandoned sessions were reset before their time was up.
+	Abandoned
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Break:
+		return "break"
+	case Abandoned:
+		return "abandoned"
+	}
+	return "focus"
+}
+
+// Kind tells focus sessions from breaks, see IsBreak.
+func (r Record) Kind() Kind {
+	switch {
+	case !r.Completed:
+		return Abandoned
+	case r.IsBreak():
+		return Break
+	}
+	return Focus
+}
+
+// IsBreak reports whether a session label names a break ("break", "long
+// break"). Sessions are marked as breaks explicitly now; only records
+// written before that are told apart by their label.
+func IsBreak(label string) bool {
+	return strings.Contains(strings.ToLower(label), "break")
+}
+
+// IsLongBreak reports whether a session label names the long break that
+// ends a round of pomodoros ("long break", "Long Break").
+func IsLongBreak(label string) bool {
+	return IsBreak(label) && strings.Contains(strings.ToLower(label), "long")
+}
+
+// Block is the part of a session that falls on one day.
+type Block struct {
+	Start, End time.Time
+	Label      string
+	Kind       Kind
+}
+
+// Day returns the midnight starting the day of t, in t's location.
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Timeline returns the sessions overlapping the day starting at day,
+// clipped to it and ordered by start. Sessions crossing midnight show up on
+// both days.
+func Timeline(records []Record, day time.Time) []Block {
+	end := day.AddDate(0, 0, 1)
+	var blocks []Block
+	for _, r := range records {
+		if !r.Start.Before(end) || !r.End.After(day) {
+			continue
+		}
+		b := Block{Start: r.Start.In(day.Location()), End: r.End.In(day.Location()), Label: r.Label, Kind: r.Kind()}
+		if b.Start.Before(day) {
+			b.Start = day
+		}
+		if b.End.After(end) {
+			b.End = end
+		}
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start.Before(blocks[j].Start) })
+	return blocks
+}
+
+// Span returns where the block lies within its day as fractions from 0
+// (midnight) to 1 (the next midnight), accounting for DST days.
+func (b Block) Span(day time.Time) (from, to float64) {
+	length := float64(day.AddDate(0, 0, 1).Sub(day))
+	return float64(b.Start.Sub(day)) / length, float64(b.End.Sub(day)) / length
+}
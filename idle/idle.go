@@ -0,0 +1,46 @@
+// Package idle tells how long the user has been away from keyboard and
+// mouse, so the timer can react to activity (flow mode, auto-pause).
+package idle
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Detector reports the time since the last user input.
+type Detector interface {
+	Idle() (time.Duration, error)
+}
+
+// DetectorFunc adapts a function to a Detector.
+type DetectorFunc func() (time.Duration, error)
+
+func (f DetectorFunc) Idle() (time.Duration, error) { return f() }
+
+// XPrintIdle reads the X11 idle time with the xprintidle tool.
+type XPrintIdle struct{}
+
+func (XPrintIdle) Idle() (time.Duration, error) {
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle: %w", err)
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Active returns a check that reports whether there was input within
+// threshold. A detector error counts as inactive, so a missing backend
+// never keeps a session running.
+func Active(d Detector, threshold time.Duration) func() bool {
+	return func() bool {
+		idle, err := d.Idle()
+		return err == nil && idle < threshold
+	}
+}
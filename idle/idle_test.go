@@ -0,0 +1,26 @@
+package idle
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestActive(t *testing.T) {
+	tests := []struct {
+		name string
+		idle time.Duration
+		err  error
+		want bool
+	}{
+		{"typing", 2 * time.Second, nil, true},
+		{"away", 2 * time.Minute, nil, false},
+		{"no backend", 0, errors.New("xprintidle: not found"), false},
+	}
+	for _, tt := range tests {
+		d := DetectorFunc(func() (time.Duration, error) { return tt.idle, tt.err })
+		if got := Active(d, 30*time.Second)(); got != tt.want {
+			t.Errorf("%s: expected active=%v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/notify"
+)
+
+// ------------------- Break enforcement -------------------
+
+// BreakStage is how far BreakWatch has escalated an ignored break.
+type BreakStage int
+
+const (
+	BreakCalm BreakStage = iota
+	BreakNotified
+	BreakOverlay
+	BreakLocked
+)
+
+func (s BreakStage) String() string {
+	switch s {
+	case BreakNotified:
+		return "notified"
+	case BreakOverlay:
+		return "overlay"
+	case BreakLocked:
+		return "locked"
+	}
+	return "calm"
+}
+
+// BreakPolicy says after how long of continued activity into a break each
+// escalation starts. Zero skips a stage.
+type BreakPolicy struct {
+	Notify  time.Duration
+	Overlay time.Duration
+	Lock    time.Duration
+}
+
+// stage is where activity lasting ignored escalates to.
+func (p BreakPolicy) stage(ignored time.Duration) BreakStage {
+	switch {
+	case p.Lock > 0 && ignored >= p.Lock:
+		return BreakLocked
+	case p.Overlay > 0 && ignored >= p.Overlay:
+		return BreakOverlay
+	case p.Notify > 0 && ignored >= p.Notify:
+		return BreakNotified
+	}
+	return BreakCalm
+}
+
+// enabled reports whether the policy includes stage.
+func (p BreakPolicy) enabled(stage BreakStage) bool {
+	switch stage {
+	case BreakNotified:
+		return p.Notify > 0
+	case BreakOverlay:
+		return p.Overlay > 0
+	case BreakLocked:
+		return p.Lock > 0
+	}
+	return false
+}
+
+// BreakActions carry out the escalations; a nil action is skipped.
+type BreakActions struct {
+	Notify func(summary, body string) error
+	// Overlay shows a reminder over the screen, or hides it again.
+	Overlay func(show bool)
+	Lock    func() error
+}
+
+// DesktopNotify returns a Notify action showing the notification through
+// n, notify.Default() when nil. Clicking it runs open, when set.
+func DesktopNotify(n notify.Notifier, open func()) func(summary, body string) error {
+	if n == nil {
+		n = notify.Default()
+	}
+	return func(summary, body string) error {
+		msg := notify.Notification{Summary: summary, Body: body}
+		if open != nil {
+			msg.Actions = []notify.Action{{Key: "default", Label: "Open"}}
+			msg.OnAction = func(key string) {
+				if key == "default" {
+					open()
+				}
+			}
+		}
+		return n.Notify(msg)
+	}
+}
+
+// LockCommand returns a Lock action running argv, by default
+// "loginctl lock-session".
+func LockCommand(argv []string) func() error {
+	if len(argv) == 0 {
+		argv = []string{"loginctl", "lock-session"}
+	}
+	return func() error {
+		if err := exec.Command(argv[0], argv[1:]...).Run(); err != nil {
+			return fmt.Errorf("%s: %w", argv[0], err)
+		}
+		return nil
+	}
+}
+
+// BreakWatch escalates breaks the user works through: while input keeps
+// arriving during a running break it notifies, then shows an overlay, then
+// locks the screen, as the policy allows. Stepping away calms it down
+// again, and the overlay goes when the break ends.
+type BreakWatch struct {
+	tm      *focotimer.TimerManager
+	active  func() bool
+	policy  BreakPolicy
+	actions BreakActions
+	now     func() time.Time
+	lastErr string // touched by Run only
+
+	mu           sync.Mutex
+	breakStart   time.Time // the break being watched
+	ignoredSince time.Time // zero while the user is away
+	stage        BreakStage
+}
+
+// NewBreakWatch watches the breaks of tm; active reports recent input,
+// e.g. idle.Active.
+func NewBreakWatch(tm *focotimer.TimerManager, active func() bool, policy BreakPolicy, actions BreakActions) *BreakWatch {
+	return &BreakWatch{tm: tm, active: active, policy: policy, actions: actions, now: time.Now}
+}
+
+// Stage reports the current escalation.
+func (w *BreakWatch) Stage() BreakStage {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stage
+}
+
+// Check polls the timer and the user's activity once and escalates or
+// calms down accordingly.
+func (w *BreakWatch) Check() error {
+	s := w.tm.State()
+	onBreak := s.Status == focotimer.StatusRunning && s.Break
+	active := onBreak && w.active()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !onBreak || !s.StartedAt.Equal(w.breakStart) {
+		w.calmLocked()
+		w.breakStart = s.StartedAt
+	}
+	if !active {
+		w.calmLocked()
+		return nil
+	}
+
+	now := w.now()
+	if w.ignoredSince.IsZero() {
+		w.ignoredSince = now
+	}
+	var errs []error
+	for target := w.policy.stage(now.Sub(w.ignoredSince)); w.stage < target; {
+		w.stage++
+		if !w.policy.enabled(w.stage) {
+			continue
+		}
+		if err := w.escalateLocked(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *BreakWatch) escalateLocked(s focotimer.State) error {
+	log.Printf("integrations.BreakWatch: break ignored, escalating to %s", w.stage)
+	switch w.stage {
+	case BreakNotified:
+		if w.actions.Notify != nil {
+			left := s.Remaining.Round(time.Minute)
+			return w.actions.Notify("Time for a break", fmt.Sprintf("Step away from the keyboard, %v left.", left))
+		}
+	case BreakOverlay:
+		if w.actions.Overlay != nil {
+			w.actions.Overlay(true)
+		}
+	case BreakLocked:
+		if w.actions.Lock != nil {
+			return w.actions.Lock()
+		}
+	}
+	return nil
+}
+
+// calmLocked ends the escalation, hiding the overlay if it is shown.
+func (w *BreakWatch) calmLocked() {
+	if w.stage >= BreakOverlay && w.actions.Overlay != nil {
+		w.actions.Overlay(false)
+	}
+	w.stage = BreakCalm
+	w.ignoredSince = time.Time{}
+}
+
+// Run checks every interval until stop is closed, logging an error once
+// until it changes.
+func (w *BreakWatch) Run(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := w.Check()
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != w.lastErr && msg != "" {
+			log.Printf("integrations.BreakWatch: %v", err)
+		}
+		w.lastErr = msg
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
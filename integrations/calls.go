@@ -0,0 +1,245 @@
+package integrations
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Call detection -------------------
+
+// CallDetector reports whether the user is in an audio or video call.
+type CallDetector interface {
+	InCall() (bool, error)
+}
+
+// DefaultCallApps are the applications whose microphone use counts as a
+// call when no list is configured. Browsers are included for web meetings.
+var DefaultCallApps = []string{
+	"zoom", "teams", "skype", "slack", "discord", "webex", "signal", "telegram",
+	"element", "jitsi", "firefox", "chrome", "chromium", "brave",
+}
+
+// MicInUse detects a call by a recording stream of one of Apps on the
+// PulseAudio server, which PipeWire provides through pipewire-pulse. Apps
+// match the stream's application name or binary, ignoring case.
+type MicInUse struct {
+	Apps []string
+
+	// sourceOutputs is replaceable in tests.
+	sourceOutputs func() ([]byte, error)
+}
+
+func NewMicInUse(apps []string) *MicInUse {
+	if len(apps) == 0 {
+		apps = DefaultCallApps
+	}
+	return &MicInUse{Apps: apps, sourceOutputs: pactlSourceOutputs}
+}
+
+func (m *MicInUse) InCall() (bool, error) {
+	out, err := m.sourceOutputs()
+	if err != nil {
+		return false, err
+	}
+	for _, app := range recordingApps(out) {
+		for _, want := range m.Apps {
+			if strings.Contains(app, strings.ToLower(want)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func pactlSourceOutputs() ([]byte, error) {
+	out, err := exec.Command("pactl", "list", "source-outputs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pactl: %w", err)
+	}
+	return out, nil
+}
+
+// recordingApps extracts the lowercased application names and binaries
+// from "pactl list source-outputs".
+func recordingApps(out []byte) []string {
+	var apps []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), " = ")
+		if !ok || (key != "application.name" && key != "application.process.binary") {
+			continue
+		}
+		apps = append(apps, strings.ToLower(strings.Trim(value, `"`)))
+	}
+	return apps
+}
+
+// Processes detects a call by any of Names running, for call apps that
+// keep no stream open or systems without a sound server. Names match the
+// process name exactly, as in /proc/<pid>/comm.
+type Processes struct {
+	Names []string
+	// Proc is the proc filesystem; defaults to /proc.
+	Proc string
+}
+
+func (p Processes) InCall() (bool, error) {
+	if len(p.Names) == 0 {
+		return false, nil
+	}
+	proc := p.Proc
+	if proc == "" {
+		proc = "/proc"
+	}
+	comms, err := filepath.Glob(filepath.Join(proc, "[0-9]*", "comm"))
+	if err != nil {
+		return false, err
+	}
+	for _, file := range comms {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue // the process exited
+		}
+		comm := strings.TrimSpace(string(data))
+		for _, name := range p.Names {
+			// the kernel truncates comm to 15 bytes
+			if comm == name || (len(name) > 15 && comm == name[:15]) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// AnyCall detects a call when one of its detectors does. Errors are only
+// reported when no detector found a call.
+type AnyCall []CallDetector
+
+func (a AnyCall) InCall() (bool, error) {
+	var errs []error
+	for _, d := range a {
+		in, err := d.InCall()
+		if in {
+			return true, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// CallAction is what CallWatch does while the user is in a call.
+type CallAction int
+
+const (
+	// CallPause pauses a running focus session when a call starts and
+	// resumes it when the call ends. Breaks keep running.
+	CallPause CallAction = iota
+	// CallMute leaves the timer alone and only silences completion sounds.
+	CallMute
+)
+
+// ParseCallAction parses "pause" or "mute".
+func ParseCallAction(s string) (CallAction, error) {
+	switch s {
+	case "pause":
+		return CallPause, nil
+	case "mute":
+		return CallMute, nil
+	}
+	return 0, fmt.Errorf("unknown call action %q, expected pause or mute", s)
+}
+
+// CallWatch polls a CallDetector and reacts to calls starting and ending.
+// Sounds are silenced for the whole call with either action; see InCall.
+//
+// A session is only resumed if CallWatch paused it and it is still
+// paused, so a user who stops or resumes it during the call keeps control.
+type CallWatch struct {
+	tm       *focotimer.TimerManager
+	detector CallDetector
+	action   CallAction
+	lastErr  string // touched by Run only
+
+	mu     sync.Mutex
+	inCall bool
+	paused bool
+}
+
+func NewCallWatch(tm *focotimer.TimerManager, detector CallDetector, action CallAction) *CallWatch {
+	return &CallWatch{tm: tm, detector: detector, action: action}
+}
+
+// InCall reports whether the last check found a call. It suits
+// Chime.MuteWhile.
+func (w *CallWatch) InCall() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.inCall
+}
+
+// Check polls the detector once. A detector error counts as no call.
+func (w *CallWatch) Check() error {
+	inCall, err := w.detector.InCall()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	started, ended := inCall && !w.inCall, !inCall && w.inCall
+	w.inCall = inCall
+
+	switch {
+	case started:
+		s := w.tm.State()
+		if w.action == CallPause && s.Status == focotimer.StatusRunning && !s.Break {
+			log.Printf("integrations.CallWatch: call started, pausing")
+			w.tm.Pause()
+			w.paused = true
+		}
+	case ended:
+		if w.paused && w.tm.State().Status == focotimer.StatusPaused {
+			log.Printf("integrations.CallWatch: call ended, resuming")
+			w.tm.Resume()
+		}
+		w.paused = false
+	}
+	return err
+}
+
+// Run checks every interval until stop is closed. An error is logged once
+// until it changes, so a missing pactl does not flood the log.
+func (w *CallWatch) Run(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := w.Check()
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != w.lastErr && msg != "" {
+			log.Printf("integrations.CallWatch: %v", err)
+		}
+		w.lastErr = msg
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
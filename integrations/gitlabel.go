@@ -0,0 +1,178 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Git session labels -------------------
+
+// GitLabel names each session after the git repository and branch being
+// worked on, e.g. "focotimer/main". The repository is looked up from a
+// fixed workspace directory or, with FollowFocus, from the working
+// directory of the focused terminal.
+//
+// A label set by the user (or a running cycle) is never overwritten; only
+// empty labels and labels GitLabel set itself are replaced.
+type GitLabel struct {
+	tm          *focotimer.TimerManager
+	workspace   string
+	followFocus bool
+
+	// focusedDir is replaceable in tests.
+	focusedDir func() (string, error)
+
+	mu   sync.Mutex
+	last string
+}
+
+func NewGitLabel(tm *focotimer.TimerManager, workspace string, followFocus bool) *GitLabel {
+	return &GitLabel{
+		tm:          tm,
+		workspace:   workspace,
+		followFocus: followFocus,
+		focusedDir:  focusedTerminalDir,
+	}
+}
+
+func (g *GitLabel) Name() string { return "git-label" }
+
+func (g *GitLabel) OnEvent(ev focotimer.Event) error {
+	if ev.Kind != focotimer.EventStarted || ev.Break {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if current := g.tm.Label(); current != "" && current != g.last {
+		return nil
+	}
+
+	dir := g.workspace
+	if g.followFocus {
+		if d, err := g.focusedDir(); err == nil {
+			dir = d
+		} else if dir == "" {
+			return err
+		}
+	}
+	if dir == "" {
+		return nil
+	}
+
+	repo, branch, err := GitHead(dir)
+	if err != nil {
+		return err
+	}
+	label := repo
+	if branch != "" {
+		label += "/" + branch
+	}
+	g.tm.SetLabel(label)
+	g.last = label
+	return nil
+}
+
+// GitHead finds the repository containing dir and returns its name and
+// current branch, or the short commit id when HEAD is detached. It reads
+// .git directly so it works without a git binary.
+func GitHead(dir string) (repo, branch string, err error) {
+	root, gitDir, err := findGitDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", "", err
+	}
+
+	ref := strings.TrimSpace(string(head))
+	switch {
+	case strings.HasPrefix(ref, "ref: refs/heads/"):
+		branch = strings.TrimPrefix(ref, "ref: refs/heads/")
+	case len(ref) >= 7:
+		branch = ref[:7]
+	}
+	return filepath.Base(root), branch, nil
+}
+
+// findGitDir walks up from dir to the repository root. Worktrees and
+// submodules have a .git file pointing at the real git directory.
+func findGitDir(dir string) (root, gitDir string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		p := filepath.Join(dir, ".git")
+		fi, err := os.Stat(p)
+		if err == nil {
+			if fi.IsDir() {
+				return dir, p, nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return "", "", err
+			}
+			target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+			if !ok {
+				return "", "", fmt.Errorf("malformed %s", p)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(dir, target)
+			}
+			return dir, target, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errors.New("not inside a git repository")
+		}
+		dir = parent
+	}
+}
+
+// focusedTerminalDir returns the working directory of the shell in the
+// focused window: the window's process is found with xdotool and its most
+// recently started descendant (the shell, or a program run from it) is
+// read from /proc.
+func focusedTerminalDir() (string, error) {
+	out, err := exec.Command("xdotool", "getactivewindow", "getwindowpid").Output()
+	if err != nil {
+		return "", fmt.Errorf("focused window: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", fmt.Errorf("focused window pid: %w", err)
+	}
+	return os.Readlink(fmt.Sprintf("/proc/%d/cwd", leafProcess(pid)))
+}
+
+// leafProcess follows the youngest child of pid down to a process without
+// children.
+func leafProcess(pid int) int {
+	for range 16 {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/task/%d/children", pid, pid))
+		if err != nil {
+			return pid
+		}
+		children := strings.Fields(string(data))
+		if len(children) == 0 {
+			return pid
+		}
+		// children are listed in creation order
+		child, err := strconv.Atoi(children[len(children)-1])
+		if err != nil {
+			return pid
+		}
+		pid = child
+	}
+	return pid
+}
@@ -0,0 +1,101 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Exec hooks -------------------
+
+// ExecHooks runs shell commands on session events, e.g. "playerctl pause"
+// when work starts, and other commands for breaks (see SetBreak). Each
+// command runs through sh in the background, with the event in its
+// environment (FOCOTIMER_EVENT, FOCOTIMER_LABEL, FOCOTIMER_PHASE,
+// FOCOTIMER_ISSUE, FOCOTIMER_DURATION and FOCOTIMER_REMAINING in seconds);
+// failures are logged. An empty command is skipped.
+type ExecHooks struct {
+	WorkStart  string
+	WorkEnd    string
+	BreakStart string
+	BreakEnd   string
+	Pause      string
+	Resume     string
+	// Timeout kills a command running longer; defaults to 30s.
+	Timeout time.Duration
+
+	wg sync.WaitGroup
+}
+
+func (h *ExecHooks) Name() string { return "hooks" }
+
+func (h *ExecHooks) OnEvent(ev focotimer.Event) error {
+	brk := ev.Break
+	var name, command string
+	switch {
+	case ev.Kind == focotimer.EventStarted && brk:
+		name, command = "break start", h.BreakStart
+	case ev.Kind == focotimer.EventStarted:
+		name, command = "work start", h.WorkStart
+	case ev.Kind == focotimer.EventCompleted && brk:
+		name, command = "break end", h.BreakEnd
+	case ev.Kind == focotimer.EventCompleted:
+		name, command = "work end", h.WorkEnd
+	case ev.Kind == focotimer.EventPaused:
+		name, command = "pause", h.Pause
+	case ev.Kind == focotimer.EventResumed:
+		name, command = "resume", h.Resume
+	}
+	if command == "" {
+		return nil
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		if err := h.run(command, ev); err != nil {
+			log.Printf("integrations.ExecHooks: %s: %v", name, err)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the commands in flight are done.
+func (h *ExecHooks) Wait() { h.wg.Wait() }
+
+func (h *ExecHooks) run(command string, ev focotimer.Event) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// children of the shell may hold its output open past the kill
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"FOCOTIMER_EVENT="+ev.Kind.String(),
+		"FOCOTIMER_LABEL="+ev.Label,
+		"FOCOTIMER_PHASE="+ev.Phase,
+		"FOCOTIMER_ISSUE="+ev.Issue,
+		"FOCOTIMER_DURATION="+strconv.Itoa(int(ev.Duration.Seconds())),
+		"FOCOTIMER_REMAINING="+strconv.Itoa(int(ev.Remaining.Seconds())),
+	)
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s: timed out after %v", command, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", command, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
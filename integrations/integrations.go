@@ -0,0 +1,40 @@
+// Package integrations connects the timer to outside tools. An integration
+// receives the timer's lifecycle events and may act on them, e.g. label a
+// session after the git branch being worked on.
+package integrations
+
+import (
+	"log"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// Integration reacts to timer events. OnEvent is called from a single
+// goroutine per Run, in event order; a returned error is logged and does
+// not stop delivery.
+type Integration interface {
+	Name() string
+	OnEvent(ev focotimer.Event) error
+}
+
+// Run delivers the events of tm to every integration until stop is closed.
+func Run(tm *focotimer.TimerManager, stop <-chan struct{}, list ...Integration) {
+	events := tm.SubscribeEvents()
+	defer tm.UnsubscribeEvents(events)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			for _, in := range list {
+				if err := in.OnEvent(ev); err != nil {
+					log.Printf("integrations: %s: %v", in.Name(), err)
+				}
+			}
+		}
+	}
+}
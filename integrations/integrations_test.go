@@ -0,0 +1,961 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/history"
+	"github.com/d093w1z/focotimer/notify"
+)
+
+func makeRepo(t *testing.T, name, head string) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte(head+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write HEAD: %v", err)
+	}
+	return root
+}
+
+func TestGitHead(t *testing.T) {
+	root := makeRepo(t, "focotimer", "ref: refs/heads/feature/cycles")
+	sub := filepath.Join(root, "api", "internal")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("Failed to create subdir: %v", err)
+	}
+
+	repo, branch, err := GitHead(sub)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo != "focotimer" || branch != "feature/cycles" {
+		t.Errorf("Expected focotimer feature/cycles, got %s %s", repo, branch)
+	}
+
+	detached := makeRepo(t, "detached", "0123456789abcdef0123456789abcdef01234567")
+	if _, branch, _ := GitHead(detached); branch != "0123456" {
+		t.Errorf("Expected short commit for detached HEAD, got %q", branch)
+	}
+
+	if _, _, err := GitHead(t.TempDir()); err == nil {
+		t.Error("Expected error outside a repository")
+	}
+}
+
+func TestGitHead_Worktree(t *testing.T) {
+	primary := makeRepo(t, "main", "ref: refs/heads/main")
+	wtGit := filepath.Join(primary, ".git", "worktrees", "wt")
+	if err := os.MkdirAll(wtGit, 0755); err != nil {
+		t.Fatalf("Failed to create worktree git dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(wtGit, "HEAD"), []byte("ref: refs/heads/hotfix\n"), 0644); err != nil {
+		t.Fatalf("Failed to write HEAD: %v", err)
+	}
+	wt := filepath.Join(t.TempDir(), "wt")
+	if err := os.MkdirAll(wt, 0755); err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+wtGit+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .git file: %v", err)
+	}
+
+	repo, branch, err := GitHead(wt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo != "wt" || branch != "hotfix" {
+		t.Errorf("Expected wt hotfix, got %s %s", repo, branch)
+	}
+}
+
+func TestGitLabel_OnEvent(t *testing.T) {
+	tm := focotimer.NewTimerManager(time.Minute)
+	root := makeRepo(t, "focotimer", "ref: refs/heads/main")
+	g := NewGitLabel(tm, root, false)
+	started := focotimer.Event{Kind: focotimer.EventStarted}
+
+	if err := g.OnEvent(started); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.Label() != "focotimer/main" {
+		t.Errorf("Expected label focotimer/main, got %q", tm.Label())
+	}
+
+	// its own label is refreshed when the branch changes
+	os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("ref: refs/heads/dev\n"), 0644)
+	g.OnEvent(started)
+	if tm.Label() != "focotimer/dev" {
+		t.Errorf("Expected label focotimer/dev, got %q", tm.Label())
+	}
+
+	// a label chosen by the user is kept
+	tm.SetLabel("reading")
+	g.OnEvent(started)
+	if tm.Label() != "reading" {
+		t.Errorf("Expected user label to be kept, got %q", tm.Label())
+	}
+}
+
+func TestGitLabel_FollowFocus(t *testing.T) {
+	tm := focotimer.NewTimerManager(time.Minute)
+	focused := makeRepo(t, "focused", "ref: refs/heads/main")
+	g := NewGitLabel(tm, "", true)
+	g.focusedDir = func() (string, error) { return focused, nil }
+
+	g.OnEvent(focotimer.Event{Kind: focotimer.EventStarted})
+	if tm.Label() != "focused/main" {
+		t.Errorf("Expected label from focused terminal, got %q", tm.Label())
+	}
+
+	g.focusedDir = func() (string, error) { return "", errors.New("no display") }
+	if err := g.OnEvent(focotimer.Event{Kind: focotimer.EventStarted}); err == nil {
+		t.Error("Expected error without focus or workspace")
+	}
+}
+
+type recorder struct{ kinds chan focotimer.EventKind }
+
+func (r *recorder) Name() string { return "recorder" }
+func (r *recorder) OnEvent(ev focotimer.Event) error {
+	r.kinds <- ev.Kind
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	tm := focotimer.NewTimerManager(time.Minute)
+	rec := &recorder{kinds: make(chan focotimer.EventKind, 16)}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		Run(tm, stop, rec)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond) // let Run subscribe
+
+	tm.Start()
+	select {
+	case k := <-rec.kinds:
+		if k != focotimer.EventStarted {
+			t.Errorf("Expected started event, got %v", k)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected integration to receive the event")
+	}
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return after stop")
+	}
+}
+
+func TestParseIssueRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    IssueRef
+		wantErr bool
+	}{
+		{in: "d093w1z/focotimer#12", want: IssueRef{Tracker: "github", Repo: "d093w1z/focotimer", Number: 12}},
+		{in: "GH-123", want: IssueRef{Tracker: "github", Repo: "me/repo", Number: 123}},
+		{in: "#7", want: IssueRef{Tracker: "github", Repo: "me/repo", Number: 7}},
+		{in: "PROJ-42", want: IssueRef{Tracker: "jira", Key: "PROJ-42"}},
+		{in: "whatever", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIssueRef(tt.in, "me/repo")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tt.in, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.in, tt.want, got)
+		}
+	}
+	if _, err := ParseIssueRef("GH-1", ""); err == nil {
+		t.Error("Expected error for short reference without a repository")
+	}
+}
+
+func TestIssueLinker(t *testing.T) {
+	type request struct {
+		path, auth string
+		body       map[string]any
+	}
+	requests := make(chan request, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests <- request{r.URL.Path, r.Header.Get("Authorization"), body}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	l := &IssueLinker{
+		GitHub: GitHubOptions{Token: "ghtoken", Repo: "me/repo", APIURL: srv.URL, Comment: true},
+		Jira:   JiraOptions{URL: srv.URL, User: "me", Token: "jiratoken", Worklog: true},
+	}
+	completed := focotimer.Event{Kind: focotimer.EventCompleted, At: time.Now(), Duration: 25 * time.Minute, Label: "review"}
+
+	completed.Issue = "GH-5"
+	if err := l.OnEvent(completed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := <-requests
+	if req.path != "/repos/me/repo/issues/5/comments" || req.auth != "Bearer ghtoken" {
+		t.Errorf("Unexpected GitHub request %+v", req)
+	}
+	if body, _ := req.body["body"].(string); !strings.Contains(body, "25m0s") {
+		t.Errorf("Expected comment to mention the session length, got %q", body)
+	}
+
+	completed.Issue = "PROJ-42"
+	if err := l.OnEvent(completed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req = <-requests
+	if req.path != "/rest/api/2/issue/PROJ-42/worklog" || !strings.HasPrefix(req.auth, "Basic ") {
+		t.Errorf("Unexpected Jira request %+v", req)
+	}
+	if secs, _ := req.body["timeSpentSeconds"].(float64); secs != 1500 {
+		t.Errorf("Expected 1500 seconds logged, got %v", req.body["timeSpentSeconds"])
+	}
+
+	// sessions without an issue, and other events, are not reported
+	l.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted})
+	l.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, Issue: "GH-5"})
+	l.Wait()
+	select {
+	case req := <-requests:
+		t.Errorf("Unexpected request %+v", req)
+	default:
+	}
+}
+
+func TestIssueLinker_Background(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	l := &IssueLinker{GitHub: GitHubOptions{Repo: "me/repo", APIURL: srv.URL, Comment: true}}
+	done := make(chan error, 1)
+	go func() {
+		done <- l.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, Issue: "GH-5", Duration: time.Minute})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the failure logged rather than returned, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected OnEvent to return while the tracker is slow")
+	}
+	close(release)
+	l.Wait()
+
+	// a reference that does not parse is still reported to the caller
+	if err := l.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, Issue: "whatever"}); err == nil {
+		t.Error("Expected an error for an unrecognised reference")
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []HookPayload
+		fails    = 2 // the first attempts hit a flaky endpoint
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if fails > 0 {
+			fails--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("X-Token") != "secret" {
+			t.Errorf("Expected the configured header, got %v", r.Header)
+		}
+		var p HookPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		payloads = append(payloads, p)
+	}))
+	defer srv.Close()
+
+	w := &Webhooks{
+		Hooks: []Webhook{
+			{URL: srv.URL + "/hook", Events: []string{HookCompleted, HookAborted}, Headers: map[string]string{"X-Token": "secret"}},
+			{URL: srv.URL + "/gone"},
+		},
+		Backoff: time.Millisecond,
+	}
+	start := time.Date(2025, 9, 2, 9, 0, 0, 0, time.UTC)
+	events := []focotimer.Event{
+		{Kind: focotimer.EventStarted, At: start, Duration: 25 * time.Minute, Remaining: 25 * time.Minute, Label: "writing"},
+		{Kind: focotimer.EventCompleted, At: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Label: "writing"},
+		{Kind: focotimer.EventReset, At: start.Add(26 * time.Minute), Duration: 25 * time.Minute, Remaining: 25 * time.Minute},
+		{Kind: focotimer.EventStarted, At: start.Add(30 * time.Minute), Duration: 25 * time.Minute, Remaining: 25 * time.Minute},
+		{Kind: focotimer.EventReset, At: start.Add(40 * time.Minute), Duration: 25 * time.Minute, Remaining: 15 * time.Minute},
+		// stopped, then started again: aborted at the stop, and only once
+		{Kind: focotimer.EventStarted, At: start.Add(50 * time.Minute), Duration: 25 * time.Minute, Remaining: 25 * time.Minute},
+		{Kind: focotimer.EventStopped, At: start.Add(55 * time.Minute), Duration: 25 * time.Minute, Remaining: 20 * time.Minute},
+		{Kind: focotimer.EventStarted, At: start.Add(60 * time.Minute), Duration: 25 * time.Minute, Remaining: 25 * time.Minute},
+		{Kind: focotimer.EventReset, At: start.Add(61 * time.Minute), Duration: 25 * time.Minute, Remaining: 24 * time.Minute},
+	}
+	for _, ev := range events {
+		if err := w.OnEvent(ev); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		w.Wait()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 4 {
+		t.Fatalf("Expected a completed and three aborted payloads, got %+v", payloads)
+	}
+	if p := payloads[0]; p.Event != HookCompleted || p.Label != "writing" || !p.StartedAt.Equal(start) || p.ElapsedMs != 25*60*1000 || p.EndedAt == nil {
+		t.Errorf("Unexpected completed payload %+v", p)
+	}
+	if p := payloads[1]; p.Event != HookAborted || p.ElapsedMs != 10*60*1000 || !p.EndedAt.Equal(start.Add(40*time.Minute)) {
+		t.Errorf("Unexpected aborted payload %+v", p)
+	}
+	if p := payloads[2]; p.Event != HookAborted || p.ElapsedMs != 5*60*1000 || !p.StartedAt.Equal(start.Add(50*time.Minute)) || !p.EndedAt.Equal(start.Add(55*time.Minute)) {
+		t.Errorf("Expected the stop to abort the session, got %+v", p)
+	}
+	if p := payloads[3]; !p.StartedAt.Equal(start.Add(60 * time.Minute)) {
+		t.Errorf("Expected the later reset to abort the next session, got %+v", p)
+	}
+}
+
+func TestExecHooks(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	h := &ExecHooks{
+		WorkStart:  `echo "work $FOCOTIMER_LABEL $FOCOTIMER_DURATION" >> ` + out,
+		BreakStart: `echo "break $FOCOTIMER_EVENT" >> ` + out,
+		Resume:     "exit 3",
+		Timeout:    50 * time.Millisecond,
+	}
+	events := []focotimer.Event{
+		{Kind: focotimer.EventStarted, Label: "writing", Duration: 25 * time.Minute},
+		{Kind: focotimer.EventCompleted, Label: "writing"}, // no command
+		{Kind: focotimer.EventStarted, Label: "short break", Break: true},
+		{Kind: focotimer.EventPaused}, // no command
+		{Kind: focotimer.EventResumed},
+		{Kind: focotimer.EventStarted, Label: "fix-line-breaks"},
+	}
+	for _, ev := range events {
+		if err := h.OnEvent(ev); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		h.Wait()
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the hooks to write %s: %v", out, err)
+	}
+	if got := string(data); got != "work writing 1500\nbreak started\nwork fix-line-breaks 0\n" {
+		t.Errorf("Unexpected hook output %q", got)
+	}
+	if err := h.run("sleep 5", focotimer.Event{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if err := h.run("echo oops; exit 3", focotimer.Event{}); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Expected the failure with its output, got %v", err)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	var mu sync.Mutex
+	var shown []notify.Notification
+	n := &Notifications{
+		Notifier: notify.Func(func(msg notify.Notification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			shown = append(shown, msg)
+			return nil
+		}),
+		Break: notify.Notification{Summary: "{label} done", Body: "back to it", Urgency: notify.Critical},
+	}
+	events := []focotimer.Event{
+		{Kind: focotimer.EventStarted, Label: "writing", Duration: 25 * time.Minute}, // not completed
+		{Kind: focotimer.EventCompleted, Label: "writing", Duration: 25 * time.Minute},
+		{Kind: focotimer.EventCompleted, Duration: 50 * time.Minute},
+		{Kind: focotimer.EventCompleted, Label: "long break", Break: true, Duration: 15 * time.Minute},
+	}
+	for _, ev := range events {
+		if err := n.OnEvent(ev); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		n.Wait()
+	}
+
+	want := []notify.Notification{
+		{Summary: "writing complete", Body: "25m0s of focus. Time for a break."},
+		{Summary: "Session complete", Body: "50m0s of focus. Time for a break."},
+		{Summary: "long break done", Body: "back to it", Urgency: notify.Critical},
+	}
+	if len(shown) != len(want) {
+		t.Fatalf("Expected %d notifications, got %+v", len(want), shown)
+	}
+	for i, w := range want {
+		if got := shown[i]; got.Summary != w.Summary || got.Body != w.Body || got.Urgency != w.Urgency || got.Actions != nil {
+			t.Errorf("Expected notification %+v, got %+v", w, got)
+		}
+	}
+}
+
+func TestNotifications_Actions(t *testing.T) {
+	var shown []notify.Notification
+	var ran []string
+	n := &Notifications{
+		Notifier: notify.Func(func(msg notify.Notification) error {
+			shown = append(shown, msg)
+			return nil
+		}),
+		Dispatch: func(line string) error {
+			ran = append(ran, line)
+			if line == "fail" {
+				return errors.New("no such command")
+			}
+			return nil
+		},
+		BreakActions: []NotificationAction{{Label: "Again", Commands: []string{"fail", "start"}}},
+	}
+	for _, ev := range []focotimer.Event{
+		{Kind: focotimer.EventCompleted, Label: "writing", Duration: 25 * time.Minute},
+		{Kind: focotimer.EventCompleted, Label: "break", Break: true, Duration: 5 * time.Minute},
+	} {
+		n.OnEvent(ev)
+		n.Wait()
+	}
+
+	work, brk := shown[0], shown[1]
+	var labels []string
+	for _, a := range work.Actions {
+		labels = append(labels, a.Label)
+	}
+	if strings.Join(labels, ",") != "Start break,Skip break,+5 min" {
+		t.Errorf("Expected the default buttons, got %v", labels)
+	}
+	work.OnAction(work.Actions[1].Key)
+	if want := []string{"set 25m0s", "label writing", "start"}; !slices.Equal(ran, want) {
+		t.Errorf("Expected Skip break to go back to the session, ran %q", ran)
+	}
+	ran = nil
+	work.OnAction("7") // not a button
+	brk.OnAction(brk.Actions[0].Key)
+	if want := []string{"fail"}; !slices.Equal(ran, want) {
+		t.Errorf("Expected the commands to stop at the failing one, ran %q", ran)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	r := NewRecorder(path)
+	start := time.Now()
+
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventReset, At: start}) // nothing started yet
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, At: start.Add(time.Minute), Duration: time.Minute, Issue: "GH-5"})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventReset, At: start.Add(time.Minute)}) // after completion
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start.Add(2 * time.Minute)})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventReset, At: start.Add(3 * time.Minute), Duration: time.Minute})
+
+	records, err := history.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if !records[0].Completed || records[0].Issue != "GH-5" {
+		t.Errorf("Expected completed session linked to GH-5, got %+v", records[0])
+	}
+	if records[1].Completed {
+		t.Errorf("Expected reset session to be recorded as incomplete, got %+v", records[1])
+	}
+}
+
+func TestRecorder_StopThenStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	r := NewRecorder(path)
+	start := time.Date(2025, 9, 2, 9, 0, 0, 0, time.UTC)
+
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start, Label: "writing"})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStopped, At: start.Add(10 * time.Minute), Duration: 25 * time.Minute, Remaining: 15 * time.Minute, Label: "writing"})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStopped, At: start.Add(11 * time.Minute)}) // stopped again, nothing running
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start.Add(12 * time.Minute), Label: "review"})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, At: start.Add(37 * time.Minute), Duration: 25 * time.Minute, Label: "review"})
+
+	records, err := history.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %+v", records)
+	}
+	if rec := records[0]; rec.Completed || rec.Label != "writing" || !rec.End.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("Expected the stopped session to end at the stop, got %+v", rec)
+	}
+	if rec := records[1]; !rec.Completed || rec.Label != "review" || !rec.Start.Equal(start.Add(12*time.Minute)) {
+		t.Errorf("Expected the next session recorded from its own start, got %+v", rec)
+	}
+}
+
+func TestRecorder_Energy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	r := NewRecorder(path)
+	start := time.Now()
+
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventRated, EnergyEnd: 3}) // nothing recorded yet
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start, EnergyStart: 4})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, At: start.Add(time.Minute), EnergyStart: 4})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: start.Add(2 * time.Minute)})
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventCompleted, At: start.Add(3 * time.Minute)})
+	if err := r.OnEvent(focotimer.Event{Kind: focotimer.EventRated, EnergyStart: 4, EnergyEnd: 2}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// a start rating for the next session changes nothing
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventRated})
+
+	records, err := history.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].EnergyStart != 4 || records[0].EnergyEnd != 0 {
+		t.Errorf("Expected the first session rated 4 at the start only, got %+v", records[0])
+	}
+	if records[1].EnergyStart != 0 || records[1].EnergyEnd != 2 {
+		t.Errorf("Expected the late end rating on the last session, got %+v", records[1])
+	}
+}
+
+func TestRecorder_BreakTaken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	r := NewRecorder(path)
+	start := time.Date(2025, 9, 2, 9, 0, 0, 0, time.UTC)
+	session := func(minute int, brk, completed bool) {
+		at := start.Add(time.Duration(minute) * time.Minute)
+		r.OnEvent(focotimer.Event{Kind: focotimer.EventStarted, At: at, Break: brk})
+		end := focotimer.EventReset
+		if completed {
+			end = focotimer.EventCompleted
+		}
+		if err := r.OnEvent(focotimer.Event{Kind: end, At: at.Add(time.Minute), Break: brk}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	session(0, false, true)
+	session(2, true, true)  // taken
+	session(4, false, true) // break skipped
+	session(6, false, true) // break cut short
+	session(8, true, false)
+	session(10, false, false) // abandoned
+	session(12, true, true)   // nothing to credit
+
+	records, err := history.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var taken, breaks []bool
+	for _, rec := range records {
+		taken = append(taken, rec.BreakTaken)
+		breaks = append(breaks, rec.Break != nil && *rec.Break)
+	}
+	if want := []bool{true, false, false, false, false, false, false}; !slices.Equal(taken, want) {
+		t.Errorf("Expected %v, got %v", want, taken)
+	}
+	if want := []bool{false, true, false, false, true, false, true}; !slices.Equal(breaks, want) {
+		t.Errorf("Expected the breaks marked %v, got %v", want, breaks)
+	}
+}
+
+const sourceOutputs = `Source Output #42
+	Driver: protocol-native.c
+	Owner Module: 9
+	Client: 77
+	Source: 1
+	Properties:
+		media.name = "Peak detect"
+		application.name = "PulseAudio Volume Control"
+		application.process.binary = "pavucontrol"
+
+Source Output #43
+	Driver: protocol-native.c
+	Properties:
+		media.name = "VoiceEngine"
+		application.name = "ZOOM VoiceEngine"
+		application.process.binary = "zoom"
+`
+
+func TestMicInUse(t *testing.T) {
+	tests := []struct {
+		apps []string
+		out  string
+		want bool
+	}{
+		{nil, sourceOutputs, true},
+		{[]string{"Teams"}, sourceOutputs, false},
+		{[]string{"ZOOM"}, sourceOutputs, true},
+		{nil, sourceOutputs[:strings.Index(sourceOutputs, "Source Output #43")], false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		m := NewMicInUse(tt.apps)
+		m.sourceOutputs = func() ([]byte, error) { return []byte(tt.out), nil }
+		got, err := m.InCall()
+		if err != nil || got != tt.want {
+			t.Errorf("InCall(%v) = %v, %v, expected %v", tt.apps, got, err, tt.want)
+		}
+	}
+
+	m := NewMicInUse(nil)
+	m.sourceOutputs = func() ([]byte, error) { return nil, errors.New("pactl: not found") }
+	if _, err := m.InCall(); err == nil {
+		t.Error("Expected the pactl error")
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	proc := t.TempDir()
+	for pid, comm := range map[string]string{"1": "systemd", "4242": "signal-desktop-", "self": "zoom"} {
+		if err := os.MkdirAll(filepath.Join(proc, pid), 0755); err != nil {
+			t.Fatalf("Failed to create proc dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(proc, pid, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write comm: %v", err)
+		}
+	}
+
+	tests := []struct {
+		names []string
+		want  bool
+	}{
+		{nil, false},
+		{[]string{"zoom"}, false}, // not a pid directory
+		{[]string{"signal"}, false},
+		{[]string{"signal-desktop-beta"}, true},
+		{[]string{"zoom", "systemd"}, true},
+	}
+	for _, tt := range tests {
+		got, err := Processes{Names: tt.names, Proc: proc}.InCall()
+		if err != nil || got != tt.want {
+			t.Errorf("InCall(%v) = %v, %v, expected %v", tt.names, got, err, tt.want)
+		}
+	}
+}
+
+type fakeCall struct {
+	in  bool
+	err error
+}
+
+func (f *fakeCall) InCall() (bool, error) { return f.in, f.err }
+
+func TestAnyCall(t *testing.T) {
+	broken := &fakeCall{err: errors.New("pactl: not found")}
+	if in, err := (AnyCall{broken, &fakeCall{in: true}}).InCall(); !in || err != nil {
+		t.Errorf("Expected a call despite the broken detector, got %v, %v", in, err)
+	}
+	if in, err := (AnyCall{broken, &fakeCall{}}).InCall(); in || err == nil {
+		t.Errorf("Expected no call and the error, got %v, %v", in, err)
+	}
+}
+
+func TestCallWatch(t *testing.T) {
+	tm := focotimer.NewTimerManager(time.Minute)
+	call := &fakeCall{}
+	w := NewCallWatch(tm, call, CallPause)
+	status := func() focotimer.Status { return tm.State().Status }
+
+	tm.Start()
+	call.in = true
+	w.Check()
+	if status() != focotimer.StatusPaused || !w.InCall() {
+		t.Fatalf("Expected the session paused during the call, got %s", status())
+	}
+	call.in = false
+	w.Check()
+	if status() != focotimer.StatusRunning || w.InCall() {
+		t.Fatalf("Expected the session resumed after the call, got %s", status())
+	}
+
+	// a session the user takes over during the call is left alone
+	call.in = true
+	w.Check()
+	tm.Reset()
+	call.in = false
+	w.Check()
+	if status() != focotimer.StatusIdle {
+		t.Errorf("Expected the reset session to stay idle, got %s", status())
+	}
+
+	// breaks keep running, unlike focus work labelled like one
+	tm.SetLabel("fix-line-breaks")
+	tm.Start()
+	call.in = true
+	w.Check()
+	if status() != focotimer.StatusPaused {
+		t.Errorf("Expected the focus session paused, got %s", status())
+	}
+	call.in = false
+	w.Check()
+	tm.Reset()
+
+	tm.SetBreak("long break")
+	tm.Start()
+	call.in = true
+	w.Check()
+	if status() != focotimer.StatusRunning {
+		t.Errorf("Expected the break to keep running, got %s", status())
+	}
+
+	// mute never touches the timer
+	call.in = false
+	w.Check()
+	tm.SetLabel("work")
+	m := NewCallWatch(tm, call, CallMute)
+	call.in = true
+	m.Check()
+	if status() != focotimer.StatusRunning || !m.InCall() {
+		t.Errorf("Expected mute to only report the call, got %s", status())
+	}
+
+	if _, err := ParseCallAction("hangup"); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
+
+func TestBreakWatch(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Minute)
+	active := true
+	var notified, locked int
+	var overlay bool
+	w := NewBreakWatch(tm, func() bool { return active },
+		BreakPolicy{Notify: time.Minute, Overlay: 2 * time.Minute, Lock: 4 * time.Minute},
+		BreakActions{
+			Notify:  func(summary, body string) error { notified++; return nil },
+			Overlay: func(show bool) { overlay = show },
+			Lock:    func() error { locked++; return nil },
+		})
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	after := func(d time.Duration) {
+		now = now.Add(d)
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+	}
+
+	// focus sessions are never escalated, whatever their label says
+	tm.SetLabel("fix-line-breaks")
+	tm.Start()
+	after(0)
+	after(10 * time.Minute)
+	if w.Stage() != BreakCalm || notified != 0 {
+		t.Fatalf("Expected a focus session left alone, got %s", w.Stage())
+	}
+
+	tm.Reset()
+	tm.SetBreak("short break")
+	tm.Start()
+	tests := []struct {
+		after   time.Duration
+		stage   BreakStage
+		overlay bool
+	}{
+		{0, BreakCalm, false},
+		{time.Minute, BreakNotified, false},
+		{time.Minute, BreakOverlay, true},
+		{time.Minute, BreakOverlay, true},
+		{time.Minute, BreakLocked, true},
+		{time.Minute, BreakLocked, true},
+	}
+	for i, tt := range tests {
+		after(tt.after)
+		if w.Stage() != tt.stage || overlay != tt.overlay {
+			t.Errorf("step %d: expected %s with overlay=%v, got %s with overlay=%v", i, tt.stage, tt.overlay, w.Stage(), overlay)
+		}
+	}
+	if notified != 1 || locked != 1 {
+		t.Errorf("Expected one notification and one lock, got %d and %d", notified, locked)
+	}
+
+	// stepping away calms down and restarts the count
+	active = false
+	after(time.Second)
+	if w.Stage() != BreakCalm || overlay {
+		t.Errorf("Expected calm without overlay once away, got %s overlay=%v", w.Stage(), overlay)
+	}
+	active = true
+	after(0)
+	after(90 * time.Second)
+	if w.Stage() != BreakNotified || notified != 2 {
+		t.Errorf("Expected a fresh notification, got %s after %d", w.Stage(), notified)
+	}
+
+	// a stage skipped by the policy is passed over
+	w.policy.Notify = 0
+	tm.Reset()
+	after(0)
+	tm.Start()
+	after(0)
+	after(3 * time.Minute)
+	if w.Stage() != BreakOverlay || notified != 2 {
+		t.Errorf("Expected the overlay without notifying, got %s after %d", w.Stage(), notified)
+	}
+
+	// the overlay goes with the break
+	tm.Reset()
+	after(0)
+	if overlay {
+		t.Error("Expected the overlay hidden when the break ends")
+	}
+}
+
+func TestDesktopNotify(t *testing.T) {
+	var shown []notify.Notification
+	n := notify.Func(func(msg notify.Notification) error {
+		shown = append(shown, msg)
+		return nil
+	})
+	opened := 0
+	DesktopNotify(n, nil)("Take a break", "")
+	DesktopNotify(n, func() { opened++ })("focotimer is running", "Click to open")
+
+	if len(shown) != 2 || shown[0].Actions != nil {
+		t.Fatalf("Expected a plain notification then one to open, got %+v", shown)
+	}
+	shown[1].OnAction("dismissed")
+	shown[1].OnAction(shown[1].Actions[0].Key)
+	if opened != 1 {
+		t.Errorf("Expected a click to open once, got %d", opened)
+	}
+}
+
+func TestReminders(t *testing.T) {
+	var shown []notify.Notification
+	played := 0
+	quiet := false
+	r := &Reminders{
+		Notifier: notify.Func(func(msg notify.Notification) error {
+			shown = append(shown, msg)
+			return nil
+		}),
+		Play:  func() error { played++; return nil },
+		Quiet: func() bool { return quiet },
+	}
+	for _, ev := range []focotimer.Event{
+		{Kind: focotimer.EventStarted, Label: "writing", Remaining: 25 * time.Minute},
+		{Kind: focotimer.EventReminder, Label: "writing", Remaining: 5*time.Minute + 300*time.Millisecond, Reminder: "5m0s"},
+	} {
+		if err := r.OnEvent(ev); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		r.Wait()
+	}
+	quiet = true
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventReminder, Remaining: time.Minute, Reminder: "50%"})
+	r.Wait()
+
+	if len(shown) != 2 {
+		t.Fatalf("Expected 2 notifications, got %+v", shown)
+	}
+	if got := shown[0]; got.Summary != "writing: 5m0s left" || got.Urgency != notify.Low {
+		t.Errorf("Expected a low urgency \"writing: 5m0s left\", got %+v", got)
+	}
+	if got := shown[1].Summary; got != "Session: 1m0s left" {
+		t.Errorf("Expected \"Session: 1m0s left\", got %q", got)
+	}
+	if played != 1 {
+		t.Errorf("Expected the sound once, muted the second time, got %d", played)
+	}
+}
+
+func TestAutoResetNotice(t *testing.T) {
+	var shown []notify.Notification
+	a := &AutoResetNotice{
+		Notifier: notify.Func(func(msg notify.Notification) error {
+			shown = append(shown, msg)
+			return nil
+		}),
+	}
+	for _, ev := range []focotimer.Event{
+		{Kind: focotimer.EventCompleted, Label: "writing", Duration: 25 * time.Minute},
+		{Kind: focotimer.EventAutoReset, Label: "writing", Duration: 25 * time.Minute},
+		{Kind: focotimer.EventReset, Duration: 25 * time.Minute},
+	} {
+		if err := a.OnEvent(ev); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		a.Wait()
+	}
+
+	if len(shown) != 1 {
+		t.Fatalf("Expected a single notice, got %+v", shown)
+	}
+	if got := shown[0]; got.Summary != "writing was left finished" || !strings.Contains(got.Body, "25m0s") {
+		t.Errorf("Expected the notice to name the session, got %+v", got)
+	}
+}
+
+func TestReminders_Warning(t *testing.T) {
+	var points, warnings []notify.Notification
+	played := 0
+	r := &Reminders{
+		Notifier: notify.Func(func(msg notify.Notification) error {
+			points = append(points, msg)
+			return nil
+		}),
+		Warnings: notify.Func(func(msg notify.Notification) error {
+			warnings = append(warnings, msg)
+			return nil
+		}),
+		Play: func() error { played++; return nil },
+	}
+	if err := r.OnEvent(focotimer.Event{Kind: focotimer.EventWarning, Label: "writing", Remaining: 2 * time.Minute}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r.Wait()
+
+	if len(warnings) != 1 || warnings[0].Summary != "writing: 2m0s left" || warnings[0].Urgency != notify.Low {
+		t.Errorf("Expected a low urgency \"writing: 2m0s left\", got %+v", warnings)
+	}
+	if len(points) != 0 || played != 0 {
+		t.Errorf("Expected the warning only on its own notifier, got %+v and %d sounds", points, played)
+	}
+
+	// without a warnings notifier the warning goes unmarked
+	r.Warnings = nil
+	r.OnEvent(focotimer.Event{Kind: focotimer.EventWarning, Remaining: time.Minute})
+	r.Wait()
+	if len(warnings) != 1 || len(points) != 0 {
+		t.Errorf("Expected nothing more shown, got %+v and %+v", warnings, points)
+	}
+}
@@ -0,0 +1,178 @@
+package integrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Issue linking -------------------
+
+// IssueRef identifies an issue in GitHub or Jira.
+type IssueRef struct {
+	Tracker string // "github" or "jira"
+	Repo    string // GitHub "owner/name"
+	Number  int    // GitHub issue number
+	Key     string // Jira issue key
+}
+
+var (
+	githubFullRef  = regexp.MustCompile(`^([\w.-]+/[\w.-]+)#(\d+)$`)
+	githubShortRef = regexp.MustCompile(`^(?:GH-|#)(\d+)$`)
+	jiraRef        = regexp.MustCompile(`^[A-Z][A-Z0-9]+-\d+$`)
+)
+
+// ParseIssueRef understands "owner/repo#123", "GH-123" and "#123" (in
+// defaultRepo) for GitHub, and Jira keys such as "PROJ-42".
+func ParseIssueRef(s, defaultRepo string) (IssueRef, error) {
+	if m := githubFullRef.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return IssueRef{Tracker: "github", Repo: m[1], Number: n}, nil
+	}
+	if m := githubShortRef.FindStringSubmatch(s); m != nil {
+		if defaultRepo == "" {
+			return IssueRef{}, fmt.Errorf("issue %q: no GitHub repository configured", s)
+		}
+		n, _ := strconv.Atoi(m[1])
+		return IssueRef{Tracker: "github", Repo: defaultRepo, Number: n}, nil
+	}
+	if jiraRef.MatchString(s) {
+		return IssueRef{Tracker: "jira", Key: s}, nil
+	}
+	return IssueRef{}, fmt.Errorf("unrecognised issue reference %q", s)
+}
+
+type GitHubOptions struct {
+	Token string
+	// Repo is used for short references like "GH-123".
+	Repo string
+	// APIURL defaults to https://api.github.com.
+	APIURL string
+	// Comment posts a comment on the issue for each completed session.
+	Comment bool
+}
+
+type JiraOptions struct {
+	URL   string
+	User  string
+	Token string
+	// Worklog logs each completed session's time on the issue.
+	Worklog bool
+}
+
+// IssueLinker reports completed sessions that were worked against an issue
+// back to the tracker: a comment on GitHub, a worklog entry in Jira.
+// Reports are sent in the background and failures logged.
+type IssueLinker struct {
+	GitHub GitHubOptions
+	Jira   JiraOptions
+	Client *http.Client
+
+	wg sync.WaitGroup
+}
+
+func (l *IssueLinker) Name() string { return "issues" }
+
+func (l *IssueLinker) OnEvent(ev focotimer.Event) error {
+	if ev.Kind != focotimer.EventCompleted || ev.Issue == "" {
+		return nil
+	}
+	ref, err := ParseIssueRef(ev.Issue, l.GitHub.Repo)
+	if err != nil {
+		return err
+	}
+	var report func(IssueRef, focotimer.Event) error
+	switch {
+	case ref.Tracker == "github" && l.GitHub.Comment:
+		report = l.commentGitHub
+	case ref.Tracker == "jira" && l.Jira.Worklog:
+		report = l.worklogJira
+	default:
+		return nil
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		if err := report(ref, ev); err != nil {
+			log.Printf("integrations.IssueLinker: %s: %v", ev.Issue, err)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the reports in flight are sent, e.g. before exit.
+func (l *IssueLinker) Wait() { l.wg.Wait() }
+
+func sessionSummary(ev focotimer.Event) string {
+	s := fmt.Sprintf("Focused for %s", ev.Duration.Round(time.Second))
+	if ev.Label != "" {
+		s += " on " + ev.Label
+	}
+	return s + " (focotimer)"
+}
+
+func (l *IssueLinker) commentGitHub(ref IssueRef, ev focotimer.Event) error {
+	api := l.GitHub.APIURL
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", api, ref.Repo, ref.Number)
+	req, err := newJSONRequest(url, map[string]string{"body": sessionSummary(ev)})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+l.GitHub.Token)
+	return l.do(req)
+}
+
+func (l *IssueLinker) worklogJira(ref IssueRef, ev focotimer.Event) error {
+	url := fmt.Sprintf("%s/rest/api/2/issue/%s/worklog", l.Jira.URL, ref.Key)
+	req, err := newJSONRequest(url, map[string]any{
+		"timeSpentSeconds": int(ev.Duration.Seconds()),
+		"started":          ev.At.Add(-ev.Duration).Format("2006-01-02T15:04:05.000-0700"),
+		"comment":          sessionSummary(ev),
+	})
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(l.Jira.User, l.Jira.Token)
+	return l.do(req)
+}
+
+func newJSONRequest(url string, body any) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (l *IssueLinker) do(req *http.Request) error {
+	client := l.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package integrations
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/notify"
+)
+
+// ------------------- Completion notifications -------------------
+
+// DefaultWorkDone and DefaultBreakDone are shown when a session or a
+// break completes.
+var (
+	DefaultWorkDone  = notify.Notification{Summary: "{label} complete", Body: "{duration} of focus. Time for a break."}
+	DefaultBreakDone = notify.Notification{Summary: "Break over", Body: "Ready for the next session?"}
+)
+
+// NotificationAction is a button on a completion notification that runs
+// Commands when clicked. "{label}" and "{duration}" in a command are
+// replaced by those of the last completed work session, so a button can
+// return to it after a break.
+type NotificationAction struct {
+	Label    string
+	Commands []string
+}
+
+// DefaultWorkActions and DefaultBreakActions are the buttons on the
+// notifications of a session and of a break completing.
+var (
+	DefaultWorkActions = []NotificationAction{
+		{Label: "Start break", Commands: []string{"set 5m", "label --break", "start"}},
+		{Label: "Skip break", Commands: []string{"set {duration}", "label {label}", "start"}},
+		{Label: "+5 min", Commands: []string{"set 5m", "start"}},
+	}
+	DefaultBreakActions = []NotificationAction{
+		{Label: "Back to work", Commands: []string{"set {duration}", "label {label}", "start"}},
+	}
+)
+
+// Notifications shows a desktop notification when a session or a break
+// completes. "{label}" and "{duration}" in the summary and body are
+// replaced by the session's; breaks are the sessions marked by SetBreak.
+// Notifications are sent in the background and failures logged.
+type Notifications struct {
+	// Notifier defaults to notify.Default().
+	Notifier notify.Notifier
+	// Work and Break default to DefaultWorkDone and DefaultBreakDone; an
+	// empty summary keeps the default text.
+	Work  notify.Notification
+	Break notify.Notification
+	// Dispatch runs the commands of a clicked button, e.g. a Dispatcher's
+	// Dispatch; without it the notifications have no buttons.
+	Dispatch func(line string) error
+	// WorkActions and BreakActions default to DefaultWorkActions and
+	// DefaultBreakActions when nil; empty shows no buttons.
+	WorkActions  []NotificationAction
+	BreakActions []NotificationAction
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	lastWork focotimer.Event
+}
+
+func (n *Notifications) Name() string { return "notifications" }
+
+func (n *Notifications) OnEvent(ev focotimer.Event) error {
+	if ev.Kind != focotimer.EventCompleted {
+		return nil
+	}
+	msg, def := n.Work, DefaultWorkDone
+	actions := n.WorkActions
+	if actions == nil {
+		actions = DefaultWorkActions
+	}
+	if ev.Break {
+		msg, def = n.Break, DefaultBreakDone
+		actions = n.BreakActions
+		if actions == nil {
+			actions = DefaultBreakActions
+		}
+	} else {
+		n.mu.Lock()
+		n.lastWork = ev
+		n.mu.Unlock()
+	}
+	if msg.Summary == "" {
+		msg.Summary, msg.Body = def.Summary, def.Body
+	}
+	r := placeholders(ev, "Session")
+	msg.Summary, msg.Body = r.Replace(msg.Summary), r.Replace(msg.Body)
+	if n.Dispatch != nil && len(actions) > 0 {
+		msg.Actions = make([]notify.Action, len(actions))
+		for i, a := range actions {
+			msg.Actions[i] = notify.Action{Key: strconv.Itoa(i), Label: a.Label}
+		}
+		msg.OnAction = func(key string) {
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(actions) {
+				n.run(actions[i])
+			}
+		}
+	}
+
+	notifier := n.Notifier
+	if notifier == nil {
+		notifier = notify.Default()
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := notifier.Notify(msg); err != nil {
+			log.Printf("integrations.Notifications: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the notifications in flight are sent.
+func (n *Notifications) Wait() { n.wg.Wait() }
+
+// run dispatches the commands of a clicked button, stopping at the first
+// that fails.
+func (n *Notifications) run(a NotificationAction) {
+	n.mu.Lock()
+	r := placeholders(n.lastWork, "")
+	n.mu.Unlock()
+	for _, cmd := range a.Commands {
+		if err := n.Dispatch(r.Replace(cmd)); err != nil {
+			log.Printf("integrations.Notifications: %s: %v", a.Label, err)
+			return
+		}
+	}
+}
+
+// placeholders replaces "{label}" and "{duration}" with those of ev; an
+// unlabelled session is unnamed.
+func placeholders(ev focotimer.Event, unnamed string) *strings.Replacer {
+	label := ev.Label
+	if label == "" {
+		label = unnamed
+	}
+	return strings.NewReplacer("{label}", label, "{duration}", ev.Duration.Round(time.Second).String())
+}
@@ -0,0 +1,84 @@
+package integrations
+
+import (
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/history"
+)
+
+// Recorder appends every finished session to the history file: completed
+// sessions, and started sessions that were stopped or reset before their
+// end, as of the stop. An
+// energy rating given after a session ended is added to its record, and a
+// completed break marks the focus session before it as followed by one.
+type Recorder struct {
+	path string
+
+	mu        sync.Mutex
+	started   time.Time
+	recorded  time.Time // start of the last session written
+	lastFocus time.Time // start of a completed focus session awaiting its break
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+func (r *Recorder) Name() string { return "history" }
+
+func (r *Recorder) OnEvent(ev focotimer.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch ev.Kind {
+	case focotimer.EventStarted:
+		r.started = ev.At
+		return nil
+	case focotimer.EventCompleted, focotimer.EventStopped, focotimer.EventReset:
+		if r.started.IsZero() {
+			return nil
+		}
+	case focotimer.EventRated:
+		if ev.EnergyEnd == 0 || !r.started.IsZero() || r.recorded.IsZero() {
+			return nil
+		}
+		return history.Amend(r.path, r.recorded, func(rec *history.Record) {
+			rec.EnergyEnd = ev.EnergyEnd
+		})
+	default:
+		return nil
+	}
+
+	rec := history.Record{
+		Start:       r.started,
+		End:         ev.At,
+		Duration:    ev.Duration,
+		Label:       ev.Label,
+		Issue:       ev.Issue,
+		Completed:   ev.Kind == focotimer.EventCompleted,
+		EnergyStart: ev.EnergyStart,
+		EnergyEnd:   ev.EnergyEnd,
+		Break:       &ev.Break,
+	}
+	r.recorded, r.started = r.started, time.Time{}
+	if err := history.Append(r.path, rec); err != nil {
+		return err
+	}
+
+	focus := r.lastFocus
+	r.lastFocus = time.Time{}
+	if !ev.Break {
+		if rec.Completed {
+			r.lastFocus = rec.Start
+		}
+		return nil
+	}
+	if !rec.Completed || focus.IsZero() {
+		return nil
+	}
+	return history.Amend(r.path, focus, func(rec *history.Record) {
+		rec.BreakTaken = true
+	})
+}
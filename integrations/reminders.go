@@ -0,0 +1,113 @@
+package integrations
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/notify"
+)
+
+// ------------------- Session reminders -------------------
+
+// reminderTimeout keeps reminders out of the way; they need no answer.
+const reminderTimeout = 5 * time.Second
+
+// Reminders marks the reminder points the manager fires during a session
+// (SetReminders) with a quiet notification, a sound, or both, and the
+// warning it fires ahead of the session end (SetWarning) the same way.
+type Reminders struct {
+	// Notifier shows "writing: 5m0s left" at reminder points; nil shows
+	// nothing.
+	Notifier notify.Notifier
+	// Warnings shows the same notification for the pre-end warning; nil
+	// shows nothing.
+	Warnings notify.Notifier
+	// Play plays the reminder sound; nil plays none.
+	Play func() error
+	// Quiet silences the sound while it returns true, e.g. during a call.
+	Quiet func() bool
+
+	wg sync.WaitGroup
+}
+
+func (r *Reminders) Name() string { return "reminders" }
+
+func (r *Reminders) OnEvent(ev focotimer.Event) error {
+	switch ev.Kind {
+	case focotimer.EventWarning:
+		r.notify(r.Warnings, ev)
+	case focotimer.EventReminder:
+		r.notify(r.Notifier, ev)
+		if r.Play != nil && (r.Quiet == nil || !r.Quiet()) {
+			return r.Play()
+		}
+	}
+	return nil
+}
+
+func (r *Reminders) notify(n notify.Notifier, ev focotimer.Event) {
+	if n == nil {
+		return
+	}
+	label := ev.Label
+	if label == "" {
+		label = "Session"
+	}
+	msg := notify.Notification{
+		Summary: label + ": " + ev.Remaining.Round(time.Second).String() + " left",
+		Urgency: notify.Low,
+		Timeout: reminderTimeout,
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := n.Notify(msg); err != nil {
+			log.Printf("integrations.Reminders: %v", err)
+		}
+	}()
+}
+
+// Wait blocks until the notifications in flight are sent.
+func (r *Reminders) Wait() { r.wg.Wait() }
+
+// ------------------- Forgotten sessions -------------------
+
+// AutoResetNotice sends the one reminder for a completed session left
+// unacknowledged (SetAutoReset), as the manager resets it, so the user
+// coming back knows why the timer is stopped.
+type AutoResetNotice struct {
+	// Notifier defaults to notify.Default().
+	Notifier notify.Notifier
+
+	wg sync.WaitGroup
+}
+
+func (a *AutoResetNotice) Name() string { return "auto-reset-notice" }
+
+func (a *AutoResetNotice) OnEvent(ev focotimer.Event) error {
+	if ev.Kind != focotimer.EventAutoReset {
+		return nil
+	}
+	r := placeholders(ev, "Session")
+	msg := notify.Notification{
+		Summary: r.Replace("{label} was left finished"),
+		Body:    r.Replace("Nobody acknowledged it, so the timer went back to {duration}."),
+	}
+	notifier := a.Notifier
+	if notifier == nil {
+		notifier = notify.Default()
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := notifier.Notify(msg); err != nil {
+			log.Printf("integrations.AutoResetNotice: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the notice in flight is sent.
+func (a *AutoResetNotice) Wait() { a.wg.Wait() }
@@ -0,0 +1,168 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// ------------------- Webhooks -------------------
+
+// Webhook events; "aborted" is a session stopped or reset before its end.
+const (
+	HookStarted   = "started"
+	HookCompleted = "completed"
+	HookAborted   = "aborted"
+)
+
+// Webhook is a URL that gets a JSON POST for session events, e.g. an
+// IFTTT, Zapier or n8n trigger.
+type Webhook struct {
+	URL string
+	// Events limits the hook to some of HookStarted, HookCompleted and
+	// HookAborted; empty is all of them.
+	Events []string
+	// Headers are added to every request, e.g. an Authorization token.
+	Headers map[string]string
+}
+
+// HookPayload is the body posted to webhooks.
+type HookPayload struct {
+	Event      string    `json:"event"`
+	Label      string    `json:"label,omitempty"`
+	Phase      string    `json:"phase,omitempty"`
+	Issue      string    `json:"issue,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	ElapsedMs  int64     `json:"elapsed_ms"`
+	StartedAt  time.Time `json:"started_at"`
+	// EndedAt is zero, and left out, for HookStarted.
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+}
+
+// Webhooks posts session events to the configured hooks. Deliveries run
+// in the background so a slow endpoint never holds up other integrations;
+// failed ones are retried with a doubling delay and finally logged.
+type Webhooks struct {
+	Hooks []Webhook
+	// Client defaults to one with a 10s timeout.
+	Client *http.Client
+	// Retries after a failed attempt; defaults to 3.
+	Retries int
+	// Backoff is the delay before the first retry; defaults to 2s.
+	Backoff time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	wg      sync.WaitGroup
+}
+
+func (w *Webhooks) Name() string { return "webhooks" }
+
+func (w *Webhooks) OnEvent(ev focotimer.Event) error {
+	w.mu.Lock()
+	var event string
+	switch ev.Kind {
+	case focotimer.EventStarted:
+		w.started = ev.At
+		event = HookStarted
+	case focotimer.EventCompleted:
+		event = HookCompleted
+	case focotimer.EventStopped, focotimer.EventReset:
+		if w.started.IsZero() {
+			w.mu.Unlock()
+			return nil
+		}
+		event = HookAborted
+	default:
+		w.mu.Unlock()
+		return nil
+	}
+	p := HookPayload{
+		Event:      event,
+		Label:      ev.Label,
+		Phase:      ev.Phase,
+		Issue:      ev.Issue,
+		DurationMs: ev.Duration.Milliseconds(),
+		ElapsedMs:  (ev.Duration - ev.Remaining).Milliseconds(),
+		StartedAt:  w.started,
+	}
+	if event != HookStarted {
+		at := ev.At
+		p.EndedAt = &at
+		w.started = time.Time{}
+	}
+	w.mu.Unlock()
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	for _, h := range w.Hooks {
+		if len(h.Events) > 0 && !slices.Contains(h.Events, event) {
+			continue
+		}
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			if err := w.deliver(h, body); err != nil {
+				log.Printf("integrations.Webhooks: %s: %v", event, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Wait blocks until the deliveries in flight are done, e.g. before exit.
+func (w *Webhooks) Wait() { w.wg.Wait() }
+
+// deliver posts body to h, retrying network errors, 429 and 5xx answers.
+func (w *Webhooks) deliver(h Webhook, body []byte) error {
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	retries, backoff := w.Retries, w.Backoff
+	if retries <= 0 {
+		retries = 3
+	}
+	if backoff <= 0 {
+		backoff = 2 * time.Second
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = w.post(client, h, body); err == nil || !retry || attempt == retries {
+			return err
+		}
+		time.Sleep(backoff << attempt)
+	}
+}
+
+// post makes one attempt; retry reports whether a failure may pass.
+func (w *Webhooks) post(client *http.Client, h Webhook, body []byte) (retry bool, err error) {
+	req, err := newJSONRequest(h.URL, json.RawMessage(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", "focotimer")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return false, nil
+}
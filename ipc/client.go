@@ -0,0 +1,156 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// DefaultSocket returns the path of the control socket, under
+// XDG_RUNTIME_DIR when set. FOCOTIMER_SOCKET overrides it.
+func DefaultSocket() string {
+	if p := os.Getenv("FOCOTIMER_SOCKET"); p != "" {
+		return p
+	}
+	return filepath.Join(RuntimeDir(), "ctl.sock")
+}
+
+// RuntimeDir is where a running focotimer keeps its sockets and pipes:
+// $XDG_RUNTIME_DIR/focotimer, or a directory of the user's in the
+// temporary directory.
+func RuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "focotimer")
+	}
+	return filepath.Join(os.TempDir(), "focotimer-"+strconv.Itoa(os.Getuid()))
+}
+
+// Client talks to the API of a running focotimer over its control socket.
+type Client struct {
+	socket string
+	http   *http.Client
+}
+
+func NewClient(socket string) *Client {
+	dialer := &net.Dialer{}
+	return &Client{
+		socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}},
+	}
+}
+
+// Status returns the state of the timer.
+func (c *Client) Status(ctx context.Context) (focotimer.State, error) {
+	return c.do(ctx, http.MethodGet, "/status", nil)
+}
+
+// Command runs a dispatcher command line such as "set 25m" and returns the
+// resulting state.
+func (c *Client) Command(ctx context.Context, line string) (focotimer.State, error) {
+	body, err := json.Marshal(map[string]string{"command": line})
+	if err != nil {
+		return focotimer.State{}, err
+	}
+	return c.do(ctx, http.MethodPost, "/command", body)
+}
+
+// Watch calls fn with every event until ctx is done, fn returns an error
+// or the instance goes away.
+func (c *Client) Watch(ctx context.Context, fn func(focotimer.Event) error) error {
+	resp, err := c.request(ctx, http.MethodGet, "/events", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev focotimer.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (focotimer.State, error) {
+	var s focotimer.State
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return s, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s, responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return s, fmt.Errorf("decode state: %w", err)
+	}
+	return s, nil
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = strings.NewReader(string(body))
+	}
+	// the host is ignored; every request goes to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://focotimer"+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("focotimer is not running (no control socket at %q)", c.socket)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// responseError turns an {"error": "..."} answer into an error.
+func responseError(resp *http.Response) error {
+	var v struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil || v.Error == "" {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return errors.New(v.Error)
+}
@@ -0,0 +1,287 @@
+package ipc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/ipc/pb"
+)
+
+//go:generate make -C .. proto
+
+// GRPC serves the Timer service of proto/focotimer.proto, so clients in
+// other languages get typed control and streaming updates without parsing
+// text. Like the API, commands run through the dispatcher. Over TCP,
+// calls changing the timer carry the token set with RequireToken.
+type GRPC struct {
+	pb.UnimplementedTimerServer
+
+	tm *focotimer.TimerManager
+	d  *focotimer.Dispatcher
+
+	mu    sync.Mutex
+	srv   *grpc.Server
+	token string
+}
+
+func NewGRPC(tm *focotimer.TimerManager, d *focotimer.Dispatcher) *GRPC {
+	return &GRPC{tm: tm, d: d}
+}
+
+// RequireToken sets the token that calls changing the timer over TCP must
+// carry, as "authorization: Bearer <token>" metadata. Without one only
+// GetState and Watch are served over TCP; unix sockets are not guarded.
+func (g *GRPC) RequireToken(token string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.token = token
+}
+
+// guard refuses the unary calls other than GetState, which all change the
+// timer, without the token. Watch only reads and is left alone.
+func (g *GRPC) guard(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if info.FullMethod != pb.Timer_GetState_FullMethodName {
+		g.mu.Lock()
+		token := g.token
+		g.mu.Unlock()
+		if !validMetadataToken(ctx, token) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+	return handler(ctx, req)
+}
+
+func validMetadataToken(ctx context.Context, token string) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if got, ok := strings.CutPrefix(v, "Bearer "); ok && token != "" &&
+			subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Register adds the Timer service to s, for embedding it in a server of
+// one's own.
+func (g *GRPC) Register(s grpc.ServiceRegistrar) {
+	pb.RegisterTimerServer(s, g)
+}
+
+// --- Control ---
+
+func (g *GRPC) GetState(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return stateProto(g.tm.State()), nil
+}
+
+func (g *GRPC) Start(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("start")
+}
+
+func (g *GRPC) Pause(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("pause")
+}
+
+func (g *GRPC) Resume(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("resume")
+}
+
+func (g *GRPC) Toggle(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("toggle")
+}
+
+func (g *GRPC) Stop(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("stop")
+}
+
+func (g *GRPC) Reset(context.Context, *emptypb.Empty) (*pb.State, error) {
+	return g.run("reset")
+}
+
+func (g *GRPC) SetDuration(_ context.Context, req *pb.SetDurationRequest) (*pb.State, error) {
+	d := req.GetDuration()
+	if err := d.CheckValid(); err != nil || d.AsDuration() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid duration %v", d.AsDuration())
+	}
+	g.tm.SetDuration(d.AsDuration())
+	return stateProto(g.tm.State()), nil
+}
+
+func (g *GRPC) SetLabel(_ context.Context, req *pb.SetLabelRequest) (*pb.State, error) {
+	g.tm.SetLabel(req.GetLabel())
+	return stateProto(g.tm.State()), nil
+}
+
+func (g *GRPC) Command(_ context.Context, req *pb.CommandRequest) (*pb.State, error) {
+	if strings.TrimSpace(req.GetCommand()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty command")
+	}
+	return g.run(req.GetCommand())
+}
+
+func (g *GRPC) run(line string) (*pb.State, error) {
+	if err := g.d.Dispatch(line); err != nil {
+		code := codes.InvalidArgument
+		if errors.Is(err, focotimer.ErrUnknownCommand) {
+			code = codes.NotFound
+		}
+		return nil, status.Error(code, err.Error())
+	}
+	return stateProto(g.tm.State()), nil
+}
+
+// --- Streaming ---
+
+func (g *GRPC) Watch(req *pb.WatchRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	events := g.tm.SubscribeEvents()
+	defer g.tm.UnsubscribeEvents(events)
+
+	var ticks <-chan time.Duration
+	if every := req.GetTickInterval().AsDuration(); every > 0 {
+		ch := g.tm.SubscribeEvery(every, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+		defer g.tm.Unsubscribe(ch)
+		ticks = ch
+	}
+
+	for {
+		var ev focotimer.Event
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev = <-events:
+		case <-ticks:
+			ev = g.tm.TickEvent()
+		}
+		if err := stream.Send(eventProto(ev)); err != nil {
+			return err
+		}
+	}
+}
+
+// --- Conversion ---
+
+var statusProtos = map[focotimer.Status]pb.Status{
+	focotimer.StatusIdle:      pb.Status_STATUS_IDLE,
+	focotimer.StatusRunning:   pb.Status_STATUS_RUNNING,
+	focotimer.StatusPaused:    pb.Status_STATUS_PAUSED,
+	focotimer.StatusCompleted: pb.Status_STATUS_COMPLETED,
+}
+
+var eventKindProtos = map[focotimer.EventKind]pb.EventKind{
+	focotimer.EventStarted:   pb.EventKind_EVENT_KIND_STARTED,
+	focotimer.EventPaused:    pb.EventKind_EVENT_KIND_PAUSED,
+	focotimer.EventResumed:   pb.EventKind_EVENT_KIND_RESUMED,
+	focotimer.EventStopped:   pb.EventKind_EVENT_KIND_STOPPED,
+	focotimer.EventCompleted: pb.EventKind_EVENT_KIND_COMPLETED,
+	focotimer.EventReset:     pb.EventKind_EVENT_KIND_RESET,
+	focotimer.EventWarning:   pb.EventKind_EVENT_KIND_WARNING,
+	focotimer.EventAutoReset: pb.EventKind_EVENT_KIND_AUTO_RESET,
+	focotimer.EventTick:      pb.EventKind_EVENT_KIND_TICK,
+	focotimer.EventPhase:     pb.EventKind_EVENT_KIND_PHASE,
+	focotimer.EventExtended:  pb.EventKind_EVENT_KIND_EXTENDED,
+	focotimer.EventRated:     pb.EventKind_EVENT_KIND_RATED,
+	focotimer.EventReminder:  pb.EventKind_EVENT_KIND_REMINDER,
+}
+
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func stateProto(s focotimer.State) *pb.State {
+	return &pb.State{
+		Status:        statusProtos[s.Status],
+		Duration:      durationpb.New(s.Duration),
+		Remaining:     durationpb.New(s.Remaining),
+		Elapsed:       durationpb.New(s.Elapsed),
+		StartedAt:     optionalTimestamp(s.StartedAt),
+		Deadline:      optionalTimestamp(s.Deadline),
+		Label:         s.Label,
+		Phase:         s.Phase,
+		Issue:         s.Issue,
+		QueuePosition: int32(s.QueuePosition),
+		QueueLength:   int32(s.QueueLength),
+		EnergyStart:   int32(s.EnergyStart),
+		EnergyEnd:     int32(s.EnergyEnd),
+	}
+}
+
+func eventProto(ev focotimer.Event) *pb.Event {
+	return &pb.Event{
+		Kind:        eventKindProtos[ev.Kind],
+		At:          timestamppb.New(ev.At),
+		Duration:    durationpb.New(ev.Duration),
+		Remaining:   durationpb.New(ev.Remaining),
+		Label:       ev.Label,
+		Phase:       ev.Phase,
+		Issue:       ev.Issue,
+		EnergyStart: int32(ev.EnergyStart),
+		EnergyEnd:   int32(ev.EnergyEnd),
+		Reminder:    ev.Reminder,
+	}
+}
+
+// --- Server ---
+
+// Listen serves the Timer service on addr in the background. An address
+// without a host, e.g. ":7273", listens on the loopback interface only, as
+// for the HTTP API.
+func (g *GRPC) Listen(addr string) error {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %q: %w", addr, err)
+	}
+	g.Serve(ln)
+	return nil
+}
+
+// Serve serves the Timer service on ln in the background.
+func (g *GRPC) Serve(ln net.Listener) {
+	var opts []grpc.ServerOption
+	if ln.Addr().Network() != "unix" {
+		opts = append(opts, grpc.UnaryInterceptor(g.guard))
+	}
+	srv := grpc.NewServer(opts...)
+	g.Register(srv)
+	g.mu.Lock()
+	g.srv = srv
+	g.mu.Unlock()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Printf("ipc.GRPC: serve: %v", err)
+		}
+	}()
+}
+
+// Close stops the server, ending open Watch streams.
+func (g *GRPC) Close() error {
+	g.mu.Lock()
+	srv := g.srv
+	g.srv = nil
+	g.mu.Unlock()
+	if srv != nil {
+		srv.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// API is a small JSON REST interface for dashboards, Stream Deck buttons
+// and scripts:
+//
+//	GET  /status            current State
+//	POST /duration          {"duration": "25m"}
+//	POST /label             {"label": "writing"}
+//	POST /command           {"command": "queue add 4x25m report"}
+//	POST /<command>         any dispatcher command without arguments,
+//	                        e.g. /start, /pause, /toggle
+//	GET  /events, /ws       live events as server-sent events or over a
+//	                        WebSocket, once Stream is called
+//	POST /trigger/<profile> start a predefined session from a webhook,
+//	                        once Triggers is called
+//
+// Every successful request answers with the resulting State; failures
+// answer {"error": "..."}. Over TCP, which any web page can post to,
+// requests other than GET carry the token set with RequireToken.
+type API struct {
+	tm  *focotimer.TimerManager
+	d   *focotimer.Dispatcher
+	mux *http.ServeMux
+
+	mu    sync.Mutex
+	srvs  []*http.Server
+	token string
+}
+
+// NewAPI serves tm, running commands through d so aliases and custom
+// commands work like on the other frontends.
+func NewAPI(tm *focotimer.TimerManager, d *focotimer.Dispatcher) *API {
+	a := &API{tm: tm, d: d, mux: http.NewServeMux()}
+	a.mux.HandleFunc("GET /status", a.status)
+	a.mux.HandleFunc("POST /duration", a.duration)
+	a.mux.HandleFunc("POST /label", a.label)
+	a.mux.HandleFunc("POST /command", a.command)
+	a.mux.HandleFunc("POST /{command}", a.named)
+	return a
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+// RequireToken sets the token that requests changing the timer over TCP
+// must carry, as for Triggers. Without one the API only answers GET
+// requests over TCP; unix sockets, private to the user, are not guarded.
+func (a *API) RequireToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+// guarded serves requests from TCP listeners, refusing those that change
+// the timer without the token.
+func (a *API) guarded(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		a.mu.Lock()
+		token := a.token
+		a.mu.Unlock()
+		if !validToken(r, token) {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+	}
+	a.mux.ServeHTTP(w, r)
+}
+
+// Handle mounts an extra handler.
+func (a *API) Handle(pattern string, h http.Handler) {
+	a.mux.Handle(pattern, h)
+}
+
+// Stream serves the events of o at /events (server-sent events) and /ws
+// (WebSocket), for overlays and remote UIs that update live.
+func (a *API) Stream(o *Observer) {
+	a.mux.Handle("GET /events", o)
+	a.mux.HandleFunc("GET /ws", o.ServeWebSocket)
+}
+
+// --- Endpoints ---
+
+func (a *API) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.tm.State())
+}
+
+func (a *API) duration(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Duration string `json:"duration"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	d, err := time.ParseDuration(body.Duration)
+	if err != nil || d <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration %q", body.Duration))
+		return
+	}
+	a.tm.SetDuration(d)
+	a.status(w, r)
+}
+
+func (a *API) label(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Label string `json:"label"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	a.tm.SetLabel(body.Label)
+	a.status(w, r)
+}
+
+func (a *API) command(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Command string `json:"command"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if strings.TrimSpace(body.Command) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("empty command"))
+		return
+	}
+	a.run(w, r, body.Command)
+}
+
+func (a *API) named(w http.ResponseWriter, r *http.Request) {
+	a.run(w, r, r.PathValue("command"))
+}
+
+func (a *API) run(w http.ResponseWriter, r *http.Request, line string) {
+	if err := a.d.Dispatch(line); err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, focotimer.ErrUnknownCommand) {
+			code = http.StatusNotFound
+		}
+		writeError(w, code, err)
+		return
+	}
+	a.status(w, r)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ipc.API: encode response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// --- Server ---
+
+// Listen serves the API on addr in the background. An address without a
+// host, e.g. ":7272", listens on the loopback interface only; name one,
+// e.g. "0.0.0.0:7272", to serve other machines.
+func (a *API) Listen(addr string) error {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %q: %w", addr, err)
+	}
+	a.Serve(ln)
+	return nil
+}
+
+// ListenUnix serves the API on the unix socket at path in the background;
+// this is what focotimerctl talks to. The socket is removed on Close.
+func (a *API) ListenUnix(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create socket directory: %w", err)
+	}
+	ln, err := listenUnix(path)
+	if err != nil {
+		return err
+	}
+	a.Serve(ln)
+	return nil
+}
+
+// Serve serves the API on ln in the background, e.g. on a socket passed by
+// systemd. Close closes ln but leaves a unix socket file in place.
+func (a *API) Serve(ln net.Listener) {
+	var h http.Handler = a
+	if ln.Addr().Network() != "unix" {
+		h = http.HandlerFunc(a.guarded)
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	a.mu.Lock()
+	a.srvs = append(a.srvs, srv)
+	a.mu.Unlock()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("ipc.API: serve: %v", err)
+		}
+	}()
+}
+
+// Close shuts the servers down, giving requests in flight a moment to
+// finish.
+func (a *API) Close() error {
+	a.mu.Lock()
+	srvs := a.srvs
+	a.srvs = nil
+	a.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var errs []error
+	for _, srv := range srvs {
+		errs = append(errs, srv.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
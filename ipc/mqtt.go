@@ -0,0 +1,495 @@
+package ipc
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// --- MQTT ---
+
+// MQTTTopics are the topics MQTT publishes to and listens on. Empty ones
+// default to "focotimer/<name>", e.g. "focotimer/state".
+type MQTTTopics struct {
+	// State gets the State as JSON on every change, retained.
+	State string
+	// Status gets "idle", "running", "paused" or "completed", retained.
+	Status string
+	// Remaining gets the remaining whole seconds whenever they change,
+	// retained.
+	Remaining string
+	// Event gets every lifecycle event as JSON.
+	Event string
+	// Command runs every message it receives as a command, e.g. "toggle"
+	// or "start 25m".
+	Command string
+	// Availability is "online" while connected; the broker sets it to
+	// "offline" when the connection drops.
+	Availability string
+}
+
+type MQTTOptions struct {
+	// Broker is "host:port", or "tls://host:port" for a TLS connection;
+	// the port defaults to 1883, or 8883 with TLS.
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	Topics   MQTTTopics
+	// KeepAlive is how often the connection is checked; defaults to 30s.
+	KeepAlive time.Duration
+	// TickRate is how often Remaining is refreshed; defaults to 1s.
+	TickRate time.Duration
+	// Discovery is the Home Assistant discovery prefix, usually
+	// "homeassistant". When set, the timer announces itself there as a
+	// device with sensors and buttons.
+	Discovery string
+}
+
+// MQTT publishes a TimerManager to an MQTT broker for home automation such
+// as Home Assistant or a desk light, and runs the commands sent to it. It
+// speaks MQTT 3.1.1 at QoS 0.
+type MQTT struct {
+	tm      *focotimer.TimerManager
+	d       *focotimer.Dispatcher
+	opts    MQTTOptions
+	lastErr string // touched by Run only
+}
+
+// NewMQTT publishes tm, running commands through d so aliases and custom
+// commands work as they do on the command pipe.
+func NewMQTT(tm *focotimer.TimerManager, d *focotimer.Dispatcher, opts MQTTOptions) *MQTT {
+	topic := func(t *string, name string) {
+		if *t == "" {
+			*t = "focotimer/" + name
+		}
+	}
+	topic(&opts.Topics.State, "state")
+	topic(&opts.Topics.Status, "status")
+	topic(&opts.Topics.Remaining, "remaining")
+	topic(&opts.Topics.Event, "event")
+	topic(&opts.Topics.Command, "command")
+	topic(&opts.Topics.Availability, "availability")
+	if opts.ClientID == "" {
+		host, _ := os.Hostname()
+		opts.ClientID = "focotimer-" + host
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.TickRate <= 0 {
+		opts.TickRate = time.Second
+	}
+	return &MQTT{tm: tm, d: d, opts: opts}
+}
+
+// Run stays connected to the broker until stop is closed, reconnecting
+// with a growing delay whenever the connection fails. An error is logged
+// once until it changes.
+func (m *MQTT) Run(stop <-chan struct{}) {
+	const maxDelay = time.Minute
+	delay := time.Second
+	for {
+		connected, err := m.session(stop)
+		if err == nil {
+			return
+		}
+		if msg := err.Error(); msg != m.lastErr {
+			log.Printf("ipc.MQTT: %v", err)
+			m.lastErr = msg
+		}
+		if connected {
+			delay = time.Second
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// session runs one connection until stop is closed, returning nil, or
+// until it fails. connected reports whether the broker accepted it.
+func (m *MQTT) session(stop <-chan struct{}) (connected bool, err error) {
+	conn, err := m.dial()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(header byte, body []byte) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		return writeMQTT(conn, header, body)
+	}
+
+	if err := write(mqttConnect, m.connectBody()); err != nil {
+		return false, err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	header, body, err := readMQTT(r)
+	if err != nil {
+		return false, fmt.Errorf("connect %s: %w", m.opts.Broker, err)
+	}
+	if header>>4 != mqttConnAck>>4 || len(body) != 2 {
+		return false, fmt.Errorf("connect %s: unexpected packet %#x", m.opts.Broker, header)
+	}
+	if code := body[1]; code != 0 {
+		return false, fmt.Errorf("connect %s: %s", m.opts.Broker, connAckError(code))
+	}
+	if m.lastErr != "" {
+		log.Printf("ipc.MQTT: connected to %s", m.opts.Broker)
+		m.lastErr = ""
+	}
+
+	sub := binary.BigEndian.AppendUint16(nil, 1) // packet identifier
+	sub = append(appendMQTTString(sub, m.opts.Topics.Command), 0)
+	if err := write(mqttSubscribe, sub); err != nil {
+		return true, err
+	}
+
+	events := m.tm.SubscribeEvents()
+	defer m.tm.UnsubscribeEvents(events)
+	ticks := m.tm.SubscribeEvery(m.opts.TickRate, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	defer m.tm.Unsubscribe(ticks)
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- m.read(conn, r) }()
+
+	publish := func(topic string, payload []byte, retain bool) error {
+		header := byte(mqttPublish)
+		if retain {
+			header |= 0x01
+		}
+		return write(header, append(appendMQTTString(nil, topic), payload...))
+	}
+	lastRemaining := -1
+	publishRemaining := func(s focotimer.State) error {
+		secs := int(s.Remaining.Round(time.Second) / time.Second)
+		if secs == lastRemaining {
+			return nil
+		}
+		lastRemaining = secs
+		return publish(m.opts.Topics.Remaining, []byte(strconv.Itoa(secs)), true)
+	}
+	publishState := func() error {
+		s := m.tm.State()
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if err := publish(m.opts.Topics.State, data, true); err != nil {
+			return err
+		}
+		if err := publish(m.opts.Topics.Status, []byte(s.Status), true); err != nil {
+			return err
+		}
+		return publishRemaining(s)
+	}
+
+	if err := publish(m.opts.Topics.Availability, []byte("online"), true); err != nil {
+		return true, err
+	}
+	for _, msg := range m.discovery() {
+		if err := publish(msg.topic, msg.payload, true); err != nil {
+			return true, err
+		}
+	}
+	if err := publishState(); err != nil {
+		return true, err
+	}
+	ping := time.NewTicker(m.opts.KeepAlive)
+	defer ping.Stop()
+	for {
+		select {
+		case <-stop:
+			_ = publish(m.opts.Topics.Availability, []byte("offline"), true)
+			_ = write(mqttDisconnect, nil)
+			return true, nil
+		case err := <-readErr:
+			return true, err
+		case ev := <-events:
+			data, err := json.Marshal(ev)
+			if err == nil {
+				err = publish(m.opts.Topics.Event, data, false)
+			}
+			if err == nil {
+				err = publishState()
+			}
+			if err != nil {
+				return true, err
+			}
+		case <-ticks:
+			if err := publishRemaining(m.tm.State()); err != nil {
+				return true, err
+			}
+		case <-ping.C:
+			if err := write(mqttPingReq, nil); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+// read handles the packets from the broker, running the commands that
+// arrive, until the connection fails. A connection that stays silent for
+// one and a half keep-alive periods, despite the pings, is dead.
+func (m *MQTT) read(conn net.Conn, r *bufio.Reader) error {
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(m.opts.KeepAlive * 3 / 2))
+		header, body, err := readMQTT(r)
+		if err != nil {
+			return err
+		}
+		switch header >> 4 {
+		case mqttSubAck >> 4:
+			if len(body) == 3 && body[2] == 0x80 {
+				return fmt.Errorf("subscribe %s: refused by the broker", m.opts.Topics.Command)
+			}
+		case mqttPublish >> 4:
+			topic, payload, err := parsePublish(header, body)
+			if err != nil {
+				return err
+			}
+			if topic != m.opts.Topics.Command {
+				continue
+			}
+			line := strings.TrimSpace(string(payload))
+			if err := m.d.Dispatch(line); err != nil {
+				log.Printf("ipc.MQTT: command %q: %v", line, err)
+			}
+		}
+	}
+}
+
+func (m *MQTT) dial() (net.Conn, error) {
+	addr, useTLS := strings.CutPrefix(m.opts.Broker, "tls://")
+	addr = strings.TrimPrefix(addr, "tcp://")
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		return tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	}
+	return dialer.Dial("tcp", addr)
+}
+
+// connectBody is a clean-session CONNECT with the availability topic as the
+// will, so the broker marks the timer offline when the connection drops.
+func (m *MQTT) connectBody() []byte {
+	body := append(appendMQTTString(nil, "MQTT"), 4) // protocol level 3.1.1
+	flags := byte(0x02 | 0x04 | 0x20)                // clean session, will, retain the will
+	if m.opts.Username != "" {
+		flags |= 0x80
+	}
+	if m.opts.Password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(m.opts.KeepAlive/time.Second))
+	body = appendMQTTString(body, m.opts.ClientID)
+	body = appendMQTTString(body, m.opts.Topics.Availability)
+	body = appendMQTTString(body, "offline")
+	if m.opts.Username != "" {
+		body = appendMQTTString(body, m.opts.Username)
+	}
+	if m.opts.Password != "" {
+		body = appendMQTTString(body, m.opts.Password)
+	}
+	return body
+}
+
+// --- Home Assistant discovery ---
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// discovery returns the Home Assistant discovery messages announcing the
+// timer: sensors for the remaining time, status and phase, and buttons
+// for the basic commands. It is empty without a discovery prefix.
+func (m *MQTT) discovery() []mqttMessage {
+	if m.opts.Discovery == "" {
+		return nil
+	}
+	node := discoveryID(m.opts.ClientID)
+	device := map[string]any{
+		"identifiers": []string{node},
+		"name":        "focotimer",
+		"model":       "focus timer",
+	}
+	var msgs []mqttMessage
+	add := func(component, object string, config map[string]any) {
+		config["unique_id"] = node + "_" + object
+		config["object_id"] = node + "_" + object
+		config["availability_topic"] = m.opts.Topics.Availability
+		config["device"] = device
+		data, err := json.Marshal(config)
+		if err != nil {
+			return
+		}
+		topic := strings.Join([]string{m.opts.Discovery, component, node, object, "config"}, "/")
+		msgs = append(msgs, mqttMessage{topic, data})
+	}
+
+	add("sensor", "remaining", map[string]any{
+		"name":                "Remaining",
+		"state_topic":         m.opts.Topics.Remaining,
+		"device_class":        "duration",
+		"unit_of_measurement": "s",
+		"icon":                "mdi:timer-outline",
+	})
+	add("sensor", "status", map[string]any{
+		"name":        "Status",
+		"state_topic": m.opts.Topics.Status,
+		"icon":        "mdi:timer-cog-outline",
+	})
+	add("sensor", "phase", map[string]any{
+		"name":           "Phase",
+		"state_topic":    m.opts.Topics.State,
+		"value_template": "{{ value_json.phase | default('') }}",
+		"icon":           "mdi:progress-clock",
+	})
+	for _, cmd := range []string{"start", "pause", "resume", "stop"} {
+		add("button", cmd, map[string]any{
+			"name":          strings.ToUpper(cmd[:1]) + cmd[1:],
+			"command_topic": m.opts.Topics.Command,
+			"payload_press": cmd,
+		})
+	}
+	return msgs
+}
+
+// discoveryID reduces s to the characters Home Assistant allows in a node
+// or object id.
+func discoveryID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Fixed header bytes of the MQTT packets used.
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttSubscribe  = 0x82
+	mqttSubAck     = 0x90
+	mqttPingReq    = 0xC0
+	mqttDisconnect = 0xE0
+)
+
+// mqttMaxPacket bounds the packets accepted from the broker.
+const mqttMaxPacket = 1 << 20
+
+func connAckError(code byte) string {
+	switch code {
+	case 1:
+		return "unsupported protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("refused with code %d", code)
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func writeMQTT(w io.Writer, header byte, body []byte) error {
+	packet := []byte{header}
+	// remaining length: 7 bits per byte, least significant first
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(packet, body...))
+	return err
+}
+
+func readMQTT(r *bufio.Reader) (header byte, body []byte, err error) {
+	if header, err = r.ReadByte(); err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+	}
+	if n > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("packet of %d bytes is too large", n)
+	}
+	body = make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// parsePublish splits a PUBLISH packet into its topic and payload.
+func parsePublish(header byte, body []byte) (topic string, payload []byte, err error) {
+	if len(body) < 2 {
+		return "", nil, errors.New("malformed publish")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+n {
+		return "", nil, errors.New("malformed publish")
+	}
+	topic, rest := string(body[2:2+n]), body[2+n:]
+	if (header>>1)&0x03 > 0 {
+		// a packet identifier follows the topic above QoS 0
+		if len(rest) < 2 {
+			return "", nil, errors.New("malformed publish")
+		}
+		rest = rest[2:]
+	}
+	return topic, rest, nil
+}
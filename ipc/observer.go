@@ -0,0 +1,270 @@
+// Package ipc exposes a running TimerManager to other processes.
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// clientBuffer is how many encoded events a slow client may lag behind
+// before it is disconnected.
+const clientBuffer = 64
+
+// Observer streams the full event stream of a TimerManager, plus periodic
+// tick events, to read-only clients as JSON. Clients connect over a unix
+// socket (one JSON object per line) or HTTP server-sent events.
+type Observer struct {
+	tm       *focotimer.TimerManager
+	tickRate time.Duration
+
+	mu       sync.Mutex
+	clients  map[chan []byte]struct{}
+	ln       net.Listener
+	path     string
+	started  bool
+	stopping chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewObserver(tm *focotimer.TimerManager, tickRate time.Duration) *Observer {
+	if tickRate <= 0 {
+		tickRate = time.Second
+	}
+	return &Observer{
+		tm:       tm,
+		tickRate: tickRate,
+		clients:  make(map[chan []byte]struct{}),
+		stopping: make(chan struct{}),
+	}
+}
+
+// start launches the fan-out goroutine once.
+func (o *Observer) start() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.started {
+		return
+	}
+	o.started = true
+
+	events := o.tm.SubscribeEvents()
+	ticks := o.tm.SubscribeEvery(o.tickRate, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer o.tm.UnsubscribeEvents(events)
+		defer o.tm.Unsubscribe(ticks)
+		for {
+			select {
+			case <-o.stopping:
+				return
+			case ev := <-events:
+				o.broadcast(ev)
+			case <-ticks:
+				o.broadcast(o.tm.TickEvent())
+			}
+		}
+	}()
+}
+
+func (o *Observer) broadcast(ev focotimer.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("ipc.Observer: encode event: %v", err)
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for ch := range o.clients {
+		select {
+		case ch <- data:
+		default:
+			// too slow to keep up; the writer notices the closed channel
+			delete(o.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// register adds a client, primed with a tick so it knows the current state
+// before anything happens.
+func (o *Observer) register() chan []byte {
+	o.start()
+	ch := make(chan []byte, clientBuffer)
+	if data, err := json.Marshal(o.tm.TickEvent()); err == nil {
+		ch <- data
+	}
+	o.mu.Lock()
+	o.clients[ch] = struct{}{}
+	o.mu.Unlock()
+	return ch
+}
+
+func (o *Observer) unregister(ch chan []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.clients[ch]; ok {
+		delete(o.clients, ch)
+		close(ch)
+	}
+}
+
+// --- Unix socket ---
+
+// Listen creates the unix socket at path, replacing a stale socket left
+// behind by a previous run, and serves clients in the background.
+func (o *Observer) Listen(path string) error {
+	ln, err := listenUnix(path)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	o.path = path
+	o.mu.Unlock()
+	o.Serve(ln)
+	return nil
+}
+
+// Serve streams events to the clients of ln, e.g. a socket passed by
+// systemd, which Close closes but does not remove.
+func (o *Observer) Serve(ln net.Listener) {
+	o.mu.Lock()
+	o.ln = ln
+	o.mu.Unlock()
+
+	o.start()
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.acceptLoop(ln)
+	}()
+}
+
+// listenUnix listens on the unix socket at path, replacing a stale socket
+// but refusing one that another process still serves.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %q is in use", path)
+		}
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %q: %w", path, err)
+	}
+	return ln, nil
+}
+
+func (o *Observer) acceptLoop(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("ipc.Observer: accept: %v", err)
+			}
+			return
+		}
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			o.serveConn(conn)
+		}()
+	}
+}
+
+func (o *Observer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	ch := o.register()
+	defer o.unregister(ch)
+
+	for {
+		select {
+		case <-o.stopping:
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+			if _, err := conn.Write(append(data, '\n')); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// --- HTTP server-sent events ---
+
+// ServeHTTP streams events as text/event-stream until the client goes away.
+func (o *Observer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := o.register()
+	defer o.unregister(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-o.stopping:
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Close stops the observer, disconnects all clients, removes the socket
+// and releases its subscriptions to the TimerManager.
+func (o *Observer) Close() error {
+	o.mu.Lock()
+	select {
+	case <-o.stopping:
+		o.mu.Unlock()
+		return nil
+	default:
+	}
+	close(o.stopping)
+	ln, path := o.ln, o.path
+	o.mu.Unlock()
+
+	var err error
+	if ln != nil {
+		err = ln.Close()
+	}
+	if path != "" {
+		if rmErr := os.Remove(path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+			err = rmErr
+		}
+	}
+	o.wg.Wait()
+	return err
+}
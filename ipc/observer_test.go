@@ -0,0 +1,663 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+	"github.com/d093w1z/focotimer/ipc/pb"
+)
+
+func readEvent(t *testing.T, r *bufio.Reader, kind focotimer.EventKind) focotimer.Event {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimPrefix(strings.TrimSpace(line), "data: ")
+		if line == "" {
+			continue
+		}
+		var ev focotimer.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", line, err)
+		}
+		if ev.Kind == kind {
+			return ev
+		}
+	}
+}
+
+func TestObserver_Socket(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	tm.SetLabel("writing")
+	obs := NewObserver(tm, 50*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "events.sock")
+
+	if err := obs.Listen(path); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer obs.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	r := bufio.NewReader(conn)
+
+	tick := readEvent(t, r, focotimer.EventTick)
+	if tick.Duration != 5*time.Second {
+		t.Errorf("Expected tick duration 5s, got %v", tick.Duration)
+	}
+
+	tm.Start()
+	started := readEvent(t, r, focotimer.EventStarted)
+	if started.Label != "writing" {
+		t.Errorf("Expected label %q, got %q", "writing", started.Label)
+	}
+}
+
+func TestObserver_SocketInUse(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	path := filepath.Join(t.TempDir(), "events.sock")
+
+	first := NewObserver(tm, time.Second)
+	if err := first.Listen(path); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer first.Close()
+
+	second := NewObserver(tm, time.Second)
+	if err := second.Listen(path); err == nil {
+		second.Close()
+		t.Error("Expected error when socket is already served")
+	}
+}
+
+func TestObserver_SSE(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	obs := NewObserver(tm, 50*time.Millisecond)
+	defer obs.Close()
+
+	srv := httptest.NewServer(obs)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	readEvent(t, r, focotimer.EventTick)
+	tm.Stop()
+	readEvent(t, r, focotimer.EventStopped)
+}
+
+func doJSON(t *testing.T, method, url, body string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	var v map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.StatusCode, v
+}
+
+func TestAPI(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	d := focotimer.NewDispatcher(tm)
+	if err := d.Define("coffee", []string{"set 5m", "label coffee"}); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	srv := httptest.NewServer(NewAPI(tm, d))
+	defer srv.Close()
+
+	tests := []struct {
+		method, path, body string
+		code               int
+		check              func(v map[string]any) bool
+	}{
+		{"GET", "/status", "", http.StatusOK, func(v map[string]any) bool { return v["status"] == "idle" }},
+		{"POST", "/duration", `{"duration": "25m"}`, http.StatusOK, func(v map[string]any) bool { return v["duration_ms"] == float64(25*60*1000) }},
+		{"POST", "/label", `{"label": "writing"}`, http.StatusOK, func(v map[string]any) bool { return v["label"] == "writing" }},
+		{"POST", "/start", "", http.StatusOK, func(v map[string]any) bool { return v["status"] == "running" }},
+		{"POST", "/pause", "", http.StatusOK, func(v map[string]any) bool { return v["status"] == "paused" }},
+		{"POST", "/command", `{"command": "coffee"}`, http.StatusOK, func(v map[string]any) bool { return v["label"] == "coffee" }},
+		{"POST", "/duration", `{"duration": "soon"}`, http.StatusBadRequest, func(v map[string]any) bool { return v["error"] != nil }},
+		{"POST", "/command", `{"command": "set"}`, http.StatusBadRequest, func(v map[string]any) bool { return v["error"] != nil }},
+		{"POST", "/launch", "", http.StatusNotFound, func(v map[string]any) bool { return v["error"] != nil }},
+	}
+	for _, tt := range tests {
+		code, v := doJSON(t, tt.method, srv.URL+tt.path, tt.body)
+		if code != tt.code {
+			t.Errorf("%s %s: expected status %d, got %d (%v)", tt.method, tt.path, tt.code, code, v)
+			continue
+		}
+		if !tt.check(v) {
+			t.Errorf("%s %s: unexpected response %v", tt.method, tt.path, v)
+		}
+	}
+}
+
+func TestAPI_Listen(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	api := NewAPI(tm, focotimer.NewDispatcher(tm))
+	if err := api.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	if err := api.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if err := api.Listen("localhost:-1"); err == nil {
+		api.Close()
+		t.Error("Expected error for invalid address")
+	}
+}
+
+// readWSEvent reads server frames until an event of the given kind.
+func readWSEvent(t *testing.T, r *bufio.Reader, kind focotimer.EventKind) focotimer.Event {
+	t.Helper()
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		if f.op != wsText {
+			t.Fatalf("Expected a text frame, got opcode %d", f.op)
+		}
+		var ev focotimer.Event
+		if err := json.Unmarshal(f.payload, &ev); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", f.payload, err)
+		}
+		if ev.Kind == kind {
+			return ev
+		}
+	}
+}
+
+func TestAPI_StreamWebSocket(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	obs := NewObserver(tm, 50*time.Millisecond)
+	defer obs.Close()
+	api := NewAPI(tm, focotimer.NewDispatcher(tm))
+	api.Stream(obs)
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// the handshake example of RFC 6455
+	req := "GET /ws HTTP/1.1\r\nHost: focotimer\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := io.WriteString(conn, req); err != nil {
+		t.Fatalf("Handshake failed: %v", err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected handshake response %d %v", resp.StatusCode, resp.Header)
+	}
+
+	readWSEvent(t, r, focotimer.EventTick)
+	tm.Start()
+	readWSEvent(t, r, focotimer.EventStarted)
+
+	// a masked close frame from the client is answered with a close
+	if _, err := conn.Write([]byte{0x88, 0x80, 1, 2, 3, 4}); err != nil {
+		t.Fatalf("Failed to send close: %v", err)
+	}
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+		if f.op == wsClose {
+			break
+		}
+	}
+}
+
+func TestAPI_StreamErrors(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	obs := NewObserver(tm, time.Second)
+	defer obs.Close()
+	api := NewAPI(tm, focotimer.NewDispatcher(tm))
+	api.Stream(obs)
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a plain GET on /ws, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected /events to stream, got %q", ct)
+	}
+}
+
+func dialGRPC(t *testing.T, g *GRPC) pb.TimerClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 16)
+	g.Serve(ln)
+	t.Cleanup(func() { g.Close() })
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTimerClient(conn)
+}
+
+func TestAPI_Triggers(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	api := NewAPI(tm, focotimer.NewDispatcher(tm))
+	api.Triggers("s3cret", map[string]Profile{
+		"deep": {Duration: 50 * time.Minute, Label: "deep work", Issue: "GH-7"},
+	})
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	tests := []struct {
+		path  string
+		code  int
+		check func(v map[string]any) bool
+	}{
+		{"/trigger/deep", http.StatusUnauthorized, func(v map[string]any) bool { return v["error"] != nil }},
+		{"/trigger/deep?token=guess", http.StatusUnauthorized, func(v map[string]any) bool { return v["error"] != nil }},
+		{"/trigger/shallow?token=s3cret", http.StatusNotFound, func(v map[string]any) bool { return v["error"] != nil }},
+		{"/trigger/deep?token=s3cret", http.StatusOK, func(v map[string]any) bool {
+			return v["status"] == "running" && v["label"] == "deep work" && v["issue"] == "GH-7" && v["duration_ms"] == float64(50*60*1000)
+		}},
+		{"/trigger/deep?token=s3cret", http.StatusConflict, func(v map[string]any) bool { return v["error"] != nil }},
+	}
+	for _, tt := range tests {
+		code, v := doJSON(t, "POST", srv.URL+tt.path, "")
+		if code != tt.code {
+			t.Errorf("POST %s: expected status %d, got %d (%v)", tt.path, tt.code, code, v)
+			continue
+		}
+		if !tt.check(v) {
+			t.Errorf("POST %s: unexpected response %v", tt.path, v)
+		}
+	}
+
+	// a stopped session no longer stands in the way
+	tm.Stop()
+	req, _ := http.NewRequest("POST", srv.URL+"/trigger/deep", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /trigger/deep failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || tm.State().Status != focotimer.StatusRunning {
+		t.Errorf("Expected a bearer token to start the session, got %d %s", resp.StatusCode, tm.State().Status)
+	}
+}
+
+func TestAPI_TokenOverTCP(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	api := NewAPI(tm, focotimer.NewDispatcher(tm))
+	defer api.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	api.Serve(ln)
+	url := "http://" + ln.Addr().String()
+
+	if code, _ := doJSON(t, "POST", url+"/start", ""); code != http.StatusUnauthorized || tm.State().Status != focotimer.StatusIdle {
+		t.Errorf("Expected POST /start without a token refused, got %d %s", code, tm.State().Status)
+	}
+	if code, _ := doJSON(t, "GET", url+"/status", ""); code != http.StatusOK {
+		t.Errorf("Expected GET /status served without a token, got %d", code)
+	}
+	api.RequireToken("s3cret")
+	if code, _ := doJSON(t, "POST", url+"/command?token=guess", `{"command": "start"}`); code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong token refused, got %d", code)
+	}
+	if code, v := doJSON(t, "POST", url+"/start?token=s3cret", ""); code != http.StatusOK || v["status"] != "running" {
+		t.Errorf("Expected the token to start the session, got %d %v", code, v)
+	}
+
+	// a unix socket is the user's own
+	sock := filepath.Join(t.TempDir(), "ctl.sock")
+	if err := api.ListenUnix(sock); err != nil {
+		t.Fatalf("ListenUnix failed: %v", err)
+	}
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+	}}}
+	resp, err := client.Post("http://focotimer/pause", "", nil)
+	if err != nil {
+		t.Fatalf("POST /pause failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || tm.State().Status != focotimer.StatusPaused {
+		t.Errorf("Expected the unix socket to pause without a token, got %d %s", resp.StatusCode, tm.State().Status)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	d := focotimer.NewDispatcher(tm)
+	if err := d.Define("coffee", []string{"set 5m", "label coffee"}); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	g := NewGRPC(tm, d)
+	g.RequireToken("s3cret")
+	client := dialGRPC(t, g)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	empty := &emptypb.Empty{}
+
+	if _, err := client.Start(ctx, empty); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Start without the token to be refused, got %v", err)
+	}
+	wrong := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer guess")
+	if _, err := client.Command(wrong, &pb.CommandRequest{Command: "start"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a command with the wrong token to be refused, got %v", err)
+	}
+	s, err := client.GetState(ctx, empty)
+	if err != nil || s.GetStatus() != pb.Status_STATUS_IDLE {
+		t.Errorf("Expected idle state, got %v (%v)", s, err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	s, err = client.SetDuration(ctx, &pb.SetDurationRequest{Duration: durationpb.New(25 * time.Minute)})
+	if err != nil || s.GetDuration().AsDuration() != 25*time.Minute {
+		t.Errorf("Expected duration 25m, got %v (%v)", s, err)
+	}
+	s, err = client.SetLabel(ctx, &pb.SetLabelRequest{Label: "writing"})
+	if err != nil || s.GetLabel() != "writing" {
+		t.Errorf("Expected label writing, got %v (%v)", s, err)
+	}
+	s, err = client.Start(ctx, empty)
+	if err != nil || s.GetStatus() != pb.Status_STATUS_RUNNING || s.GetDeadline() == nil {
+		t.Errorf("Expected running state with a deadline, got %v (%v)", s, err)
+	}
+	s, err = client.Pause(ctx, empty)
+	if err != nil || s.GetStatus() != pb.Status_STATUS_PAUSED {
+		t.Errorf("Expected paused state, got %v (%v)", s, err)
+	}
+	s, err = client.Command(ctx, &pb.CommandRequest{Command: "coffee"})
+	if err != nil || s.GetLabel() != "coffee" {
+		t.Errorf("Expected label coffee, got %v (%v)", s, err)
+	}
+
+	errTests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"zero duration", func() error {
+			_, err := client.SetDuration(ctx, &pb.SetDurationRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"empty command", func() error {
+			_, err := client.Command(ctx, &pb.CommandRequest{Command: " "})
+			return err
+		}, codes.InvalidArgument},
+		{"bad arguments", func() error {
+			_, err := client.Command(ctx, &pb.CommandRequest{Command: "set"})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown command", func() error {
+			_, err := client.Command(ctx, &pb.CommandRequest{Command: "launch"})
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range errTests {
+		if code := status.Code(tt.call()); code != tt.code {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.code, code)
+		}
+	}
+}
+
+func TestGRPC_Watch(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	client := dialGRPC(t, NewGRPC(tm, focotimer.NewDispatcher(tm)))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pb.WatchRequest{TickInterval: durationpb.New(50 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	// the stream is live once the first tick arrives
+	if ev, err := stream.Recv(); err != nil || ev.GetKind() != pb.EventKind_EVENT_KIND_TICK {
+		t.Fatalf("Expected a tick event, got %v (%v)", ev, err)
+	}
+
+	tm.SetLabel("deep work")
+	tm.Start()
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if ev.GetKind() != pb.EventKind_EVENT_KIND_STARTED {
+			continue
+		}
+		if ev.GetLabel() != "deep work" || ev.GetDuration().AsDuration() != 5*time.Second {
+			t.Errorf("Unexpected started event %v", ev)
+		}
+		break
+	}
+
+	cancel()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if status.Code(err) != codes.Canceled {
+				t.Errorf("Expected the stream to end with Canceled, got %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestStatusFile(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	path := filepath.Join(t.TempDir(), "run", "status.json")
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		NewStatusFile(tm, path, 50*time.Millisecond).Run(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	read := func() focotimer.State {
+		var s focotimer.State
+		if data, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(data, &s)
+		}
+		return s
+	}
+	waitFor := func(what string, ok func(focotimer.State) bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !ok(read()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the status file to show %s, got %+v", what, read())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("an idle timer", func(s focotimer.State) bool { return s.Status == focotimer.StatusIdle })
+	tm.SetLabel("writing")
+	waitFor("the new label", func(s focotimer.State) bool { return s.Label == "writing" })
+	tm.Start()
+	waitFor("a running timer", func(s focotimer.State) bool { return s.Status == focotimer.StatusRunning })
+	first := read().Remaining
+	waitFor("the countdown", func(s focotimer.State) bool { return s.Remaining < first })
+}
+
+func TestMQTT(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	m := NewMQTT(tm, focotimer.NewDispatcher(tm), MQTTOptions{
+		Broker:   ln.Addr().String(),
+		Username: "home",
+		Topics:   MQTTTopics{Command: "desk/timer/set"},
+		TickRate: 50 * time.Millisecond,
+	})
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		m.Run(stop)
+		close(done)
+	}()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+
+	header, body, err := readMQTT(r)
+	if err != nil || header != mqttConnect {
+		t.Fatalf("Expected CONNECT, got %#x %v", header, err)
+	}
+	if !strings.Contains(string(body), "focotimer/availability") || !strings.Contains(string(body), "home") {
+		t.Errorf("Expected the will and user name in CONNECT, got %q", body)
+	}
+	writeMQTT(conn, mqttConnAck, []byte{0, 0})
+	header, body, err = readMQTT(r)
+	if err != nil || header != mqttSubscribe || !strings.Contains(string(body), "desk/timer/set") {
+		t.Fatalf("Expected SUBSCRIBE to the command topic, got %#x %q %v", header, body, err)
+	}
+	writeMQTT(conn, mqttSubAck, []byte{0, 1, 0})
+
+	// waitFor reads publishes until topic gets payload.
+	waitFor := func(topic, payload string) {
+		t.Helper()
+		for {
+			header, body, err := readMQTT(r)
+			if err != nil {
+				t.Fatalf("Expected %s on %s, got %v", payload, topic, err)
+			}
+			if header>>4 != mqttPublish>>4 {
+				continue
+			}
+			got, data, err := parsePublish(header, body)
+			if err != nil {
+				t.Fatalf("Bad publish: %v", err)
+			}
+			if got == topic && string(data) == payload {
+				return
+			}
+		}
+	}
+	waitFor("focotimer/availability", "online")
+	waitFor("focotimer/status", "idle")
+	waitFor("focotimer/remaining", "5")
+
+	writeMQTT(conn, mqttPublish, append(appendMQTTString(nil, "desk/timer/set"), "start"...))
+	waitFor("focotimer/status", "running")
+	waitFor("focotimer/remaining", "4")
+
+	close(stop)
+	waitFor("focotimer/availability", "offline")
+	<-done
+	if tm.State().Status != focotimer.StatusRunning {
+		t.Errorf("Expected the command to start the timer, got %s", tm.State().Status)
+	}
+}
+
+func TestMQTT_Discovery(t *testing.T) {
+	tm := focotimer.NewTimerManager(5 * time.Second)
+	m := NewMQTT(tm, focotimer.NewDispatcher(tm), MQTTOptions{ClientID: "focotimer-my.desk"})
+	if msgs := m.discovery(); msgs != nil {
+		t.Errorf("Expected no discovery without a prefix, got %d messages", len(msgs))
+	}
+
+	m = NewMQTT(tm, focotimer.NewDispatcher(tm), MQTTOptions{ClientID: "focotimer-my.desk", Discovery: "homeassistant"})
+	configs := make(map[string]map[string]any)
+	for _, msg := range m.discovery() {
+		var c map[string]any
+		if err := json.Unmarshal(msg.payload, &c); err != nil {
+			t.Fatalf("Bad discovery payload for %s: %v", msg.topic, err)
+		}
+		configs[msg.topic] = c
+	}
+
+	remaining := configs["homeassistant/sensor/focotimer-my_desk/remaining/config"]
+	if remaining == nil || remaining["state_topic"] != "focotimer/remaining" || remaining["availability_topic"] != "focotimer/availability" {
+		t.Errorf("Expected a remaining sensor on the remaining topic, got %v", remaining)
+	}
+	if phase := configs["homeassistant/sensor/focotimer-my_desk/phase/config"]; phase == nil || phase["state_topic"] != "focotimer/state" {
+		t.Errorf("Expected a phase sensor reading the state, got %v", phase)
+	}
+	for _, cmd := range []string{"start", "pause", "stop"} {
+		b := configs["homeassistant/button/focotimer-my_desk/"+cmd+"/config"]
+		if b == nil || b["command_topic"] != "focotimer/command" || b["payload_press"] != cmd {
+			t.Errorf("Expected a %s button sending %q, got %v", cmd, cmd, b)
+		}
+	}
+}
@@ -0,0 +1,775 @@
+// Timer service of a running focotimer, for programmatic clients in any
+// language. Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: focotimer.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_IDLE        Status = 1
+	Status_STATUS_RUNNING     Status = 2
+	Status_STATUS_PAUSED      Status = 3
+	Status_STATUS_COMPLETED   Status = 4
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_IDLE",
+		2: "STATUS_RUNNING",
+		3: "STATUS_PAUSED",
+		4: "STATUS_COMPLETED",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_IDLE":        1,
+		"STATUS_RUNNING":     2,
+		"STATUS_PAUSED":      3,
+		"STATUS_COMPLETED":   4,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_focotimer_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_focotimer_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{0}
+}
+
+type EventKind int32
+
+const (
+	EventKind_EVENT_KIND_UNSPECIFIED EventKind = 0
+	EventKind_EVENT_KIND_STARTED     EventKind = 1
+	EventKind_EVENT_KIND_PAUSED      EventKind = 2
+	EventKind_EVENT_KIND_RESUMED     EventKind = 3
+	EventKind_EVENT_KIND_STOPPED     EventKind = 4
+	EventKind_EVENT_KIND_COMPLETED   EventKind = 5
+	EventKind_EVENT_KIND_RESET       EventKind = 6
+	EventKind_EVENT_KIND_WARNING     EventKind = 7
+	EventKind_EVENT_KIND_AUTO_RESET  EventKind = 8
+	EventKind_EVENT_KIND_TICK        EventKind = 9
+	EventKind_EVENT_KIND_PHASE       EventKind = 10
+	EventKind_EVENT_KIND_EXTENDED    EventKind = 11
+	EventKind_EVENT_KIND_RATED       EventKind = 12
+	EventKind_EVENT_KIND_REMINDER    EventKind = 13
+)
+
+// Enum value maps for EventKind.
+var (
+	EventKind_name = map[int32]string{
+		0:  "EVENT_KIND_UNSPECIFIED",
+		1:  "EVENT_KIND_STARTED",
+		2:  "EVENT_KIND_PAUSED",
+		3:  "EVENT_KIND_RESUMED",
+		4:  "EVENT_KIND_STOPPED",
+		5:  "EVENT_KIND_COMPLETED",
+		6:  "EVENT_KIND_RESET",
+		7:  "EVENT_KIND_WARNING",
+		8:  "EVENT_KIND_AUTO_RESET",
+		9:  "EVENT_KIND_TICK",
+		10: "EVENT_KIND_PHASE",
+		11: "EVENT_KIND_EXTENDED",
+		12: "EVENT_KIND_RATED",
+		13: "EVENT_KIND_REMINDER",
+	}
+	EventKind_value = map[string]int32{
+		"EVENT_KIND_UNSPECIFIED": 0,
+		"EVENT_KIND_STARTED":     1,
+		"EVENT_KIND_PAUSED":      2,
+		"EVENT_KIND_RESUMED":     3,
+		"EVENT_KIND_STOPPED":     4,
+		"EVENT_KIND_COMPLETED":   5,
+		"EVENT_KIND_RESET":       6,
+		"EVENT_KIND_WARNING":     7,
+		"EVENT_KIND_AUTO_RESET":  8,
+		"EVENT_KIND_TICK":        9,
+		"EVENT_KIND_PHASE":       10,
+		"EVENT_KIND_EXTENDED":    11,
+		"EVENT_KIND_RATED":       12,
+		"EVENT_KIND_REMINDER":    13,
+	}
+)
+
+func (x EventKind) Enum() *EventKind {
+	p := new(EventKind)
+	*p = x
+	return p
+}
+
+func (x EventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_focotimer_proto_enumTypes[1].Descriptor()
+}
+
+func (EventKind) Type() protoreflect.EnumType {
+	return &file_focotimer_proto_enumTypes[1]
+}
+
+func (x EventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventKind.Descriptor instead.
+func (EventKind) EnumDescriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{1}
+}
+
+type State struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Status    Status                 `protobuf:"varint,1,opt,name=status,proto3,enum=focotimer.v1.Status" json:"status,omitempty"`
+	Duration  *durationpb.Duration   `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	Remaining *durationpb.Duration   `protobuf:"bytes,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Elapsed   *durationpb.Duration   `protobuf:"bytes,4,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
+	// Unset while idle.
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// Unset unless running.
+	Deadline *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Label    string                 `protobuf:"bytes,7,opt,name=label,proto3" json:"label,omitempty"`
+	Phase    string                 `protobuf:"bytes,8,opt,name=phase,proto3" json:"phase,omitempty"`
+	Issue    string                 `protobuf:"bytes,9,opt,name=issue,proto3" json:"issue,omitempty"`
+	// Both zero without a session plan.
+	QueuePosition int32 `protobuf:"varint,10,opt,name=queue_position,json=queuePosition,proto3" json:"queue_position,omitempty"`
+	QueueLength   int32 `protobuf:"varint,11,opt,name=queue_length,json=queueLength,proto3" json:"queue_length,omitempty"`
+	// Energy ratings from 1 to 5; zero when unrated.
+	EnergyStart   int32 `protobuf:"varint,12,opt,name=energy_start,json=energyStart,proto3" json:"energy_start,omitempty"`
+	EnergyEnd     int32 `protobuf:"varint,13,opt,name=energy_end,json=energyEnd,proto3" json:"energy_end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_focotimer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *State) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *State) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *State) GetRemaining() *durationpb.Duration {
+	if x != nil {
+		return x.Remaining
+	}
+	return nil
+}
+
+func (x *State) GetElapsed() *durationpb.Duration {
+	if x != nil {
+		return x.Elapsed
+	}
+	return nil
+}
+
+func (x *State) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *State) GetDeadline() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Deadline
+	}
+	return nil
+}
+
+func (x *State) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *State) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *State) GetIssue() string {
+	if x != nil {
+		return x.Issue
+	}
+	return ""
+}
+
+func (x *State) GetQueuePosition() int32 {
+	if x != nil {
+		return x.QueuePosition
+	}
+	return 0
+}
+
+func (x *State) GetQueueLength() int32 {
+	if x != nil {
+		return x.QueueLength
+	}
+	return 0
+}
+
+func (x *State) GetEnergyStart() int32 {
+	if x != nil {
+		return x.EnergyStart
+	}
+	return 0
+}
+
+func (x *State) GetEnergyEnd() int32 {
+	if x != nil {
+		return x.EnergyEnd
+	}
+	return 0
+}
+
+type SetDurationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDurationRequest) Reset() {
+	*x = SetDurationRequest{}
+	mi := &file_focotimer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDurationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDurationRequest) ProtoMessage() {}
+
+func (x *SetDurationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDurationRequest.ProtoReflect.Descriptor instead.
+func (*SetDurationRequest) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetDurationRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+type SetLabelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLabelRequest) Reset() {
+	*x = SetLabelRequest{}
+	mi := &file_focotimer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLabelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLabelRequest) ProtoMessage() {}
+
+func (x *SetLabelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLabelRequest.ProtoReflect.Descriptor instead.
+func (*SetLabelRequest) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SetLabelRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+type CommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_focotimer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TickInterval  *durationpb.Duration   `protobuf:"bytes,1,opt,name=tick_interval,json=tickInterval,proto3" json:"tick_interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_focotimer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchRequest) GetTickInterval() *durationpb.Duration {
+	if x != nil {
+		return x.TickInterval
+	}
+	return nil
+}
+
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Kind        EventKind              `protobuf:"varint,1,opt,name=kind,proto3,enum=focotimer.v1.EventKind" json:"kind,omitempty"`
+	At          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Duration    *durationpb.Duration   `protobuf:"bytes,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	Remaining   *durationpb.Duration   `protobuf:"bytes,4,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Label       string                 `protobuf:"bytes,5,opt,name=label,proto3" json:"label,omitempty"`
+	Phase       string                 `protobuf:"bytes,6,opt,name=phase,proto3" json:"phase,omitempty"`
+	Issue       string                 `protobuf:"bytes,7,opt,name=issue,proto3" json:"issue,omitempty"`
+	EnergyStart int32                  `protobuf:"varint,8,opt,name=energy_start,json=energyStart,proto3" json:"energy_start,omitempty"`
+	EnergyEnd   int32                  `protobuf:"varint,9,opt,name=energy_end,json=energyEnd,proto3" json:"energy_end,omitempty"`
+	// The reminder point reached, e.g. "50%" or "5m0s"; set on
+	// EVENT_KIND_REMINDER.
+	Reminder      string `protobuf:"bytes,10,opt,name=reminder,proto3" json:"reminder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_focotimer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_focotimer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_focotimer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Event) GetKind() EventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return EventKind_EVENT_KIND_UNSPECIFIED
+}
+
+func (x *Event) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *Event) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *Event) GetRemaining() *durationpb.Duration {
+	if x != nil {
+		return x.Remaining
+	}
+	return nil
+}
+
+func (x *Event) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Event) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *Event) GetIssue() string {
+	if x != nil {
+		return x.Issue
+	}
+	return ""
+}
+
+func (x *Event) GetEnergyStart() int32 {
+	if x != nil {
+		return x.EnergyStart
+	}
+	return 0
+}
+
+func (x *Event) GetEnergyEnd() int32 {
+	if x != nil {
+		return x.EnergyEnd
+	}
+	return 0
+}
+
+func (x *Event) GetReminder() string {
+	if x != nil {
+		return x.Reminder
+	}
+	return ""
+}
+
+var File_focotimer_proto protoreflect.FileDescriptor
+
+const file_focotimer_proto_rawDesc = "" +
+	"\n" +
+	"\x0ffocotimer.proto\x12\ffocotimer.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x04\n" +
+	"\x05State\x12,\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x14.focotimer.v1.StatusR\x06status\x125\n" +
+	"\bduration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bduration\x127\n" +
+	"\tremaining\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\tremaining\x123\n" +
+	"\aelapsed\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\aelapsed\x129\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x126\n" +
+	"\bdeadline\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bdeadline\x12\x14\n" +
+	"\x05label\x18\a \x01(\tR\x05label\x12\x14\n" +
+	"\x05phase\x18\b \x01(\tR\x05phase\x12\x14\n" +
+	"\x05issue\x18\t \x01(\tR\x05issue\x12%\n" +
+	"\x0equeue_position\x18\n" +
+	" \x01(\x05R\rqueuePosition\x12!\n" +
+	"\fqueue_length\x18\v \x01(\x05R\vqueueLength\x12!\n" +
+	"\fenergy_start\x18\f \x01(\x05R\venergyStart\x12\x1d\n" +
+	"\n" +
+	"energy_end\x18\r \x01(\x05R\tenergyEnd\"K\n" +
+	"\x12SetDurationRequest\x125\n" +
+	"\bduration\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\bduration\"'\n" +
+	"\x0fSetLabelRequest\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\"*\n" +
+	"\x0eCommandRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\"N\n" +
+	"\fWatchRequest\x12>\n" +
+	"\rtick_interval\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\ftickInterval\"\xf0\x02\n" +
+	"\x05Event\x12+\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x17.focotimer.v1.EventKindR\x04kind\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x125\n" +
+	"\bduration\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bduration\x127\n" +
+	"\tremaining\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\tremaining\x12\x14\n" +
+	"\x05label\x18\x05 \x01(\tR\x05label\x12\x14\n" +
+	"\x05phase\x18\x06 \x01(\tR\x05phase\x12\x14\n" +
+	"\x05issue\x18\a \x01(\tR\x05issue\x12!\n" +
+	"\fenergy_start\x18\b \x01(\x05R\venergyStart\x12\x1d\n" +
+	"\n" +
+	"energy_end\x18\t \x01(\x05R\tenergyEnd\x12\x1a\n" +
+	"\breminder\x18\n" +
+	" \x01(\tR\breminder*n\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vSTATUS_IDLE\x10\x01\x12\x12\n" +
+	"\x0eSTATUS_RUNNING\x10\x02\x12\x11\n" +
+	"\rSTATUS_PAUSED\x10\x03\x12\x14\n" +
+	"\x10STATUS_COMPLETED\x10\x04*\xdc\x02\n" +
+	"\tEventKind\x12\x1a\n" +
+	"\x16EVENT_KIND_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EVENT_KIND_STARTED\x10\x01\x12\x15\n" +
+	"\x11EVENT_KIND_PAUSED\x10\x02\x12\x16\n" +
+	"\x12EVENT_KIND_RESUMED\x10\x03\x12\x16\n" +
+	"\x12EVENT_KIND_STOPPED\x10\x04\x12\x18\n" +
+	"\x14EVENT_KIND_COMPLETED\x10\x05\x12\x14\n" +
+	"\x10EVENT_KIND_RESET\x10\x06\x12\x16\n" +
+	"\x12EVENT_KIND_WARNING\x10\a\x12\x19\n" +
+	"\x15EVENT_KIND_AUTO_RESET\x10\b\x12\x13\n" +
+	"\x0fEVENT_KIND_TICK\x10\t\x12\x14\n" +
+	"\x10EVENT_KIND_PHASE\x10\n" +
+	"\x12\x17\n" +
+	"\x13EVENT_KIND_EXTENDED\x10\v\x12\x14\n" +
+	"\x10EVENT_KIND_RATED\x10\f\x12\x17\n" +
+	"\x13EVENT_KIND_REMINDER\x10\r2\x85\x05\n" +
+	"\x05Timer\x127\n" +
+	"\bGetState\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x124\n" +
+	"\x05Start\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x124\n" +
+	"\x05Pause\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x125\n" +
+	"\x06Resume\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x125\n" +
+	"\x06Toggle\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x123\n" +
+	"\x04Stop\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x124\n" +
+	"\x05Reset\x12\x16.google.protobuf.Empty\x1a\x13.focotimer.v1.State\x12D\n" +
+	"\vSetDuration\x12 .focotimer.v1.SetDurationRequest\x1a\x13.focotimer.v1.State\x12>\n" +
+	"\bSetLabel\x12\x1d.focotimer.v1.SetLabelRequest\x1a\x13.focotimer.v1.State\x12<\n" +
+	"\aCommand\x12\x1c.focotimer.v1.CommandRequest\x1a\x13.focotimer.v1.State\x12:\n" +
+	"\x05Watch\x12\x1a.focotimer.v1.WatchRequest\x1a\x13.focotimer.v1.Event0\x01B%Z#github.com/d093w1z/focotimer/ipc/pbb\x06proto3"
+
+var (
+	file_focotimer_proto_rawDescOnce sync.Once
+	file_focotimer_proto_rawDescData []byte
+)
+
+func file_focotimer_proto_rawDescGZIP() []byte {
+	file_focotimer_proto_rawDescOnce.Do(func() {
+		file_focotimer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_focotimer_proto_rawDesc), len(file_focotimer_proto_rawDesc)))
+	})
+	return file_focotimer_proto_rawDescData
+}
+
+var file_focotimer_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_focotimer_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_focotimer_proto_goTypes = []any{
+	(Status)(0),                   // 0: focotimer.v1.Status
+	(EventKind)(0),                // 1: focotimer.v1.EventKind
+	(*State)(nil),                 // 2: focotimer.v1.State
+	(*SetDurationRequest)(nil),    // 3: focotimer.v1.SetDurationRequest
+	(*SetLabelRequest)(nil),       // 4: focotimer.v1.SetLabelRequest
+	(*CommandRequest)(nil),        // 5: focotimer.v1.CommandRequest
+	(*WatchRequest)(nil),          // 6: focotimer.v1.WatchRequest
+	(*Event)(nil),                 // 7: focotimer.v1.Event
+	(*durationpb.Duration)(nil),   // 8: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 10: google.protobuf.Empty
+}
+var file_focotimer_proto_depIdxs = []int32{
+	0,  // 0: focotimer.v1.State.status:type_name -> focotimer.v1.Status
+	8,  // 1: focotimer.v1.State.duration:type_name -> google.protobuf.Duration
+	8,  // 2: focotimer.v1.State.remaining:type_name -> google.protobuf.Duration
+	8,  // 3: focotimer.v1.State.elapsed:type_name -> google.protobuf.Duration
+	9,  // 4: focotimer.v1.State.started_at:type_name -> google.protobuf.Timestamp
+	9,  // 5: focotimer.v1.State.deadline:type_name -> google.protobuf.Timestamp
+	8,  // 6: focotimer.v1.SetDurationRequest.duration:type_name -> google.protobuf.Duration
+	8,  // 7: focotimer.v1.WatchRequest.tick_interval:type_name -> google.protobuf.Duration
+	1,  // 8: focotimer.v1.Event.kind:type_name -> focotimer.v1.EventKind
+	9,  // 9: focotimer.v1.Event.at:type_name -> google.protobuf.Timestamp
+	8,  // 10: focotimer.v1.Event.duration:type_name -> google.protobuf.Duration
+	8,  // 11: focotimer.v1.Event.remaining:type_name -> google.protobuf.Duration
+	10, // 12: focotimer.v1.Timer.GetState:input_type -> google.protobuf.Empty
+	10, // 13: focotimer.v1.Timer.Start:input_type -> google.protobuf.Empty
+	10, // 14: focotimer.v1.Timer.Pause:input_type -> google.protobuf.Empty
+	10, // 15: focotimer.v1.Timer.Resume:input_type -> google.protobuf.Empty
+	10, // 16: focotimer.v1.Timer.Toggle:input_type -> google.protobuf.Empty
+	10, // 17: focotimer.v1.Timer.Stop:input_type -> google.protobuf.Empty
+	10, // 18: focotimer.v1.Timer.Reset:input_type -> google.protobuf.Empty
+	3,  // 19: focotimer.v1.Timer.SetDuration:input_type -> focotimer.v1.SetDurationRequest
+	4,  // 20: focotimer.v1.Timer.SetLabel:input_type -> focotimer.v1.SetLabelRequest
+	5,  // 21: focotimer.v1.Timer.Command:input_type -> focotimer.v1.CommandRequest
+	6,  // 22: focotimer.v1.Timer.Watch:input_type -> focotimer.v1.WatchRequest
+	2,  // 23: focotimer.v1.Timer.GetState:output_type -> focotimer.v1.State
+	2,  // 24: focotimer.v1.Timer.Start:output_type -> focotimer.v1.State
+	2,  // 25: focotimer.v1.Timer.Pause:output_type -> focotimer.v1.State
+	2,  // 26: focotimer.v1.Timer.Resume:output_type -> focotimer.v1.State
+	2,  // 27: focotimer.v1.Timer.Toggle:output_type -> focotimer.v1.State
+	2,  // 28: focotimer.v1.Timer.Stop:output_type -> focotimer.v1.State
+	2,  // 29: focotimer.v1.Timer.Reset:output_type -> focotimer.v1.State
+	2,  // 30: focotimer.v1.Timer.SetDuration:output_type -> focotimer.v1.State
+	2,  // 31: focotimer.v1.Timer.SetLabel:output_type -> focotimer.v1.State
+	2,  // 32: focotimer.v1.Timer.Command:output_type -> focotimer.v1.State
+	7,  // 33: focotimer.v1.Timer.Watch:output_type -> focotimer.v1.Event
+	23, // [23:34] is the sub-list for method output_type
+	12, // [12:23] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_focotimer_proto_init() }
+func file_focotimer_proto_init() {
+	if File_focotimer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_focotimer_proto_rawDesc), len(file_focotimer_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_focotimer_proto_goTypes,
+		DependencyIndexes: file_focotimer_proto_depIdxs,
+		EnumInfos:         file_focotimer_proto_enumTypes,
+		MessageInfos:      file_focotimer_proto_msgTypes,
+	}.Build()
+	File_focotimer_proto = out.File
+	file_focotimer_proto_goTypes = nil
+	file_focotimer_proto_depIdxs = nil
+}
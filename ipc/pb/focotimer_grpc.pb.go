@@ -0,0 +1,529 @@
+// Timer service of a running focotimer, for programmatic clients in any
+// language. Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: focotimer.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Timer_GetState_FullMethodName    = "/focotimer.v1.Timer/GetState"
+	Timer_Start_FullMethodName       = "/focotimer.v1.Timer/Start"
+	Timer_Pause_FullMethodName       = "/focotimer.v1.Timer/Pause"
+	Timer_Resume_FullMethodName      = "/focotimer.v1.Timer/Resume"
+	Timer_Toggle_FullMethodName      = "/focotimer.v1.Timer/Toggle"
+	Timer_Stop_FullMethodName        = "/focotimer.v1.Timer/Stop"
+	Timer_Reset_FullMethodName       = "/focotimer.v1.Timer/Reset"
+	Timer_SetDuration_FullMethodName = "/focotimer.v1.Timer/SetDuration"
+	Timer_SetLabel_FullMethodName    = "/focotimer.v1.Timer/SetLabel"
+	Timer_Command_FullMethodName     = "/focotimer.v1.Timer/Command"
+	Timer_Watch_FullMethodName       = "/focotimer.v1.Timer/Watch"
+)
+
+// TimerClient is the client API for Timer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Over TCP, calls other than GetState and Watch must carry the triggers
+// token as "authorization: Bearer <token>" metadata, or fail with
+// UNAUTHENTICATED.
+type TimerClient interface {
+	// GetState samples the timer.
+	GetState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Start(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Pause(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Resume(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Toggle(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Stop(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	Reset(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error)
+	SetDuration(ctx context.Context, in *SetDurationRequest, opts ...grpc.CallOption) (*State, error)
+	SetLabel(ctx context.Context, in *SetLabelRequest, opts ...grpc.CallOption) (*State, error)
+	// Command runs a dispatcher command line such as "set 5m" or
+	// "queue add 4x25m report", including aliases and custom commands.
+	// Unknown commands fail with NOT_FOUND.
+	Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*State, error)
+	// Watch streams lifecycle events, plus tick events every tick_interval
+	// if it is set, until the client cancels.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type timerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTimerClient(cc grpc.ClientConnInterface) TimerClient {
+	return &timerClient{cc}
+}
+
+func (c *timerClient) GetState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Start(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Start_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Pause(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Resume(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Resume_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Toggle(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Toggle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Stop(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Reset(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Reset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) SetDuration(ctx context.Context, in *SetDurationRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_SetDuration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) SetLabel(ctx context.Context, in *SetLabelRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_SetLabel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Timer_Command_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timerClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Timer_ServiceDesc.Streams[0], Timer_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Timer_WatchClient = grpc.ServerStreamingClient[Event]
+
+// TimerServer is the server API for Timer service.
+// All implementations must embed UnimplementedTimerServer
+// for forward compatibility.
+//
+// Over TCP, calls other than GetState and Watch must carry the triggers
+// token as "authorization: Bearer <token>" metadata, or fail with
+// UNAUTHENTICATED.
+type TimerServer interface {
+	// GetState samples the timer.
+	GetState(context.Context, *emptypb.Empty) (*State, error)
+	Start(context.Context, *emptypb.Empty) (*State, error)
+	Pause(context.Context, *emptypb.Empty) (*State, error)
+	Resume(context.Context, *emptypb.Empty) (*State, error)
+	Toggle(context.Context, *emptypb.Empty) (*State, error)
+	Stop(context.Context, *emptypb.Empty) (*State, error)
+	Reset(context.Context, *emptypb.Empty) (*State, error)
+	SetDuration(context.Context, *SetDurationRequest) (*State, error)
+	SetLabel(context.Context, *SetLabelRequest) (*State, error)
+	// Command runs a dispatcher command line such as "set 5m" or
+	// "queue add 4x25m report", including aliases and custom commands.
+	// Unknown commands fail with NOT_FOUND.
+	Command(context.Context, *CommandRequest) (*State, error)
+	// Watch streams lifecycle events, plus tick events every tick_interval
+	// if it is set, until the client cancels.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedTimerServer()
+}
+
+// UnimplementedTimerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTimerServer struct{}
+
+func (UnimplementedTimerServer) GetState(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedTimerServer) Start(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedTimerServer) Pause(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedTimerServer) Resume(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedTimerServer) Toggle(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Toggle not implemented")
+}
+func (UnimplementedTimerServer) Stop(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedTimerServer) Reset(context.Context, *emptypb.Empty) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Reset not implemented")
+}
+func (UnimplementedTimerServer) SetDuration(context.Context, *SetDurationRequest) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetDuration not implemented")
+}
+func (UnimplementedTimerServer) SetLabel(context.Context, *SetLabelRequest) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetLabel not implemented")
+}
+func (UnimplementedTimerServer) Command(context.Context, *CommandRequest) (*State, error) {
+	return nil, status.Error(codes.Unimplemented, "method Command not implemented")
+}
+func (UnimplementedTimerServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedTimerServer) mustEmbedUnimplementedTimerServer() {}
+func (UnimplementedTimerServer) testEmbeddedByValue()               {}
+
+// UnsafeTimerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TimerServer will
+// result in compilation errors.
+type UnsafeTimerServer interface {
+	mustEmbedUnimplementedTimerServer()
+}
+
+func RegisterTimerServer(s grpc.ServiceRegistrar, srv TimerServer) {
+	// If the following call panics, it indicates UnimplementedTimerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Timer_ServiceDesc, srv)
+}
+
+func _Timer_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).GetState(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Start_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Start(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Pause(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Resume(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Toggle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Toggle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Toggle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Toggle(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Stop(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Reset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Reset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Reset(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_SetDuration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDurationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).SetDuration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_SetDuration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).SetDuration(ctx, req.(*SetDurationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_SetLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).SetLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_SetLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).SetLabel(ctx, req.(*SetLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Command_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimerServer).Command(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timer_Command_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimerServer).Command(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timer_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TimerServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Timer_WatchServer = grpc.ServerStreamingServer[Event]
+
+// Timer_ServiceDesc is the grpc.ServiceDesc for Timer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Timer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "focotimer.v1.Timer",
+	HandlerType: (*TimerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _Timer_GetState_Handler,
+		},
+		{
+			MethodName: "Start",
+			Handler:    _Timer_Start_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _Timer_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _Timer_Resume_Handler,
+		},
+		{
+			MethodName: "Toggle",
+			Handler:    _Timer_Toggle_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Timer_Stop_Handler,
+		},
+		{
+			MethodName: "Reset",
+			Handler:    _Timer_Reset_Handler,
+		},
+		{
+			MethodName: "SetDuration",
+			Handler:    _Timer_SetDuration_Handler,
+		},
+		{
+			MethodName: "SetLabel",
+			Handler:    _Timer_SetLabel_Handler,
+		},
+		{
+			MethodName: "Command",
+			Handler:    _Timer_Command_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Timer_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "focotimer.proto",
+}
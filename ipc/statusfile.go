@@ -0,0 +1,69 @@
+package ipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// StatusFile keeps the State of a TimerManager as JSON in a file, for tools
+// that poll a file rather than connect, e.g. waybar or conky. The file is
+// rewritten on every event and every tick, through a temporary file so
+// readers never see a partial write.
+type StatusFile struct {
+	tm       *focotimer.TimerManager
+	path     string
+	tickRate time.Duration
+}
+
+func NewStatusFile(tm *focotimer.TimerManager, path string, tickRate time.Duration) *StatusFile {
+	if tickRate <= 0 {
+		tickRate = time.Second
+	}
+	return &StatusFile{tm: tm, path: path, tickRate: tickRate}
+}
+
+// Write stores the current state.
+func (f *StatusFile) Write() error {
+	data, err := json.Marshal(f.tm.State())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("status file: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("status file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("status file: %w", err)
+	}
+	return nil
+}
+
+// Run keeps the file current until stop is closed.
+func (f *StatusFile) Run(stop <-chan struct{}) {
+	events := f.tm.SubscribeEvents()
+	defer f.tm.UnsubscribeEvents(events)
+	ticks := f.tm.SubscribeEvery(f.tickRate, focotimer.WithBuffer(1), focotimer.WithDropPolicy(focotimer.DropOldest))
+	defer f.tm.Unsubscribe(ticks)
+
+	for {
+		if err := f.Write(); err != nil {
+			log.Printf("ipc.StatusFile: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-events:
+		case <-ticks:
+		}
+	}
+}
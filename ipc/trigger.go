@@ -0,0 +1,66 @@
+package ipc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	focotimer "github.com/d093w1z/focotimer/api"
+)
+
+// Profile is a predefined session that external systems may start.
+type Profile struct {
+	// Duration of the session; zero keeps the current duration.
+	Duration time.Duration
+	Label    string
+	// Issue is an issue reference reported back to the tracker.
+	Issue string
+}
+
+// Triggers serves POST /trigger/{profile}, a webhook with which calendar
+// automation or a CI job finishing a long build starts one of profiles.
+// Callers authenticate with token, as "Authorization: Bearer <token>" or
+// in the "token" query parameter for senders that only take a URL. A
+// session in progress is never replaced: the request answers 409 Conflict.
+// The same token is usually given to RequireToken.
+func (a *API) Triggers(token string, profiles map[string]Profile) {
+	a.mux.HandleFunc("POST /trigger/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		if !validToken(r, token) {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+		name := r.PathValue("profile")
+		p, ok := profiles[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown profile %q", name))
+			return
+		}
+		switch a.tm.State().Status {
+		case focotimer.StatusRunning, focotimer.StatusPaused:
+			writeError(w, http.StatusConflict, errors.New("a session is in progress"))
+			return
+		}
+		if p.Duration > 0 {
+			a.tm.SetDuration(p.Duration)
+		}
+		a.tm.SetLabel(p.Label)
+		a.tm.SetIssue(p.Issue)
+		a.tm.Start()
+		log.Printf("ipc.API: webhook started profile %q", name)
+		a.status(w, r)
+	})
+}
+
+// validToken reports whether r carries token, comparing in constant time.
+// An empty token accepts nothing.
+func validToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		got = r.URL.Query().Get("token")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
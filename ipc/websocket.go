@@ -0,0 +1,209 @@
+package ipc
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// --- WebSocket ---
+
+// websocketGUID is the fixed key suffix of the RFC 6455 handshake.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// ServeWebSocket upgrades the request to a WebSocket and sends every event
+// as a JSON text message until either side closes. Messages from the
+// client are ignored apart from close and ping frames.
+func (o *Observer) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || !headerHas(r.Header, "Connection", "upgrade") || !headerHas(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket unsupported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	ch := o.register()
+	defer o.unregister(ch)
+
+	// control frames from the reader go out through this loop, which owns
+	// the connection's writes
+	control := make(chan wsFrame, 1)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(closed)
+		readWebSocket(rw.Reader, control, done)
+	}()
+
+	write := func(f wsFrame) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+		if err := writeFrame(rw.Writer, f); err != nil {
+			return false
+		}
+		return rw.Flush() == nil
+	}
+	for {
+		select {
+		case <-o.stopping:
+			write(wsFrame{op: wsClose})
+			return
+		case <-closed:
+			return
+		case f := <-control:
+			if !write(f) || f.op == wsClose {
+				return
+			}
+		case data, ok := <-ch:
+			if !ok {
+				write(wsFrame{op: wsClose})
+				return
+			}
+			if !write(wsFrame{op: wsText, payload: data}) {
+				return
+			}
+		}
+	}
+}
+
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type wsFrame struct {
+	op      byte
+	payload []byte
+}
+
+// writeFrame writes one unfragmented, unmasked server frame.
+func writeFrame(w io.Writer, f wsFrame) error {
+	header := []byte{0x80 | f.op}
+	switch n := len(f.payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(f.payload)
+	return err
+}
+
+// maxClientFrame bounds what a client may send; this is a push-only
+// endpoint.
+const maxClientFrame = 1 << 16
+
+// readWebSocket consumes client frames, answering pings and closes through
+// control, until the connection fails or is closed or done is closed.
+func readWebSocket(r *bufio.Reader, control chan<- wsFrame, done <-chan struct{}) {
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		var reply wsFrame
+		switch f.op {
+		case wsPing:
+			reply = wsFrame{op: wsPong, payload: f.payload}
+		case wsClose:
+			reply = wsFrame{op: wsClose}
+		default:
+			continue
+		}
+		select {
+		case control <- reply:
+		case <-done:
+			return
+		}
+		if f.op == wsClose {
+			return
+		}
+	}
+}
+
+// readFrame reads one (masked) client frame.
+func readFrame(r *bufio.Reader) (wsFrame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return wsFrame{}, err
+	}
+	f := wsFrame{op: head[0] & 0x0F}
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxClientFrame {
+		return f, io.ErrShortBuffer
+	}
+	var mask [4]byte
+	masked := head[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, n)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, nil
+}
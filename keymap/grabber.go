@@ -0,0 +1,13 @@
+package keymap
+
+// ------------------- Global hotkeys -------------------
+
+// Grabber registers system-wide hotkeys with the desktop. XBindKeys grabs
+// them on Linux; elsewhere it refuses them and only the bindings of the
+// window apply.
+type Grabber interface {
+	// Grab replaces the active hotkeys. run is called with the command of
+	// each hotkey pressed.
+	Grab(bindings map[Chord]string, run func(cmd string)) error
+	Close() error
+}
@@ -0,0 +1,271 @@
+// Package keymap parses key chords such as "Ctrl+Shift+P" and validates the
+// window and global key bindings of the user configuration.
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mod is a set of modifier keys.
+type Mod uint8
+
+const (
+	Ctrl Mod = 1 << iota
+	Shift
+	Alt
+	Super
+)
+
+// modOrder is the canonical order of modifiers in a chord.
+var modOrder = []struct {
+	mod  Mod
+	name string
+}{
+	{Ctrl, "Ctrl"},
+	{Shift, "Shift"},
+	{Alt, "Alt"},
+	{Super, "Super"},
+}
+
+var modNames = map[string]Mod{
+	"ctrl":    Ctrl,
+	"control": Ctrl,
+	"shift":   Shift,
+	"alt":     Alt,
+	"super":   Super,
+	"mod4":    Super,
+	"win":     Super,
+	"cmd":     Super,
+}
+
+// keyNames maps the accepted spellings of named keys to their canonical
+// form. "+" separates the parts of a chord, so the + and - keys are
+// written Plus and Minus.
+var keyNames = map[string]string{
+	"space":     "Space",
+	"escape":    "Escape",
+	"esc":       "Escape",
+	"return":    "Return",
+	"enter":     "Return",
+	"tab":       "Tab",
+	"left":      "Left",
+	"right":     "Right",
+	"up":        "Up",
+	"down":      "Down",
+	"home":      "Home",
+	"end":       "End",
+	"pageup":    "PageUp",
+	"pagedown":  "PageDown",
+	"backspace": "Backspace",
+	"delete":    "Delete",
+	"plus":      "Plus",
+	"minus":     "Minus",
+	"+":         "Plus",
+	"-":         "Minus",
+}
+
+// Chord is a key together with the modifiers held down with it.
+type Chord struct {
+	Mods Mod
+	Key  string
+}
+
+// Escape cancels a key capture, so it cannot be captured, and is never a
+// global hotkey. In the window it runs CloseCommand unless bound to
+// another command.
+var Escape = Chord{Key: "Escape"}
+
+// CloseCommand closes the window. Escape runs it while no window binding
+// names it or Escape, so binding it to another key frees Escape.
+const CloseCommand = "close"
+
+// Parse reads a chord like "Ctrl+Shift+P", "space" or "Super+F5".
+// Modifier and key names are case-insensitive.
+func Parse(s string) (Chord, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	var c Chord
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return Chord{}, fmt.Errorf("invalid chord %q (write the + key as Plus)", s)
+		}
+		if i < len(parts)-1 {
+			mod, ok := modNames[strings.ToLower(part)]
+			if !ok {
+				return Chord{}, fmt.Errorf("invalid chord %q: unknown modifier %q", s, part)
+			}
+			c.Mods |= mod
+			continue
+		}
+		key, err := CanonicalKey(part)
+		if err != nil {
+			return Chord{}, fmt.Errorf("invalid chord %q: %w", s, err)
+		}
+		c.Key = key
+	}
+	return c, nil
+}
+
+// CanonicalKey returns the canonical name of a key: named keys as listed
+// in the package, F1 to F12, and single characters with letters in upper
+// case.
+func CanonicalKey(k string) (string, error) {
+	if name, ok := keyNames[strings.ToLower(k)]; ok {
+		return name, nil
+	}
+	if len(k) > 1 && (k[0] == 'F' || k[0] == 'f') {
+		if n, err := strconv.Atoi(k[1:]); err == nil && n >= 1 && n <= 12 {
+			return "F" + k[1:], nil
+		}
+	}
+	if utf8.RuneCountInString(k) == 1 {
+		return strings.ToUpper(k), nil
+	}
+	return "", fmt.Errorf("unknown key %q", k)
+}
+
+func (c Chord) String() string {
+	var parts []string
+	for _, m := range modOrder {
+		if c.Mods&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, c.Key), "+")
+}
+
+// ------------------- Keymap -------------------
+
+// Keymap is a validated set of bindings from chords to command lines, in
+// the window and system-wide.
+type Keymap struct {
+	GUI    map[Chord]string
+	Global map[Chord]string
+}
+
+// DefaultGUI are the window bindings used when the config has none.
+var DefaultGUI = map[string]string{
+	"Space":     "toggle",
+	"R":         "reset",
+	"Plus":      "inc",
+	"Up":        "inc",
+	"Right":     "inc",
+	"Minus":     "dec",
+	"Down":      "dec",
+	"Left":      "dec",
+	"B":         "skip-break",
+	"S":         "settings",
+	"T":         "timeline",
+	"I":         "insights",
+	"L":         "tasks",
+	"G":         "stats",
+	"Backspace": "back",
+}
+
+// New validates the bindings (chord -> command) and builds the keymap. Two
+// spellings of the same chord, unknown keys, a global Escape and chords
+// bound both globally and in the window are reported together.
+func New(gui, global map[string]string) (*Keymap, error) {
+	km := &Keymap{GUI: make(map[Chord]string), Global: make(map[Chord]string)}
+	var errs []error
+	add := func(scope string, dst map[Chord]string, specs map[string]string) map[Chord]string {
+		seen := make(map[Chord]string)
+		for _, spec := range sortedKeys(specs) {
+			c, err := Parse(spec)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s binding: %w", scope, err))
+				continue
+			}
+			cmd := strings.TrimSpace(specs[spec])
+			switch {
+			case cmd == "":
+				errs = append(errs, fmt.Errorf("%s binding %q has no command", scope, spec))
+			case c == Escape && scope == "global":
+				errs = append(errs, fmt.Errorf("%s binding %q: Escape is reserved", scope, spec))
+			case seen[c] != "":
+				errs = append(errs, fmt.Errorf("%s bindings %q and %q are the same key %s", scope, seen[c], spec, c))
+			default:
+				seen[c] = spec
+				dst[c] = cmd
+			}
+		}
+		return seen
+	}
+	add("gui", km.GUI, gui)
+	globalSpecs := add("global", km.Global, global)
+	for _, c := range sortedChords(km.Global) {
+		if _, ok := km.GUI[c]; ok {
+			errs = append(errs, fmt.Errorf("%s is bound globally (%q) and in the window; the global hotkey would shadow it", c, globalSpecs[c]))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if _, ok := km.GUI[Escape]; !ok && len(km.Chords(CloseCommand)) == 0 {
+		km.GUI[Escape] = CloseCommand
+	}
+	return km, nil
+}
+
+// Lookup returns the command bound to c in the window.
+func (k *Keymap) Lookup(c Chord) (string, bool) {
+	cmd, ok := k.GUI[c]
+	return cmd, ok
+}
+
+// Chords returns the window chords bound to command, in canonical order.
+func (k *Keymap) Chords(command string) []Chord { return chordsOf(k.GUI, command) }
+
+// GlobalChords returns the system-wide chords bound to command, in
+// canonical order.
+func (k *Keymap) GlobalChords(command string) []Chord { return chordsOf(k.Global, command) }
+
+func chordsOf(bindings map[Chord]string, command string) []Chord {
+	var out []Chord
+	for _, c := range sortedChords(bindings) {
+		if bindings[c] == command {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Rebind returns a copy of specs in which command is bound to c alone:
+// the command's previous chords and whatever c was bound to are dropped.
+func Rebind(specs map[string]string, command string, c Chord) map[string]string {
+	out := make(map[string]string, len(specs)+1)
+	for spec, cmd := range specs {
+		if cmd == command {
+			continue
+		}
+		if pc, err := Parse(spec); err == nil && pc == c {
+			continue
+		}
+		out[spec] = cmd
+	}
+	out[c.String()] = command
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedChords(m map[Chord]string) []Chord {
+	out := make([]Chord, 0, len(m))
+	for c := range m {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
@@ -0,0 +1,110 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{"space", "Space", false},
+		{"ctrl+shift+p", "Ctrl+Shift+P", false},
+		{"Shift+Control+p", "Ctrl+Shift+P", false},
+		{"Super+F5", "Super+F5", false},
+		{"Ctrl+Plus", "Ctrl+Plus", false},
+		{"-", "Minus", false},
+		{"Ctrl+,", "Ctrl+,", false},
+		{"Ctrl++", "", true},
+		{"Hyper+P", "", true},
+		{"Ctrl+F13", "", true},
+		{"Ctrl+Wheel", "", true},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Parse(%q): expected error, got %v", tt.in, c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if c.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, expected %s", tt.in, c, tt.want)
+		}
+	}
+}
+
+func TestNew_Conflicts(t *testing.T) {
+	km, err := New(DefaultGUI, map[string]string{"Super+Shift+P": "toggle"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd, ok := km.Lookup(Chord{Key: "Space"}); !ok || cmd != "toggle" {
+		t.Errorf("Expected Space to toggle, got %q, %v", cmd, ok)
+	}
+
+	tests := []struct {
+		name        string
+		gui, global map[string]string
+		want        string
+	}{
+		{"same chord twice", map[string]string{"ctrl+p": "pause", "Control+P": "toggle"}, nil, "same key Ctrl+P"},
+		{"escape", nil, map[string]string{"Esc": "stop"}, "reserved"},
+		{"empty command", map[string]string{"P": " "}, nil, "no command"},
+		{"global shadows window", map[string]string{"Ctrl+P": "pause"}, map[string]string{"ctrl+p": "toggle"}, "bound globally"},
+		{"bad chord", nil, map[string]string{"Ctrl++": "inc"}, "global binding"},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.gui, tt.global)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestNew_Close(t *testing.T) {
+	tests := []struct {
+		name   string
+		gui    map[string]string
+		escape string
+	}{
+		{"default", DefaultGUI, CloseCommand},
+		{"close moved", map[string]string{"Ctrl+W": CloseCommand}, ""},
+		{"escape rebound", map[string]string{"Esc": "back"}, "back"},
+	}
+	for _, tt := range tests {
+		km, err := New(tt.gui, nil)
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %v", tt.name, err)
+		}
+		if cmd, _ := km.Lookup(Escape); cmd != tt.escape {
+			t.Errorf("%s: Expected Escape to run %q, got %q", tt.name, tt.escape, cmd)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	specs := map[string]string{"Plus": "inc", "I": "inc", "Ctrl+D": "dec"}
+	got := Rebind(specs, "inc", Chord{Mods: Ctrl, Key: "D"})
+	if len(got) != 1 || got["Ctrl+D"] != "inc" {
+		t.Errorf("Expected only Ctrl+D bound to inc, got %v", got)
+	}
+	if specs["Plus"] != "inc" {
+		t.Error("Expected Rebind to leave its input alone")
+	}
+
+	km, _ := New(map[string]string{"Plus": "inc", "I": "inc", "Minus": "dec"}, map[string]string{"alt+ctrl+p": "toggle"})
+	if chords := km.GlobalChords("toggle"); len(chords) != 1 || chords[0].String() != "Ctrl+Alt+P" {
+		t.Errorf("Expected the global toggle chord, got %v", chords)
+	}
+	if chords := km.Chords("inc"); len(chords) != 2 || chords[0].String() != "I" || chords[1].String() != "Plus" {
+		t.Errorf("Expected chords I and Plus for inc, got %v", chords)
+	}
+}
@@ -0,0 +1,175 @@
+//go:build linux
+
+package keymap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// XBindKeys grabs hotkeys through xbindkeys(1). Each hotkey writes its
+// command to a private FIFO that is read back in-process, so no other
+// transport has to be running.
+type XBindKeys struct {
+	// Dir holds the generated xbindkeys config and the FIFO; a temporary
+	// directory is used if empty.
+	Dir string
+
+	mu   sync.Mutex
+	run  func(cmd string)
+	cmd  *exec.Cmd
+	fifo *os.File
+}
+
+var xModifiers = map[Mod]string{Ctrl: "Control", Shift: "Shift", Alt: "Mod1", Super: "Mod4"}
+
+// xKeysyms maps canonical key names to X keysyms; letters and digits are
+// their own keysym in lower case.
+var xKeysyms = map[string]string{
+	"Space": "space", "Escape": "Escape", "Return": "Return", "Tab": "Tab",
+	"Left": "Left", "Right": "Right", "Up": "Up", "Down": "Down",
+	"Home": "Home", "End": "End", "PageUp": "Page_Up", "PageDown": "Page_Down",
+	"Backspace": "BackSpace", "Delete": "Delete", "Plus": "plus", "Minus": "minus",
+	",": "comma", ".": "period", "/": "slash", ";": "semicolon", "=": "equal",
+	"[": "bracketleft", "]": "bracketright", "'": "apostrophe", "`": "grave", "\\": "backslash",
+}
+
+// xbindkeysChord renders c in xbindkeys syntax, e.g. "Control+Shift + p".
+func xbindkeysChord(c Chord) (string, error) {
+	key, ok := xKeysyms[c.Key]
+	switch {
+	case ok:
+	case len(c.Key) == 1 && (c.Key[0] >= 'A' && c.Key[0] <= 'Z' || c.Key[0] >= '0' && c.Key[0] <= '9'):
+		key = strings.ToLower(c.Key)
+	case len(c.Key) > 1 && c.Key[0] == 'F':
+		key = c.Key
+	default:
+		return "", fmt.Errorf("key %q cannot be grabbed globally", c.Key)
+	}
+	var mods []string
+	for _, m := range modOrder {
+		if c.Mods&m.mod != 0 {
+			mods = append(mods, xModifiers[m.mod])
+		}
+	}
+	if len(mods) == 0 {
+		return key, nil
+	}
+	return strings.Join(mods, "+") + " + " + key, nil
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Config renders the xbindkeys configuration that writes each command to
+// fifo.
+func (x *XBindKeys) Config(bindings map[Chord]string, fifo string) (string, error) {
+	var b strings.Builder
+	for _, c := range sortedChords(bindings) {
+		cmd := bindings[c]
+		if strings.ContainsAny(cmd, "\"\n") {
+			return "", fmt.Errorf("global binding %s: command %q cannot contain quotes or newlines", c, cmd)
+		}
+		keys, err := xbindkeysChord(c)
+		if err != nil {
+			return "", fmt.Errorf("global binding %s: %w", c, err)
+		}
+		fmt.Fprintf(&b, "\"echo %s >> %s\"\n    %s\n\n", shellQuote(cmd), shellQuote(fifo), keys)
+	}
+	return b.String(), nil
+}
+
+func (x *XBindKeys) Grab(bindings map[Chord]string, run func(cmd string)) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stopLocked()
+	x.run = run
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	if x.Dir == "" {
+		dir, err := os.MkdirTemp("", "focotimer-hotkeys")
+		if err != nil {
+			return err
+		}
+		x.Dir = dir
+	}
+	fifo := filepath.Join(x.Dir, "hotkeys.fifo")
+	if x.fifo == nil {
+		if err := syscall.Mkfifo(fifo, 0600); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("mkfifo %q: %w", fifo, err)
+		}
+		// opened read-write so reads never see EOF between hotkeys
+		f, err := os.OpenFile(fifo, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		x.fifo = f
+		go x.read(f)
+	}
+
+	rc, err := x.Config(bindings, fifo)
+	if err != nil {
+		return err
+	}
+	rcPath := filepath.Join(x.Dir, "xbindkeysrc")
+	if err := os.WriteFile(rcPath, []byte(rc), 0600); err != nil {
+		return err
+	}
+	cmd := exec.Command("xbindkeys", "--nodaemon", "--file", rcPath)
+	// the grabs must not outlive the timer
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start xbindkeys: %w", err)
+	}
+	x.cmd = cmd
+	go cmd.Wait()
+	return nil
+}
+
+func (x *XBindKeys) read(f *os.File) {
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		x.mu.Lock()
+		run := x.run
+		x.mu.Unlock()
+		if cmd := strings.TrimSpace(sc.Text()); cmd != "" && run != nil {
+			run(cmd)
+		}
+	}
+	if err := sc.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		log.Printf("keymap.XBindKeys: %v", err)
+	}
+}
+
+func (x *XBindKeys) stopLocked() {
+	if x.cmd != nil && x.cmd.Process != nil {
+		x.cmd.Process.Kill()
+	}
+	x.cmd = nil
+}
+
+// Close releases the hotkeys and removes the FIFO.
+func (x *XBindKeys) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stopLocked()
+	if x.fifo == nil {
+		return nil
+	}
+	err := x.fifo.Close()
+	x.fifo = nil
+	os.Remove(filepath.Join(x.Dir, "hotkeys.fifo"))
+	return err
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package keymap
+
+import "errors"
+
+// XBindKeys stands in for the xbindkeys grabber where there is none:
+// global hotkeys are refused.
+type XBindKeys struct {
+	Dir string
+}
+
+func (x *XBindKeys) Grab(bindings map[Chord]string, run func(cmd string)) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	return errors.New("global hotkeys need xbindkeys on Linux; the window bindings still apply")
+}
+
+// Close does nothing; there is nothing grabbed.
+func (x *XBindKeys) Close() error { return nil }
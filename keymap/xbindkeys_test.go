@@ -0,0 +1,71 @@
+//go:build linux
+
+package keymap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXBindKeys_Config(t *testing.T) {
+	x := &XBindKeys{}
+	rc, err := x.Config(map[Chord]string{
+		{Mods: Ctrl | Shift, Key: "P"}: "toggle",
+		{Mods: Super, Key: "Plus"}:     "label it's late",
+	}, "/run/hotkeys.fifo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `"echo 'toggle' >> '/run/hotkeys.fifo'"` + "\n    Control+Shift + p\n\n" +
+		`"echo 'label it'\''s late' >> '/run/hotkeys.fifo'"` + "\n    Mod4 + plus\n\n"
+	if rc != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, rc)
+	}
+
+	if _, err := x.Config(map[Chord]string{{Key: "Ä"}: "toggle"}, "/f"); err == nil {
+		t.Error("Expected error for a key without keysym")
+	}
+	if _, err := x.Config(map[Chord]string{{Key: "P"}: `label "x"`}, "/f"); err == nil {
+		t.Error("Expected error for a command with quotes")
+	}
+}
+
+func TestXBindKeys_Grab(t *testing.T) {
+	// a stand-in xbindkeys that stays up like the real one
+	bin := t.TempDir()
+	script := "#!/bin/sh\nexec sleep 60\n"
+	if err := os.WriteFile(filepath.Join(bin, "xbindkeys"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake xbindkeys: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	x := &XBindKeys{Dir: t.TempDir()}
+	defer x.Close()
+	got := make(chan string, 1)
+	if err := x.Grab(map[Chord]string{{Mods: Super, Key: "P"}: "toggle"}, func(cmd string) { got <- cmd }); err != nil {
+		t.Fatalf("Grab failed: %v", err)
+	}
+	if rc, err := os.ReadFile(filepath.Join(x.Dir, "xbindkeysrc")); err != nil || !strings.Contains(string(rc), "Mod4 + p") {
+		t.Errorf("Expected the generated config, got %q, %v", rc, err)
+	}
+
+	// what xbindkeys runs when the hotkey is pressed
+	f, err := os.OpenFile(filepath.Join(x.Dir, "hotkeys.fifo"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Failed to open hotkey FIFO: %v", err)
+	}
+	f.WriteString("toggle\n")
+	f.Close()
+
+	select {
+	case cmd := <-got:
+		if cmd != "toggle" {
+			t.Errorf("Expected toggle, got %q", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the hotkey command to be run")
+	}
+}
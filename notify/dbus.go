@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/d093w1z/focotimer/dbus"
+)
+
+// ------------------- D-Bus -------------------
+
+// dbusTimeout bounds a whole notification: connecting, authenticating and
+// the call.
+const dbusTimeout = 5 * time.Second
+
+// DBus shows notifications through the notification server on the session
+// bus, over a fresh connection per notification.
+type DBus struct {
+	// Address is a D-Bus server address such as
+	// "unix:path=/run/user/1000/bus"; empty is the session bus.
+	Address string
+	// AppName defaults to "focotimer".
+	AppName string
+}
+
+func (d *DBus) Notify(n Notification) error {
+	conn, err := dbus.Dial(d.Address)
+	if err != nil {
+		return fmt.Errorf("dbus: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(dbusTimeout))
+	waiting := false
+	defer func() {
+		if !waiting {
+			conn.Close()
+		}
+	}()
+
+	withActions := len(n.Actions) > 0 && n.OnAction != nil
+	// signals wait here until the reply tells which notification is ours
+	signals := make(chan dbus.Message, 16)
+	if withActions {
+		conn.Handle(func(m dbus.Message) {
+			if m.Type == dbus.Signal && m.Interface == "org.freedesktop.Notifications" {
+				select {
+				case signals <- m:
+				default: // a burst of other notifications
+				}
+			}
+		})
+		for _, member := range []string{"ActionInvoked", "NotificationClosed"} {
+			if err := conn.AddMatch("type='signal',interface='org.freedesktop.Notifications',member='" + member + "'"); err != nil {
+				return fmt.Errorf("dbus: AddMatch: %w", err)
+			}
+		}
+	}
+	reply, err := conn.Call(notifyCall(appName(d.AppName), n))
+	if err != nil {
+		return fmt.Errorf("dbus: Notify: %w", err)
+	}
+	if withActions {
+		body := reply.Decoder()
+		id := body.Uint32()
+		if err := body.Err(); err != nil {
+			return fmt.Errorf("dbus: Notify: %w", err)
+		}
+		waiting = true
+		conn.SetDeadline(time.Now().Add(actionTimeout))
+		go func() {
+			defer conn.Close()
+			waitForAction(conn, signals, id, n.OnAction)
+		}()
+	}
+	return nil
+}
+
+// waitForAction calls onAction with the key of every action invoked on
+// the notification id, until the server closes it or the connection ends.
+func waitForAction(conn *dbus.Conn, signals <-chan dbus.Message, id uint32, onAction func(key string)) {
+	for {
+		var m dbus.Message
+		select {
+		case m = <-signals:
+		case <-conn.Done():
+			return
+		}
+		body := m.Decoder()
+		if body.Uint32() != id {
+			continue
+		}
+		switch m.Member {
+		case "ActionInvoked":
+			if key := body.String(); body.Err() == nil {
+				onAction(key)
+			}
+		case "NotificationClosed":
+			return
+		}
+	}
+}
+
+// actionTimeout is how long a notification with actions is listened to
+// when the server never reports it closed.
+const actionTimeout = time.Hour
+
+// notifyCall is org.freedesktop.Notifications.Notify(app_name, replaces_id,
+// app_icon, summary, body, actions, hints, expire_timeout).
+func notifyCall(app string, n Notification) dbus.Message {
+	var e dbus.Encoder
+	e.String(app)
+	e.Uint32(0)
+	e.String(n.Icon)
+	e.String(n.Summary)
+	e.String(n.Body)
+	e.Array(4, func() {
+		for _, a := range n.Actions {
+			e.String(a.Key)
+			e.String(a.Label)
+		}
+	})
+	e.Array(8, func() {
+		e.Struct(func() {
+			e.String("urgency")
+			e.Variant("y", func() { e.Byte(n.Urgency.level()) })
+		})
+	})
+	timeout := int32(-1)
+	if n.Timeout > 0 {
+		timeout = int32(n.Timeout.Milliseconds())
+	}
+	e.Int32(timeout)
+	return dbus.Message{
+		Path:        "/org/freedesktop/Notifications",
+		Interface:   "org.freedesktop.Notifications",
+		Member:      "Notify",
+		Destination: "org.freedesktop.Notifications",
+		Signature:   "susssasa{sv}i",
+		Body:        e.Bytes(),
+	}
+}
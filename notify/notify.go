@@ -0,0 +1,174 @@
+// Package notify shows desktop notifications: over D-Bus with the
+// freedesktop org.freedesktop.Notifications interface, or with notify-send
+// where the session bus cannot be reached.
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Urgency is how pressing a notification is; notification servers may
+// keep a critical one on screen until it is dismissed.
+type Urgency int
+
+const (
+	Normal Urgency = iota
+	Low
+	Critical
+)
+
+var urgencyNames = map[Urgency]string{
+	Low:      "low",
+	Normal:   "normal",
+	Critical: "critical",
+}
+
+func (u Urgency) String() string {
+	if name, ok := urgencyNames[u]; ok {
+		return name
+	}
+	return "normal"
+}
+
+// ParseUrgency reads "low", "normal" or "critical"; empty is Normal.
+func ParseUrgency(s string) (Urgency, error) {
+	if s == "" {
+		return Normal, nil
+	}
+	for u, name := range urgencyNames {
+		if name == s {
+			return u, nil
+		}
+	}
+	return Normal, fmt.Errorf("unknown urgency %q, want low, normal or critical", s)
+}
+
+// level is the urgency hint of the notification specification.
+func (u Urgency) level() byte {
+	switch u {
+	case Low:
+		return 0
+	case Critical:
+		return 2
+	}
+	return 1
+}
+
+type Notification struct {
+	Summary string
+	Body    string
+	Urgency Urgency
+	// Icon is an icon name or path; empty shows none.
+	Icon string
+	// Timeout is how long the notification shows; zero leaves it to the
+	// notification server.
+	Timeout time.Duration
+	// Actions are buttons on the notification; OnAction gets the key of
+	// the one clicked, from another goroutine. Servers without buttons
+	// show the notification without them.
+	Actions  []Action
+	OnAction func(key string)
+}
+
+// Action is a button on a notification.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Notifier shows notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Func adapts a function to a Notifier.
+type Func func(n Notification) error
+
+func (f Func) Notify(n Notification) error { return f(n) }
+
+// Fallback tries each notifier in turn until one shows the notification.
+type Fallback []Notifier
+
+func (f Fallback) Notify(n Notification) error {
+	var errs []error
+	for _, notifier := range f {
+		err := notifier.Notify(n)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Default notifies over the session bus, falling back to notify-send.
+func Default() Notifier {
+	return Fallback{&DBus{}, &NotifySend{}}
+}
+
+// NotifySend shows notifications with the notify-send tool of libnotify.
+type NotifySend struct {
+	// AppName defaults to "focotimer".
+	AppName string
+}
+
+func (s *NotifySend) Notify(n Notification) error {
+	args := []string{"-a", appName(s.AppName), "-u", n.Urgency.String()}
+	if n.Icon != "" {
+		args = append(args, "-i", n.Icon)
+	}
+	if n.Timeout > 0 {
+		args = append(args, "-t", strconv.FormatInt(n.Timeout.Milliseconds(), 10))
+	}
+	if len(n.Actions) == 0 || n.OnAction == nil {
+		return notifySend(append(args, "--", n.Summary, n.Body))
+	}
+
+	withActions := slices.Clone(args)
+	for _, a := range n.Actions {
+		withActions = append(withActions, "--action="+a.Key+"="+a.Label)
+	}
+	// notify-send prints the key of the action clicked once the
+	// notification closes, so it runs in the background
+	var out bytes.Buffer
+	cmd := exec.Command("notify-send", append(withActions, "--", n.Summary, n.Body)...)
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("notify-send: %w", err)
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			// libnotify before 0.7.10 has no actions
+			if err := notifySend(append(args, "--", n.Summary, n.Body)); err != nil {
+				log.Printf("notify.NotifySend: %v", err)
+			}
+			return
+		}
+		if key := strings.TrimSpace(out.String()); key != "" {
+			n.OnAction(key)
+		}
+	}()
+	return nil
+}
+
+func notifySend(args []string) error {
+	if out, err := exec.Command("notify-send", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("notify-send: %w: %s", err, out)
+	}
+	return nil
+}
+
+func appName(name string) string {
+	if name == "" {
+		return "focotimer"
+	}
+	return name
+}
@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/dbus"
+	"github.com/d093w1z/focotimer/dbus/dbustest"
+)
+
+func TestParseUrgency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Urgency
+		wantErr bool
+	}{
+		{"", Normal, false},
+		{"low", Low, false},
+		{"normal", Normal, false},
+		{"critical", Critical, false},
+		{"urgent", Normal, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUrgency(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseUrgency(%q): Expected %v (error %v), got %v, %v", tt.in, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var tried []string
+	failing := Func(func(Notification) error {
+		tried = append(tried, "failing")
+		return errors.New("no bus")
+	})
+	working := Func(func(Notification) error {
+		tried = append(tried, "working")
+		return nil
+	})
+	if err := (Fallback{failing, working, failing}).Notify(Notification{}); err != nil {
+		t.Errorf("Expected the second notifier to succeed, got %v", err)
+	}
+	if strings.Join(tried, ",") != "failing,working" {
+		t.Errorf("Expected to stop at the first success, tried %v", tried)
+	}
+	if err := (Fallback{failing, failing}).Notify(Notification{}); err == nil {
+		t.Error("Expected an error when every notifier fails")
+	}
+}
+
+// fakeBus is a session bus with a notification server. It records the
+// Notify calls and fails them with failWith when set.
+type fakeBus struct {
+	*dbustest.Bus
+	t        *testing.T
+	calls    chan []any
+	failWith string
+}
+
+func newFakeBus(t *testing.T) *fakeBus {
+	b := &fakeBus{Bus: dbustest.New(t), t: t, calls: make(chan []any, 1)}
+	go b.serve()
+	return b
+}
+
+func (b *fakeBus) serve() {
+	conn, r := b.Accept()
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	for {
+		m, err := dbus.ReadMessage(r)
+		if err != nil {
+			return
+		}
+		reply := dbus.Message{Type: dbus.MethodReturn, Serial: 100 + m.Serial, ReplySerial: m.Serial}
+		var after func()
+		switch m.Member {
+		case "Hello":
+			var e dbus.Encoder
+			e.String(":1.42")
+			reply.Signature, reply.Body = "s", e.Bytes()
+		case "Notify":
+			if m.Signature != "susssasa{sv}i" || m.Destination != "org.freedesktop.Notifications" {
+				b.t.Errorf("Unexpected Notify call: %+v", m)
+			}
+			d := m.Decoder()
+			call := []any{d.String(), d.Uint32(), d.String(), d.String(), d.String()}
+			var actions []string
+			d.Array(4, func() { actions = append(actions, d.String()) })
+			call = append(call, strings.Join(actions, ","))
+			d.Uint32() // hints length
+			d.Align(8)
+			call = append(call, d.String(), d.Variant(), d.Byte(), d.Int32())
+			if err := d.Err(); err != nil {
+				b.t.Errorf("Bad Notify body: %v", err)
+			}
+			b.calls <- call
+			var e dbus.Encoder
+			e.Uint32(7)
+			reply.Signature, reply.Body = "u", e.Bytes()
+			if len(actions) > 0 {
+				after = func() {
+					b.signal(conn, "ActionInvoked", 6, "ignored")
+					b.signal(conn, "ActionInvoked", 7, actions[0])
+					b.signal(conn, "NotificationClosed", 7, "")
+				}
+			}
+			if b.failWith != "" {
+				var e dbus.Encoder
+				e.String(b.failWith)
+				reply = dbus.Message{Type: dbus.Error, Serial: reply.Serial, ReplySerial: m.Serial,
+					ErrorName: "org.freedesktop.DBus.Error.Failed", Signature: "s", Body: e.Bytes()}
+			}
+		case "AddMatch":
+		default:
+			b.t.Errorf("Unexpected call %q", m.Member)
+		}
+		conn.Write(reply.Encode())
+		if after != nil {
+			after()
+		}
+	}
+}
+
+// signal sends a Notifications signal about notification id; a
+// NotificationClosed gives the reason instead of an action key.
+func (b *fakeBus) signal(conn net.Conn, member string, id uint32, key string) {
+	var e dbus.Encoder
+	e.Uint32(id)
+	sig := "uu"
+	if key != "" {
+		e.String(key)
+		sig = "us"
+	} else {
+		e.Uint32(2) // dismissed
+	}
+	conn.Write(dbus.Message{Type: dbus.Signal, Serial: 500, Path: "/org/freedesktop/Notifications",
+		Interface: "org.freedesktop.Notifications", Member: member, Signature: sig, Body: e.Bytes()}.Encode())
+}
+
+func TestDBus_Notify(t *testing.T) {
+	bus := newFakeBus(t)
+	d := &DBus{Address: "unix:path=/nonexistent;" + bus.Address()}
+	err := d.Notify(Notification{Summary: "writing complete", Body: "25m0s of focus", Urgency: Critical, Icon: "alarm", Timeout: 3 * time.Second})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	got := <-bus.calls
+	want := []any{"focotimer", uint32(0), "alarm", "writing complete", "25m0s of focus", "", "urgency", "y", byte(2), int32(3000)}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Argument %d: Expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDBus_Actions(t *testing.T) {
+	bus := newFakeBus(t)
+	keys := make(chan string, 2)
+	err := (&DBus{Address: bus.Address()}).Notify(Notification{
+		Summary:  "writing complete",
+		Actions:  []Action{{Key: "break", Label: "Start break"}, {Key: "more", Label: "+5 min"}},
+		OnAction: func(key string) { keys <- key },
+	})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if call := <-bus.calls; call[5] != "break,Start break,more,+5 min" {
+		t.Errorf("Expected the actions as key and label pairs, got %q", call[5])
+	}
+	select {
+	case key := <-keys:
+		if key != "break" {
+			t.Errorf("Expected the action of this notification, got %q", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the action")
+	}
+	select {
+	case key := <-keys:
+		t.Errorf("Expected one action, got %q too", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDBus_NotifyError(t *testing.T) {
+	bus := newFakeBus(t)
+	bus.failWith = "no notification server"
+	err := (&DBus{Address: bus.Address()}).Notify(Notification{Summary: "break over"})
+	if err == nil || !strings.Contains(err.Error(), "no notification server") {
+		t.Errorf("Expected the error reply, got %v", err)
+	}
+}
+
+func TestDBus_NoBus(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if err := (&DBus{}).Notify(Notification{Summary: "break over"}); err == nil {
+		t.Error("Expected an error without a session bus")
+	}
+}
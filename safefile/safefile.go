@@ -0,0 +1,154 @@
+// Package safefile writes files so that a crash or power loss mid-write
+// never leaves them half-written, and reads them back falling over to the
+// last good version when they are damaged anyway.
+//
+// A write goes to a synced temporary file that is renamed over the
+// original. The version being replaced is kept next to it as path+".bak",
+// and with checksums enabled the SHA-256 of the contents is kept in
+// path+".sum" so silent damage is noticed on the next read.
+package safefile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrDamaged is wrapped by read errors for a file that failed its
+	// checksum or validation.
+	ErrDamaged = errors.New("damaged")
+	// ErrRecovered is wrapped, together with ErrDamaged, when Read
+	// returned the backup in place of a damaged file.
+	ErrRecovered = errors.New("recovered the last good version")
+	// ErrChecksum is the damage of contents not matching their checksum.
+	ErrChecksum = errors.New("checksum mismatch")
+)
+
+// File is a file written atomically with a backup of its previous version.
+type File struct {
+	Path string
+	// Perm is the mode of new files, 0644 if zero.
+	Perm os.FileMode
+	// Checksum keeps a checksum of the contents next to the file. Leave it
+	// off for files people edit by hand.
+	Checksum bool
+	// Valid, if set, rejects contents that do not parse.
+	Valid func([]byte) error
+}
+
+func (f File) backup() string { return f.Path + ".bak" }
+
+// Read returns the contents of the file. A missing file is
+// os.ErrNotExist. A damaged file is replaced by its backup if that is
+// intact, with an error wrapping ErrRecovered; without a good backup the
+// damaged contents are returned along with an error wrapping ErrDamaged,
+// so callers can still salvage what parses.
+func (f File) Read() ([]byte, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	damage := f.check(f.Path, data)
+	if damage == nil {
+		return data, nil
+	}
+	if bak, err := os.ReadFile(f.backup()); err == nil && f.check(f.backup(), bak) == nil {
+		return bak, fmt.Errorf("%w %q (%v), %w", ErrDamaged, f.Path, damage, ErrRecovered)
+	}
+	return data, fmt.Errorf("%w %q: %w", ErrDamaged, f.Path, damage)
+}
+
+// check verifies data read from path against its checksum and Valid. A
+// missing checksum is accepted, for files written before it existed.
+func (f File) check(path string, data []byte) error {
+	if f.Checksum {
+		sums, err := os.ReadFile(path + ".sum")
+		if err == nil && !bytes.Contains(sums, []byte(checksum(data))) {
+			return ErrChecksum
+		}
+	}
+	if f.Valid != nil {
+		return f.Valid(data)
+	}
+	return nil
+}
+
+// Write replaces the contents of the file with data, creating its
+// directory. An intact current version becomes the backup first.
+func (f File) Write(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	var prev string
+	if cur, err := os.ReadFile(f.Path); err == nil && f.check(f.Path, cur) == nil {
+		if err := f.replace(f.backup(), cur, ""); err != nil {
+			return err
+		}
+		prev = checksum(cur)
+	}
+	return f.replace(f.Path, data, prev)
+}
+
+// replace writes data to path. The checksum goes first and also accepts
+// prev, the checksum of the version being replaced, so a crash between the
+// two renames leaves a file that still verifies.
+func (f File) replace(path string, data []byte, prev string) error {
+	perm := f.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+	if f.Checksum {
+		sums := checksum(data) + "\n"
+		if prev != "" {
+			sums += prev + "\n"
+		}
+		if err := rename(path+".sum", []byte(sums), perm); err != nil {
+			return err
+		}
+	}
+	return rename(path, data, perm)
+}
+
+// rename writes data to a synced temporary file next to path and renames
+// it into place.
+func rename(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	// Make the rename itself durable. Not every platform can sync a
+	// directory, so a failure here is not an error.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
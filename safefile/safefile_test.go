@@ -0,0 +1,114 @@
+package safefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	f := File{Path: filepath.Join(t.TempDir(), "nested", "data"), Checksum: true}
+	if _, err := f.Read(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected ErrNotExist for a missing file, got %v", err)
+	}
+	for _, v := range []string{"one", "two"} {
+		if err := f.Write([]byte(v)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if got, err := f.Read(); err != nil || string(got) != "two" {
+		t.Errorf("Expected two, got %q, %v", got, err)
+	}
+	if bak, _ := os.ReadFile(f.Path + ".bak"); string(bak) != "one" {
+		t.Errorf("Expected the previous version as backup, got %q", bak)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(f.Path))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Errorf("Expected no temporary files left, found %s", e.Name())
+		}
+	}
+}
+
+func TestRead_Recovery(t *testing.T) {
+	valid := func(data []byte) error {
+		if strings.Contains(string(data), "broken") {
+			return errors.New("broken")
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		file    File
+		damage  string
+		want    string
+		wantErr error
+	}{
+		{"checksum", File{Checksum: true}, "tw0", "one", ErrRecovered},
+		{"validation", File{Valid: valid}, "broken", "one", ErrRecovered},
+		{"unchecked", File{}, "tw0", "tw0", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.file
+			f.Path = filepath.Join(t.TempDir(), "data")
+			for _, v := range []string{"one", "two"} {
+				if err := f.Write([]byte(v)); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			// Damage the file behind the package's back.
+			if err := os.WriteFile(f.Path, []byte(tt.damage), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.Read()
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && (!errors.Is(err, tt.wantErr) || !errors.Is(err, ErrDamaged)) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRead_DamagedWithoutBackup(t *testing.T) {
+	f := File{Path: filepath.Join(t.TempDir(), "data"), Checksum: true}
+	if err := f.Write([]byte("one")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := os.WriteFile(f.Path, []byte("0ne"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Read()
+	if !errors.Is(err, ErrDamaged) || errors.Is(err, ErrRecovered) {
+		t.Errorf("Expected an unrecovered damage error, got %v", err)
+	}
+	if string(got) != "0ne" {
+		t.Errorf("Expected the damaged contents to be returned, got %q", got)
+	}
+
+	// Writing over a damaged file must not replace the good backup with it.
+	if err := f.Write([]byte("two")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := os.Stat(f.Path + ".bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the damaged version not to become the backup, got %v", err)
+	}
+}
+
+func TestRead_CrashBetweenRenames(t *testing.T) {
+	f := File{Path: filepath.Join(t.TempDir(), "data"), Checksum: true}
+	if err := f.Write([]byte("one")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// The checksum of the next version is in place but the data is not.
+	if err := os.WriteFile(f.Path+".sum", []byte(checksum([]byte("two"))+"\n"+checksum([]byte("one"))+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := f.Read(); err != nil || string(got) != "one" {
+		t.Errorf("Expected the old version to still verify, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package systemd lets focotimer run as a systemd user service: it takes
+// over the sockets passed by socket activation and reports readiness to
+// the service manager.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// firstFD is where passed file descriptors start, SD_LISTEN_FDS_START.
+var firstFD = 3
+
+// Listener is a socket passed by the service manager.
+type Listener struct {
+	// Name is set with FileDescriptorName= in the socket unit and defaults
+	// to the unit's name.
+	Name string
+	net.Listener
+}
+
+// Listeners returns the sockets systemd passed to this process, or nil
+// when it was not socket activated. The LISTEN_* variables are cleared so
+// that child processes do not take the sockets for their own.
+func Listeners() ([]Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	var lns []Listener
+	for i := 0; i < n; i++ {
+		var name string
+		if i < len(names) {
+			name = names[i]
+		}
+		// FileListener keeps a duplicate, so the passed descriptor is
+		// closed either way
+		f := os.NewFile(uintptr(firstFD+i), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range lns {
+				l.Close()
+			}
+			return nil, fmt.Errorf("passed socket %d (%q): %w", firstFD+i, name, err)
+		}
+		lns = append(lns, Listener{Name: name, Listener: ln})
+	}
+	return lns, nil
+}
+
+// States reported with Notify.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+)
+
+// Notify sends state to the service manager, e.g. Ready once focotimer
+// serves its sockets. It does nothing outside a Type=notify service.
+func Notify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	// a leading '@' names an abstract socket, which net maps itself
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("notify %q: %w", addr, err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("notify %q: %w", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// passSocket listens on a unix socket and hands its descriptor over like
+// systemd does, returning the socket path.
+func passSocket(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	f, err := ln.(*net.UnixListener).File()
+	if err != nil {
+		t.Fatalf("File failed: %v", err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("Dup failed: %v", err)
+	}
+	f.Close()
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	old := firstFD
+	firstFD = fd
+	t.Cleanup(func() { firstFD = old })
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", name)
+	return path
+}
+
+func TestListeners(t *testing.T) {
+	path := passSocket(t, "ctl")
+
+	lns, err := Listeners()
+	if err != nil {
+		t.Fatalf("Listeners failed: %v", err)
+	}
+	if len(lns) != 1 || lns[0].Name != "ctl" {
+		t.Fatalf("Expected one socket named ctl, got %v", lns)
+	}
+	defer lns[0].Close()
+	if os.Getenv("LISTEN_FDS") != "" || os.Getenv("LISTEN_PID") != "" {
+		t.Error("Expected the LISTEN_* variables to be cleared")
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.Close()
+	if c, err := lns[0].Accept(); err != nil {
+		t.Errorf("Accept failed: %v", err)
+	} else {
+		c.Close()
+	}
+}
+
+func TestListeners_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if lns, err := Listeners(); lns != nil || err != nil {
+		t.Errorf("Expected no sockets for another process, got %v %v", lns, err)
+	}
+
+	t.Setenv("LISTEN_PID", "")
+	if lns, err := Listeners(); lns != nil || err != nil {
+		t.Errorf("Expected no sockets without LISTEN_PID, got %v %v", lns, err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Notify(Ready); err != nil {
+		t.Errorf("Expected Notify to do nothing outside systemd, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram failed: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if err := Notify(Ready); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := string(buf[:n]); got != Ready {
+		t.Errorf("Expected %q, got %q", Ready, got)
+	}
+}
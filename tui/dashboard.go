@@ -0,0 +1,204 @@
+// Package tui renders the focus history as a terminal dashboard, for
+// "focotimer stats".
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/d093w1z/focotimer/history"
+)
+
+// View selects the period the dashboard shows.
+type View struct {
+	Period history.Period
+	// Back counts periods before the current one.
+	Back int
+	// Goal, when set, adds today's progress towards it.
+	Goal history.Goal
+}
+
+// Bounds returns the span v shows as [from, to).
+func (v View) Bounds(now time.Time) (from, to time.Time, err error) {
+	from, to, err = v.Period.Bounds(now)
+	for i := 0; i < v.Back && err == nil; i++ {
+		from, to, err = v.Period.Previous(from)
+	}
+	return from, to, err
+}
+
+// Key applies a key press to v; quit reports a key that closes the
+// dashboard.
+func (v View) Key(key string) (next View, quit bool) {
+	switch key {
+	case "q", "\x1b", "\x03": // Esc, Ctrl+C
+		return v, true
+	case "h", "\x1b[D": // left arrow
+		v.Back++
+	case "l", "\x1b[C": // right arrow
+		v.Back = max(v.Back-1, 0)
+	case "t":
+		v.Back = 0
+	case "w":
+		v.Period, v.Back = history.Weekly, 0
+	case "m":
+		v.Period, v.Back = history.Monthly, 0
+	}
+	return v, false
+}
+
+const help = "←/h earlier · →/l later · t today · w week · m month · q quit"
+
+// Render writes the dashboard of records for v, width columns wide: focus
+// per day as bars, the split by label and the streak. Breaks are left out.
+func Render(w io.Writer, records []history.Record, v View, now time.Time, width int) error {
+	from, to, err := v.Bounds(now)
+	if err != nil {
+		return err
+	}
+	width = max(width, 40)
+
+	var (
+		total               time.Duration
+		sessions, completed int
+		daily               = make(map[time.Time]time.Duration)
+		byLabel             = make(map[string]time.Duration)
+	)
+	for _, s := range history.Summarize(records, from, to) {
+		if s.Break {
+			continue
+		}
+		total += s.Focus
+		sessions += s.Sessions
+		completed += s.Completed
+		daily[s.Day] += s.Focus
+		byLabel[s.Label] += s.Focus
+	}
+	current, longest := history.Streak(records, now)
+
+	bw := bufio.NewWriter(w)
+	title := "week of " + from.Format("Mon 2 Jan 2006")
+	if v.Period == history.Monthly {
+		title = from.Format("January 2006")
+	}
+	fmt.Fprintf(bw, "focotimer stats · %s\n", title)
+	fmt.Fprintf(bw, "%s focus · %d sessions, %d completed · streak %s (longest %s)\n",
+		formatDuration(total), sessions, completed, days(current), days(longest))
+	if v.Goal.Daily > 0 {
+		note := ""
+		if v.Goal.RequireBreaks {
+			note = " · counting sessions followed by a break"
+		}
+		fmt.Fprintf(bw, "goal today %d/%d%s\n", v.Goal.Progress(records, now), v.Goal.Daily, note)
+	}
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "DAILY")
+	var most time.Duration
+	for _, d := range daily {
+		most = max(most, d)
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		focus := daily[history.Day(day)]
+		fmt.Fprintf(bw, "%-7s %s %6s\n", day.Format("Mon 02"), bar(focus, most, width-16), formatDuration(focus))
+	}
+
+	fmt.Fprintln(bw, "\nLABELS")
+	labels := make([]string, 0, len(byLabel))
+	for l := range byLabel {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if byLabel[labels[i]] != byLabel[labels[j]] {
+			return byLabel[labels[i]] > byLabel[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+	if len(labels) == 0 {
+		fmt.Fprintln(bw, "no sessions yet")
+	}
+	const labelWidth = 16
+	for _, l := range labels {
+		name := l
+		if name == "" {
+			name = "(no label)"
+		}
+		if r := []rune(name); len(r) > labelWidth {
+			name = string(r[:labelWidth-1]) + "…"
+		}
+		var share float64
+		if total > 0 {
+			share = float64(byLabel[l]) / float64(total) * 100
+		}
+		fmt.Fprintf(bw, "%-*s %s %6s %3.0f%%\n", labelWidth, name, bar(byLabel[l], total, width-labelWidth-14), formatDuration(byLabel[l]), share)
+	}
+	return bw.Flush()
+}
+
+// bar draws part of whole as a bar cells wide.
+func bar(part, whole time.Duration, cells int) string {
+	cells = max(cells, 1)
+	filled := 0
+	if whole > 0 {
+		filled = int(float64(part) / float64(whole) * float64(cells))
+	}
+	if part > 0 && filled == 0 {
+		filled = 1
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", cells-filled)
+}
+
+// formatDuration reads "1h35m", "25m" or "0m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if h := d / time.Hour; h > 0 {
+		return fmt.Sprintf("%dh%02dm", h, (d%time.Hour)/time.Minute)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+func days(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// Run shows the dashboard full screen on the terminal behind in and out
+// until the user quits, redrawing on every key press.
+func Run(in, out *os.File, records []history.Record, v View) error {
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l") // alternate screen, hide cursor
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		var frame strings.Builder
+		frame.WriteString("\x1b[H\x1b[2J")
+		if err := Render(&frame, records, v, time.Now(), termWidth(int(out.Fd()))); err != nil {
+			return err
+		}
+		frame.WriteString("\n" + help)
+		if _, err := io.WriteString(out, frame.String()); err != nil {
+			return err
+		}
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		var quit bool
+		if v, quit = v.Key(string(buf[:n])); quit {
+			return nil
+		}
+	}
+}
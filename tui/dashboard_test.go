@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d093w1z/focotimer/history"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC) // a Wednesday
+	session := func(day int, hour int, d time.Duration, label string) history.Record {
+		start := time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+		return history.Record{Start: start, End: start.Add(d), Duration: d, Label: label, Completed: true}
+	}
+	records := []history.Record{
+		session(4, 9, time.Hour, "writing"),
+		session(4, 11, 30*time.Minute, "review"),
+		session(5, 9, 30*time.Minute, "writing"),
+		session(5, 10, 10*time.Minute, "short break"),
+		session(6, 9, 25*time.Minute, ""),
+		session(1, 9, 2*time.Hour, "last week"),
+	}
+
+	var b strings.Builder
+	if err := Render(&b, records, View{Period: history.Weekly}, now, 60); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"week of Mon 4 Mar 2024",
+		"2h25m focus · 4 sessions, 4 completed · streak 3 days (longest 3 days)",
+		"Mon 04  " + strings.Repeat("█", 44) + "  1h30m",
+		"Sun 10  " + strings.Repeat("░", 44) + "     0m",
+		"writing          " + strings.Repeat("█", 18) + strings.Repeat("░", 12) + "  1h30m  62%",
+		"(no label)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "break") || strings.Contains(out, "last week") {
+		t.Errorf("Expected breaks and other weeks left out:\n%s", out)
+	}
+
+	b.Reset()
+	if err := Render(&b, records, View{Period: history.Weekly, Back: 1}, now, 60); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "last week") || !strings.Contains(b.String(), "week of Mon 26 Feb 2024") {
+		t.Errorf("Expected the previous week, got\n%s", b.String())
+	}
+
+	b.Reset()
+	records[4].BreakTaken = true
+	goal := history.Goal{Daily: 4, RequireBreaks: true}
+	if err := Render(&b, records, View{Period: history.Weekly, Goal: goal}, now, 60); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "goal today 1/4") {
+		t.Errorf("Expected today's goal progress, got\n%s", b.String())
+	}
+
+	if err := Render(&b, nil, View{Period: "daily"}, now, 60); err == nil {
+		t.Error("Expected an error for an unknown period")
+	}
+}
+
+func TestView_Key(t *testing.T) {
+	v := View{Period: history.Weekly}
+	steps := []struct {
+		key  string
+		want View
+		quit bool
+	}{
+		{"h", View{Period: history.Weekly, Back: 1}, false},
+		{"\x1b[D", View{Period: history.Weekly, Back: 2}, false},
+		{"l", View{Period: history.Weekly, Back: 1}, false},
+		{"m", View{Period: history.Monthly, Back: 0}, false},
+		{"\x1b[C", View{Period: history.Monthly, Back: 0}, false},
+		{"x", View{Period: history.Monthly, Back: 0}, false},
+		{"q", View{Period: history.Monthly, Back: 0}, true},
+	}
+	for _, s := range steps {
+		var quit bool
+		v, quit = v.Key(s.key)
+		if v != s.want || quit != s.quit {
+			t.Errorf("%q: expected %+v quit=%v, got %+v quit=%v", s.key, s.want, s.quit, v, quit)
+		}
+	}
+}
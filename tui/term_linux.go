@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// makeRaw switches the terminal at fd to reading single key presses
+// without echo; Ctrl+C arrives as a key so the screen is always restored.
+// Output processing stays on.
+func makeRaw(fd int) (restore func(), err error) {
+	old, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, fmt.Errorf("not a terminal: %w", err)
+	}
+	raw := *old
+	raw.Lflag &^= unix.ICANON | unix.ECHO | unix.ISIG
+	raw.Cc[unix.VMIN], raw.Cc[unix.VTIME] = 1, 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, fmt.Errorf("set terminal mode: %w", err)
+	}
+	return func() { unix.IoctlSetTermios(fd, unix.TCSETS, old) }, nil
+}
+
+// termWidth is the width of the terminal at fd, 80 when unknown.
+func termWidth(fd int) int {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return 80
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package tui
+
+import "errors"
+
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("the interactive dashboard needs Linux; run without --tui")
+}
+
+func termWidth(fd int) int { return 80 }